@@ -218,6 +218,25 @@ func AuthorizeFindScrapers(ctx context.Context, rs []influxdb.ScraperTarget) ([]
 	return rrs, len(rrs), nil
 }
 
+// AuthorizeFindMQTTSubscriptions takes the given items and returns only the ones that the user is authorized to read.
+// Subscriptions are authorized as scrapers.
+func AuthorizeFindMQTTSubscriptions(ctx context.Context, rs []influxdb.MQTTSubscription) ([]influxdb.MQTTSubscription, int, error) {
+	// This filters without allocating
+	// https://github.com/golang/go/wiki/SliceTricks#filtering-without-allocating
+	rrs := rs[:0]
+	for _, r := range rs {
+		_, _, err := AuthorizeRead(ctx, influxdb.ScraperResourceType, r.ID, r.OrgID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, 0, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		rrs = append(rrs, r)
+	}
+	return rrs, len(rrs), nil
+}
+
 // AuthorizeFindLabels takes the given items and returns only the ones that the user is authorized to read.
 func AuthorizeFindLabels(ctx context.Context, rs []*influxdb.Label) ([]*influxdb.Label, int, error) {
 	// This filters without allocating
@@ -0,0 +1,196 @@
+package authorizer
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.StorageService = (*StorageService)(nil)
+
+// StorageService wraps a influxdb.StorageService and authorizes actions
+// against it appropriately.
+type StorageService struct {
+	s influxdb.StorageService
+}
+
+// NewStorageService constructs an instance of an authorizing storage service.
+func NewStorageService(s influxdb.StorageService) *StorageService {
+	return &StorageService{
+		s: s,
+	}
+}
+
+func (s StorageService) ListShards(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageShard, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.ListShards(ctx, filter)
+}
+
+func (s StorageService) FindShardByID(ctx context.Context, id uint64) (*influxdb.StorageShard, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.FindShardByID(ctx, id)
+}
+
+func (s StorageService) CompactShard(ctx context.Context, id uint64) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return s.s.CompactShard(ctx, id)
+}
+
+func (s StorageService) SnapshotShard(ctx context.Context, id uint64) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return s.s.SnapshotShard(ctx, id)
+}
+
+func (s StorageService) RebuildShardIndex(ctx context.Context, id uint64) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return s.s.RebuildShardIndex(ctx, id)
+}
+
+func (s StorageService) ExportShard(ctx context.Context, id uint64, start, stop time.Time, w io.Writer) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return s.s.ExportShard(ctx, id, start, stop, w)
+}
+
+func (s StorageService) ImportShard(ctx context.Context, bucketID platform.ID, start, stop time.Time, r io.Reader) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return s.s.ImportShard(ctx, bucketID, start, stop, r)
+}
+
+func (s StorageService) MoveShardToColdTier(ctx context.Context, id uint64) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return s.s.MoveShardToColdTier(ctx, id)
+}
+
+func (s StorageService) FindTieringPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageTieringPolicy, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.FindTieringPolicy(ctx, bucketID)
+}
+
+func (s StorageService) UpdateTieringPolicy(ctx context.Context, policy influxdb.StorageTieringPolicy) (*influxdb.StorageTieringPolicy, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateTieringPolicy(ctx, policy)
+}
+
+func (s StorageService) ListCompactions(ctx context.Context, filter influxdb.StorageShardFilter) (*influxdb.StorageCompactions, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.ListCompactions(ctx, filter)
+}
+
+func (s StorageService) FindCompactionLimits(ctx context.Context) (*influxdb.StorageCompactionLimits, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.FindCompactionLimits(ctx)
+}
+
+func (s StorageService) UpdateCompactionLimits(ctx context.Context, upd influxdb.StorageCompactionLimitsUpdate) (*influxdb.StorageCompactionLimits, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateCompactionLimits(ctx, upd)
+}
+
+func (s StorageService) FindDurabilityPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageDurabilityPolicy, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.FindDurabilityPolicy(ctx, bucketID)
+}
+
+func (s StorageService) UpdateDurabilityPolicy(ctx context.Context, policy influxdb.StorageDurabilityPolicy) (*influxdb.StorageDurabilityPolicy, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateDurabilityPolicy(ctx, policy)
+}
+
+func (s StorageService) ListMemoryUsage(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageBucketMemoryUsage, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.ListMemoryUsage(ctx, filter)
+}
+
+func (s StorageService) ReshardBucket(ctx context.Context, bucketID platform.ID) (*influxdb.StorageReshardStep, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.ReshardBucket(ctx, bucketID)
+}
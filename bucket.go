@@ -19,12 +19,15 @@ const (
 	MonitoringSystemBucketRetention = time.Hour * 24 * 7
 	// TasksSystemBucketRetention is the time we should retain task system bucket information
 	TasksSystemBucketRetention = time.Hour * 24 * 3
+	// QueriesSystemBucketRetention is the time we should retain query system bucket information
+	QueriesSystemBucketRetention = time.Hour * 24 * 7
 )
 
 // Bucket names constants
 const (
 	TasksSystemBucketName      = "_tasks"
 	MonitoringSystemBucketName = "_monitoring"
+	QueriesSystemBucketName    = "_queries"
 )
 
 // InfiniteRetention is default infinite retention period.
@@ -23,7 +23,8 @@ type cmdBucketBuilder struct {
 	genericCLIOpts
 	*globalFlags
 
-	svcFn bucketSVCsFn
+	svcFn        bucketSVCsFn
+	storageSVCFn func() (influxdb.StorageService, error)
 
 	id                 string
 	hideHeaders        bool
@@ -33,6 +34,9 @@ type cmdBucketBuilder struct {
 	org                organization
 	retention          string
 	shardGroupDuration string
+	path               string
+	start              string
+	stop               string
 }
 
 func newCmdBucketBuilder(svcsFn bucketSVCsFn, f *globalFlags, opts genericCLIOpts) *cmdBucketBuilder {
@@ -40,6 +44,7 @@ func newCmdBucketBuilder(svcsFn bucketSVCsFn, f *globalFlags, opts genericCLIOpt
 		globalFlags:    f,
 		genericCLIOpts: opts,
 		svcFn:          svcsFn,
+		storageSVCFn:   newBucketStorageSVC,
 	}
 }
 
@@ -51,7 +56,10 @@ func (b *cmdBucketBuilder) cmd() *cobra.Command {
 	cmd.AddCommand(
 		b.cmdCreate(),
 		b.cmdDelete(),
+		b.cmdExportShards(),
+		b.cmdImportShards(),
 		b.cmdList(),
+		b.cmdReshard(),
 		b.cmdUpdate(),
 	)
 
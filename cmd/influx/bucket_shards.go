@@ -0,0 +1,383 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/internal"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/spf13/cobra"
+)
+
+// shardExportManifestName is the name of the manifest written next to the
+// shard archives of an export.
+const shardExportManifestName = "shards.manifest"
+
+// shardExportManifest lists the shard archives of an export.
+type shardExportManifest struct {
+	BucketID   string                    `json:"bucketID"`
+	BucketName string                    `json:"bucketName"`
+	Start      time.Time                 `json:"start"`
+	Stop       time.Time                 `json:"stop"`
+	Files      []shardExportManifestFile `json:"files"`
+}
+
+// shardExportManifestFile describes the archive of a single shard.
+type shardExportManifestFile struct {
+	ShardID   uint64    `json:"shardID"`
+	FileName  string    `json:"fileName"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Size      int64     `json:"size"`
+}
+
+func (b *cmdBucketBuilder) cmdExportShards() *cobra.Command {
+	cmd := b.newCmd("export-shards", b.cmdExportShardsRunEFn)
+	cmd.Short = "Export the TSM data of a bucket for a time range"
+	cmd.Long = `Export the TSM data of a bucket for a time range.
+
+The data of each shard overlapping the time range is written to a compressed
+archive in the given directory, along with a manifest used by import-shards.`
+
+	b.registerShardsFlags(cmd)
+	cmd.Flags().StringVar(&b.start, "start", "", "The start time in RFC3339Nano format, exp 2009-01-02T23:00:00Z (required)")
+	cmd.Flags().StringVar(&b.stop, "stop", "", "The stop time in RFC3339Nano format, exp 2009-01-02T23:00:00Z. Defaults to now.")
+	cmd.MarkFlagRequired("start")
+
+	return cmd
+}
+
+func (b *cmdBucketBuilder) cmdExportShardsRunEFn(cmd *cobra.Command, args []string) error {
+	start, err := time.Parse(time.RFC3339Nano, b.start)
+	if err != nil {
+		return fmt.Errorf("invalid start time %q: %v", b.start, err)
+	}
+	stop := time.Now().UTC()
+	if b.stop != "" {
+		if stop, err = time.Parse(time.RFC3339Nano, b.stop); err != nil {
+			return fmt.Errorf("invalid stop time %q: %v", b.stop, err)
+		}
+	}
+	if !start.Before(stop) {
+		return fmt.Errorf("start time must be before stop time")
+	}
+
+	bkt, storageSVC, err := b.findShardsBucket()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	shards, err := storageSVC.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &bkt.ID})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve shards: %v", err)
+	}
+
+	if err := os.MkdirAll(b.path, 0777); err != nil {
+		return err
+	}
+
+	manifest := shardExportManifest{
+		BucketID:   bkt.ID.String(),
+		BucketName: bkt.Name,
+		Start:      start.UTC(),
+		Stop:       stop.UTC(),
+		Files:      []shardExportManifestFile{},
+	}
+	for _, sh := range shards {
+		if !sh.StartTime.Before(stop) || !sh.EndTime.After(start) {
+			continue
+		}
+
+		file := shardExportManifestFile{
+			ShardID:   sh.ID,
+			FileName:  fmt.Sprintf("shard-%d.tar.gz", sh.ID),
+			StartTime: sh.StartTime,
+			EndTime:   sh.EndTime,
+		}
+		if file.Size, err = exportShard(ctx, storageSVC, sh.ID, start, stop, filepath.Join(b.path, file.FileName)); err != nil {
+			return fmt.Errorf("failed to export shard %d: %v", sh.ID, err)
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(b.path, shardExportManifestName), buf, 0666); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+
+	return b.printShardFiles(manifest.Files)
+}
+
+// exportShard writes the compressed export of a shard to path and returns the
+// size of the file.
+func exportShard(ctx context.Context, svc influxdb.StorageService, id uint64, start, stop time.Time, path string) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	if err := svc.ExportShard(ctx, id, start, stop, gw); err != nil {
+		return 0, err
+	}
+	if err := gw.Close(); err != nil {
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), f.Close()
+}
+
+func (b *cmdBucketBuilder) cmdImportShards() *cobra.Command {
+	cmd := b.newCmd("import-shards", b.cmdImportShardsRunEFn)
+	cmd.Short = "Import TSM data exported with export-shards into a bucket"
+	cmd.Long = `Import TSM data exported with export-shards into a bucket.
+
+The data is split by the shard groups of the destination bucket, and its
+series are added to the series file of the destination.`
+
+	b.registerShardsFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdBucketBuilder) cmdImportShardsRunEFn(cmd *cobra.Command, args []string) error {
+	buf, err := ioutil.ReadFile(filepath.Join(b.path, shardExportManifestName))
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %v", err)
+	}
+	var manifest shardExportManifest
+	if err := json.Unmarshal(buf, &manifest); err != nil {
+		return fmt.Errorf("failed to read manifest: %v", err)
+	}
+
+	bkt, storageSVC, err := b.findShardsBucket()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, file := range manifest.Files {
+		if err := importShard(ctx, storageSVC, bkt.ID, manifest.Start, manifest.Stop, filepath.Join(b.path, file.FileName)); err != nil {
+			return fmt.Errorf("failed to import shard %d: %v", file.ShardID, err)
+		}
+	}
+
+	return b.printShardFiles(manifest.Files)
+}
+
+func importShard(ctx context.Context, svc influxdb.StorageService, bucketID platform.ID, start, stop time.Time, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	return svc.ImportShard(ctx, bucketID, start, stop, gr)
+}
+
+func (b *cmdBucketBuilder) cmdReshard() *cobra.Command {
+	cmd := b.newCmd("reshard", b.cmdReshardRunEFn)
+	cmd.Short = "Apply the shard group duration of a bucket to its existing data"
+	cmd.Long = `Apply the shard group duration of a bucket to its existing data.
+
+The shard groups of the bucket that don't have its shard group duration are
+replaced, one time range at a time, by shard groups that do. Their data is
+merged or split into the new shards while the bucket is still written to and
+queried. An interrupted reshard continues where it stopped when run again.`
+
+	cmd.Flags().StringVarP(&b.id, "id", "i", "", "The bucket ID, required if name isn't provided")
+	cmd.Flags().StringVarP(&b.name, "name", "n", "", "The bucket name, org or org-id will be required by choosing this")
+	cmd.Flags().StringVarP(&b.shardGroupDuration, "shard-group-duration", "", "",
+		"New shard group duration to set on the bucket before resharding it")
+	b.org.register(b.viper, cmd, false)
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdBucketBuilder) cmdReshardRunEFn(cmd *cobra.Command, args []string) error {
+	bkt, storageSVC, err := b.findShardsBucket()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if b.shardGroupDuration != "" {
+		sgDur, err := internal.RawDurationToTimeDuration(b.shardGroupDuration)
+		if err != nil {
+			return err
+		}
+
+		bktSVC, _, err := b.svcFn()
+		if err != nil {
+			return err
+		}
+		if _, err := bktSVC.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{ShardGroupDuration: &sgDur}); err != nil {
+			return fmt.Errorf("failed to update bucket: %v", err)
+		}
+	}
+
+	steps := []*influxdb.StorageReshardStep{}
+	for {
+		step, err := storageSVC.ReshardBucket(ctx, bkt.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reshard bucket: %v", err)
+		}
+		if len(step.SourceShardIDs) == 0 {
+			break
+		}
+		steps = append(steps, step)
+
+		fmt.Fprintf(b.errW, "Resharded %d shards from %s to %s into %d shards, %d shard groups remaining\n",
+			len(step.SourceShardIDs),
+			step.StartTime.Format(time.RFC3339),
+			step.EndTime.Format(time.RFC3339),
+			len(step.ShardIDs),
+			step.Remaining)
+		if step.Remaining == 0 {
+			break
+		}
+	}
+
+	return b.printReshardSteps(steps)
+}
+
+func (b *cmdBucketBuilder) printReshardSteps(steps []*influxdb.StorageReshardStep) error {
+	if b.json {
+		return b.writeJSON(steps)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	w.WriteHeaders("Start", "End", "Shard Group Duration", "Source Shards", "Shards")
+	for _, step := range steps {
+		w.Write(map[string]interface{}{
+			"Start":                step.StartTime.Format(time.RFC3339),
+			"End":                  step.EndTime.Format(time.RFC3339),
+			"Shard Group Duration": step.ShardGroupDuration.Duration.String(),
+			"Source Shards":        formatShardIDs(step.SourceShardIDs),
+			"Shards":               formatShardIDs(step.ShardIDs),
+		})
+	}
+
+	return nil
+}
+
+func formatShardIDs(ids []uint64) string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, strconv.FormatUint(id, 10))
+	}
+	return strings.Join(s, ",")
+}
+
+func (b *cmdBucketBuilder) registerShardsFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&b.id, "id", "i", "", "The bucket ID, required if name isn't provided")
+	cmd.Flags().StringVarP(&b.name, "name", "n", "", "The bucket name, org or org-id will be required by choosing this")
+	cmd.Flags().StringVarP(&b.path, "path", "p", "", "The directory of the shard archives (required)")
+	cmd.MarkFlagRequired("path")
+	b.org.register(b.viper, cmd, false)
+	b.registerPrintFlags(cmd)
+}
+
+// findShardsBucket returns the bucket of the id or name flags, and the
+// storage service holding its shards.
+func (b *cmdBucketBuilder) findShardsBucket() (*influxdb.Bucket, influxdb.StorageService, error) {
+	bktSVC, _, err := b.svcFn()
+	if err != nil {
+		return nil, nil, err
+	}
+	storageSVC, err := b.storageSVCFn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var filter influxdb.BucketFilter
+	switch {
+	case b.id != "":
+		if filter.ID, err = platform.IDFromString(b.id); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode bucket id %q: %v", b.id, err)
+		}
+	case b.name != "":
+		if err := b.org.validOrgFlags(b.globalFlags); err != nil {
+			return nil, nil, err
+		}
+		filter.Name = &b.name
+		if b.org.id != "" {
+			if filter.OrganizationID, err = platform.IDFromString(b.org.id); err != nil {
+				return nil, nil, fmt.Errorf("failed to decode org id %q: %v", b.org.id, err)
+			}
+		} else {
+			filter.Org = &b.org.name
+		}
+	default:
+		return nil, nil, fmt.Errorf("must specify one of id or name")
+	}
+
+	bkt, err := bktSVC.FindBucket(context.Background(), filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find bucket: %v", err)
+	}
+	return bkt, storageSVC, nil
+}
+
+func (b *cmdBucketBuilder) printShardFiles(files []shardExportManifestFile) error {
+	if b.json {
+		return b.writeJSON(files)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	w.WriteHeaders("Shard ID", "Start", "End", "File", "Size")
+	for _, f := range files {
+		w.Write(map[string]interface{}{
+			"Shard ID": f.ShardID,
+			"Start":    f.StartTime.Format(time.RFC3339),
+			"End":      f.EndTime.Format(time.RFC3339),
+			"File":     f.FileName,
+			"Size":     f.Size,
+		})
+	}
+
+	return nil
+}
+
+func newBucketStorageSVC() (influxdb.StorageService, error) {
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return &http.StorageService{Client: httpClient}, nil
+}
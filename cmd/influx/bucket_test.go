@@ -3,9 +3,11 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -474,6 +476,103 @@ func TestCmdBucket(t *testing.T) {
 	})
 }
 
+func TestCmdBucketShards(t *testing.T) {
+	src, dst := platform.ID(2), platform.ID(3)
+	shards := []*influxdb.StorageShard{
+		{ID: 5, BucketID: src, StartTime: time.Date(2019, 12, 30, 0, 0, 0, 0, time.UTC), EndTime: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)},
+		{ID: 6, BucketID: src, StartTime: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), EndTime: time.Date(2020, 1, 13, 0, 0, 0, 0, time.UTC)},
+		{ID: 7, BucketID: src, StartTime: time.Date(2020, 1, 13, 0, 0, 0, 0, time.UTC), EndTime: time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	svc := &fakeStorageSVC{}
+	storageSVC := &fakeShardsStorageSVC{fakeStorageSVC: svc, shards: shards}
+	var bktUpdateFn func(ctx context.Context, id platform.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error)
+
+	run := func(t *testing.T, args ...string) {
+		t.Helper()
+
+		bktSVC := mock.NewBucketService()
+		bktSVC.FindBucketFn = func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			if f.Name != nil {
+				require.Equal(t, "rg", *f.Org)
+				return &influxdb.Bucket{ID: dst, Name: *f.Name}, nil
+			}
+			return &influxdb.Bucket{ID: *f.ID, Name: "ups"}, nil
+		}
+		if bktUpdateFn != nil {
+			bktSVC.UpdateBucketFn = bktUpdateFn
+		}
+
+		builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(ioutil.Discard))
+		cmd := builder.cmd(func(g *globalFlags, opt genericCLIOpts) *cobra.Command {
+			svcFn := func() (influxdb.BucketService, influxdb.OrganizationService, error) {
+				return bktSVC, &mock.OrganizationService{}, nil
+			}
+			b := newCmdBucketBuilder(svcFn, g, opt)
+			b.storageSVCFn = func() (influxdb.StorageService, error) {
+				return storageSVC, nil
+			}
+			return b.cmd()
+		})
+		cmd.SetArgs(append([]string{"bucket"}, args...))
+		require.NoError(t, cmd.Execute())
+	}
+
+	defer addEnvVars(t, envVarsZeroMap)()
+
+	dir := t.TempDir()
+	run(t, "export-shards",
+		"--id="+src.String(),
+		"--path="+dir,
+		"--start=2020-01-01T00:00:00Z",
+		"--stop=2020-01-10T00:00:00Z",
+	)
+
+	// Only the shards overlapping the time range are exported.
+	var manifest shardExportManifest
+	buf, err := ioutil.ReadFile(filepath.Join(dir, shardExportManifestName))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(buf, &manifest))
+	require.Equal(t, src.String(), manifest.BucketID)
+	require.Len(t, manifest.Files, 2)
+	assert.Equal(t, uint64(5), manifest.Files[0].ShardID)
+	assert.Equal(t, uint64(6), manifest.Files[1].ShardID)
+
+	run(t, "import-shards", "--name=central", "--org=rg", "--path="+dir)
+	assert.Equal(t, []string{
+		dst.String() + ": shard 5 from 2020-01-01T00:00:00Z to 2020-01-10T00:00:00Z",
+		dst.String() + ": shard 6 from 2020-01-01T00:00:00Z to 2020-01-10T00:00:00Z",
+	}, svc.imported)
+
+	// The bucket is resharded until no shard group is left.
+	var updated *influxdb.BucketUpdate
+	bktUpdateFn = func(ctx context.Context, id platform.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+		require.Equal(t, src, id)
+		updated = &upd
+		return &influxdb.Bucket{ID: id}, nil
+	}
+	svc.calls = nil
+	svc.reshards = []*influxdb.StorageReshardStep{
+		{BucketID: src, StartTime: shards[0].StartTime, EndTime: shards[1].EndTime, SourceShardIDs: []uint64{5, 6}, ShardIDs: []uint64{8}, Remaining: 1},
+		{BucketID: src, StartTime: shards[2].StartTime, EndTime: shards[2].EndTime.Add(7 * 24 * time.Hour), SourceShardIDs: []uint64{7}, ShardIDs: []uint64{9}},
+	}
+	run(t, "reshard", "--id="+src.String(), "--shard-group-duration=2w")
+	require.NotNil(t, updated)
+	assert.Equal(t, durPtr(14*24*time.Hour), updated.ShardGroupDuration)
+	assert.Equal(t, []string{"reshard " + src.String(), "reshard " + src.String()}, svc.calls)
+	assert.Empty(t, svc.reshards)
+}
+
+// fakeShardsStorageSVC is a fakeStorageSVC listing several shards.
+type fakeShardsStorageSVC struct {
+	*fakeStorageSVC
+	shards []*influxdb.StorageShard
+}
+
+func (f *fakeShardsStorageSVC) ListShards(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageShard, error) {
+	return f.shards, nil
+}
+
 func strPtr(s string) *string {
 	return &s
 }
@@ -338,6 +338,7 @@ func influxCmd(opts ...genericCLIOptFn) *cobra.Command {
 		cmdSecret,
 		cmdSetup,
 		cmdStack,
+		cmdStorage,
 		cmdTask,
 		cmdTelegraf,
 		cmdTemplate,
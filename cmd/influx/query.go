@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,14 +18,16 @@ import (
 	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/values"
 	ihttp "github.com/influxdata/influxdb/v2/http"
+	"github.com/klauspost/compress/zstd"
 	"github.com/spf13/cobra"
 )
 
 var queryFlags struct {
-	org       organization
-	file      string
-	raw       bool
-	profilers []string
+	org         organization
+	file        string
+	raw         bool
+	profilers   []string
+	compression string
 }
 
 func cmdQuery(f *globalFlags, opts genericCLIOpts) *cobra.Command {
@@ -38,6 +41,13 @@ func cmdQuery(f *globalFlags, opts genericCLIOpts) *cobra.Command {
 	cmd.Flags().StringVarP(&queryFlags.file, "file", "f", "", "Path to Flux query file")
 	cmd.Flags().BoolVarP(&queryFlags.raw, "raw", "r", false, "Display raw query results")
 	cmd.Flags().StringSliceVarP(&queryFlags.profilers, "profilers", "p", nil, "Names of Flux profilers to enable. Profiler information will be appended to query results")
+	cmd.Flags().StringVar(&queryFlags.compression, "compression", "gzip", "Compression of the query results sent by the server, either 'none', 'gzip' or 'zstd'")
+
+	registryBuilder := newCmdQueryRegistryBuilder(&queryFlags.org, f, opts)
+	cmd.AddCommand(
+		registryBuilder.cmdList(),
+		registryBuilder.cmdKill(),
+	)
 
 	return cmd
 }
@@ -80,6 +90,11 @@ func fluxQueryF(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	acceptEncoding, ok := queryAcceptEncodings[queryFlags.compression]
+	if !ok {
+		return fmt.Errorf("unsupported compression: %s", queryFlags.compression)
+	}
+
 	q, err := readFluxQuery(args, queryFlags.file)
 	if err != nil {
 		return fmt.Errorf("failed to load query: %v", err)
@@ -122,6 +137,7 @@ func fluxQueryF(cmd *cobra.Command, args []string) error {
 	req, _ := http.NewRequest("POST", u.String(), bytes.NewReader(body))
 	req.Header.Set("Authorization", "Token "+flags.config().Token)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", acceptEncoding)
 
 	client := ihttp.NewClient(u.Scheme, flags.skipVerify)
 	resp, err := client.Do(req)
@@ -129,6 +145,9 @@ func fluxQueryF(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
+	if err := decompressResponse(resp); err != nil {
+		return err
+	}
 
 	if err := ihttp.CheckError(resp); err != nil {
 		return err
@@ -163,6 +182,50 @@ func fluxQueryF(cmd *cobra.Command, args []string) error {
 	return results.Err()
 }
 
+// queryAcceptEncodings maps the values of the compression flag to the
+// Accept-Encoding header of the query request.
+var queryAcceptEncodings = map[string]string{
+	"none": "identity",
+	"gzip": "gzip",
+	"zstd": "zstd",
+}
+
+// decompressResponse replaces the body of a compressed response with its
+// decompressed content.
+func decompressResponse(resp *http.Response) error {
+	var (
+		zr  io.ReadCloser
+		err error
+	)
+	switch resp.Header.Get("Content-Encoding") {
+	case "gzip":
+		zr, err = gzip.NewReader(resp.Body)
+	case "zstd":
+		var dec *zstd.Decoder
+		if dec, err = zstd.NewReader(resp.Body); err == nil {
+			zr = dec.IOReadCloser()
+		}
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decompress response: %w", err)
+	}
+	resp.Body = &decompressedBody{ReadCloser: zr, body: resp.Body}
+	return nil
+}
+
+// decompressedBody closes the compressed body along with its decompressor.
+type decompressedBody struct {
+	io.ReadCloser
+	body io.Closer
+}
+
+func (b *decompressedBody) Close() error {
+	_ = b.ReadCloser.Close()
+	return b.body.Close()
+}
+
 // buildProfilersExtern constructs the AST representation of a Flux statement enabling
 // the specified profilers in the query options.
 //
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/query/registry"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/spf13/cobra"
+)
+
+type cmdQueryRegistryBuilder struct {
+	genericCLIOpts
+	*globalFlags
+
+	org *organization
+
+	allOrgs     bool
+	json        bool
+	hideHeaders bool
+}
+
+func newCmdQueryRegistryBuilder(org *organization, f *globalFlags, opt genericCLIOpts) *cmdQueryRegistryBuilder {
+	return &cmdQueryRegistryBuilder{
+		genericCLIOpts: opt,
+		globalFlags:    f,
+		org:            org,
+	}
+}
+
+func (b *cmdQueryRegistryBuilder) cmdList() *cobra.Command {
+	cmd := b.newCmd("list", b.cmdListRunEFn)
+	cmd.Short = "List running queries"
+	cmd.Long = `List the running Flux and InfluxQL queries of the organization.
+Listing the queries of all organizations requires operator access.`
+	cmd.Aliases = []string{"find", "ls"}
+	cmd.Args = cobra.NoArgs
+
+	b.registerFlags(cmd)
+	registerPrintOptions(b.viper, cmd, &b.hideHeaders, &b.json)
+
+	return cmd
+}
+
+func (b *cmdQueryRegistryBuilder) cmdListRunEFn(cmd *cobra.Command, args []string) error {
+	client, orgID, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	queries, err := client.Queries(context.Background(), orgID)
+	if err != nil {
+		return fmt.Errorf("failed to list queries: %v", err)
+	}
+
+	if b.json {
+		return b.writeJSON(queries)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+	w.WriteHeaders("ID", "Organization ID", "Language", "Database", "Duration", "Status", "Query")
+	for _, q := range queries {
+		w.Write(map[string]interface{}{
+			"ID":              q.ID,
+			"Organization ID": q.OrgID.String(),
+			"Language":        q.Language,
+			"Database":        q.Database,
+			"Duration":        time.Since(q.StartTime).Round(time.Millisecond).String(),
+			"Status":          q.Status,
+			"Query":           q.Query,
+		})
+	}
+	return nil
+}
+
+func (b *cmdQueryRegistryBuilder) cmdKill() *cobra.Command {
+	cmd := b.newCmd("kill <query ID>", b.cmdKillRunEFn)
+	cmd.Short = "Kill a running query"
+	cmd.Long = `Kill a running Flux or InfluxQL query of the organization.
+Killing the queries of all organizations requires operator access.`
+	cmd.Args = cobra.ExactArgs(1)
+
+	b.registerFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdQueryRegistryBuilder) cmdKillRunEFn(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid query ID %q: %v", args[0], err)
+	}
+
+	client, orgID, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.Kill(context.Background(), orgID, id); err != nil {
+		return fmt.Errorf("failed to kill query %d: %v", id, err)
+	}
+	return nil
+}
+
+func (b *cmdQueryRegistryBuilder) newCmd(use string, runE func(*cobra.Command, []string) error) *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd(use, runE, true)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	return cmd
+}
+
+func (b *cmdQueryRegistryBuilder) registerFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&b.allOrgs, "all-orgs", false, "Include the queries of all organizations, requires operator access")
+}
+
+// client returns the client to the queries API along with the organization
+// to restrict the queries to, which is invalid with --all-orgs.
+func (b *cmdQueryRegistryBuilder) client() (*registry.Client, platform.ID, error) {
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if b.allOrgs {
+		return &registry.Client{Client: httpClient}, 0, nil
+	}
+
+	orgID, err := b.org.getID(&tenant.OrgClientService{Client: httpClient})
+	if err != nil {
+		return nil, 0, err
+	}
+	return &registry.Client{Client: httpClient}, orgID, nil
+}
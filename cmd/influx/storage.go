@@ -0,0 +1,840 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/internal"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/spf13/cobra"
+)
+
+type storageSVCsFn func() (influxdb.StorageService, influxdb.BucketService, error)
+
+func cmdStorage(f *globalFlags, opt genericCLIOpts) *cobra.Command {
+	builder := newCmdStorageBuilder(newStorageSVCs, f, opt)
+	return builder.cmd()
+}
+
+type cmdStorageBuilder struct {
+	genericCLIOpts
+	*globalFlags
+
+	svcFn storageSVCsFn
+
+	id              uint64
+	bucketID        string
+	bucketName      string
+	coldAfter       string
+	durabilityMode  string
+	fsyncDelay      string
+	snapshotEvery   string
+	throughput      int64
+	throughputBurst int64
+	maxConcurrent   int
+	measurements    bool
+	hideHeaders     bool
+	json            bool
+	org             organization
+}
+
+func newCmdStorageBuilder(svcsFn storageSVCsFn, f *globalFlags, opts genericCLIOpts) *cmdStorageBuilder {
+	return &cmdStorageBuilder{
+		globalFlags:    f,
+		genericCLIOpts: opts,
+		svcFn:          svcsFn,
+	}
+}
+
+func (b *cmdStorageBuilder) cmd() *cobra.Command {
+	cmd := b.newCmd("storage", nil)
+	cmd.Short = "Storage engine management commands"
+	cmd.TraverseChildren = true
+	cmd.Run = seeHelp
+	cmd.AddCommand(
+		b.cmdShards(),
+		b.cmdTiering(),
+		b.cmdDurability(),
+		b.cmdCompactions(),
+		b.cmdMemory(),
+	)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdShards() *cobra.Command {
+	cmd := b.newCmd("shards", nil)
+	cmd.Short = "Shard management commands"
+	cmd.Run = seeHelp
+	cmd.AddCommand(
+		b.cmdShardsList(),
+		b.cmdShardAction("compact", "Schedule a full compaction of a shard", influxdb.StorageService.CompactShard),
+		b.cmdShardAction("snapshot", "Write the cache of a shard to a new TSM file", influxdb.StorageService.SnapshotShard),
+		b.cmdShardAction("rebuild-index", "Rebuild the index of a shard from its data", influxdb.StorageService.RebuildShardIndex),
+		b.cmdShardAction("move-to-cold", "Move a shard to the cold tier, where it is read-only", influxdb.StorageService.MoveShardToColdTier),
+	)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdShardsList() *cobra.Command {
+	cmd := b.newCmd("list", b.cmdShardsListRunEFn)
+	cmd.Short = "List shards"
+	cmd.Aliases = []string{"find", "ls"}
+
+	b.registerBucketFlags(cmd, "The ID of the bucket to list shards of", "The name of the bucket to list shards of")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdShardsListRunEFn(cmd *cobra.Command, args []string) error {
+	if b.bucketID != "" && b.bucketName != "" {
+		return fmt.Errorf("must specify at most one of bucket-id or bucket")
+	}
+
+	storageSVC, bktSVC, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	var filter influxdb.StorageShardFilter
+	if b.bucketID != "" || b.bucketName != "" {
+		if filter.BucketID, err = b.findBucketID(ctx, bktSVC); err != nil {
+			return err
+		}
+	}
+
+	shards, err := storageSVC.ListShards(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve shards: %v", err)
+	}
+
+	return b.printShards(shards...)
+}
+
+func (b *cmdStorageBuilder) cmdShardAction(use, short string, fn func(influxdb.StorageService, context.Context, uint64) error) *cobra.Command {
+	cmd := b.newCmd(use, func(*cobra.Command, []string) error {
+		storageSVC, _, err := b.svcFn()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		if err := fn(storageSVC, ctx, b.id); err != nil {
+			return fmt.Errorf("failed to %s shard %d: %v", use, b.id, err)
+		}
+
+		shard, err := storageSVC.FindShardByID(ctx, b.id)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve shard %d: %v", b.id, err)
+		}
+		return b.printShards(shard)
+	})
+	cmd.Short = short
+
+	cmd.Flags().Uint64VarP(&b.id, "id", "i", 0, "The shard ID (required)")
+	cmd.MarkFlagRequired("id")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdTiering() *cobra.Command {
+	cmd := b.newCmd("tiering", nil)
+	cmd.Short = "Bucket tiering policy management commands"
+	cmd.Long = `Bucket tiering policy management commands.
+
+The shards of a bucket move to the cold tier once their end time is older
+than the cold-after duration of the bucket. Shards on the cold tier are
+stored in the directory set with the storage-cold-dir flag of influxd, and
+are read-only.`
+	cmd.Run = seeHelp
+	cmd.AddCommand(
+		b.cmdTieringFind(),
+		b.cmdTieringUpdate(),
+	)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdTieringFind() *cobra.Command {
+	cmd := b.newCmd("find", b.cmdTieringFindRunEFn)
+	cmd.Short = "Show the tiering policy of a bucket"
+	cmd.Aliases = []string{"get"}
+
+	b.registerBucketFlags(cmd, "The ID of the bucket", "The name of the bucket")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdTieringFindRunEFn(cmd *cobra.Command, args []string) error {
+	storageSVC, bktSVC, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bucketID, err := b.findBucketID(ctx, bktSVC)
+	if err != nil {
+		return err
+	}
+
+	policy, err := storageSVC.FindTieringPolicy(ctx, *bucketID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve tiering policy: %v", err)
+	}
+
+	return b.printTieringPolicy(policy)
+}
+
+func (b *cmdStorageBuilder) cmdTieringUpdate() *cobra.Command {
+	cmd := b.newCmd("update", b.cmdTieringUpdateRunEFn)
+	cmd.Short = "Update the tiering policy of a bucket"
+
+	b.registerBucketFlags(cmd, "The ID of the bucket", "The name of the bucket")
+	cmd.Flags().StringVarP(&b.coldAfter, "cold-after", "", "", "Duration after the end of their shard group shards move to the cold tier. 0 keeps shards on the primary tier. (required)")
+	cmd.MarkFlagRequired("cold-after")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdTieringUpdateRunEFn(cmd *cobra.Command, args []string) error {
+	coldAfter, err := internal.RawDurationToTimeDuration(b.coldAfter)
+	if err != nil {
+		return err
+	}
+
+	storageSVC, bktSVC, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bucketID, err := b.findBucketID(ctx, bktSVC)
+	if err != nil {
+		return err
+	}
+
+	policy, err := storageSVC.UpdateTieringPolicy(ctx, influxdb.StorageTieringPolicy{
+		BucketID:  *bucketID,
+		ColdAfter: influxdb.Duration{Duration: coldAfter},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update tiering policy: %v", err)
+	}
+
+	return b.printTieringPolicy(policy)
+}
+
+func (b *cmdStorageBuilder) cmdDurability() *cobra.Command {
+	cmd := b.newCmd("durability", nil)
+	cmd.Short = "Bucket durability policy management commands"
+	cmd.Long = `Bucket durability policy management commands.
+
+The durability mode of a bucket controls when writes to its shards are
+durable:
+
+	default       use the wal-fsync-delay setting of influxd
+	fsync         fsync the WAL before each write is acknowledged
+	group-commit  fsync the WAL once for the writes made within the fsync delay
+	no-wal        keep writes in the cache only, and write the cache to TSM files
+	              at least every snapshot interval. Writes since the last
+	              snapshot are lost if influxd crashes.
+
+Changing the durability mode briefly closes the shards of the bucket.`
+	cmd.Run = seeHelp
+	cmd.AddCommand(
+		b.cmdDurabilityFind(),
+		b.cmdDurabilityUpdate(),
+	)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdDurabilityFind() *cobra.Command {
+	cmd := b.newCmd("find", b.cmdDurabilityFindRunEFn)
+	cmd.Short = "Show the durability policy of a bucket"
+	cmd.Aliases = []string{"get"}
+
+	b.registerBucketFlags(cmd, "The ID of the bucket", "The name of the bucket")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdDurabilityFindRunEFn(cmd *cobra.Command, args []string) error {
+	storageSVC, bktSVC, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bucketID, err := b.findBucketID(ctx, bktSVC)
+	if err != nil {
+		return err
+	}
+
+	policy, err := storageSVC.FindDurabilityPolicy(ctx, *bucketID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve durability policy: %v", err)
+	}
+
+	return b.printDurabilityPolicy(policy)
+}
+
+func (b *cmdStorageBuilder) cmdDurabilityUpdate() *cobra.Command {
+	cmd := b.newCmd("update", b.cmdDurabilityUpdateRunEFn)
+	cmd.Short = "Update the durability policy of a bucket"
+
+	b.registerBucketFlags(cmd, "The ID of the bucket", "The name of the bucket")
+	cmd.Flags().StringVarP(&b.durabilityMode, "mode", "", "", "Durability mode of the bucket: default, fsync, group-commit or no-wal (required)")
+	cmd.MarkFlagRequired("mode")
+	cmd.Flags().StringVarP(&b.fsyncDelay, "fsync-delay", "", "", "Delay before the WAL is fsynced in the group-commit mode")
+	cmd.Flags().StringVarP(&b.snapshotEvery, "snapshot-interval", "", "", "Longest time the cache holds writes in the no-wal mode. 0 only writes the cache when it is too large or cold.")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdDurabilityUpdateRunEFn(cmd *cobra.Command, args []string) error {
+	mode := b.durabilityMode
+	switch mode {
+	case "default":
+		mode = influxdb.DurabilityDefault
+	case influxdb.DurabilityFsync, influxdb.DurabilityGroupCommit, influxdb.DurabilityNoWAL:
+	default:
+		return fmt.Errorf("invalid durability mode %q", b.durabilityMode)
+	}
+
+	var fsyncDelay, snapshotInterval time.Duration
+	if b.fsyncDelay != "" {
+		d, err := internal.RawDurationToTimeDuration(b.fsyncDelay)
+		if err != nil {
+			return err
+		}
+		fsyncDelay = d
+	}
+	if b.snapshotEvery != "" {
+		d, err := internal.RawDurationToTimeDuration(b.snapshotEvery)
+		if err != nil {
+			return err
+		}
+		snapshotInterval = d
+	}
+
+	storageSVC, bktSVC, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bucketID, err := b.findBucketID(ctx, bktSVC)
+	if err != nil {
+		return err
+	}
+
+	policy, err := storageSVC.UpdateDurabilityPolicy(ctx, influxdb.StorageDurabilityPolicy{
+		BucketID:         *bucketID,
+		Mode:             mode,
+		FsyncDelay:       influxdb.Duration{Duration: fsyncDelay},
+		SnapshotInterval: influxdb.Duration{Duration: snapshotInterval},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update durability policy: %v", err)
+	}
+
+	return b.printDurabilityPolicy(policy)
+}
+
+func (b *cmdStorageBuilder) cmdCompactions() *cobra.Command {
+	cmd := b.newCmd("compactions", nil)
+	cmd.Short = "Compaction management commands"
+	cmd.Run = seeHelp
+	cmd.AddCommand(
+		b.cmdCompactionsList(),
+		b.cmdCompactionLimits(),
+	)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdCompactionsList() *cobra.Command {
+	cmd := b.newCmd("list", b.cmdCompactionsListRunEFn)
+	cmd.Short = "List queued and in progress compactions"
+	cmd.Aliases = []string{"find", "ls"}
+
+	b.registerBucketFlags(cmd, "The ID of the bucket to list compactions of", "The name of the bucket to list compactions of")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdCompactionsListRunEFn(cmd *cobra.Command, args []string) error {
+	if b.bucketID != "" && b.bucketName != "" {
+		return fmt.Errorf("must specify at most one of bucket-id or bucket")
+	}
+
+	storageSVC, bktSVC, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	var filter influxdb.StorageShardFilter
+	if b.bucketID != "" || b.bucketName != "" {
+		if filter.BucketID, err = b.findBucketID(ctx, bktSVC); err != nil {
+			return err
+		}
+	}
+
+	compactions, err := storageSVC.ListCompactions(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve compactions: %v", err)
+	}
+
+	return b.printCompactions(compactions)
+}
+
+func (b *cmdStorageBuilder) cmdCompactionLimits() *cobra.Command {
+	cmd := b.newCmd("limits", nil)
+	cmd.Short = "Compaction limit management commands"
+	cmd.Long = `Compaction limit management commands.
+
+The limits are shared by the compactions of all shards. Updated limits apply
+until influxd restarts, which resets them to the storage-compact-throughput,
+storage-compact-throughput-burst and storage-max-concurrent-compactions flags.`
+	cmd.Run = seeHelp
+	cmd.AddCommand(
+		b.cmdCompactionLimitsFind(),
+		b.cmdCompactionLimitsUpdate(),
+	)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdCompactionLimitsFind() *cobra.Command {
+	cmd := b.newCmd("find", func(*cobra.Command, []string) error {
+		storageSVC, _, err := b.svcFn()
+		if err != nil {
+			return err
+		}
+
+		limits, err := storageSVC.FindCompactionLimits(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to retrieve compaction limits: %v", err)
+		}
+		return b.printCompactionLimits(limits)
+	})
+	cmd.Short = "Show the compaction limits"
+	cmd.Aliases = []string{"get"}
+
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdCompactionLimitsUpdate() *cobra.Command {
+	cmd := b.newCmd("update", b.cmdCompactionLimitsUpdateRunEFn)
+	cmd.Short = "Update the compaction limits"
+
+	cmd.Flags().Int64VarP(&b.throughput, "throughput", "", 0, "The rate in bytes per second at which compactions write to disk. 0 is unlimited.")
+	cmd.Flags().Int64VarP(&b.throughputBurst, "throughput-burst", "", 0, "The number of bytes compactions may write at once, at least the throughput.")
+	cmd.Flags().IntVarP(&b.maxConcurrent, "max-concurrent", "", 0, "The number of compactions that may run at once.")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdCompactionLimitsUpdateRunEFn(cmd *cobra.Command, args []string) error {
+	var upd influxdb.StorageCompactionLimitsUpdate
+	if cmd.Flags().Changed("throughput") {
+		upd.Throughput = &b.throughput
+	}
+	if cmd.Flags().Changed("throughput-burst") {
+		upd.ThroughputBurst = &b.throughputBurst
+	}
+	if cmd.Flags().Changed("max-concurrent") {
+		upd.MaxConcurrent = &b.maxConcurrent
+	}
+	if upd.Throughput == nil && upd.ThroughputBurst == nil && upd.MaxConcurrent == nil {
+		return fmt.Errorf("must specify at least one of throughput, throughput-burst or max-concurrent")
+	}
+
+	storageSVC, _, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	limits, err := storageSVC.UpdateCompactionLimits(context.Background(), upd)
+	if err != nil {
+		return fmt.Errorf("failed to update compaction limits: %v", err)
+	}
+
+	return b.printCompactionLimits(limits)
+}
+
+func (b *cmdStorageBuilder) cmdMemory() *cobra.Command {
+	cmd := b.newCmd("memory", b.cmdMemoryRunEFn)
+	cmd.Short = "Report the memory used by the shards and series files of buckets"
+	cmd.Long = `Report the memory used by the shards and series files of buckets.
+
+The heap memory of the shards of a bucket is its index, series ID set cache
+and cache. When it exceeds the budget set with storage-bucket-memory-budget,
+the shards write their caches to TSM files, compact their index log files and
+empty their series ID set caches. The mmapped TSM indexes and series files are
+not counted against the budget.`
+
+	b.registerBucketFlags(cmd, "The ID of the bucket to report the memory of", "The name of the bucket to report the memory of")
+	cmd.Flags().BoolVar(&b.measurements, "measurements", false, "Report the memory used by each measurement, largest first")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdStorageBuilder) cmdMemoryRunEFn(cmd *cobra.Command, args []string) error {
+	if b.bucketID != "" && b.bucketName != "" {
+		return fmt.Errorf("must specify at most one of bucket-id or bucket")
+	}
+
+	storageSVC, bktSVC, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	var filter influxdb.StorageShardFilter
+	if b.bucketID != "" || b.bucketName != "" {
+		if filter.BucketID, err = b.findBucketID(ctx, bktSVC); err != nil {
+			return err
+		}
+	}
+
+	usages, err := storageSVC.ListMemoryUsage(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve memory usage: %v", err)
+	}
+
+	if b.measurements {
+		return b.printMeasurementsMemoryUsage(usages)
+	}
+	return b.printMemoryUsage(usages)
+}
+
+func (b *cmdStorageBuilder) registerBucketFlags(cmd *cobra.Command, idDesc, nameDesc string) {
+	cmd.Flags().StringVarP(&b.bucketID, "bucket-id", "", "", idDesc)
+	cmd.Flags().StringVarP(&b.bucketName, "bucket", "b", "", nameDesc+", org or org-id will be required by choosing this")
+	b.org.register(b.viper, cmd, false)
+}
+
+// findBucketID returns the ID of the bucket of the bucket-id or bucket flags.
+func (b *cmdStorageBuilder) findBucketID(ctx context.Context, bktSVC influxdb.BucketService) (*platform.ID, error) {
+	switch {
+	case b.bucketID != "" && b.bucketName != "":
+		return nil, fmt.Errorf("must specify at most one of bucket-id or bucket")
+	case b.bucketID != "":
+		id, err := platform.IDFromString(b.bucketID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode bucket id %q: %v", b.bucketID, err)
+		}
+		return id, nil
+	case b.bucketName != "":
+		if err := b.org.validOrgFlags(b.globalFlags); err != nil {
+			return nil, err
+		}
+		var err error
+		bktFilter := influxdb.BucketFilter{Name: &b.bucketName}
+		if b.org.id != "" {
+			if bktFilter.OrganizationID, err = platform.IDFromString(b.org.id); err != nil {
+				return nil, fmt.Errorf("failed to decode org id %q: %v", b.org.id, err)
+			}
+		} else {
+			bktFilter.Org = &b.org.name
+		}
+		bkt, err := bktSVC.FindBucket(ctx, bktFilter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find bucket %q: %v", b.bucketName, err)
+		}
+		return &bkt.ID, nil
+	default:
+		return nil, fmt.Errorf("must specify one of bucket-id or bucket")
+	}
+}
+
+func (b *cmdStorageBuilder) newCmd(use string, runE func(*cobra.Command, []string) error) *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd(use, runE, true)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	return cmd
+}
+
+func (b *cmdStorageBuilder) registerPrintFlags(cmd *cobra.Command) {
+	registerPrintOptions(b.viper, cmd, &b.hideHeaders, &b.json)
+}
+
+func (b *cmdStorageBuilder) printShards(shards ...*influxdb.StorageShard) error {
+	if b.json {
+		return b.writeJSON(shards)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	w.WriteHeaders("ID", "Bucket ID", "Start", "End", "Size", "Series", "TSM Files", "Levels", "State", "Tier")
+	for _, s := range shards {
+		w.Write(map[string]interface{}{
+			"ID":        s.ID,
+			"Bucket ID": s.BucketID.String(),
+			"Start":     s.StartTime.Format(time.RFC3339),
+			"End":       s.EndTime.Format(time.RFC3339),
+			"Size":      s.Size,
+			"Series":    s.SeriesN,
+			"TSM Files": len(s.TSMFiles),
+			"Levels":    formatTSMLevels(s.TSMFiles),
+			"State":     string(s.State),
+			"Tier":      string(s.Tier),
+		})
+	}
+
+	return nil
+}
+
+func (b *cmdStorageBuilder) printTieringPolicy(policy *influxdb.StorageTieringPolicy) error {
+	if b.json {
+		return b.writeJSON(policy)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	coldAfter := "none"
+	if policy.ColdAfter.Duration > 0 {
+		coldAfter = policy.ColdAfter.String()
+	}
+
+	w.WriteHeaders("Bucket ID", "Cold After")
+	w.Write(map[string]interface{}{
+		"Bucket ID":  policy.BucketID.String(),
+		"Cold After": coldAfter,
+	})
+
+	return nil
+}
+
+func (b *cmdStorageBuilder) printDurabilityPolicy(policy *influxdb.StorageDurabilityPolicy) error {
+	if b.json {
+		return b.writeJSON(policy)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	mode, fsyncDelay, snapshotInterval := policy.Mode, "", ""
+	switch mode {
+	case influxdb.DurabilityDefault:
+		mode = "default"
+	case influxdb.DurabilityGroupCommit:
+		fsyncDelay = policy.FsyncDelay.String()
+	case influxdb.DurabilityNoWAL:
+		snapshotInterval = "none"
+		if policy.SnapshotInterval.Duration > 0 {
+			snapshotInterval = policy.SnapshotInterval.String()
+		}
+	}
+
+	w.WriteHeaders("Bucket ID", "Mode", "Fsync Delay", "Snapshot Interval")
+	w.Write(map[string]interface{}{
+		"Bucket ID":         policy.BucketID.String(),
+		"Mode":              mode,
+		"Fsync Delay":       fsyncDelay,
+		"Snapshot Interval": snapshotInterval,
+	})
+
+	return nil
+}
+
+func (b *cmdStorageBuilder) printCompactions(compactions *influxdb.StorageCompactions) error {
+	if b.json {
+		return b.writeJSON(compactions)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	w.WriteHeaders("Shard ID", "Bucket ID", "Level", "Files", "Size", "State", "Started", "Progress")
+	write := func(c *influxdb.StorageCompaction, state string) {
+		var started, progress string
+		if c.StartTime != nil {
+			started = c.StartTime.Format(time.RFC3339)
+			progress = formatCompactionProgress(c)
+		}
+		w.Write(map[string]interface{}{
+			"Shard ID":  c.ShardID,
+			"Bucket ID": c.BucketID.String(),
+			"Level":     c.Level,
+			"Files":     len(c.Files),
+			"Size":      c.Size,
+			"State":     state,
+			"Started":   started,
+			"Progress":  progress,
+		})
+	}
+	for _, c := range compactions.InProgress {
+		write(c, "running")
+	}
+	for _, c := range compactions.Queued {
+		write(c, "queued")
+	}
+
+	return nil
+}
+
+func (b *cmdStorageBuilder) printCompactionLimits(limits *influxdb.StorageCompactionLimits) error {
+	if b.json {
+		return b.writeJSON(limits)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	throughput, burst := "unlimited", "unlimited"
+	if limits.Throughput > 0 {
+		throughput = strconv.FormatInt(limits.Throughput, 10)
+		burst = strconv.FormatInt(limits.ThroughputBurst, 10)
+	}
+
+	w.WriteHeaders("Throughput", "Throughput Burst", "Max Concurrent")
+	w.Write(map[string]interface{}{
+		"Throughput":       throughput,
+		"Throughput Burst": burst,
+		"Max Concurrent":   limits.MaxConcurrent,
+	})
+
+	return nil
+}
+
+func (b *cmdStorageBuilder) printMemoryUsage(usages []*influxdb.StorageBucketMemoryUsage) error {
+	if b.json {
+		return b.writeJSON(usages)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	w.WriteHeaders("Bucket ID", "Shards", "Heap", "Budget", "Index", "Series ID Set Cache", "Cache", "TSM Index", "Series File Index", "Series File Mmap")
+	for _, u := range usages {
+		budget := "unlimited"
+		if u.Budget > 0 {
+			budget = strconv.FormatInt(u.Budget, 10)
+		}
+		w.Write(map[string]interface{}{
+			"Bucket ID":           u.BucketID.String(),
+			"Shards":              len(u.Shards),
+			"Heap":                u.Heap,
+			"Budget":              budget,
+			"Index":               u.Index,
+			"Series ID Set Cache": u.SeriesIDSetCache,
+			"Cache":               u.Cache,
+			"TSM Index":           u.TSMIndex,
+			"Series File Index":   u.SeriesFile.Index,
+			"Series File Mmap":    u.SeriesFile.Mmap,
+		})
+	}
+
+	return nil
+}
+
+func (b *cmdStorageBuilder) printMeasurementsMemoryUsage(usages []*influxdb.StorageBucketMemoryUsage) error {
+	if b.json {
+		return b.writeJSON(usages)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+
+	w.WriteHeaders("Bucket ID", "Measurement", "Index", "Series ID Set Cache", "Cache", "TSM Index")
+	for _, u := range usages {
+		for _, m := range u.Measurements {
+			w.Write(map[string]interface{}{
+				"Bucket ID":           u.BucketID.String(),
+				"Measurement":         m.Name,
+				"Index":               m.Index,
+				"Series ID Set Cache": m.SeriesIDSetCache,
+				"Cache":               m.Cache,
+				"TSM Index":           m.TSMIndex,
+			})
+		}
+	}
+
+	return nil
+}
+
+// formatCompactionProgress returns the bytes written by a compaction in
+// progress as a percentage of the size of its files. The written blocks
+// don't include the index of the files, so the percentage is an estimate.
+func formatCompactionProgress(c *influxdb.StorageCompaction) string {
+	if c.Size <= 0 {
+		return ""
+	}
+	percent := c.BytesWritten * 100 / c.Size
+	if percent > 100 {
+		percent = 100
+	}
+	return strconv.FormatInt(percent, 10) + "%"
+}
+
+// formatTSMLevels returns the number of TSM files per compaction level,
+// for instance "1:4 2:1" for four level 1 files and one level 2 file.
+func formatTSMLevels(files []influxdb.StorageTSMFile) string {
+	counts := make(map[int]int)
+	for _, f := range files {
+		counts[f.Level]++
+	}
+
+	levels := make([]int, 0, len(counts))
+	for level := range counts {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+
+	parts := make([]string, 0, len(levels))
+	for _, level := range levels {
+		parts = append(parts, strconv.Itoa(level)+":"+strconv.Itoa(counts[level]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func newStorageSVCs() (influxdb.StorageService, influxdb.BucketService, error) {
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &http.StorageService{Client: httpClient}, &tenant.BucketClientService{Client: httpClient}, nil
+}
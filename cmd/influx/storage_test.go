@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdStorage(t *testing.T) {
+	bucketID := platform.ID(2)
+	shard := &influxdb.StorageShard{
+		ID:        7,
+		BucketID:  bucketID,
+		StartTime: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2000, 1, 8, 0, 0, 0, 0, time.UTC),
+		Size:      1024,
+		SeriesN:   3,
+		State:     influxdb.StorageShardCold,
+		TSMFiles: []influxdb.StorageTSMFile{
+			{Name: "000000001-000000001.tsm", Level: 1},
+			{Name: "000000002-000000001.tsm", Level: 1},
+			{Name: "000000004-000000003.tsm", Level: 3},
+		},
+	}
+
+	cmdFn := func(svc *fakeStorageSVC) func(*globalFlags, genericCLIOpts) *cobra.Command {
+		bktSVC := mock.NewBucketService()
+		bktSVC.FindBucketFn = func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			return &influxdb.Bucket{ID: bucketID, Name: *f.Name}, nil
+		}
+		svcFn := func() (influxdb.StorageService, influxdb.BucketService, error) {
+			return svc, bktSVC, nil
+		}
+		return func(g *globalFlags, opt genericCLIOpts) *cobra.Command {
+			return newCmdStorageBuilder(svcFn, g, opt).cmd()
+		}
+	}
+
+	t.Run("list", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			flags    []string
+			envVars  map[string]string
+			expected *platform.ID
+		}{
+			{
+				name:    "all buckets",
+				envVars: envVarsZeroMap,
+			},
+			{
+				name:     "bucket id",
+				flags:    []string{"--bucket-id=" + bucketID.String()},
+				envVars:  envVarsZeroMap,
+				expected: &bucketID,
+			},
+			{
+				name:     "bucket name",
+				flags:    []string{"--bucket=b1", "--org=rg"},
+				envVars:  envVarsZeroMap,
+				expected: &bucketID,
+			},
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				defer addEnvVars(t, tt.envVars)()
+
+				svc := &fakeStorageSVC{shard: shard}
+				w := new(bytes.Buffer)
+				builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+				cmd := builder.cmd(cmdFn(svc))
+				cmd.SetArgs(append([]string{"storage", "shards", "list"}, tt.flags...))
+
+				require.NoError(t, cmd.Execute())
+				assert.Equal(t, tt.expected, svc.filter.BucketID)
+				assert.Contains(t, w.String(), "1:2 3:1")
+				assert.Contains(t, w.String(), "cold")
+			}
+
+			t.Run(tt.name, fn)
+		}
+	})
+
+	t.Run("actions", func(t *testing.T) {
+		for _, action := range []string{"compact", "snapshot", "rebuild-index", "move-to-cold"} {
+			fn := func(t *testing.T) {
+				svc := &fakeStorageSVC{shard: shard}
+				builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(ioutil.Discard))
+				cmd := builder.cmd(cmdFn(svc))
+				cmd.SetArgs([]string{"storage", "shards", action, "--id=7"})
+
+				require.NoError(t, cmd.Execute())
+				assert.Equal(t, []string{action + " 7"}, svc.calls)
+			}
+
+			t.Run(action, fn)
+		}
+	})
+
+	t.Run("tiering", func(t *testing.T) {
+		t.Run("find", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard, coldAfter: 30 * 24 * time.Hour}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "tiering", "find", "--bucket-id=" + bucketID.String()})
+
+			require.NoError(t, cmd.Execute())
+			assert.Contains(t, w.String(), bucketID.String())
+			assert.Contains(t, w.String(), "720h0m0s")
+		})
+
+		t.Run("update", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "tiering", "update", "--bucket=b1", "--org=rg", "--cold-after=7d"})
+
+			require.NoError(t, cmd.Execute())
+			assert.Equal(t, 7*24*time.Hour, svc.coldAfter)
+			assert.Contains(t, w.String(), "168h0m0s")
+		})
+
+		t.Run("update requires a bucket", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(ioutil.Discard))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "tiering", "update", "--cold-after=7d"})
+
+			require.Error(t, cmd.Execute())
+		})
+	})
+
+	t.Run("durability", func(t *testing.T) {
+		t.Run("find", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "durability", "find", "--bucket-id=" + bucketID.String()})
+
+			require.NoError(t, cmd.Execute())
+			assert.Contains(t, w.String(), bucketID.String())
+			assert.Contains(t, w.String(), "default")
+		})
+
+		t.Run("update", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "durability", "update", "--bucket=b1", "--org=rg", "--mode=group-commit", "--fsync-delay=10ms"})
+
+			require.NoError(t, cmd.Execute())
+			assert.Equal(t, influxdb.StorageDurabilityPolicy{
+				BucketID:   bucketID,
+				Mode:       influxdb.DurabilityGroupCommit,
+				FsyncDelay: influxdb.Duration{Duration: 10 * time.Millisecond},
+			}, svc.durability)
+			assert.Contains(t, w.String(), "10ms")
+		})
+
+		t.Run("update default", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard, durability: influxdb.StorageDurabilityPolicy{Mode: influxdb.DurabilityFsync}}
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(ioutil.Discard))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "durability", "update", "--bucket-id=" + bucketID.String(), "--mode=default"})
+
+			require.NoError(t, cmd.Execute())
+			assert.Equal(t, influxdb.DurabilityDefault, svc.durability.Mode)
+		})
+
+		t.Run("update rejects unknown modes", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(ioutil.Discard))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "durability", "update", "--bucket-id=" + bucketID.String(), "--mode=async"})
+
+			require.Error(t, cmd.Execute())
+		})
+	})
+
+	t.Run("compactions", func(t *testing.T) {
+		t.Run("list", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "compactions", "list", "--bucket-id=" + bucketID.String()})
+
+			require.NoError(t, cmd.Execute())
+			assert.Equal(t, &bucketID, svc.filter.BucketID)
+			assert.Contains(t, w.String(), "running")
+			assert.Contains(t, w.String(), "2000-01-08T12:00:00Z")
+			assert.Contains(t, w.String(), "25%")
+			assert.Contains(t, w.String(), "queued")
+		})
+
+		t.Run("limits find", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard, limits: influxdb.StorageCompactionLimits{MaxConcurrent: 2}}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "compactions", "limits", "find"})
+
+			require.NoError(t, cmd.Execute())
+			assert.Contains(t, w.String(), "unlimited")
+		})
+
+		t.Run("limits update", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard, limits: influxdb.StorageCompactionLimits{MaxConcurrent: 2}}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "compactions", "limits", "update", "--throughput=1048576", "--throughput-burst=4194304"})
+
+			require.NoError(t, cmd.Execute())
+			assert.Equal(t, influxdb.StorageCompactionLimits{Throughput: 1048576, ThroughputBurst: 4194304, MaxConcurrent: 2}, svc.limits)
+			assert.Contains(t, w.String(), "4194304")
+		})
+
+		t.Run("limits update requires a limit", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(ioutil.Discard))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "compactions", "limits", "update"})
+
+			require.Error(t, cmd.Execute())
+		})
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		t.Run("buckets", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "memory", "--bucket-id=" + bucketID.String()})
+
+			require.NoError(t, cmd.Execute())
+			assert.Equal(t, &bucketID, svc.filter.BucketID)
+			assert.Contains(t, w.String(), "Series File Mmap")
+			assert.Contains(t, w.String(), "4096")
+			assert.Contains(t, w.String(), "unlimited")
+			assert.NotContains(t, w.String(), "cpu")
+		})
+
+		t.Run("measurements", func(t *testing.T) {
+			svc := &fakeStorageSVC{shard: shard}
+			w := new(bytes.Buffer)
+			builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+			cmd := builder.cmd(cmdFn(svc))
+			cmd.SetArgs([]string{"storage", "memory", "--measurements"})
+
+			require.NoError(t, cmd.Execute())
+			assert.Nil(t, svc.filter.BucketID)
+			assert.Contains(t, w.String(), "cpu")
+			assert.Contains(t, w.String(), "mem")
+		})
+	})
+}
+
+type fakeStorageSVC struct {
+	shard      *influxdb.StorageShard
+	filter     influxdb.StorageShardFilter
+	calls      []string
+	imported   []string
+	coldAfter  time.Duration
+	limits     influxdb.StorageCompactionLimits
+	durability influxdb.StorageDurabilityPolicy
+	reshards   []*influxdb.StorageReshardStep
+}
+
+func (f *fakeStorageSVC) ListShards(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageShard, error) {
+	f.filter = filter
+	return []*influxdb.StorageShard{f.shard}, nil
+}
+
+func (f *fakeStorageSVC) FindShardByID(ctx context.Context, id uint64) (*influxdb.StorageShard, error) {
+	return f.shard, nil
+}
+
+func (f *fakeStorageSVC) CompactShard(ctx context.Context, id uint64) error {
+	f.calls = append(f.calls, "compact "+strconv.FormatUint(id, 10))
+	return nil
+}
+
+func (f *fakeStorageSVC) SnapshotShard(ctx context.Context, id uint64) error {
+	f.calls = append(f.calls, "snapshot "+strconv.FormatUint(id, 10))
+	return nil
+}
+
+func (f *fakeStorageSVC) RebuildShardIndex(ctx context.Context, id uint64) error {
+	f.calls = append(f.calls, "rebuild-index "+strconv.FormatUint(id, 10))
+	return nil
+}
+
+func (f *fakeStorageSVC) ExportShard(ctx context.Context, id uint64, start, stop time.Time, w io.Writer) error {
+	_, err := fmt.Fprintf(w, "shard %d from %s to %s", id, start.Format(time.RFC3339), stop.Format(time.RFC3339))
+	return err
+}
+
+func (f *fakeStorageSVC) ImportShard(ctx context.Context, bucketID platform.ID, start, stop time.Time, r io.Reader) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.imported = append(f.imported, bucketID.String()+": "+string(buf))
+	return nil
+}
+
+func (f *fakeStorageSVC) MoveShardToColdTier(ctx context.Context, id uint64) error {
+	f.calls = append(f.calls, "move-to-cold "+strconv.FormatUint(id, 10))
+	return nil
+}
+
+func (f *fakeStorageSVC) FindTieringPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageTieringPolicy, error) {
+	return &influxdb.StorageTieringPolicy{
+		BucketID:  bucketID,
+		ColdAfter: influxdb.Duration{Duration: f.coldAfter},
+	}, nil
+}
+
+func (f *fakeStorageSVC) UpdateTieringPolicy(ctx context.Context, policy influxdb.StorageTieringPolicy) (*influxdb.StorageTieringPolicy, error) {
+	f.coldAfter = policy.ColdAfter.Duration
+	return f.FindTieringPolicy(ctx, policy.BucketID)
+}
+
+func (f *fakeStorageSVC) ListCompactions(ctx context.Context, filter influxdb.StorageShardFilter) (*influxdb.StorageCompactions, error) {
+	f.filter = filter
+	start := time.Date(2000, 1, 8, 12, 0, 0, 0, time.UTC)
+	return &influxdb.StorageCompactions{
+		Queued: []*influxdb.StorageCompaction{
+			{ShardID: f.shard.ID, BucketID: f.shard.BucketID, Level: 1, Files: []string{"000000005-000000001.tsm"}, Size: 512},
+		},
+		InProgress: []*influxdb.StorageCompaction{
+			{ShardID: f.shard.ID, BucketID: f.shard.BucketID, Level: 2, Files: []string{"000000001-000000001.tsm", "000000002-000000001.tsm"}, Size: 1024, StartTime: &start, BytesWritten: 256},
+		},
+	}, nil
+}
+
+func (f *fakeStorageSVC) FindCompactionLimits(ctx context.Context) (*influxdb.StorageCompactionLimits, error) {
+	limits := f.limits
+	return &limits, nil
+}
+
+func (f *fakeStorageSVC) UpdateCompactionLimits(ctx context.Context, upd influxdb.StorageCompactionLimitsUpdate) (*influxdb.StorageCompactionLimits, error) {
+	if upd.Throughput != nil {
+		f.limits.Throughput = *upd.Throughput
+	}
+	if upd.ThroughputBurst != nil {
+		f.limits.ThroughputBurst = *upd.ThroughputBurst
+	}
+	if upd.MaxConcurrent != nil {
+		f.limits.MaxConcurrent = *upd.MaxConcurrent
+	}
+	return f.FindCompactionLimits(ctx)
+}
+
+func (f *fakeStorageSVC) FindDurabilityPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageDurabilityPolicy, error) {
+	policy := f.durability
+	policy.BucketID = bucketID
+	return &policy, nil
+}
+
+func (f *fakeStorageSVC) UpdateDurabilityPolicy(ctx context.Context, policy influxdb.StorageDurabilityPolicy) (*influxdb.StorageDurabilityPolicy, error) {
+	f.durability = policy
+	return f.FindDurabilityPolicy(ctx, policy.BucketID)
+}
+
+func (f *fakeStorageSVC) ListMemoryUsage(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageBucketMemoryUsage, error) {
+	f.filter = filter
+	return []*influxdb.StorageBucketMemoryUsage{
+		{
+			BucketID:           f.shard.BucketID,
+			StorageMemoryUsage: influxdb.StorageMemoryUsage{Index: 2048, Cache: 1024, TSMIndex: 512},
+			Heap:               3072,
+			SeriesFile:         influxdb.StorageSeriesFileMemoryUsage{Index: 256, Mmap: 4096},
+			Shards: []*influxdb.StorageShardMemoryUsage{
+				{ShardID: f.shard.ID, StorageMemoryUsage: influxdb.StorageMemoryUsage{Index: 2048, Cache: 1024, TSMIndex: 512}},
+			},
+			Measurements: []*influxdb.StorageMeasurementMemoryUsage{
+				{Name: "cpu", StorageMemoryUsage: influxdb.StorageMemoryUsage{Index: 1536, Cache: 1024, TSMIndex: 256}},
+				{Name: "mem", StorageMemoryUsage: influxdb.StorageMemoryUsage{Index: 512, TSMIndex: 256}},
+			},
+		},
+	}, nil
+}
+
+func (f *fakeStorageSVC) ReshardBucket(ctx context.Context, bucketID platform.ID) (*influxdb.StorageReshardStep, error) {
+	f.calls = append(f.calls, "reshard "+bucketID.String())
+	if len(f.reshards) == 0 {
+		return &influxdb.StorageReshardStep{BucketID: bucketID}, nil
+	}
+	step := f.reshards[0]
+	f.reshards = f.reshards[1:]
+	return step, nil
+}
@@ -1038,7 +1038,7 @@ func (b *cmdTemplateBuilder) readRawTemplatesFromFiles(filePaths []string, recur
 
 	var rawTemplates []*pkger.Template
 	for f := range mFiles {
-		template, err := pkger.Parse(b.convertFileEncoding(f), pkger.FromFile(f), pkger.ValidSkipParseError())
+		template, err := pkger.ParseWithLocalDependencies(b.convertFileEncoding(f), pkger.FromFile(f), pkger.ValidSkipParseError())
 		if err != nil {
 			return nil, err
 		}
@@ -1100,7 +1100,7 @@ func (b *cmdTemplateBuilder) readTemplate() (*pkger.Template, bool, error) {
 		return template, false, err
 	}
 
-	stdinTemplate, err := pkger.Parse(b.convertEncoding(), pkger.FromReader(b.in), pkger.ValidSkipParseError())
+	stdinTemplate, err := pkger.ParseWithLocalDependencies(b.convertEncoding(), pkger.FromReader(b.in), pkger.ValidSkipParseError())
 	if err != nil {
 		return nil, true, err
 	}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -13,24 +16,29 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
 
 	"github.com/fujiwara/shapeio"
 	platform "github.com/influxdata/influxdb/v2"
 	ihttp "github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/http/points"
 	"github.com/influxdata/influxdb/v2/kit/signals"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/csv2lp"
 	"github.com/influxdata/influxdb/v2/write"
+	"github.com/klauspost/compress/zstd"
 	"github.com/spf13/cobra"
 )
 
 const (
 	inputFormatCsv          = "csv"
 	inputFormatLineProtocol = "lp"
+	inputFormatJSON         = "json"
 	inputCompressionNone    = "none"
 	inputCompressionGzip    = "gzip"
+	inputCompressionZstd    = "zstd"
 )
 
 type buildWriteSvcFn func(builder *writeFlagsBuilder) platform.WriteService
@@ -58,6 +66,11 @@ type writeFlagsBuilder struct {
 	ErrorsFile                 string
 	RateLimit                  string
 	Compression                string
+	RequestCompression         string
+
+	// errorsFile receives the lines rejected by the server, set when the
+	// line reader is created with an errors file.
+	errorsFile io.Writer
 }
 
 func newWriteFlagsBuilder(svcFn buildWriteSvcFn, f *globalFlags, opt genericCLIOpts) *writeFlagsBuilder {
@@ -75,17 +88,75 @@ func cmdWrite(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 
 func newBatchingWriteService(b *writeFlagsBuilder) platform.WriteService {
 	ac := b.config()
+	var svc platform.WriteService = &ihttp.WriteService{
+		Addr:               ac.Host,
+		Token:              ac.Token,
+		Precision:          b.Precision,
+		InsecureSkipVerify: b.skipVerify,
+		Partial:            b.errorsFile != nil,
+		Compression:        b.RequestCompression,
+	}
+	if b.errorsFile != nil {
+		svc = &errorsFileWriteService{WriteService: svc, w: b.errorsFile}
+	}
 	return &write.Batcher{
-		Service: &ihttp.WriteService{
-			Addr:               ac.Host,
-			Token:              ac.Token,
-			Precision:          b.Precision,
-			InsecureSkipVerify: b.skipVerify,
-		},
+		Service:       svc,
 		MaxLineLength: b.MaxLineLength,
 	}
 }
 
+// errorsFileWriteService writes the lines of a batch rejected by a partial
+// write of the underlying service to the errors file, and continues.
+type errorsFileWriteService struct {
+	platform.WriteService
+	w io.Writer
+}
+
+func (s *errorsFileWriteService) WriteTo(ctx context.Context, filter platform.BucketFilter, r io.Reader) error {
+	batch, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	err = s.WriteService.WriteTo(ctx, filter, bytes.NewReader(batch))
+	var rerr *ihttp.RejectedLinesError
+	if !errors.As(err, &rerr) {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, l := range rerr.Lines {
+		msg := fmt.Sprintf("%s: %s", l.Reason, l.Message)
+		log.Println(msg)
+		fmt.Fprintf(&buf, "# error : %s\n", msg)
+		if line := batchLine(batch, l.Line); line != nil {
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+	}
+	// a single write, the rows skipped by the CSV reader are written concurrently
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		log.Printf("Unable to write to error-file: %v\n", err)
+	}
+	return nil
+}
+
+// batchLine returns the line of protocol starting at line number n of the
+// batch, which may span several lines when a string field contains newlines.
+func batchLine(batch []byte, n int) []byte {
+	if n < 1 {
+		return nil
+	}
+	start := 0
+	for ; n > 1; n-- {
+		i := bytes.IndexByte(batch[start:], '\n')
+		if i < 0 {
+			return nil
+		}
+		start += i + 1
+	}
+	return batch[start:models.LineEnd(batch, start)]
+}
+
 func (b *writeFlagsBuilder) cmd() *cobra.Command {
 	cmd := b.newCmd("write", b.writeRunE, true)
 	cmd.Args = cobra.MaximumNArgs(1)
@@ -119,7 +190,7 @@ func (b *writeFlagsBuilder) cmd() *cobra.Command {
 		},
 	}
 	opts.mustRegister(b.viper, cmd)
-	cmd.PersistentFlags().StringVar(&b.Format, "format", "", "Input format, either lp (Line Protocol) or csv (Comma Separated Values). Defaults to lp unless '.csv' extension")
+	cmd.PersistentFlags().StringVar(&b.Format, "format", "", "Input format, either lp (Line Protocol), csv (Comma Separated Values) or json (array of JSON points). Defaults to lp unless '.csv' or '.json' extension")
 	cmd.PersistentFlags().StringArrayVar(&b.Headers, "header", []string{}, "Header prepends lines to input data; Example --header HEADER1 --header HEADER2")
 	cmd.PersistentFlags().StringArrayVarP(&b.Files, "file", "f", []string{}, "The path to the file to import")
 	cmd.PersistentFlags().StringArrayVarP(&b.URLs, "url", "u", []string{}, "The URL to import data from")
@@ -131,9 +202,10 @@ func (b *writeFlagsBuilder) cmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(&b.IgnoreDataTypeInColumnName, "xIgnoreDataTypeInColumnName", false, "Ignores dataType which could be specified after ':' in column name")
 	cmd.PersistentFlags().MarkHidden("xIgnoreDataTypeInColumnName") // should be used only upon explicit advice
 	cmd.PersistentFlags().StringVar(&b.Encoding, "encoding", "UTF-8", "Character encoding of input files or stdin")
-	cmd.PersistentFlags().StringVar(&b.ErrorsFile, "errors-file", "", "The path to the file to write rejected rows to")
+	cmd.PersistentFlags().StringVar(&b.ErrorsFile, "errors-file", "", "The path to the file to write rejected rows to, including the lines rejected by the server")
 	cmd.PersistentFlags().StringVar(&b.RateLimit, "rate-limit", "", "Throttles write, examples: \"5 MB / 5 min\" , \"17kBs\". \"\" (default) disables throttling.")
-	cmd.PersistentFlags().StringVar(&b.Compression, "compression", "", "Input compression, either 'none' or 'gzip'. Defaults to 'none' unless an input has a '.gz' extension")
+	cmd.PersistentFlags().StringVar(&b.Compression, "compression", "", "Input compression, either 'none', 'gzip' or 'zstd'. Defaults to 'none' unless an input has a '.gz' or '.zst' extension")
+	cmd.PersistentFlags().StringVar(&b.RequestCompression, "request-compression", "gzip", "Compression of the data sent to the server, either 'none', 'gzip' or 'zstd'")
 
 	cmdDryRun := b.newCmd("dryrun", b.writeDryrunE, false)
 	cmdDryRun.Args = cobra.MaximumNArgs(1)
@@ -163,11 +235,11 @@ func (b *writeFlagsBuilder) createLineReader(ctx context.Context, cmd *cobra.Com
 	closers := make([]io.Closer, 0, len(files)+len(b.URLs))
 
 	// validate input format
-	if len(b.Format) > 0 && b.Format != inputFormatLineProtocol && b.Format != inputFormatCsv {
+	if len(b.Format) > 0 && b.Format != inputFormatLineProtocol && b.Format != inputFormatCsv && b.Format != inputFormatJSON {
 		return nil, csv2lp.MultiCloser(closers...), fmt.Errorf("unsupported input format: %s", b.Format)
 	}
 	// validate input compression
-	if len(b.Compression) > 0 && b.Compression != inputCompressionNone && b.Compression != inputCompressionGzip {
+	if len(b.Compression) > 0 && b.Compression != inputCompressionNone && b.Compression != inputCompressionGzip && b.Compression != inputCompressionZstd {
 		return nil, csv2lp.MultiCloser(closers...), fmt.Errorf("unsupported input compression: %s", b.Compression)
 	}
 
@@ -179,14 +251,22 @@ func (b *writeFlagsBuilder) createLineReader(ctx context.Context, cmd *cobra.Com
 
 	// utility to manage common steps used to decode / decompress input sources,
 	// while tracking resources that must be cleaned-up after reading.
-	addReader := func(r io.Reader, name string, compressed bool) error {
-		if compressed {
+	addReader := func(r io.Reader, name string, compression string) error {
+		switch compression {
+		case inputCompressionGzip:
 			rcz, err := gzip.NewReader(r)
 			if err != nil {
 				return fmt.Errorf("failed to decompress %s: %w", name, err)
 			}
 			closers = append(closers, rcz)
 			r = rcz
+		case inputCompressionZstd:
+			rcz, err := zstd.NewReader(r)
+			if err != nil {
+				return fmt.Errorf("failed to decompress %s: %w", name, err)
+			}
+			closers = append(closers, rcz.IOReadCloser())
+			r = rcz
 		}
 		readers = append(readers, decode(r), strings.NewReader("\n"))
 		return nil
@@ -212,15 +292,19 @@ func (b *writeFlagsBuilder) createLineReader(ctx context.Context, cmd *cobra.Com
 			closers = append(closers, f)
 
 			fname := file
-			compressed := b.Compression == "gzip" || (len(b.Compression) == 0 && strings.HasSuffix(fname, ".gz"))
-			if compressed {
-				fname = strings.TrimSuffix(fname, ".gz")
+			compression := b.Compression
+			if len(compression) == 0 {
+				compression = compressionFromExtension(fname)
 			}
+			fname = strings.TrimSuffix(fname, compressionExtensions[compression])
 			if len(b.Format) == 0 && strings.HasSuffix(fname, ".csv") {
 				b.Format = inputFormatCsv
 			}
+			if len(b.Format) == 0 && strings.HasSuffix(fname, ".json") {
+				b.Format = inputFormatJSON
+			}
 
-			if err = addReader(f, file, compressed); err != nil {
+			if err = addReader(f, file, compression); err != nil {
 				return nil, csv2lp.MultiCloser(closers...), err
 			}
 		}
@@ -248,18 +332,24 @@ func (b *writeFlagsBuilder) createLineReader(ctx context.Context, cmd *cobra.Com
 				return nil, csv2lp.MultiCloser(closers...), fmt.Errorf("failed to open %q: response status_code=%d", addr, resp.StatusCode)
 			}
 
-			compressed := b.Compression == "gzip" ||
-				resp.Header.Get("Content-Encoding") == "gzip" ||
-				(len(b.Compression) == 0 && strings.HasSuffix(u.Path, ".gz"))
-			if compressed {
-				u.Path = strings.TrimSuffix(u.Path, ".gz")
+			compression := b.Compression
+			if len(compression) == 0 {
+				compression = compressionFromExtension(u.Path)
+			}
+			if resp.Header.Get("Content-Encoding") == "gzip" {
+				compression = inputCompressionGzip
 			}
+			u.Path = strings.TrimSuffix(u.Path, compressionExtensions[compression])
 			if len(b.Format) == 0 &&
 				(strings.HasSuffix(u.Path, ".csv") || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv")) {
 				b.Format = inputFormatCsv
 			}
+			if len(b.Format) == 0 &&
+				(strings.HasSuffix(u.Path, ".json") || strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json")) {
+				b.Format = inputFormatJSON
+			}
 
-			if err = addReader(resp.Body, addr, compressed); err != nil {
+			if err = addReader(resp.Body, addr, compression); err != nil {
 				return nil, csv2lp.MultiCloser(closers...), err
 			}
 		}
@@ -270,17 +360,17 @@ func (b *writeFlagsBuilder) createLineReader(ctx context.Context, cmd *cobra.Com
 	case len(args) == 0:
 		// use also stdIn if it is a terminal
 		if !isCharacterDevice(cmd.InOrStdin()) {
-			if err = addReader(cmd.InOrStdin(), "stdin", b.Compression == "gzip"); err != nil {
+			if err = addReader(cmd.InOrStdin(), "stdin", b.Compression); err != nil {
 				return nil, csv2lp.MultiCloser(closers...), err
 			}
 		}
 	case args[0] == "-":
 		// "-" also means stdin
-		if err = addReader(cmd.InOrStdin(), "stdin", b.Compression == "gzip"); err != nil {
+		if err = addReader(cmd.InOrStdin(), "stdin", b.Compression); err != nil {
 			return nil, csv2lp.MultiCloser(closers...), err
 		}
 	default:
-		if err = addReader(strings.NewReader(args[0]), "arg 0", b.Compression == "gzip"); err != nil {
+		if err = addReader(strings.NewReader(args[0]), "arg 0", b.Compression); err != nil {
 			return nil, csv2lp.MultiCloser(closers...), err
 		}
 	}
@@ -306,6 +396,7 @@ func (b *writeFlagsBuilder) createLineReader(ctx context.Context, cmd *cobra.Com
 			return nil, csv2lp.MultiCloser(closers...), fmt.Errorf("failed to create %q: %v", b.ErrorsFile, err)
 		}
 		closers = append(closers, writer)
+		b.errorsFile = writer
 		errorsFile = csv.NewWriter(writer)
 		rowSkippedListener = func(source *csv2lp.CsvToLineReader, lineError error, row []string) {
 			log.Println(lineError)
@@ -330,6 +421,9 @@ func (b *writeFlagsBuilder) createLineReader(ctx context.Context, cmd *cobra.Com
 		csvReader.RowSkipped = rowSkippedListener
 		r = csvReader
 	}
+	if b.Format == inputFormatJSON {
+		r = &jsonToLineReader{dec: points.NewJSONDecoder(r, b.Precision, time.Time{}), precision: b.Precision}
+	}
 	// throttle reader if requested
 	rateLimit, err := ToBytesPerSecond(b.RateLimit)
 	if err != nil {
@@ -347,6 +441,37 @@ func (b *writeFlagsBuilder) createLineReader(ctx context.Context, cmd *cobra.Com
 	return r, csv2lp.MultiCloser(closers...), nil
 }
 
+// jsonToLineReader converts points of the JSON write format into line
+// protocol in the precision of the write.
+type jsonToLineReader struct {
+	dec       *points.JSONDecoder
+	precision string
+	buf       []byte
+	err       error
+}
+
+func (r *jsonToLineReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		pt, err := r.dec.Next()
+		if err == io.EOF {
+			r.err = io.EOF
+			continue
+		}
+		if err != nil {
+			r.err = fmt.Errorf("invalid JSON points: %w", err)
+			continue
+		}
+		r.buf = append(r.buf[:0], pt.PrecisionString(r.precision)...)
+		r.buf = append(r.buf, '\n')
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
 func (b *writeFlagsBuilder) writeRunE(cmd *cobra.Command, args []string) error {
 	b.dump(args) // print flags when in Debug mode
 	// validate InfluxDB flags
@@ -426,6 +551,23 @@ func (b *writeFlagsBuilder) writeDryrunE(cmd *cobra.Command, args []string) erro
 }
 
 // IsCharacterDevice returns true if the supplied reader is a character device (a terminal)
+// compressionExtensions are the file extensions of the input compressions.
+var compressionExtensions = map[string]string{
+	inputCompressionGzip: ".gz",
+	inputCompressionZstd: ".zst",
+}
+
+// compressionFromExtension returns the input compression of a file name,
+// empty if it has no compressed extension.
+func compressionFromExtension(name string) string {
+	for compression, ext := range compressionExtensions {
+		if strings.HasSuffix(name, ext) {
+			return compression
+		}
+	}
+	return ""
+}
+
 func isCharacterDevice(reader io.Reader) bool {
 	file, isFile := reader.(*os.File)
 	if !isFile {
@@ -18,7 +18,10 @@ import (
 	"testing"
 
 	"github.com/influxdata/influxdb/v2"
+	ihttp "github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/http/points"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/klauspost/compress/zstd"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
@@ -109,9 +112,17 @@ func Test_writeFlags_createLineReader(t *testing.T) {
 		return contents
 	}
 
+	zstdCompress := func(uncompressed string) []byte {
+		enc, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		defer enc.Close()
+		return enc.EncodeAll([]byte(uncompressed), nil)
+	}
+
 	lpContents := "f1 b=f2,c=f3,d=f4"
 	lpFile := createTempFile(t, "txt", []byte(lpContents), false)
 	gzipLpFile := createTempFile(t, "txt.gz", []byte(lpContents), true)
+	zstdLpFile := createTempFile(t, "txt.zst", zstdCompress(lpContents), false)
 	gzipLpFileNoExt := createTempFile(t, "lp", []byte(lpContents), true)
 	stdInLpContents := "stdin3 i=stdin1,j=stdin2,k=stdin4"
 
@@ -121,6 +132,9 @@ func Test_writeFlags_createLineReader(t *testing.T) {
 	gzipCsvFileNoExt := createTempFile(t, "csv", []byte(csvContents), true)
 	stdInCsvContents := "i,j,_measurement,k\nstdin1,stdin2,stdin3,stdin4"
 
+	jsonContents := `[{"measurement": "f1", "fields": {"b": "f2", "c": 3, "d": 4.5}, "timestamp": 1}]`
+	jsonFile := createTempFile(t, "json", []byte(jsonContents), false)
+
 	// use a test HTTP server to provide CSV data
 	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		// fmt.Println(req.URL.String())
@@ -210,6 +224,26 @@ func Test_writeFlags_createLineReader(t *testing.T) {
 				lpContents,
 			},
 		},
+		{
+			name: "read zstd compressed LP data from file ending in .zst",
+			flags: writeFlagsBuilder{
+				Files: []string{zstdLpFile},
+			},
+			firstLineCorrection: 0,
+			lines: []string{
+				lpContents,
+			},
+		},
+		{
+			name: "read zstd compressed LP data from stdin",
+			flags: writeFlagsBuilder{
+				Compression: inputCompressionZstd,
+			},
+			stdIn: bytes.NewReader(zstdCompress(stdInLpContents)),
+			lines: []string{
+				stdInLpContents,
+			},
+		},
 		{
 			name: "read compressed and uncompressed LP data from file in the same call",
 			flags: writeFlagsBuilder{
@@ -491,6 +525,26 @@ func Test_writeFlags_createLineReader(t *testing.T) {
 				lpContents,
 			},
 		},
+		{
+			name: "read data from JSON file + transform to line protocol",
+			flags: writeFlagsBuilder{
+				Files:     []string{jsonFile},
+				Precision: "s",
+			},
+			lines: []string{
+				`f1 b="f2",c=3i,d=4.5 1`,
+			},
+		},
+		{
+			name: "read JSON data from stdin + transform to line protocol",
+			flags: writeFlagsBuilder{
+				Format: inputFormatJSON,
+			},
+			stdIn: strings.NewReader(`[{"measurement": "stdin3", "tags": {"t": "v"}, "fields": {"i": true}}]`),
+			lines: []string{
+				`stdin3,t=v i=true`,
+			},
+		},
 		{
 			name: "read data from CSV file + transform to line protocol + throttle read to 1MB/min",
 			flags: writeFlagsBuilder{
@@ -762,6 +816,31 @@ func Test_writeFlags_errorsFile(t *testing.T) {
 	require.Equal(t, "# error : line 3: column 'a': '1.1' cannot fit into long data type\nm,1.1", strings.Trim(string(errorLines), "\n"))
 }
 
+// Test_errorsFileWriteService tests that lines rejected by the server are written to errors file
+func Test_errorsFileWriteService(t *testing.T) {
+	batch := "m a=1i\nm a=\"x\ny\"\nm a=\n"
+	writeSvc := &mock.WriteService{
+		WriteToF: func(_ context.Context, _ influxdb.BucketFilter, reader io.Reader) error {
+			data, err := ioutil.ReadAll(reader)
+			require.NoError(t, err)
+			require.Equal(t, batch, string(data))
+			return &ihttp.RejectedLinesError{Lines: []points.RejectedLine{
+				{Line: 2, Reason: "field type conflict", Message: "conflict"},
+				{Line: 4, Reason: "parse error", Message: "missing field value"},
+			}}
+		},
+	}
+	var errorsFile bytes.Buffer
+	svc := &errorsFileWriteService{WriteService: writeSvc, w: &errorsFile}
+
+	restoreLogging, _ := overrideLogging()
+	defer restoreLogging()
+	err := svc.WriteTo(context.Background(), influxdb.BucketFilter{}, strings.NewReader(batch))
+	require.NoError(t, err)
+	require.Equal(t, "# error : field type conflict: conflict\nm a=\"x\ny\"\n"+
+		"# error : parse error: missing field value\nm a=\n", errorsFile.String())
+}
+
 func Test_ToBytesPerSecond(t *testing.T) {
 	var tests = []struct {
 		in    string
@@ -0,0 +1,649 @@
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/fs"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// tmpIndexDirectory is where the index of a shard is built before it
+	// replaces the current one.
+	tmpIndexDirectory = ".index"
+	// oldIndexDirectory is where the current index of a shard is moved while
+	// the rebuilt one takes its place.
+	oldIndexDirectory = ".index.old"
+	// tmpSeriesFileSuffix is appended to the path of the series file of a
+	// bucket to build a new one, and oldSeriesFileSuffix to move the current
+	// one out of the way.
+	tmpSeriesFileSuffix = ".tmp"
+	oldSeriesFileSuffix = ".old"
+	// swappedFile is created in the directory of a bucket once all its
+	// rebuilt files are swapped in. The files they replaced are restored by
+	// the next run if it is missing, and removed otherwise.
+	swappedFile = ".build-tsi.swapped"
+)
+
+type buildTSIFlags struct {
+	enginePath     string
+	bucketID       platform.ID
+	shardID        int64
+	dryRun         bool
+	concurrency    int
+	maxLogFileSize int64
+	maxCacheSize   int64
+	batchSize      int
+	logLevel       zapcore.Level
+}
+
+// shardIndexBuild is the result of the rebuild of the index of a shard.
+type shardIndexBuild struct {
+	shardLocation
+	TSMFiles int    `json:"tsmFiles"`
+	WALFiles int    `json:"walFiles"`
+	Series   int64  `json:"series"`
+	Error    string `json:"error,omitempty"`
+
+	dir    string
+	walDir string
+}
+
+// bucketIndexBuild is the result of the rebuild of the indexes of the shards
+// of a bucket, and of its series file.
+type bucketIndexBuild struct {
+	BucketID string `json:"bucketID"`
+	// SeriesFileRebuilt is set when the series file of the bucket has been
+	// regenerated along with the indexes of all of its shards.
+	SeriesFileRebuilt bool               `json:"seriesFileRebuilt"`
+	Series            uint64             `json:"series"`
+	Shards            []*shardIndexBuild `json:"shards"`
+	Error             string             `json:"error,omitempty"`
+}
+
+type buildTSIReport struct {
+	DryRun  bool                `json:"dryRun"`
+	Buckets []*bucketIndexBuild `json:"buckets"`
+	Healthy bool                `json:"healthy"`
+}
+
+// NewBuildTSICommand builds the `build-tsi` subcommand of `influxd inspect`.
+func NewBuildTSICommand(v *viper.Viper) (*cobra.Command, error) {
+	flags := buildTSIFlags{
+		concurrency:    runtime.GOMAXPROCS(0),
+		maxLogFileSize: tsdb.DefaultMaxIndexLogFileSize,
+		maxCacheSize:   tsdb.DefaultCacheMaxMemorySize,
+		batchSize:      10000,
+		logLevel:       zapcore.InfoLevel,
+	}
+
+	cmd := &cobra.Command{
+		Use:   `build-tsi`,
+		Short: "Rebuilds the TSI indexes and series files of the engine",
+		Long: `
+This command regenerates the tsi1 index of shards by scanning the keys of
+their TSM files and WAL segments. The server must not be running.
+
+When a whole bucket is rebuilt, its series file is regenerated as well.
+When a single shard is rebuilt with --shard-id, the existing series file of
+its bucket is reused and must be healthy.
+
+Indexes and series files are written next to the ones they replace, and
+only swapped in once all the shards of a bucket are rebuilt. If the command
+is interrupted, run it again before starting the server: partially written
+files are discarded, and the ones replaced by an interrupted swap restored.
+
+With --dry-run, indexes are rebuilt in a temporary directory to check that
+they can be, and the engine is left untouched.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			logconf := zap.NewProductionConfig()
+			logconf.Level = zap.NewAtomicLevelAt(flags.logLevel)
+			logger, err := logconf.Build()
+			if err != nil {
+				return err
+			}
+
+			report, err := buildTSI(flags, logger)
+			if err != nil {
+				return err
+			}
+			if err := writeJSON(cmd.OutOrStdout(), report); err != nil {
+				return err
+			}
+			if !report.Healthy {
+				return errors.New("failed to rebuild indexes")
+			}
+			return nil
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.enginePath,
+			Flag:     "engine-path",
+			Desc:     "path to persistent engine files",
+			Required: true,
+		},
+		{
+			DestP: &flags.bucketID,
+			Flag:  "bucket-id",
+			Desc:  "optional: ID of the bucket to rebuild, all buckets are rebuilt if not set",
+		},
+		{
+			DestP: &flags.shardID,
+			Flag:  "shard-id",
+			Desc:  "optional: ID of the shard to rebuild, requires --bucket-id",
+		},
+		{
+			DestP: &flags.dryRun,
+			Flag:  "dry-run",
+			Desc:  "rebuild indexes in a temporary directory without replacing the ones of the engine",
+		},
+		{
+			DestP:   &flags.concurrency,
+			Flag:    "concurrency",
+			Default: flags.concurrency,
+			Desc:    "number of shards rebuilt concurrently",
+		},
+		{
+			DestP:   &flags.maxLogFileSize,
+			Flag:    "max-log-file-size",
+			Default: flags.maxLogFileSize,
+			Desc:    "size in bytes at which index log files are compacted",
+		},
+		{
+			DestP:   &flags.maxCacheSize,
+			Flag:    "max-cache-size",
+			Default: flags.maxCacheSize,
+			Desc:    "maximum size in bytes of the cache used to read the WAL of a shard",
+		},
+		{
+			DestP:   &flags.batchSize,
+			Flag:    "batch-size",
+			Default: flags.batchSize,
+			Desc:    "number of series added to the index at once",
+		},
+		{
+			DestP:   &flags.logLevel,
+			Flag:    "log-level",
+			Default: flags.logLevel,
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func buildTSI(flags buildTSIFlags, log *zap.Logger) (*buildTSIReport, error) {
+	if flags.shardID < 0 {
+		return nil, errors.New("--shard-id must be positive")
+	}
+	if flags.shardID != 0 && !flags.bucketID.Valid() {
+		return nil, errors.New("--shard-id requires --bucket-id")
+	}
+	if flags.concurrency < 1 {
+		return nil, errors.New("--concurrency must be at least 1")
+	}
+	if flags.batchSize < 1 {
+		return nil, errors.New("--batch-size must be at least 1")
+	}
+
+	bucket := "*"
+	if flags.bucketID.Valid() {
+		bucket = flags.bucketID.String()
+	}
+	bucketDirs, err := filepath.Glob(filepath.Join(flags.enginePath, "data", bucket))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &buildTSIReport{DryRun: flags.dryRun, Buckets: []*bucketIndexBuild{}, Healthy: true}
+	for _, dir := range bucketDirs {
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			continue
+		}
+		res, err := buildBucketTSI(flags, dir, log)
+		if err != nil {
+			return nil, err
+		}
+		report.Healthy = report.Healthy && res.Error == ""
+		report.Buckets = append(report.Buckets, res)
+	}
+	if flags.bucketID.Valid() && len(report.Buckets) == 0 {
+		return nil, fmt.Errorf("bucket %s not found in %s", flags.bucketID, flags.enginePath)
+	}
+	return report, nil
+}
+
+// buildBucketTSI rebuilds the indexes of the shards of the bucket stored in
+// dir, and its series file unless a single shard is rebuilt.
+func buildBucketTSI(flags buildTSIFlags, dir string, log *zap.Logger) (*bucketIndexBuild, error) {
+	bucketID := filepath.Base(dir)
+	log = log.With(zap.String("bucket_id", bucketID))
+	res := &bucketIndexBuild{BucketID: bucketID, Shards: []*shardIndexBuild{}}
+
+	shardDirs, err := bucketShards(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, shardDir := range shardDirs {
+		loc := locateShardDir(shardDir)
+		if flags.shardID != 0 && loc.ShardID != uint64(flags.shardID) {
+			continue
+		}
+		res.Shards = append(res.Shards, &shardIndexBuild{
+			shardLocation: loc,
+			dir:           shardDir,
+			walDir:        filepath.Join(flags.enginePath, "wal", bucketID, loc.RetentionPolicy, filepath.Base(shardDir)),
+		})
+	}
+	if flags.shardID != 0 && len(res.Shards) == 0 {
+		return nil, fmt.Errorf("shard %d not found in bucket %s", flags.shardID, bucketID)
+	}
+
+	// Finish or revert the swap of an interrupted run, and discard the files
+	// it left over.
+	sfilePath := filepath.Join(dir, tsdb.SeriesFileDirectory)
+	if !flags.dryRun {
+		if err := recoverLeftovers(dir, sfilePath, shardDirs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Pick where the series file and the indexes are built.
+	res.SeriesFileRebuilt = flags.shardID == 0
+	buildSFilePath := sfilePath + tmpSeriesFileSuffix
+	indexPath := func(s *shardIndexBuild) string { return filepath.Join(s.dir, tmpIndexDirectory) }
+	if flags.dryRun {
+		workDir, err := ioutil.TempDir("", "build-tsi")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(workDir)
+
+		buildSFilePath = filepath.Join(workDir, tsdb.SeriesFileDirectory)
+		indexPath = func(s *shardIndexBuild) string {
+			return filepath.Join(workDir, s.RetentionPolicy, filepath.Base(s.dir), "index")
+		}
+	} else if !res.SeriesFileRebuilt {
+		if _, err := os.Stat(sfilePath); err != nil {
+			return nil, fmt.Errorf("series file of bucket %s is required to rebuild a single shard: %w", bucketID, err)
+		}
+		buildSFilePath = sfilePath
+	}
+
+	sfile := tsdb.NewSeriesFile(buildSFilePath)
+	sfile.Logger = log
+	if err := sfile.Open(); err != nil {
+		return nil, err
+	}
+
+	// Rebuild the indexes of the shards concurrently, they share the series
+	// file.
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, flags.concurrency)
+	)
+	for _, s := range res.Shards {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(s *shardIndexBuild) {
+			defer func() { <-sem; wg.Done() }()
+			if err := buildShardTSI(flags, sfile, s, indexPath(s), log); err != nil {
+				s.Error = err.Error()
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	res.Series = sfile.SeriesCount()
+	if err := sfile.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range res.Shards {
+		if s.Error != "" {
+			res.Error = fmt.Sprintf("failed to rebuild the index of shard %d", s.ShardID)
+			return res, nil
+		}
+	}
+	if flags.dryRun {
+		return res, nil
+	}
+
+	// All the indexes are built: swap them in, along with the series file
+	// they reference. The files they replace are kept until all are swapped
+	// in, so that an interrupted run can be reverted.
+	var swaps []swap
+	if res.SeriesFileRebuilt {
+		swaps = append(swaps, swap{dst: sfilePath, src: buildSFilePath, old: sfilePath + oldSeriesFileSuffix})
+	}
+	for _, s := range res.Shards {
+		swaps = append(swaps, swap{dst: filepath.Join(s.dir, "index"), src: indexPath(s), old: filepath.Join(s.dir, oldIndexDirectory)})
+	}
+	if err := commitSwaps(dir, buildSFilePath, swaps); err != nil {
+		return nil, err
+	}
+	log.Info("Rebuilt indexes", zap.Int("shards", len(res.Shards)), zap.Bool("series_file", res.SeriesFileRebuilt))
+	return res, nil
+}
+
+// recoverLeftovers finishes or reverts the swap of an interrupted run in the
+// bucket directory dir, and removes the series file and indexes it built.
+func recoverLeftovers(dir, sfilePath string, shardDirs []string) error {
+	swaps := []swap{{dst: sfilePath, src: sfilePath + tmpSeriesFileSuffix, old: sfilePath + oldSeriesFileSuffix}}
+	for _, shardDir := range shardDirs {
+		swaps = append(swaps, swap{
+			dst: filepath.Join(shardDir, "index"),
+			src: filepath.Join(shardDir, tmpIndexDirectory),
+			old: filepath.Join(shardDir, oldIndexDirectory),
+		})
+	}
+
+	marker := filepath.Join(dir, swappedFile)
+	_, err := os.Stat(marker)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	swapped := err == nil
+
+	for _, sw := range swaps {
+		if swapped {
+			if err := os.RemoveAll(sw.old); err != nil {
+				return err
+			}
+		} else if err := sw.revert(); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(sw.src); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(marker)
+}
+
+// swap is the replacement of the series file or index at dst by the one
+// built at src. The current one is moved to old.
+type swap struct {
+	dst, src, old string
+}
+
+// commitSwaps syncs the files built, and swaps them in. Once all of them are
+// swapped in, the swappedFile of the bucket directory dir is created and the
+// files they replaced are removed.
+func commitSwaps(dir, sfilePath string, swaps []swap) error {
+	// The series file is synced even when a single shard is rebuilt and the
+	// series are added to it in place.
+	if err := syncTree(sfilePath); err != nil {
+		return err
+	}
+	for _, sw := range swaps {
+		if err := syncTree(sw.src); err != nil {
+			return err
+		}
+	}
+	for _, sw := range swaps {
+		if err := sw.apply(); err != nil {
+			return err
+		}
+	}
+
+	marker := filepath.Join(dir, swappedFile)
+	f, err := os.Create(marker)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := fs.SyncDir(dir); err != nil {
+		return err
+	}
+
+	for _, sw := range swaps {
+		if err := os.RemoveAll(sw.old); err != nil {
+			return err
+		}
+	}
+	return os.Remove(marker)
+}
+
+// apply moves src to dst, moving the current content of dst to old first. A
+// missing dst is recorded as an empty old directory, as the swap would not
+// be reverted otherwise.
+func (sw swap) apply() error {
+	if err := os.Rename(sw.dst, sw.old); os.IsNotExist(err) {
+		if err := os.Mkdir(sw.old, 0777); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if err := os.Rename(sw.src, sw.dst); err != nil {
+		return err
+	}
+	return fs.SyncDir(filepath.Dir(sw.dst))
+}
+
+// revert moves old back to dst, if the swap was started.
+func (sw swap) revert() error {
+	if _, err := os.Stat(sw.old); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(sw.dst); err != nil {
+		return err
+	}
+	if err := os.Rename(sw.old, sw.dst); err != nil {
+		return err
+	}
+	return fs.SyncDir(filepath.Dir(sw.dst))
+}
+
+// syncTree syncs the files and directories under root, which are built
+// without fsync, to the disk.
+func syncTree(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fs.SyncDir(path)
+		}
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// buildShardTSI builds the index of a shard at path, from the keys of its TSM
+// files and WAL segments.
+func buildShardTSI(flags buildTSIFlags, sfile *tsdb.SeriesFile, s *shardIndexBuild, path string, log *zap.Logger) error {
+	log = log.With(zap.String("retention_policy", s.RetentionPolicy), zap.Uint64("shard_id", s.ShardID))
+
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	idx := tsi1.NewIndex(sfile, s.BucketID,
+		tsi1.WithPath(path),
+		tsi1.WithMaximumLogFileSize(flags.maxLogFileSize),
+		tsi1.DisableFsync(),
+		tsi1.WithLogFileBufferSize(1<<20),
+	)
+	idx.WithLogger(log)
+	if err := idx.Open(); err != nil {
+		return err
+	}
+	if err := indexShardFiles(flags, idx, s, log); err != nil {
+		idx.Close()
+		return err
+	}
+
+	// Compact the index so that it is read from index files rather than
+	// replayed from log files when the shard is opened.
+	idx.Compact()
+	idx.Wait()
+	s.Series = idx.SeriesN()
+	return idx.Close()
+}
+
+// indexShardFiles adds the series of the TSM files and WAL segments of a
+// shard to idx.
+func indexShardFiles(flags buildTSIFlags, idx *tsi1.Index, s *shardIndexBuild, log *zap.Logger) error {
+	batch := newSeriesBatch(idx, flags.batchSize)
+
+	tsmFiles, err := filepath.Glob(filepath.Join(s.dir, "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		return err
+	}
+	for _, path := range tsmFiles {
+		log.Debug("Indexing TSM file", zap.String("path", path))
+		if err := indexTSMFile(batch, path); err != nil {
+			return fmt.Errorf("failed to index %s: %w", path, err)
+		}
+	}
+	s.TSMFiles = len(tsmFiles)
+
+	walFiles, err := filepath.Glob(filepath.Join(s.walDir, "*."+tsm1.WALFileExtension))
+	if err != nil {
+		return err
+	}
+	if err := indexWALFiles(batch, walFiles, uint64(flags.maxCacheSize), log); err != nil {
+		return err
+	}
+	s.WALFiles = len(walFiles)
+
+	return batch.flush()
+}
+
+func indexTSMFile(batch *seriesBatch, path string) error {
+	r, err := openTSM(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for i := 0; i < r.KeyCount(); i++ {
+		key, _ := r.KeyAt(i)
+		if err := batch.add(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexWALFiles adds the keys of the WAL segments that are not deleted by
+// later segments to batch.
+func indexWALFiles(batch *seriesBatch, paths []string, maxCacheSize uint64, log *zap.Logger) error {
+	cache := tsm1.NewCache(maxCacheSize)
+	for _, path := range paths {
+		log.Debug("Indexing WAL segment", zap.String("path", path))
+		if err := loadWALSegment(cache, path, log); err != nil {
+			return fmt.Errorf("failed to index %s: %w", path, err)
+		}
+	}
+	for _, key := range cache.Keys() {
+		if err := batch.add(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadWALSegment applies the entries of a WAL segment to cache. Like the
+// engine does, entries past a corrupt one are ignored.
+func loadWALSegment(cache *tsm1.Cache, path string, log *zap.Logger) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	r := tsm1.NewWALSegmentReader(f)
+	defer r.Close()
+
+	for r.Next() {
+		entry, err := r.Read()
+		if err != nil {
+			log.Warn("Ignoring WAL entries past a corrupt one", zap.String("path", path), zap.Int64("offset", r.Count()), zap.Error(err))
+			return nil
+		}
+
+		switch e := entry.(type) {
+		case *tsm1.WriteWALEntry:
+			if err := cache.WriteMulti(e.Values); err != nil {
+				return err
+			}
+		case *tsm1.DeleteWALEntry:
+			cache.Delete(e.Keys)
+		case *tsm1.DeleteRangeWALEntry:
+			cache.DeleteRange(e.Keys, e.Min, e.Max)
+		}
+	}
+	return nil
+}
+
+// seriesBatch adds the series of TSM keys to an index in batches.
+type seriesBatch struct {
+	idx   *tsi1.Index
+	size  int
+	keys  [][]byte
+	names [][]byte
+	tags  []models.Tags
+}
+
+func newSeriesBatch(idx *tsi1.Index, size int) *seriesBatch {
+	return &seriesBatch{idx: idx, size: size}
+}
+
+func (b *seriesBatch) add(key []byte) error {
+	// Keys are read from TSM files that are closed before the batch is
+	// flushed, so they are copied.
+	seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key)
+	seriesKey = append([]byte(nil), seriesKey...)
+	name, tags := models.ParseKeyBytes(seriesKey)
+	b.keys = append(b.keys, seriesKey)
+	b.names = append(b.names, name)
+	b.tags = append(b.tags, tags)
+	if len(b.keys) < b.size {
+		return nil
+	}
+	return b.flush()
+}
+
+func (b *seriesBatch) flush() error {
+	if len(b.keys) == 0 {
+		return nil
+	}
+	if err := b.idx.CreateSeriesListIfNotExists(b.keys, b.names, b.tags); err != nil {
+		return err
+	}
+	b.keys, b.names, b.tags = b.keys[:0], b.names[:0], b.tags[:0]
+	return nil
+}
@@ -0,0 +1,224 @@
+package inspect
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	_ "github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	_ "github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func openTestStore(t *testing.T, enginePath string) *tsdb.Store {
+	t.Helper()
+
+	s := tsdb.NewStore(filepath.Join(enginePath, "data"))
+	s.EngineOptions.IndexVersion = tsdb.TSI1IndexName
+	s.EngineOptions.Config.WALDir = filepath.Join(enginePath, "wal")
+	s.WithLogger(zaptest.NewLogger(t))
+	require.NoError(t, s.Open())
+	return s
+}
+
+// writeTestShards writes the series of two measurements to two shards of
+// testBucketID and returns the number of series written.
+func writeTestShards(t *testing.T, enginePath string) int64 {
+	t.Helper()
+
+	s := openTestStore(t, enginePath)
+	defer s.Close()
+
+	db := testBucketID.String()
+	for shardID := uint64(1); shardID <= 2; shardID++ {
+		require.NoError(t, s.CreateShard(db, "autogen", shardID, true))
+
+		var points []models.Point
+		for i := 0; i < 10; i++ {
+			host := strconv.Itoa(int(shardID)*100 + i)
+			for _, m := range []string{"cpu", "mem"} {
+				p, err := models.NewPoint(m, models.NewTags(map[string]string{"host": host}), models.Fields{"value": float64(i)}, time.Unix(int64(i), 0))
+				require.NoError(t, err)
+				points = append(points, p)
+			}
+		}
+		require.NoError(t, s.WriteToShard(shardID, points))
+	}
+
+	n, err := s.SeriesCardinality(context.Background(), db)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), n)
+	return n
+}
+
+// corruptIndex overwrites the manifests of the index partitions of a shard.
+func corruptIndex(t *testing.T, enginePath string, shardID uint64) {
+	t.Helper()
+
+	shardDir := filepath.Join(enginePath, "data", testBucketID.String(), "autogen", strconv.FormatUint(shardID, 10))
+	manifests, err := filepath.Glob(filepath.Join(shardDir, "index", "*", "MANIFEST"))
+	require.NoError(t, err)
+	require.NotEmpty(t, manifests)
+	for _, path := range manifests {
+		require.NoError(t, ioutil.WriteFile(path, []byte("not a manifest"), 0666))
+	}
+}
+
+func requireStoreSeries(t *testing.T, enginePath string, series int64) {
+	t.Helper()
+
+	s := openTestStore(t, enginePath)
+	defer s.Close()
+
+	db := testBucketID.String()
+	n, err := s.SeriesCardinality(context.Background(), db)
+	require.NoError(t, err)
+	require.Equal(t, series, n)
+
+	names, err := s.MeasurementNames(context.Background(), query.OpenAuthorizer, db, nil)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("cpu"), []byte("mem")}, names)
+}
+
+func TestBuildTSI_CorruptIndex(t *testing.T) {
+	enginePath := t.TempDir()
+	series := writeTestShards(t, enginePath)
+	corruptIndex(t, enginePath, 1)
+
+	// The shard with the corrupt index can't be loaded.
+	requireStoreSeries(t, enginePath, series/2)
+
+	// A dry run leaves the corrupt index in place.
+	var report buildTSIReport
+	require.NoError(t, runCommand(t, NewBuildTSICommand, &report, "--engine-path", enginePath, "--dry-run", "--log-level", "error"))
+	require.True(t, report.DryRun)
+	require.Len(t, report.Buckets, 1)
+	require.Len(t, report.Buckets[0].Shards, 2)
+	require.Equal(t, uint64(series), report.Buckets[0].Series)
+	manifest, err := ioutil.ReadFile(filepath.Join(enginePath, "data", testBucketID.String(), "autogen", "1", "index", "0", "MANIFEST"))
+	require.NoError(t, err)
+	require.Equal(t, "not a manifest", string(manifest))
+
+	report = buildTSIReport{}
+	require.NoError(t, runCommand(t, NewBuildTSICommand, &report, "--engine-path", enginePath, "--concurrency", "1", "--log-level", "error"))
+	require.True(t, report.Healthy)
+	require.Len(t, report.Buckets, 1)
+
+	b := report.Buckets[0]
+	require.True(t, b.SeriesFileRebuilt)
+	require.Equal(t, uint64(series), b.Series)
+	require.Len(t, b.Shards, 2)
+	for _, s := range b.Shards {
+		require.Empty(t, s.Error)
+		require.Equal(t, int64(20), s.Series)
+		require.Equal(t, 1, s.WALFiles)
+	}
+
+	// Nothing is left next to the rebuilt files.
+	bucketDir := filepath.Join(enginePath, "data", testBucketID.String())
+	for _, path := range []string{
+		filepath.Join(bucketDir, tsdb.SeriesFileDirectory+tmpSeriesFileSuffix),
+		filepath.Join(bucketDir, tsdb.SeriesFileDirectory+oldSeriesFileSuffix),
+		filepath.Join(bucketDir, "autogen", "1", tmpIndexDirectory),
+		filepath.Join(bucketDir, "autogen", "1", oldIndexDirectory),
+	} {
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err), path)
+	}
+
+	requireStoreSeries(t, enginePath, series)
+}
+
+func TestBuildTSI_Shard(t *testing.T) {
+	enginePath := t.TempDir()
+	series := writeTestShards(t, enginePath)
+	corruptIndex(t, enginePath, 2)
+
+	// Leftovers of an interrupted run are discarded.
+	leftover := filepath.Join(enginePath, "data", testBucketID.String(), "autogen", "2", tmpIndexDirectory)
+	require.NoError(t, os.MkdirAll(filepath.Join(leftover, "0"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(leftover, "0", "MANIFEST"), []byte("partial"), 0666))
+
+	var report buildTSIReport
+	require.NoError(t, runCommand(t, NewBuildTSICommand, &report,
+		"--engine-path", enginePath,
+		"--bucket-id", testBucketID.String(),
+		"--shard-id", "2",
+		"--log-level", "error",
+	))
+	require.True(t, report.Healthy)
+	require.False(t, report.Buckets[0].SeriesFileRebuilt)
+	require.Len(t, report.Buckets[0].Shards, 1)
+	require.Equal(t, uint64(2), report.Buckets[0].Shards[0].ShardID)
+
+	requireStoreSeries(t, enginePath, series)
+}
+
+func TestBuildTSI_InterruptedSwap(t *testing.T) {
+	enginePath := t.TempDir()
+	series := writeTestShards(t, enginePath)
+	bucketDir := filepath.Join(enginePath, "data", testBucketID.String())
+	shardDir := filepath.Join(bucketDir, "autogen", "1")
+
+	// A run interrupted while swapping in the indexes: the index of shard 1
+	// was moved out of the way, and the rebuilt one only partly moved in.
+	require.NoError(t, os.Rename(filepath.Join(shardDir, "index"), filepath.Join(shardDir, oldIndexDirectory)))
+	require.NoError(t, os.MkdirAll(filepath.Join(shardDir, "index", "0"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(shardDir, "index", "0", "MANIFEST"), []byte("partial"), 0666))
+
+	// The next run restores the index it replaced, even when it rebuilds
+	// another shard.
+	args := []string{"--engine-path", enginePath, "--bucket-id", testBucketID.String(), "--shard-id", "2", "--log-level", "error"}
+	var report buildTSIReport
+	require.NoError(t, runCommand(t, NewBuildTSICommand, &report, args...))
+	require.True(t, report.Healthy)
+	_, err := os.Stat(filepath.Join(shardDir, oldIndexDirectory))
+	require.True(t, os.IsNotExist(err))
+	requireStoreSeries(t, enginePath, series)
+
+	// A run interrupted after all the indexes were swapped in: the replaced
+	// index is removed rather than restored.
+	require.NoError(t, os.MkdirAll(filepath.Join(shardDir, oldIndexDirectory, "0"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(shardDir, oldIndexDirectory, "0", "MANIFEST"), []byte("not a manifest"), 0666))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bucketDir, swappedFile), nil, 0666))
+
+	report = buildTSIReport{}
+	require.NoError(t, runCommand(t, NewBuildTSICommand, &report, args...))
+	require.True(t, report.Healthy)
+	for _, path := range []string{filepath.Join(shardDir, oldIndexDirectory), filepath.Join(bucketDir, swappedFile)} {
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err), path)
+	}
+	requireStoreSeries(t, enginePath, series)
+}
+
+func TestBuildTSI_TSMFiles(t *testing.T) {
+	e := newTestEngine(t)
+
+	var report buildTSIReport
+	require.NoError(t, runCommand(t, NewBuildTSICommand, &report, "--engine-path", e.path, "--log-level", "error"))
+	require.True(t, report.Healthy)
+	require.Len(t, report.Buckets, 1)
+
+	s := report.Buckets[0].Shards[0]
+	require.Equal(t, 1, s.TSMFiles)
+	require.Equal(t, int64(len(basicCorpus)), s.Series)
+	require.DirExists(t, filepath.Join(filepath.Dir(e.tsmPath), "index"))
+	require.DirExists(t, filepath.Join(e.path, "data", testBucketID.String(), tsdb.SeriesFileDirectory))
+}
+
+func TestBuildTSI_Flags(t *testing.T) {
+	e := newTestEngine(t)
+
+	var report buildTSIReport
+	require.Error(t, runCommand(t, NewBuildTSICommand, &report, "--engine-path", e.path, "--shard-id", "1"))
+	require.Error(t, runCommand(t, NewBuildTSICommand, &report, "--engine-path", e.path, "--bucket-id", testBucketID.String(), "--shard-id", "3"))
+}
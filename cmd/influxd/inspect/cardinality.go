@@ -0,0 +1,126 @@
+package inspect
+
+import (
+	"sort"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/estimator/hll"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+)
+
+// cardinality counts the distinct values added to it.
+type cardinality interface {
+	Add(v []byte)
+	Count() uint64
+}
+
+// exactCardinality counts distinct values exactly, at the cost of keeping
+// them all in memory.
+type exactCardinality map[string]struct{}
+
+func (c exactCardinality) Add(v []byte) { c[string(v)] = struct{}{} }
+
+func (c exactCardinality) Count() uint64 { return uint64(len(c)) }
+
+func newCardinality(exact bool) cardinality {
+	if exact {
+		return exactCardinality{}
+	}
+	return hll.NewDefaultPlus()
+}
+
+// measurementCardinality counts the series, fields and tag values of a
+// measurement.
+type measurementCardinality struct {
+	series    cardinality
+	fields    cardinality
+	tagValues map[string]cardinality
+}
+
+// cardinalityCounter counts the series of TSM keys, in total and by
+// measurement.
+type cardinalityCounter struct {
+	exact        bool
+	series       cardinality
+	measurements map[string]*measurementCardinality
+}
+
+func newCardinalityCounter(exact bool) *cardinalityCounter {
+	return &cardinalityCounter{
+		exact:        exact,
+		series:       newCardinality(exact),
+		measurements: make(map[string]*measurementCardinality),
+	}
+}
+
+// add counts the series and field of a TSM key.
+func (c *cardinalityCounter) add(key []byte) {
+	seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey(key)
+	c.series.Add(seriesKey)
+
+	name, tags := models.ParseKeyBytes(seriesKey)
+	m, ok := c.measurements[string(name)]
+	if !ok {
+		m = &measurementCardinality{
+			series:    newCardinality(c.exact),
+			fields:    newCardinality(c.exact),
+			tagValues: make(map[string]cardinality),
+		}
+		c.measurements[string(name)] = m
+	}
+	m.series.Add(seriesKey)
+	m.fields.Add(field)
+	for _, t := range tags {
+		values, ok := m.tagValues[string(t.Key)]
+		if !ok {
+			values = newCardinality(c.exact)
+			m.tagValues[string(t.Key)] = values
+		}
+		values.Add(t.Value)
+	}
+}
+
+// cardinalitySummary is the JSON form of the counts of a cardinalityCounter.
+type cardinalitySummary struct {
+	Series       uint64                `json:"series"`
+	Measurements int                   `json:"measurements"`
+	Fields       uint64                `json:"fields"`
+	TagValues    uint64                `json:"tagValues"`
+	Details      []*measurementSummary `json:"details,omitempty"`
+}
+
+type measurementSummary struct {
+	Measurement string            `json:"measurement"`
+	Series      uint64            `json:"series"`
+	Fields      uint64            `json:"fields"`
+	TagValues   map[string]uint64 `json:"tagValues"`
+}
+
+// summary returns the counts of c, with the details of every measurement
+// when detailed is set.
+func (c *cardinalityCounter) summary(detailed bool) cardinalitySummary {
+	s := cardinalitySummary{
+		Series:       c.series.Count(),
+		Measurements: len(c.measurements),
+	}
+	for name, m := range c.measurements {
+		ms := &measurementSummary{
+			Measurement: name,
+			Series:      m.series.Count(),
+			Fields:      m.fields.Count(),
+			TagValues:   make(map[string]uint64, len(m.tagValues)),
+		}
+		s.Fields += ms.Fields
+		for k, values := range m.tagValues {
+			ms.TagValues[k] = values.Count()
+			s.TagValues += ms.TagValues[k]
+		}
+		if detailed {
+			s.Details = append(s.Details, ms)
+		}
+	}
+	sort.Slice(s.Details, func(i, j int) bool {
+		return s.Details[i].Measurement < s.Details[j].Measurement
+	})
+	return s
+}
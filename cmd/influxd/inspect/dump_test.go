@@ -0,0 +1,51 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpTSM(t *testing.T) {
+	e := newTestEngine(t)
+
+	var dump tsmDump
+	require.NoError(t, runCommand(t, NewDumpTSMCommand, &dump, "--file-path", e.tsmPath, "--index", "--blocks"))
+	require.Equal(t, len(basicCorpus), dump.KeyCount)
+	require.Equal(t, len(basicCorpus), dump.BlockCount)
+	require.Equal(t, int64(1), dump.MinTime)
+	require.Len(t, dump.Index, len(basicCorpus))
+	require.Len(t, dump.Blocks, len(basicCorpus))
+	for _, b := range dump.Blocks {
+		require.True(t, b.ChecksumValid, b.Key)
+		require.Equal(t, 2, b.Points, b.Key)
+		require.Equal(t, "default", b.Encoding, b.Key)
+	}
+
+	dump = tsmDump{}
+	require.NoError(t, runCommand(t, NewDumpTSMCommand, &dump, "--file-path", e.tsmPath, "--blocks", "--filter-key", "floats"))
+	require.Empty(t, dump.Index)
+	require.Len(t, dump.Blocks, 1)
+	require.Equal(t, "float", dump.Blocks[0].Type)
+}
+
+func TestDumpWAL(t *testing.T) {
+	e := newTestEngine(t)
+
+	var dump struct {
+		Files []*walDump `json:"files"`
+	}
+	require.NoError(t, runCommand(t, NewDumpWALCommand, &dump, "--file-path", e.walPath, "--print-values"))
+	require.Len(t, dump.Files, 1)
+	require.Len(t, dump.Files[0].Entries, 1)
+
+	entry := dump.Files[0].Entries[0]
+	require.Equal(t, "write", entry.Type)
+	require.Len(t, entry.Keys, len(basicCorpus))
+	for _, k := range entry.Keys {
+		require.Equal(t, 2, k.Count, k.Key)
+		require.Len(t, k.Values, 2, k.Key)
+	}
+
+	require.Error(t, runCommand(t, NewDumpWALCommand, &dump, "--file-path", e.walPath+".missing"))
+}
@@ -0,0 +1,185 @@
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type dumpTSMFlags struct {
+	path      string
+	index     bool
+	blocks    bool
+	filterKey string
+}
+
+type tsmDump struct {
+	Path       string          `json:"path"`
+	Size       uint32          `json:"size"`
+	IndexSize  uint32          `json:"indexSize"`
+	MinTime    int64           `json:"minTime"`
+	MaxTime    int64           `json:"maxTime"`
+	KeyCount   int             `json:"keyCount"`
+	BlockCount int             `json:"blockCount"`
+	Index      []*tsmIndexDump `json:"index,omitempty"`
+	Blocks     []*tsmBlockDump `json:"blocks,omitempty"`
+	fileErrors
+}
+
+// tsmIndexDump is the index of the blocks of a key.
+type tsmIndexDump struct {
+	Key     string              `json:"key"`
+	Type    string              `json:"type"`
+	Entries []tsmIndexEntryDump `json:"entries"`
+}
+
+type tsmIndexEntryDump struct {
+	MinTime int64  `json:"minTime"`
+	MaxTime int64  `json:"maxTime"`
+	Offset  int64  `json:"offset"`
+	Size    uint32 `json:"size"`
+}
+
+type tsmBlockDump struct {
+	Key           string `json:"key"`
+	Type          string `json:"type"`
+	Encoding      string `json:"encoding,omitempty"`
+	Offset        int64  `json:"offset"`
+	Size          uint32 `json:"size"`
+	MinTime       int64  `json:"minTime"`
+	MaxTime       int64  `json:"maxTime"`
+	Points        int    `json:"points"`
+	Checksum      uint32 `json:"checksum"`
+	ChecksumValid bool   `json:"checksumValid"`
+}
+
+// NewDumpTSMCommand builds the `dump-tsm` subcommand of `influxd inspect`.
+func NewDumpTSMCommand(v *viper.Viper) (*cobra.Command, error) {
+	var flags dumpTSMFlags
+
+	cmd := &cobra.Command{
+		Use:   `dump-tsm`,
+		Short: "Dumps the index and blocks of a TSM file",
+		Long: `
+This command prints a summary of a TSM file as JSON, optionally
+followed by its index entries and the description of its blocks.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dump, err := dumpTSM(flags)
+			if err != nil {
+				return err
+			}
+			if err := writeJSON(cmd.OutOrStdout(), dump); err != nil {
+				return err
+			}
+			if dump.Count > 0 {
+				return fmt.Errorf("%d blocks could not be read", dump.Count)
+			}
+			return nil
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.path,
+			Flag:     "file-path",
+			Desc:     "path to the TSM file",
+			Required: true,
+		},
+		{
+			DestP: &flags.index,
+			Flag:  "index",
+			Desc:  "dump the index entries of every key",
+		},
+		{
+			DestP: &flags.blocks,
+			Flag:  "blocks",
+			Desc:  "dump the description of every block",
+		},
+		{
+			DestP: &flags.filterKey,
+			Flag:  "filter-key",
+			Desc:  "optional: only dump the index entries and blocks of the keys containing this string",
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func dumpTSM(flags dumpTSMFlags) (*tsmDump, error) {
+	r, err := openTSM(flags.path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	dump := &tsmDump{
+		Path:      flags.path,
+		Size:      r.Size(),
+		IndexSize: r.IndexSize(),
+		KeyCount:  r.KeyCount(),
+	}
+	dump.MinTime, dump.MaxTime = r.TimeRange()
+
+	filter := []byte(flags.filterKey)
+	var (
+		key     []byte
+		typ     byte
+		entries []tsm1.IndexEntry
+	)
+	for i := 0; i < dump.KeyCount; i++ {
+		key, typ, entries = r.Key(i, &entries)
+		dump.BlockCount += len(entries)
+		if !bytes.Contains(key, filter) {
+			continue
+		}
+
+		if flags.index {
+			index := &tsmIndexDump{Key: string(key), Type: blockTypeName(typ)}
+			for _, e := range entries {
+				index.Entries = append(index.Entries, tsmIndexEntryDump{
+					MinTime: e.MinTime,
+					MaxTime: e.MaxTime,
+					Offset:  e.Offset,
+					Size:    e.Size,
+				})
+			}
+			dump.Index = append(dump.Index, index)
+		}
+		if !flags.blocks {
+			continue
+		}
+		for j := range entries {
+			e := &entries[j]
+			block := &tsmBlockDump{
+				Key:     string(key),
+				Type:    blockTypeName(typ),
+				Offset:  e.Offset,
+				Size:    e.Size,
+				MinTime: e.MinTime,
+				MaxTime: e.MaxTime,
+			}
+			checksum, buf, err := r.ReadBytes(e, nil)
+			if err != nil {
+				dump.add("could not read block at offset %d: %v", e.Offset, err)
+				dump.Blocks = append(dump.Blocks, block)
+				continue
+			}
+			block.Checksum = checksum
+			block.ChecksumValid = crc32.ChecksumIEEE(buf) == checksum
+			block.Encoding = blockEncodingName(buf)
+			if block.Points, err = tsm1.BlockCount(buf); err != nil {
+				dump.add("could not decode block at offset %d: %v", e.Offset, err)
+			}
+			dump.Blocks = append(dump.Blocks, block)
+		}
+	}
+	return dump, nil
+}
@@ -0,0 +1,176 @@
+package inspect
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type dumpWALFlags struct {
+	paths       []string
+	printValues bool
+}
+
+type walDump struct {
+	Path    string          `json:"path"`
+	Entries []*walEntryDump `json:"entries"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type walEntryDump struct {
+	// Type is either write, delete or delete-range.
+	Type string        `json:"type"`
+	Keys []*walKeyDump `json:"keys"`
+	// MinTime and MaxTime are the time range of delete-range entries.
+	MinTime *int64 `json:"minTime,omitempty"`
+	MaxTime *int64 `json:"maxTime,omitempty"`
+}
+
+type walKeyDump struct {
+	Key    string         `json:"key"`
+	Type   string         `json:"type,omitempty"`
+	Count  int            `json:"count,omitempty"`
+	Values []walValueDump `json:"values,omitempty"`
+}
+
+type walValueDump struct {
+	Time  int64       `json:"time"`
+	Value interface{} `json:"value"`
+}
+
+// NewDumpWALCommand builds the `dump-wal` subcommand of `influxd inspect`.
+func NewDumpWALCommand(v *viper.Viper) (*cobra.Command, error) {
+	var flags dumpWALFlags
+
+	cmd := &cobra.Command{
+		Use:   `dump-wal`,
+		Short: "Dumps the entries of WAL segments",
+		Long: `
+This command prints the entries of WAL segments as JSON: the keys
+written with their number of values, and the deleted keys.
+It fails if a segment is corrupt.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dumps := make([]*walDump, 0, len(flags.paths))
+			var failed int
+			for _, path := range flags.paths {
+				dump := dumpWAL(path, flags.printValues)
+				if dump.Error != "" {
+					failed++
+				}
+				dumps = append(dumps, dump)
+			}
+			if err := writeJSON(cmd.OutOrStdout(), map[string]interface{}{"files": dumps}); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d WAL segments could not be read", failed)
+			}
+			return nil
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.paths,
+			Flag:     "file-path",
+			Desc:     "path to a WAL segment, can be repeated",
+			Required: true,
+		},
+		{
+			DestP: &flags.printValues,
+			Flag:  "print-values",
+			Desc:  "dump the values written along with their keys",
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func dumpWAL(path string, printValues bool) *walDump {
+	dump := &walDump{Path: path, Entries: []*walEntryDump{}}
+
+	f, err := os.Open(path)
+	if err != nil {
+		dump.Error = err.Error()
+		return dump
+	}
+	r := tsm1.NewWALSegmentReader(f)
+	defer r.Close()
+
+	for r.Next() {
+		entry, err := r.Read()
+		if err != nil {
+			dump.Error = fmt.Sprintf("corrupt entry at offset %d: %v", r.Count(), err)
+			return dump
+		}
+
+		switch e := entry.(type) {
+		case *tsm1.WriteWALEntry:
+			keys := make([]string, 0, len(e.Values))
+			for k := range e.Values {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			d := &walEntryDump{Type: "write"}
+			for _, k := range keys {
+				values := e.Values[k]
+				kd := &walKeyDump{Key: k, Count: len(values)}
+				if len(values) > 0 {
+					kd.Type = valueTypeName(values[0].Value())
+				}
+				if printValues {
+					for _, v := range values {
+						kd.Values = append(kd.Values, walValueDump{Time: v.UnixNano(), Value: v.Value()})
+					}
+				}
+				d.Keys = append(d.Keys, kd)
+			}
+			dump.Entries = append(dump.Entries, d)
+		case *tsm1.DeleteWALEntry:
+			dump.Entries = append(dump.Entries, &walEntryDump{Type: "delete", Keys: walKeys(e.Keys)})
+		case *tsm1.DeleteRangeWALEntry:
+			min, max := e.Min, e.Max
+			dump.Entries = append(dump.Entries, &walEntryDump{
+				Type:    "delete-range",
+				Keys:    walKeys(e.Keys),
+				MinTime: &min,
+				MaxTime: &max,
+			})
+		}
+	}
+	return dump
+}
+
+func walKeys(keys [][]byte) []*walKeyDump {
+	dumps := make([]*walKeyDump, 0, len(keys))
+	for _, k := range keys {
+		dumps = append(dumps, &walKeyDump{Key: string(k)})
+	}
+	return dumps
+}
+
+// valueTypeName returns the name of the type of a value written to the WAL.
+func valueTypeName(v interface{}) string {
+	switch v.(type) {
+	case float64:
+		return "float"
+	case int64:
+		return "integer"
+	case uint64:
+		return "unsigned"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	}
+	return "unknown"
+}
@@ -0,0 +1,136 @@
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+)
+
+// maxReportedErrors bounds the number of errors reported for a single file.
+const maxReportedErrors = 100
+
+// engineFiles returns the files with extension ext of the shards of a bucket
+// stored under `<engine>/<dir>/<bucket-id>/<rp>/<shard-id>/`, in the order
+// the engine opens them. All the buckets are searched when bucketID is not
+// valid.
+func engineFiles(enginePath, dir string, bucketID platform.ID, ext string) ([]string, error) {
+	bucket := "*"
+	if bucketID.Valid() {
+		bucket = bucketID.String()
+	}
+	files, err := filepath.Glob(filepath.Join(enginePath, dir, bucket, "*", "*", "*."+ext))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// bucketShards returns the directories of the shards of the bucket stored in
+// bucketDir, sorted by retention policy and shard ID.
+func bucketShards(bucketDir string) ([]string, error) {
+	dirs, err := filepath.Glob(filepath.Join(bucketDir, "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	shards := dirs[:0]
+	for _, dir := range dirs {
+		// Skip the partitions of the series file, and of the ones build-tsi
+		// writes next to it.
+		if rp := filepath.Base(filepath.Dir(dir)); rp == tsdb.SeriesFileDirectory || strings.HasPrefix(rp, tsdb.SeriesFileDirectory+".") {
+			continue
+		}
+		if _, err := strconv.ParseUint(filepath.Base(dir), 10, 64); err != nil {
+			continue
+		}
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			continue
+		}
+		shards = append(shards, dir)
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		a, b := locateShardDir(shards[i]), locateShardDir(shards[j])
+		if a.RetentionPolicy != b.RetentionPolicy {
+			return a.RetentionPolicy < b.RetentionPolicy
+		}
+		return a.ShardID < b.ShardID
+	})
+	return shards, nil
+}
+
+// shardLocation identifies the shard a file of the engine belongs to.
+type shardLocation struct {
+	BucketID        string `json:"bucketID"`
+	RetentionPolicy string `json:"retentionPolicy"`
+	ShardID         uint64 `json:"shardID"`
+}
+
+// locateShard returns the shard of a file found by engineFiles.
+func locateShard(path string) shardLocation {
+	return locateShardDir(filepath.Dir(path))
+}
+
+// locateShardDir returns the shard stored in shardDir.
+func locateShardDir(shardDir string) shardLocation {
+	rpDir := filepath.Dir(shardDir)
+	shardID, _ := strconv.ParseUint(filepath.Base(shardDir), 10, 64)
+	return shardLocation{
+		BucketID:        filepath.Base(filepath.Dir(rpDir)),
+		RetentionPolicy: filepath.Base(rpDir),
+		ShardID:         shardID,
+	}
+}
+
+// fileErrors collects the errors found in a file, keeping the first
+// maxReportedErrors of them.
+type fileErrors struct {
+	Count  int      `json:"errorCount"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (e *fileErrors) add(format string, args ...interface{}) {
+	e.Count++
+	if len(e.Errors) < maxReportedErrors {
+		e.Errors = append(e.Errors, fmt.Sprintf(format, args...))
+	}
+}
+
+// blockTypeName returns the name of the type of the values of a TSM block.
+func blockTypeName(typ byte) string {
+	if typ > tsm1.BlockUnsigned {
+		return "unknown"
+	}
+	return tsm1.BlockTypeToInfluxQLDataType(typ).String()
+}
+
+// blockEncodingName returns the name of the encoding of the values of a TSM block.
+func blockEncodingName(block []byte) string {
+	if len(block) == 0 {
+		return ""
+	}
+	switch tsm1.BlockEncoding(block) {
+	case tsm1.BlockEncodingDefault:
+		return "default"
+	case tsm1.BlockEncodingZstd:
+		return "zstd"
+	default:
+		return "unknown"
+	}
+}
+
+// writeJSON writes the report of a command to w.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -23,5 +23,24 @@ func NewCommand(v *viper.Viper) (*cobra.Command, error) {
 	base.AddCommand(exportLp)
 	base.AddCommand(NewExportIndexCommand())
 
+	for _, newCmd := range []func(*viper.Viper) (*cobra.Command, error){
+		NewVerifyTSMCommand,
+		NewVerifyTombstoneCommand,
+		NewVerifySeriesFileCommand,
+		NewVerifyWALCommand,
+		NewDumpTSMCommand,
+		NewDumpWALCommand,
+		NewReportTSMCommand,
+		NewReportDBCommand,
+		NewReportDiskCommand,
+		NewBuildTSICommand,
+	} {
+		cmd, err := newCmd(v)
+		if err != nil {
+			return nil, err
+		}
+		base.AddCommand(cmd)
+	}
+
 	return base, nil
 }
@@ -0,0 +1,141 @@
+package inspect
+
+import (
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type reportDBFlags struct {
+	enginePath string
+	bucketID   platform.ID
+	detailed   bool
+	exact      bool
+}
+
+// bucketReport is the cardinality of the data of a bucket and retention
+// policy.
+type bucketReport struct {
+	BucketID        string `json:"bucketID"`
+	RetentionPolicy string `json:"retentionPolicy"`
+	Shards          int    `json:"shards"`
+	cardinalitySummary
+}
+
+type reportDB struct {
+	Buckets []*bucketReport `json:"buckets"`
+	Series  uint64          `json:"series"`
+}
+
+// NewReportDBCommand builds the `report-db` subcommand of `influxd inspect`.
+func NewReportDBCommand(v *viper.Viper) (*cobra.Command, error) {
+	var flags reportDBFlags
+
+	cmd := &cobra.Command{
+		Use:   `report-db`,
+		Short: "Reports the cardinality of the data of every bucket",
+		Long: `
+This command prints the number of series, measurements, fields and tag
+values stored in the TSM files of every bucket and retention policy as
+JSON. Cardinalities are estimated unless --exact is set.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			report, err := reportBuckets(flags)
+			if err != nil {
+				return err
+			}
+			return writeJSON(cmd.OutOrStdout(), report)
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.enginePath,
+			Flag:     "engine-path",
+			Desc:     "path to persistent engine files",
+			Required: true,
+		},
+		{
+			DestP: &flags.bucketID,
+			Flag:  "bucket-id",
+			Desc:  "optional: ID of the bucket to report on, all buckets are reported if not set",
+		},
+		{
+			DestP: &flags.detailed,
+			Flag:  "detailed",
+			Desc:  "report the cardinality of every measurement",
+		},
+		{
+			DestP: &flags.exact,
+			Flag:  "exact",
+			Desc:  "count cardinalities exactly instead of estimating them, this uses more memory",
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func reportBuckets(flags reportDBFlags) (*reportDB, error) {
+	files, err := engineFiles(flags.enginePath, "data", flags.bucketID, tsm1.TSMFileExtension)
+	if err != nil {
+		return nil, err
+	}
+
+	type bucketRP struct{ bucketID, rp string }
+	var (
+		order    []bucketRP
+		counters = make(map[bucketRP]*cardinalityCounter)
+		shards   = make(map[bucketRP]map[uint64]struct{})
+		total    = newCardinality(flags.exact)
+	)
+	for _, path := range files {
+		loc := locateShard(path)
+		k := bucketRP{loc.BucketID, loc.RetentionPolicy}
+		counter, ok := counters[k]
+		if !ok {
+			counter = newCardinalityCounter(flags.exact)
+			counters[k] = counter
+			shards[k] = make(map[uint64]struct{})
+			order = append(order, k)
+		}
+		shards[k][loc.ShardID] = struct{}{}
+		if err := addTSMKeys(path, counter, total); err != nil {
+			return nil, err
+		}
+	}
+
+	// Files are sorted by path, so buckets are too.
+	report := &reportDB{Buckets: []*bucketReport{}, Series: total.Count()}
+	for _, k := range order {
+		report.Buckets = append(report.Buckets, &bucketReport{
+			BucketID:           k.bucketID,
+			RetentionPolicy:    k.rp,
+			Shards:             len(shards[k]),
+			cardinalitySummary: counters[k].summary(flags.detailed),
+		})
+	}
+	return report, nil
+}
+
+// addTSMKeys adds the keys of the TSM file at path to counter, and their
+// series, qualified by bucket, to series.
+func addTSMKeys(path string, counter *cardinalityCounter, series cardinality) error {
+	r, err := openTSM(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	bucketID := locateShard(path).BucketID
+	for i := 0; i < r.KeyCount(); i++ {
+		key, _ := r.KeyAt(i)
+		counter.add(key)
+		seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key)
+		series.Add(append([]byte(bucketID+","), seriesKey...))
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package inspect
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type reportDiskFlags struct {
+	enginePath string
+	bucketID   platform.ID
+	detailed   bool
+}
+
+// shardDiskUsage is the size in bytes of the files of a shard.
+type shardDiskUsage struct {
+	RetentionPolicy string                  `json:"retentionPolicy"`
+	ShardID         uint64                  `json:"shardID"`
+	TSM             int64                   `json:"tsm"`
+	Tombstones      int64                   `json:"tombstones"`
+	Index           int64                   `json:"index"`
+	WAL             int64                   `json:"wal"`
+	Total           int64                   `json:"total"`
+	Measurements    []*measurementDiskUsage `json:"measurements,omitempty"`
+	measurements    map[string]*measurementDiskUsage
+}
+
+// measurementDiskUsage is the size in bytes of the TSM blocks of a
+// measurement.
+type measurementDiskUsage struct {
+	Measurement string `json:"measurement"`
+	Size        int64  `json:"size"`
+}
+
+type bucketDiskUsage struct {
+	BucketID   string            `json:"bucketID"`
+	Shards     []*shardDiskUsage `json:"shards"`
+	SeriesFile int64             `json:"seriesFile"`
+	Total      int64             `json:"total"`
+}
+
+type reportDisk struct {
+	Buckets []*bucketDiskUsage `json:"buckets"`
+	Total   int64              `json:"total"`
+}
+
+// NewReportDiskCommand builds the `report-disk` subcommand of `influxd inspect`.
+func NewReportDiskCommand(v *viper.Viper) (*cobra.Command, error) {
+	var flags reportDiskFlags
+
+	cmd := &cobra.Command{
+		Use:   `report-disk`,
+		Short: "Reports the disk usage of every bucket and shard",
+		Long: `
+This command prints the size in bytes of the TSM, tombstone, index and
+WAL files of every shard, and of the series file of every bucket, as JSON.
+With --detailed the TSM size of every measurement is reported as well.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			report, err := reportDiskUsage(flags)
+			if err != nil {
+				return err
+			}
+			return writeJSON(cmd.OutOrStdout(), report)
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.enginePath,
+			Flag:     "engine-path",
+			Desc:     "path to persistent engine files",
+			Required: true,
+		},
+		{
+			DestP: &flags.bucketID,
+			Flag:  "bucket-id",
+			Desc:  "optional: ID of the bucket to report on, all buckets are reported if not set",
+		},
+		{
+			DestP: &flags.detailed,
+			Flag:  "detailed",
+			Desc:  "report the size of the TSM blocks of every measurement",
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func reportDiskUsage(flags reportDiskFlags) (*reportDisk, error) {
+	bucket := "*"
+	if flags.bucketID.Valid() {
+		bucket = flags.bucketID.String()
+	}
+	bucketDirs, err := filepath.Glob(filepath.Join(flags.enginePath, "data", bucket))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(bucketDirs)
+
+	report := &reportDisk{Buckets: []*bucketDiskUsage{}}
+	for _, dir := range bucketDirs {
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			continue
+		}
+		usage, err := bucketUsage(flags.enginePath, dir, flags.detailed)
+		if err != nil {
+			return nil, err
+		}
+		report.Total += usage.Total
+		report.Buckets = append(report.Buckets, usage)
+	}
+	return report, nil
+}
+
+func bucketUsage(enginePath, dir string, detailed bool) (*bucketDiskUsage, error) {
+	bucketID := filepath.Base(dir)
+	usage := &bucketDiskUsage{BucketID: bucketID, Shards: []*shardDiskUsage{}}
+
+	var err error
+	if usage.SeriesFile, err = dirSize(filepath.Join(dir, tsdb.SeriesFileDirectory)); err != nil {
+		return nil, err
+	}
+	usage.Total = usage.SeriesFile
+
+	shardDirs, err := bucketShards(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, shardDir := range shardDirs {
+		loc := locateShardDir(shardDir)
+		shard := &shardDiskUsage{RetentionPolicy: loc.RetentionPolicy, ShardID: loc.ShardID}
+		walDir := filepath.Join(enginePath, "wal", bucketID, loc.RetentionPolicy, filepath.Base(shardDir))
+		if err := shardUsage(shard, shardDir, walDir, detailed); err != nil {
+			return nil, err
+		}
+		usage.Total += shard.Total
+		usage.Shards = append(usage.Shards, shard)
+	}
+	return usage, nil
+}
+
+func shardUsage(shard *shardDiskUsage, dir, walDir string, detailed bool) error {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range fis {
+		path := filepath.Join(dir, fi.Name())
+		if fi.IsDir() {
+			if fi.Name() == "index" {
+				if shard.Index, err = dirSize(path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		switch {
+		case strings.HasSuffix(fi.Name(), "."+tsm1.TSMFileExtension):
+			shard.TSM += fi.Size()
+			if detailed {
+				if err := addMeasurementUsage(shard, path); err != nil {
+					return err
+				}
+			}
+		case strings.HasSuffix(fi.Name(), "."+tsm1.TombstoneFileExtension):
+			shard.Tombstones += fi.Size()
+		}
+	}
+	if shard.WAL, err = dirSize(walDir); err != nil {
+		return err
+	}
+	shard.Total = shard.TSM + shard.Tombstones + shard.Index + shard.WAL
+
+	for _, m := range shard.measurements {
+		shard.Measurements = append(shard.Measurements, m)
+	}
+	sort.Slice(shard.Measurements, func(i, j int) bool {
+		return shard.Measurements[i].Measurement < shard.Measurements[j].Measurement
+	})
+	return nil
+}
+
+// addMeasurementUsage adds the size of the blocks of the TSM file at path to
+// the measurements of shard.
+func addMeasurementUsage(shard *shardDiskUsage, path string) error {
+	r, err := openTSM(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if shard.measurements == nil {
+		shard.measurements = make(map[string]*measurementDiskUsage)
+	}
+	var entries []tsm1.IndexEntry
+	for i := 0; i < r.KeyCount(); i++ {
+		var key []byte
+		key, _, entries = r.Key(i, &entries)
+		seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key)
+		name, _ := models.ParseKeyBytes(seriesKey)
+		m, ok := shard.measurements[string(name)]
+		if !ok {
+			m = &measurementDiskUsage{Measurement: string(name)}
+			shard.measurements[string(name)] = m
+		}
+		for _, e := range entries {
+			m.Size += int64(e.Size)
+		}
+	}
+	return nil
+}
+
+// dirSize returns the total size of the files under dir, or 0 if dir does
+// not exist.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,59 @@
+package inspect
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportTSM(t *testing.T) {
+	e := newTestEngine(t)
+
+	var report reportTSM
+	require.NoError(t, runCommand(t, NewReportTSMCommand, &report, "--engine-path", e.path, "--exact", "--detailed"))
+	require.Len(t, report.Files, 1)
+	require.Equal(t, len(basicCorpus), report.Files[0].Series)
+	require.Equal(t, uint64(len(basicCorpus)), report.Series)
+	require.Equal(t, len(basicCorpus), report.Measurements)
+	require.Len(t, report.Details, len(basicCorpus))
+	require.Equal(t, "bools", report.Details[0].Measurement)
+	require.Equal(t, map[string]uint64{"k": 1}, report.Details[0].TagValues)
+}
+
+func TestReportDB(t *testing.T) {
+	e := newTestEngine(t)
+
+	var report reportDB
+	require.NoError(t, runCommand(t, NewReportDBCommand, &report, "--engine-path", e.path))
+	require.Equal(t, uint64(len(basicCorpus)), report.Series)
+	require.Len(t, report.Buckets, 1)
+
+	b := report.Buckets[0]
+	require.Equal(t, testBucketID.String(), b.BucketID)
+	require.Equal(t, "autogen", b.RetentionPolicy)
+	require.Equal(t, 1, b.Shards)
+	require.Equal(t, uint64(len(basicCorpus)), b.Fields)
+	require.Empty(t, b.Details)
+}
+
+func TestReportDisk(t *testing.T) {
+	e := newTestEngine(t)
+
+	tsmInfo, err := os.Stat(e.tsmPath)
+	require.NoError(t, err)
+	walInfo, err := os.Stat(e.walPath)
+	require.NoError(t, err)
+
+	var report reportDisk
+	require.NoError(t, runCommand(t, NewReportDiskCommand, &report, "--engine-path", e.path, "--detailed"))
+	require.Len(t, report.Buckets, 1)
+	require.Len(t, report.Buckets[0].Shards, 1)
+
+	shard := report.Buckets[0].Shards[0]
+	require.Equal(t, uint64(1), shard.ShardID)
+	require.Equal(t, tsmInfo.Size(), shard.TSM)
+	require.Equal(t, walInfo.Size(), shard.WAL)
+	require.Equal(t, tsmInfo.Size()+walInfo.Size(), report.Total)
+	require.Len(t, shard.Measurements, len(basicCorpus))
+}
@@ -0,0 +1,126 @@
+package inspect
+
+import (
+	"bytes"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type reportTSMFlags struct {
+	enginePath string
+	bucketID   platform.ID
+	detailed   bool
+	exact      bool
+}
+
+type tsmFileReport struct {
+	Path string `json:"path"`
+	shardLocation
+	Series  int    `json:"series"`
+	MinTime int64  `json:"minTime"`
+	MaxTime int64  `json:"maxTime"`
+	Size    uint32 `json:"size"`
+}
+
+type reportTSM struct {
+	Files []*tsmFileReport `json:"files"`
+	cardinalitySummary
+}
+
+// NewReportTSMCommand builds the `report-tsm` subcommand of `influxd inspect`.
+func NewReportTSMCommand(v *viper.Viper) (*cobra.Command, error) {
+	var flags reportTSMFlags
+
+	cmd := &cobra.Command{
+		Use:   `report-tsm`,
+		Short: "Reports the series cardinality of TSM files",
+		Long: `
+This command prints the number of series, the time range and the size of
+every TSM file of the engine as JSON, followed by the series cardinality
+of all the files. Cardinalities are estimated unless --exact is set.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			report, err := reportTSMFiles(flags)
+			if err != nil {
+				return err
+			}
+			return writeJSON(cmd.OutOrStdout(), report)
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.enginePath,
+			Flag:     "engine-path",
+			Desc:     "path to persistent engine files",
+			Required: true,
+		},
+		{
+			DestP: &flags.bucketID,
+			Flag:  "bucket-id",
+			Desc:  "optional: ID of the bucket to report on, all buckets are reported if not set",
+		},
+		{
+			DestP: &flags.detailed,
+			Flag:  "detailed",
+			Desc:  "report the cardinality of every measurement",
+		},
+		{
+			DestP: &flags.exact,
+			Flag:  "exact",
+			Desc:  "count cardinalities exactly instead of estimating them, this uses more memory",
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func reportTSMFiles(flags reportTSMFlags) (*reportTSM, error) {
+	files, err := engineFiles(flags.enginePath, "data", flags.bucketID, tsm1.TSMFileExtension)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := newCardinalityCounter(flags.exact)
+	report := &reportTSM{Files: []*tsmFileReport{}}
+	for _, path := range files {
+		res, err := reportTSMFile(path, counter)
+		if err != nil {
+			return nil, err
+		}
+		report.Files = append(report.Files, res)
+	}
+	report.cardinalitySummary = counter.summary(flags.detailed)
+	return report, nil
+}
+
+// reportTSMFile reports on the TSM file at path and adds its keys to counter.
+func reportTSMFile(path string, counter *cardinalityCounter) (*tsmFileReport, error) {
+	r, err := openTSM(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	res := &tsmFileReport{Path: path, shardLocation: locateShard(path), Size: r.Size()}
+	res.MinTime, res.MaxTime = r.TimeRange()
+
+	// Keys are sorted, so the keys of a series are next to each other.
+	var prev []byte
+	for i := 0; i < r.KeyCount(); i++ {
+		key, _ := r.KeyAt(i)
+		seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key)
+		if prev == nil || !bytes.Equal(seriesKey, prev) {
+			res.Series++
+			prev = append(prev[:0], seriesKey...)
+		}
+		counter.add(key)
+	}
+	return res, nil
+}
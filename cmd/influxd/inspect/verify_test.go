@@ -0,0 +1,135 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+const testBucketID = platform.ID(0x1234567890abcdef)
+
+// testEngine holds the paths of an engine written from basicCorpus, with a
+// single TSM file and WAL segment in shard 1 of testBucketID.
+type testEngine struct {
+	path    string
+	tsmPath string
+	walPath string
+}
+
+func newTestEngine(t *testing.T) *testEngine {
+	t.Helper()
+
+	dir := t.TempDir()
+	e := &testEngine{
+		path:    dir,
+		tsmPath: filepath.Join(dir, "data", testBucketID.String(), "autogen", "1", "000000001-000000001.tsm"),
+		walPath: filepath.Join(dir, "wal", testBucketID.String(), "autogen", "1", "_00001.wal"),
+	}
+
+	tsmFile, err := writeCorpusToTSMFile(basicCorpus)
+	require.NoError(t, err)
+	walFile, err := writeCorpusToWALFile(basicCorpus)
+	require.NoError(t, err)
+	require.NoError(t, walFile.Close())
+
+	for src, dst := range map[string]string{tsmFile.Name(): e.tsmPath, walFile.Name(): e.walPath} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0777))
+		require.NoError(t, os.Rename(src, dst))
+	}
+	return e
+}
+
+// corrupt overwrites the byte of the file at path found at offset.
+func corrupt(t *testing.T, path string, offset int64) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	b := make([]byte, 1)
+	_, err = f.ReadAt(b, offset)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte{^b[0]}, offset)
+	require.NoError(t, err)
+}
+
+// runCommand runs the command built by newCmd with args, decodes its JSON
+// output into report and returns its error.
+func runCommand(t *testing.T, newCmd func(*viper.Viper) (*cobra.Command, error), report interface{}, args ...string) error {
+	t.Helper()
+
+	cmd, err := newCmd(viper.New())
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs(args)
+	runErr := cmd.Execute()
+	if out.Len() > 0 {
+		require.NoError(t, json.Unmarshal(out.Bytes(), report))
+	}
+	return runErr
+}
+
+func TestVerifyTSM(t *testing.T) {
+	e := newTestEngine(t)
+
+	var report verifyTSMReport
+	require.NoError(t, runCommand(t, NewVerifyTSMCommand, &report, "--engine-path", e.path, "--check-utf8"))
+	require.True(t, report.Healthy)
+	require.Zero(t, report.InvalidKeys)
+
+	report = verifyTSMReport{}
+	require.NoError(t, runCommand(t, NewVerifyTSMCommand, &report, "--engine-path", e.path))
+	require.True(t, report.Healthy)
+	require.Len(t, report.Files, 1)
+	require.Equal(t, len(basicCorpus), report.TotalBlocks)
+	require.Equal(t, testBucketID.String(), report.Files[0].BucketID)
+	require.Equal(t, uint64(1), report.Files[0].ShardID)
+
+	// Corrupt the data of the first block, past the file header and the
+	// block checksum.
+	corrupt(t, e.tsmPath, 10)
+
+	report = verifyTSMReport{}
+	require.Error(t, runCommand(t, NewVerifyTSMCommand, &report, "--engine-path", e.path))
+	require.False(t, report.Healthy)
+	require.Equal(t, 1, report.BrokenBlocks)
+}
+
+func TestVerifyTSM_OtherBucket(t *testing.T) {
+	e := newTestEngine(t)
+
+	var report verifyTSMReport
+	require.NoError(t, runCommand(t, NewVerifyTSMCommand, &report, "--engine-path", e.path, "--bucket-id", platform.ID(1).String()))
+	require.True(t, report.Healthy)
+	require.Empty(t, report.Files)
+}
+
+func TestVerifyWAL(t *testing.T) {
+	e := newTestEngine(t)
+
+	var report verifyWALReport
+	require.NoError(t, runCommand(t, NewVerifyWALCommand, &report, "--engine-path", e.path))
+	require.True(t, report.Healthy)
+	require.Equal(t, 1, report.Entries)
+
+	// Corrupt the compressed entry, past its type and length.
+	corrupt(t, e.walPath, 8)
+
+	report = verifyWALReport{}
+	require.Error(t, runCommand(t, NewVerifyWALCommand, &report, "--engine-path", e.path))
+	require.False(t, report.Healthy)
+	require.NotEmpty(t, report.Files[0].Error)
+}
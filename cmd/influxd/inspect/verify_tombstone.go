@@ -0,0 +1,100 @@
+package inspect
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type verifyTombstoneFlags struct {
+	enginePath string
+	bucketID   platform.ID
+}
+
+// tombstoneVerification is the result of the verification of a tombstone file.
+type tombstoneVerification struct {
+	Path string `json:"path"`
+	shardLocation
+	Entries int64  `json:"entries"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+type verifyTombstoneReport struct {
+	Files   []*tombstoneVerification `json:"files"`
+	Entries int64                    `json:"entries"`
+	Healthy bool                     `json:"healthy"`
+}
+
+// NewVerifyTombstoneCommand builds the `verify-tombstone` subcommand of `influxd inspect`.
+func NewVerifyTombstoneCommand(v *viper.Viper) (*cobra.Command, error) {
+	var flags verifyTombstoneFlags
+
+	cmd := &cobra.Command{
+		Use:   `verify-tombstone`,
+		Short: "Verifies the integrity of tombstone files",
+		Long: `
+This command reads every entry of the tombstone files of the engine.
+It prints a JSON report and fails if any file can't be read.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			report, err := verifyTombstones(flags)
+			if err != nil {
+				return err
+			}
+			if err := writeJSON(cmd.OutOrStdout(), report); err != nil {
+				return err
+			}
+			if !report.Healthy {
+				return fmt.Errorf("tombstone verification failed")
+			}
+			return nil
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.enginePath,
+			Flag:     "engine-path",
+			Desc:     "path to persistent engine files",
+			Required: true,
+		},
+		{
+			DestP: &flags.bucketID,
+			Flag:  "bucket-id",
+			Desc:  "optional: ID of the bucket to verify, all buckets are verified if not set",
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func verifyTombstones(flags verifyTombstoneFlags) (*verifyTombstoneReport, error) {
+	files, err := engineFiles(flags.enginePath, "data", flags.bucketID, tsm1.TombstoneFileExtension)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &verifyTombstoneReport{Files: []*tombstoneVerification{}, Healthy: true}
+	for _, path := range files {
+		res := &tombstoneVerification{Path: path, shardLocation: locateShard(path), Healthy: true}
+		err := tsm1.NewTombstoner(path, nil).Walk(func(t tsm1.Tombstone) error {
+			res.Entries++
+			return nil
+		})
+		if err != nil {
+			res.Healthy = false
+			res.Error = fmt.Sprintf("failed to read entry %d: %v", res.Entries+1, err)
+			report.Healthy = false
+		}
+		report.Entries += res.Entries
+		report.Files = append(report.Files, res)
+	}
+	return report, nil
+}
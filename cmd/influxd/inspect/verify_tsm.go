@@ -0,0 +1,169 @@
+package inspect
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+	"unicode/utf8"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type verifyTSMFlags struct {
+	enginePath string
+	bucketID   platform.ID
+	checkUTF8  bool
+}
+
+// tsmVerification is the result of the verification of a TSM file.
+type tsmVerification struct {
+	Path string `json:"path"`
+	shardLocation
+	Blocks  int  `json:"blocks"`
+	Keys    int  `json:"keys"`
+	Healthy bool `json:"healthy"`
+	fileErrors
+}
+
+type verifyTSMReport struct {
+	Files        []*tsmVerification `json:"files"`
+	TotalBlocks  int                `json:"totalBlocks"`
+	BrokenBlocks int                `json:"brokenBlocks"`
+	InvalidKeys  int                `json:"invalidKeys"`
+	Healthy      bool               `json:"healthy"`
+}
+
+// NewVerifyTSMCommand builds the `verify-tsm` subcommand of `influxd inspect`.
+func NewVerifyTSMCommand(v *viper.Viper) (*cobra.Command, error) {
+	var flags verifyTSMFlags
+
+	cmd := &cobra.Command{
+		Use:   `verify-tsm`,
+		Short: "Verifies the integrity of TSM files",
+		Long: `
+This command checks the checksums of all the blocks of the TSM files
+of the engine, and optionally that all the series keys are valid UTF-8.
+It prints a JSON report and fails if any file is not healthy.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			report, err := verifyTSM(flags)
+			if err != nil {
+				return err
+			}
+			if err := writeJSON(cmd.OutOrStdout(), report); err != nil {
+				return err
+			}
+			if !report.Healthy {
+				return fmt.Errorf("TSM verification failed: %d broken blocks, %d invalid keys", report.BrokenBlocks, report.InvalidKeys)
+			}
+			return nil
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.enginePath,
+			Flag:     "engine-path",
+			Desc:     "path to persistent engine files",
+			Required: true,
+		},
+		{
+			DestP: &flags.bucketID,
+			Flag:  "bucket-id",
+			Desc:  "optional: ID of the bucket to verify, all buckets are verified if not set",
+		},
+		{
+			DestP: &flags.checkUTF8,
+			Flag:  "check-utf8",
+			Desc:  "verify that series keys are valid UTF-8 instead of verifying block checksums",
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func verifyTSM(flags verifyTSMFlags) (*verifyTSMReport, error) {
+	files, err := engineFiles(flags.enginePath, "data", flags.bucketID, tsm1.TSMFileExtension)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &verifyTSMReport{Files: []*tsmVerification{}, Healthy: true}
+	for _, path := range files {
+		res := &tsmVerification{Path: path, shardLocation: locateShard(path)}
+		if flags.checkUTF8 {
+			verifyTSMKeys(res)
+			report.InvalidKeys += res.Count
+		} else {
+			verifyTSMBlocks(res)
+			report.TotalBlocks += res.Blocks
+			report.BrokenBlocks += res.Count
+		}
+		res.Healthy = res.Count == 0
+		report.Healthy = report.Healthy && res.Healthy
+		report.Files = append(report.Files, res)
+	}
+	return report, nil
+}
+
+func openTSM(path string) (*tsm1.TSMReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// verifyTSMBlocks checks the checksum of every block of a TSM file.
+func verifyTSMBlocks(res *tsmVerification) {
+	r, err := openTSM(res.Path)
+	if err != nil {
+		res.add("could not open file: %v", err)
+		return
+	}
+	defer r.Close()
+
+	res.Keys = r.KeyCount()
+	itr := r.BlockIterator()
+	for itr.Next() {
+		key, _, _, _, checksum, buf, err := itr.Read()
+		if err != nil {
+			res.add("could not read block %d of key %q: %v", res.Blocks, key, err)
+		} else if expected := crc32.ChecksumIEEE(buf); checksum != expected {
+			res.add("got checksum %d but expected %d for block %d of key %q", checksum, expected, res.Blocks, key)
+		}
+		res.Blocks++
+	}
+	if err := itr.Err(); err != nil {
+		res.add("could not iterate blocks: %v", err)
+	}
+}
+
+// verifyTSMKeys checks that all the keys of a TSM file are valid UTF-8.
+func verifyTSMKeys(res *tsmVerification) {
+	r, err := openTSM(res.Path)
+	if err != nil {
+		res.add("could not open file: %v", err)
+		return
+	}
+	defer r.Close()
+
+	res.Keys = r.KeyCount()
+	for i := 0; i < res.Keys; i++ {
+		key, _ := r.KeyAt(i)
+		if !utf8.Valid(key) {
+			res.add("key #%d is not valid UTF-8: %q", i, key)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/influxdata/influxdb/v2/bolt"
 	"github.com/influxdata/influxdb/v2/fluxinit"
+	"github.com/influxdata/influxdb/v2/http/points"
 	"github.com/influxdata/influxdb/v2/internal/fs"
 	"github.com/influxdata/influxdb/v2/kit/cli"
 	"github.com/influxdata/influxdb/v2/kit/signals"
@@ -135,17 +136,21 @@ type InfluxdOpts struct {
 	SecretStore string
 	VaultConfig vault.Config
 
-	HttpBindAddress       string
-	HttpReadHeaderTimeout time.Duration
-	HttpReadTimeout       time.Duration
-	HttpWriteTimeout      time.Duration
-	HttpIdleTimeout       time.Duration
-	HttpTLSCert           string
-	HttpTLSKey            string
-	HttpTLSMinVersion     string
-	HttpTLSStrictCiphers  bool
-	SessionLength         int // in minutes
-	SessionRenewDisabled  bool
+	HttpBindAddress        string
+	HttpReadHeaderTimeout  time.Duration
+	HttpReadTimeout        time.Duration
+	HttpWriteTimeout       time.Duration
+	HttpIdleTimeout        time.Duration
+	WriteIdempotencyWindow time.Duration
+	WriteIdempotencyKeys   int
+	WriteSpoolDir          string
+	WriteSpoolBytes        int
+	HttpTLSCert            string
+	HttpTLSKey             string
+	HttpTLSMinVersion      string
+	HttpTLSStrictCiphers   bool
+	SessionLength          int // in minutes
+	SessionRenewDisabled   bool
 
 	ProfilingDisabled bool
 	MetricsDisabled   bool
@@ -162,8 +167,21 @@ type InfluxdOpts struct {
 	MemoryBytesQuotaPerQuery        int64
 	MaxMemoryBytes                  int64
 	QueueSize                       int32
+	OrgConcurrencyQuota             int32
+	OrgQueueSize                    int32
+	OrgMemoryBytes                  int64
+	OrgQueryTime                    time.Duration
+	OrgQueryTimeWindow              time.Duration
+	QueryLogEnabled                 bool
+	SlowQueryThreshold              time.Duration
+	QueryCacheMaxBytes              int64
+	QueryCacheMaxAge                time.Duration
 	CoordinatorConfig               coordinator.Config
 
+	// Listener options.
+	Listeners        []string
+	IngestConfigPath string
+
 	// Storage options.
 	StorageConfig storage.Config
 
@@ -189,13 +207,16 @@ func NewOpts(viper *viper.Viper) *InfluxdOpts {
 		SqLitePath: filepath.Join(dir, sqlite.DefaultFilename),
 		EnginePath: filepath.Join(dir, "engine"),
 
-		HttpBindAddress:       ":8086",
-		HttpReadHeaderTimeout: 10 * time.Second,
-		HttpIdleTimeout:       3 * time.Minute,
-		HttpTLSMinVersion:     "1.2",
-		HttpTLSStrictCiphers:  false,
-		SessionLength:         60, // 60 minutes
-		SessionRenewDisabled:  false,
+		HttpBindAddress:        ":8086",
+		HttpReadHeaderTimeout:  10 * time.Second,
+		HttpIdleTimeout:        3 * time.Minute,
+		WriteIdempotencyWindow: 10 * time.Minute,
+		WriteIdempotencyKeys:   100000,
+		WriteSpoolBytes:        points.DefaultSpoolBytes,
+		HttpTLSMinVersion:      "1.2",
+		HttpTLSStrictCiphers:   false,
+		SessionLength:          60, // 60 minutes
+		SessionRenewDisabled:   false,
 
 		ProfilingDisabled: false,
 		MetricsDisabled:   false,
@@ -213,6 +234,15 @@ func NewOpts(viper *viper.Viper) *InfluxdOpts {
 		MemoryBytesQuotaPerQuery:        MaxInt,
 		MaxMemoryBytes:                  0,
 		QueueSize:                       1024,
+		OrgConcurrencyQuota:             0,
+		OrgQueueSize:                    0,
+		OrgMemoryBytes:                  0,
+		OrgQueryTime:                    0,
+		OrgQueryTimeWindow:              time.Hour,
+		QueryLogEnabled:                 false,
+		SlowQueryThreshold:              10 * time.Second,
+		QueryCacheMaxBytes:              0,
+		QueryCacheMaxAge:                time.Hour,
 
 		Testing:                 false,
 		TestingAlwaysAllowSetup: false,
@@ -380,6 +410,29 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.HttpIdleTimeout,
 			Desc:    "max duration the server should keep established connections alive while waiting for new requests. Set to 0 for no timeout",
 		},
+		{
+			DestP:   &o.WriteIdempotencyWindow,
+			Flag:    "write-idempotency-window",
+			Default: o.WriteIdempotencyWindow,
+			Desc:    "how long the result of a write sent with an Idempotency-Key header is remembered, so that retries of the batch with the same key are not written again. Set to 0 to ignore the header",
+		},
+		{
+			DestP:   &o.WriteIdempotencyKeys,
+			Flag:    "write-idempotency-keys",
+			Default: o.WriteIdempotencyKeys,
+			Desc:    "maximum number of idempotency keys whose write result is remembered, the least recently used are forgotten first. Set to 0 for no limit",
+		},
+		{
+			DestP:   &o.WriteSpoolBytes,
+			Flag:    "write-spool-bytes",
+			Default: o.WriteSpoolBytes,
+			Desc:    "size of the write batches held in memory while they are validated. Larger batches are copied to a temporary file in write-spool-dir",
+		},
+		{
+			DestP: &o.WriteSpoolDir,
+			Flag:  "write-spool-dir",
+			Desc:  "directory of the temporary files holding the write batches larger than write-spool-bytes while they are validated. Defaults to the directory of temporary files of the system",
+		},
 		{
 			DestP: &o.HttpTLSCert,
 			Flag:  "tls-cert",
@@ -439,6 +492,70 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.QueueSize,
 			Desc:    "the number of queries that are allowed to be awaiting execution before new queries are rejected. Must be > 0 if query-concurrency is not unlimited",
 		},
+		{
+			DestP:   &o.OrgConcurrencyQuota,
+			Flag:    "query-org-concurrency",
+			Default: o.OrgConcurrencyQuota,
+			Desc:    "the number of queries of a single organization that are allowed to execute concurrently. Set to 0 to disable the per-organization limit",
+		},
+		{
+			DestP:   &o.OrgQueueSize,
+			Flag:    "query-org-queue-size",
+			Default: o.OrgQueueSize,
+			Desc:    "the number of queries of a single organization that are allowed to be awaiting execution before new queries of that organization are rejected. Set to 0 to disable the per-organization limit",
+		},
+		{
+			DestP:   &o.OrgMemoryBytes,
+			Flag:    "query-org-memory-bytes",
+			Default: o.OrgMemoryBytes,
+			Desc:    "the maximum number of bytes the queries of a single organization are allowed to use at any given time. Must be >= query-initial-memory-bytes. Set to 0 to disable the per-organization limit",
+		},
+		{
+			DestP:   &o.OrgQueryTime,
+			Flag:    "query-org-time",
+			Default: o.OrgQueryTime,
+			Desc:    "the total query execution time a single organization is allowed to use within each query-org-time-window. Set to 0 to disable the per-organization limit",
+		},
+		{
+			DestP:   &o.OrgQueryTimeWindow,
+			Flag:    "query-org-time-window",
+			Default: o.OrgQueryTimeWindow,
+			Desc:    "the window query-org-time is accounted in",
+		},
+		{
+			DestP:   &o.QueryLogEnabled,
+			Flag:    "query-log-enabled",
+			Default: o.QueryLogEnabled,
+			Desc:    "record every completed query in the _queries system bucket of its organization",
+		},
+		{
+			DestP:   &o.SlowQueryThreshold,
+			Flag:    "query-log-slow-threshold",
+			Default: o.SlowQueryThreshold,
+			Desc:    "queries taking at least this long are additionally recorded with their full text as slow queries when query-log-enabled is set. Set to 0 to disable the slow query log",
+		},
+		{
+			DestP:   &o.QueryCacheMaxBytes,
+			Flag:    "query-cache-max-bytes",
+			Default: o.QueryCacheMaxBytes,
+			Desc:    "the maximum memory in bytes used to cache the results of Flux queries over absolute, past time ranges. Set to 0 to disable the cache",
+		},
+		{
+			DestP:   &o.QueryCacheMaxAge,
+			Flag:    "query-cache-max-age",
+			Default: o.QueryCacheMaxAge,
+			Desc:    "the maximum time a result stays in the query cache. Set to 0 to keep results until they are invalidated by writes or deletes",
+		},
+		{
+			DestP: &o.Listeners,
+			Flag:  "listeners",
+			Desc:  "UDP or TCP listeners receiving line protocol, each written to one bucket with the permissions of a token, such as udp://:8089?org=my-org&bucket=my-bucket&token-env=MY_TOKEN. The token is read from the environment variable named by token-env or from the file named by token-file. Optional parameters are batch-size, batch-timeout, read-buffer and precision",
+		},
+		{
+			DestP: &o.IngestConfigPath,
+			Flag:  "ingest-config",
+			Desc:  "path to a TOML file with [[graphite]] and [[opentsdb]] input sections in the 1.x format, each with the org, bucket and token the points are written with",
+		},
 		{
 			DestP: &o.FeatureFlags,
 			Flag:  "feature-flags",
@@ -481,6 +598,11 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-compact-throughput-burst",
 			Desc:  "The rate limit in bytes per second that we will allow TSM compactions to write to disk.",
 		},
+		{
+			DestP: &o.StorageConfig.Data.TSMBlockCompression,
+			Flag:  "storage-tsm-block-compression",
+			Desc:  "The compression of TSM float and string blocks written by compactions, default or zstd. Blocks compressed with zstd can't be read by older versions.",
+		},
 		// limits
 		{
 			DestP: &o.StorageConfig.Data.MaxConcurrentCompactions,
@@ -497,6 +619,11 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-series-id-set-cache-size",
 			Desc:  "The size of the internal cache used in the TSI index to store previously calculated series results.",
 		},
+		{
+			DestP: &o.StorageConfig.Data.BucketMemoryBudget,
+			Flag:  "storage-bucket-memory-budget",
+			Desc:  "The heap memory, in bytes, the shards of a bucket may use for their index, series ID set cache and cache. The shards of a bucket using more memory write their caches to TSM files, compact their index log files and empty their series ID set caches. The budget applies to each bucket on its own, not to all buckets together. A value of 0 disables the budget.",
+		},
 		{
 			DestP: &o.StorageConfig.Data.SeriesFileMaxConcurrentSnapshotCompactions,
 			Flag:  "storage-series-file-max-concurrent-snapshot-compactions",
@@ -522,6 +649,16 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-shard-precreator-advance-period",
 			Desc:  "The default period ahead of the endtime of a shard group that its successor group is created.",
 		},
+		{
+			DestP: &o.StorageConfig.Data.ColdDir,
+			Flag:  "storage-cold-dir",
+			Desc:  "The directory shards are moved to once they reach the cold tier of their bucket, such as a larger, slower volume or a mounted object store. Shards on the cold tier are read-only. Tiering is disabled if unset.",
+		},
+		{
+			DestP: &o.StorageConfig.TieringService.CheckInterval,
+			Flag:  "storage-tiering-check-interval",
+			Desc:  "The interval of time when the check to move shards to the cold tier runs.",
+		},
 
 		// InfluxQL Coordinator Config
 		{
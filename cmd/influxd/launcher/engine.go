@@ -30,6 +30,7 @@ type Engine interface {
 	prom.PrometheusCollector
 	influxdb.BackupService
 	influxdb.RestoreService
+	influxdb.StorageService
 
 	SeriesCardinality(ctx context.Context, bucketID platform.ID) int64
 
@@ -180,6 +181,74 @@ func (t *TemporaryEngine) RestoreShard(ctx context.Context, shardID uint64, r io
 	return t.engine.RestoreShard(ctx, shardID, r)
 }
 
+func (t *TemporaryEngine) ListShards(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageShard, error) {
+	return t.engine.ListShards(ctx, filter)
+}
+
+func (t *TemporaryEngine) FindShardByID(ctx context.Context, id uint64) (*influxdb.StorageShard, error) {
+	return t.engine.FindShardByID(ctx, id)
+}
+
+func (t *TemporaryEngine) CompactShard(ctx context.Context, id uint64) error {
+	return t.engine.CompactShard(ctx, id)
+}
+
+func (t *TemporaryEngine) SnapshotShard(ctx context.Context, id uint64) error {
+	return t.engine.SnapshotShard(ctx, id)
+}
+
+func (t *TemporaryEngine) RebuildShardIndex(ctx context.Context, id uint64) error {
+	return t.engine.RebuildShardIndex(ctx, id)
+}
+
+func (t *TemporaryEngine) ExportShard(ctx context.Context, id uint64, start, stop time.Time, w io.Writer) error {
+	return t.engine.ExportShard(ctx, id, start, stop, w)
+}
+
+func (t *TemporaryEngine) ImportShard(ctx context.Context, bucketID platform.ID, start, stop time.Time, r io.Reader) error {
+	return t.engine.ImportShard(ctx, bucketID, start, stop, r)
+}
+
+func (t *TemporaryEngine) MoveShardToColdTier(ctx context.Context, id uint64) error {
+	return t.engine.MoveShardToColdTier(ctx, id)
+}
+
+func (t *TemporaryEngine) FindTieringPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageTieringPolicy, error) {
+	return t.engine.FindTieringPolicy(ctx, bucketID)
+}
+
+func (t *TemporaryEngine) UpdateTieringPolicy(ctx context.Context, policy influxdb.StorageTieringPolicy) (*influxdb.StorageTieringPolicy, error) {
+	return t.engine.UpdateTieringPolicy(ctx, policy)
+}
+
+func (t *TemporaryEngine) ListCompactions(ctx context.Context, filter influxdb.StorageShardFilter) (*influxdb.StorageCompactions, error) {
+	return t.engine.ListCompactions(ctx, filter)
+}
+
+func (t *TemporaryEngine) FindCompactionLimits(ctx context.Context) (*influxdb.StorageCompactionLimits, error) {
+	return t.engine.FindCompactionLimits(ctx)
+}
+
+func (t *TemporaryEngine) UpdateCompactionLimits(ctx context.Context, upd influxdb.StorageCompactionLimitsUpdate) (*influxdb.StorageCompactionLimits, error) {
+	return t.engine.UpdateCompactionLimits(ctx, upd)
+}
+
+func (t *TemporaryEngine) FindDurabilityPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageDurabilityPolicy, error) {
+	return t.engine.FindDurabilityPolicy(ctx, bucketID)
+}
+
+func (t *TemporaryEngine) UpdateDurabilityPolicy(ctx context.Context, policy influxdb.StorageDurabilityPolicy) (*influxdb.StorageDurabilityPolicy, error) {
+	return t.engine.UpdateDurabilityPolicy(ctx, policy)
+}
+
+func (t *TemporaryEngine) ListMemoryUsage(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageBucketMemoryUsage, error) {
+	return t.engine.ListMemoryUsage(ctx, filter)
+}
+
+func (t *TemporaryEngine) ReshardBucket(ctx context.Context, bucketID platform.ID) (*influxdb.StorageReshardStep, error) {
+	return t.engine.ReshardBucket(ctx, bucketID)
+}
+
 func (t *TemporaryEngine) TSDBStore() storage.TSDBStore {
 	return &t.tsdbStore
 }
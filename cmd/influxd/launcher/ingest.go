@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/v1/services/graphite"
+	"github.com/influxdata/influxdb/v2/v1/services/opentsdb"
+)
+
+// ingestConfig holds the [[graphite]] and [[opentsdb]] sections of a 1.x
+// configuration file. Its other sections are ignored.
+type ingestConfig struct {
+	Graphite []graphite.Config `toml:"graphite"`
+	OpenTSDB []opentsdb.Config `toml:"opentsdb"`
+}
+
+func loadIngestConfig(path string) (ingestConfig, error) {
+	var c ingestConfig
+	if _, err := toml.DecodeFile(path, &c); err != nil {
+		return ingestConfig{}, fmt.Errorf("failed to load ingest config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// addIngestListeners adds a listener for each enabled Graphite and OpenTSDB
+// input of c.
+func addIngestListeners(s *listener.Service, c ingestConfig) error {
+	for _, gc := range c.Graphite {
+		if !gc.Enabled {
+			continue
+		}
+		gc = gc.WithDefaults()
+		lc, err := gc.ListenerConfig()
+		if err != nil {
+			return err
+		}
+		p, err := graphite.NewParser(gc.Templates, gc.Tags, gc.Separator)
+		if err != nil {
+			return fmt.Errorf("graphite %s: %w", gc.BindAddress, err)
+		}
+		s.Add(lc, listener.Protocol{Parse: p.Parse})
+	}
+
+	for _, oc := range c.OpenTSDB {
+		if !oc.Enabled {
+			continue
+		}
+		oc = oc.WithDefaults()
+		lc, err := oc.ListenerConfig()
+		if err != nil {
+			return err
+		}
+		s.Add(lc, opentsdb.Protocol(oc))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package launcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const testIngestConfig = `
+reporting-disabled = false
+
+[meta]
+  dir = "/var/lib/influxdb/meta"
+
+[[graphite]]
+  enabled = true
+  bind-address = ":2003"
+  database = "facility"
+  protocol = "tcp"
+  batch-size = 5000
+  batch-pending = 10
+  batch-timeout = "1s"
+  consistency-level = "one"
+  separator = "_"
+  templates = ["facility.* .room.measurement.field*"]
+  org = "my-org"
+  token-env = "INGEST_TOKEN"
+
+[[graphite]]
+  enabled = false
+
+[[opentsdb]]
+  enabled = true
+  bind-address = ":4242"
+  database = "pdu"
+  org = "my-org"
+  token-env = "INGEST_TOKEN"
+`
+
+func TestLoadIngestConfig(t *testing.T) {
+	require.NoError(t, os.Setenv("INGEST_TOKEN", "my-token"))
+	defer os.Unsetenv("INGEST_TOKEN")
+
+	dir, err := ioutil.TempDir("", "ingest-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "influxdb.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testIngestConfig), 0600))
+
+	c, err := loadIngestConfig(path)
+	require.NoError(t, err)
+	require.Len(t, c.Graphite, 2)
+	require.Equal(t, []string{"facility.* .room.measurement.field*"}, c.Graphite[0].Templates)
+	require.Equal(t, "_", c.Graphite[0].Separator)
+	require.Len(t, c.OpenTSDB, 1)
+	require.Equal(t, "pdu", c.OpenTSDB[0].Database)
+
+	s := listener.NewService(zaptest.NewLogger(t), nil, nil, nil, nil, nil)
+	require.NoError(t, addIngestListeners(s, c))
+
+	c.OpenTSDB[0].TokenEnv = ""
+	require.Error(t, addIngestListeners(s, c))
+
+	// A token in plain text is rejected.
+	c.OpenTSDB[0].Token = "my-token"
+	require.Error(t, addIngestListeners(s, c))
+
+	_, err = loadIngestConfig(filepath.Join(dir, "missing.conf"))
+	require.Error(t, err)
+}
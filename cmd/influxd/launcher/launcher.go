@@ -27,8 +27,10 @@ import (
 	"github.com/influxdata/influxdb/v2/dbrp"
 	"github.com/influxdata/influxdb/v2/gather"
 	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/influxql"
 	iqlcontrol "github.com/influxdata/influxdb/v2/influxql/control"
 	iqlquery "github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/ingest"
 	"github.com/influxdata/influxdb/v2/inmem"
 	"github.com/influxdata/influxdb/v2/internal/resource"
 	"github.com/influxdata/influxdb/v2/kit/feature"
@@ -42,6 +44,8 @@ import (
 	"github.com/influxdata/influxdb/v2/kv/migration"
 	"github.com/influxdata/influxdb/v2/kv/migration/all"
 	"github.com/influxdata/influxdb/v2/label"
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/mqtt"
 	"github.com/influxdata/influxdb/v2/nats"
 	notebookSvc "github.com/influxdata/influxdb/v2/notebooks/service"
 	notebookTransport "github.com/influxdata/influxdb/v2/notebooks/transport"
@@ -49,9 +53,13 @@ import (
 	ruleservice "github.com/influxdata/influxdb/v2/notification/rule/service"
 	"github.com/influxdata/influxdb/v2/pkger"
 	infprom "github.com/influxdata/influxdb/v2/prometheus"
+	promremote "github.com/influxdata/influxdb/v2/prometheus/remote"
 	"github.com/influxdata/influxdb/v2/query"
+	querycache "github.com/influxdata/influxdb/v2/query/cache"
 	"github.com/influxdata/influxdb/v2/query/control"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
+	"github.com/influxdata/influxdb/v2/query/querylog"
+	"github.com/influxdata/influxdb/v2/query/registry"
 	"github.com/influxdata/influxdb/v2/query/stdlib/influxdata/influxdb"
 	"github.com/influxdata/influxdb/v2/secret"
 	"github.com/influxdata/influxdb/v2/session"
@@ -122,6 +130,9 @@ type Launcher struct {
 	// InfluxQL query engine
 	queryController *control.Controller
 
+	// UDP and TCP line protocol listeners
+	listeners *listener.Service
+
 	httpPort   int
 	httpServer *nethttp.Server
 	tlsEnabled bool
@@ -177,6 +188,14 @@ func (m *Launcher) Shutdown(ctx context.Context) error {
 		errs = append(errs, err.Error())
 	}
 
+	if m.listeners != nil {
+		m.log.Info("Stopping", zap.String("service", "listeners"))
+		if err := m.listeners.Close(); err != nil {
+			m.log.Error("Failed to close listeners", zap.Error(err))
+			errs = append(errs, err.Error())
+		}
+	}
+
 	m.log.Info("Stopping", zap.String("service", "task"))
 
 	m.scheduler.Stop()
@@ -457,11 +476,54 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		pointsWriter   storage.PointsWriter    = m.engine
 		backupService  platform.BackupService  = m.engine
 		restoreService platform.RestoreService = m.engine
+		storageService platform.StorageService = m.engine
 	)
 
+	var queryCache *querycache.Cache
+	if opts.QueryCacheMaxBytes > 0 {
+		queryCache = querycache.New(querycache.Config{
+			MaxBytes: opts.QueryCacheMaxBytes,
+			MaxAge:   opts.QueryCacheMaxAge,
+		})
+		m.reg.MustRegister(queryCache.PrometheusCollectors()...)
+		pointsWriter = &querycache.PointsWriter{Underlying: pointsWriter, Cache: queryCache}
+		deleteService = &querycache.DeleteService{Underlying: deleteService, Cache: queryCache}
+		restoreService = &querycache.RestoreService{Underlying: restoreService, Cache: queryCache}
+		storageService = &querycache.StorageService{StorageService: storageService, Cache: queryCache}
+	}
+
+	if len(opts.Listeners) > 0 || opts.IngestConfigPath != "" {
+		configs := make([]listener.Config, 0, len(opts.Listeners))
+		for _, spec := range opts.Listeners {
+			c, err := listener.ParseConfig(spec)
+			if err != nil {
+				m.log.Error("Invalid listener", zap.Error(err))
+				return err
+			}
+			configs = append(configs, c)
+		}
+		m.listeners = listener.NewService(m.log.With(zap.String("service", "listeners")), configs, pointsWriter, authSvc, ts.OrganizationService, ts.BucketService)
+		if opts.IngestConfigPath != "" {
+			ingestConf, err := loadIngestConfig(opts.IngestConfigPath)
+			if err == nil {
+				err = addIngestListeners(m.listeners, ingestConf)
+			}
+			if err != nil {
+				m.log.Error("Invalid ingest config", zap.Error(err))
+				return err
+			}
+		}
+		if err := m.listeners.Open(ctx); err != nil {
+			m.log.Error("Failed to open listeners", zap.Error(err))
+			return err
+		}
+		m.reg.MustRegister(m.listeners.PrometheusCollectors()...)
+	}
+
+	storageStore := storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient())
 	deps, err := influxdb.NewDependencies(
-		storageflux.NewReader(storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient())),
-		m.engine,
+		storageflux.NewReader(storageStore),
+		pointsWriter,
 		authorizer.NewBucketService(ts.BucketService),
 		authorizer.NewOrgService(ts.OrganizationService),
 		authorizer.NewSecretService(secretSvc),
@@ -477,13 +539,22 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		dependencyList = append(dependencyList, testing.FrameworkConfig{})
 	}
 
+	queryRegistry := registry.New()
 	m.queryController, err = control.New(control.Config{
 		ConcurrencyQuota:                opts.ConcurrencyQuota,
 		InitialMemoryBytesQuotaPerQuery: opts.InitialMemoryBytesQuotaPerQuery,
 		MemoryBytesQuotaPerQuery:        opts.MemoryBytesQuotaPerQuery,
 		MaxMemoryBytes:                  opts.MaxMemoryBytes,
 		QueueSize:                       opts.QueueSize,
-		ExecutorDependencies:            dependencyList,
+		PerOrgQuota: control.OrgQuota{
+			ConcurrencyQuota: opts.OrgConcurrencyQuota,
+			QueueSize:        opts.OrgQueueSize,
+			MemoryBytes:      opts.OrgMemoryBytes,
+			QueryTime:        opts.OrgQueryTime,
+			QueryTimeWindow:  opts.OrgQueryTimeWindow,
+		},
+		Registry:             queryRegistry,
+		ExecutorDependencies: dependencyList,
 	}, m.log.With(zap.String("service", "storage-reads")))
 	if err != nil {
 		m.log.Error("Failed to create query controller", zap.Error(err))
@@ -589,7 +660,9 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		MaxSelectPointN:   opts.CoordinatorConfig.MaxSelectPointN,
 		MaxSelectSeriesN:  opts.CoordinatorConfig.MaxSelectSeriesN,
 		MaxSelectBucketsN: opts.CoordinatorConfig.MaxSelectBucketsN,
+		Registry:          queryRegistry,
 	}
+	qe.Registry = queryRegistry
 	qe.StatementExecutor = se
 	qe.StatementNormalizer = se
 
@@ -664,6 +737,19 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		log.Info("Stopping")
 	}(m.log)
 
+	mqttStore := mqtt.NewService(ts.BucketService, m.kvStore)
+	mqttSvc := mqtt.NewAuthorizedService(mqttStore)
+	mqttSubscriber := mqtt.NewSubscriber(m.log.With(zap.String("service", "mqtt")), mqttStore, pointsWriter)
+	m.wg.Add(1)
+	go func(log *zap.Logger) {
+		defer m.wg.Done()
+		log = log.With(zap.String("service", "mqtt"))
+		if err := mqttSubscriber.Run(ctx); err != nil {
+			log.Error("Failed MQTT subscriber service", zap.Error(err))
+		}
+		log.Info("Stopping")
+	}(m.log)
+
 	if m.flagger == nil {
 		m.flagger = feature.DefaultFlagger()
 		if len(opts.FeatureFlags) > 0 {
@@ -759,21 +845,44 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		NotificationRuleFinder:     notificationRuleSvc,
 	}
 
+	var (
+		fluxQueryService query.ProxyQueryService    = storageQueryService
+		influxqldService influxql.ProxyQueryService = iqlquery.NewProxyExecutor(m.log, qe)
+	)
+	if queryCache != nil {
+		fluxQueryService = querycache.NewProxyQueryService(m.log.With(zap.String("service", "query-cache")), queryCache, ts.BucketService, fluxQueryService)
+	}
+	if opts.QueryLogEnabled {
+		queryRecorder := querylog.NewRecorder(
+			m.log.With(zap.String("service", "query-log")),
+			pointsWriter,
+			ts.BucketService,
+			querylog.WithSlowQueryThreshold(opts.SlowQueryThreshold),
+		)
+		fluxQueryService = query.NewLoggingProxyQueryService(m.log.With(zap.String("service", "query-log")), queryRecorder, fluxQueryService)
+		influxqldService = querylog.NewInfluxQLService(queryRecorder, influxqldService)
+	}
+
+	ingestRulesSvc := ingest.NewService(ts.BucketService, m.kvStore)
+	ingestPointsWriter := ingest.NewPointsWriter(m.log.With(zap.String("service", "ingest")), &storage.LoggingPointsWriter{
+		Underlying:    pointsWriter,
+		BucketFinder:  ts.BucketService,
+		LogBucketName: platform.MonitoringSystemBucketName,
+	}, ingestRulesSvc)
+	m.reg.MustRegister(ingestPointsWriter.PrometheusCollectors()...)
+
 	m.apibackend = &http.APIBackend{
-		AssetsPath:           opts.AssetsPath,
-		HTTPErrorHandler:     kithttp.ErrorHandler(0),
-		Logger:               m.log,
-		SessionRenewDisabled: opts.SessionRenewDisabled,
-		NewBucketService:     source.NewBucketService,
-		NewQueryService:      source.NewQueryService,
-		PointsWriter: &storage.LoggingPointsWriter{
-			Underlying:    pointsWriter,
-			BucketFinder:  ts.BucketService,
-			LogBucketName: platform.MonitoringSystemBucketName,
-		},
+		AssetsPath:             opts.AssetsPath,
+		HTTPErrorHandler:       kithttp.ErrorHandler(0),
+		Logger:                 m.log,
+		SessionRenewDisabled:   opts.SessionRenewDisabled,
+		NewBucketService:       source.NewBucketService,
+		NewQueryService:        source.NewQueryService,
+		PointsWriter:           ingestPointsWriter,
 		DeleteService:          deleteService,
 		BackupService:          backupService,
 		RestoreService:         restoreService,
+		StorageService:         storageService,
 		AuthorizationService:   authSvc,
 		AuthorizationV1Service: authSvcV1,
 		PasswordV1Service:      passwordV1,
@@ -801,9 +910,9 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		SourceService:                   sourceSvc,
 		VariableService:                 variableSvc,
 		PasswordsService:                ts.PasswordsService,
-		InfluxQLService:                 storageQueryService,
-		InfluxqldService:                iqlquery.NewProxyExecutor(m.log, qe),
-		FluxService:                     storageQueryService,
+		InfluxQLService:                 fluxQueryService,
+		InfluxqldService:                influxqldService,
+		FluxService:                     fluxQueryService,
 		FluxLanguageService:             fluxlang.DefaultService,
 		TaskService:                     taskSvc,
 		TelegrafService:                 telegrafSvc,
@@ -821,6 +930,10 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		Flagger:                         m.flagger,
 		FlagsHandler:                    feature.NewFlagsHandler(kithttp.ErrorHandler(0), feature.ByKey),
 	}
+	m.apibackend.WriteSpoolDir, m.apibackend.WriteSpoolBytes = opts.WriteSpoolDir, opts.WriteSpoolBytes
+	if opts.WriteIdempotencyWindow > 0 {
+		m.apibackend.WriteDeduplicator = http.NewWriteDeduplicator(opts.WriteIdempotencyWindow, opts.WriteIdempotencyKeys)
+	}
 
 	m.reg.MustRegister(m.apibackend.PrometheusCollectors()...)
 
@@ -838,6 +951,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			pkger.WithBucketSVC(authorizer.NewBucketService(b.BucketService)),
 			pkger.WithCheckSVC(authorizer.NewCheckService(b.CheckService, authedUrmSVC, authedOrgSVC)),
 			pkger.WithDashboardSVC(authorizer.NewDashboardService(b.DashboardService)),
+			pkger.WithIngestRulesSVC(ingest.NewAuthorizedService(ingestRulesSvc)),
 			pkger.WithLabelSVC(label.NewAuthedLabelService(labelSvc, b.OrgLookupService)),
 			pkger.WithNotificationEndpointSVC(authorizer.NewNotificationEndpointService(b.NotificationEndpointService, authedUrmSVC, authedOrgSVC)),
 			pkger.WithNotificationRuleSVC(authorizer.NewNotificationRuleStore(b.NotificationRuleStore, authedUrmSVC, authedOrgSVC)),
@@ -966,6 +1080,24 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		http.WithResourceHandler(v1AuthHTTPServer),
 		http.WithResourceHandler(dashboardServer),
 		http.WithResourceHandler(notebookServer),
+		http.WithResourceHandler(control.NewHTTPHandler(m.log.With(zap.String("handler", "queries")), m.queryController)),
+		http.WithResourceHandler(promremote.NewHTTPHandler(
+			m.log.With(zap.String("handler", "prometheus")),
+			ts.OrganizationService,
+			ts.BucketService,
+			m.apibackend.PointsWriter,
+			storageStore,
+		)),
+		http.WithResourceHandler(ingest.NewHTTPHandler(
+			m.log.With(zap.String("handler", "ingest_rules")),
+			ingest.NewAuthorizedService(ingestRulesSvc),
+			authorizer.NewBucketService(ts.BucketService),
+		)),
+		http.WithResourceHandler(mqtt.NewHTTPHandler(
+			m.log.With(zap.String("handler", "mqtt")),
+			mqttSvc,
+			ts.OrganizationService,
+		)),
 	)
 
 	httpLogger := m.log.With(zap.String("service", "http"))
@@ -487,6 +487,11 @@ func (tl *TestLauncher) RestoreService(tb testing.TB) influxdb.RestoreService {
 	return &http.RestoreService{Addr: tl.URL(), Token: tl.Auth.Token}
 }
 
+func (tl *TestLauncher) StorageService(tb testing.TB) influxdb.StorageService {
+	tb.Helper()
+	return &http.StorageService{Client: tl.HTTPClient(tb)}
+}
+
 func (tl *TestLauncher) HTTPClient(tb testing.TB) *httpc.Client {
 	tb.Helper()
 
@@ -0,0 +1,114 @@
+package launcher_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	nethttp "net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/launcher"
+	"github.com/influxdata/influxdb/v2/prometheus/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusRemote_WriteRead(t *testing.T) {
+	ctx := context.Background()
+
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	do := func(t *testing.T, path string, m proto.Message) *nethttp.Response {
+		t.Helper()
+
+		b, err := proto.Marshal(m)
+		require.NoError(t, err)
+		params := url.Values{"orgID": {l.Org.ID.String()}, "bucketID": {l.Bucket.ID.String()}}
+		req, err := l.NewHTTPRequest(nethttp.MethodPost, "/api/v2/prometheus/"+path+"?"+params.Encode(), l.Auth.Token, "")
+		require.NoError(t, err)
+		req.Body = ioutil.NopCloser(bytes.NewReader(snappy.Encode(nil, b)))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("Content-Encoding", "snappy")
+
+		resp, err := nethttp.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := do(t, "write", &remote.WriteRequest{
+		Timeseries: []remote.TimeSeries{
+			{
+				Labels: []remote.Label{
+					{Name: "__name__", Value: "ups_load_percent"},
+					{Name: "ups", Value: "rack1"},
+				},
+				Samples: []remote.Sample{
+					{Value: 42, Timestamp: 1577836800000},
+					{Value: 43, Timestamp: 1577836860000},
+				},
+			},
+		},
+	})
+	resp.Body.Close()
+	require.Equal(t, nethttp.StatusNoContent, resp.StatusCode)
+
+	// A metric collected by the prometheus scraper has a field per type.
+	l.WritePointsOrFail(t, "ups_battery,ups=rack1 gauge=97,counter=3 1577836800000000000")
+
+	resp = do(t, "read", &remote.ReadRequest{
+		Queries: []*remote.Query{
+			{
+				StartTimestampMs: 1577836800000,
+				EndTimestampMs:   1577836860000,
+				Matchers: []*remote.LabelMatcher{
+					{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "ups_load_percent"},
+				},
+			},
+			{
+				StartTimestampMs: 1577836800000,
+				EndTimestampMs:   1577836860000,
+				Matchers: []*remote.LabelMatcher{
+					{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "ups_battery"},
+				},
+			},
+		},
+	})
+	defer resp.Body.Close()
+	require.Equal(t, nethttp.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	b, err := snappy.Decode(nil, body)
+	require.NoError(t, err)
+	var res remote.ReadResponse
+	require.NoError(t, proto.Unmarshal(b, &res))
+	require.Len(t, res.Results, 2)
+
+	// The written series is read back with the labels it was written with.
+	require.Len(t, res.Results[0].Timeseries, 1)
+	assert.Equal(t, []remote.Label{
+		{Name: "__name__", Value: "ups_load_percent"},
+		{Name: "ups", Value: "rack1"},
+	}, res.Results[0].Timeseries[0].Labels)
+	assert.Equal(t, []remote.Sample{
+		{Value: 42, Timestamp: 1577836800000},
+		{Value: 43, Timestamp: 1577836860000},
+	}, res.Results[0].Timeseries[0].Samples)
+
+	// The fields of a scraped metric are read as distinct series.
+	require.Len(t, res.Results[1].Timeseries, 2)
+	assert.Equal(t, []remote.Label{
+		{Name: "__name__", Value: "ups_battery"},
+		{Name: "_field", Value: "counter"},
+		{Name: "ups", Value: "rack1"},
+	}, res.Results[1].Timeseries[0].Labels)
+	assert.Equal(t, []remote.Label{
+		{Name: "__name__", Value: "ups_battery"},
+		{Name: "_field", Value: "gauge"},
+		{Name: "ups", Value: "rack1"},
+	}, res.Results[1].Timeseries[1].Labels)
+}
@@ -0,0 +1,456 @@
+package launcher_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/launcher"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageShards(t *testing.T) {
+	ctx := context.Background()
+
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	l.WritePointsOrFail(t, "m,k=v1 f=100i 946684800000000000\nm,k=v2 f=200i 946684800000000001")
+
+	svc := l.StorageService(t)
+	shards, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, shards, 1)
+
+	shard := shards[0]
+	require.Equal(t, l.Bucket.ID, shard.BucketID)
+	require.Equal(t, int64(2), shard.SeriesN)
+	require.Equal(t, influxdb.StorageShardHot, shard.State)
+	require.Empty(t, shard.TSMFiles)
+	require.True(t, shard.StartTime.Before(shard.EndTime))
+
+	// Snapshotting the cache writes a level 1 TSM file.
+	require.NoError(t, svc.SnapshotShard(ctx, shard.ID))
+	shard, err = svc.FindShardByID(ctx, shard.ID)
+	require.NoError(t, err)
+	require.Len(t, shard.TSMFiles, 1)
+	require.Equal(t, 1, shard.TSMFiles[0].Level)
+	require.NotZero(t, shard.Size)
+
+	require.NoError(t, svc.CompactShard(ctx, shard.ID))
+
+	// The index of the shard is rebuilt from its data.
+	require.NoError(t, svc.RebuildShardIndex(ctx, shard.ID))
+	require.Equal(t, int64(2), l.Launcher.Engine().SeriesCardinality(ctx, l.Bucket.ID))
+	l.WritePointsOrFail(t, "m,k=v3 f=300i 946684800000000002")
+	require.Equal(t, int64(3), l.Launcher.Engine().SeriesCardinality(ctx, l.Bucket.ID))
+
+	// Other buckets have no shards.
+	b := influxdb.Bucket{OrgID: l.Org.ID, Name: "empty"}
+	require.NoError(t, l.BucketService(t).CreateBucket(ctx, &b))
+	shards, err = svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &b.ID})
+	require.NoError(t, err)
+	require.Empty(t, shards)
+
+	_, err = svc.FindShardByID(ctx, shard.ID+1)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(svc.CompactShard(ctx, shard.ID+1)))
+}
+
+func TestStorageShards_ExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	// Write two series every 12 hours for three weeks, to shards of 7 days.
+	var lines []string
+	first := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for ts := first; ts.Before(first.AddDate(0, 0, 21)); ts = ts.Add(12 * time.Hour) {
+		for _, host := range []string{"a", "b"} {
+			lines = append(lines, fmt.Sprintf("ups,host=%s load=1 %d", host, ts.UnixNano()))
+		}
+	}
+	l.WritePointsOrFail(t, strings.Join(lines, "\n"))
+
+	svc := l.StorageService(t)
+	shards, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	for _, sh := range shards {
+		require.NoError(t, svc.SnapshotShard(ctx, sh.ID))
+	}
+
+	// Deleted values are left out of the export.
+	ds := http.DeleteService{Addr: l.URL(), Token: l.Auth.Token}
+	require.NoError(t, ds.DeleteBucketRangePredicate(ctx, http.DeleteRequest{
+		OrgID:    l.Org.ID.String(),
+		BucketID: l.Bucket.ID.String(),
+		Start:    "2020-01-03T00:00:00Z",
+		Stop:     "2020-01-04T23:59:59Z",
+	}))
+
+	// Import the values between January 2nd and 10th into a bucket with daily shards.
+	dst := influxdb.Bucket{OrgID: l.Org.ID, Name: "central", ShardGroupDuration: 24 * time.Hour}
+	require.NoError(t, l.BucketService(t).CreateBucket(ctx, &dst))
+
+	start := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	stop := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	for _, sh := range shards {
+		var buf bytes.Buffer
+		require.NoError(t, svc.ExportShard(ctx, sh.ID, start, stop, &buf))
+		require.NoError(t, svc.ImportShard(ctx, dst.ID, start, stop, &buf))
+	}
+
+	imported, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &dst.ID})
+	require.NoError(t, err)
+
+	var days []int
+	for _, sh := range imported {
+		require.Equal(t, 24*time.Hour, sh.EndTime.Sub(sh.StartTime))
+		require.Equal(t, int64(2), sh.SeriesN)
+		for _, f := range sh.TSMFiles {
+			require.False(t, f.MinTime.Before(sh.StartTime))
+			require.True(t, f.MaxTime.Before(sh.EndTime))
+		}
+		days = append(days, sh.StartTime.Day())
+	}
+	require.Equal(t, []int{2, 5, 6, 7, 8, 9}, days)
+	require.Equal(t, int64(2), l.Launcher.Engine().SeriesCardinality(ctx, dst.ID))
+
+	// Importing into a missing bucket fails.
+	err = svc.ImportShard(ctx, platform.ID(1), start, stop, &bytes.Buffer{})
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+func TestStorageShards_Tiering(t *testing.T) {
+	ctx := context.Background()
+
+	coldDir := t.TempDir()
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t, func(o *launcher.InfluxdOpts) {
+		o.StorageConfig.Data.ColdDir = coldDir
+	})
+	defer l.ShutdownOrFail(t, ctx)
+
+	l.WritePointsOrFail(t, "m,k=v1 f=100i 946684800000000000\nm,k=v2 f=200i 946684800000000001")
+
+	svc := l.StorageService(t)
+	policy, err := svc.FindTieringPolicy(ctx, l.Bucket.ID)
+	require.NoError(t, err)
+	require.Zero(t, policy.ColdAfter.Duration)
+
+	policy, err = svc.UpdateTieringPolicy(ctx, influxdb.StorageTieringPolicy{
+		BucketID:  l.Bucket.ID,
+		ColdAfter: influxdb.Duration{Duration: 30 * 24 * time.Hour},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 30*24*time.Hour, policy.ColdAfter.Duration)
+
+	_, err = svc.UpdateTieringPolicy(ctx, influxdb.StorageTieringPolicy{
+		BucketID:  l.Bucket.ID,
+		ColdAfter: influxdb.Duration{Duration: -time.Hour},
+	})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	shards, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, shards, 1)
+	require.Equal(t, influxdb.StorageTierPrimary, shards[0].Tier)
+
+	require.NoError(t, svc.MoveShardToColdTier(ctx, shards[0].ID))
+	shard, err := svc.FindShardByID(ctx, shards[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.StorageTierCold, shard.Tier)
+	require.Equal(t, int64(2), shard.SeriesN)
+	require.NotEmpty(t, shard.TSMFiles)
+
+	// The files of the shard are under the cold directory.
+	fis, err := ioutil.ReadDir(filepath.Join(coldDir, l.Bucket.ID.String(), "autogen", strconv.FormatUint(shard.ID, 10)))
+	require.NoError(t, err)
+	require.NotEmpty(t, fis)
+
+	// Shards on the cold tier are read-only.
+	require.Error(t, l.WritePoints("m,k=v3 f=300i 946684800000000002"))
+	require.NoError(t, svc.MoveShardToColdTier(ctx, shard.ID))
+
+	// New shards are created on the primary tier.
+	l.WritePointsOrFail(t, "m,k=v1 f=100i 1577836800000000000")
+	shards, err = svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, shards, 2)
+	require.Equal(t, influxdb.StorageTierPrimary, shards[1].Tier)
+}
+
+func TestStorageShards_TieringDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	l.WritePointsOrFail(t, "m,k=v1 f=100i 946684800000000000")
+
+	svc := l.StorageService(t)
+	_, err := svc.UpdateTieringPolicy(ctx, influxdb.StorageTieringPolicy{
+		BucketID:  l.Bucket.ID,
+		ColdAfter: influxdb.Duration{Duration: time.Hour},
+	})
+	require.Equal(t, errors.EUnprocessableEntity, errors.ErrorCode(err))
+
+	shards, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, shards, 1)
+	err = svc.MoveShardToColdTier(ctx, shards[0].ID)
+	require.Equal(t, errors.EUnprocessableEntity, errors.ErrorCode(err))
+}
+
+func TestStorageShards_Compactions(t *testing.T) {
+	ctx := context.Background()
+
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t, func(o *launcher.InfluxdOpts) {
+		o.StorageConfig.Data.CompactThroughput = 1024 * 1024
+		o.StorageConfig.Data.CompactThroughputBurst = 2 * 1024 * 1024
+		o.StorageConfig.Data.MaxConcurrentCompactions = 1
+	})
+	defer l.ShutdownOrFail(t, ctx)
+
+	l.WritePointsOrFail(t, "m,k=v1 f=100i 946684800000000000\nm,k=v2 f=200i 946684800000000001")
+
+	svc := l.StorageService(t)
+	compactions, err := svc.ListCompactions(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Empty(t, compactions.Queued)
+	require.Empty(t, compactions.InProgress)
+
+	limits, err := svc.FindCompactionLimits(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &influxdb.StorageCompactionLimits{
+		Throughput:      1024 * 1024,
+		ThroughputBurst: 2 * 1024 * 1024,
+		MaxConcurrent:   1,
+	}, limits)
+
+	// Only the limits that are set change.
+	throughput := int64(512 * 1024)
+	limits, err = svc.UpdateCompactionLimits(ctx, influxdb.StorageCompactionLimitsUpdate{Throughput: &throughput})
+	require.NoError(t, err)
+	require.Equal(t, &influxdb.StorageCompactionLimits{
+		Throughput:      512 * 1024,
+		ThroughputBurst: 2 * 1024 * 1024,
+		MaxConcurrent:   1,
+	}, limits)
+
+	// Compactions run with the new limits.
+	shards, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, shards, 1)
+	shardID := shards[0].ID
+	require.NoError(t, svc.SnapshotShard(ctx, shardID))
+	l.WritePointsOrFail(t, "m,k=v3 f=300i 946684800000000002")
+	require.NoError(t, svc.SnapshotShard(ctx, shardID))
+	require.NoError(t, svc.CompactShard(ctx, shardID))
+	require.Eventually(t, func() bool {
+		shard, err := svc.FindShardByID(ctx, shardID)
+		return err == nil && len(shard.TSMFiles) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	maxConcurrent := 0
+	_, err = svc.UpdateCompactionLimits(ctx, influxdb.StorageCompactionLimitsUpdate{MaxConcurrent: &maxConcurrent})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+}
+
+func TestStorageShards_Durability(t *testing.T) {
+	ctx := context.Background()
+
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	l.WritePointsOrFail(t, "m,k=v1 f=100i 946684800000000000\nm,k=v2 f=200i 946684800000000001")
+
+	svc := l.StorageService(t)
+	policy, err := svc.FindDurabilityPolicy(ctx, l.Bucket.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.DurabilityDefault, policy.Mode)
+
+	shards, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, shards, 1)
+	require.Empty(t, shards[0].TSMFiles)
+
+	policy, err = svc.UpdateDurabilityPolicy(ctx, influxdb.StorageDurabilityPolicy{
+		BucketID:         l.Bucket.ID,
+		Mode:             influxdb.DurabilityNoWAL,
+		SnapshotInterval: influxdb.Duration{Duration: time.Minute},
+	})
+	require.NoError(t, err)
+	require.Equal(t, influxdb.DurabilityNoWAL, policy.Mode)
+	require.Equal(t, time.Minute, policy.SnapshotInterval.Duration)
+
+	// Disabling the WAL writes it to a TSM file.
+	shard, err := svc.FindShardByID(ctx, shards[0].ID)
+	require.NoError(t, err)
+	require.Len(t, shard.TSMFiles, 1)
+	require.Equal(t, int64(2), shard.SeriesN)
+	l.WritePointsOrFail(t, "m,k=v3 f=300i 946684800000000002")
+
+	_, err = svc.UpdateDurabilityPolicy(ctx, influxdb.StorageDurabilityPolicy{
+		BucketID:   l.Bucket.ID,
+		Mode:       influxdb.DurabilityGroupCommit,
+		FsyncDelay: influxdb.Duration{Duration: -time.Millisecond},
+	})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	_, err = svc.UpdateDurabilityPolicy(ctx, influxdb.StorageDurabilityPolicy{
+		BucketID: platform.ID(1),
+		Mode:     influxdb.DurabilityFsync,
+	})
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+func TestStorageShards_Memory(t *testing.T) {
+	ctx := context.Background()
+
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t, func(o *launcher.InfluxdOpts) {
+		o.StorageConfig.Data.BucketMemoryBudget = 1
+	})
+	defer l.ShutdownOrFail(t, ctx)
+
+	l.WritePointsOrFail(t, "m,k=v1 f=100i 946684800000000000\nm,k=v2 f=200i 946684800000000001")
+
+	svc := l.StorageService(t)
+	usages, err := svc.ListMemoryUsage(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, usages, 1)
+
+	usage := usages[0]
+	require.Equal(t, l.Bucket.ID, usage.BucketID)
+	require.Equal(t, int64(1), usage.Budget)
+	require.Len(t, usage.Shards, 1)
+	require.Len(t, usage.Measurements, 1)
+	require.Equal(t, "m", usage.Measurements[0].Name)
+	require.NotZero(t, usage.SeriesFile.Mmap)
+
+	// The bucket is over its budget, so its cache is written to a TSM file.
+	require.Eventually(t, func() bool {
+		usages, err := svc.ListMemoryUsage(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+		return err == nil && len(usages) == 1 && usages[0].Cache == 0 && usages[0].TSMIndex > 0
+	}, 30*time.Second, 100*time.Millisecond)
+
+	// Other buckets use no memory.
+	b := influxdb.Bucket{OrgID: l.Org.ID, Name: "empty"}
+	require.NoError(t, l.BucketService(t).CreateBucket(ctx, &b))
+	usages, err = svc.ListMemoryUsage(ctx, influxdb.StorageShardFilter{BucketID: &b.ID})
+	require.NoError(t, err)
+	require.Len(t, usages, 1)
+	require.Empty(t, usages[0].Shards)
+	require.Zero(t, usages[0].Heap)
+}
+
+func TestStorageShards_Reshard(t *testing.T) {
+	ctx := context.Background()
+
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	hour := time.Hour
+	_, err := l.BucketService(t).UpdateBucket(ctx, l.Bucket.ID, influxdb.BucketUpdate{ShardGroupDuration: &hour})
+	require.NoError(t, err)
+
+	// Write two series every 30 minutes for three hours, to hourly shards.
+	var lines []string
+	first := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for ts := first; ts.Before(first.Add(3 * time.Hour)); ts = ts.Add(30 * time.Minute) {
+		for _, host := range []string{"a", "b"} {
+			lines = append(lines, fmt.Sprintf("ups,host=%s load=1 %d", host, ts.UnixNano()))
+		}
+	}
+	l.WritePointsOrFail(t, strings.Join(lines, "\n"))
+
+	svc := l.StorageService(t)
+	shards, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, shards, 3)
+	require.NoError(t, svc.SnapshotShard(ctx, shards[0].ID))
+
+	// Nothing is resharded until the shard group duration changes.
+	step, err := svc.ReshardBucket(ctx, l.Bucket.ID)
+	require.NoError(t, err)
+	require.Empty(t, step.SourceShardIDs)
+	require.Zero(t, step.Remaining)
+
+	day := 24 * time.Hour
+	_, err = l.BucketService(t).UpdateBucket(ctx, l.Bucket.ID, influxdb.BucketUpdate{ShardGroupDuration: &day})
+	require.NoError(t, err)
+
+	// The hourly shards are merged into a daily shard.
+	step, err = svc.ReshardBucket(ctx, l.Bucket.ID)
+	require.NoError(t, err)
+	require.Equal(t, day, step.ShardGroupDuration.Duration)
+	require.Equal(t, first, step.StartTime.UTC())
+	require.Equal(t, first.Add(day), step.EndTime.UTC())
+	require.Equal(t, []uint64{shards[0].ID, shards[1].ID, shards[2].ID}, step.SourceShardIDs)
+	require.Len(t, step.ShardIDs, 1)
+	require.Zero(t, step.Remaining)
+
+	merged, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, merged, 1)
+	require.Equal(t, step.ShardIDs[0], merged[0].ID)
+	require.Equal(t, int64(2), merged[0].SeriesN)
+	requireReshardedValues(t, merged[0], first, first.Add(150*time.Minute))
+	require.Equal(t, int64(2), l.Launcher.Engine().SeriesCardinality(ctx, l.Bucket.ID))
+
+	// Writes go to the new shard.
+	l.WritePointsOrFail(t, fmt.Sprintf("ups,host=c load=1 %d", first.Add(12*time.Hour).UnixNano()))
+	shards, err = svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, shards, 1)
+	require.Equal(t, int64(3), shards[0].SeriesN)
+
+	// Shrinking the shard group duration splits the daily shard.
+	twelveHours := 12 * time.Hour
+	_, err = l.BucketService(t).UpdateBucket(ctx, l.Bucket.ID, influxdb.BucketUpdate{ShardGroupDuration: &twelveHours})
+	require.NoError(t, err)
+	step, err = svc.ReshardBucket(ctx, l.Bucket.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{merged[0].ID}, step.SourceShardIDs)
+	require.Len(t, step.ShardIDs, 2)
+
+	split, err := svc.ListShards(ctx, influxdb.StorageShardFilter{BucketID: &l.Bucket.ID})
+	require.NoError(t, err)
+	require.Len(t, split, 2)
+	require.Equal(t, int64(2), split[0].SeriesN)
+	requireReshardedValues(t, split[0], first, first.Add(150*time.Minute))
+	require.Equal(t, int64(1), split[1].SeriesN)
+	requireReshardedValues(t, split[1], first.Add(12*time.Hour), first.Add(12*time.Hour))
+
+	_, err = svc.ReshardBucket(ctx, platform.ID(1))
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+// requireReshardedValues checks the values of a shard are between min and
+// max, once written to TSM files.
+func requireReshardedValues(t *testing.T, sh *influxdb.StorageShard, min, max time.Time) {
+	t.Helper()
+
+	require.NotEmpty(t, sh.TSMFiles)
+	minTime, maxTime := sh.TSMFiles[0].MinTime, sh.TSMFiles[0].MaxTime
+	for _, f := range sh.TSMFiles {
+		if f.MinTime.Before(minTime) {
+			minTime = f.MinTime
+		}
+		if f.MaxTime.After(maxTime) {
+			maxTime = f.MaxTime
+		}
+	}
+	require.Equal(t, min, minTime.UTC())
+	require.Equal(t, max, maxTime.UTC())
+}
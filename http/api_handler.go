@@ -41,6 +41,16 @@ type APIBackend struct {
 	// in a single points batch
 	MaxBatchSizeBytes int64
 
+	// WriteDeduplicator remembers the writes sent with an Idempotency-Key
+	// header. If nil, the header is ignored.
+	WriteDeduplicator *WriteDeduplicator
+
+	// WriteSpoolBytes is the size of the write batches held in memory while
+	// they are validated, larger batches are copied to a temporary file in
+	// WriteSpoolDir.
+	WriteSpoolBytes int
+	WriteSpoolDir   string
+
 	// WriteParserMaxBytes specifies the maximum number of bytes that may be allocated when processing a single
 	// write request. A value of zero specifies there is no limit.
 	WriteParserMaxBytes int
@@ -65,6 +75,7 @@ type APIBackend struct {
 	DeleteService                   influxdb.DeleteService
 	BackupService                   influxdb.BackupService
 	RestoreService                  influxdb.RestoreService
+	StorageService                  influxdb.StorageService
 	AuthorizationService            influxdb.AuthorizationService
 	AuthorizationV1Service          influxdb.AuthorizationService
 	PasswordV1Service               influxdb.PasswordsService
@@ -116,6 +127,10 @@ func (b *APIBackend) PrometheusCollectors() []prometheus.Collector {
 		cs = append(cs, pc.PrometheusCollectors()...)
 	}
 
+	if b.WriteDeduplicator != nil {
+		cs = append(cs, b.WriteDeduplicator.PrometheusCollectors()...)
+	}
+
 	return cs
 }
 
@@ -205,11 +220,17 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	restoreBackend.RestoreService = authorizer.NewRestoreService(restoreBackend.RestoreService)
 	h.Mount(prefixRestore, NewRestoreHandler(restoreBackend))
 
+	storageBackend := NewStorageBackend(b)
+	storageBackend.StorageService = authorizer.NewStorageService(storageBackend.StorageService)
+	h.Mount(prefixStorage, NewStorageHandler(storageBackend))
+
 	h.Mount(dbrp.PrefixDBRP, dbrp.NewHTTPHandler(b.Logger, b.DBRPService, b.OrganizationService))
 
 	writeBackend := NewWriteBackend(b.Logger.With(zap.String("handler", "write")), b)
 	h.Mount(prefixWrite, NewWriteHandler(b.Logger, writeBackend,
 		WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
+		WithWriteDeduplicator(b.WriteDeduplicator),
+		WithWriteSpool(b.WriteSpoolDir, b.WriteSpoolBytes),
 		//WithParserOptions(
 		//	models.WithParserMaxBytes(b.WriteParserMaxBytes),
 		//	models.WithParserMaxLines(b.WriteParserMaxLines),
@@ -8,6 +8,7 @@ import (
 	platform "github.com/influxdata/influxdb/v2"
 	influxqld "github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/influxql/control"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/query"
 	"go.uber.org/zap"
 )
@@ -50,7 +51,8 @@ func NewInfluxQLHandler(b *InfluxQLBackend, config HandlerConfig) *InfluxqlHandl
 }
 
 func (h *InfluxqlHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	h.handleInfluxqldQuery(w, req)
+	// query responses can optionally be zstd or gzip encoded
+	kithttp.CompressResponse(http.HandlerFunc(h.handleInfluxqldQuery)).ServeHTTP(w, req)
 }
 
 // DefaultChunkSize is the default number of points to write in
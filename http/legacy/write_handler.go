@@ -2,9 +2,11 @@ package legacy
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -138,14 +140,15 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, auth.OrgID, bucket.ID, req.Body)
-	if err != nil {
-		h.HandleHTTPError(ctx, err, sw)
-		return
+	parser := points.NewParser(req.Precision)
+	var rejected []points.RejectedLine
+	if req.Partial {
+		requestBytes, rejected, err = parser.WritePartial(ctx, auth.OrgID, bucket.ID, req.Body, h.PointsWriter)
+	} else {
+		requestBytes, err = parser.Write(ctx, auth.OrgID, bucket.ID, req.Body, h.PointsWriter)
 	}
-
-	if err := h.PointsWriter.WritePoints(ctx, auth.OrgID, bucket.ID, parsed.Points); err != nil {
-		if partialErr, ok := err.(tsdb.PartialWriteError); ok {
+	if werr, ok := err.(*points.WriteError); ok {
+		if partialErr, ok := werr.Err.(tsdb.PartialWriteError); ok {
 			h.HandleHTTPError(ctx, &errors.Error{
 				Code: errors.EUnprocessableEntity,
 				Op:   opWriteHandler,
@@ -159,10 +162,22 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 			Code: errors.EInternal,
 			Op:   opWriteHandler,
 			Msg:  "unexpected error writing points to database",
-			Err:  err,
+			Err:  werr.Err,
 		}, sw)
 		return
 	}
+	if err != nil {
+		h.HandleHTTPError(ctx, err, sw)
+		return
+	}
+	if len(rejected) > 0 {
+		sw.Header().Set("Content-Type", "application/json; charset=utf-8")
+		sw.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(sw).Encode(points.NewPartialWriteResponse(rejected)); err != nil {
+			h.logger.Debug("Failed to encode partial write response", zap.Error(err))
+		}
+		return
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -235,7 +250,9 @@ type writeRequest struct {
 	Database         string
 	RetentionPolicy  string
 	Precision        string
-	Body             io.ReadCloser
+	// Partial writes the valid lines and reports the rejected ones.
+	Partial bool
+	Body    io.ReadCloser
 }
 
 // decodeWriteRequest extracts write request information from an inbound
@@ -246,6 +263,17 @@ func decodeWriteRequest(_ context.Context, r *http.Request, maxBatchSizeBytes in
 	if precision == "" {
 		precision = "ns"
 	}
+	partial := false
+	if v := qp.Get("partial"); v != "" {
+		var err error
+		if partial, err = strconv.ParseBool(v); err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid partial parameter; must be true or false",
+				Err:  err,
+			}
+		}
+	}
 	db := qp.Get("db")
 	if db == "" {
 		return nil, &errors.Error{
@@ -265,6 +293,7 @@ func decodeWriteRequest(_ context.Context, r *http.Request, maxBatchSizeBytes in
 		Database:         db,
 		RetentionPolicy:  qp.Get("rp"),
 		Precision:        precision,
+		Partial:          partial,
 		Body:             body,
 	}, nil
 }
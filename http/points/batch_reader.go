@@ -1,14 +1,18 @@
 package points
 
 import (
+	"bytes"
 	"compress/gzip"
 	"io"
+	"io/ioutil"
 
+	"github.com/golang/snappy"
 	io2 "github.com/influxdata/influxdb/v2/kit/io"
+	"github.com/klauspost/compress/zstd"
 )
 
-// BatchReadCloser (potentially) wraps an io.ReadCloser in Gzip
-// decompression and limits the reading to a specific number of bytes.
+// BatchReadCloser (potentially) wraps an io.ReadCloser in gzip, zstd or
+// snappy decompression and limits the reading to a specific number of bytes.
 func BatchReadCloser(rc io.ReadCloser, encoding string, maxBatchSizeBytes int64) (io.ReadCloser, error) {
 	switch encoding {
 	case "gzip", "x-gzip":
@@ -17,9 +21,79 @@ func BatchReadCloser(rc io.ReadCloser, encoding string, maxBatchSizeBytes int64)
 		if err != nil {
 			return nil, err
 		}
+	case "zstd":
+		dec, err := zstd.NewReader(rc, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		rc = &zstdReadCloser{Decoder: dec, rc: rc}
+	case "snappy":
+		rc = &snappyReadCloser{rc: rc, max: maxBatchSizeBytes}
 	}
 	if maxBatchSizeBytes > 0 {
 		rc = io2.NewLimitedReadCloser(rc, maxBatchSizeBytes)
 	}
 	return rc, nil
 }
+
+// zstdReadCloser releases the resources of the decoder when closed.
+type zstdReadCloser struct {
+	*zstd.Decoder
+	rc io.ReadCloser
+}
+
+func (r *zstdReadCloser) Close() error {
+	r.Decoder.Close()
+	return r.rc.Close()
+}
+
+// snappyReadCloser decodes a body compressed with the snappy block format,
+// as sent by Prometheus remote write clients. The block format can't be
+// streamed, so the whole body is decoded on the first read.
+type snappyReadCloser struct {
+	rc  io.ReadCloser
+	max int64
+	r   *bytes.Reader
+	err error
+}
+
+func (r *snappyReadCloser) Read(p []byte) (int, error) {
+	if r.r == nil && r.err == nil {
+		r.r, r.err = r.decode()
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.r.Read(p)
+}
+
+func (r *snappyReadCloser) decode() (*bytes.Reader, error) {
+	var body io.Reader = r.rc
+	if r.max > 0 {
+		// Bound the compressed body by the largest encoding of a valid batch.
+		body = io.LimitReader(body, int64(snappy.MaxEncodedLen(int(r.max)))+1)
+	}
+	compressed, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if r.max > 0 && len(compressed) > snappy.MaxEncodedLen(int(r.max)) {
+		return nil, ErrMaxBatchSizeExceeded
+	}
+	n, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, err
+	}
+	if r.max > 0 && int64(n) > r.max {
+		return nil, ErrMaxBatchSizeExceeded
+	}
+	decoded, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(decoded), nil
+}
+
+func (r *snappyReadCloser) Close() error {
+	return r.rc.Close()
+}
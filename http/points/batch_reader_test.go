@@ -0,0 +1,76 @@
+package points
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, encoding, s string) []byte {
+	t.Helper()
+	switch encoding {
+	case "gzip":
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	case "zstd":
+		enc, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		defer enc.Close()
+		return enc.EncodeAll([]byte(s), nil)
+	case "snappy":
+		return snappy.Encode(nil, []byte(s))
+	}
+	return []byte(s)
+}
+
+func TestBatchReadCloser(t *testing.T) {
+	body := "cpu,host=a value=1 1\ncpu,host=b value=2 2\n"
+	for _, encoding := range []string{"", "gzip", "zstd", "snappy"} {
+		t.Run(encoding, func(t *testing.T) {
+			rc, err := BatchReadCloser(ioutil.NopCloser(bytes.NewReader(compress(t, encoding, body))), encoding, 1024)
+			require.NoError(t, err)
+			w := &chunkWriter{}
+			n, err := NewParser("ns").Write(context.Background(), orgID, bucketID, rc, w)
+			require.NoError(t, err)
+			assert.Equal(t, len(body), n)
+			require.Len(t, w.chunks, 1)
+			assert.Len(t, w.chunks[0], 2)
+		})
+	}
+}
+
+func TestBatchReadCloser_TooLarge(t *testing.T) {
+	body := strings.Repeat("cpu value=1 1\n", 100)
+	for _, encoding := range []string{"", "gzip", "zstd", "snappy"} {
+		t.Run(encoding, func(t *testing.T) {
+			rc, err := BatchReadCloser(ioutil.NopCloser(bytes.NewReader(compress(t, encoding, body))), encoding, 100)
+			require.NoError(t, err)
+			_, err = NewParser("ns").Write(context.Background(), orgID, bucketID, rc, &chunkWriter{})
+			assert.Equal(t, errors2.ETooLarge, errors2.ErrorCode(err))
+		})
+	}
+}
+
+func TestBatchReadCloser_Corrupt(t *testing.T) {
+	for _, encoding := range []string{"zstd", "snappy"} {
+		t.Run(encoding, func(t *testing.T) {
+			rc, err := BatchReadCloser(ioutil.NopCloser(strings.NewReader("cpu value=1 1\n")), encoding, 1024)
+			require.NoError(t, err)
+			_, err = NewParser("ns").Write(context.Background(), orgID, bucketID, rc, &chunkWriter{})
+			assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package points
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const (
+	// FormatLineProtocol is the format of line protocol bodies.
+	FormatLineProtocol = "lp"
+
+	// FormatJSON is the format of bodies holding an array of points encoded
+	// as JSON objects, see NewJSONDecoder.
+	FormatJSON = "json"
+
+	msgInvalidJSONPoints = "invalid JSON points"
+)
+
+// jsonPoint is a point of the JSON format, modeled on gather.Metrics.
+type jsonPoint struct {
+	Measurement string                 `json:"measurement"`
+	Tags        map[string]string      `json:"tags"`
+	Fields      map[string]interface{} `json:"fields"`
+	Timestamp   interface{}            `json:"timestamp"`
+}
+
+// JSONDecoder decodes points from arrays of JSON objects such as
+//
+//	[{"measurement": "ups", "tags": {"ups": "rack1"}, "fields": {"load": 12.0, "outages": 2}, "timestamp": 1600000000}]
+//
+// The types of field values are strict: numbers with a fraction or an exponent
+// are floats, numbers without are integers, and strings and booleans are kept
+// as such. Any other value is rejected.
+//
+// The timestamp is either an integer in the precision of the decoder or an
+// RFC 3339 string, within the range of models.MinNanoTime and
+// models.MaxNanoTime. Points without timestamp are given the default time.
+type JSONDecoder struct {
+	dec       *json.Decoder
+	precision string
+	now       time.Time
+
+	inArray bool
+	index   int
+}
+
+// NewJSONDecoder returns a decoder of the points in r. Several arrays may
+// follow each other in r.
+func NewJSONDecoder(r io.Reader, precision string, now time.Time) *JSONDecoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &JSONDecoder{
+		dec:       dec,
+		precision: precision,
+		now:       now,
+	}
+}
+
+// Next returns the next point, or io.EOF once all points have been decoded.
+func (d *JSONDecoder) Next() (models.Point, error) {
+	for !d.inArray || !d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		delim, ok := tok.(json.Delim)
+		switch {
+		case d.inArray && ok && delim == ']':
+			d.inArray = false
+		case !d.inArray && ok && delim == '[':
+			d.inArray = true
+		default:
+			return nil, fmt.Errorf("expected an array of points, got %v", tok)
+		}
+	}
+
+	d.index++
+	var jp jsonPoint
+	if err := d.dec.Decode(&jp); err != nil {
+		return nil, fmt.Errorf("point %d: %w", d.index, err)
+	}
+	p, err := jp.point(d.precision, d.now)
+	if err != nil {
+		return nil, fmt.Errorf("point %d: %w", d.index, err)
+	}
+	return p, nil
+}
+
+func (jp *jsonPoint) point(precision string, now time.Time) (models.Point, error) {
+	if jp.Measurement == "" {
+		return nil, errors.New("missing measurement")
+	}
+	if len(jp.Fields) == 0 {
+		return nil, errors.New("missing fields")
+	}
+
+	fields := make(models.Fields, len(jp.Fields))
+	for k, v := range jp.Fields {
+		fv, err := fieldValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", k, err)
+		}
+		fields[k] = fv
+	}
+
+	t, err := timestamp(jp.Timestamp, precision, now)
+	if err != nil {
+		return nil, fmt.Errorf("timestamp: %w", err)
+	}
+	return models.NewPoint(jp.Measurement, models.NewTags(jp.Tags), fields, t)
+}
+
+func fieldValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		s := v.String()
+		if strings.ContainsAny(s, ".eE") {
+			return strconv.ParseFloat(s, 64)
+		}
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("integer %s is out of range", s)
+		}
+		return i, nil
+	case string, bool:
+		return v, nil
+	case nil:
+		return nil, errors.New("null is not a valid field value")
+	default:
+		return nil, fmt.Errorf("%T is not a valid field value", v)
+	}
+}
+
+func timestamp(v interface{}, precision string, now time.Time) (time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return now, nil
+	case json.Number:
+		ts, err := strconv.ParseInt(v.String(), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s is not an integer", v)
+		}
+		return models.SafeCalcTime(ts, precision)
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t, models.CheckTime(t)
+	default:
+		return time.Time{}, fmt.Errorf("%T is not a valid timestamp", v)
+	}
+}
+
+// streamJSON calls fn with the points decoded from rc, in chunks of about
+// ChunkBytes bytes of the body, and returns the number of bytes read.
+func (pw *Parser) streamJSON(rc io.ReadCloser, fn func(models.Points) error) (int, error) {
+	closed := false
+	defer func() {
+		if !closed {
+			rc.Close()
+		}
+	}()
+
+	chunkBytes := pw.ChunkBytes
+	if chunkBytes <= 0 {
+		chunkBytes = DefaultChunkBytes
+	}
+	cr := &countingReader{r: rc}
+	dec := NewJSONDecoder(cr, pw.Precision, time.Now().UTC())
+
+	var (
+		points  models.Points
+		flushed int
+		written bool
+	)
+	for {
+		p, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The size limit of the batch is only reported on close.
+			closed = true
+			if cerr := closeReader(rc); cerr != nil {
+				return cr.n, readError(cerr)
+			}
+			if cr.err != nil && cr.err != io.EOF {
+				return cr.n, readError(cr.err)
+			}
+			return cr.n, &errors2.Error{
+				Code: errors2.EInvalid,
+				Op:   opPointsWriter,
+				Msg:  msgInvalidJSONPoints,
+				Err:  err,
+			}
+		}
+
+		points, written = append(points, p), true
+		if cr.n-flushed >= chunkBytes {
+			if err := fn(points); err != nil {
+				return cr.n, err
+			}
+			points, flushed = nil, cr.n
+		}
+	}
+
+	closed = true
+	if err := closeReader(rc); err != nil {
+		return cr.n, readError(err)
+	}
+	// An empty array is rejected like an empty line protocol body.
+	if !written {
+		return cr.n, &errors2.Error{
+			Op:   opPointsWriter,
+			Code: errors2.EInvalid,
+			Msg:  msgWritingRequiresPoints,
+		}
+	}
+	if len(points) > 0 {
+		if err := fn(points); err != nil {
+			return cr.n, err
+		}
+	}
+	return cr.n, nil
+}
+
+// countingReader counts the bytes read and keeps the last read error.
+type countingReader struct {
+	r   io.Reader
+	n   int
+	err error
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	c.err = err
+	return n, err
+}
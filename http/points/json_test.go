@@ -0,0 +1,116 @@
+package points
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONDecoder(t *testing.T) {
+	body := `[
+		{"measurement": "ups", "tags": {"ups": "rack 1"}, "fields": {"load": 12.0, "outages": 2, "status": "ONLINE", "battery": true}, "timestamp": 1600000000},
+		{"measurement": "ups", "fields": {"load": 1e1}, "timestamp": "2020-09-13T12:26:40Z"}
+	]
+	[{"measurement": "ups", "fields": {"load": 3.5}}]`
+	now := time.Unix(0, 42)
+
+	dec := NewJSONDecoder(strings.NewReader(body), "s", now)
+	var got []string
+	for {
+		p, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		`ups,ups=rack\ 1 battery=true,load=12,outages=2i,status="ONLINE" 1600000000000000000`,
+		`ups load=10 1600000000000000000`,
+		`ups load=3.5 42`,
+	}, got)
+}
+
+func TestJSONDecoder_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		body      string
+		precision string
+		err       string
+	}{
+		{body: `{"measurement": "ups"}`, err: "expected an array of points"},
+		{body: `[{"fields": {"load": 1}}]`, err: "point 1: missing measurement"},
+		{body: `[{"measurement": "ups"}]`, err: "point 1: missing fields"},
+		{body: `[{"measurement": "ups", "fields": {"load": [1]}}]`, err: `point 1: field "load": []interface {} is not a valid field value`},
+		{body: `[{"measurement": "ups", "fields": {"load": 9223372036854775808}}]`, err: `point 1: field "load": integer 9223372036854775808 is out of range`},
+		{body: `[{"measurement": "ups", "fields": {"load": 1}, "timestamp": 1.5}]`, err: "point 1: timestamp: 1.5 is not an integer"},
+		{body: `[{"measurement": "ups", "fields": {"load": 1}, "timestamp": 9223372036855}]`, precision: "s", err: "point 1: timestamp: time outside range"},
+		{body: `[{"measurement": "ups", "fields": {"load": 1}, "timestamp": -9223372036855}]`, precision: "s", err: "point 1: timestamp: time outside range"},
+		{body: `[{"measurement": "ups", "fields": {"load": 1}, "timestamp": 9223372036854775807}]`, err: "point 1: timestamp: time outside range"},
+		{body: `[{"measurement": "ups", "fields": {"load": 1}, "timestamp": "2300-01-01T00:00:00Z"}]`, err: "point 1: timestamp: time outside range"},
+		{body: `[{"measurement": "ups", "fields": {"load": 1}}`, err: "unexpected end of JSON input"},
+	} {
+		precision := tt.precision
+		if precision == "" {
+			precision = "ns"
+		}
+		dec := NewJSONDecoder(strings.NewReader(tt.body), precision, time.Now())
+		var err error
+		for err == nil {
+			_, err = dec.Next()
+		}
+		require.Error(t, err, tt.body)
+		assert.Contains(t, err.Error(), tt.err, tt.body)
+	}
+}
+
+func TestParser_WriteJSON(t *testing.T) {
+	body := `[{"measurement": "cpu", "fields": {"value": 1.0}, "timestamp": 1},` +
+		`{"measurement": "cpu", "fields": {"value": 2.0}, "timestamp": 2},` +
+		`{"measurement": "cpu", "fields": {"value": 3.0}, "timestamp": 3}]`
+
+	w := &chunkWriter{}
+	p := &Parser{Precision: "ns", Format: FormatJSON, ChunkBytes: 60}
+	n, err := p.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(body)), w)
+	require.NoError(t, err)
+	assert.Equal(t, len(body), n)
+
+	var got []string
+	for _, chunk := range w.chunks {
+		for _, pt := range chunk {
+			got = append(got, pt.String())
+		}
+	}
+	assert.Equal(t, []string{"cpu value=1 1", "cpu value=2 2", "cpu value=3 3"}, got)
+	assert.True(t, len(w.chunks) > 1, "the points should be written in chunks")
+}
+
+func TestParser_WriteJSONInvalid(t *testing.T) {
+	w := &chunkWriter{}
+	p := &Parser{Precision: "ns", Format: FormatJSON}
+	_, err := p.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(`[{"measurement": "cpu"}]`)), w)
+	assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+	assert.Empty(t, w.chunks)
+
+	for _, body := range []string{``, `[]`, `[] []`} {
+		_, err = p.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(body)), w)
+		require.Error(t, err, body)
+		assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err), body)
+		assert.Equal(t, msgWritingRequiresPoints, err.(*errors2.Error).Msg, body)
+	}
+	assert.Empty(t, w.chunks)
+
+	ps := &Parser{Precision: "s", Format: FormatJSON}
+	_, err = ps.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(`[{"measurement": "cpu", "fields": {"value": 1}, "timestamp": 9223372036855}]`)), w)
+	assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+	assert.Empty(t, w.chunks)
+
+	_, _, err = p.WritePartial(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(`[]`)), w)
+	assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+}
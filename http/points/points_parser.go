@@ -1,20 +1,27 @@
 package points
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"sort"
 	"time"
 
+	"github.com/golang/snappy"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 
 	io2 "github.com/influxdata/influxdb/v2/kit/io"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opentracing/opentracing-go"
 )
 
@@ -29,8 +36,56 @@ const (
 	opPointsWriter           = "http/pointsWriter"
 	msgUnableToReadData      = "unable to read data"
 	msgWritingRequiresPoints = "writing requires points"
+
+	// DefaultChunkBytes is the default size of the chunks of lines parsed at once.
+	DefaultChunkBytes = 1 << 20
+
+	// DefaultSpoolBytes is the default size of the batches held in memory
+	// while they are validated.
+	DefaultSpoolBytes = 16 << 20
+
+	// maxSnippetBytes bounds the size of the snippets of rejected lines.
+	maxSnippetBytes = 128
 )
 
+const (
+	// ReasonParseError is the reason of rejected lines that are not valid
+	// line protocol. Lines whose point was dropped by the points writer have
+	// the cause of the drop as reason, see tsdb.DropCause.
+	ReasonParseError = "parse error"
+
+	// ReasonUnknown is the reason of dropped points that could not be
+	// attributed to a line.
+	ReasonUnknown = "unknown"
+)
+
+// RejectedLine is a line rejected by a partial write.
+type RejectedLine struct {
+	// Line is the number of the line in the body, starting at 1.
+	Line    int    `json:"line"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// PartialWriteResponse is the body of the response to a partial write that
+// rejected some lines.
+type PartialWriteResponse struct {
+	Code     string         `json:"code"`
+	Message  string         `json:"message"`
+	Rejected []RejectedLine `json:"rejected"`
+}
+
+// NewPartialWriteResponse returns the response to a partial write that
+// rejected lines.
+func NewPartialWriteResponse(rejected []RejectedLine) *PartialWriteResponse {
+	return &PartialWriteResponse{
+		Code:     errors2.EUnprocessableEntity,
+		Message:  fmt.Sprintf("partial write: %d lines rejected", len(rejected)),
+		Rejected: rejected,
+	}
+}
+
 // ParsedPoints contains the points parsed as well as the total number of bytes
 // after decompression.
 type ParsedPoints struct {
@@ -41,6 +96,17 @@ type ParsedPoints struct {
 // Parser parses batches of Points.
 type Parser struct {
 	Precision string
+	// Format is the format of the body, FormatLineProtocol by default.
+	Format string
+	// ChunkBytes is the size of the chunks of lines parsed at once. Lines
+	// longer than ChunkBytes are parsed in chunks of their own.
+	ChunkBytes int
+	// SpoolBytes is the size of the batches held in memory while they are
+	// validated, DefaultSpoolBytes by default. Larger batches are copied to
+	// a temporary file in SpoolDir, or in the default directory for
+	// temporary files if SpoolDir is empty.
+	SpoolBytes int
+	SpoolDir   string
 	//ParserOptions []models.ParserOption
 }
 
@@ -48,88 +114,512 @@ type Parser struct {
 func (pw *Parser) Parse(ctx context.Context, orgID, bucketID platform.ID, rc io.ReadCloser) (*ParsedPoints, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "write points")
 	defer span.Finish()
-	return pw.parsePoints(ctx, orgID, bucketID, rc)
+
+	var points models.Points
+	n, err := pw.stream(ctx, rc, func(chunk models.Points) error {
+		points = append(points, chunk...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParsedPoints{
+		Points:  points,
+		RawSize: n,
+	}, nil
+}
+
+// WriteError is returned by Write when the points writer fails.
+type WriteError struct {
+	Err error
+}
+
+func (e *WriteError) Error() string {
+	return e.Err.Error()
 }
 
-func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID, rc io.ReadCloser) (*ParsedPoints, error) {
-	data, err := readAll(ctx, rc)
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// Write parses the points from an io.ReadCloser and writes them to a specific
+// Bucket in chunks, so the memory used does not grow with the size of the
+// batch. Reading pauses while a chunk is written, applying backpressure to the
+// client. It returns the number of bytes read.
+//
+// The whole batch is parsed before any point is written, so a batch that is
+// not valid or exceeds the size limit writes nothing, as with Parse. The
+// points of batches up to SpoolBytes are kept in memory once parsed, larger
+// batches are copied to a temporary file and parsed again from it to be
+// written. Errors of the points writer are returned as a *WriteError. Partial write
+// errors do not stop the stream, they are combined into a single
+// tsdb.PartialWriteError once all chunks have been written.
+func (pw *Parser) Write(ctx context.Context, orgID, bucketID platform.ID, rc io.ReadCloser, w storage.PointsWriter) (int, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "write points")
+	defer span.Finish()
+
+	// The points parsed are kept while the batch is held in memory.
+	var parsed []models.Points
+	body := pw.newSpoolFile()
+	defer body.Close()
+	n, err := pw.spool(rc, body, func(rc io.ReadCloser) (int, error) {
+		return pw.stream(ctx, rc, func(points models.Points) error {
+			if body.file == nil {
+				parsed = append(parsed, points)
+			} else {
+				parsed = nil
+			}
+			return nil
+		})
+	})
 	if err != nil {
-		code := errors2.EInternal
-		if errors.Is(err, ErrMaxBatchSizeExceeded) {
-			code = errors2.ETooLarge
-		} else if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) {
-			code = errors2.EInvalid
-		}
-		return nil, &errors2.Error{
-			Code: code,
-			Op:   opPointsWriter,
-			Msg:  msgUnableToReadData,
-			Err:  err,
+		return n, err
+	}
+
+	var partial *tsdb.PartialWriteError
+	write := func(points models.Points) error {
+		err := w.WritePoints(ctx, orgID, bucketID, points)
+		if perr, ok := err.(tsdb.PartialWriteError); ok {
+			partial = tsdb.MergePartialWriteErrors(partial, perr)
+			return nil
+		}
+		if err != nil {
+			return &WriteError{Err: err}
 		}
+		return nil
 	}
+	if body.file == nil {
+		for _, points := range parsed {
+			if err := write(points); err != nil {
+				return n, err
+			}
+		}
+	} else if _, err := pw.stream(ctx, body, write); err != nil {
+		return n, err
+	}
+	if partial != nil {
+		return n, &WriteError{Err: *partial}
+	}
+	return n, nil
+}
 
-	requestBytes := len(data)
-	if requestBytes == 0 {
-		return nil, &errors2.Error{
-			Op:   opPointsWriter,
+// WritePartial writes the points of an io.ReadCloser like Write, but parses
+// every line on its own, so the lines which are not valid line protocol are
+// skipped instead of failing the write. It returns the number of bytes read
+// and the lines that were rejected, either because they could not be parsed
+// or because the points writer dropped their point, in the order of the body.
+//
+// Errors are returned as by Write, except for partial write errors which are
+// reported as rejected lines. As with Write, a batch that exceeds the size
+// limit writes nothing. Partial writes are only supported for line protocol.
+func (pw *Parser) WritePartial(ctx context.Context, orgID, bucketID platform.ID, rc io.ReadCloser, w storage.PointsWriter) (int, []RejectedLine, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "write points")
+	defer span.Finish()
+
+	if pw.Format == FormatJSON {
+		rc.Close()
+		return 0, nil, &errors2.Error{
 			Code: errors2.EInvalid,
-			Msg:  msgWritingRequiresPoints,
+			Op:   opPointsWriter,
+			Msg:  "partial writes require line protocol",
 		}
 	}
 
-	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "encoding and parsing")
-
-	points, err := models.ParsePointsWithPrecision(data, time.Now().UTC(), pw.Precision)
-	span.LogKV("values_total", len(points))
-	span.Finish()
+	body := pw.newSpoolFile()
+	defer body.Close()
+	n, err := pw.spool(rc, body, func(rc io.ReadCloser) (int, error) {
+		return pw.chunks(rc, func([]byte) error { return nil })
+	})
 	if err != nil {
-		tracing.LogError(span, fmt.Errorf("error parsing points: %v", err))
-
-		code := errors2.EInvalid
-		// TODO - backport these
-		// if errors.Is(err, models.ErrLimitMaxBytesExceeded) ||
-		// 	errors.Is(err, models.ErrLimitMaxLinesExceeded) ||
-		// 	errors.Is(err, models.ErrLimitMaxValuesExceeded) {
-		// 	code = influxdb.ETooLarge
-		// }
-
-		return nil, &errors2.Error{
-			Code: code,
-			Op:   opPointsWriter,
-			Msg:  "",
-			Err:  err,
+		return n, nil, err
+	}
+
+	var (
+		rejected []RejectedLine
+		line     = 1 // number of the first line of the chunk
+		now      = time.Now().UTC()
+	)
+	_, err = pw.chunks(body, func(chunk []byte) error {
+		var (
+			points models.Points
+			lines  []lineRef // line of each point
+		)
+		for i := 0; i < len(chunk); {
+			start := i
+			for start < len(chunk) && (chunk[start] == ' ' || chunk[start] == '\t') {
+				start++
+			}
+			end := models.LineEnd(chunk, start)
+			text := chunk[i:end]
+
+			pts, err := models.ParsePointsWithPrecision(text, now, pw.Precision)
+			if err != nil {
+				rejected = append(rejected, RejectedLine{
+					Line:    line,
+					Reason:  ReasonParseError,
+					Message: err.Error(),
+					Snippet: snippet(text),
+				})
+			}
+			for _, p := range pts {
+				points = append(points, p)
+				lines = append(lines, lineRef{line: line, text: text})
+			}
+
+			line += bytes.Count(text, []byte{'\n'}) + 1
+			i = end + 1
+		}
+		if len(points) == 0 {
+			return nil
+		}
+
+		err := w.WritePoints(ctx, orgID, bucketID, points)
+		if perr, ok := err.(tsdb.PartialWriteError); ok {
+			rejected = append(rejected, droppedLines(perr, points, lines)...)
+			return nil
+		}
+		if err != nil {
+			return &WriteError{Err: err}
 		}
+		return nil
+	})
+	sort.SliceStable(rejected, func(i, j int) bool {
+		return rejected[i].Line < rejected[j].Line
+	})
+	return n, rejected, err
+}
+
+// lineRef is the line a point was parsed from.
+type lineRef struct {
+	line int
+	text []byte
+}
+
+// droppedLines returns the rejected lines of the points dropped by a partial
+// write. Dropped points that cannot be attributed to a line are reported as a
+// single rejected line numbered 0.
+func droppedLines(perr tsdb.PartialWriteError, points models.Points, lines []lineRef) []RejectedLine {
+	index := make(map[models.Point]int, len(points))
+	for i, p := range points {
+		index[p] = i
 	}
 
-	return &ParsedPoints{
-		Points:  points,
-		RawSize: requestBytes,
-	}, nil
+	var rejected []RejectedLine
+	for _, d := range perr.DroppedPoints {
+		i, ok := index[d.Point]
+		if !ok {
+			continue
+		}
+		rejected = append(rejected, RejectedLine{
+			Line:    lines[i].line,
+			Reason:  string(d.Cause),
+			Message: d.Reason,
+			Snippet: snippet(lines[i].text),
+		})
+	}
+	if unknown := perr.Dropped - len(rejected); unknown > 0 {
+		rejected = append(rejected, RejectedLine{
+			Reason:  ReasonUnknown,
+			Message: fmt.Sprintf("%d points dropped: %s", unknown, perr.Reason),
+		})
+	}
+	return rejected
 }
 
-func readAll(ctx context.Context, rc io.ReadCloser) (data []byte, err error) {
+// snippet returns the beginning of a rejected line.
+func snippet(line []byte) string {
+	if len(line) > maxSnippetBytes {
+		return string(line[:maxSnippetBytes]) + "..."
+	}
+	return string(line)
+}
+
+// stream calls fn with the points of each chunk of lines read from rc, and
+// returns the number of bytes read.
+func (pw *Parser) stream(ctx context.Context, rc io.ReadCloser, fn func(models.Points) error) (n int, err error) {
+	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "encoding and parsing")
+	var values int
 	defer func() {
-		if cerr := rc.Close(); cerr != nil && err == nil {
-			if errors.Is(cerr, io2.ErrReadLimitExceeded) {
-				cerr = ErrMaxBatchSizeExceeded
+		span.LogKV("request_bytes", n, "values_total", values)
+		span.Finish()
+	}()
+
+	if pw.Format == FormatJSON {
+		return pw.streamJSON(rc, func(points models.Points) error {
+			values += len(points)
+			return fn(points)
+		})
+	}
+
+	now := time.Now().UTC()
+	return pw.chunks(rc, func(chunk []byte) error {
+		points, err := models.ParsePointsWithPrecision(chunk, now, pw.Precision)
+		if err != nil {
+			tracing.LogError(span, fmt.Errorf("error parsing points: %v", err))
+
+			code := errors2.EInvalid
+			// TODO - backport these
+			// if errors.Is(err, models.ErrLimitMaxBytesExceeded) ||
+			// 	errors.Is(err, models.ErrLimitMaxLinesExceeded) ||
+			// 	errors.Is(err, models.ErrLimitMaxValuesExceeded) {
+			// 	code = influxdb.ETooLarge
+			// }
+
+			return &errors2.Error{
+				Code: code,
+				Op:   opPointsWriter,
+				Msg:  "",
+				Err:  err,
 			}
-			err = cerr
 		}
-	}()
+		if len(points) == 0 {
+			return nil
+		}
+		values += len(points)
+
+		return fn(points)
+	})
+}
+
+// newSpoolFile returns the spoolFile holding a copy of a batch.
+func (pw *Parser) newSpoolFile() *spoolFile {
+	s := &spoolFile{maxMemBytes: pw.SpoolBytes, dir: pw.SpoolDir}
+	if s.maxMemBytes <= 0 {
+		s.maxMemBytes = DefaultSpoolBytes
+	}
+	return s
+}
+
+// spool reads the whole batch of rc through check, which validates it, while
+// copying it to s. Once the batch has been validated, s is rewound to be read
+// from its beginning. It returns the number of bytes read.
+func (pw *Parser) spool(rc io.ReadCloser, s *spoolFile, check func(io.ReadCloser) (int, error)) (int, error) {
+	n, err := check(&spoolReader{Reader: io.TeeReader(rc, s), rc: rc})
+	if err != nil {
+		return n, err
+	}
+	if err := s.rewind(); err != nil {
+		return n, readError(err)
+	}
+	return n, nil
+}
+
+// spoolReader reads a batch while it is copied to a spoolFile.
+type spoolReader struct {
+	io.Reader
+	rc io.ReadCloser
+}
+
+func (r *spoolReader) Close() error {
+	return r.rc.Close()
+}
+
+// spoolFile holds a copy of a batch, in memory up to maxMemBytes and in a
+// temporary file of dir beyond.
+type spoolFile struct {
+	maxMemBytes int
+	dir         string
+	buf         bytes.Buffer
+	file        *os.File
+}
+
+func (s *spoolFile) Write(p []byte) (int, error) {
+	if s.file == nil && s.buf.Len()+len(p) > s.maxMemBytes {
+		f, err := ioutil.TempFile(s.dir, "influxdb-write-")
+		if err != nil {
+			return 0, err
+		}
+		s.file = f
+		if _, err := s.buf.WriteTo(f); err != nil {
+			return 0, err
+		}
+	}
+	if s.file != nil {
+		return s.file.Write(p)
+	}
+	return s.buf.Write(p)
+}
+
+// rewind prepares the copy to be read from its beginning.
+func (s *spoolFile) rewind() error {
+	if s.file == nil {
+		return nil
+	}
+	_, err := s.file.Seek(0, io.SeekStart)
+	return err
+}
+
+func (s *spoolFile) Read(p []byte) (int, error) {
+	if s.file != nil {
+		return s.file.Read(p)
+	}
+	return s.buf.Read(p)
+}
 
-	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "read request body")
+// Close removes the temporary file of the copy, if any.
+func (s *spoolFile) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	name := s.file.Name()
+	err := s.file.Close()
+	if rerr := os.Remove(name); err == nil {
+		err = rerr
+	}
+	s.file = nil
+	return err
+}
 
+// chunks calls fn with each chunk of complete lines read from rc, and returns
+// the number of bytes read.
+func (pw *Parser) chunks(rc io.ReadCloser, fn func([]byte) error) (n int, err error) {
+	closed := false
 	defer func() {
-		span.LogKV("request_bytes", len(data))
-		span.Finish()
+		if !closed {
+			rc.Close()
+		}
 	}()
 
-	data, err = ioutil.ReadAll(rc)
-	if err != nil {
-		return nil, err
+	chunkBytes := pw.ChunkBytes
+	if chunkBytes <= 0 {
+		chunkBytes = DefaultChunkBytes
+	}
+	cr := &chunkReader{r: rc, buf: make([]byte, chunkBytes)}
+
+	for {
+		chunk, err := cr.next()
+		n = cr.read
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, readError(err)
+		}
+		if cr.eof && !closed {
+			// The size limit of the batch is only reported on close, check it
+			// before handling the last chunk.
+			closed = true
+			if err := closeReader(rc); err != nil {
+				return n, readError(err)
+			}
+		}
+
+		if err := fn(chunk); err != nil {
+			return n, err
+		}
+	}
+
+	if !closed {
+		closed = true
+		if err := closeReader(rc); err != nil {
+			return n, readError(err)
+		}
+	}
+	if n == 0 {
+		return 0, &errors2.Error{
+			Op:   opPointsWriter,
+			Code: errors2.EInvalid,
+			Msg:  msgWritingRequiresPoints,
+		}
+	}
+	return n, nil
+}
+
+func closeReader(rc io.ReadCloser) error {
+	err := rc.Close()
+	if errors.Is(err, io2.ErrReadLimitExceeded) {
+		return ErrMaxBatchSizeExceeded
+	}
+	return err
+}
+
+func readError(err error) error {
+	code := errors2.EInternal
+	if errors.Is(err, ErrMaxBatchSizeExceeded) {
+		code = errors2.ETooLarge
+	} else if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) ||
+		errors.Is(err, zstd.ErrMagicMismatch) || errors.Is(err, zstd.ErrCRCMismatch) || errors.Is(err, snappy.ErrCorrupt) {
+		code = errors2.EInvalid
+	}
+	return &errors2.Error{
+		Code: code,
+		Op:   opPointsWriter,
+		Msg:  msgUnableToReadData,
+		Err:  err,
+	}
+}
+
+// chunkReader splits line protocol into chunks of complete lines.
+type chunkReader struct {
+	r   io.Reader
+	buf []byte
+	n   int // number of bytes buffered in buf
+
+	eof  bool
+	read int // total number of bytes read
+}
+
+// next returns the next chunk of complete lines, or io.EOF once all lines
+// have been returned.
+func (c *chunkReader) next() ([]byte, error) {
+	for {
+		if err := c.fill(); err != nil {
+			return nil, err
+		}
+
+		end := c.n
+		if !c.eof {
+			end = c.lastLineEnd()
+		}
+		if end == 0 && c.eof {
+			return nil, io.EOF
+		}
+		if end > 0 {
+			chunk := make([]byte, end)
+			copy(chunk, c.buf[:end])
+			c.n = copy(c.buf, c.buf[end:c.n])
+			return chunk, nil
+		}
+
+		// a single line does not fit the buffer
+		buf := make([]byte, 2*len(c.buf))
+		copy(buf, c.buf[:c.n])
+		c.buf = buf
+	}
+}
 
+// fill reads until the buffer is full or the reader is exhausted.
+func (c *chunkReader) fill() error {
+	for !c.eof && c.n < len(c.buf) {
+		n, err := c.r.Read(c.buf[c.n:])
+		c.n += n
+		c.read += n
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastLineEnd returns the position following the last complete line of the
+// buffer, or 0 if the buffer does not hold a complete line.
+func (c *chunkReader) lastLineEnd() int {
+	buf := c.buf[:c.n]
+	last := 0
+	for i := 0; i < len(buf); {
+		end := models.LineEnd(buf, i)
+		// The scanning of escaped characters close to the end of the
+		// buffer depends on the bytes that follow.
+		if end >= len(buf)-2 {
+			break
+		}
+		last = end + 1
+		i = last
 	}
-	return data, nil
+	return last
 }
 
 // NewParser returns a new Parser
@@ -0,0 +1,233 @@
+package points
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	orgID    = platform.ID(1)
+	bucketID = platform.ID(2)
+)
+
+type chunkWriter struct {
+	chunks []models.Points
+	err    func(models.Points) error
+}
+
+func (w *chunkWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	w.chunks = append(w.chunks, points)
+	if w.err != nil {
+		return w.err(points)
+	}
+	return nil
+}
+
+func TestParser_Write(t *testing.T) {
+	lines := []string{
+		`cpu,host=a value=1 1`,
+		`cpu,host=b value=2 2`,
+		`log,host=a msg="line one` + "\n" + `line two" 3`,
+		`cpu,host=c\ d value=4 4`,
+		`cpu,host=e value=5 5`,
+	}
+	body := strings.Join(lines, "\n") + "\n"
+
+	for _, chunkBytes := range []int{1, 16, 40, len(body), DefaultChunkBytes} {
+		w := &chunkWriter{}
+		p := &Parser{Precision: "ns", ChunkBytes: chunkBytes}
+		n, err := p.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(body)), w)
+		require.NoError(t, err)
+		assert.Equal(t, len(body), n)
+
+		var got []string
+		for _, chunk := range w.chunks {
+			for _, pt := range chunk {
+				got = append(got, pt.String())
+			}
+		}
+		assert.Equal(t, lines, got, "chunk bytes %d", chunkBytes)
+	}
+}
+
+func TestParser_WriteChunks(t *testing.T) {
+	body := "cpu value=1 1\ncpu value=2 2\ncpu value=3 3\n"
+	w := &chunkWriter{}
+	p := &Parser{Precision: "ns", ChunkBytes: 20}
+	_, err := p.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(body)), w)
+	require.NoError(t, err)
+	assert.Len(t, w.chunks, 3, "the points should be written in chunks")
+}
+
+func TestParser_WritePartial(t *testing.T) {
+	body := "cpu value=1 1\ncpu value=2 2\ncpu value=3 3\n"
+	w := &chunkWriter{
+		err: func(models.Points) error {
+			return tsdb.PartialWriteError{Reason: "out of retention", Dropped: 1, DroppedKeys: [][]byte{[]byte("cpu")}}
+		},
+	}
+	p := &Parser{Precision: "ns", ChunkBytes: 20}
+	_, err := p.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(body)), w)
+
+	require.IsType(t, &WriteError{}, err)
+	partial, ok := err.(*WriteError).Err.(tsdb.PartialWriteError)
+	require.True(t, ok)
+	assert.Equal(t, 3, partial.Dropped)
+	assert.Len(t, w.chunks, 3, "partial writes must not stop the stream")
+}
+
+func TestParser_WriteInvalid(t *testing.T) {
+	body := "cpu value=1 1\ncpu value=2 2\ncpu value= 3\n"
+	w := &chunkWriter{}
+	p := &Parser{Precision: "ns", ChunkBytes: 20}
+	_, err := p.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(body)), w)
+	assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+	assert.Empty(t, w.chunks, "nothing is written when a line is invalid")
+}
+
+func TestParser_WriteTooLarge(t *testing.T) {
+	body := strings.Repeat("cpu value=1 1\n", 100)
+	newBody := func() io.ReadCloser {
+		rc, err := BatchReadCloser(ioutil.NopCloser(strings.NewReader(body)), "", int64(len(body)-1))
+		require.NoError(t, err)
+		return rc
+	}
+
+	w := &chunkWriter{}
+	p := &Parser{Precision: "ns", ChunkBytes: 20}
+	_, err := p.Write(context.Background(), orgID, bucketID, newBody(), w)
+	assert.Equal(t, errors2.ETooLarge, errors2.ErrorCode(err))
+	assert.Empty(t, w.chunks, "nothing is written when the batch is too large")
+
+	_, _, err = p.WritePartial(context.Background(), orgID, bucketID, newBody(), w)
+	assert.Equal(t, errors2.ETooLarge, errors2.ErrorCode(err))
+	assert.Empty(t, w.chunks, "nothing is written when the batch is too large")
+}
+
+func TestParser_WriteSpool(t *testing.T) {
+	body := strings.Repeat("cpu value=1 1\n", 100)
+	for _, tt := range []struct {
+		name       string
+		body       string
+		spoolBytes int
+		valid      bool
+		spooled    bool
+	}{
+		{name: "valid", body: body, spoolBytes: 100, valid: true, spooled: true},
+		{name: "invalid", body: body + "cpu value= 2\n", spoolBytes: 100},
+		{name: "in memory", body: body, spoolBytes: len(body), valid: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Batches larger than SpoolBytes are spooled to a temporary file
+			// of SpoolDir.
+			dir := t.TempDir()
+			var spooled bool
+			w := &chunkWriter{
+				err: func(models.Points) error {
+					files, err := ioutil.ReadDir(dir)
+					require.NoError(t, err)
+					spooled = spooled || len(files) > 0
+					return nil
+				},
+			}
+			p := &Parser{Precision: "ns", ChunkBytes: 20, SpoolBytes: tt.spoolBytes, SpoolDir: dir}
+			_, err := p.Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(tt.body)), w)
+			if tt.valid {
+				require.NoError(t, err)
+				assert.Len(t, w.chunks, 100)
+			} else {
+				require.Error(t, err)
+				assert.Empty(t, w.chunks)
+			}
+			assert.Equal(t, tt.spooled, spooled)
+
+			files, err := ioutil.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, files, "the temporary file is removed")
+		})
+	}
+}
+
+func TestParser_WriteEmpty(t *testing.T) {
+	w := &chunkWriter{}
+	_, err := NewParser("ns").Write(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader("")), w)
+	assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+	assert.Empty(t, w.chunks)
+}
+
+func TestParser_WritePartialLines(t *testing.T) {
+	body := "cpu value=1 1\n" +
+		"cpu value= 2\n" +
+		"\n" +
+		"log msg=\"one\ntwo\" 3\n" +
+		"cpu value=\"conflict\" 4\n" +
+		"cpu value=5 5\n"
+	w := &chunkWriter{
+		err: func(points models.Points) error {
+			for _, p := range points {
+				if p.UnixNano() == 4 {
+					return tsdb.PartialWriteError{
+						Reason:        "field type conflict",
+						Dropped:       1,
+						DroppedPoints: []tsdb.DroppedPoint{{Point: p, Cause: tsdb.DropFieldTypeConflict, Reason: "field type conflict"}},
+					}
+				}
+			}
+			return nil
+		},
+	}
+
+	for _, chunkBytes := range []int{1, 20, DefaultChunkBytes} {
+		w.chunks = nil
+		p := &Parser{Precision: "ns", ChunkBytes: chunkBytes}
+		n, rejected, err := p.WritePartial(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader(body)), w)
+		require.NoError(t, err)
+		assert.Equal(t, len(body), n)
+
+		require.Len(t, rejected, 2, "chunk bytes %d", chunkBytes)
+		assert.Equal(t, 2, rejected[0].Line)
+		assert.Equal(t, ReasonParseError, rejected[0].Reason)
+		assert.Equal(t, "cpu value= 2", rejected[0].Snippet)
+		assert.Equal(t, RejectedLine{
+			Line:    6,
+			Reason:  "field type conflict",
+			Message: "field type conflict",
+			Snippet: `cpu value="conflict" 4`,
+		}, rejected[1])
+
+		var written int
+		for _, chunk := range w.chunks {
+			written += len(chunk)
+		}
+		assert.Equal(t, 4, written, "the valid lines must be written")
+	}
+}
+
+func TestParser_WritePartialUnknown(t *testing.T) {
+	w := &chunkWriter{
+		err: func(models.Points) error {
+			return tsdb.PartialWriteError{Reason: "points beyond retention policy", Dropped: 1}
+		},
+	}
+	_, rejected, err := NewParser("ns").WritePartial(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader("cpu value=1 1\n")), w)
+	require.NoError(t, err)
+	require.Len(t, rejected, 1)
+	assert.Equal(t, 0, rejected[0].Line)
+	assert.Equal(t, ReasonUnknown, rejected[0].Reason)
+}
+
+func TestParser_WritePartialEmpty(t *testing.T) {
+	w := &chunkWriter{}
+	_, _, err := NewParser("ns").WritePartial(context.Background(), orgID, bucketID, ioutil.NopCloser(strings.NewReader("")), w)
+	assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+}
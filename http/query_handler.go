@@ -12,7 +12,6 @@ import (
 	"sort"
 	"time"
 
-	"github.com/NYTimes/gziphandler"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/csv"
@@ -113,8 +112,8 @@ func NewFluxHandler(log *zap.Logger, b *FluxBackend) *FluxHandler {
 		Flagger:             b.Flagger,
 	}
 
-	// query reponses can optionally be gzip encoded
-	qh := gziphandler.GzipHandler(http.HandlerFunc(h.handleQuery))
+	// query reponses can optionally be zstd or gzip encoded
+	qh := kithttp.CompressResponse(http.HandlerFunc(h.handleQuery))
 	h.Handler("POST", prefixQuery, withFeatureProxy(b.AlgoWProxy, qh))
 	h.Handler("POST", "/api/v2/query/ast", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.postFluxAST)))
 	h.Handler("POST", "/api/v2/query/analyze", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.postQueryAnalyze)))
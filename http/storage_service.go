@@ -0,0 +1,799 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/httprouter"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	"go.uber.org/zap"
+)
+
+// StorageBackend is all services and associated parameters required to construct the StorageHandler.
+type StorageBackend struct {
+	Logger *zap.Logger
+	errors.HTTPErrorHandler
+
+	StorageService influxdb.StorageService
+}
+
+// NewStorageBackend returns a new instance of StorageBackend.
+func NewStorageBackend(b *APIBackend) *StorageBackend {
+	return &StorageBackend{
+		Logger: b.Logger.With(zap.String("handler", "storage")),
+
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		StorageService:   b.StorageService,
+	}
+}
+
+// StorageHandler is http handler for the shards of the storage engine.
+type StorageHandler struct {
+	*httprouter.Router
+	errors.HTTPErrorHandler
+	Logger *zap.Logger
+
+	StorageService influxdb.StorageService
+}
+
+const (
+	prefixStorage               = "/api/v2/storage"
+	storageShardsPath           = prefixStorage + "/shards"
+	storageShardPath            = storageShardsPath + "/:shardID"
+	storageShardCompactPath     = storageShardPath + "/compact"
+	storageShardSnapshotPath    = storageShardPath + "/snapshot"
+	storageShardReindexPath     = storageShardPath + "/rebuild-index"
+	storageShardExportPath      = storageShardPath + "/export"
+	storageShardColdPath        = storageShardPath + "/move-to-cold"
+	storageShardActionPathFmt   = storageShardsPath + "/%d/%s"
+	storageBucketImportPath     = prefixStorage + "/buckets/:bucketID/import"
+	storageBucketTieringPath    = prefixStorage + "/buckets/:bucketID/tiering"
+	storageBucketDurabilityPath = prefixStorage + "/buckets/:bucketID/durability"
+	storageBucketReshardPath    = prefixStorage + "/buckets/:bucketID/reshard"
+	storageCompactionsPath      = prefixStorage + "/compactions"
+	storageCompactionLimitsPath = storageCompactionsPath + "/limits"
+	storageMemoryPath           = prefixStorage + "/memory"
+)
+
+// NewStorageHandler creates a new handler at /api/v2/storage to list and act on shards.
+func NewStorageHandler(b *StorageBackend) *StorageHandler {
+	h := &StorageHandler{
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		Router:           NewRouter(b.HTTPErrorHandler),
+		Logger:           b.Logger,
+		StorageService:   b.StorageService,
+	}
+
+	h.HandlerFunc(http.MethodGet, storageShardsPath, h.handleListShards)
+	h.HandlerFunc(http.MethodGet, storageShardPath, h.handleGetShard)
+	h.HandlerFunc(http.MethodPost, storageShardCompactPath, h.handleShardAction(h.StorageService.CompactShard))
+	h.HandlerFunc(http.MethodPost, storageShardSnapshotPath, h.handleShardAction(h.StorageService.SnapshotShard))
+	h.HandlerFunc(http.MethodPost, storageShardReindexPath, h.handleShardAction(h.StorageService.RebuildShardIndex))
+	h.HandlerFunc(http.MethodPost, storageShardColdPath, h.handleShardAction(h.StorageService.MoveShardToColdTier))
+	h.HandlerFunc(http.MethodGet, storageShardExportPath, h.handleExportShard)
+	h.HandlerFunc(http.MethodPost, storageBucketImportPath, h.handleImportShard)
+	h.HandlerFunc(http.MethodGet, storageBucketTieringPath, h.handleGetTieringPolicy)
+	h.HandlerFunc(http.MethodPut, storageBucketTieringPath, h.handlePutTieringPolicy)
+	h.HandlerFunc(http.MethodGet, storageBucketDurabilityPath, h.handleGetDurabilityPolicy)
+	h.HandlerFunc(http.MethodPut, storageBucketDurabilityPath, h.handlePutDurabilityPolicy)
+	h.HandlerFunc(http.MethodPost, storageBucketReshardPath, h.handleReshardBucket)
+	h.HandlerFunc(http.MethodGet, storageCompactionsPath, h.handleListCompactions)
+	h.HandlerFunc(http.MethodGet, storageCompactionLimitsPath, h.handleGetCompactionLimits)
+	h.HandlerFunc(http.MethodPatch, storageCompactionLimitsPath, h.handlePatchCompactionLimits)
+	h.HandlerFunc(http.MethodGet, storageMemoryPath, h.handleListMemoryUsage)
+
+	return h
+}
+
+type storageShardsResponse struct {
+	Shards []*influxdb.StorageShard `json:"shards"`
+}
+
+func (h *StorageHandler) handleListShards(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleListShards")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	filter, err := decodeStorageShardFilter(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	shards, err := h.StorageService.ListShards(ctx, filter)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, storageShardsResponse{Shards: shards}); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handleGetShard(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleGetShard")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	shardID, err := decodeShardID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	shard, err := h.StorageService.FindShardByID(ctx, shardID)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, shard); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// handleShardAction returns a handler applying fn to the shard of the request.
+func (h *StorageHandler) handleShardAction(fn func(ctx context.Context, id uint64) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleShardAction")
+		defer span.Finish()
+
+		ctx := r.Context()
+
+		shardID, err := decodeShardID(ctx)
+		if err != nil {
+			h.HandleHTTPError(ctx, err, w)
+			return
+		}
+
+		if err := fn(ctx, shardID); err != nil {
+			h.HandleHTTPError(ctx, err, w)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *StorageHandler) handleExportShard(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleExportShard")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	shardID, err := decodeShardID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	start, stop, err := decodeStorageTimeRange(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	// Look the shard up first so that a missing shard is reported before the
+	// archive starts streaming.
+	if _, err := h.StorageService.FindShardByID(ctx, shardID); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	if err := h.StorageService.ExportShard(ctx, shardID, start, stop, w); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+}
+
+func (h *StorageHandler) handleImportShard(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleImportShard")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, err := decodeStorageBucketID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	start, stop, err := decodeStorageTimeRange(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := h.StorageService.ImportShard(ctx, bucketID, start, stop, r.Body); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StorageHandler) handleGetTieringPolicy(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleGetTieringPolicy")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, err := decodeStorageBucketID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	policy, err := h.StorageService.FindTieringPolicy(ctx, bucketID)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, policy); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handlePutTieringPolicy(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handlePutTieringPolicy")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, err := decodeStorageBucketID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	var policy influxdb.StorageTieringPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid tiering policy",
+			Err:  err,
+		}, w)
+		return
+	}
+	policy.BucketID = bucketID
+
+	updated, err := h.StorageService.UpdateTieringPolicy(ctx, policy)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, updated); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handleGetDurabilityPolicy(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleGetDurabilityPolicy")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, err := decodeStorageBucketID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	policy, err := h.StorageService.FindDurabilityPolicy(ctx, bucketID)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, policy); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handlePutDurabilityPolicy(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handlePutDurabilityPolicy")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, err := decodeStorageBucketID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	var policy influxdb.StorageDurabilityPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid durability policy",
+			Err:  err,
+		}, w)
+		return
+	}
+	policy.BucketID = bucketID
+
+	updated, err := h.StorageService.UpdateDurabilityPolicy(ctx, policy)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, updated); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handleReshardBucket(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleReshardBucket")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, err := decodeStorageBucketID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	step, err := h.StorageService.ReshardBucket(ctx, bucketID)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, step); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handleListCompactions(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleListCompactions")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	filter, err := decodeStorageShardFilter(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	compactions, err := h.StorageService.ListCompactions(ctx, filter)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, compactions); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handleListMemoryUsage(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleListMemoryUsage")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	filter, err := decodeStorageShardFilter(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	usages, err := h.StorageService.ListMemoryUsage(ctx, filter)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, usages); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handleGetCompactionLimits(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handleGetCompactionLimits")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	limits, err := h.StorageService.FindCompactionLimits(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, limits); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *StorageHandler) handlePatchCompactionLimits(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "StorageHandler.handlePatchCompactionLimits")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	var upd influxdb.StorageCompactionLimitsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid compaction limits",
+			Err:  err,
+		}, w)
+		return
+	}
+
+	limits, err := h.StorageService.UpdateCompactionLimits(ctx, upd)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, limits); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// decodeStorageShardFilter returns the filter of the bucketID query parameter of r.
+func decodeStorageShardFilter(r *http.Request) (influxdb.StorageShardFilter, error) {
+	var filter influxdb.StorageShardFilter
+	if s := r.URL.Query().Get("bucketID"); s != "" {
+		id, err := platform.IDFromString(s)
+		if err != nil {
+			return filter, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid bucketID",
+				Err:  err,
+			}
+		}
+		filter.BucketID = id
+	}
+	return filter, nil
+}
+
+// decodeStorageTimeRange returns the start and stop query parameters of r.
+func decodeStorageTimeRange(r *http.Request) (start, stop time.Time, err error) {
+	qp := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{name: "start", dst: &start},
+		{name: "stop", dst: &stop},
+	} {
+		if *p.dst, err = time.Parse(time.RFC3339Nano, qp.Get(p.name)); err != nil {
+			return time.Time{}, time.Time{}, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid %s time", p.name),
+				Err:  err,
+			}
+		}
+	}
+	if !start.Before(stop) {
+		return time.Time{}, time.Time{}, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "start time must be before stop time",
+		}
+	}
+	return start, stop, nil
+}
+
+func decodeStorageBucketID(ctx context.Context) (platform.ID, error) {
+	params := httprouter.ParamsFromContext(ctx)
+	bucketID, err := platform.IDFromString(params.ByName("bucketID"))
+	if err != nil {
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid bucket ID",
+			Err:  err,
+		}
+	}
+	return *bucketID, nil
+}
+
+func decodeShardID(ctx context.Context) (uint64, error) {
+	params := httprouter.ParamsFromContext(ctx)
+	shardID, err := strconv.ParseUint(params.ByName("shardID"), 10, 64)
+	if err != nil {
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid shard ID",
+			Err:  err,
+		}
+	}
+	return shardID, nil
+}
+
+// StorageService is the client implementation of influxdb.StorageService.
+type StorageService struct {
+	Client *httpc.Client
+}
+
+var _ influxdb.StorageService = (*StorageService)(nil)
+
+// ListShards returns the shards matching the filter.
+func (s *StorageService) ListShards(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageShard, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp storageShardsResponse
+	err := s.Client.
+		Get(storageShardsPath).
+		QueryParams(storageShardFilterParams(filter)...).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Shards, nil
+}
+
+// FindShardByID returns a single shard by ID.
+func (s *StorageService) FindShardByID(ctx context.Context, id uint64) (*influxdb.StorageShard, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var shard influxdb.StorageShard
+	err := s.Client.
+		Get(storageShardsPath, strconv.FormatUint(id, 10)).
+		DecodeJSON(&shard).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &shard, nil
+}
+
+// CompactShard schedules a full compaction of a shard.
+func (s *StorageService) CompactShard(ctx context.Context, id uint64) error {
+	return s.shardAction(ctx, id, "compact")
+}
+
+// SnapshotShard writes the cache of a shard to a new TSM file.
+func (s *StorageService) SnapshotShard(ctx context.Context, id uint64) error {
+	return s.shardAction(ctx, id, "snapshot")
+}
+
+// RebuildShardIndex rebuilds the index of a shard from its data.
+func (s *StorageService) RebuildShardIndex(ctx context.Context, id uint64) error {
+	return s.shardAction(ctx, id, "rebuild-index")
+}
+
+// MoveShardToColdTier moves the files of a shard to the cold tier.
+func (s *StorageService) MoveShardToColdTier(ctx context.Context, id uint64) error {
+	return s.shardAction(ctx, id, "move-to-cold")
+}
+
+func (s *StorageService) shardAction(ctx context.Context, id uint64, action string) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	return s.Client.
+		Post(httpc.BodyEmpty, fmt.Sprintf(storageShardActionPathFmt, id, action)).
+		Do(ctx)
+}
+
+// ExportShard writes a tar archive of the TSM files of a shard, keeping the
+// blocks with values between start and stop.
+func (s *StorageService) ExportShard(ctx context.Context, id uint64, start, stop time.Time, w io.Writer) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	return s.Client.
+		Get(storageShardsPath, strconv.FormatUint(id, 10), "export").
+		QueryParams(storageTimeRangeParams(start, stop)...).
+		Decode(func(resp *http.Response) error {
+			_, err := io.Copy(w, resp.Body)
+			return err
+		}).
+		Do(ctx)
+}
+
+// ImportShard adds the values between start and stop of an archive written
+// by ExportShard to a bucket.
+func (s *StorageService) ImportShard(ctx context.Context, bucketID platform.ID, start, stop time.Time, r io.Reader) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	body := func(w io.Writer) (string, string, error) {
+		_, err := io.Copy(w, r)
+		return "Content-Type", "application/x-tar", err
+	}
+	return s.Client.
+		Post(body, prefixStorage, "buckets", bucketID.String(), "import").
+		QueryParams(storageTimeRangeParams(start, stop)...).
+		Do(ctx)
+}
+
+// FindTieringPolicy returns the tiering policy of a bucket.
+func (s *StorageService) FindTieringPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageTieringPolicy, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var policy influxdb.StorageTieringPolicy
+	err := s.Client.
+		Get(prefixStorage, "buckets", bucketID.String(), "tiering").
+		DecodeJSON(&policy).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// UpdateTieringPolicy sets the tiering policy of a bucket.
+func (s *StorageService) UpdateTieringPolicy(ctx context.Context, policy influxdb.StorageTieringPolicy) (*influxdb.StorageTieringPolicy, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var updated influxdb.StorageTieringPolicy
+	err := s.Client.
+		PutJSON(policy, prefixStorage, "buckets", policy.BucketID.String(), "tiering").
+		DecodeJSON(&updated).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ListCompactions returns the queued and in progress compactions of the
+// shards matching the filter.
+func (s *StorageService) ListCompactions(ctx context.Context, filter influxdb.StorageShardFilter) (*influxdb.StorageCompactions, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var compactions influxdb.StorageCompactions
+	err := s.Client.
+		Get(storageCompactionsPath).
+		QueryParams(storageShardFilterParams(filter)...).
+		DecodeJSON(&compactions).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &compactions, nil
+}
+
+// FindCompactionLimits returns the limits shared by all compactions.
+func (s *StorageService) FindCompactionLimits(ctx context.Context) (*influxdb.StorageCompactionLimits, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var limits influxdb.StorageCompactionLimits
+	err := s.Client.
+		Get(storageCompactionLimitsPath).
+		DecodeJSON(&limits).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// UpdateCompactionLimits changes the limits shared by all compactions.
+func (s *StorageService) UpdateCompactionLimits(ctx context.Context, upd influxdb.StorageCompactionLimitsUpdate) (*influxdb.StorageCompactionLimits, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var limits influxdb.StorageCompactionLimits
+	err := s.Client.
+		PatchJSON(upd, storageCompactionLimitsPath).
+		DecodeJSON(&limits).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// FindDurabilityPolicy returns the durability policy of a bucket.
+func (s *StorageService) FindDurabilityPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageDurabilityPolicy, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var policy influxdb.StorageDurabilityPolicy
+	err := s.Client.
+		Get(prefixStorage, "buckets", bucketID.String(), "durability").
+		DecodeJSON(&policy).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// UpdateDurabilityPolicy sets the durability policy of a bucket.
+func (s *StorageService) UpdateDurabilityPolicy(ctx context.Context, policy influxdb.StorageDurabilityPolicy) (*influxdb.StorageDurabilityPolicy, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var updated influxdb.StorageDurabilityPolicy
+	err := s.Client.
+		PutJSON(policy, prefixStorage, "buckets", policy.BucketID.String(), "durability").
+		DecodeJSON(&updated).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ListMemoryUsage returns the memory used by the shards and series files of
+// the buckets matching the filter.
+func (s *StorageService) ListMemoryUsage(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageBucketMemoryUsage, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var usages []*influxdb.StorageBucketMemoryUsage
+	err := s.Client.
+		Get(storageMemoryPath).
+		QueryParams(storageShardFilterParams(filter)...).
+		DecodeJSON(&usages).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+// ReshardBucket replaces the next shard groups of a bucket that don't have its
+// shard group duration by groups that do.
+func (s *StorageService) ReshardBucket(ctx context.Context, bucketID platform.ID) (*influxdb.StorageReshardStep, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var step influxdb.StorageReshardStep
+	err := s.Client.
+		Post(httpc.BodyEmpty, prefixStorage, "buckets", bucketID.String(), "reshard").
+		DecodeJSON(&step).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &step, nil
+}
+
+func storageShardFilterParams(filter influxdb.StorageShardFilter) [][2]string {
+	var params [][2]string
+	if filter.BucketID != nil {
+		params = append(params, [2]string{"bucketID", filter.BucketID.String()})
+	}
+	return params
+}
+
+func storageTimeRangeParams(start, stop time.Time) [][2]string {
+	return [][2]string{
+		{"start", start.UTC().Format(time.RFC3339Nano)},
+		{"stop", stop.UTC().Format(time.RFC3339Nano)},
+	}
+}
@@ -1,11 +1,16 @@
 package http
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -20,6 +25,7 @@ import (
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/klauspost/compress/zstd"
 	"go.uber.org/zap"
 )
 
@@ -59,6 +65,9 @@ type WriteHandler struct {
 	router            *httprouter.Router
 	log               *zap.Logger
 	maxBatchSizeBytes int64
+	deduplicator      *WriteDeduplicator
+	spoolDir          string
+	spoolBytes        int
 	// parserOptions     []models.ParserOption
 }
 
@@ -73,6 +82,23 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithWriteDeduplicator configures the deduplicator remembering the writes
+// sent with an Idempotency-Key header. Without one, the header is ignored.
+func WithWriteDeduplicator(d *WriteDeduplicator) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.deduplicator = d
+	}
+}
+
+// WithWriteSpool configures where the batches are held while they are
+// validated: in memory up to n bytes, and in a temporary file of dir beyond.
+func WithWriteSpool(dir string, n int) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.spoolDir = dir
+		w.spoolBytes = n
+	}
+}
+
 //func WithParserOptions(opts ...models.ParserOption) WriteHandlerOption {
 //	return func(w *WriteHandler) {
 //		w.parserOptions = opts
@@ -160,6 +186,11 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 	}
 	span.LogKV("org_id", org.ID)
 
+	var rec *recordingResponseWriter
+	if h.deduplicator != nil && req.IdempotencyKey != "" {
+		rec = &recordingResponseWriter{ResponseWriter: w}
+		w = rec
+	}
 	sw := kithttp.NewStatusResponseWriter(w)
 	recorder := NewWriteUsageRecorder(sw, h.EventRecorder)
 	var requestBytes int
@@ -180,18 +211,48 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var pointsWriter storage.PointsWriter = h.PointsWriter
+	if rec != nil {
+		entry, owner, err := h.deduplicator.begin(ctx, bucket.ID, req.IdempotencyKey)
+		if err != nil {
+			h.HandleHTTPError(ctx, err, sw)
+			return
+		}
+		body := &digestReadCloser{rc: req.Body, digest: newWriteDigest(req)}
+		if !owner {
+			// A body that can't be read does not match the one of the
+			// original write, which was read in full.
+			res, err := h.deduplicator.replay(entry, body.sum())
+			if err != nil {
+				h.HandleHTTPError(ctx, err, sw)
+				return
+			}
+			span.LogKV("idempotency_key", req.IdempotencyKey, "replayed", true)
+			res.replay(sw)
+			return
+		}
+		req.Body = body
+		apw := &attemptedPointsWriter{PointsWriter: h.PointsWriter}
+		pointsWriter = apw
+		defer func() {
+			h.deduplicator.finish(entry, rec.result(), body.sum(), apw.attempted)
+		}()
+	}
+
 	// TODO: Backport?
 	//opts := append([]models.ParserOption{}, h.parserOptions...)
 	//opts = append(opts, models.WithParserPrecision(req.Precision))
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, org.ID, bucket.ID, req.Body)
-	if err != nil {
-		h.HandleHTTPError(ctx, err, sw)
-		return
+	parser := points.NewParser(req.Precision)
+	parser.Format = req.Format
+	parser.SpoolDir, parser.SpoolBytes = h.spoolDir, h.spoolBytes
+	var rejected []points.RejectedLine
+	if req.Partial {
+		requestBytes, rejected, err = parser.WritePartial(ctx, org.ID, bucket.ID, req.Body, pointsWriter)
+	} else {
+		requestBytes, err = parser.Write(ctx, org.ID, bucket.ID, req.Body, pointsWriter)
 	}
-	requestBytes = parsed.RawSize
-
-	if err := h.PointsWriter.WritePoints(ctx, org.ID, bucket.ID, parsed.Points); err != nil {
-		if partialErr, ok := err.(tsdb.PartialWriteError); ok {
+	if werr, ok := err.(*points.WriteError); ok {
+		if partialErr, ok := werr.Err.(tsdb.PartialWriteError); ok {
 			h.HandleHTTPError(ctx, &errors.Error{
 				Code: errors.EUnprocessableEntity,
 				Op:   opWriteHandler,
@@ -205,10 +266,20 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 			Code: errors.EInternal,
 			Op:   opWriteHandler,
 			Msg:  "unexpected error writing points to database",
-			Err:  err,
+			Err:  werr.Err,
 		}, sw)
 		return
 	}
+	if err != nil {
+		h.HandleHTTPError(ctx, err, sw)
+		return
+	}
+	if len(rejected) > 0 {
+		if err := encodeResponse(ctx, sw, http.StatusUnprocessableEntity, points.NewPartialWriteResponse(rejected)); err != nil {
+			logEncodingError(h.log, r, err)
+		}
+		return
+	}
 
 	sw.WriteHeader(http.StatusNoContent)
 }
@@ -242,7 +313,13 @@ type writeRequest struct {
 	Org       string
 	Bucket    string
 	Precision string
-	Body      io.ReadCloser
+	// Format is the format of the body, derived from its content type.
+	Format string
+	// Partial writes the valid lines and reports the rejected ones.
+	Partial bool
+	// IdempotencyKey identifies the batch across retries.
+	IdempotencyKey string
+	Body           io.ReadCloser
 }
 
 // decodeWriteRequest extracts information from an http.Request object to
@@ -262,6 +339,11 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 		}
 	}
 
+	partial, err := parsePartial(qp.Get("partial"))
+	if err != nil {
+		return nil, err
+	}
+
 	bucket := qp.Get("bucket")
 	if bucket == "" {
 		return nil, &errors.Error{
@@ -271,6 +353,16 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 		}
 	}
 
+	idempotencyKey := r.Header.Get(headerIdempotencyKey)
+	if err := validIdempotencyKey(idempotencyKey); err != nil {
+		return nil, err
+	}
+
+	format := points.FormatLineProtocol
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mt == "application/json" {
+		format = points.FormatJSON
+	}
+
 	encoding := r.Header.Get("Content-Encoding")
 	body, err := points.BatchReadCloser(r.Body, encoding, maxBatchSizeBytes)
 	if err != nil {
@@ -278,23 +370,58 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 	}
 
 	return &writeRequest{
-		Bucket:    qp.Get("bucket"),
-		Org:       qp.Get("org"),
-		Precision: precision,
-		Body:      body,
+		Bucket:         qp.Get("bucket"),
+		Org:            qp.Get("org"),
+		Precision:      precision,
+		Format:         format,
+		Partial:        partial,
+		IdempotencyKey: idempotencyKey,
+		Body:           body,
 	}, nil
 }
 
+// parsePartial parses the partial query parameter of a write request.
+func parsePartial(v string) (bool, error) {
+	if v == "" {
+		return false, nil
+	}
+	partial, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &errors.Error{
+			Code: errors.EInvalid,
+			Op:   "http/newWriteRequest",
+			Msg:  "invalid partial parameter; must be true or false",
+			Err:  err,
+		}
+	}
+	return partial, nil
+}
+
 // WriteService sends data over HTTP to influxdb via line protocol.
 type WriteService struct {
 	Addr               string
 	Token              string
 	Precision          string
 	InsecureSkipVerify bool
+	// Partial requests partial writes: the valid lines are written and the
+	// rejected ones are returned as a *RejectedLinesError.
+	Partial bool
+	// Compression is the content encoding of the request bodies, either
+	// gzip, zstd or none. Defaults to gzip.
+	Compression string
 }
 
 var _ influxdb.WriteService = (*WriteService)(nil)
 
+// RejectedLinesError is returned by a partial write that rejected lines.
+type RejectedLinesError struct {
+	Lines []points.RejectedLine
+}
+
+func (e *RejectedLinesError) Error() string {
+	return fmt.Sprintf("partial write: %d lines rejected", len(e.Lines))
+}
+
 func compressWithGzip(data io.Reader) (io.Reader, error) {
 	pr, pw := io.Pipe()
 	gw := gzip.NewWriter(pw)
@@ -309,6 +436,46 @@ func compressWithGzip(data io.Reader) (io.Reader, error) {
 	return pr, err
 }
 
+func compressWithZstd(data io.Reader) (io.Reader, error) {
+	pr, pw := io.Pipe()
+	zw, err := zstd.NewWriter(pw, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		_, err := io.Copy(zw, data)
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr, nil
+}
+
+// compressBody compresses the body of a write request and returns the
+// content encoding of the compressed body, empty if it isn't compressed.
+func compressBody(data io.Reader, compression string) (io.Reader, string, error) {
+	var err error
+	switch compression {
+	case "", "gzip":
+		data, err = compressWithGzip(data)
+		return data, "gzip", err
+	case "zstd":
+		data, err = compressWithZstd(data)
+		return data, "zstd", err
+	case "none":
+		return data, "", nil
+	default:
+		return nil, "", &errors.Error{
+			Code: errors.EInvalid,
+			Op:   "http/Write",
+			Msg:  fmt.Sprintf("unsupported compression %q; must be gzip, zstd or none", compression),
+		}
+	}
+}
+
 // WriteTo writes to the bucket matching the filter.
 func (s *WriteService) WriteTo(ctx context.Context, filter influxdb.BucketFilter, r io.Reader) error {
 	precision := s.Precision
@@ -329,7 +496,7 @@ func (s *WriteService) WriteTo(ctx context.Context, filter influxdb.BucketFilter
 		return err
 	}
 
-	r, err = compressWithGzip(r)
+	r, encoding, err := compressBody(r, s.Compression)
 	if err != nil {
 		return err
 	}
@@ -340,7 +507,9 @@ func (s *WriteService) WriteTo(ctx context.Context, filter influxdb.BucketFilter
 	}
 
 	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
-	req.Header.Set("Content-Encoding", "gzip")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
 	SetToken(s.Token, req)
 
 	params := req.URL.Query()
@@ -359,6 +528,9 @@ func (s *WriteService) WriteTo(ctx context.Context, filter influxdb.BucketFilter
 		params.Set("bucket", *filter.Name)
 	}
 	params.Set("precision", precision)
+	if s.Partial {
+		params.Set("partial", "true")
+	}
 	req.URL.RawQuery = params.Encode()
 
 	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
@@ -369,5 +541,17 @@ func (s *WriteService) WriteTo(ctx context.Context, filter influxdb.BucketFilter
 	}
 	defer resp.Body.Close()
 
+	if s.Partial && resp.StatusCode == http.StatusUnprocessableEntity {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		var res points.PartialWriteResponse
+		if err := json.Unmarshal(body, &res); err == nil && len(res.Rejected) > 0 {
+			return &RejectedLinesError{Lines: res.Rejected}
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
 	return CheckError(resp)
 }
@@ -17,10 +17,12 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/http/points"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
@@ -143,6 +145,45 @@ func TestWriteService_WriteTo(t *testing.T) {
 	}
 }
 
+func TestWriteService_WriteToCompression(t *testing.T) {
+	for _, tt := range []struct {
+		compression string
+		encoding    string
+	}{
+		{compression: "", encoding: "gzip"},
+		{compression: "gzip", encoding: "gzip"},
+		{compression: "zstd", encoding: "zstd"},
+		{compression: "none", encoding: ""},
+	} {
+		t.Run(tt.compression, func(t *testing.T) {
+			var (
+				encoding string
+				lp       []byte
+			)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding = r.Header.Get("Content-Encoding")
+				body, err := points.BatchReadCloser(r.Body, encoding, 0)
+				require.NoError(t, err)
+				defer body.Close()
+				lp, _ = ioutil.ReadAll(body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer ts.Close()
+
+			bucketID := platform.ID(2)
+			s := &WriteService{Addr: ts.URL, Compression: tt.compression}
+			err := s.WriteTo(context.Background(), influxdb.BucketFilter{ID: &bucketID}, strings.NewReader("m,t1=v1 f1=2"))
+			require.NoError(t, err)
+			require.Equal(t, tt.encoding, encoding)
+			require.Equal(t, "m,t1=v1 f1=2", string(lp))
+		})
+	}
+
+	s := &WriteService{Addr: "http://localhost:8086", Compression: "br"}
+	err := s.WriteTo(context.Background(), influxdb.BucketFilter{}, strings.NewReader("m f=1"))
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+}
+
 func TestWriteHandler_handleWrite(t *testing.T) {
 	// state is the internal state of org and bucket services
 	type state struct {
@@ -162,10 +203,12 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 
 	// request is sent to the HTTP endpoint
 	type request struct {
-		auth   influxdb.Authorizer
-		org    string
-		bucket string
-		body   string
+		auth        influxdb.Authorizer
+		org         string
+		bucket      string
+		body        string
+		contentType string
+		partial     bool
 	}
 
 	tests := []struct {
@@ -208,6 +251,41 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 				body: `{"code":"unprocessable entity","message":"failure writing points to database: partial write: bad points dropped=1"}`,
 			},
 		},
+		{
+			name: "partial write reports rejected lines",
+			request: request{
+				org:     "043e0780ee2b1000",
+				bucket:  "04504b356e23b000",
+				body:    "m1,t1=v1 f1=1\nm1 f1=\nm1,t1=v2 f1=2",
+				partial: true,
+				auth:    bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 422,
+				body: `{"code":"unprocessable entity","message":"partial write: 1 lines rejected","rejected":[{"line":2,"reason":"parse error","message":"unable to parse 'm1 f1=': missing field value","snippet":"m1 f1="}]}` + "\n",
+			},
+		},
+		{
+			name: "partial write of valid lines is accepted",
+			request: request{
+				org:     "043e0780ee2b1000",
+				bucket:  "04504b356e23b000",
+				body:    "m1,t1=v1 f1=1\nm1,t1=v2 f1=2",
+				partial: true,
+				auth:    bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 204,
+			},
+		},
 		{
 			name: "points writer error is an internal error",
 			request: request{
@@ -325,6 +403,77 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 				body: `{"code":"invalid","message":"unable to parse 'invalid': missing fields"}`,
 			},
 		},
+		{
+			name: "JSON body is accepted",
+			request: request{
+				org:         "043e0780ee2b1000",
+				bucket:      "04504b356e23b000",
+				auth:        bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+				body:        `[{"measurement":"m1","tags":{"t1":"v1"},"fields":{"f1":1.5,"f2":2},"timestamp":1}]`,
+				contentType: "application/json",
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 204,
+			},
+		},
+		{
+			name: "invalid JSON points returns 400",
+			request: request{
+				org:         "043e0780ee2b1000",
+				bucket:      "04504b356e23b000",
+				auth:        bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+				body:        `[{"measurement":"m1","fields":{"f1":null}}]`,
+				contentType: "application/json",
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"invalid JSON points: point 1: field \"f1\": null is not a valid field value"}`,
+			},
+		},
+		{
+			name: "empty JSON array returns 400",
+			request: request{
+				org:         "043e0780ee2b1000",
+				bucket:      "04504b356e23b000",
+				auth:        bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+				body:        `[]`,
+				contentType: "application/json",
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"writing requires points"}`,
+			},
+		},
+		{
+			name: "JSON timestamp out of range returns 400",
+			request: request{
+				org:         "043e0780ee2b1000",
+				bucket:      "04504b356e23b000",
+				auth:        bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+				body:        `[{"measurement":"m1","fields":{"f1":1.5},"timestamp":9223372036854775807}]`,
+				contentType: "application/json",
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"invalid JSON points: point 1: timestamp: time outside range -9223372036854775806 - 9223372036854775806"}`,
+			},
+		},
 		{
 			name: "forbidden to write with insufficient permission",
 			request: request{
@@ -404,10 +553,16 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 				"http://localhost:8086/api/v2/write",
 				strings.NewReader(tt.request.body),
 			)
+			if tt.request.contentType != "" {
+				r.Header.Set("Content-Type", tt.request.contentType)
+			}
 
 			params := r.URL.Query()
 			params.Set("org", tt.request.org)
 			params.Set("bucket", tt.request.bucket)
+			if tt.request.partial {
+				params.Set("partial", "true")
+			}
 			r.URL.RawQuery = params.Encode()
 
 			w := httptest.NewRecorder()
@@ -423,6 +578,52 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 	}
 }
 
+// TestWriteHandler_handleWrite_NothingWritten checks that a batch of several
+// chunks writes nothing when it is rejected as a whole.
+func TestWriteHandler_handleWrite_NothingWritten(t *testing.T) {
+	valid := strings.Repeat("m1,t1=v1 f1=1 1\n", 3*points.DefaultChunkBytes/16)
+
+	for _, tt := range []struct {
+		name string
+		body string
+		opts []WriteHandlerOption
+		code int
+	}{
+		{name: "invalid last line", body: valid + "m1,t1=v1 f1=\n", code: http.StatusBadRequest},
+		{name: "too large", body: valid, opts: []WriteHandlerOption{WithMaxBatchSizeBytes(int64(len(valid) - 1))}, code: http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg("043e0780ee2b1000"), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket("043e0780ee2b1000", "04504b356e23b000"), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				Logger:              zaptest.NewLogger(t),
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), tt.opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"))
+
+			r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+			assert.Equal(t, 0, pw.WritePointsCalled(), "no chunk of a rejected batch is written")
+		})
+	}
+}
+
 var DefaultErrorHandler = kithttp.ErrorHandler(0)
 
 func bucketWritePermission(org, bucket string) *influxdb.Authorization {
@@ -0,0 +1,356 @@
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// headerIdempotencyKey identifies a write batch across retries.
+	headerIdempotencyKey = "Idempotency-Key"
+	// headerIdempotentReplayed is set on the responses of deduplicated writes.
+	headerIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// WriteDeduplicator remembers the results of the writes sent with an
+// Idempotency-Key header for a window of time, so that a retried batch is
+// answered with the result of the original write instead of being written
+// again. Keys are scoped to the bucket written to.
+//
+// A key reused for a different batch, or for a batch whose original write
+// failed after some of its points were written, is rejected with a conflict.
+// At most maxKeys results are remembered, the least recently used ones are
+// forgotten first.
+type WriteDeduplicator struct {
+	window  time.Duration
+	maxKeys int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[idempotencyKey]*idempotencyEntry
+	// expiry holds the remembered entries in the order they expire, and lru
+	// in the order they were last used.
+	expiry *list.List
+	lru    *list.List
+
+	deduplicated *prometheus.CounterVec
+	keys         prometheus.GaugeFunc
+}
+
+type idempotencyKey struct {
+	bucketID platform.ID
+	key      string
+}
+
+type idempotencyEntry struct {
+	key     idempotencyKey
+	expires time.Time
+	// done is closed once the result of the original write is known.
+	done chan struct{}
+
+	result *writeResult
+	// digest is the hash of the parameters and body of the original write.
+	digest []byte
+	// inDoubt is set when the original write failed after some of its
+	// points were written.
+	inDoubt bool
+
+	expiryElem *list.Element
+	lruElem    *list.Element
+}
+
+// writeResult is the response of a write, replayed for its duplicates.
+type writeResult struct {
+	code        int
+	contentType string
+	body        []byte
+}
+
+// NewWriteDeduplicator returns a WriteDeduplicator remembering the results
+// of writes for window, and the results of at most maxKeys writes. There is no
+// limit on the number of keys when maxKeys is 0.
+func NewWriteDeduplicator(window time.Duration, maxKeys int) *WriteDeduplicator {
+	d := &WriteDeduplicator{
+		window:  window,
+		maxKeys: maxKeys,
+		now:     time.Now,
+		entries: make(map[idempotencyKey]*idempotencyEntry),
+		expiry:  list.New(),
+		lru:     list.New(),
+		deduplicated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "http",
+			Subsystem: "write",
+			Name:      "deduplicated_batches_total",
+			Help:      "Number of write batches answered with the result of a previous write with the same idempotency key",
+		}, []string{"bucket"}),
+	}
+	d.keys = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "http",
+		Subsystem: "write",
+		Name:      "idempotency_keys",
+		Help:      "Number of idempotency keys remembered",
+	}, func() float64 {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return float64(len(d.entries))
+	})
+	return d
+}
+
+// PrometheusCollectors returns the metrics of the deduplicator.
+func (d *WriteDeduplicator) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{d.deduplicated, d.keys}
+}
+
+// begin returns the entry of the write of key to the bucket. When owner is
+// true, the caller must write the batch and complete the entry with finish.
+// Otherwise the entry is that of a previous write, whose result is known and
+// is checked with replay. Duplicates of a write in progress wait for it.
+func (d *WriteDeduplicator) begin(ctx context.Context, bucketID platform.ID, key string) (e *idempotencyEntry, owner bool, err error) {
+	k := idempotencyKey{bucketID: bucketID, key: key}
+	for {
+		d.mu.Lock()
+		d.expire()
+		e, ok := d.entries[k]
+		if !ok {
+			d.evict()
+			e = &idempotencyEntry{key: k, done: make(chan struct{})}
+			d.entries[k] = e
+			d.mu.Unlock()
+			return e, true, nil
+		}
+		d.mu.Unlock()
+
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+		if e.result != nil || e.inDoubt {
+			return e, false, nil
+		}
+		// The original write failed and was forgotten: try again.
+	}
+}
+
+// replay returns the result of the write of e for a duplicate whose hash is
+// digest. Duplicates of another batch, or of a write that is in doubt, are
+// rejected.
+func (d *WriteDeduplicator) replay(e *idempotencyEntry, digest []byte) (*writeResult, error) {
+	if e.inDoubt {
+		return nil, &errors.Error{
+			Code: errors.EConflict,
+			Op:   opWriteHandler,
+			Msg:  "the write with this Idempotency-Key failed after some of its points were written; retry it with a new key",
+		}
+	}
+	if !bytes.Equal(e.digest, digest) {
+		return nil, &errors.Error{
+			Code: errors.EConflict,
+			Op:   opWriteHandler,
+			Msg:  "Idempotency-Key was already used for a different batch",
+		}
+	}
+
+	d.mu.Lock()
+	if e.lruElem != nil {
+		d.lru.MoveToBack(e.lruElem)
+	}
+	d.mu.Unlock()
+	d.deduplicated.WithLabelValues(e.key.bucketID.String()).Inc()
+	return e.result, nil
+}
+
+// finish records the result of the write of an entry, and the hash of its
+// batch. A write that failed with a server error is forgotten so that it can
+// be retried, unless some of its points were written, in which case it is
+// remembered as in doubt. A write whose batch could not be hashed is
+// forgotten as well.
+func (d *WriteDeduplicator) finish(e *idempotencyEntry, res *writeResult, digest []byte, written bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case (res == nil || res.code >= http.StatusInternalServerError) && written:
+		e.inDoubt = true
+		d.remember(e)
+	case res == nil || res.code >= http.StatusInternalServerError || digest == nil:
+		delete(d.entries, e.key)
+	default:
+		e.result, e.digest = res, digest
+		d.remember(e)
+	}
+	close(e.done)
+}
+
+// remember keeps e until it expires or is evicted. d.mu must be held.
+func (d *WriteDeduplicator) remember(e *idempotencyEntry) {
+	e.expires = d.now().Add(d.window)
+	e.expiryElem = d.expiry.PushBack(e)
+	e.lruElem = d.lru.PushBack(e)
+}
+
+// forget removes a remembered entry. d.mu must be held.
+func (d *WriteDeduplicator) forget(e *idempotencyEntry) {
+	d.expiry.Remove(e.expiryElem)
+	d.lru.Remove(e.lruElem)
+	delete(d.entries, e.key)
+}
+
+// expire forgets the results older than the window. d.mu must be held.
+func (d *WriteDeduplicator) expire() {
+	now := d.now()
+	for elem := d.expiry.Front(); elem != nil; elem = d.expiry.Front() {
+		e := elem.Value.(*idempotencyEntry)
+		if now.Before(e.expires) {
+			return
+		}
+		d.forget(e)
+	}
+}
+
+// evict forgets the least recently used results to make room for a new key.
+// Writes in progress are never evicted, so their number may exceed maxKeys.
+// d.mu must be held.
+func (d *WriteDeduplicator) evict() {
+	if d.maxKeys <= 0 {
+		return
+	}
+	for len(d.entries) >= d.maxKeys && d.lru.Len() > 0 {
+		d.forget(d.lru.Front().Value.(*idempotencyEntry))
+	}
+}
+
+// newWriteDigest returns the hash of the parameters of a write request that
+// change what it writes. Its decoded body must be added to it.
+func newWriteDigest(req *writeRequest) hash.Hash {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%t\n", req.Precision, req.Format, req.Partial)
+	return h
+}
+
+// digestReadCloser adds the bytes read from a request body to its digest.
+// Once closed, the digest holds the whole body, even if the reader stopped
+// reading early.
+type digestReadCloser struct {
+	rc     io.ReadCloser
+	digest hash.Hash
+	err    error
+
+	closed   bool
+	closeErr error
+}
+
+func (r *digestReadCloser) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	r.digest.Write(p[:n])
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// Close reads the rest of the body into the digest before closing it.
+func (r *digestReadCloser) Close() error {
+	if r.closed {
+		return r.closeErr
+	}
+	r.closed = true
+	if r.err == nil {
+		if _, err := io.Copy(r.digest, r.rc); err != nil {
+			r.err = err
+		}
+	}
+	r.closeErr = r.rc.Close()
+	return r.closeErr
+}
+
+// sum returns the digest of the whole body, or nil if it could not be read,
+// for instance because it exceeds the size limit of a batch.
+func (r *digestReadCloser) sum() []byte {
+	if err := r.Close(); err != nil || r.err != nil {
+		return nil
+	}
+	return r.digest.Sum(nil)
+}
+
+// attemptedPointsWriter records whether points were passed to a writer.
+type attemptedPointsWriter struct {
+	storage.PointsWriter
+	attempted bool
+}
+
+func (w *attemptedPointsWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	w.attempted = true
+	return w.PointsWriter.WritePoints(ctx, orgID, bucketID, points)
+}
+
+// validIdempotencyKey returns an error if key can't identify a write.
+func validIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Op:   "http/newWriteRequest",
+			Msg:  "Idempotency-Key header must be at most 255 characters",
+		}
+	}
+	return nil
+}
+
+// recordingResponseWriter keeps a copy of the response written, so that it
+// can be replayed.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingResponseWriter) result() *writeResult {
+	if w.code == 0 {
+		return nil
+	}
+	return &writeResult{
+		code:        w.code,
+		contentType: w.Header().Get("Content-Type"),
+		body:        w.body.Bytes(),
+	}
+}
+
+func (res *writeResult) replay(w http.ResponseWriter) {
+	if res.contentType != "" {
+		w.Header().Set("Content-Type", res.contentType)
+	}
+	w.Header().Set(headerIdempotentReplayed, "true")
+	w.WriteHeader(res.code)
+	_, _ = w.Write(res.body)
+}
@@ -0,0 +1,272 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_IdempotencyKey(t *testing.T) {
+	const (
+		org     = "043e0780ee2b1000"
+		bucket1 = "04504b356e23b000"
+		bucket2 = "04504b356e23b001"
+	)
+
+	now := time.Unix(1600000000, 0)
+	dedup := NewWriteDeduplicator(time.Minute, 0)
+	dedup.now = func() time.Time { return now }
+
+	var (
+		writes   int
+		writeErr error
+	)
+	pointsWriter := &mock.PointsWriter{
+		WritePointsFn: func(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+			writes++
+			return writeErr
+		},
+	}
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(org), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(org, filter.ID.String()), nil
+	}
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		Logger:              zaptest.NewLogger(t),
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        pointsWriter,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithWriteDeduplicator(dedup))
+
+	write := func(bucket, key, body string, params ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		auth := &influxdb.Authorization{
+			OrgID:  influxtesting.MustIDBase16(org),
+			Status: influxdb.Active,
+			Permissions: append(
+				bucketWritePermission(org, bucket1).Permissions,
+				bucketWritePermission(org, bucket2).Permissions...,
+			),
+		}
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:8086/api/v2/write", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		qp := r.URL.Query()
+		qp.Set("org", org)
+		qp.Set("bucket", bucket)
+		for i := 0; i+1 < len(params); i += 2 {
+			qp.Set(params[i], params[i+1])
+		}
+		r.URL.RawQuery = qp.Encode()
+
+		w := httptest.NewRecorder()
+		httpmock.NewAuthMiddlewareHandler(writeHandler, auth).ServeHTTP(w, r)
+		return w
+	}
+
+	if w := write(bucket1, "batch-1", "m1 f1=1"); w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	w := write(bucket1, "batch-1", "m1 f1=1")
+	if w.Code != http.StatusNoContent || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected the write to be replayed, got %d %v", w.Code, w.Header())
+	}
+	if writes != 1 {
+		t.Fatalf("expected the batch to be written once, got %d writes", writes)
+	}
+	if got := testutil.ToFloat64(dedup.deduplicated); got != 1 {
+		t.Fatalf("unexpected deduplicated batches %v", got)
+	}
+
+	// Keys are scoped to the bucket, and writes without keys are not deduplicated.
+	write(bucket2, "batch-1", "m1 f1=1")
+	write(bucket1, "", "m1 f1=1")
+	write(bucket1, "", "m1 f1=1")
+	if writes != 4 {
+		t.Fatalf("unexpected number of writes %d", writes)
+	}
+
+	// Client errors are replayed.
+	if w := write(bucket1, "batch-2", "invalid"); w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	w = write(bucket1, "batch-2", "invalid")
+	if w.Code != http.StatusBadRequest || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected the error to be replayed, got %d %v", w.Code, w.Header())
+	}
+	if !strings.Contains(w.Body.String(), "missing fields") {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+
+	// A key reused for another batch, or other parameters, is a conflict.
+	for _, w := range []*httptest.ResponseRecorder{
+		write(bucket1, "batch-1", "m1 f1=2"),
+		write(bucket1, "batch-1", "m1 f1=1", "precision", "s"),
+		write(bucket1, "batch-2", "invalid", "partial", "true"),
+	} {
+		if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "already used for a different batch") {
+			t.Fatalf("expected a conflict, got %d %s", w.Code, w.Body.String())
+		}
+	}
+	if writes != 4 {
+		t.Fatalf("unexpected number of writes %d", writes)
+	}
+
+	// Server errors once points were written leave the batch in doubt.
+	writeErr = errors.New("engine unavailable")
+	if w := write(bucket1, "batch-3", "m1 f1=1"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	writeErr = nil
+	if w := write(bucket1, "batch-3", "m1 f1=1"); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "retry it with a new key") {
+		t.Fatalf("expected the batch to be in doubt, got %d %s", w.Code, w.Body.String())
+	}
+	if writes != 5 {
+		t.Fatalf("unexpected number of writes %d", writes)
+	}
+
+	// Keys are forgotten after the window.
+	now = now.Add(time.Minute)
+	write(bucket1, "batch-1", "m1 f1=1")
+	if writes != 6 {
+		t.Fatalf("expected the key to expire, got %d writes", writes)
+	}
+
+	if w := write(bucket1, strings.Repeat("k", 256), "m1 f1=1"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected a long key to be rejected, got %d", w.Code)
+	}
+}
+
+func TestWriteDeduplicator_ConcurrentDuplicates(t *testing.T) {
+	dedup := NewWriteDeduplicator(time.Minute, 0)
+	ctx := context.Background()
+	digest := []byte("digest")
+
+	entry, owner, err := dedup.begin(ctx, 1, "batch")
+	if err != nil || !owner {
+		t.Fatalf("expected to own the write, got %v %v", owner, err)
+	}
+
+	replayed := make(chan *writeResult)
+	go func() {
+		e, _, _ := dedup.begin(ctx, 1, "batch")
+		res, _ := dedup.replay(e, digest)
+		replayed <- res
+	}()
+	select {
+	case <-replayed:
+		t.Fatal("the duplicate must wait for the original write")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	dedup.finish(entry, &writeResult{code: http.StatusNoContent}, digest, true)
+	if res := <-replayed; res == nil || res.code != http.StatusNoContent {
+		t.Fatalf("unexpected replayed result %+v", res)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	entry, _, _ = dedup.begin(ctx, 1, "other")
+	defer dedup.finish(entry, nil, nil, false)
+	if _, _, err := dedup.begin(cctx, 1, "other"); err != context.Canceled {
+		t.Fatalf("expected the wait to be canceled, got %v", err)
+	}
+}
+
+func TestWriteDeduplicator_Forget(t *testing.T) {
+	dedup := NewWriteDeduplicator(time.Minute, 0)
+	ctx := context.Background()
+
+	// Server errors before any point was written, and batches that could
+	// not be read, are forgotten.
+	for _, tt := range []struct {
+		res    *writeResult
+		digest []byte
+	}{
+		{res: &writeResult{code: http.StatusServiceUnavailable}, digest: []byte("digest")},
+		{res: nil, digest: []byte("digest")},
+		{res: &writeResult{code: http.StatusRequestEntityTooLarge}, digest: nil},
+	} {
+		entry, owner, _ := dedup.begin(ctx, 1, "batch")
+		if !owner {
+			t.Fatalf("expected the key to be forgotten after %+v", tt)
+		}
+		dedup.finish(entry, tt.res, tt.digest, false)
+	}
+	if _, owner, _ := dedup.begin(ctx, 1, "batch"); !owner {
+		t.Fatal("expected the key to be forgotten")
+	}
+}
+
+func TestWriteDeduplicator_MaxKeys(t *testing.T) {
+	dedup := NewWriteDeduplicator(time.Minute, 2)
+	ctx := context.Background()
+	digest := []byte("digest")
+
+	write := func(key string) bool {
+		t.Helper()
+		e, owner, err := dedup.begin(ctx, 1, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if owner {
+			dedup.finish(e, &writeResult{code: http.StatusNoContent}, digest, true)
+		} else if _, err := dedup.replay(e, digest); err != nil {
+			t.Fatal(err)
+		}
+		return owner
+	}
+
+	write("a")
+	write("b")
+	// Replaying a makes b the least recently used key.
+	if write("a") {
+		t.Fatal("expected a to be replayed")
+	}
+	write("c")
+	if got := testutil.ToFloat64(dedup.keys); got != 2 {
+		t.Fatalf("unexpected number of keys %v", got)
+	}
+	if write("a") {
+		t.Fatal("expected a to be remembered")
+	}
+	if !write("b") {
+		t.Fatal("expected b to be evicted")
+	}
+
+	// Writes in progress are not evicted.
+	d, _, _ := dedup.begin(ctx, 1, "d")
+	e, _, _ := dedup.begin(ctx, 1, "e")
+	f, owner, _ := dedup.begin(ctx, 1, "f")
+	if !owner || len(dedup.entries) != 3 {
+		t.Fatalf("unexpected keys %v", dedup.entries)
+	}
+	for _, entry := range []*idempotencyEntry{d, e, f} {
+		dedup.finish(entry, &writeResult{code: http.StatusNoContent}, digest, true)
+	}
+	if write("d") {
+		t.Fatal("expected d to be remembered")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/influxdata/influxdb/v2/influxql/control"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query/registry"
 	"github.com/influxdata/influxql"
 	"github.com/opentracing/opentracing-go/log"
 	"go.uber.org/zap"
@@ -173,6 +174,10 @@ type Executor struct {
 
 	Metrics *control.ControllerMetrics
 
+	// Registry, when set, is where running queries are registered
+	// so they can be listed and killed.
+	Registry *registry.Registry
+
 	log *zap.Logger
 }
 
@@ -194,7 +199,23 @@ func (e *Executor) Close() error {
 func (e *Executor) ExecuteQuery(ctx context.Context, query *influxql.Query, opt ExecutionOptions) (<-chan *Result, *iql.Statistics) {
 	results := make(chan *Result)
 	statistics := new(iql.Statistics)
-	go e.executeQuery(ctx, query, opt, results, statistics)
+	if e.Registry == nil {
+		go e.executeQuery(ctx, query, opt, results, statistics)
+		return results, statistics
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	_, unregister := e.Registry.Register(registry.Query{
+		OrgID:    opt.OrgID,
+		Language: registry.LanguageInfluxQL,
+		Query:    query.String(),
+		Database: opt.Database,
+	}, cancel, nil)
+	go func() {
+		defer cancel()
+		defer unregister()
+		e.executeQuery(ctx, query, opt, results, statistics)
+	}()
 	return results, statistics
 }
 
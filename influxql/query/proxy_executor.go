@@ -63,6 +63,7 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 	rw := NewResponseWriter(req.EncodingFormat)
 
 	results, stats := s.executor.ExecuteQuery(ctx, q, opts)
+	var rows int
 	if req.Chunked {
 		for r := range results {
 			// Ignore nil results.
@@ -75,6 +76,7 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 				convertToEpoch(r, epoch)
 			}
 
+			rows += countRows(r)
 			err = rw.WriteResponse(ctx, w, Response{Results: []*Result{r}})
 			if err != nil {
 				break
@@ -82,10 +84,24 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 		}
 	} else {
 		resp := Response{Results: GatherResults(results, epoch)}
+		for _, r := range resp.Results {
+			rows += countRows(r)
+		}
 		err = rw.WriteResponse(ctx, w, resp)
 	}
 
-	return *stats, err
+	res := *stats
+	res.ReturnedRows = rows
+	return res, err
+}
+
+// countRows returns the number of rows within all series of the result.
+func countRows(r *Result) int {
+	var n int
+	for _, row := range r.Series {
+		n += len(row.Values)
+	}
+	return n
 }
 
 // GatherResults consumes the results from the given channel and organizes them correctly.
@@ -15,6 +15,7 @@ type Statistics struct {
 	StatementCount  int           `json:"statement_count"`  // StatementCount is the number of InfluxQL statements executed
 	ScannedValues   int           `json:"scanned_values"`   // ScannedValues is the number of values scanned from storage
 	ScannedBytes    int           `json:"scanned_bytes"`    // ScannedBytes is the number of bytes scanned from storage
+	ReturnedRows    int           `json:"returned_rows"`    // ReturnedRows is the number of rows returned to the client
 }
 
 // Adding returns the sum of s and other.
@@ -25,6 +26,7 @@ func (s Statistics) Adding(other Statistics) Statistics {
 		StatementCount:  s.StatementCount + other.StatementCount,
 		ScannedValues:   s.ScannedValues + other.ScannedValues,
 		ScannedBytes:    s.ScannedBytes + other.ScannedBytes,
+		ReturnedRows:    s.ReturnedRows + other.ReturnedRows,
 	}
 }
 
@@ -35,6 +37,7 @@ func (s *Statistics) Add(other Statistics) {
 	s.StatementCount += other.StatementCount
 	s.ScannedValues += other.ScannedValues
 	s.ScannedBytes += other.ScannedBytes
+	s.ReturnedRows += other.ReturnedRows
 }
 
 func (s *Statistics) LogToSpan(span opentracing.Span) {
@@ -47,6 +50,7 @@ func (s *Statistics) LogToSpan(span opentracing.Span) {
 		log.Int("stats_statement_count", s.StatementCount),
 		log.Int("stats_scanned_values", s.ScannedValues),
 		log.Int("stats_scanned_bytes", s.ScannedBytes),
+		log.Int("stats_returned_rows", s.ReturnedRows),
 	)
 }
 
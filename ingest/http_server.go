@@ -0,0 +1,168 @@
+package ingest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixIngestRules = "/api/v2/ingest-rules"
+
+// Handler serves the ingest rules API, where the rules of a bucket are
+// addressed by the bucket ID.
+type Handler struct {
+	chi.Router
+
+	api       *kithttp.API
+	log       *zap.Logger
+	rulesSvc  influxdb.IngestRulesService
+	bucketSvc influxdb.BucketService
+}
+
+// NewHTTPHandler constructs a new http server for the ingest rules.
+func NewHTTPHandler(log *zap.Logger, rulesSvc influxdb.IngestRulesService, bucketSvc influxdb.BucketService) *Handler {
+	h := &Handler{
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		log:       log,
+		rulesSvc:  rulesSvc,
+		bucketSvc: bucketSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetAllRules)
+
+		r.Route("/{bucketID}", func(r chi.Router) {
+			r.Get("/", h.handleGetRules)
+			r.Put("/", h.handlePutRules)
+			r.Delete("/", h.handleDeleteRules)
+		})
+	})
+
+	h.Router = r
+	return h
+}
+
+// Prefix provides the prefix to this route tree.
+func (h *Handler) Prefix() string {
+	return prefixIngestRules
+}
+
+type getAllRulesResponse struct {
+	IngestRules []*influxdb.BucketIngestRules `json:"ingestRules"`
+}
+
+type putRulesRequest struct {
+	Rules []influxdb.IngestRule `json:"rules"`
+}
+
+func (h *Handler) handleGetAllRules(w http.ResponseWriter, r *http.Request) {
+	var filter influxdb.IngestRulesFilter
+	q := r.URL.Query()
+	for key, dst := range map[string]**platform.ID{
+		"orgID":    &filter.OrgID,
+		"bucketID": &filter.BucketID,
+	} {
+		if s := q.Get(key); s != "" {
+			id, err := platform.IDFromString(s)
+			if err != nil {
+				h.api.Err(w, r, &errors.Error{
+					Code: errors.EInvalid,
+					Msg:  "invalid " + key,
+					Err:  err,
+				})
+				return
+			}
+			*dst = id
+		}
+	}
+
+	rules, err := h.rulesSvc.FindIngestRules(r.Context(), filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, getAllRulesResponse{IngestRules: rules})
+}
+
+func (h *Handler) handleGetRules(w http.ResponseWriter, r *http.Request) {
+	bucketID, err := decodeBucketID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	rules, err := h.rulesSvc.GetIngestRules(r.Context(), bucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, rules)
+}
+
+func (h *Handler) handlePutRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bucketID, err := decodeBucketID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	var req putRulesRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	bkt, err := h.bucketSvc.FindBucketByID(ctx, bucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	rules := &influxdb.BucketIngestRules{
+		BucketID: bkt.ID,
+		OrgID:    bkt.OrgID,
+		Rules:    req.Rules,
+	}
+	if err := rules.Valid(); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if err := h.rulesSvc.PutIngestRules(ctx, rules); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, rules)
+}
+
+func (h *Handler) handleDeleteRules(w http.ResponseWriter, r *http.Request) {
+	bucketID, err := decodeBucketID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if err := h.rulesSvc.DeleteIngestRules(r.Context(), bucketID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeBucketID(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "bucketID")); err != nil {
+		return 0, ErrInvalidBucketID
+	}
+	return id, nil
+}
@@ -0,0 +1,72 @@
+package ingest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/ingest"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	svc := newTestService(t)
+	h := ingest.NewHTTPHandler(zaptest.NewLogger(t), svc, testBucketService())
+	server := httptest.NewServer(http.StripPrefix(h.Prefix(), h))
+	defer server.Close()
+
+	do := func(method, path string, body interface{}, status int, v interface{}) {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		req, err := http.NewRequest(method, server.URL+h.Prefix()+path, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("%s %s: unexpected status %d, want %d", method, path, resp.StatusCode, status)
+		}
+		if v != nil {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	path := "/" + bucketID.String()
+	do(http.MethodGet, path, nil, http.StatusNotFound, nil)
+
+	req := map[string]interface{}{"rules": []influxdb.IngestRule{renameWatts}}
+	var put influxdb.BucketIngestRules
+	do(http.MethodPut, path, req, http.StatusOK, &put)
+	if put.BucketID != bucketID || put.OrgID != orgID || len(put.Rules) != 1 {
+		t.Fatalf("unexpected rules %+v", put)
+	}
+
+	var list struct {
+		IngestRules []influxdb.BucketIngestRules `json:"ingestRules"`
+	}
+	do(http.MethodGet, "/?orgID="+orgID.String(), nil, http.StatusOK, &list)
+	if len(list.IngestRules) != 1 || list.IngestRules[0].Rules[0] != renameWatts {
+		t.Fatalf("unexpected rules %+v", list.IngestRules)
+	}
+
+	invalid := map[string]interface{}{"rules": []influxdb.IngestRule{{Type: influxdb.IngestRuleAddTag}}}
+	do(http.MethodPut, path, invalid, http.StatusBadRequest, nil)
+	do(http.MethodGet, "/invalid", nil, http.StatusBadRequest, nil)
+	do(http.MethodGet, "/?orgID=invalid", nil, http.StatusBadRequest, nil)
+	do(http.MethodDelete, path, nil, http.StatusNoContent, nil)
+	do(http.MethodGet, path, nil, http.StatusNotFound, nil)
+}
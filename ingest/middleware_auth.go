@@ -0,0 +1,75 @@
+package ingest
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.IngestRulesService = (*AuthorizedService)(nil)
+
+// AuthorizedService authorizes the rules as part of their buckets: reading
+// them requires read access to the bucket, changing them write access.
+type AuthorizedService struct {
+	s influxdb.IngestRulesService
+}
+
+// NewAuthorizedService wraps s with authorization.
+func NewAuthorizedService(s influxdb.IngestRulesService) *AuthorizedService {
+	return &AuthorizedService{s: s}
+}
+
+func (svc *AuthorizedService) FindIngestRules(ctx context.Context, filter influxdb.IngestRulesFilter) ([]*influxdb.BucketIngestRules, error) {
+	rules, err := svc.s.FindIngestRules(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	// This filters without allocating
+	// https://github.com/golang/go/wiki/SliceTricks#filtering-without-allocating
+	authorized := rules[:0]
+	for _, r := range rules {
+		_, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, r.BucketID, r.OrgID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		authorized = append(authorized, r)
+	}
+	return authorized, nil
+}
+
+func (svc *AuthorizedService) GetIngestRules(ctx context.Context, bucketID platform.ID) (*influxdb.BucketIngestRules, error) {
+	r, err := svc.s.GetIngestRules(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, bucketID, r.OrgID); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// PutIngestRules requires write access to the bucket in the organization of
+// the rules, which the underlying service checks the bucket belongs to.
+func (svc *AuthorizedService) PutIngestRules(ctx context.Context, r *influxdb.BucketIngestRules) error {
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, r.BucketID, r.OrgID); err != nil {
+		return err
+	}
+	return svc.s.PutIngestRules(ctx, r)
+}
+
+func (svc *AuthorizedService) DeleteIngestRules(ctx context.Context, bucketID platform.ID) error {
+	r, err := svc.s.GetIngestRules(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, bucketID, r.OrgID); err != nil {
+		return err
+	}
+	return svc.s.DeleteIngestRules(ctx, bucketID)
+}
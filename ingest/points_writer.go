@@ -0,0 +1,152 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// TransformerFinder returns the transformer of the rules of a bucket, or nil
+// if the bucket has none.
+type TransformerFinder interface {
+	Transformer(ctx context.Context, bucketID platform.ID) (*Transformer, error)
+}
+
+// PointsWriter applies the ingest rules of the buckets to the points written
+// to the underlying writer.
+type PointsWriter struct {
+	Underlying storage.PointsWriter
+	Rules      TransformerFinder
+
+	log         *zap.Logger
+	transformed *prometheus.CounterVec
+	failedCasts *prometheus.CounterVec
+}
+
+// NewPointsWriter returns a PointsWriter applying the rules of rules to the
+// points written to w.
+func NewPointsWriter(log *zap.Logger, w storage.PointsWriter, rules TransformerFinder) *PointsWriter {
+	return &PointsWriter{
+		Underlying: w,
+		Rules:      rules,
+		log:        log,
+		transformed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ingest",
+			Subsystem: "rules",
+			Name:      "points_total",
+			Help:      "Number of points transformed or dropped by ingest rules.",
+		}, []string{"result"}),
+		failedCasts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ingest",
+			Subsystem: "rules",
+			Name:      "failed_casts_total",
+			Help:      "Number of field values cast rules could not convert, by whether the field was removed or the point dropped.",
+		}, []string{"action"}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the writer.
+func (w *PointsWriter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{w.transformed, w.failedCasts}
+}
+
+// WritePoints writes the points transformed by the rules of the bucket. The
+// points dropped by a partial write are reported as the points written, so
+// that they can be attributed to the lines they were parsed from. Points
+// dropped because a field could not be cast are reported in the same way,
+// after the other points are written.
+func (w *PointsWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	t, err := w.Rules.Transformer(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return w.Underlying.WritePoints(ctx, orgID, bucketID, points)
+	}
+
+	out := make([]models.Point, 0, len(points))
+	var origins map[models.Point]models.Point
+	var changed, dropped, removed int
+	var castErr *tsdb.PartialWriteError
+	onRemoved := func(r influxdb.IngestRule) {
+		removed++
+		w.log.Debug("Ingest rule removed a field that could not be cast",
+			zap.String("field", r.Key), zap.String("type", r.To), zap.Stringer("bucket_id", bucketID))
+	}
+	for _, p := range points {
+		tp, err := t.transform(p, onRemoved)
+		var cerr *CastError
+		if errors.As(err, &cerr) {
+			castErr = tsdb.MergePartialWriteErrors(castErr, tsdb.PartialWriteError{
+				Reason:        cerr.Error(),
+				Dropped:       1,
+				DroppedPoints: []tsdb.DroppedPoint{{Point: p, Cause: tsdb.DropSchemaViolation, Reason: cerr.Error()}},
+			})
+			continue
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case tp == nil:
+			dropped++
+			continue
+		case tp != p:
+			if origins == nil {
+				origins = make(map[models.Point]models.Point)
+			}
+			origins[tp] = p
+			changed++
+		}
+		out = append(out, tp)
+	}
+	w.transformed.WithLabelValues("changed").Add(float64(changed))
+	w.transformed.WithLabelValues("dropped").Add(float64(dropped))
+	w.failedCasts.WithLabelValues("field_removed").Add(float64(removed))
+	if castErr != nil {
+		w.failedCasts.WithLabelValues("point_dropped").Add(float64(castErr.Dropped))
+		w.log.Warn("Ingest rules dropped points with a value that could not be cast",
+			zap.Int("dropped", castErr.Dropped), zap.String("reason", castErr.Reason), zap.Stringer("bucket_id", bucketID))
+	}
+
+	if len(out) == 0 {
+		return castError(castErr)
+	}
+	err = w.Underlying.WritePoints(ctx, orgID, bucketID, out)
+	perr, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		if err != nil {
+			return err
+		}
+		return castError(castErr)
+	}
+	if origins != nil {
+		dps := make([]tsdb.DroppedPoint, len(perr.DroppedPoints))
+		for i, d := range perr.DroppedPoints {
+			if p, ok := origins[d.Point]; ok {
+				d.Point = p
+			}
+			dps[i] = d
+		}
+		perr.DroppedPoints = dps
+	}
+	if castErr != nil {
+		perr = *tsdb.MergePartialWriteErrors(&perr, *castErr)
+	}
+	return perr
+}
+
+// castError returns the partial write error of the points dropped by cast
+// rules, or nil if none was dropped.
+func castError(perr *tsdb.PartialWriteError) error {
+	if perr == nil {
+		return nil
+	}
+	return *perr
+}
@@ -0,0 +1,212 @@
+package ingest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/dbrp"
+	ihttp "github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/http/legacy"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/ingest"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap/zaptest"
+)
+
+var upsRules = []influxdb.IngestRule{
+	{Type: influxdb.IngestRuleRenameField, Key: "WATTS", To: "watts"},
+	{Type: influxdb.IngestRuleDropTag, Key: "serial"},
+	{Type: influxdb.IngestRuleAddTag, Key: "site", Value: "north"},
+	{Type: influxdb.IngestRuleCastField, Key: "LOADPCT", To: influxdb.IngestFieldTypeInteger},
+	{Type: influxdb.IngestRuleDropPoints, Key: "host", Pattern: "^test-"},
+}
+
+const upsBody = "ups,host=rack1,serial=123 WATTS=120,LOADPCT=12.5 1\n" +
+	"ups,host=test-1 WATTS=1 2\n" +
+	"ups,host=rack2 WATTS=80 3"
+
+var upsWant = []string{
+	"ups,host=rack1,site=north LOADPCT=12i,watts=120 1",
+	"ups,host=rack2,site=north watts=80 3",
+}
+
+// newRulesPointsWriter returns a writer applying upsRules to the points
+// collected by the returned mock.
+func newRulesPointsWriter(t *testing.T) (*ingest.PointsWriter, *mock.PointsWriter) {
+	t.Helper()
+	svc := newTestService(t)
+	err := svc.PutIngestRules(context.Background(), &influxdb.BucketIngestRules{BucketID: bucketID, Rules: upsRules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	underlying := &mock.PointsWriter{}
+	return ingest.NewPointsWriter(zaptest.NewLogger(t), underlying, svc), underlying
+}
+
+func assertPoints(t *testing.T, got []models.Point, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("unexpected points %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i].String() != want[i] {
+			t.Fatalf("unexpected point %s, want %s", got[i], want[i])
+		}
+	}
+}
+
+func bucketWriteAuthorization() *influxdb.Authorization {
+	org, bkt := orgID, bucketID
+	return &influxdb.Authorization{
+		ID:     1,
+		OrgID:  orgID,
+		Status: influxdb.Active,
+		Permissions: []influxdb.Permission{{
+			Action:   influxdb.WriteAction,
+			Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &org, ID: &bkt},
+		}},
+	}
+}
+
+func testBucketService() *mock.BucketService {
+	bucket := &influxdb.Bucket{ID: bucketID, OrgID: orgID, Name: "ups"}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return bucket, nil
+	}
+	buckets.FindBucketByIDFn = func(context.Context, platform.ID) (*influxdb.Bucket, error) {
+		return bucket, nil
+	}
+	return buckets
+}
+
+func TestPointsWriter_V2Write(t *testing.T) {
+	pw, underlying := newRulesPointsWriter(t)
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return &influxdb.Organization{ID: orgID}, nil
+	}
+	b := &ihttp.APIBackend{
+		HTTPErrorHandler:    kithttp.ErrorHandler(0),
+		Logger:              zaptest.NewLogger(t),
+		OrganizationService: orgs,
+		BucketService:       testBucketService(),
+		PointsWriter:        pw,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := ihttp.NewWriteHandler(zaptest.NewLogger(t), ihttp.NewWriteBackend(zaptest.NewLogger(t), b))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWriteAuthorization())
+
+	r := httptest.NewRequest(http.MethodPost, "http://localhost:8086/api/v2/write", strings.NewReader(upsBody))
+	params := r.URL.Query()
+	params.Set("org", orgID.String())
+	params.Set("bucket", bucketID.String())
+	params.Set("precision", "ns")
+	r.URL.RawQuery = params.Encode()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	assertPoints(t, underlying.Points, upsWant)
+}
+
+func TestPointsWriter_V1Write(t *testing.T) {
+	pw, underlying := newRulesPointsWriter(t)
+
+	dbrps := &mock.DBRPMappingServiceV2{
+		FindManyFn: func(ctx context.Context, filter influxdb.DBRPMappingFilterV2, opts ...influxdb.FindOptions) ([]*influxdb.DBRPMappingV2, int, error) {
+			return []*influxdb.DBRPMappingV2{{
+				ID:              1,
+				OrganizationID:  orgID,
+				BucketID:        bucketID,
+				Database:        "ups",
+				RetentionPolicy: "autogen",
+				Default:         true,
+			}}, 1, nil
+		},
+	}
+	handler := legacy.NewWriterHandler(&legacy.PointsWriterBackend{
+		HTTPErrorHandler:   kithttp.ErrorHandler(0),
+		Logger:             zaptest.NewLogger(t),
+		BucketService:      testBucketService(),
+		DBRPMappingService: dbrp.NewAuthorizedService(dbrps),
+		PointsWriter:       pw,
+		EventRecorder:      &metric.NopEventRecorder{},
+	})
+
+	ctx := pcontext.SetAuthorizer(context.Background(), bucketWriteAuthorization())
+	r := httptest.NewRequest(http.MethodPost, "http://localhost:8086/write", strings.NewReader(upsBody)).WithContext(ctx)
+	params := r.URL.Query()
+	params.Set("db", "ups")
+	r.URL.RawQuery = params.Encode()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	assertPoints(t, underlying.Points, upsWant)
+}
+
+func TestPointsWriter_PartialWrite(t *testing.T) {
+	pw, underlying := newRulesPointsWriter(t)
+	underlying.WritePointsFn = func(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+		return tsdb.PartialWriteError{
+			Reason:        "field type conflict",
+			Dropped:       1,
+			DroppedPoints: []tsdb.DroppedPoint{{Point: points[1], Reason: "field type conflict"}},
+		}
+	}
+
+	points, err := models.ParsePointsString(upsBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pw.WritePoints(context.Background(), orgID, bucketID, points)
+	perr, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		t.Fatalf("expected a partial write error, got %v", err)
+	}
+	// The dropped point is reported as written, not as transformed.
+	if len(perr.DroppedPoints) != 1 || perr.DroppedPoints[0].Point != points[2] {
+		t.Fatalf("unexpected dropped points %+v", perr.DroppedPoints)
+	}
+}
+
+func TestPointsWriter_CastNotFinite(t *testing.T) {
+	pw, underlying := newRulesPointsWriter(t)
+
+	points, err := models.ParsePointsString(`ups,host=rack1 WATTS=120,LOADPCT="NaN" 1` + "\n" +
+		`ups,host=rack2 WATTS=80,LOADPCT="12.5" 2` + "\n" +
+		`ups,host=rack3 WATTS=60,LOADPCT="idle" 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pw.WritePoints(context.Background(), orgID, bucketID, points)
+	perr, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		t.Fatalf("expected a partial write error, got %v", err)
+	}
+	// Only the point with a NaN value is dropped; a value that cannot be
+	// converted is removed from its point.
+	if perr.Dropped != 1 || len(perr.DroppedPoints) != 1 || perr.DroppedPoints[0].Point != points[0] {
+		t.Fatalf("unexpected dropped points %+v", perr.DroppedPoints)
+	}
+	assertPoints(t, underlying.Points, []string{
+		"ups,host=rack2,site=north LOADPCT=12i,watts=80 2",
+		"ups,host=rack3,site=north watts=60 3",
+	})
+}
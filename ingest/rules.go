@@ -0,0 +1,346 @@
+// Package ingest applies the ingest rules of buckets to the points written
+// to them, renaming, dropping, adding or converting their measurement, tags
+// and fields before they reach the storage engine.
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// Transformer applies compiled ingest rules to points.
+type Transformer struct {
+	rules []rule
+}
+
+type rule struct {
+	influxdb.IngestRule
+	re *regexp.Regexp
+}
+
+// Compile returns the transformer applying rules in order.
+func Compile(rules []influxdb.IngestRule) (*Transformer, error) {
+	t := &Transformer{rules: make([]rule, 0, len(rules))}
+	for _, r := range rules {
+		if err := r.Valid(); err != nil {
+			return nil, err
+		}
+		cr := rule{IngestRule: r}
+		if r.Type == influxdb.IngestRuleDropPoints {
+			cr.re = regexp.MustCompile(r.Pattern)
+		}
+		t.rules = append(t.rules, cr)
+	}
+	return t, nil
+}
+
+// point is a point being transformed. The tags and fields are decoded on
+// first change.
+type point struct {
+	orig    models.Point
+	name    string
+	tags    map[string]string
+	fields  models.Fields
+	changed bool
+}
+
+func (p *point) tag(key string) (string, bool) {
+	if p.tags != nil {
+		v, ok := p.tags[key]
+		return v, ok
+	}
+	v := p.orig.Tags().Get([]byte(key))
+	return string(v), v != nil
+}
+
+func (p *point) mutableTags() map[string]string {
+	if p.tags == nil {
+		p.tags = p.orig.Tags().Map()
+	}
+	p.changed = true
+	return p.tags
+}
+
+func (p *point) mutableFields() (models.Fields, error) {
+	if p.fields == nil {
+		fields, err := p.orig.Fields()
+		if err != nil {
+			return nil, err
+		}
+		p.fields = fields
+	}
+	p.changed = true
+	return p.fields, nil
+}
+
+func (p *point) hasField(key string) bool {
+	if p.fields != nil {
+		_, ok := p.fields[key]
+		return ok
+	}
+	return hasFieldKey(p.orig, key)
+}
+
+// hasFieldKey reports whether pt has the field key, without decoding the
+// values of the fields.
+func hasFieldKey(pt models.Point, key string) bool {
+	iter := pt.FieldIterator()
+	for iter.Next() {
+		if string(iter.FieldKey()) == key {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// errCannotCast is returned by cast when a value cannot be converted.
+	errCannotCast = errors.New("value cannot be converted")
+	// errNotFinite is returned by cast when a value is NaN or infinite.
+	errNotFinite = errors.New("value is not a finite number")
+)
+
+// CastError is returned by Transform when a cast rule meets a NaN or infinite
+// value. The point cannot be stored and is dropped.
+type CastError struct {
+	Field string
+	To    string
+}
+
+func (e *CastError) Error() string {
+	return fmt.Sprintf("cannot cast field %q to %s: %v", e.Field, e.To, errNotFinite)
+}
+
+// Transform returns the point transformed by the rules, or nil if it is
+// dropped. Points the rules do not change are returned as is. A *CastError
+// is returned if a cast rule meets a value that is not a finite number.
+func (t *Transformer) Transform(pt models.Point) (models.Point, error) {
+	return t.transform(pt, nil)
+}
+
+// transform is Transform, calling removed, if not nil, with the rule of each
+// field removed because its value could not be cast.
+func (t *Transformer) transform(pt models.Point, removed func(r influxdb.IngestRule)) (models.Point, error) {
+	p := &point{orig: pt, name: string(pt.Name())}
+	for _, r := range t.rules {
+		if r.Measurement != "" && r.Measurement != p.name {
+			continue
+		}
+
+		switch r.Type {
+		case influxdb.IngestRuleRenameMeasurement:
+			if p.name == r.Key {
+				p.name = r.To
+				p.changed = true
+			}
+		case influxdb.IngestRuleRenameTag:
+			if v, ok := p.tag(r.Key); ok {
+				tags := p.mutableTags()
+				delete(tags, r.Key)
+				tags[r.To] = v
+			}
+		case influxdb.IngestRuleDropTag:
+			if _, ok := p.tag(r.Key); ok {
+				delete(p.mutableTags(), r.Key)
+			}
+		case influxdb.IngestRuleAddTag:
+			if v, ok := p.tag(r.Key); !ok || v != r.Value {
+				p.mutableTags()[r.Key] = r.Value
+			}
+		case influxdb.IngestRuleRenameField, influxdb.IngestRuleDropField, influxdb.IngestRuleCastField:
+			if !p.hasField(r.Key) {
+				continue
+			}
+			fields, err := p.mutableFields()
+			if err != nil {
+				return nil, err
+			}
+			v := fields[r.Key]
+			switch r.Type {
+			case influxdb.IngestRuleRenameField:
+				delete(fields, r.Key)
+				fields[r.To] = v
+			case influxdb.IngestRuleDropField:
+				delete(fields, r.Key)
+			case influxdb.IngestRuleCastField:
+				cv, err := cast(v, r.To)
+				switch err {
+				case nil:
+					fields[r.Key] = cv
+				case errNotFinite:
+					return nil, &CastError{Field: r.Key, To: r.To}
+				default:
+					delete(fields, r.Key)
+					if removed != nil {
+						removed(r.IngestRule)
+					}
+				}
+			}
+		case influxdb.IngestRuleDropPoints:
+			v, ok := p.name, true
+			if r.Key != "" {
+				v, ok = p.tag(r.Key)
+			}
+			if ok && r.re.MatchString(v) {
+				return nil, nil
+			}
+		}
+	}
+
+	if !p.changed {
+		return pt, nil
+	}
+	if p.fields == nil {
+		fields, err := pt.Fields()
+		if err != nil {
+			return nil, err
+		}
+		p.fields = fields
+	}
+	if len(p.fields) == 0 {
+		return nil, nil
+	}
+	tags := pt.Tags()
+	if p.tags != nil {
+		tags = models.NewTags(p.tags)
+	}
+	out, err := models.NewPoint(p.name, tags, p.fields, pt.Time())
+	if err != nil {
+		return nil, fmt.Errorf("transformed point is invalid: %w", err)
+	}
+	return out, nil
+}
+
+// cast converts v to the type to. It returns errNotFinite if v is, or is
+// parsed as, NaN or an infinity, and errCannotCast if v cannot be converted.
+func cast(v interface{}, to string) (interface{}, error) {
+	if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil, errNotFinite
+	}
+
+	switch to {
+	case influxdb.IngestFieldTypeFloat:
+		switch v := v.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case uint64:
+			return float64(v), nil
+		case bool:
+			return boolTo(v, 1.0, 0.0), nil
+		case string:
+			return parseFloat(v)
+		}
+	case influxdb.IngestFieldTypeInteger:
+		switch v := v.(type) {
+		case float64:
+			if v < math.MinInt64 || v >= math.MaxInt64 {
+				return nil, errCannotCast
+			}
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case uint64:
+			if v > math.MaxInt64 {
+				return nil, errCannotCast
+			}
+			return int64(v), nil
+		case bool:
+			return boolTo(v, int64(1), int64(0)), nil
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i, nil
+			}
+			f, err := parseFloat(v)
+			if err != nil {
+				return nil, err
+			}
+			return cast(f, to)
+		}
+	case influxdb.IngestFieldTypeUnsigned:
+		switch v := v.(type) {
+		case float64:
+			if v < 0 || v >= math.MaxUint64 {
+				return nil, errCannotCast
+			}
+			return uint64(v), nil
+		case int64:
+			if v < 0 {
+				return nil, errCannotCast
+			}
+			return uint64(v), nil
+		case uint64:
+			return v, nil
+		case bool:
+			return boolTo(v, uint64(1), uint64(0)), nil
+		case string:
+			if u, err := strconv.ParseUint(v, 10, 64); err == nil {
+				return u, nil
+			}
+			f, err := parseFloat(v)
+			if err != nil {
+				return nil, err
+			}
+			return cast(f, to)
+		}
+	case influxdb.IngestFieldTypeString:
+		switch v := v.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case uint64:
+			return strconv.FormatUint(v, 10), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		case string:
+			return v, nil
+		}
+	case influxdb.IngestFieldTypeBoolean:
+		switch v := v.(type) {
+		case float64:
+			return v != 0, nil
+		case int64:
+			return v != 0, nil
+		case uint64:
+			return v != 0, nil
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, errCannotCast
+			}
+			return b, nil
+		}
+	}
+	return nil, errCannotCast
+}
+
+// parseFloat parses s as a finite float. ParseFloat accepts "NaN" and
+// "Inf", and returns an infinity for values out of range, which cannot be
+// stored.
+func parseFloat(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errNotFinite
+	}
+	if err != nil {
+		return 0, errCannotCast
+	}
+	return f, nil
+}
+
+func boolTo(b bool, t, f interface{}) interface{} {
+	if b {
+		return t
+	}
+	return f
+}
@@ -0,0 +1,202 @@
+package ingest_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/ingest"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+func TestTransformer_Transform(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []influxdb.IngestRule
+		point string
+		want  string // empty if the point is dropped
+	}{
+		{
+			name:  "no rules",
+			point: "ups,host=a WATTS=10 1",
+			want:  "ups,host=a WATTS=10 1",
+		},
+		{
+			name:  "rename measurement",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleRenameMeasurement, Key: "ups", To: "apcupsd"}},
+			point: "ups,host=a WATTS=10 1",
+			want:  "apcupsd,host=a WATTS=10 1",
+		},
+		{
+			name:  "rename field",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleRenameField, Key: "WATTS", To: "watts"}},
+			point: "ups,host=a WATTS=10,LOADPCT=5 1",
+			want:  "ups,host=a LOADPCT=5,watts=10 1",
+		},
+		{
+			name:  "rename tag",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleRenameTag, Key: "host", To: "hostname"}},
+			point: "ups,host=a WATTS=10 1",
+			want:  "ups,hostname=a WATTS=10 1",
+		},
+		{
+			name:  "drop tag",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleDropTag, Key: "serial"}},
+			point: "ups,host=a,serial=123 WATTS=10 1",
+			want:  "ups,host=a WATTS=10 1",
+		},
+		{
+			name:  "drop field",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleDropField, Key: "STATUS"}},
+			point: `ups,host=a WATTS=10,STATUS="ONLINE" 1`,
+			want:  "ups,host=a WATTS=10 1",
+		},
+		{
+			name:  "drop only field drops the point",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleDropField, Key: "WATTS"}},
+			point: "ups,host=a WATTS=10 1",
+		},
+		{
+			name:  "add tag",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleAddTag, Key: "site", Value: "north"}},
+			point: "ups,host=a WATTS=10 1",
+			want:  "ups,host=a,site=north WATTS=10 1",
+		},
+		{
+			name:  "add tag overrides the tag",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleAddTag, Key: "host", Value: "b"}},
+			point: "ups,host=a WATTS=10 1",
+			want:  "ups,host=b WATTS=10 1",
+		},
+		{
+			name: "cast fields",
+			rules: []influxdb.IngestRule{
+				{Type: influxdb.IngestRuleCastField, Key: "WATTS", To: influxdb.IngestFieldTypeInteger},
+				{Type: influxdb.IngestRuleCastField, Key: "CHARGE", To: influxdb.IngestFieldTypeFloat},
+				{Type: influxdb.IngestRuleCastField, Key: "ONLINE", To: influxdb.IngestFieldTypeBoolean},
+				{Type: influxdb.IngestRuleCastField, Key: "SERIAL", To: influxdb.IngestFieldTypeString},
+			},
+			point: `ups WATTS=10.7,CHARGE="97.5",ONLINE=1i,SERIAL=123i 1`,
+			want:  `ups CHARGE=97.5,ONLINE=true,SERIAL="123",WATTS=10i 1`,
+		},
+		{
+			name:  "cast removes values that cannot be converted",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleCastField, Key: "STATUS", To: influxdb.IngestFieldTypeFloat}},
+			point: `ups WATTS=10,STATUS="ONLINE" 1`,
+			want:  "ups WATTS=10 1",
+		},
+		{
+			name:  "drop points matching the measurement",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleDropPoints, Pattern: "^debug_"}},
+			point: "debug_ups WATTS=10 1",
+		},
+		{
+			name:  "drop points matching a tag",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleDropPoints, Key: "host", Pattern: "^test-"}},
+			point: "ups,host=test-1 WATTS=10 1",
+		},
+		{
+			name:  "keep points not matching",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleDropPoints, Key: "host", Pattern: "^test-"}},
+			point: "ups,host=prod-1 WATTS=10 1",
+			want:  "ups,host=prod-1 WATTS=10 1",
+		},
+		{
+			name:  "rule restricted to another measurement",
+			rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleRenameField, Measurement: "cpu", Key: "WATTS", To: "watts"}},
+			point: "ups WATTS=10 1",
+			want:  "ups WATTS=10 1",
+		},
+		{
+			name: "rules apply in order",
+			rules: []influxdb.IngestRule{
+				{Type: influxdb.IngestRuleRenameMeasurement, Key: "UPS", To: "ups"},
+				{Type: influxdb.IngestRuleRenameField, Measurement: "ups", Key: "WATTS", To: "watts"},
+				{Type: influxdb.IngestRuleRenameTag, Key: "host", To: "name"},
+				{Type: influxdb.IngestRuleDropPoints, Key: "name", Pattern: "^test-"},
+			},
+			point: "UPS,host=rack1 WATTS=10 1",
+			want:  "ups,name=rack1 watts=10 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := ingest.Compile(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			points, err := models.ParsePointsString(tt.point)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tr.Transform(points[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("expected the point to be dropped, got %s", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("unexpected dropped point")
+			}
+			if got.String() != tt.want {
+				t.Fatalf("unexpected point %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformer_TransformNotFinite(t *testing.T) {
+	tests := []struct {
+		to    string
+		value string
+	}{
+		{influxdb.IngestFieldTypeFloat, "NaN"},
+		{influxdb.IngestFieldTypeFloat, "+Inf"},
+		{influxdb.IngestFieldTypeFloat, "1e400"},
+		{influxdb.IngestFieldTypeInteger, "-Inf"},
+		{influxdb.IngestFieldTypeUnsigned, "nan"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.to+" "+tt.value, func(t *testing.T) {
+			tr, err := ingest.Compile([]influxdb.IngestRule{{Type: influxdb.IngestRuleCastField, Key: "CHARGE", To: tt.to}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			points, err := models.ParsePointsString(`ups WATTS=10,CHARGE="` + tt.value + `" 1`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tr.Transform(points[0])
+			if _, ok := err.(*ingest.CastError); !ok {
+				t.Fatalf("expected a cast error, got %v", err)
+			}
+			if got != nil {
+				t.Fatalf("unexpected point %s", got)
+			}
+		})
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule influxdb.IngestRule
+	}{
+		{"unsupported type", influxdb.IngestRule{Type: "upperCase", Key: "host"}},
+		{"rename without target", influxdb.IngestRule{Type: influxdb.IngestRuleRenameField, Key: "WATTS"}},
+		{"drop without key", influxdb.IngestRule{Type: influxdb.IngestRuleDropTag}},
+		{"add tag without value", influxdb.IngestRule{Type: influxdb.IngestRuleAddTag, Key: "site"}},
+		{"cast to unsupported type", influxdb.IngestRule{Type: influxdb.IngestRuleCastField, Key: "WATTS", To: "decimal"}},
+		{"invalid pattern", influxdb.IngestRule{Type: influxdb.IngestRuleDropPoints, Pattern: "("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ingest.Compile([]influxdb.IngestRule{tt.rule}); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var rulesBucket = []byte("ingestrulesv1")
+
+var (
+	// ErrRulesNotFound is used when a bucket has no ingest rules.
+	ErrRulesNotFound = &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  "ingest rules not found",
+	}
+
+	// ErrInvalidBucketID is used when the service was provided
+	// an invalid ID format.
+	ErrInvalidBucketID = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "provided bucket ID has invalid format",
+	}
+)
+
+// ErrInternalService is used when the error comes from an internal system.
+func ErrInternalService(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.EInternal,
+		Msg:  "unexpected error in the ingest rules store",
+		Err:  err,
+		Op:   "ingest/service",
+	}
+}
+
+var _ influxdb.IngestRulesService = (*Service)(nil)
+
+// Service stores the ingest rules of buckets in the kv store. It keeps the
+// rules compiled for the buckets written to.
+type Service struct {
+	store     kv.Store
+	bucketSvc influxdb.BucketService
+	now       func() time.Time
+
+	mu           sync.RWMutex
+	transformers map[platform.ID]*Transformer
+}
+
+// NewService returns a Service storing rules in st. The buckets of the rules
+// must exist in bucketSvc.
+func NewService(bucketSvc influxdb.BucketService, st kv.Store) *Service {
+	return &Service{
+		store:        st,
+		bucketSvc:    bucketSvc,
+		now:          time.Now,
+		transformers: make(map[platform.ID]*Transformer),
+	}
+}
+
+// FindIngestRules returns the rules of the buckets matching filter.
+func (s *Service) FindIngestRules(ctx context.Context, filter influxdb.IngestRulesFilter) ([]*influxdb.BucketIngestRules, error) {
+	if filter.BucketID != nil {
+		r, err := s.GetIngestRules(ctx, *filter.BucketID)
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return []*influxdb.BucketIngestRules{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if filter.OrgID != nil && r.OrgID != *filter.OrgID {
+			return []*influxdb.BucketIngestRules{}, nil
+		}
+		return []*influxdb.BucketIngestRules{r}, nil
+	}
+
+	rules := []*influxdb.BucketIngestRules{}
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(rulesBucket)
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		cur, err := b.ForwardCursor(nil)
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		return kv.WalkCursor(ctx, cur, func(_, v []byte) (bool, error) {
+			var r influxdb.BucketIngestRules
+			if err := json.Unmarshal(v, &r); err != nil {
+				return false, ErrInternalService(err)
+			}
+			if filter.OrgID == nil || r.OrgID == *filter.OrgID {
+				rules = append(rules, &r)
+			}
+			return true, nil
+		})
+	})
+	return rules, err
+}
+
+// GetIngestRules returns the rules of a bucket.
+func (s *Service) GetIngestRules(ctx context.Context, bucketID platform.ID) (*influxdb.BucketIngestRules, error) {
+	encID, err := bucketID.Encode()
+	if err != nil {
+		return nil, ErrInvalidBucketID
+	}
+	var r influxdb.BucketIngestRules
+	err = s.store.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(rulesBucket)
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		v, err := b.Get(encID)
+		if kv.IsNotFound(err) {
+			return ErrRulesNotFound
+		}
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		if err := json.Unmarshal(v, &r); err != nil {
+			return ErrInternalService(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// PutIngestRules replaces the rules of a bucket. The organization of the
+// rules is the one of the bucket.
+func (s *Service) PutIngestRules(ctx context.Context, r *influxdb.BucketIngestRules) error {
+	encID, err := r.BucketID.Encode()
+	if err != nil {
+		return ErrInvalidBucketID
+	}
+	t, err := Compile(r.Rules)
+	if err != nil {
+		return err
+	}
+	bkt, err := s.bucketSvc.FindBucketByID(ctx, r.BucketID)
+	if err != nil {
+		return err
+	}
+	if r.OrgID.Valid() && r.OrgID != bkt.OrgID {
+		return &errors.Error{Code: errors.EInvalid, Msg: "bucket does not belong to the organization"}
+	}
+	r.OrgID = bkt.OrgID
+	if r.Rules == nil {
+		r.Rules = []influxdb.IngestRule{}
+	}
+	r.UpdatedAt = s.now().UTC()
+
+	v, err := json.Marshal(r)
+	if err != nil {
+		return ErrInternalService(err)
+	}
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(rulesBucket)
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		if err := b.Put(encID, v); err != nil {
+			return ErrInternalService(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(r.Rules) == 0 {
+		t = nil
+	}
+	s.mu.Lock()
+	s.transformers[r.BucketID] = t
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteIngestRules removes the rules of a bucket.
+func (s *Service) DeleteIngestRules(ctx context.Context, bucketID platform.ID) error {
+	if _, err := s.GetIngestRules(ctx, bucketID); err != nil {
+		return err
+	}
+	encID, _ := bucketID.Encode()
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(rulesBucket)
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		if err := b.Delete(encID); err != nil {
+			return ErrInternalService(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.transformers[bucketID] = nil
+	s.mu.Unlock()
+	return nil
+}
+
+// Transformer returns the transformer of the rules of a bucket, or nil if it
+// has none. The rules are loaded from the store once per bucket.
+func (s *Service) Transformer(ctx context.Context, bucketID platform.ID) (*Transformer, error) {
+	s.mu.RLock()
+	t, ok := s.transformers[bucketID]
+	s.mu.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	r, err := s.GetIngestRules(ctx, bucketID)
+	if err != nil && errors.ErrorCode(err) != errors.ENotFound {
+		return nil, err
+	}
+	if r != nil && len(r.Rules) > 0 {
+		if t, err = Compile(r.Rules); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The rules may have been replaced while they were loaded.
+	if cached, ok := s.transformers[bucketID]; ok {
+		return cached, nil
+	}
+	s.transformers[bucketID] = t
+	return t, nil
+}
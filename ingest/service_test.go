@@ -0,0 +1,107 @@
+package ingest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/ingest"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	orgID    = platform.ID(0x1111)
+	bucketID = platform.ID(0x2222)
+)
+
+func newTestService(t *testing.T) *ingest.Service {
+	t.Helper()
+	store := inmem.NewKVStore()
+	if err := all.Up(context.Background(), zaptest.NewLogger(t), store); err != nil {
+		t.Fatal(err)
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		if id != bucketID {
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		}
+		return &influxdb.Bucket{ID: bucketID, OrgID: orgID, Name: "ups"}, nil
+	}
+	return ingest.NewService(buckets, store)
+}
+
+var renameWatts = influxdb.IngestRule{Type: influxdb.IngestRuleRenameField, Key: "WATTS", To: "watts"}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	if _, err := svc.GetIngestRules(ctx, bucketID); errors.ErrorCode(err) != errors.ENotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	tr, err := svc.Transformer(ctx, bucketID)
+	if err != nil || tr != nil {
+		t.Fatalf("expected no transformer, got %v: %v", tr, err)
+	}
+
+	rules := &influxdb.BucketIngestRules{BucketID: bucketID, Rules: []influxdb.IngestRule{renameWatts}}
+	if err := svc.PutIngestRules(ctx, rules); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.GetIngestRules(ctx, bucketID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OrgID != orgID || len(got.Rules) != 1 || got.Rules[0] != renameWatts || got.UpdatedAt.IsZero() {
+		t.Fatalf("unexpected rules %+v", got)
+	}
+	if tr, err := svc.Transformer(ctx, bucketID); err != nil || tr == nil {
+		t.Fatalf("expected a transformer, got %v: %v", tr, err)
+	}
+
+	id := orgID
+	found, err := svc.FindIngestRules(ctx, influxdb.IngestRulesFilter{OrgID: &id})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("unexpected rules %+v: %v", found, err)
+	}
+	otherOrg := platform.ID(0x3333)
+	if found, err := svc.FindIngestRules(ctx, influxdb.IngestRulesFilter{OrgID: &otherOrg}); err != nil || len(found) != 0 {
+		t.Fatalf("unexpected rules %+v: %v", found, err)
+	}
+
+	if err := svc.DeleteIngestRules(ctx, bucketID); err != nil {
+		t.Fatal(err)
+	}
+	if tr, err := svc.Transformer(ctx, bucketID); err != nil || tr != nil {
+		t.Fatalf("expected the transformer to be removed, got %v: %v", tr, err)
+	}
+	if err := svc.DeleteIngestRules(ctx, bucketID); errors.ErrorCode(err) != errors.ENotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestService_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules influxdb.BucketIngestRules
+	}{
+		{"invalid rule", influxdb.BucketIngestRules{BucketID: bucketID, Rules: []influxdb.IngestRule{{Type: influxdb.IngestRuleDropTag}}}},
+		{"unknown bucket", influxdb.BucketIngestRules{BucketID: 0x4444}},
+		{"bucket of another org", influxdb.BucketIngestRules{BucketID: bucketID, OrgID: 0x3333}},
+	}
+	svc := newTestService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.PutIngestRules(context.Background(), &tt.rules); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+var _ influxdb.IngestRulesService = (*ingest.Service)(nil)
@@ -0,0 +1,146 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// IngestRuleType is the transformation of an IngestRule.
+type IngestRuleType string
+
+// Ingest rule types
+const (
+	// IngestRuleRenameMeasurement renames the measurement Key to To.
+	IngestRuleRenameMeasurement IngestRuleType = "renameMeasurement"
+	// IngestRuleRenameField renames the field Key to To.
+	IngestRuleRenameField IngestRuleType = "renameField"
+	// IngestRuleRenameTag renames the tag Key to To.
+	IngestRuleRenameTag IngestRuleType = "renameTag"
+	// IngestRuleDropTag removes the tag Key.
+	IngestRuleDropTag IngestRuleType = "dropTag"
+	// IngestRuleDropField removes the field Key. Points left without fields
+	// are dropped.
+	IngestRuleDropField IngestRuleType = "dropField"
+	// IngestRuleAddTag sets the tag Key to Value.
+	IngestRuleAddTag IngestRuleType = "addTag"
+	// IngestRuleCastField converts the field Key to the type To. Values that
+	// cannot be converted are removed. Points with a NaN or infinite value to
+	// convert are dropped.
+	IngestRuleCastField IngestRuleType = "castField"
+	// IngestRuleDropPoints drops the points whose measurement, or the value
+	// of the tag Key if set, matches the regular expression Pattern.
+	IngestRuleDropPoints IngestRuleType = "dropPoints"
+)
+
+// Field types of IngestRuleCastField rules.
+const (
+	IngestFieldTypeFloat    = "float"
+	IngestFieldTypeInteger  = "integer"
+	IngestFieldTypeUnsigned = "unsigned"
+	IngestFieldTypeString   = "string"
+	IngestFieldTypeBoolean  = "boolean"
+)
+
+// IngestRule transforms the points written to a bucket before they are
+// stored.
+type IngestRule struct {
+	Type IngestRuleType `json:"type" yaml:"type"`
+	// Measurement restricts the rule to the points of a measurement.
+	Measurement string `json:"measurement,omitempty" yaml:"measurement,omitempty"`
+	Key         string `json:"key,omitempty" yaml:"key,omitempty"`
+	To          string `json:"to,omitempty" yaml:"to,omitempty"`
+	Value       string `json:"value,omitempty" yaml:"value,omitempty"`
+	Pattern     string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+// Valid returns an error if the rule is invalid.
+func (r IngestRule) Valid() error {
+	invalid := func(format string, args ...interface{}) error {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid %s rule: ", r.Type) + fmt.Sprintf(format, args...),
+		}
+	}
+
+	switch r.Type {
+	case IngestRuleRenameMeasurement, IngestRuleRenameField, IngestRuleRenameTag:
+		if r.Key == "" || r.To == "" {
+			return invalid("key and to are required")
+		}
+	case IngestRuleDropTag, IngestRuleDropField:
+		if r.Key == "" {
+			return invalid("key is required")
+		}
+	case IngestRuleAddTag:
+		if r.Key == "" || r.Value == "" {
+			return invalid("key and value are required")
+		}
+	case IngestRuleCastField:
+		if r.Key == "" {
+			return invalid("key is required")
+		}
+		switch r.To {
+		case IngestFieldTypeFloat, IngestFieldTypeInteger, IngestFieldTypeUnsigned, IngestFieldTypeString, IngestFieldTypeBoolean:
+		default:
+			return invalid("unsupported type %q", r.To)
+		}
+	case IngestRuleDropPoints:
+		if r.Pattern == "" {
+			return invalid("pattern is required")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return invalid("%v", err)
+		}
+	default:
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("unsupported ingest rule type %q", r.Type),
+		}
+	}
+	return nil
+}
+
+// BucketIngestRules are the rules applied, in order, to the points written
+// to a bucket.
+type BucketIngestRules struct {
+	BucketID  platform.ID  `json:"bucketID"`
+	OrgID     platform.ID  `json:"orgID"`
+	Rules     []IngestRule `json:"rules"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// Valid returns an error if a rule is invalid.
+func (r *BucketIngestRules) Valid() error {
+	for _, rule := range r.Rules {
+		if err := rule.Valid(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IngestRulesFilter represents a set of filter that restrict the returned results.
+type IngestRulesFilter struct {
+	OrgID    *platform.ID
+	BucketID *platform.ID
+}
+
+// IngestRulesService manages the ingest rules of buckets.
+type IngestRulesService interface {
+	// FindIngestRules returns the rules of the buckets matching filter.
+	FindIngestRules(ctx context.Context, filter IngestRulesFilter) ([]*BucketIngestRules, error)
+
+	// GetIngestRules returns the rules of a bucket.
+	GetIngestRules(ctx context.Context, bucketID platform.ID) (*BucketIngestRules, error)
+
+	// PutIngestRules replaces the rules of a bucket.
+	PutIngestRules(ctx context.Context, rules *BucketIngestRules) error
+
+	// DeleteIngestRules removes the rules of a bucket.
+	DeleteIngestRules(ctx context.Context, bucketID platform.ID) error
+}
@@ -34,6 +34,7 @@ type MetaClientMock struct {
 
 	PrecreateShardGroupsFn func(from, to time.Time) error
 	PruneShardGroupsFn     func() error
+	ReplaceShardGroupsFn   func(database, policy string, ids []uint64, groups []meta.ShardGroupInfo) ([]meta.ShardGroupInfo, error)
 
 	RetentionPolicyFn func(database, name string) (rpi *meta.RetentionPolicyInfo, err error)
 
@@ -42,6 +43,7 @@ type MetaClientMock struct {
 	SetAdminPrivilegeFn      func(username string, admin bool) error
 	SetDataFn                func(*meta.Data) error
 	SetPrivilegeFn           func(username, database string, p influxql.Privilege) error
+	SetShardTierFn           func(id uint64, tier string) error
 	ShardGroupsByTimeRangeFn func(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error)
 	ShardOwnerFn             func(shardID uint64) (database, policy string, sgi *meta.ShardGroupInfo)
 	TruncateShardGroupsFn    func(t time.Time) error
@@ -133,6 +135,10 @@ func (c *MetaClientMock) SetPrivilege(username, database string, p influxql.Priv
 	return c.SetPrivilegeFn(username, database, p)
 }
 
+func (c *MetaClientMock) SetShardTier(id uint64, tier string) error {
+	return c.SetShardTierFn(id, tier)
+}
+
 func (c *MetaClientMock) ShardGroupsByTimeRange(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error) {
 	return c.ShardGroupsByTimeRangeFn(database, policy, min, max)
 }
@@ -177,3 +183,7 @@ func (c *MetaClientMock) PrecreateShardGroups(from, to time.Time) error {
 	return c.PrecreateShardGroupsFn(from, to)
 }
 func (c *MetaClientMock) PruneShardGroups() error { return c.PruneShardGroupsFn() }
+
+func (c *MetaClientMock) ReplaceShardGroups(database, policy string, ids []uint64, groups []meta.ShardGroupInfo) ([]meta.ShardGroupInfo, error) {
+	return c.ReplaceShardGroupsFn(database, policy, ids, groups)
+}
@@ -32,6 +32,7 @@ type TSDBStoreMock struct {
 	MeasurementSeriesCountsFn func(database string) (measurements int, series int)
 	MeasurementsCardinalityFn func(database string) (int64, error)
 	MeasurementNamesFn        func(ctx context.Context, auth query.Authorizer, database string, cond influxql.Expr) ([][]byte, error)
+	MoveShardToColdTierFn     func(id uint64) error
 	OpenFn                    func() error
 	PathFn                    func() string
 	RestoreShardFn            func(id uint64, r io.Reader) error
@@ -102,6 +103,9 @@ func (s *TSDBStoreMock) MeasurementSeriesCounts(database string) (measurements i
 func (s *TSDBStoreMock) MeasurementsCardinality(database string) (int64, error) {
 	return s.MeasurementsCardinalityFn(database)
 }
+func (s *TSDBStoreMock) MoveShardToColdTier(id uint64) error {
+	return s.MoveShardToColdTierFn(id)
+}
 func (s *TSDBStoreMock) Open() error {
 	return s.OpenFn()
 }
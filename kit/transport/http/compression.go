@@ -0,0 +1,123 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NYTimes/gziphandler"
+	"github.com/klauspost/compress/zstd"
+)
+
+var zstdEncoderPool = sync.Pool{
+	New: func() interface{} {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return enc
+	},
+}
+
+// CompressResponse compresses the responses of next with zstd or gzip,
+// whichever the client prefers in its Accept-Encoding header. zstd is
+// preferred when the client accepts both equally.
+func CompressResponse(next http.Handler) http.Handler {
+	gz := gziphandler.GzipHandler(next)
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !prefersZstd(r.Header.Get("Accept-Encoding")) {
+			gz.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		zw := &zstdResponseWriter{ResponseWriter: w}
+		defer zw.Close()
+		next.ServeHTTP(zw, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// prefersZstd returns true if an Accept-Encoding header accepts zstd at
+// least as much as gzip.
+func prefersZstd(acceptEncoding string) bool {
+	var zstdQ, gzipQ float64
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, q := parseCoding(coding)
+		switch name {
+		case "zstd":
+			zstdQ = q
+		case "gzip":
+			gzipQ = q
+		}
+	}
+	return zstdQ > 0 && zstdQ >= gzipQ
+}
+
+// parseCoding parses a content coding with an optional quality value, such
+// as "gzip;q=0.5".
+func parseCoding(s string) (string, float64) {
+	parts := strings.Split(s, ";")
+	name := strings.ToLower(strings.TrimSpace(parts[0]))
+	q := 1.0
+	for _, param := range parts[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+		if err != nil {
+			return name, 0
+		}
+		q = v
+	}
+	return name, q
+}
+
+// zstdResponseWriter compresses the body of a response with zstd.
+type zstdResponseWriter struct {
+	http.ResponseWriter
+	enc         *zstd.Encoder
+	wroteHeader bool
+}
+
+func (w *zstdResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set("Content-Encoding", "zstd")
+		w.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *zstdResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.enc == nil {
+		w.enc = zstdEncoderPool.Get().(*zstd.Encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	return w.enc.Write(b)
+}
+
+// Flush flushes the compressed data written so far to the client.
+func (w *zstdResponseWriter) Flush() {
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close ends the zstd stream and releases the encoder.
+func (w *zstdResponseWriter) Close() error {
+	if w.enc == nil {
+		return nil
+	}
+	err := w.enc.Close()
+	zstdEncoderPool.Put(w.enc)
+	w.enc = nil
+	return err
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressResponse(t *testing.T) {
+	body := strings.Repeat("_result,table,_value\n,0,1\n", 100)
+	handler := CompressResponse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = io.WriteString(w, body)
+	}))
+
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{acceptEncoding: "", encoding: ""},
+		{acceptEncoding: "gzip", encoding: "gzip"},
+		{acceptEncoding: "zstd", encoding: "zstd"},
+		{acceptEncoding: "gzip, zstd", encoding: "zstd"},
+		{acceptEncoding: "zstd;q=0.5, gzip", encoding: "gzip"},
+		{acceptEncoding: "zstd;q=0, gzip;q=0", encoding: ""},
+		{acceptEncoding: "identity", encoding: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v2/query", nil)
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			require.Equal(t, tt.encoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+			var got io.Reader = w.Body
+			switch tt.encoding {
+			case "gzip":
+				zr, err := gzip.NewReader(w.Body)
+				require.NoError(t, err)
+				got = zr
+			case "zstd":
+				zr, err := zstd.NewReader(w.Body)
+				require.NoError(t, err)
+				defer zr.Close()
+				got = zr
+			}
+			b, err := ioutil.ReadAll(got)
+			require.NoError(t, err)
+			assert.Equal(t, body, string(b))
+		})
+	}
+}
+
+func TestCompressResponse_NoBody(t *testing.T) {
+	handler := CompressResponse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/query", nil)
+	r.Header.Set("Accept-Encoding", "zstd")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+}
@@ -0,0 +1,11 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var mqttSubscriptionsBucket = []byte("mqttsubscriptionsv1")
+
+// Migration0016_AddMQTTSubscriptionsBucket creates the bucket necessary for the MQTT subscription service to operate.
+var Migration0016_AddMQTTSubscriptionsBucket = migration.CreateBuckets(
+	"create MQTT subscriptions bucket",
+	mqttSubscriptionsBucket,
+)
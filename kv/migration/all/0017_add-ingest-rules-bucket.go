@@ -0,0 +1,11 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var ingestRulesBucket = []byte("ingestrulesv1")
+
+// Migration0017_AddIngestRulesBucket creates the bucket necessary for the ingest rules service to operate.
+var Migration0017_AddIngestRulesBucket = migration.CreateBuckets(
+	"create ingest rules bucket",
+	ingestRulesBucket,
+)
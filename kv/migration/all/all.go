@@ -37,5 +37,9 @@ var Migrations = [...]migration.Spec{
 	Migration0014_ReindexDBRPs,
 	// record shard group durations in bucket metadata
 	Migration0015_RecordShardGroupDurationsInBucketMetadata,
+	// add MQTT subscriptions bucket
+	Migration0016_AddMQTTSubscriptionsBucket,
+	// add ingest rules bucket
+	Migration0017_AddIngestRulesBucket,
 	// {{ do_not_edit . }}
 }
@@ -0,0 +1,85 @@
+package listener
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// batcher groups points into batches written when they are full, or when the
+// batch timeout expires.
+type batcher struct {
+	size    int
+	timeout time.Duration
+	write   func([]models.Point)
+
+	mu     sync.Mutex
+	points []models.Point
+
+	batches chan []models.Point
+}
+
+func newBatcher(size int, timeout time.Duration, write func([]models.Point)) *batcher {
+	return &batcher{
+		size:    size,
+		timeout: timeout,
+		write:   write,
+		batches: make(chan []models.Point, 1),
+	}
+}
+
+// add adds points to the current batch. It blocks while a full batch waits to
+// be written.
+func (b *batcher) add(points []models.Point) {
+	b.mu.Lock()
+	b.points = append(b.points, points...)
+	var full []models.Point
+	if len(b.points) >= b.size {
+		full, b.points = b.points, nil
+	}
+	b.mu.Unlock()
+
+	if full != nil {
+		b.batches <- full
+	}
+}
+
+// take removes the current batch.
+func (b *batcher) take() []models.Point {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	points := b.points
+	b.points = nil
+	return points
+}
+
+// run writes the batches until done is closed, then writes the points left.
+// Points must not be added once done is closed.
+func (b *batcher) run(done <-chan struct{}) {
+	ticker := time.NewTicker(b.timeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case batch := <-b.batches:
+			b.write(batch)
+		case <-ticker.C:
+			if points := b.take(); len(points) > 0 {
+				b.write(points)
+			}
+		case <-done:
+			for {
+				select {
+				case batch := <-b.batches:
+					b.write(batch)
+				default:
+					if points := b.take(); len(points) > 0 {
+						b.write(points)
+					}
+					return
+				}
+			}
+		}
+	}
+}
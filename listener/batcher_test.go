@@ -0,0 +1,62 @@
+package listener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+func TestBatcher(t *testing.T) {
+	batches := make(chan []models.Point, 10)
+	b := newBatcher(3, 50*time.Millisecond, func(points []models.Point) {
+		batches <- points
+	})
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		b.run(done)
+		close(stopped)
+	}()
+
+	points, err := models.ParsePointsString("m v=1 1\nm v=2 2\nm v=3 3\nm v=4 4\nm v=5 5\nm v=6 6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A full batch is written without waiting for the timeout.
+	b.add(points[:2])
+	b.add(points[2:4])
+	select {
+	case batch := <-batches:
+		if len(batch) != 4 {
+			t.Fatalf("expected 4 points, got %d", len(batch))
+		}
+	case <-time.After(40 * time.Millisecond):
+		t.Fatal("full batch was not written")
+	}
+
+	// An incomplete batch is written on timeout.
+	b.add(points[4:5])
+	select {
+	case batch := <-batches:
+		if len(batch) != 1 {
+			t.Fatalf("expected 1 point, got %d", len(batch))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("incomplete batch was not written")
+	}
+
+	// The points left are written when stopping.
+	b.add(points[5:])
+	close(done)
+	<-stopped
+	select {
+	case batch := <-batches:
+		if len(batch) != 1 {
+			t.Fatalf("expected 1 point, got %d", len(batch))
+		}
+	default:
+		t.Fatal("points left were not written")
+	}
+}
@@ -0,0 +1,176 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const (
+	// ProtocolUDP is the protocol of listeners receiving line protocol in
+	// UDP datagrams.
+	ProtocolUDP = "udp"
+
+	// ProtocolTCP is the protocol of listeners receiving line protocol over
+	// TCP connections, one point per line.
+	ProtocolTCP = "tcp"
+
+	// DefaultBatchSize is the default number of points written at once.
+	DefaultBatchSize = 5000
+
+	// DefaultBatchTimeout is the default interval at which incomplete batches
+	// are written.
+	DefaultBatchTimeout = time.Second
+
+	// DefaultPrecision is the default precision of the timestamps received.
+	DefaultPrecision = "ns"
+)
+
+// Config is the configuration of a listener.
+type Config struct {
+	Protocol    string
+	BindAddress string
+
+	// Org and Bucket are the names or IDs of the organization and bucket the
+	// points are written to, with the permissions of Token.
+	Org    string
+	Bucket string
+	Token  string
+
+	Precision    string
+	BatchSize    int
+	BatchTimeout time.Duration
+
+	// ReadBuffer is the size of the operating system receive buffer of the
+	// socket, the system default is kept when it is 0.
+	ReadBuffer int
+}
+
+// ParseConfig parses the configuration of a listener from a URL such as
+//
+//	udp://:8089?org=my-org&bucket=my-bucket&token-env=MY_TOKEN&batch-size=5000&batch-timeout=1s&read-buffer=8388608&precision=ns
+//
+// The org and bucket parameters are required, and so is either token-env, the
+// name of the environment variable holding the token, or token-file, the path
+// of a file holding it. The token itself is not accepted in the URL, which
+// would expose it in the command line of the process. The other parameters
+// are optional.
+func ParseConfig(s string) (Config, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return Config{}, err
+	}
+
+	qp := u.Query()
+	token, err := parseToken(qp)
+	if err != nil {
+		return Config{}, err
+	}
+	c := Config{
+		Protocol:     u.Scheme,
+		BindAddress:  u.Host,
+		Org:          qp.Get("org"),
+		Bucket:       qp.Get("bucket"),
+		Token:        token,
+		Precision:    DefaultPrecision,
+		BatchSize:    DefaultBatchSize,
+		BatchTimeout: DefaultBatchTimeout,
+	}
+	if v := qp.Get("precision"); v != "" {
+		c.Precision = v
+	}
+	if v := qp.Get("batch-size"); v != "" {
+		if c.BatchSize, err = strconv.Atoi(v); err != nil {
+			return Config{}, fmt.Errorf("invalid batch-size: %w", err)
+		}
+	}
+	if v := qp.Get("batch-timeout"); v != "" {
+		if c.BatchTimeout, err = time.ParseDuration(v); err != nil {
+			return Config{}, fmt.Errorf("invalid batch-timeout: %w", err)
+		}
+	}
+	if v := qp.Get("read-buffer"); v != "" {
+		if c.ReadBuffer, err = strconv.Atoi(v); err != nil {
+			return Config{}, fmt.Errorf("invalid read-buffer: %w", err)
+		}
+	}
+	return c, c.Validate()
+}
+
+// parseToken reads the token named by the token-env or token-file parameter.
+func parseToken(qp url.Values) (string, error) {
+	if _, ok := qp["token"]; ok {
+		return "", errors.New("the token must be given with token-env or token-file, not in the URL")
+	}
+
+	return ReadToken(qp.Get("token-env"), qp.Get("token-file"))
+}
+
+// ReadToken reads a token from the environment variable env or from the file
+// at path file. Only one of them may be given, the token is empty if neither
+// is.
+func ReadToken(env, file string) (string, error) {
+	switch {
+	case env != "" && file != "":
+		return "", errors.New("only one of token-env and token-file may be given")
+	case env != "":
+		token, ok := os.LookupEnv(env)
+		if !ok {
+			return "", fmt.Errorf("token-env: environment variable %s is not set", env)
+		}
+		return token, nil
+	case file != "":
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("token-file: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return "", nil
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	switch c.Protocol {
+	case ProtocolUDP, ProtocolTCP:
+	default:
+		return fmt.Errorf("unsupported protocol %q", c.Protocol)
+	}
+	if c.BindAddress == "" {
+		return errors.New("missing bind address")
+	}
+	if c.Org == "" {
+		return errors.New("missing org")
+	}
+	if c.Bucket == "" {
+		return errors.New("missing bucket")
+	}
+	if c.Token == "" {
+		return errors.New("missing token")
+	}
+	if !models.ValidPrecision(c.Precision) {
+		return fmt.Errorf("invalid precision %q", c.Precision)
+	}
+	if c.BatchSize <= 0 {
+		return errors.New("batch-size must be positive")
+	}
+	if c.BatchTimeout <= 0 {
+		return errors.New("batch-timeout must be positive")
+	}
+	if c.ReadBuffer < 0 {
+		return errors.New("read-buffer must not be negative")
+	}
+	return nil
+}
+
+// String identifies the listener by its protocol and bind address.
+func (c Config) String() string {
+	return c.Protocol + "://" + c.BindAddress
+}
@@ -0,0 +1,97 @@
+package listener_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/listener"
+)
+
+func TestParseConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listener-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("my-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		spec    string
+		want    listener.Config
+		wantErr bool
+	}{
+		{
+			spec: "udp://:8089?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN",
+			want: listener.Config{
+				Protocol:     listener.ProtocolUDP,
+				BindAddress:  ":8089",
+				Org:          "my-org",
+				Bucket:       "my-bucket",
+				Token:        "my-token",
+				Precision:    listener.DefaultPrecision,
+				BatchSize:    listener.DefaultBatchSize,
+				BatchTimeout: listener.DefaultBatchTimeout,
+			},
+		},
+		{
+			spec: "tcp://127.0.0.1:8094?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&batch-size=100&batch-timeout=200ms&read-buffer=1024&precision=s",
+			want: listener.Config{
+				Protocol:     listener.ProtocolTCP,
+				BindAddress:  "127.0.0.1:8094",
+				Org:          "my-org",
+				Bucket:       "my-bucket",
+				Token:        "my-token",
+				Precision:    "s",
+				BatchSize:    100,
+				BatchTimeout: 200 * time.Millisecond,
+				ReadBuffer:   1024,
+			},
+		},
+		{
+			spec: "udp://:8089?org=my-org&bucket=my-bucket&token-file=" + tokenFile,
+			want: listener.Config{
+				Protocol:     listener.ProtocolUDP,
+				BindAddress:  ":8089",
+				Org:          "my-org",
+				Bucket:       "my-bucket",
+				Token:        "my-token",
+				Precision:    listener.DefaultPrecision,
+				BatchSize:    listener.DefaultBatchSize,
+				BatchTimeout: listener.DefaultBatchTimeout,
+			},
+		},
+		{spec: "http://:8089?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN", wantErr: true},
+		{spec: "udp://?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN", wantErr: true},
+		{spec: "udp://:8089?bucket=my-bucket&token-env=LISTENER_TOKEN", wantErr: true},
+		{spec: "udp://:8089?org=my-org&token-env=LISTENER_TOKEN", wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket", wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket&token=my-token", wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket&token-env=LISTENER_UNSET_TOKEN", wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&token-file=" + tokenFile, wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket&token-file=" + tokenFile + ".missing", wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&precision=d", wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&batch-size=0", wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&batch-timeout=soon", wantErr: true},
+		{spec: "udp://:8089?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&read-buffer=-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := listener.ParseConfig(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("unexpected config:\n got %+v\nwant %+v", got, tt.want)
+			}
+			if !tt.wantErr && got.String() != tt.want.Protocol+"://"+tt.want.BindAddress {
+				t.Errorf("unexpected string %q", got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+// Package listener implements services receiving line protocol over UDP and
+// TCP sockets, and writing it to a bucket.
+package listener
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxUDPPayload is the largest payload of a UDP datagram.
+	maxUDPPayload = 64 * 1024
+
+	// maxLineBytes bounds the length of the lines received over TCP.
+	maxLineBytes = 1 << 20
+)
+
+// AuthorizationFinder finds the authorization of a token.
+type AuthorizationFinder interface {
+	FindAuthorizationByToken(ctx context.Context, token string) (*influxdb.Authorization, error)
+}
+
+// Protocol is the format of the data received by a listener.
+type Protocol struct {
+	// Parse parses the points of a UDP datagram, or of a line received over
+	// TCP. The points that parse are returned along with the error of the
+	// lines that do not.
+	Parse func(buf []byte, now time.Time) ([]models.Point, error)
+
+	// ServeConn, when set, reads the points of the connections accepted by a
+	// TCP listener instead of parsing them line by line. It returns once the
+	// connection is closed.
+	ServeConn func(l *Listener, conn net.Conn)
+}
+
+// LineProtocol returns the protocol of listeners receiving line protocol with
+// timestamps of the given precision.
+func LineProtocol(precision string) Protocol {
+	return Protocol{
+		Parse: func(buf []byte, now time.Time) ([]models.Point, error) {
+			return models.ParsePointsWithPrecision(buf, now, precision)
+		},
+	}
+}
+
+type spec struct {
+	config   Config
+	protocol Protocol
+}
+
+// Service runs a set of listeners.
+type Service struct {
+	log     *zap.Logger
+	specs   []spec
+	metrics *metrics
+
+	writer  storage.PointsWriter
+	auths   AuthorizationFinder
+	orgs    influxdb.OrganizationService
+	buckets influxdb.BucketService
+
+	listeners []*Listener
+}
+
+// NewService returns a service running a line protocol listener for each
+// configuration.
+func NewService(log *zap.Logger, configs []Config, writer storage.PointsWriter, auths AuthorizationFinder, orgs influxdb.OrganizationService, buckets influxdb.BucketService) *Service {
+	s := &Service{
+		log:     log,
+		metrics: newMetrics(),
+		writer:  writer,
+		auths:   auths,
+		orgs:    orgs,
+		buckets: buckets,
+	}
+	for _, c := range configs {
+		s.Add(c, LineProtocol(c.Precision))
+	}
+	return s
+}
+
+// Add adds a listener receiving data of the given protocol. It must be called
+// before Open.
+func (s *Service) Add(c Config, p Protocol) {
+	s.specs = append(s.specs, spec{config: c, protocol: p})
+}
+
+// Open starts the listeners. It fails if a listener cannot write to its
+// bucket with its token, or cannot bind its address.
+func (s *Service) Open(ctx context.Context) error {
+	for _, sp := range s.specs {
+		c := sp.config
+		l := &Listener{
+			config:   c,
+			protocol: sp.protocol,
+			log:      s.log.With(zap.String("listener", c.String())),
+			metrics:  s.metrics.listener(c.String()),
+			s:        s,
+		}
+		if err := l.open(ctx); err != nil {
+			s.Close()
+			return fmt.Errorf("failed to open listener %s: %w", c, err)
+		}
+		s.listeners = append(s.listeners, l)
+	}
+	return nil
+}
+
+// Close stops the listeners, once the points received have been written.
+func (s *Service) Close() error {
+	for _, l := range s.listeners {
+		l.close()
+	}
+	s.listeners = nil
+	return nil
+}
+
+// Listeners returns the listeners that are running.
+func (s *Service) Listeners() []*Listener {
+	return s.listeners
+}
+
+// PrometheusCollectors returns the metrics of the listeners.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	return s.metrics.collectors()
+}
+
+// Listener receives points on a socket and writes them in batches.
+type Listener struct {
+	config   Config
+	protocol Protocol
+	log      *zap.Logger
+	metrics  *listenerMetrics
+	s        *Service
+
+	orgID    platform.ID
+	bucketID platform.ID
+	batcher  *batcher
+
+	conn net.PacketConn // UDP listeners
+	ln   net.Listener   // TCP listeners
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+
+	closing chan struct{} // closed before the socket
+	done    chan struct{} // closed once the readers have stopped
+	readers sync.WaitGroup
+	writer  sync.WaitGroup
+}
+
+// Addr returns the address the listener is bound to.
+func (l *Listener) Addr() net.Addr {
+	if l.conn != nil {
+		return l.conn.LocalAddr()
+	}
+	return l.ln.Addr()
+}
+
+func (l *Listener) open(ctx context.Context) error {
+	org, err := findOrganization(ctx, l.s.orgs, l.config.Org)
+	if err != nil {
+		return err
+	}
+	bucket, err := findBucket(ctx, l.s.buckets, org.ID, l.config.Bucket)
+	if err != nil {
+		return err
+	}
+	l.orgID, l.bucketID = org.ID, bucket.ID
+	if err := l.authorize(ctx); err != nil {
+		return err
+	}
+
+	switch l.config.Protocol {
+	case ProtocolUDP:
+		conn, err := net.ListenPacket("udp", l.config.BindAddress)
+		if err != nil {
+			return err
+		}
+		if l.config.ReadBuffer > 0 {
+			if err := conn.(*net.UDPConn).SetReadBuffer(l.config.ReadBuffer); err != nil {
+				conn.Close()
+				return err
+			}
+		}
+		l.conn = conn
+	case ProtocolTCP:
+		ln, err := net.Listen("tcp", l.config.BindAddress)
+		if err != nil {
+			return err
+		}
+		l.ln = ln
+		l.conns = make(map[net.Conn]struct{})
+	}
+
+	l.closing = make(chan struct{})
+	l.done = make(chan struct{})
+	l.batcher = newBatcher(l.config.BatchSize, l.config.BatchTimeout, l.write)
+	l.writer.Add(1)
+	go func() {
+		defer l.writer.Done()
+		l.batcher.run(l.done)
+	}()
+
+	l.readers.Add(1)
+	if l.conn != nil {
+		go l.serveUDP()
+	} else {
+		go l.serveTCP()
+	}
+
+	l.log.Info("Listening", zap.String("addr", l.Addr().String()))
+	return nil
+}
+
+func (l *Listener) close() {
+	close(l.closing)
+	if l.conn != nil {
+		l.conn.Close()
+	}
+	if l.ln != nil {
+		l.ln.Close()
+		l.mu.Lock()
+		for conn := range l.conns {
+			conn.Close()
+		}
+		l.mu.Unlock()
+	}
+
+	// Stop the readers before the batcher, so that no points are added
+	// once it writes the last batch.
+	l.readers.Wait()
+	close(l.done)
+	l.writer.Wait()
+}
+
+// authorize checks that the token of the listener is active and may write to
+// its bucket.
+func (l *Listener) authorize(ctx context.Context) error {
+	auth, err := l.s.auths.FindAuthorizationByToken(ctx, l.config.Token)
+	if err != nil {
+		return err
+	}
+	if !auth.IsActive() {
+		return errors.New("token is inactive")
+	}
+	ps, err := auth.PermissionSet()
+	if err != nil {
+		return err
+	}
+	p, err := influxdb.NewPermissionAtID(l.bucketID, influxdb.WriteAction, influxdb.BucketsResourceType, l.orgID)
+	if err != nil {
+		return err
+	}
+	if !ps.Allowed(*p) {
+		return fmt.Errorf("token is not allowed to write to bucket %s", l.config.Bucket)
+	}
+	return nil
+}
+
+// isClosing reports whether the listener is closing, in which case errors
+// reading from its socket are expected.
+func (l *Listener) isClosing() bool {
+	select {
+	case <-l.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *Listener) serveUDP() {
+	defer l.readers.Done()
+
+	buf := make([]byte, maxUDPPayload)
+	for {
+		n, _, err := l.conn.ReadFrom(buf)
+		if err != nil {
+			if l.isClosing() {
+				return
+			}
+			l.log.Info("Failed to read UDP datagram", zap.Error(err))
+			continue
+		}
+		l.metrics.bytesReceived.Add(float64(n))
+
+		// The points reference the bytes they are parsed from.
+		datagram := make([]byte, n)
+		copy(datagram, buf[:n])
+		l.parse(datagram)
+	}
+}
+
+func (l *Listener) serveTCP() {
+	defer l.readers.Done()
+
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			if l.isClosing() {
+				return
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				l.log.Info("Failed to accept TCP connection", zap.Error(err))
+				continue
+			}
+			l.log.Error("Stopped accepting TCP connections", zap.Error(err))
+			return
+		}
+		if l.config.ReadBuffer > 0 {
+			if err := conn.(*net.TCPConn).SetReadBuffer(l.config.ReadBuffer); err != nil {
+				l.log.Info("Failed to set read buffer", zap.Error(err))
+			}
+		}
+
+		l.mu.Lock()
+		if l.isClosing() {
+			l.mu.Unlock()
+			conn.Close()
+			return
+		}
+		l.conns[conn] = struct{}{}
+		l.mu.Unlock()
+		l.metrics.connections.Inc()
+
+		l.readers.Add(1)
+		go l.handleConn(conn)
+	}
+}
+
+func (l *Listener) handleConn(conn net.Conn) {
+	defer l.readers.Done()
+	defer func() {
+		conn.Close()
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+		l.metrics.connections.Dec()
+	}()
+
+	conn = &countingConn{Conn: conn, bytesReceived: l.metrics.bytesReceived}
+	if l.protocol.ServeConn != nil {
+		l.protocol.ServeConn(l, conn)
+		return
+	}
+	if err := l.serveLines(conn); err != nil {
+		l.log.Debug("Closing TCP connection", zap.String("remote_addr", conn.RemoteAddr().String()), zap.Error(err))
+	}
+}
+
+// serveLines parses the lines read from r until it is exhausted, and adds
+// their points to the current batch.
+func (l *Listener) serveLines(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineBytes)
+	for scanner.Scan() {
+		// The points reference the bytes they are parsed from.
+		line := scanner.Bytes()
+		buf := make([]byte, len(line))
+		copy(buf, line)
+		l.parse(buf)
+	}
+	return scanner.Err()
+}
+
+// parse adds the points of buf to the current batch. Invalid lines are
+// dropped.
+func (l *Listener) parse(buf []byte) {
+	points, err := l.protocol.Parse(buf, time.Now().UTC())
+	if err != nil {
+		l.ParseError(err)
+	}
+	l.Add(points)
+}
+
+// Add adds points to the current batch.
+func (l *Listener) Add(points []models.Point) {
+	if len(points) == 0 {
+		return
+	}
+	l.metrics.pointsReceived.Add(float64(len(points)))
+	l.batcher.add(points)
+}
+
+// ParseError records that some of the data received could not be parsed.
+func (l *Listener) ParseError(err error) {
+	l.metrics.parseErrors.Inc()
+	l.log.Debug("Failed to parse points", zap.Error(err))
+}
+
+// Logger returns the logger of the listener.
+func (l *Listener) Logger() *zap.Logger {
+	return l.log
+}
+
+// write writes a batch of points, if the token of the listener may still
+// write to the bucket.
+func (l *Listener) write(points []models.Point) {
+	ctx := context.Background()
+	if err := l.authorize(ctx); err != nil {
+		l.metrics.writeErrors.Inc()
+		l.log.Warn("Dropping batch, token not authorized", zap.Int("points", len(points)), zap.Error(err))
+		return
+	}
+	if err := l.s.writer.WritePoints(ctx, l.orgID, l.bucketID, points); err != nil {
+		l.metrics.writeErrors.Inc()
+		l.log.Warn("Failed to write batch", zap.Int("points", len(points)), zap.Error(err))
+		return
+	}
+	l.metrics.batchesWritten.Inc()
+	l.metrics.pointsWritten.Add(float64(len(points)))
+}
+
+// countingConn counts the bytes read from a connection.
+type countingConn struct {
+	net.Conn
+	bytesReceived prometheus.Counter
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.bytesReceived.Add(float64(n))
+	return n, err
+}
+
+func findOrganization(ctx context.Context, orgs influxdb.OrganizationService, org string) (*influxdb.Organization, error) {
+	if id, err := platform.IDFromString(org); err == nil {
+		if o, err := orgs.FindOrganizationByID(ctx, *id); err == nil {
+			return o, nil
+		}
+	}
+	return orgs.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &org})
+}
+
+func findBucket(ctx context.Context, buckets influxdb.BucketService, orgID platform.ID, bucket string) (*influxdb.Bucket, error) {
+	if id, err := platform.IDFromString(bucket); err == nil {
+		if b, err := buckets.FindBucket(ctx, influxdb.BucketFilter{OrganizationID: &orgID, ID: id}); err == nil {
+			return b, nil
+		}
+	}
+	return buckets.FindBucket(ctx, influxdb.BucketFilter{OrganizationID: &orgID, Name: &bucket})
+}
@@ -0,0 +1,292 @@
+package listener_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	orgID    = platform.ID(0x1111)
+	bucketID = platform.ID(0x2222)
+)
+
+// pointsWriter collects the batches written.
+type pointsWriter struct {
+	mu      sync.Mutex
+	batches [][]models.Point
+	written chan struct{}
+}
+
+func newPointsWriter() *pointsWriter {
+	return &pointsWriter{written: make(chan struct{}, 100)}
+}
+
+func (w *pointsWriter) WritePoints(ctx context.Context, org, bucket platform.ID, points []models.Point) error {
+	if org != orgID || bucket != bucketID {
+		return fmt.Errorf("unexpected org %s or bucket %s", org, bucket)
+	}
+	w.mu.Lock()
+	w.batches = append(w.batches, points)
+	w.mu.Unlock()
+	w.written <- struct{}{}
+	return nil
+}
+
+func (w *pointsWriter) wait(t *testing.T, n int) [][]models.Point {
+	t.Helper()
+	for {
+		w.mu.Lock()
+		got := 0
+		for _, b := range w.batches {
+			got += len(b)
+		}
+		batches := w.batches
+		w.mu.Unlock()
+		if got >= n {
+			return batches
+		}
+
+		select {
+		case <-w.written:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %d points, got %d", n, got)
+		}
+	}
+}
+
+func TestMain(m *testing.M) {
+	// The specs of the tests read their token from the environment.
+	os.Setenv("LISTENER_TOKEN", "my-token")
+	os.Setenv("LISTENER_OTHER_TOKEN", "other-token")
+	os.Exit(m.Run())
+}
+
+// tokenFinder finds the authorization of my-token, whose status may change
+// while the listeners run. Each lookup is sent to found, if set.
+type tokenFinder struct {
+	mu     sync.Mutex
+	status influxdb.Status
+	perms  []influxdb.Permission
+	found  chan influxdb.Status
+}
+
+func (f *tokenFinder) FindAuthorizationByToken(ctx context.Context, token string) (*influxdb.Authorization, error) {
+	if token != "my-token" {
+		return nil, fmt.Errorf("unknown token")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.found != nil {
+		select {
+		case f.found <- f.status:
+		default:
+		}
+	}
+	return &influxdb.Authorization{Status: f.status, OrgID: orgID, Permissions: f.perms}, nil
+}
+
+func (f *tokenFinder) setStatus(status influxdb.Status) {
+	f.mu.Lock()
+	f.status = status
+	f.mu.Unlock()
+}
+
+func newService(t *testing.T, w *pointsWriter, perms []influxdb.Permission, specs ...string) *listener.Service {
+	t.Helper()
+	return newServiceWithFinder(t, w, &tokenFinder{status: influxdb.Active, perms: perms}, specs...)
+}
+
+func newServiceWithFinder(t *testing.T, w *pointsWriter, auths listener.AuthorizationFinder, specs ...string) *listener.Service {
+	t.Helper()
+
+	var configs []listener.Config
+	for _, spec := range specs {
+		c, err := listener.ParseConfig(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs = append(configs, c)
+	}
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		if filter.Name == nil || *filter.Name != "my-org" {
+			return nil, fmt.Errorf("org not found")
+		}
+		return &influxdb.Organization{ID: orgID, Name: "my-org"}, nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		if filter.Name == nil || *filter.Name != "my-bucket" {
+			return nil, fmt.Errorf("bucket not found")
+		}
+		return &influxdb.Bucket{ID: bucketID, OrgID: orgID, Name: "my-bucket"}, nil
+	}
+
+	return listener.NewService(zaptest.NewLogger(t), configs, w, auths, orgs, buckets)
+}
+
+func writePermissions(t *testing.T) []influxdb.Permission {
+	t.Helper()
+	p, err := influxdb.NewPermissionAtID(bucketID, influxdb.WriteAction, influxdb.BucketsResourceType, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []influxdb.Permission{*p}
+}
+
+func TestService_UDP(t *testing.T) {
+	w := newPointsWriter()
+	s := newService(t, w, writePermissions(t), "udp://127.0.0.1:0?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&batch-size=3&batch-timeout=50ms&read-buffer=65536&precision=s")
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	conn, err := net.Dial("udp", s.Listeners()[0].Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	datagrams := []string{
+		"ups,ups=rack1 load=12 1600000000\nups,ups=rack2 load=15 1600000000",
+		"ups,ups=rack1 load=13 1600000010\nnot line protocol",
+		"ups,ups=rack1 load=14 1600000020",
+	}
+	for _, d := range datagrams {
+		if _, err := conn.Write([]byte(d)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	batches := w.wait(t, 4)
+	if len(batches[0]) != 3 {
+		t.Errorf("expected a first batch of 3 points, got %d", len(batches[0]))
+	}
+	var got []string
+	for _, b := range batches {
+		for _, p := range b {
+			got = append(got, p.String())
+		}
+	}
+	want := []string{
+		"ups,ups=rack1 load=12 1600000000000000000",
+		"ups,ups=rack2 load=15 1600000000000000000",
+		"ups,ups=rack1 load=13 1600000010000000000",
+		"ups,ups=rack1 load=14 1600000020000000000",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("unexpected points:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestService_TCP(t *testing.T) {
+	w := newPointsWriter()
+	s := newService(t, w, writePermissions(t), "tcp://127.0.0.1:0?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&batch-timeout=50ms")
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("tcp", s.Listeners()[0].Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("ups,ups=rack1 load=12 1\nups,ups=rack2 load=15 2\n")); err != nil {
+		t.Fatal(err)
+	}
+	w.wait(t, 2)
+
+	// Points received before closing are written.
+	if _, err := conn.Write([]byte("ups,ups=rack1 load=13 3\n")); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	time.Sleep(50 * time.Millisecond)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w.wait(t, 3)
+}
+
+func TestService_Unauthorized(t *testing.T) {
+	for _, spec := range []string{
+		"udp://127.0.0.1:0?org=my-org&bucket=my-bucket&token-env=LISTENER_OTHER_TOKEN",
+		"udp://127.0.0.1:0?org=other-org&bucket=my-bucket&token-env=LISTENER_TOKEN",
+		"udp://127.0.0.1:0?org=my-org&bucket=other-bucket&token-env=LISTENER_TOKEN",
+	} {
+		s := newService(t, newPointsWriter(), writePermissions(t), spec)
+		if err := s.Open(context.Background()); err == nil {
+			s.Close()
+			t.Errorf("%s: expected an error", spec)
+		}
+	}
+
+	readOnly, err := influxdb.NewPermissionAtID(bucketID, influxdb.ReadAction, influxdb.BucketsResourceType, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newService(t, newPointsWriter(), []influxdb.Permission{*readOnly}, "udp://127.0.0.1:0?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN")
+	if err := s.Open(context.Background()); err == nil {
+		s.Close()
+		t.Error("expected an error with a read-only token")
+	}
+}
+
+func TestService_Inactive(t *testing.T) {
+	auths := &tokenFinder{status: influxdb.Inactive, perms: writePermissions(t)}
+	s := newServiceWithFinder(t, newPointsWriter(), auths, "udp://127.0.0.1:0?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&batch-timeout=10ms")
+	err := s.Open(context.Background())
+	if err == nil {
+		s.Close()
+		t.Fatal("expected an error with an inactive token")
+	}
+	if !strings.Contains(err.Error(), "token is inactive") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Batches are dropped once the token is deactivated.
+	w := newPointsWriter()
+	auths.setStatus(influxdb.Active)
+	s = newServiceWithFinder(t, w, auths, "udp://127.0.0.1:0?org=my-org&bucket=my-bucket&token-env=LISTENER_TOKEN&batch-timeout=10ms")
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	found := make(chan influxdb.Status, 1)
+	auths.mu.Lock()
+	auths.status, auths.found = influxdb.Inactive, found
+	auths.mu.Unlock()
+
+	conn, err := net.Dial("udp", s.Listeners()[0].Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ups,ups=rack1 load=12 1")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-found:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the batch to be authorized")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.batches) != 0 {
+		t.Errorf("expected no batch to be written, got %d", len(w.batches))
+	}
+}
@@ -0,0 +1,83 @@
+package listener
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// metrics holds the metrics of the listeners, labeled by listener.
+type metrics struct {
+	bytesReceived  *prometheus.CounterVec
+	pointsReceived *prometheus.CounterVec
+	parseErrors    *prometheus.CounterVec
+	batchesWritten *prometheus.CounterVec
+	pointsWritten  *prometheus.CounterVec
+	writeErrors    *prometheus.CounterVec
+	connections    *prometheus.GaugeVec
+}
+
+func newMetrics() *metrics {
+	labels := []string{"listener"}
+	return &metrics{
+		bytesReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "listener_bytes_received_total",
+			Help: "Count of bytes received by the listener",
+		}, labels),
+		pointsReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "listener_points_received_total",
+			Help: "Count of points parsed by the listener",
+		}, labels),
+		parseErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "listener_parse_errors_total",
+			Help: "Count of datagrams or lines the listener failed to parse",
+		}, labels),
+		batchesWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "listener_batches_written_total",
+			Help: "Count of batches written by the listener",
+		}, labels),
+		pointsWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "listener_points_written_total",
+			Help: "Count of points written by the listener",
+		}, labels),
+		writeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "listener_write_errors_total",
+			Help: "Count of batches the listener failed to write",
+		}, labels),
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "listener_connections",
+			Help: "Number of open connections of TCP listeners",
+		}, labels),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.bytesReceived,
+		m.pointsReceived,
+		m.parseErrors,
+		m.batchesWritten,
+		m.pointsWritten,
+		m.writeErrors,
+		m.connections,
+	}
+}
+
+// listenerMetrics are the metrics of a single listener.
+type listenerMetrics struct {
+	bytesReceived  prometheus.Counter
+	pointsReceived prometheus.Counter
+	parseErrors    prometheus.Counter
+	batchesWritten prometheus.Counter
+	pointsWritten  prometheus.Counter
+	writeErrors    prometheus.Counter
+	connections    prometheus.Gauge
+}
+
+func (m *metrics) listener(name string) *listenerMetrics {
+	return &listenerMetrics{
+		bytesReceived:  m.bytesReceived.WithLabelValues(name),
+		pointsReceived: m.pointsReceived.WithLabelValues(name),
+		parseErrors:    m.parseErrors.WithLabelValues(name),
+		batchesWritten: m.batchesWritten.WithLabelValues(name),
+		pointsWritten:  m.pointsWritten.WithLabelValues(name),
+		writeErrors:    m.writeErrors.WithLabelValues(name),
+		connections:    m.connections.WithLabelValues(name),
+	}
+}
@@ -1123,6 +1123,14 @@ func skipWhitespace(buf []byte, i int) int {
 	return i
 }
 
+// LineEnd returns the position of the newline ending the line starting at i
+// within buf, or len(buf) if the line is not terminated. Newlines within
+// quoted string field values do not end a line.
+func LineEnd(buf []byte, i int) int {
+	end, _ := scanLine(buf, i)
+	return end
+}
+
 // scanLine returns the end position in buf and the next line found within
 // buf.
 func scanLine(buf []byte, i int) (int, []byte) {
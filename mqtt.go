@@ -0,0 +1,213 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// ops for MQTTSubscription Store
+const (
+	OpListSubscriptions   = "ListSubscriptions"
+	OpAddSubscription     = "AddSubscription"
+	OpGetSubscriptionByID = "GetSubscriptionByID"
+	OpRemoveSubscription  = "RemoveSubscription"
+	OpUpdateSubscription  = "UpdateSubscription"
+)
+
+// MQTTFormat is the format of the payloads of MQTT messages.
+type MQTTFormat string
+
+// MQTT payload formats
+const (
+	// MQTTFormatLineProtocol payloads are line protocol.
+	MQTTFormatLineProtocol MQTTFormat = "lp"
+	// MQTTFormatJSON payloads are JSON objects, or arrays of objects, mapped
+	// to points with an MQTTJSONMapping.
+	MQTTFormatJSON MQTTFormat = "json"
+	// MQTTFormatValue payloads are a single value, whose measurement and tags
+	// come from the topic.
+	MQTTFormatValue MQTTFormat = "value"
+)
+
+// MQTTSubscription subscribes to the topics of an MQTT broker, and writes the
+// points parsed from the messages received to a bucket. Subscriptions are
+// authorized as scrapers.
+type MQTTSubscription struct {
+	ID       platform.ID `json:"id,omitempty"`
+	Name     string      `json:"name"`
+	OrgID    platform.ID `json:"orgID,omitempty"`
+	BucketID platform.ID `json:"bucketID,omitempty"`
+
+	// BrokerURL is the URL of the broker, such as tcp://localhost:1883. The
+	// tcp, ssl, ws and wss schemes are supported.
+	BrokerURL string `json:"brokerURL"`
+	ClientID  string `json:"clientID,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+
+	// Topics are the topic filters subscribed to, with the QoS level.
+	Topics []string `json:"topics"`
+	QoS    byte     `json:"qos"`
+
+	Format MQTTFormat `json:"format"`
+	// Precision is the precision of the timestamps of line protocol and JSON
+	// payloads, ns by default.
+	Precision string `json:"precision,omitempty"`
+	// TopicTemplate maps the levels of topics to the measurement, tags and
+	// field of value and JSON payloads, see ParseMQTTTopicTemplate.
+	TopicTemplate string `json:"topicTemplate,omitempty"`
+	// JSON maps JSON payloads to points.
+	JSON *MQTTJSONMapping `json:"json,omitempty"`
+}
+
+// MQTTJSONMapping maps the values of JSON objects to the measurement, tags,
+// fields and timestamp of points. Values are selected with paths of keys and
+// array indexes separated by dots, such as "battery.charge" or "outlets.0.load".
+type MQTTJSONMapping struct {
+	// Measurement is the measurement of the points, unless MeasurementPath
+	// selects a string value holding it.
+	Measurement     string `json:"measurement,omitempty"`
+	MeasurementPath string `json:"measurementPath,omitempty"`
+
+	// TimestampPath selects an integer timestamp in the precision of the
+	// subscription, or an RFC 3339 string. Points are given the time they are
+	// received otherwise.
+	TimestampPath string `json:"timestampPath,omitempty"`
+
+	Tags   []MQTTJSONValue `json:"tags,omitempty"`
+	Fields []MQTTJSONValue `json:"fields"`
+}
+
+// MQTTJSONValue names the value selected by a path.
+type MQTTJSONValue struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Valid returns an error if the subscription is invalid.
+func (s *MQTTSubscription) Valid() error {
+	invalid := func(format string, args ...interface{}) error {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf(format, args...),
+		}
+	}
+
+	if s.Name == "" {
+		return invalid("subscription name is required")
+	}
+	u, err := url.Parse(s.BrokerURL)
+	if err != nil || u.Host == "" {
+		return invalid("invalid broker URL %q", s.BrokerURL)
+	}
+	switch u.Scheme {
+	case "tcp", "ssl", "ws", "wss":
+	default:
+		return invalid("unsupported broker URL scheme %q", u.Scheme)
+	}
+	if len(s.Topics) == 0 {
+		return invalid("at least one topic is required")
+	}
+	for _, t := range s.Topics {
+		if t == "" {
+			return invalid("topics must not be empty")
+		}
+	}
+	if s.QoS > 2 {
+		return invalid("qos must be 0, 1 or 2")
+	}
+	if s.Precision != "" && !models.ValidPrecision(s.Precision) {
+		return invalid("invalid precision %q", s.Precision)
+	}
+	if _, err := ParseMQTTTopicTemplate(s.TopicTemplate); err != nil {
+		return invalid("%s", err)
+	}
+
+	switch s.Format {
+	case MQTTFormatLineProtocol, MQTTFormatValue:
+	case MQTTFormatJSON:
+		if s.JSON == nil || len(s.JSON.Fields) == 0 {
+			return invalid("JSON payloads require a mapping with at least one field")
+		}
+		for _, v := range append(s.JSON.Tags, s.JSON.Fields...) {
+			if v.Name == "" || v.Path == "" {
+				return invalid("JSON tags and fields require a name and a path")
+			}
+		}
+	default:
+		return invalid("unsupported format %q", s.Format)
+	}
+	return nil
+}
+
+// MQTTTopicTemplate maps the levels of a topic to a measurement, tags and a
+// field.
+type MQTTTopicTemplate []string
+
+// ParseMQTTTopicTemplate parses a template with a part per topic level. Levels
+// named measurement and field give the measurement and field of the points,
+// the levels named _ are skipped, and the other ones are tags:
+//
+//	_/ups/measurement/field
+//
+// maps the topic "ups/rack1/battery/charge" to the measurement battery, the tag
+// ups=rack1 and the field charge.
+func ParseMQTTTopicTemplate(s string) (MQTTTopicTemplate, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, "/")
+	seen := make(map[string]bool, len(parts))
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid topic template %q: empty level", s)
+		}
+		if p != "_" && seen[p] {
+			return nil, fmt.Errorf("invalid topic template %q: %s is repeated", s, p)
+		}
+		seen[p] = true
+	}
+	return MQTTTopicTemplate(parts), nil
+}
+
+// Apply returns the measurement, tags and field the template maps topic to.
+// They are empty when the topic has no such level.
+func (t MQTTTopicTemplate) Apply(topic string) (measurement string, tags map[string]string, field string) {
+	tags = make(map[string]string)
+	for i, level := range strings.Split(topic, "/") {
+		if i >= len(t) {
+			break
+		}
+		switch t[i] {
+		case "_":
+		case "measurement":
+			measurement = level
+		case "field":
+			field = level
+		default:
+			tags[t[i]] = level
+		}
+	}
+	return measurement, tags, field
+}
+
+// MQTTSubscriptionService defines the crud service for MQTTSubscription.
+type MQTTSubscriptionService interface {
+	ListSubscriptions(ctx context.Context, filter MQTTSubscriptionFilter) ([]MQTTSubscription, error)
+	AddSubscription(ctx context.Context, s *MQTTSubscription, userID platform.ID) error
+	GetSubscriptionByID(ctx context.Context, id platform.ID) (*MQTTSubscription, error)
+	RemoveSubscription(ctx context.Context, id platform.ID) error
+	UpdateSubscription(ctx context.Context, s *MQTTSubscription, userID platform.ID) (*MQTTSubscription, error)
+}
+
+// MQTTSubscriptionFilter represents a set of filter that restrict the returned results.
+type MQTTSubscriptionFilter struct {
+	Name  *string      `json:"name"`
+	OrgID *platform.ID `json:"orgID"`
+}
@@ -0,0 +1,158 @@
+package mqtt_test
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+)
+
+// broker is a minimal MQTT broker delivering the messages published at QoS 0
+// to the clients subscribed to matching topics.
+type broker struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	conns map[net.Conn][]string
+	subs  chan []string
+}
+
+func newBroker(t *testing.T) *broker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &broker{ln: ln, conns: make(map[net.Conn][]string), subs: make(chan []string, 10)}
+	go b.serve()
+	t.Cleanup(b.close)
+	return b
+}
+
+func (b *broker) URL() string {
+	return "tcp://" + b.ln.Addr().String()
+}
+
+func (b *broker) close() {
+	b.ln.Close()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for conn := range b.conns {
+		conn.Close()
+	}
+}
+
+// disconnectAll drops the connections of the clients, as a broker restart
+// would.
+func (b *broker) disconnectAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for conn := range b.conns {
+		conn.Close()
+		delete(b.conns, conn)
+	}
+}
+
+func (b *broker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		b.mu.Lock()
+		b.conns[conn] = nil
+		b.mu.Unlock()
+		go b.serveConn(conn)
+	}
+}
+
+func (b *broker) serveConn(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		b.mu.Lock()
+		delete(b.conns, conn)
+		b.mu.Unlock()
+	}()
+	for {
+		cp, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		switch p := cp.(type) {
+		case *packets.ConnectPacket:
+			ack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket)
+			ack.ReturnCode = packets.Accepted
+			err = b.write(conn, ack)
+		case *packets.SubscribePacket:
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = make([]byte, len(p.Topics))
+			b.mu.Lock()
+			b.conns[conn] = append(b.conns[conn], p.Topics...)
+			b.mu.Unlock()
+			if err = b.write(conn, ack); err == nil {
+				b.subs <- p.Topics
+			}
+		case *packets.PingreqPacket:
+			err = b.write(conn, packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (b *broker) write(conn net.Conn, cp packets.ControlPacket) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return cp.Write(conn)
+}
+
+// publish delivers payload to the clients subscribed to topic.
+func (b *broker) publish(topic, payload string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for conn, filters := range b.conns {
+		for _, f := range filters {
+			if matchTopic(f, topic) {
+				p := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+				p.TopicName = topic
+				p.Payload = []byte(payload)
+				_ = p.Write(conn)
+				break
+			}
+		}
+	}
+}
+
+// matchTopic reports whether topic matches filter, with the + and #
+// wildcards.
+func matchTopic(filter, topic string) bool {
+	fs, ts := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return true
+		}
+		if i >= len(ts) || (f != "+" && f != ts[i]) {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
+
+// waitSubscribed waits for a client to subscribe.
+func (b *broker) waitSubscribed(t *testing.T) []string {
+	t.Helper()
+	select {
+	case topics := <-b.subs:
+		return topics
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a subscription")
+		return nil
+	}
+}
@@ -0,0 +1,244 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	pctx "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixSubscriptions = "/api/v2/mqtt/subscriptions"
+
+// Handler serves the MQTT subscriptions API.
+type Handler struct {
+	chi.Router
+
+	api    *kithttp.API
+	log    *zap.Logger
+	subSvc influxdb.MQTTSubscriptionService
+	orgSvc influxdb.OrganizationService
+}
+
+// NewHTTPHandler constructs a new http server for the MQTT subscriptions.
+func NewHTTPHandler(log *zap.Logger, subSvc influxdb.MQTTSubscriptionService, orgSvc influxdb.OrganizationService) *Handler {
+	h := &Handler{
+		api:    kithttp.NewAPI(kithttp.WithLog(log)),
+		log:    log,
+		subSvc: subSvc,
+		orgSvc: orgSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetSubscriptions)
+		r.Post("/", h.handlePostSubscription)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetSubscription)
+			r.Patch("/", h.handlePatchSubscription)
+			r.Delete("/", h.handleDeleteSubscription)
+		})
+	})
+
+	h.Router = r
+	return h
+}
+
+// Prefix provides the prefix to this route tree.
+func (h *Handler) Prefix() string {
+	return prefixSubscriptions
+}
+
+// subscriptionRequest is a subscription whose organization may be given by
+// name.
+type subscriptionRequest struct {
+	influxdb.MQTTSubscription
+	Org string `json:"org,omitempty"`
+}
+
+type getSubscriptionsResponse struct {
+	Subscriptions []influxdb.MQTTSubscription `json:"subscriptions"`
+}
+
+// redact removes the password from the subscriptions returned.
+func redact(sub influxdb.MQTTSubscription) influxdb.MQTTSubscription {
+	sub.Password = ""
+	return sub
+}
+
+func (h *Handler) handleGetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	var filter influxdb.MQTTSubscriptionFilter
+	if name := q.Get("name"); name != "" {
+		filter.Name = &name
+	}
+	if q.Get("orgID") != "" || q.Get("org") != "" {
+		var orgID platform.ID
+		if id := q.Get("orgID"); id != "" {
+			if err := orgID.DecodeFromString(id); err != nil {
+				h.api.Err(w, r, &errors.Error{
+					Code: errors.EInvalid,
+					Msg:  "invalid org ID",
+					Err:  err,
+				})
+				return
+			}
+		}
+		orgID, err := h.findOrgID(r, orgID, q.Get("org"))
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.OrgID = &orgID
+	}
+
+	subs, err := h.subSvc.ListSubscriptions(ctx, filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	for i := range subs {
+		subs[i] = redact(subs[i])
+	}
+	h.api.Respond(w, r, http.StatusOK, getSubscriptionsResponse{Subscriptions: subs})
+}
+
+func (h *Handler) handlePostSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req subscriptionRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	orgID, err := h.findOrgID(r, req.OrgID, req.Org)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	sub := req.MQTTSubscription
+	sub.OrgID = orgID
+
+	userID, err := pctx.GetUserID(ctx)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if err := h.subSvc.AddSubscription(ctx, &sub, userID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusCreated, redact(sub))
+}
+
+func (h *Handler) handleGetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := decodeID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	sub, err := h.subSvc.GetSubscriptionByID(r.Context(), id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, redact(*sub))
+}
+
+// handlePatchSubscription replaces the subscription, keeping the stored
+// password when none is given, as it is never returned.
+func (h *Handler) handlePatchSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := decodeID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	var upd influxdb.MQTTSubscription
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		})
+		return
+	}
+	upd.ID = id
+
+	current, err := h.subSvc.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if upd.Password == "" && upd.Username == current.Username {
+		upd.Password = current.Password
+	}
+
+	userID, err := pctx.GetUserID(ctx)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	sub, err := h.subSvc.UpdateSubscription(ctx, &upd, userID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, redact(*sub))
+}
+
+func (h *Handler) handleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := decodeID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if err := h.subSvc.RemoveSubscription(r.Context(), id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findOrgID returns id if it is valid, or the ID of the organization named
+// name.
+func (h *Handler) findOrgID(r *http.Request, id platform.ID, name string) (platform.ID, error) {
+	if id.Valid() {
+		return id, nil
+	}
+	if name == "" {
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "either 'org' or 'orgID' must be provided",
+		}
+	}
+	org, err := h.orgSvc.FindOrganization(r.Context(), influxdb.OrganizationFilter{Name: &name})
+	if err != nil {
+		return 0, err
+	}
+	return org.ID, nil
+}
+
+func decodeID(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, ErrInvalidSubscriptionID
+	}
+	return id, nil
+}
@@ -0,0 +1,102 @@
+package mqtt_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	pctx "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/mqtt"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return &influxdb.Organization{ID: orgID, Name: *filter.Name}, nil
+	}
+	h := mqtt.NewHTTPHandler(zaptest.NewLogger(t), svc, orgs)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(pctx.SetAuthorizer(r.Context(), &influxdb.Authorization{UserID: 1}))
+		http.StripPrefix(h.Prefix(), h).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	do := func(method, path string, body interface{}, status int, v interface{}) {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		req, err := http.NewRequest(method, server.URL+h.Prefix()+path, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("%s %s: unexpected status %d, want %d", method, path, resp.StatusCode, status)
+		}
+		if v != nil {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	req := map[string]interface{}{
+		"name":      "ups",
+		"org":       "my-org",
+		"bucketID":  bucketID.String(),
+		"brokerURL": "tcp://localhost:1883",
+		"username":  "influx",
+		"password":  "secret",
+		"topics":    []string{"apc/#"},
+		"format":    "value",
+	}
+	var created influxdb.MQTTSubscription
+	do(http.MethodPost, "/", req, http.StatusCreated, &created)
+	if created.OrgID != orgID || created.Password != "" {
+		t.Fatalf("unexpected subscription %+v", created)
+	}
+
+	var list struct {
+		Subscriptions []influxdb.MQTTSubscription `json:"subscriptions"`
+	}
+	do(http.MethodGet, "/?org=my-org", nil, http.StatusOK, &list)
+	if len(list.Subscriptions) != 1 || list.Subscriptions[0].Password != "" {
+		t.Fatalf("unexpected subscriptions %+v", list.Subscriptions)
+	}
+
+	// The stored password is kept when the update has none.
+	upd := created
+	upd.QoS = 2
+	var updated influxdb.MQTTSubscription
+	do(http.MethodPatch, "/"+created.ID.String(), upd, http.StatusOK, &updated)
+	stored, err := svc.GetSubscriptionByID(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.QoS != 2 || stored.Password != "secret" {
+		t.Fatalf("unexpected stored subscription %+v", stored)
+	}
+
+	do(http.MethodPost, "/", map[string]interface{}{"name": "invalid", "org": "my-org"}, http.StatusBadRequest, nil)
+	do(http.MethodGet, "/invalid", nil, http.StatusBadRequest, nil)
+	do(http.MethodDelete, "/"+created.ID.String(), nil, http.StatusNoContent, nil)
+	do(http.MethodGet, "/"+created.ID.String(), nil, http.StatusNotFound, nil)
+	do(http.MethodGet, "/"+platform.ID(0x5555).String(), nil, http.StatusNotFound, nil)
+}
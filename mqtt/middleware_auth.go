@@ -0,0 +1,83 @@
+package mqtt
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+var _ influxdb.MQTTSubscriptionService = (*AuthorizedService)(nil)
+
+// AuthorizedService authorizes the subscriptions as scrapers, and requires
+// write access to the buckets they write to.
+type AuthorizedService struct {
+	s influxdb.MQTTSubscriptionService
+}
+
+// NewAuthorizedService wraps s with authorization.
+func NewAuthorizedService(s influxdb.MQTTSubscriptionService) *AuthorizedService {
+	return &AuthorizedService{s: s}
+}
+
+func (svc *AuthorizedService) ListSubscriptions(ctx context.Context, filter influxdb.MQTTSubscriptionFilter) ([]influxdb.MQTTSubscription, error) {
+	subs, err := svc.s.ListSubscriptions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	subs, _, err = authorizer.AuthorizeFindMQTTSubscriptions(ctx, subs)
+	return subs, err
+}
+
+func (svc *AuthorizedService) AddSubscription(ctx context.Context, sub *influxdb.MQTTSubscription, userID platform.ID) error {
+	if _, _, err := authorizer.AuthorizeCreate(ctx, influxdb.ScraperResourceType, sub.OrgID); err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, sub.BucketID, sub.OrgID); err != nil {
+		return err
+	}
+	return svc.s.AddSubscription(ctx, sub, userID)
+}
+
+func (svc *AuthorizedService) GetSubscriptionByID(ctx context.Context, id platform.ID) (*influxdb.MQTTSubscription, error) {
+	sub, err := svc.s.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.ScraperResourceType, id, sub.OrgID); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (svc *AuthorizedService) UpdateSubscription(ctx context.Context, upd *influxdb.MQTTSubscription, userID platform.ID) (*influxdb.MQTTSubscription, error) {
+	sub, err := svc.s.GetSubscriptionByID(ctx, upd.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.ScraperResourceType, sub.ID, sub.OrgID); err != nil {
+		return nil, err
+	}
+	bucketID := sub.BucketID
+	if upd.BucketID.Valid() {
+		bucketID = upd.BucketID
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, bucketID, sub.OrgID); err != nil {
+		return nil, err
+	}
+	// The organization of a subscription cannot be changed.
+	upd.OrgID = sub.OrgID
+	return svc.s.UpdateSubscription(ctx, upd, userID)
+}
+
+func (svc *AuthorizedService) RemoveSubscription(ctx context.Context, id platform.ID) error {
+	sub, err := svc.s.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.ScraperResourceType, sub.ID, sub.OrgID); err != nil {
+		return err
+	}
+	return svc.s.RemoveSubscription(ctx, id)
+}
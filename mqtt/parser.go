@@ -0,0 +1,223 @@
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const (
+	// defaultMeasurement is the measurement of value payloads when the topic
+	// template gives none, as in the Telegraf mqtt_consumer input.
+	defaultMeasurement = "mqtt_consumer"
+	// defaultField is the field of value payloads when the topic template
+	// gives none.
+	defaultField = "value"
+	// topicTag is the tag holding the topic of value payloads without a
+	// topic template.
+	topicTag = "topic"
+)
+
+// Parser parses the payloads of the messages of a subscription into points.
+type Parser struct {
+	format    influxdb.MQTTFormat
+	precision string
+	template  influxdb.MQTTTopicTemplate
+	json      *influxdb.MQTTJSONMapping
+}
+
+// NewParser returns the parser of the payloads of sub.
+func NewParser(sub *influxdb.MQTTSubscription) (*Parser, error) {
+	tmpl, err := influxdb.ParseMQTTTopicTemplate(sub.TopicTemplate)
+	if err != nil {
+		return nil, err
+	}
+	precision := sub.Precision
+	if precision == "" {
+		precision = "ns"
+	}
+	return &Parser{
+		format:    sub.Format,
+		precision: precision,
+		template:  tmpl,
+		json:      sub.JSON,
+	}, nil
+}
+
+// Parse returns the points of the payload of a message received on topic at
+// now.
+func (p *Parser) Parse(topic string, payload []byte, now time.Time) ([]models.Point, error) {
+	switch p.format {
+	case influxdb.MQTTFormatLineProtocol:
+		return models.ParsePointsWithPrecision(payload, now, p.precision)
+	case influxdb.MQTTFormatJSON:
+		return p.parseJSON(topic, payload, now)
+	case influxdb.MQTTFormatValue:
+		return p.parseValue(topic, payload, now)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", p.format)
+	}
+}
+
+// topic returns the measurement, tags and field the topic maps to.
+func (p *Parser) topic(topic string) (string, map[string]string, string) {
+	if p.template == nil {
+		return "", map[string]string{topicTag: topic}, ""
+	}
+	return p.template.Apply(topic)
+}
+
+// parseValue parses a single float, boolean or string value.
+func (p *Parser) parseValue(topic string, payload []byte, now time.Time) ([]models.Point, error) {
+	measurement, tags, field := p.topic(topic)
+	if measurement == "" {
+		measurement = defaultMeasurement
+	}
+	if field == "" {
+		field = defaultField
+	}
+
+	s := string(bytes.TrimSpace(payload))
+	if s == "" {
+		return nil, fmt.Errorf("empty payload")
+	}
+	var v interface{} = s
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		v = f
+	} else if b, err := strconv.ParseBool(s); err == nil {
+		v = b
+	}
+
+	pt, err := models.NewPoint(measurement, models.NewTags(tags), models.Fields{field: v}, now)
+	if err != nil {
+		return nil, err
+	}
+	return []models.Point{pt}, nil
+}
+
+// parseJSON maps a JSON object, or each object of a JSON array, to a point.
+func (p *Parser) parseJSON(topic string, payload []byte, now time.Time) ([]models.Point, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	objs, ok := v.([]interface{})
+	if !ok {
+		objs = []interface{}{v}
+	}
+	points := make([]models.Point, 0, len(objs))
+	for _, obj := range objs {
+		pt, err := p.jsonPoint(topic, obj, now)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, pt)
+	}
+	return points, nil
+}
+
+func (p *Parser) jsonPoint(topic string, obj interface{}, now time.Time) (models.Point, error) {
+	measurement, tags, _ := p.topic(topic)
+	if p.json.Measurement != "" {
+		measurement = p.json.Measurement
+	}
+	if p.json.MeasurementPath != "" {
+		v, ok := lookup(obj, p.json.MeasurementPath).(string)
+		if !ok {
+			return nil, fmt.Errorf("no string measurement at %q", p.json.MeasurementPath)
+		}
+		measurement = v
+	}
+	if measurement == "" {
+		measurement = defaultMeasurement
+	}
+
+	for _, t := range p.json.Tags {
+		switch v := lookup(obj, t.Path).(type) {
+		case nil:
+		case string:
+			tags[t.Name] = v
+		case json.Number:
+			tags[t.Name] = v.String()
+		case bool:
+			tags[t.Name] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("tag %s at %q is not a scalar", t.Name, t.Path)
+		}
+	}
+
+	fields := make(models.Fields, len(p.json.Fields))
+	for _, f := range p.json.Fields {
+		switch v := lookup(obj, f.Path).(type) {
+		case nil:
+		case string, bool:
+			fields[f.Name] = v
+		case json.Number:
+			n, err := v.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", f.Name, err)
+			}
+			fields[f.Name] = n
+		default:
+			return nil, fmt.Errorf("field %s at %q is not a scalar", f.Name, f.Path)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no field found")
+	}
+
+	ts := now
+	if p.json.TimestampPath != "" {
+		var err error
+		if ts, err = p.timestamp(lookup(obj, p.json.TimestampPath)); err != nil {
+			return nil, err
+		}
+	}
+	return models.NewPoint(measurement, models.NewTags(tags), fields, ts)
+}
+
+// timestamp converts an integer timestamp in the precision of the parser, or
+// an RFC 3339 string.
+func (p *Parser) timestamp(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %s: %v", v, err)
+		}
+		return time.Unix(0, n*models.GetPrecisionMultiplier(p.precision)).UTC(), nil
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+	}
+}
+
+// lookup returns the value at path in v, or nil. The parts of the path are
+// object keys, or indexes of arrays.
+func lookup(v interface{}, path string) interface{} {
+	for _, part := range strings.Split(path, ".") {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			v = vv[part]
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(vv) {
+				return nil
+			}
+			v = vv[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
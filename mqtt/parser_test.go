@@ -0,0 +1,120 @@
+package mqtt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mqtt"
+)
+
+func TestParser_Parse(t *testing.T) {
+	now := time.Unix(1600000000, 0).UTC()
+	tests := []struct {
+		name    string
+		sub     influxdb.MQTTSubscription
+		topic   string
+		payload string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "line protocol",
+			sub:     influxdb.MQTTSubscription{Format: influxdb.MQTTFormatLineProtocol, Precision: "s"},
+			topic:   "ups",
+			payload: "ups,name=rack1 charge=97 1600000001\nups,name=rack2 charge=50",
+			want: []string{
+				"ups,name=rack1 charge=97 1600000001000000000",
+				"ups,name=rack2 charge=50 1600000000000000000",
+			},
+		},
+		{
+			name:    "value without template",
+			sub:     influxdb.MQTTSubscription{Format: influxdb.MQTTFormatValue},
+			topic:   "apc/rack1/charge",
+			payload: " 42.5\n",
+			want:    []string{"mqtt_consumer,topic=apc/rack1/charge value=42.5 1600000000000000000"},
+		},
+		{
+			name:    "value with template",
+			sub:     influxdb.MQTTSubscription{Format: influxdb.MQTTFormatValue, TopicTemplate: "_/ups/measurement/field"},
+			topic:   "apc/rack1/battery/online",
+			payload: "true",
+			want:    []string{"battery,ups=rack1 online=true 1600000000000000000"},
+		},
+		{
+			name:    "empty value",
+			sub:     influxdb.MQTTSubscription{Format: influxdb.MQTTFormatValue},
+			topic:   "apc",
+			wantErr: true,
+		},
+		{
+			name: "json",
+			sub: influxdb.MQTTSubscription{
+				Format:        influxdb.MQTTFormatJSON,
+				Precision:     "ms",
+				TopicTemplate: "_/ups",
+				JSON: &influxdb.MQTTJSONMapping{
+					MeasurementPath: "kind",
+					TimestampPath:   "time",
+					Tags:            []influxdb.MQTTJSONValue{{Name: "model", Path: "model"}},
+					Fields: []influxdb.MQTTJSONValue{
+						{Name: "charge", Path: "battery.charge"},
+						{Name: "load", Path: "outputs.1.load"},
+						{Name: "status", Path: "status"},
+						{Name: "missing", Path: "battery.missing"},
+					},
+				},
+			},
+			topic: "apc/rack1",
+			payload: `[
+				{"kind": "ups", "time": 1600000000123, "model": "SMT1500", "status": "ONLINE", "battery": {"charge": 100}, "outputs": [{"load": 1}, {"load": 12.5}]},
+				{"kind": "ups", "time": "2020-09-13T12:26:41Z", "model": "SMT1500", "battery": {"charge": 99}}
+			]`,
+			want: []string{
+				`ups,model=SMT1500,ups=rack1 charge=100,load=12.5,status="ONLINE" 1600000000123000000`,
+				"ups,model=SMT1500,ups=rack1 charge=99 1600000001000000000",
+			},
+		},
+		{
+			name: "json without fields",
+			sub: influxdb.MQTTSubscription{
+				Format: influxdb.MQTTFormatJSON,
+				JSON:   &influxdb.MQTTJSONMapping{Fields: []influxdb.MQTTJSONValue{{Name: "charge", Path: "charge"}}},
+			},
+			topic:   "apc",
+			payload: `{"load": 1}`,
+			wantErr: true,
+		},
+		{
+			name: "invalid json",
+			sub: influxdb.MQTTSubscription{
+				Format: influxdb.MQTTFormatJSON,
+				JSON:   &influxdb.MQTTJSONMapping{Fields: []influxdb.MQTTJSONValue{{Name: "charge", Path: "charge"}}},
+			},
+			topic:   "apc",
+			payload: `{"charge":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := mqtt.NewParser(&tt.sub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			points, err := p.Parse(tt.topic, []byte(tt.payload), now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(points) != len(tt.want) {
+				t.Fatalf("unexpected points %v, want %v", points, tt.want)
+			}
+			for i, p := range points {
+				if got := p.String(); got != tt.want[i] {
+					t.Errorf("unexpected point:\n got %s\nwant %s", got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package mqtt subscribes to the topics of MQTT brokers and writes the points
+// parsed from the messages received to buckets. The subscriptions are stored
+// in the kv store and managed through the /api/v2/mqtt/subscriptions API.
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/snowflake"
+)
+
+var subscriptionsBucket = []byte("mqttsubscriptionsv1")
+
+var (
+	// ErrSubscriptionNotFound is used when the subscription cannot be found.
+	ErrSubscriptionNotFound = &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  "MQTT subscription not found",
+	}
+
+	// ErrInvalidSubscriptionID is used when the service was provided
+	// an invalid ID format.
+	ErrInvalidSubscriptionID = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "provided MQTT subscription ID has invalid format",
+	}
+)
+
+// ErrInternalService is used when the error comes from an internal system.
+func ErrInternalService(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.EInternal,
+		Msg:  "unexpected error in the MQTT subscription store",
+		Err:  err,
+		Op:   "mqtt/service",
+	}
+}
+
+var _ influxdb.MQTTSubscriptionService = (*Service)(nil)
+
+// Service stores the MQTT subscriptions in the kv store.
+type Service struct {
+	store kv.Store
+	IDGen platform.IDGenerator
+
+	bucketSvc influxdb.BucketService
+}
+
+// NewService returns a Service storing subscriptions in st. The buckets of
+// the subscriptions must exist in bucketSvc.
+func NewService(bucketSvc influxdb.BucketService, st kv.Store) *Service {
+	return &Service{
+		store:     st,
+		IDGen:     snowflake.NewDefaultIDGenerator(),
+		bucketSvc: bucketSvc,
+	}
+}
+
+// ListSubscriptions returns the subscriptions matching filter.
+func (s *Service) ListSubscriptions(ctx context.Context, filter influxdb.MQTTSubscriptionFilter) ([]influxdb.MQTTSubscription, error) {
+	subs := []influxdb.MQTTSubscription{}
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(subscriptionsBucket)
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		cur, err := b.ForwardCursor(nil)
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		return kv.WalkCursor(ctx, cur, func(_, v []byte) (bool, error) {
+			var sub influxdb.MQTTSubscription
+			if err := json.Unmarshal(v, &sub); err != nil {
+				return false, ErrInternalService(err)
+			}
+			if filter.Name != nil && sub.Name != *filter.Name {
+				return true, nil
+			}
+			if filter.OrgID != nil && sub.OrgID != *filter.OrgID {
+				return true, nil
+			}
+			subs = append(subs, sub)
+			return true, nil
+		})
+	})
+	return subs, err
+}
+
+// AddSubscription stores a new subscription, setting its ID.
+func (s *Service) AddSubscription(ctx context.Context, sub *influxdb.MQTTSubscription, userID platform.ID) error {
+	if err := s.validate(ctx, sub); err != nil {
+		return err
+	}
+	sub.ID = s.IDGen.ID()
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		return putSubscription(tx, sub)
+	})
+}
+
+// GetSubscriptionByID returns the subscription with id.
+func (s *Service) GetSubscriptionByID(ctx context.Context, id platform.ID) (*influxdb.MQTTSubscription, error) {
+	var sub *influxdb.MQTTSubscription
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		var err error
+		sub, err = findSubscriptionByID(tx, id)
+		return err
+	})
+	return sub, err
+}
+
+// UpdateSubscription replaces the stored subscription with the ID of update.
+// The organization and bucket are kept when unset in update.
+func (s *Service) UpdateSubscription(ctx context.Context, update *influxdb.MQTTSubscription, userID platform.ID) (*influxdb.MQTTSubscription, error) {
+	if !update.ID.Valid() {
+		return nil, ErrInvalidSubscriptionID
+	}
+	current, err := s.GetSubscriptionByID(ctx, update.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !update.OrgID.Valid() {
+		update.OrgID = current.OrgID
+	}
+	if !update.BucketID.Valid() {
+		update.BucketID = current.BucketID
+	}
+	if err := s.validate(ctx, update); err != nil {
+		return nil, err
+	}
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		if _, err := findSubscriptionByID(tx, update.ID); err != nil {
+			return err
+		}
+		return putSubscription(tx, update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// RemoveSubscription deletes the subscription with id.
+func (s *Service) RemoveSubscription(ctx context.Context, id platform.ID) error {
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		if _, err := findSubscriptionByID(tx, id); err != nil {
+			return err
+		}
+		encID, _ := id.Encode()
+		b, err := tx.Bucket(subscriptionsBucket)
+		if err != nil {
+			return ErrInternalService(err)
+		}
+		if err := b.Delete(encID); err != nil {
+			return ErrInternalService(err)
+		}
+		return nil
+	})
+}
+
+// validate checks the subscription, and that its bucket exists in its
+// organization.
+func (s *Service) validate(ctx context.Context, sub *influxdb.MQTTSubscription) error {
+	if err := sub.Valid(); err != nil {
+		return err
+	}
+	if !sub.OrgID.Valid() {
+		return &errors.Error{Code: errors.EInvalid, Msg: "provided organization ID has invalid format"}
+	}
+	if !sub.BucketID.Valid() {
+		return &errors.Error{Code: errors.EInvalid, Msg: "provided bucket ID has invalid format"}
+	}
+	b, err := s.bucketSvc.FindBucketByID(ctx, sub.BucketID)
+	if err != nil {
+		return err
+	}
+	if b.OrgID != sub.OrgID {
+		return &errors.Error{Code: errors.EInvalid, Msg: "bucket does not belong to the organization"}
+	}
+	return nil
+}
+
+func findSubscriptionByID(tx kv.Tx, id platform.ID) (*influxdb.MQTTSubscription, error) {
+	encID, err := id.Encode()
+	if err != nil {
+		return nil, ErrInvalidSubscriptionID
+	}
+	b, err := tx.Bucket(subscriptionsBucket)
+	if err != nil {
+		return nil, ErrInternalService(err)
+	}
+	v, err := b.Get(encID)
+	if kv.IsNotFound(err) {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, ErrInternalService(err)
+	}
+	var sub influxdb.MQTTSubscription
+	if err := json.Unmarshal(v, &sub); err != nil {
+		return nil, ErrInternalService(err)
+	}
+	return &sub, nil
+}
+
+func putSubscription(tx kv.Tx, sub *influxdb.MQTTSubscription) error {
+	encID, err := sub.ID.Encode()
+	if err != nil {
+		return ErrInvalidSubscriptionID
+	}
+	v, err := json.Marshal(sub)
+	if err != nil {
+		return ErrInternalService(err)
+	}
+	b, err := tx.Bucket(subscriptionsBucket)
+	if err != nil {
+		return ErrInternalService(err)
+	}
+	if err := b.Put(encID, v); err != nil {
+		return ErrInternalService(err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package mqtt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mqtt"
+)
+
+func validSubscription() *influxdb.MQTTSubscription {
+	return &influxdb.MQTTSubscription{
+		Name:      "ups",
+		OrgID:     orgID,
+		BucketID:  bucketID,
+		BrokerURL: "tcp://localhost:1883",
+		Topics:    []string{"apc/#"},
+		QoS:       1,
+		Format:    influxdb.MQTTFormatLineProtocol,
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	sub := validSubscription()
+	if err := svc.AddSubscription(ctx, sub, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !sub.ID.Valid() {
+		t.Fatal("expected an ID to be set")
+	}
+	other := validSubscription()
+	other.Name = "other"
+	if err := svc.AddSubscription(ctx, other, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	name := "ups"
+	subs, err := svc.ListSubscriptions(ctx, influxdb.MQTTSubscriptionFilter{Name: &name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].ID != sub.ID {
+		t.Fatalf("unexpected subscriptions %+v", subs)
+	}
+	otherOrg := platform.ID(0x3333)
+	if subs, err := svc.ListSubscriptions(ctx, influxdb.MQTTSubscriptionFilter{OrgID: &otherOrg}); err != nil || len(subs) != 0 {
+		t.Fatalf("unexpected subscriptions %+v: %v", subs, err)
+	}
+
+	upd := *sub
+	upd.OrgID, upd.BucketID = 0, 0
+	upd.Topics = []string{"apc/+/battery/#"}
+	got, err := svc.UpdateSubscription(ctx, &upd, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OrgID != orgID || got.BucketID != bucketID || got.Topics[0] != "apc/+/battery/#" {
+		t.Fatalf("unexpected update %+v", got)
+	}
+
+	if err := svc.RemoveSubscription(ctx, sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetSubscriptionByID(ctx, sub.ID); errors.ErrorCode(err) != errors.ENotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := svc.RemoveSubscription(ctx, sub.ID); errors.ErrorCode(err) != errors.ENotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestService_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(s *influxdb.MQTTSubscription)
+	}{
+		{"no name", func(s *influxdb.MQTTSubscription) { s.Name = "" }},
+		{"invalid broker", func(s *influxdb.MQTTSubscription) { s.BrokerURL = "localhost:1883" }},
+		{"unsupported scheme", func(s *influxdb.MQTTSubscription) { s.BrokerURL = "http://localhost:1883" }},
+		{"no topics", func(s *influxdb.MQTTSubscription) { s.Topics = nil }},
+		{"invalid qos", func(s *influxdb.MQTTSubscription) { s.QoS = 3 }},
+		{"invalid precision", func(s *influxdb.MQTTSubscription) { s.Precision = "h" }},
+		{"invalid template", func(s *influxdb.MQTTSubscription) { s.TopicTemplate = "_//field" }},
+		{"repeated template tag", func(s *influxdb.MQTTSubscription) { s.TopicTemplate = "ups/ups" }},
+		{"unsupported format", func(s *influxdb.MQTTSubscription) { s.Format = "csv" }},
+		{"json without mapping", func(s *influxdb.MQTTSubscription) { s.Format = influxdb.MQTTFormatJSON }},
+		{"unknown bucket", func(s *influxdb.MQTTSubscription) { s.BucketID = 0x4444 }},
+		{"bucket of another org", func(s *influxdb.MQTTSubscription) { s.OrgID = 0x3333 }},
+	}
+	svc := newTestService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := validSubscription()
+			tt.update(sub)
+			if err := svc.AddSubscription(context.Background(), sub, 1); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+var _ influxdb.MQTTSubscriptionService = (*mqtt.Service)(nil)
@@ -0,0 +1,242 @@
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSyncInterval is how often the subscriber reloads the
+	// subscriptions.
+	DefaultSyncInterval = 10 * time.Second
+	// DefaultMinBackoff is the delay before retrying the first failed
+	// connection to a broker. It doubles with each failure.
+	DefaultMinBackoff = time.Second
+	// DefaultMaxBackoff bounds the delay between connection attempts.
+	DefaultMaxBackoff = 2 * time.Minute
+
+	connectTimeout = 30 * time.Second
+	writeTimeout   = 30 * time.Second
+)
+
+// Subscriber runs a client for each subscription stored, writing the points
+// parsed from the messages received to the buckets of the subscriptions.
+type Subscriber struct {
+	SyncInterval time.Duration
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+
+	log    *zap.Logger
+	subs   influxdb.MQTTSubscriptionService
+	writer storage.PointsWriter
+
+	clients map[platform.ID]*client
+}
+
+// NewSubscriber returns a subscriber to the subscriptions of subs.
+func NewSubscriber(log *zap.Logger, subs influxdb.MQTTSubscriptionService, writer storage.PointsWriter) *Subscriber {
+	return &Subscriber{
+		SyncInterval: DefaultSyncInterval,
+		MinBackoff:   DefaultMinBackoff,
+		MaxBackoff:   DefaultMaxBackoff,
+		log:          log,
+		subs:         subs,
+		writer:       writer,
+		clients:      make(map[platform.ID]*client),
+	}
+}
+
+// Run syncs the clients with the subscriptions stored until ctx is done, and
+// then disconnects them.
+func (s *Subscriber) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.SyncInterval)
+	defer ticker.Stop()
+	defer s.stopAll()
+
+	for {
+		if err := s.sync(ctx); err != nil && ctx.Err() == nil {
+			s.log.Error("Failed to load MQTT subscriptions", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync starts the clients of new subscriptions, stops the ones of removed
+// subscriptions, and restarts the ones of the subscriptions updated.
+func (s *Subscriber) sync(ctx context.Context) error {
+	subs, err := s.subs.ListSubscriptions(ctx, influxdb.MQTTSubscriptionFilter{})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[platform.ID]bool, len(subs))
+	for i := range subs {
+		sub := subs[i]
+		seen[sub.ID] = true
+		if c, ok := s.clients[sub.ID]; ok {
+			if reflect.DeepEqual(c.sub, sub) {
+				continue
+			}
+			c.stop()
+			delete(s.clients, sub.ID)
+		}
+
+		c, err := s.newClient(sub)
+		if err != nil {
+			s.log.Error("Invalid MQTT subscription", zap.Stringer("subscription_id", sub.ID), zap.Error(err))
+			continue
+		}
+		s.clients[sub.ID] = c
+		c.start()
+	}
+
+	for id, c := range s.clients {
+		if !seen[id] {
+			c.stop()
+			delete(s.clients, id)
+		}
+	}
+	return nil
+}
+
+func (s *Subscriber) stopAll() {
+	for id, c := range s.clients {
+		c.stop()
+		delete(s.clients, id)
+	}
+}
+
+// client is the connection of a subscription to its broker.
+type client struct {
+	sub    influxdb.MQTTSubscription
+	log    *zap.Logger
+	parser *Parser
+	writer storage.PointsWriter
+	conn   paho.Client
+
+	minBackoff, maxBackoff time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (s *Subscriber) newClient(sub influxdb.MQTTSubscription) (*client, error) {
+	parser, err := NewParser(&sub)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &client{
+		sub: sub,
+		log: s.log.With(
+			zap.Stringer("subscription_id", sub.ID),
+			zap.String("broker", sub.BrokerURL),
+		),
+		parser:     parser,
+		writer:     s.writer,
+		minBackoff: s.MinBackoff,
+		maxBackoff: s.MaxBackoff,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	clientID := sub.ClientID
+	if clientID == "" {
+		clientID = "influxdb-" + sub.ID.String()
+	}
+	opts := paho.NewClientOptions().
+		AddBroker(sub.BrokerURL).
+		SetClientID(clientID).
+		SetUsername(sub.Username).
+		SetPassword(sub.Password).
+		// Sessions are persisted by the broker for QoS 1 and 2, so that
+		// the messages published while disconnected are received.
+		SetCleanSession(sub.QoS == 0).
+		SetConnectTimeout(connectTimeout).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(s.MaxBackoff).
+		SetOnConnectHandler(c.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			c.log.Warn("Lost connection to MQTT broker", zap.Error(err))
+		})
+	c.conn = paho.NewClient(opts)
+	return c, nil
+}
+
+// start connects to the broker, retrying with an exponential backoff until
+// connected. The client reconnects by itself once connected.
+func (c *client) start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		backoff := c.minBackoff
+		for {
+			t := c.conn.Connect()
+			t.Wait()
+			if t.Error() == nil {
+				return
+			}
+			c.log.Warn("Failed to connect to MQTT broker", zap.Error(t.Error()), zap.Duration("retry_in", backoff))
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > c.maxBackoff {
+				backoff = c.maxBackoff
+			}
+		}
+	}()
+}
+
+func (c *client) stop() {
+	c.cancel()
+	c.wg.Wait()
+	c.conn.Disconnect(250)
+}
+
+// onConnect subscribes to the topics on each connection, as the broker does
+// not keep the subscriptions of clean sessions.
+func (c *client) onConnect(conn paho.Client) {
+	c.log.Info("Connected to MQTT broker")
+	filters := make(map[string]byte, len(c.sub.Topics))
+	for _, t := range c.sub.Topics {
+		filters[t] = c.sub.QoS
+	}
+	t := conn.SubscribeMultiple(filters, c.onMessage)
+	go func() {
+		if t.Wait(); t.Error() != nil {
+			c.log.Error("Failed to subscribe to MQTT topics", zap.Strings("topics", c.sub.Topics), zap.Error(t.Error()))
+		}
+	}()
+}
+
+func (c *client) onMessage(_ paho.Client, msg paho.Message) {
+	points, err := c.parser.Parse(msg.Topic(), msg.Payload(), time.Now().UTC())
+	if err != nil {
+		c.log.Info("Dropping invalid MQTT message", zap.String("topic", msg.Topic()), zap.Error(err))
+	}
+	if len(points) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, writeTimeout)
+	defer cancel()
+	if err := c.writer.WritePoints(ctx, c.sub.OrgID, c.sub.BucketID, points); err != nil {
+		c.log.Error("Failed to write MQTT points", zap.String("topic", msg.Topic()), zap.Error(fmt.Errorf("bucket %s: %w", c.sub.BucketID, err)))
+	}
+}
@@ -0,0 +1,155 @@
+package mqtt_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/mqtt"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	orgID    = platform.ID(0x1111)
+	bucketID = platform.ID(0x2222)
+)
+
+func newTestService(t *testing.T) *mqtt.Service {
+	t.Helper()
+	store := inmem.NewKVStore()
+	if err := all.Up(context.Background(), zaptest.NewLogger(t), store); err != nil {
+		t.Fatal(err)
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		if id != bucketID {
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		}
+		return &influxdb.Bucket{ID: bucketID, OrgID: orgID, Name: "ups"}, nil
+	}
+	return mqtt.NewService(buckets, store)
+}
+
+// pointsWriter collects the points written, without their timestamps.
+type pointsWriter struct {
+	mu      sync.Mutex
+	points  []string
+	written chan struct{}
+}
+
+func newPointsWriter() *pointsWriter {
+	return &pointsWriter{written: make(chan struct{}, 100)}
+}
+
+func (w *pointsWriter) WritePoints(ctx context.Context, org, bucket platform.ID, points []models.Point) error {
+	w.mu.Lock()
+	for _, p := range points {
+		s := p.String()
+		w.points = append(w.points, s[:strings.LastIndex(s, " ")])
+	}
+	w.mu.Unlock()
+	w.written <- struct{}{}
+	return nil
+}
+
+func (w *pointsWriter) wait(t *testing.T, n int) []string {
+	t.Helper()
+	for {
+		w.mu.Lock()
+		points := append([]string(nil), w.points...)
+		w.mu.Unlock()
+		if len(points) >= n {
+			return points
+		}
+		select {
+		case <-w.written:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %d points, got %v", n, points)
+		}
+	}
+}
+
+func TestSubscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := newBroker(t)
+	svc := newTestService(t)
+	sub := &influxdb.MQTTSubscription{
+		Name:          "ups",
+		OrgID:         orgID,
+		BucketID:      bucketID,
+		BrokerURL:     b.URL(),
+		Topics:        []string{"apc/#"},
+		Format:        influxdb.MQTTFormatValue,
+		TopicTemplate: "_/ups/measurement/field",
+	}
+	if err := svc.AddSubscription(ctx, sub, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	w := newPointsWriter()
+	s := mqtt.NewSubscriber(zaptest.NewLogger(t), svc, w)
+	s.SyncInterval = 20 * time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := s.Run(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if topics := b.waitSubscribed(t); len(topics) != 1 || topics[0] != "apc/#" {
+		t.Fatalf("unexpected topics %v", topics)
+	}
+	b.publish("apc/rack1/battery/charge", "97.5")
+	b.publish("apc/rack1/battery/charge", "not a number")
+	got := w.wait(t, 2)
+	want := []string{
+		"battery,ups=rack1 charge=97.5",
+		`battery,ups=rack1 charge="not a number"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected points:\n got %v\nwant %v", got, want)
+	}
+
+	// The client resubscribes once reconnected.
+	b.disconnectAll()
+	b.waitSubscribed(t)
+	b.publish("apc/rack2/input/voltage", "230")
+	if got := w.wait(t, 3); got[2] != "input,ups=rack2 voltage=230" {
+		t.Fatalf("unexpected point after reconnecting: %s", got[2])
+	}
+
+	// The client is restarted when the subscription is updated.
+	sub.Format = influxdb.MQTTFormatJSON
+	sub.TopicTemplate = "_/ups"
+	sub.JSON = &influxdb.MQTTJSONMapping{
+		Measurement: "status",
+		Fields:      []influxdb.MQTTJSONValue{{Name: "load", Path: "outputs.0.load"}},
+	}
+	if _, err := svc.UpdateSubscription(ctx, sub, 1); err != nil {
+		t.Fatal(err)
+	}
+	b.waitSubscribed(t)
+	b.publish("apc/rack3", `{"outputs": [{"load": 12}]}`)
+	if got := w.wait(t, 4); got[3] != "status,ups=rack3 load=12" {
+		t.Fatalf("unexpected point after update: %s", got[3])
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscriber did not stop")
+	}
+}
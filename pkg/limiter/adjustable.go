@@ -0,0 +1,131 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Adjustable is a concurrency limiter like Fixed whose capacity can be changed
+// while tokens are taken. Lowering the capacity below the number of tokens
+// taken prevents tokens from being taken until enough of them are released.
+type Adjustable struct {
+	mu       sync.Mutex
+	capacity int
+	taken    int
+}
+
+func NewAdjustable(limit int) *Adjustable {
+	return &Adjustable{capacity: limit}
+}
+
+// Available returns the number of available tokens that may be taken.
+func (t *Adjustable) Available() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n := t.capacity - t.taken; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// Capacity returns the number of tokens can be taken.
+func (t *Adjustable) Capacity() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.capacity
+}
+
+// SetCapacity changes the number of tokens that can be taken.
+func (t *Adjustable) SetCapacity(limit int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.capacity = limit
+}
+
+// TryTake attempts to take a token and return true if successful, otherwise returns false.
+func (t *Adjustable) TryTake() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.taken >= t.capacity {
+		return false
+	}
+	t.taken++
+	return true
+}
+
+// Release releases a token back to the limiter.
+func (t *Adjustable) Release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.taken--
+}
+
+// AdjustableRate is a Rate whose limit can be changed while it is in use. A
+// limit of zero is unlimited.
+type AdjustableRate struct {
+	mu          sync.RWMutex
+	limiter     *rate.Limiter
+	bytesPerSec int
+	burstLimit  int
+}
+
+// NewAdjustableRate returns a rate limiter with a limit of bytesPerSec and a
+// maximum burst of burstLimit.
+func NewAdjustableRate(bytesPerSec, burstLimit int) *AdjustableRate {
+	r := &AdjustableRate{}
+	r.SetLimit(bytesPerSec, burstLimit)
+	return r
+}
+
+// Limit returns the limit and maximum burst of the rate limiter.
+func (r *AdjustableRate) Limit() (bytesPerSec, burstLimit int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.bytesPerSec, r.burstLimit
+}
+
+// SetLimit changes the limit of the rate limiter to bytesPerSec with a maximum
+// burst of burstLimit. Callers waiting for the previous limit are not woken up.
+func (r *AdjustableRate) SetLimit(bytesPerSec, burstLimit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bytesPerSec, r.burstLimit = bytesPerSec, burstLimit
+	if bytesPerSec <= 0 {
+		r.limiter = nil
+		return
+	}
+	r.limiter = rate.NewLimiter(rate.Limit(bytesPerSec), burstLimit)
+	r.limiter.AllowN(time.Now(), burstLimit) // spend initial burst
+}
+
+// WaitN blocks until n bytes may be written. Requests larger than the current
+// burst, for instance because the limit was lowered, wait for the burst.
+func (r *AdjustableRate) WaitN(ctx context.Context, n int) error {
+	r.mu.RLock()
+	limiter := r.limiter
+	r.mu.RUnlock()
+
+	if limiter == nil {
+		return nil
+	}
+	if burst := limiter.Burst(); n > burst {
+		n = burst
+	}
+	return limiter.WaitN(ctx, n)
+}
+
+// Burst returns the maximum number of bytes that may be written at once.
+func (r *AdjustableRate) Burst() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.limiter == nil {
+		return math.MaxInt32
+	}
+	return r.limiter.Burst()
+}
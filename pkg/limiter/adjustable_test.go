@@ -0,0 +1,72 @@
+package limiter_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/pkg/limiter"
+)
+
+func TestAdjustable_SetCapacity(t *testing.T) {
+	a := limiter.NewAdjustable(2)
+	if !a.TryTake() || !a.TryTake() {
+		t.Fatal("expected to take 2 tokens")
+	}
+	if a.TryTake() {
+		t.Fatal("expected limiter to be exhausted")
+	}
+
+	a.SetCapacity(1)
+	a.Release()
+	if exp, got := 0, a.Available(); exp != got {
+		t.Fatalf("available mismatch: exp %v, got %v", exp, got)
+	}
+	if a.TryTake() {
+		t.Fatal("expected lowered capacity to be exhausted")
+	}
+
+	a.SetCapacity(3)
+	if exp, got := 2, a.Available(); exp != got {
+		t.Fatalf("available mismatch: exp %v, got %v", exp, got)
+	}
+	if !a.TryTake() {
+		t.Fatal("expected to take a token after raising capacity")
+	}
+}
+
+func TestAdjustableRate_SetLimit(t *testing.T) {
+	r := limiter.NewAdjustableRate(0, 0)
+	b := nopWriteCloser{bytes.NewBuffer(nil)}
+	w := limiter.NewWriterWithRate(b, r)
+
+	// Writes are not limited without a limit.
+	start := time.Now()
+	if _, err := w.Write(make([]byte, 1024*1024)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("unlimited write took %v", elapsed)
+	}
+
+	r.SetLimit(100, 100)
+	if bytesPerSec, burst := r.Limit(); bytesPerSec != 100 || burst != 100 {
+		t.Fatalf("limit mismatch: got %d, %d", bytesPerSec, burst)
+	}
+
+	// The initial burst is spent, so writing 50 bytes takes about half a second.
+	start = time.Now()
+	if _, err := w.Write(make([]byte, 50)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("limited write took %v", elapsed)
+	}
+
+	// Requests beyond the burst of a lowered limit don't fail.
+	r.SetLimit(1000, 10)
+	if err := r.WaitN(context.Background(), 20); err != nil {
+		t.Fatal(err)
+	}
+}
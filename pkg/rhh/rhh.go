@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"sort"
 	"time"
+	"unsafe"
 
 	"github.com/cespare/xxhash"
 	"github.com/prometheus/client_golang/prometheus"
@@ -245,6 +246,16 @@ func (m *HashMap) Len() int64 { return m.n }
 // Cap returns the number of key/values set in map.
 func (m *HashMap) Cap() int64 { return m.capacity }
 
+// Bytes estimates the memory footprint of the hash map, in bytes. The memory
+// referenced by values isn't counted.
+func (m *HashMap) Bytes() int {
+	b := cap(m.hashes)*8 + cap(m.elems)*int(unsafe.Sizeof(hashElem{}))
+	for i := range m.elems {
+		b += cap(m.elems[i].key)
+	}
+	return b
+}
+
 // AverageProbeCount returns the average number of probes for each element.
 func (m *HashMap) AverageProbeCount() float64 {
 	var sum float64
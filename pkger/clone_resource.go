@@ -11,6 +11,7 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	ierrors "github.com/influxdata/influxdb/v2/kit/errors"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/notification"
 	icheck "github.com/influxdata/influxdb/v2/notification/check"
 	"github.com/influxdata/influxdb/v2/notification/endpoint"
@@ -87,6 +88,7 @@ type resourceExporter struct {
 	bucketSVC   influxdb.BucketService
 	checkSVC    influxdb.CheckService
 	dashSVC     influxdb.DashboardService
+	ingestSVC   influxdb.IngestRulesService
 	labelSVC    influxdb.LabelService
 	endpointSVC influxdb.NotificationEndpointService
 	ruleSVC     influxdb.NotificationRuleStore
@@ -105,6 +107,7 @@ func newResourceExporter(svc *Service) *resourceExporter {
 		bucketSVC:       svc.bucketSVC,
 		checkSVC:        svc.checkSVC,
 		dashSVC:         svc.dashSVC,
+		ingestSVC:       svc.ingestSVC,
 		labelSVC:        svc.labelSVC,
 		endpointSVC:     svc.endpointSVC,
 		ruleSVC:         svc.ruleSVC,
@@ -238,7 +241,17 @@ func (ex *resourceExporter) resourceCloneToKind(ctx context.Context, r ResourceT
 		}
 
 		for _, bkt := range bkts {
-			mapResource(bkt.OrgID, bkt.ID, KindBucket, BucketToObject(r.Name, *bkt))
+			o := BucketToObject(r.Name, *bkt)
+			if ex.ingestSVC != nil {
+				rules, err := ex.ingestSVC.GetIngestRules(ctx, bkt.ID)
+				if err != nil && errors2.ErrorCode(err) != errors2.ENotFound {
+					return err
+				}
+				if rules != nil && len(rules.Rules) > 0 {
+					o.Spec[fieldBucketIngestRules] = rules.Rules
+				}
+			}
+			mapResource(bkt.OrgID, bkt.ID, KindBucket, o)
 		}
 	case r.Kind.is(KindCheck), r.Kind.is(KindCheckDeadman), r.Kind.is(KindCheckThreshold):
 		filter := influxdb.CheckFilter{}
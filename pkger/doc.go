@@ -19,6 +19,29 @@ file and see a summary of its contents:
 The parser will validate all contents of the template and provide any
 and all fields/entries that failed validation.
 
+A template may depend on other templates by declaring a TemplateDependency
+with the URL or file path of the template to include. Each dependency may
+provide its own env refs, which are only applied to the resources of that
+dependency:
+
+	apiVersion: influxdata.com/v2alpha1
+	kind: TemplateDependency
+	metadata:
+	  name: ups-monitoring
+	spec:
+	  templateURL: https://example.com/templates/ups.yml
+	  envRefs:
+	    bucket-name: site-a-ups
+
+Dependencies are resolved and merged into the template during parsing. A
+dependency cycle, or a resource from a dependency whose name collides with
+another resource of the template, results in an error. Every resource pulled
+in from a dependency remembers the dependency it came from, and stacks record
+it alongside the resource. The sources of the dependencies are not recorded as
+sources of the stack. Dependencies given as local file paths are only read by
+ParseWithLocalDependencies, templates parsed with Parse only read http and
+https dependencies.
+
 If you wish to use the Template type in your transport layer and let the
 the transport layer manage the decoding, then you can run the following
 to validate the template after the raw decoding is done:
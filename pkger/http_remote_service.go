@@ -327,9 +327,10 @@ func convertRespStackResources(resources []RespStackResource) ([]StackResource,
 	out := make([]StackResource, 0, len(resources))
 	for _, r := range resources {
 		sr := StackResource{
-			APIVersion: r.APIVersion,
-			MetaName:   r.MetaName,
-			Kind:       r.Kind,
+			APIVersion:         r.APIVersion,
+			MetaName:           r.MetaName,
+			Kind:               r.Kind,
+			TemplateDependency: r.TemplateDependency,
 		}
 		for _, a := range r.Associations {
 			sr.Associations = append(sr.Associations, StackResourceAssociation(a))
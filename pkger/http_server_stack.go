@@ -83,12 +83,13 @@ type (
 	// of templates in the API. We could add a custom UnmarshalJSON method, but
 	// I would rather keep it obvious and explicit with a separate field.
 	RespStackResource struct {
-		APIVersion   string                   `json:"apiVersion"`
-		ID           string                   `json:"resourceID"`
-		Kind         Kind                     `json:"kind"`
-		MetaName     string                   `json:"templateMetaName"`
-		Associations []RespStackResourceAssoc `json:"associations"`
-		Links        RespStackResourceLinks   `json:"links"`
+		APIVersion         string                   `json:"apiVersion"`
+		ID                 string                   `json:"resourceID"`
+		Kind               Kind                     `json:"kind"`
+		MetaName           string                   `json:"templateMetaName"`
+		TemplateDependency string                   `json:"templateDependency,omitempty"`
+		Associations       []RespStackResourceAssoc `json:"associations"`
+		Links              RespStackResourceLinks   `json:"links"`
 	}
 
 	// RespStackResourceAssoc is the response for a stack resource's associations.
@@ -393,12 +394,13 @@ func convertStackEvent(ev StackEvent) RespStackEvent {
 			asses = append(asses, RespStackResourceAssoc(a))
 		}
 		resources = append(resources, RespStackResource{
-			APIVersion:   r.APIVersion,
-			ID:           r.ID.String(),
-			Kind:         r.Kind,
-			MetaName:     r.MetaName,
-			Links:        stackResLinks(r),
-			Associations: asses,
+			APIVersion:         r.APIVersion,
+			ID:                 r.ID.String(),
+			Kind:               r.Kind,
+			MetaName:           r.MetaName,
+			TemplateDependency: r.TemplateDependency,
+			Links:              stackResLinks(r),
+			Associations:       asses,
 		})
 	}
 
@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -449,6 +450,36 @@ func TestPkgerHTTPServerTemplate(t *testing.T) {
 					},
 					expectedStatusCode: http.StatusBadRequest,
 				},
+				{
+					name:        "file template dependency",
+					contentType: "application/json",
+					reqBody: pkger.ReqApply{
+						DryRun:      true,
+						OrgID:       platform.ID(9000).String(),
+						RawTemplate: dependencyPkg("/etc/passwd"),
+					},
+					expectedStatusCode: http.StatusUnprocessableEntity,
+				},
+				{
+					name:        "valid file template dependency",
+					contentType: "application/json",
+					reqBody: pkger.ReqApply{
+						DryRun:      true,
+						OrgID:       platform.ID(9000).String(),
+						RawTemplate: dependencyPkg(testdataPath(t, "template_dependency_ups.yml")),
+					},
+					expectedStatusCode: http.StatusUnprocessableEntity,
+				},
+				{
+					name:        "relative template dependency of a file source",
+					contentType: "application/json",
+					reqBody: pkger.ReqApply{
+						DryRun:      true,
+						OrgID:       platform.ID(9000).String(),
+						RawTemplate: dependencyPkg("template_dependency_ups.yml", "file://"+testdataPath(t, "template.json")),
+					},
+					expectedStatusCode: http.StatusUnprocessableEntity,
+				},
 			}
 
 			for _, tt := range tests {
@@ -597,6 +628,35 @@ func assertNonZeroApplyResp(t *testing.T, resp pkger.RespApply) {
 	assert.NotNil(t, resp.Summary.Variables)
 }
 
+// dependencyPkg returns a template made of a single template dependency
+// on templateURL.
+func dependencyPkg(templateURL string, sources ...string) pkger.ReqRawTemplate {
+	return pkger.ReqRawTemplate{
+		ContentType: "application/json",
+		Sources:     sources,
+		Template: []byte(fmt.Sprintf(`[
+  {
+    "apiVersion": %q,
+    "kind": "TemplateDependency",
+    "metadata": {
+      "name": "dep"
+    },
+    "spec": {
+      "templateURL": %q
+    }
+  }
+]`, pkger.APIVersion, templateURL)),
+	}
+}
+
+func testdataPath(t *testing.T, name string) string {
+	t.Helper()
+
+	p, err := filepath.Abs(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return p
+}
+
 func bucketPkgKinds(t *testing.T, encoding pkger.Encoding) pkger.ReqRawTemplate {
 	t.Helper()
 
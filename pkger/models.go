@@ -31,6 +31,7 @@ const (
 	KindPackage                       Kind = "Package"
 	KindTask                          Kind = "Task"
 	KindTelegraf                      Kind = "Telegraf"
+	KindTemplateDependency            Kind = "TemplateDependency"
 	KindVariable                      Kind = "Variable"
 )
 
@@ -57,6 +58,7 @@ var kinds = map[Kind]bool{
 	KindNotificationRule:              true,
 	KindTask:                          true,
 	KindTelegraf:                      true,
+	KindTemplateDependency:            true,
 	KindVariable:                      true,
 }
 
@@ -467,7 +469,8 @@ type SummaryBucket struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	// TODO: return retention rules?
-	RetentionPeriod time.Duration `json:"retentionPeriod"`
+	RetentionPeriod time.Duration         `json:"retentionPeriod"`
+	IngestRules     []influxdb.IngestRule `json:"ingestRules,omitempty"`
 
 	LabelAssociations []SummaryLabel `json:"labelAssociations"`
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/ast/edit"
 	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/pkg/jsonnet"
 	"github.com/influxdata/influxdb/v2/task/options"
 	"gopkg.in/yaml.v3"
@@ -77,7 +78,33 @@ func Parse(encoding Encoding, readerFn ReaderFn, opts ...ValidateOptFn) (*Templa
 		return nil, err
 	}
 
-	var pkgFn func(io.Reader, ...ValidateOptFn) (*Template, error)
+	return parseWithDependencies(encoding, r, []string{source}, parseOpt{}, opts...)
+}
+
+// ParseWithLocalDependencies parses a template like Parse, but also reads the
+// template dependencies given as local file paths. This is meant for the CLI,
+// templates parsed by the server must never read the files of the server.
+func ParseWithLocalDependencies(encoding Encoding, readerFn ReaderFn, opts ...ValidateOptFn) (*Template, error) {
+	r, source, err := readerFn()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseWithDependencies(encoding, r, []string{source}, parseOpt{localDependencies: true}, opts...)
+}
+
+// parseOpt holds the options of the parsing of a template and of its
+// dependencies.
+type parseOpt struct {
+	localDependencies bool
+}
+
+// parseWithDependencies decodes the template, resolves all of its dependencies
+// and then validates the combined result. The chain holds the sources of the
+// templates that are currently being resolved, the last entry being the source
+// of the template being parsed. It is used to detect dependency cycles.
+func parseWithDependencies(encoding Encoding, r io.Reader, chain []string, pOpt parseOpt, opts ...ValidateOptFn) (*Template, error) {
+	var pkgFn func(io.Reader) (*Template, error)
 	switch encoding {
 	case EncodingJSON:
 		pkgFn = parseJSON
@@ -91,15 +118,39 @@ func Parse(encoding Encoding, readerFn ReaderFn, opts ...ValidateOptFn) (*Templa
 		return nil, ErrInvalidEncoding
 	}
 
-	pkg, err := pkgFn(r, opts...)
+	pkg, err := pkgFn(r)
 	if err != nil {
 		return nil, err
 	}
-	pkg.sources = []string{source}
+	pkg.sources = []string{chain[len(chain)-1]}
+
+	if err := pkg.resolveDependencies(chain, pOpt); err != nil {
+		return nil, err
+	}
+
+	if err := pkg.Validate(opts...); err != nil {
+		return nil, err
+	}
 
 	return pkg, nil
 }
 
+// encodingFromPath infers the template encoding from the extension of the
+// file or url path. When no known extension is found, the encoding is
+// inferred from the source itself.
+func encodingFromPath(p string) Encoding {
+	switch path.Ext(p) {
+	case ".jsonnet":
+		return EncodingJsonnet
+	case ".json":
+		return EncodingJSON
+	case ".yaml", ".yml":
+		return EncodingYAML
+	default:
+		return EncodingSource
+	}
+}
+
 // FromFile reads a file from disk and provides a reader from it.
 func FromFile(filePath string) ReaderFn {
 	return func() (io.Reader, string, error) {
@@ -205,15 +256,15 @@ func normalizeGithubURLToContent(addr string) string {
 	return u.String()
 }
 
-func parseJSON(r io.Reader, opts ...ValidateOptFn) (*Template, error) {
-	return parse(json.NewDecoder(r), opts...)
+func parseJSON(r io.Reader) (*Template, error) {
+	return parse(json.NewDecoder(r))
 }
 
-func parseJsonnet(r io.Reader, opts ...ValidateOptFn) (*Template, error) {
-	return parse(jsonnet.NewDecoder(r), opts...)
+func parseJsonnet(r io.Reader) (*Template, error) {
+	return parse(jsonnet.NewDecoder(r))
 }
 
-func parseSource(r io.Reader, opts ...ValidateOptFn) (*Template, error) {
+func parseSource(r io.Reader) (*Template, error) {
 	var b []byte
 	if byter, ok := r.(interface{ Bytes() []byte }); ok {
 		b = byter.Bytes()
@@ -229,18 +280,18 @@ func parseSource(r io.Reader, opts ...ValidateOptFn) (*Template, error) {
 	switch {
 	case strings.Contains(contentType, "jsonnet"):
 		// highly unlikely to fall in here with supported content type detection as is
-		return parseJsonnet(bytes.NewReader(b), opts...)
+		return parseJsonnet(bytes.NewReader(b))
 	case strings.Contains(contentType, "json"):
-		return parseJSON(bytes.NewReader(b), opts...)
+		return parseJSON(bytes.NewReader(b))
 	case strings.Contains(contentType, "yaml"),
 		strings.Contains(contentType, "yml"):
-		return parseYAML(bytes.NewReader(b), opts...)
+		return parseYAML(bytes.NewReader(b))
 	default:
-		return parseYAML(bytes.NewReader(b), opts...)
+		return parseYAML(bytes.NewReader(b))
 	}
 }
 
-func parseYAML(r io.Reader, opts ...ValidateOptFn) (*Template, error) {
+func parseYAML(r io.Reader) (*Template, error) {
 	dec := yaml.NewDecoder(r)
 
 	var pkg Template
@@ -258,10 +309,6 @@ func parseYAML(r io.Reader, opts ...ValidateOptFn) (*Template, error) {
 		pkg.Objects = append(pkg.Objects, k)
 	}
 
-	if err := pkg.Validate(opts...); err != nil {
-		return nil, err
-	}
-
 	return &pkg, nil
 }
 
@@ -269,16 +316,12 @@ type decoder interface {
 	Decode(interface{}) error
 }
 
-func parse(dec decoder, opts ...ValidateOptFn) (*Template, error) {
+func parse(dec decoder) (*Template, error) {
 	var pkg Template
 	if err := dec.Decode(&pkg.Objects); err != nil {
 		return nil, err
 	}
 
-	if err := pkg.Validate(opts...); err != nil {
-		return nil, err
-	}
-
 	return &pkg, nil
 }
 
@@ -347,6 +390,9 @@ func (k Object) SetMetadataName(name string) {
 type Template struct {
 	Objects []Object `json:"-" yaml:"-"`
 	sources []string
+	// dependencySources are the sources of the template dependencies, they
+	// are kept apart from the sources recorded by stacks.
+	dependencySources []string
 
 	mLabels                map[string]*label
 	mBuckets               map[string]*bucket
@@ -402,6 +448,12 @@ func (p *Template) Sources() []string {
 	return p.sources
 }
 
+// DependencySources returns the sources of the template dependencies that were
+// merged into the template.
+func (p *Template) DependencySources() []string {
+	return p.dependencySources
+}
+
 // Summary returns a package Summary that describes all the resources and
 // associations the pkg contains. It is very useful for informing users of
 // the changes that will take place when this pkg would be applied.
@@ -529,6 +581,7 @@ func Combine(pkgs []*Template, validationOpts ...ValidateOptFn) (*Template, erro
 			continue
 		}
 		newPkg.sources = append(newPkg.sources, p.sources...)
+		newPkg.dependencySources = append(newPkg.dependencySources, p.dependencySources...)
 		newPkg.Objects = append(newPkg.Objects, p.Objects...)
 	}
 
@@ -824,6 +877,20 @@ func (p *Template) graphBuckets() *parseErr {
 				})
 			}
 		}
+		if rules, ok := o.Spec[fieldBucketIngestRules].([]influxdb.IngestRule); ok {
+			bkt.IngestRules = rules
+		} else {
+			for _, r := range o.Spec.slcResource(fieldBucketIngestRules) {
+				bkt.IngestRules = append(bkt.IngestRules, influxdb.IngestRule{
+					Type:        influxdb.IngestRuleType(r.stringShort(fieldType)),
+					Measurement: r.stringShort(fieldIngestRuleMeasurement),
+					Key:         r.stringShort(fieldKey),
+					To:          r.stringShort(fieldIngestRuleTo),
+					Value:       r.stringShort(fieldValue),
+					Pattern:     r.stringShort(fieldIngestRulePattern),
+				})
+			}
+		}
 		p.setRefs(bkt.name, bkt.displayName)
 
 		failures := p.parseNestedLabels(o.Spec, func(l *label) error {
@@ -1396,6 +1463,7 @@ func (p *Template) trackNames(resourceUniqueByName bool) func(Object) (identity,
 		identity := identity{
 			name:        nameRef,
 			displayName: displayNameRef,
+			dependency:  o.Metadata.stringShort(fieldTemplateDependency),
 		}
 		if !resourceUniqueByName {
 			return identity, nil
@@ -0,0 +1,214 @@
+package pkger
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+const (
+	fieldTemplateDependency    = "templateDependency"
+	fieldDependencyEnvRefs     = "envRefs"
+	fieldDependencyTemplateURL = "templateURL"
+)
+
+// resolveDependencies replaces all TemplateDependency objects within the template
+// with the objects of the templates they reference. Every object pulled in from
+// a dependency has its metadata.templateDependency field set to the name of the
+// dependency it came from, nested dependencies are joined by a "/". The chain
+// holds the sources of all templates currently being resolved and is used to
+// detect cycles. Dependencies are only read from local files when the
+// template is parsed with ParseWithLocalDependencies.
+func (p *Template) resolveDependencies(chain []string, opt parseOpt) error {
+	var (
+		objects []Object
+		deps    []Object
+	)
+	for _, o := range p.Objects {
+		if o.Kind.is(KindTemplateDependency) {
+			deps = append(deps, o)
+			continue
+		}
+		objects = append(objects, o)
+	}
+	if len(deps) == 0 {
+		return nil
+	}
+
+	owners := make(map[dependencyKey]string)
+	for _, o := range objects {
+		owners[newDependencyKey(o)] = o.Metadata.stringShort(fieldTemplateDependency)
+	}
+
+	for _, dep := range deps {
+		depName := dep.Name()
+		if errs := isDNS1123Label(depName); len(errs) > 0 {
+			return influxErr(errors2.EInvalid, fmt.Sprintf(
+				"template dependency name %q is invalid; %s", depName, strings.Join(errs, "; "),
+			))
+		}
+
+		rawURL := dep.Spec.stringShort(fieldDependencyTemplateURL)
+		if rawURL == "" {
+			return influxErr(errors2.EInvalid, fmt.Sprintf(
+				"template dependency %q must provide a %s", depName, fieldDependencyTemplateURL,
+			))
+		}
+
+		source, readerFn := dependencyReaderFn(chain[len(chain)-1], rawURL)
+		if !opt.localDependencies && !isRemoteDependency(source) {
+			return influxErr(errors2.EInvalid, fmt.Sprintf(
+				"template dependency %q must be an http or https url; got %q", depName, rawURL,
+			))
+		}
+		for _, s := range chain {
+			if s == source {
+				return influxErr(errors2.EInvalid, fmt.Sprintf(
+					"template dependency cycle detected: %s",
+					strings.Join(append(chain, source), " -> "),
+				))
+			}
+		}
+
+		r, _, err := readerFn()
+		if err != nil {
+			return influxErr(errors2.EUnprocessableEntity, fmt.Sprintf(
+				"failed to read template dependency %q from %s: %s", depName, source, err,
+			))
+		}
+
+		depTemplate, err := parseWithDependencies(encodingFromPath(source), r, append(chain, source), opt, ValidWithoutResources(), ValidSkipParseError())
+		if err != nil {
+			return influxErr(errors2.EUnprocessableEntity, fmt.Sprintf(
+				"template dependency %q from %s had an issue: %s", depName, source, err,
+			))
+		}
+
+		envRefs, _ := ifaceToResource(dep.Spec[fieldDependencyEnvRefs])
+		for _, o := range depTemplate.Objects {
+			o.Metadata = applyDependencyEnvRefs(o.Metadata, envRefs)
+			o.Spec = applyDependencyEnvRefs(o.Spec, envRefs)
+
+			origin := depName
+			if nested := o.Metadata.stringShort(fieldTemplateDependency); nested != "" {
+				origin = depName + "/" + nested
+			}
+			o.Metadata[fieldTemplateDependency] = origin
+
+			key := newDependencyKey(o)
+			if owner, ok := owners[key]; ok {
+				if owner == "" {
+					owner = "the template"
+				} else {
+					owner = fmt.Sprintf("template dependency %q", owner)
+				}
+				return influxErr(errors2.EConflict, fmt.Sprintf(
+					"%s %q from template dependency %q collides with %s",
+					o.Kind, key.name, origin, owner,
+				))
+			}
+			owners[key] = origin
+			objects = append(objects, o)
+		}
+		p.dependencySources = append(p.dependencySources, depTemplate.sources...)
+		p.dependencySources = append(p.dependencySources, depTemplate.dependencySources...)
+	}
+	p.Objects = objects
+
+	return nil
+}
+
+type dependencyKey struct {
+	resType influxdb.ResourceType
+	name    string
+}
+
+func newDependencyKey(o Object) dependencyKey {
+	return dependencyKey{
+		resType: o.Kind.ResourceType(),
+		name:    o.Name(),
+	}
+}
+
+// dependencyReaderFn provides the normalized source and a reader for the dependency
+// url. Urls without a scheme are treated as file paths. Relative file paths are
+// resolved against the parent source when the parent is a file or remote url.
+func dependencyReaderFn(parentSource, rawURL string) (string, ReaderFn) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, FromFile(rawURL)
+	}
+
+	if parent, err := url.Parse(parentSource); err == nil && !u.IsAbs() && !filepath.IsAbs(rawURL) {
+		switch parent.Scheme {
+		case "file":
+			// relative file sources are encoded with the leading directory as the host
+			parentPath := parent.Host + parent.Path
+			u = &url.URL{Scheme: "file", Path: filepath.Join(filepath.Dir(parentPath), u.Path)}
+		case "http", "https":
+			u = parent.ResolveReference(u)
+		}
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return u.String(), FromHTTPRequest(u.String())
+	default:
+		u.Scheme = "file"
+		return u.String(), FromFile(u.Path)
+	}
+}
+
+// isRemoteDependency reports whether the normalized dependency source is an
+// http or https url.
+func isRemoteDependency(source string) bool {
+	u, err := url.Parse(source)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// applyDependencyEnvRefs replaces all env references whose key is provided by
+// the dependency envRefs with the provided value. References that are not
+// provided are left as is, to be satisfied when the template is applied.
+func applyDependencyEnvRefs(r Resource, envRefs Resource) Resource {
+	if r == nil {
+		return make(Resource)
+	}
+	if len(envRefs) == 0 {
+		return r
+	}
+	for k, v := range r {
+		r[k] = applyDependencyEnvRefsIface(v, envRefs)
+	}
+	return r
+}
+
+func applyDependencyEnvRefsIface(v interface{}, envRefs Resource) interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		for i := range t {
+			t[i] = applyDependencyEnvRefsIface(t[i], envRefs)
+		}
+		return t
+	case []Resource:
+		for i := range t {
+			t[i] = applyDependencyEnvRefs(t[i], envRefs)
+		}
+		return t
+	}
+
+	r, ok := ifaceToResource(v)
+	if !ok {
+		return v
+	}
+	if envRes, ok := ifaceToResource(r[fieldReferencesEnv]); ok {
+		if val, ok := envRefs[envRes.stringShort(fieldKey)]; ok {
+			return val
+		}
+		return r
+	}
+	return applyDependencyEnvRefs(r, envRefs)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/influxdata/flux/ast/edit"
 	"github.com/influxdata/flux/parser"
 	"github.com/influxdata/influxdb/v2"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/notification"
 	icheck "github.com/influxdata/influxdb/v2/notification/check"
 	"github.com/influxdata/influxdb/v2/notification/endpoint"
@@ -22,6 +23,7 @@ import (
 type identity struct {
 	name        *references
 	displayName *references
+	dependency  string
 }
 
 func (i *identity) Name() string {
@@ -35,6 +37,12 @@ func (i *identity) MetaName() string {
 	return i.name.String()
 }
 
+// TemplateDependency is the name of the template dependency the resource
+// was pulled in from. It is empty for resources defined by the template itself.
+func (i *identity) TemplateDependency() string {
+	return i.dependency
+}
+
 func (i *identity) summarizeReferences() []SummaryReference {
 	refs := make([]SummaryReference, 0)
 	if i.name.hasEnvRef() {
@@ -78,9 +86,16 @@ const (
 )
 
 const (
+	fieldBucketIngestRules    = "ingestRules"
 	fieldBucketRetentionRules = "retentionRules"
 )
 
+const (
+	fieldIngestRuleMeasurement = "measurement"
+	fieldIngestRulePattern     = "pattern"
+	fieldIngestRuleTo          = "to"
+)
+
 const bucketNameMinLength = 2
 
 type bucket struct {
@@ -88,6 +103,7 @@ type bucket struct {
 
 	Description    string
 	RetentionRules retentionRules
+	IngestRules    []influxdb.IngestRule
 	labels         sortedLabels
 }
 
@@ -101,6 +117,7 @@ func (b *bucket) summarize() SummaryBucket {
 		Name:              b.Name(),
 		Description:       b.Description,
 		RetentionPeriod:   b.RetentionRules.RP(),
+		IngestRules:       b.IngestRules,
 		LabelAssociations: toSummaryLabels(b.labels...),
 	}
 }
@@ -115,6 +132,15 @@ func (b *bucket) valid() []validationErr {
 		vErrs = append(vErrs, err)
 	}
 	vErrs = append(vErrs, b.RetentionRules.valid()...)
+	for i, r := range b.IngestRules {
+		if err := r.Valid(); err != nil {
+			vErrs = append(vErrs, validationErr{
+				Field: fieldBucketIngestRules,
+				Index: intPtr(i),
+				Msg:   errors2.ErrorMessage(err),
+			})
+		}
+	}
 	if len(vErrs) == 0 {
 		return nil
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"path/filepath"
 	"sort"
@@ -84,6 +85,33 @@ func TestParse(t *testing.T) {
 			})
 		})
 
+		t.Run("with ingest rules should be valid", func(t *testing.T) {
+			testfileRunner(t, "testdata/bucket_ingest_rules.yml", func(t *testing.T, template *Template) {
+				actual := template.Summary().Buckets
+				require.Len(t, actual, 1)
+
+				expectedRules := []influxdb.IngestRule{
+					{
+						Type:        influxdb.IngestRuleRenameField,
+						Measurement: "apcupsd",
+						Key:         "WATTS",
+						To:          "watts",
+					},
+					{
+						Type:  influxdb.IngestRuleAddTag,
+						Key:   "site",
+						Value: "north",
+					},
+					{
+						Type:    influxdb.IngestRuleDropPoints,
+						Key:     "host",
+						Pattern: "^test-",
+					},
+				}
+				assert.Equal(t, expectedRules, actual[0].IngestRules)
+			})
+		})
+
 		t.Run("should handle bad config", func(t *testing.T) {
 			tests := []testTemplateResourceError{
 				{
@@ -182,6 +210,20 @@ metadata:
   name:  invalid-name
 spec:
   name:  f
+`,
+				},
+				{
+					name:           "invalid ingest rule",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldBucketIngestRules},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name:  rucket-1
+spec:
+  ingestRules:
+    - type: renameTag
+      key: host
 `,
 				},
 			}
@@ -4405,6 +4447,129 @@ spec:
 		}
 		assert.Equal(t, bkts, sum.Buckets)
 	})
+
+	t.Run("template dependencies", func(t *testing.T) {
+		writeTemplate := func(t *testing.T, dir, name, contents string) string {
+			t.Helper()
+
+			p := filepath.Join(dir, name)
+			require.NoError(t, ioutil.WriteFile(p, []byte(contents), 0600))
+			return p
+		}
+
+		dependencyTemplate := func(name, url string) string {
+			return fmt.Sprintf(`
+apiVersion: %[1]s
+kind: TemplateDependency
+metadata:
+  name: %[2]s
+spec:
+  templateURL: %[3]s
+`, APIVersion, name, url)
+		}
+
+		t.Run("resolves and merges dependencies", func(t *testing.T) {
+			template, err := ParseWithLocalDependencies(EncodingYAML, FromFile("testdata/template_dependency.yml"))
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"file://testdata/template_dependency.yml"}, template.Sources())
+			assert.Equal(t, []string{"file://testdata/template_dependency_ups.yml"}, template.DependencySources())
+			assert.False(t, template.Contains(KindTemplateDependency, "ups-pack"))
+
+			sum := template.Summary()
+			require.Len(t, sum.Labels, 1)
+			assert.Equal(t, "label-ups", sum.Labels[0].Name)
+
+			require.Len(t, sum.Buckets, 2)
+			assert.Equal(t, "site-bucket", sum.Buckets[0].Name)
+			assert.Equal(t, "ups-bucket", sum.Buckets[1].Name)
+			for _, b := range sum.Buckets {
+				require.Len(t, b.LabelAssociations, 1)
+				assert.Equal(t, "label-ups", b.LabelAssociations[0].Name)
+			}
+			assert.Empty(t, sum.MissingEnvs)
+
+			assert.Empty(t, template.mBuckets["site-bucket"].TemplateDependency())
+			assert.Equal(t, "ups-pack", template.mBuckets["ups-bucket"].TemplateDependency())
+			assert.Equal(t, "ups-pack", template.mLabels["label-ups"].TemplateDependency())
+		})
+
+		t.Run("nested dependencies are tracked by their full path", func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplate(t, dir, "leaf.yml", fmt.Sprintf(`
+apiVersion: %[1]s
+kind: Label
+metadata:
+  name: label-leaf
+`, APIVersion))
+			writeTemplate(t, dir, "middle.yml", dependencyTemplate("leaf", "leaf.yml"))
+			root := writeTemplate(t, dir, "root.yml", dependencyTemplate("middle", "middle.yml"))
+
+			template, err := ParseWithLocalDependencies(EncodingYAML, FromFile(root))
+			require.NoError(t, err)
+
+			require.Contains(t, template.mLabels, "label-leaf")
+			assert.Equal(t, "middle/leaf", template.mLabels["label-leaf"].TemplateDependency())
+		})
+
+		t.Run("cycles are rejected", func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplate(t, dir, "a.yml", dependencyTemplate("b", "b.yml"))
+			writeTemplate(t, dir, "b.yml", dependencyTemplate("a", "a.yml"))
+
+			_, err := ParseWithLocalDependencies(EncodingYAML, FromFile(filepath.Join(dir, "a.yml")))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "template dependency cycle detected")
+		})
+
+		t.Run("name collisions are rejected", func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplate(t, dir, "dep.yml", fmt.Sprintf(`
+apiVersion: %[1]s
+kind: Bucket
+metadata:
+  name: rucket-1
+`, APIVersion))
+			root := writeTemplate(t, dir, "root.yml", dependencyTemplate("dep", "dep.yml")+fmt.Sprintf(`
+---
+apiVersion: %[1]s
+kind: Bucket
+metadata:
+  name: rucket-1
+`, APIVersion))
+
+			_, err := ParseWithLocalDependencies(EncodingYAML, FromFile(root), ValidSkipParseError())
+			require.Error(t, err)
+			assert.Equal(t, errors2.EConflict, errors2.ErrorCode(err))
+		})
+
+		t.Run("file dependencies are rejected unless allowed", func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplate(t, dir, "dep.yml", fmt.Sprintf(`
+apiVersion: %[1]s
+kind: Label
+metadata:
+  name: label-dep
+`, APIVersion))
+			root := writeTemplate(t, dir, "root.yml", dependencyTemplate("dep", "dep.yml"))
+
+			for _, tmpl := range []ReaderFn{
+				FromFile(root),
+				FromString(dependencyTemplate("dep", "/etc/passwd")),
+				FromString(dependencyTemplate("dep", "file:///etc/passwd")),
+			} {
+				_, err := Parse(EncodingYAML, tmpl)
+				require.Error(t, err)
+				assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+			}
+		})
+
+		t.Run("missing template url is rejected", func(t *testing.T) {
+			_, err := Parse(EncodingYAML, FromString(dependencyTemplate("dep", "")))
+			require.Error(t, err)
+			assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+		})
+	})
 }
 
 func TestCombine(t *testing.T) {
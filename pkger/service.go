@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"path"
 	"regexp"
 	"sort"
 	"strings"
@@ -80,6 +79,10 @@ type (
 		Kind         Kind
 		MetaName     string
 		Associations []StackResourceAssociation
+
+		// TemplateDependency is the name of the template dependency the resource
+		// was applied from. Empty when the resource is defined by the template itself.
+		TemplateDependency string
 	}
 
 	// StackResourceAssociation associates a stack resource with another stack resource.
@@ -157,6 +160,7 @@ type serviceOpt struct {
 	bucketSVC   influxdb.BucketService
 	checkSVC    influxdb.CheckService
 	dashSVC     influxdb.DashboardService
+	ingestSVC   influxdb.IngestRulesService
 	labelSVC    influxdb.LabelService
 	endpointSVC influxdb.NotificationEndpointService
 	orgSVC      influxdb.OrganizationService
@@ -219,6 +223,13 @@ func WithDashboardSVC(dashSVC influxdb.DashboardService) ServiceSetterFn {
 	}
 }
 
+// WithIngestRulesSVC sets the ingest rules service.
+func WithIngestRulesSVC(ingestSVC influxdb.IngestRulesService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.ingestSVC = ingestSVC
+	}
+}
+
 // WithLabelSVC sets the label service.
 func WithLabelSVC(labelSVC influxdb.LabelService) ServiceSetterFn {
 	return func(opt *serviceOpt) {
@@ -306,6 +317,7 @@ type Service struct {
 	bucketSVC   influxdb.BucketService
 	checkSVC    influxdb.CheckService
 	dashSVC     influxdb.DashboardService
+	ingestSVC   influxdb.IngestRulesService
 	labelSVC    influxdb.LabelService
 	endpointSVC influxdb.NotificationEndpointService
 	orgSVC      influxdb.OrganizationService
@@ -344,6 +356,7 @@ func NewService(opts ...ServiceSetterFn) *Service {
 		checkSVC:    opt.checkSVC,
 		labelSVC:    opt.labelSVC,
 		dashSVC:     opt.dashSVC,
+		ingestSVC:   opt.ingestSVC,
 		endpointSVC: opt.endpointSVC,
 		orgSVC:      opt.orgSVC,
 		ruleSVC:     opt.ruleSVC,
@@ -1668,6 +1681,13 @@ func (s *Service) applyBuckets(ctx context.Context, buckets []*stateBucket) appl
 			rollbackBuckets = append(rollbackBuckets, buckets[i])
 		})
 
+		if err := s.applyBucketIngestRules(ctx, b); err != nil {
+			return &applyErrBody{
+				name: b.parserBkt.MetaName(),
+				msg:  err.Error(),
+			}
+		}
+
 		return nil
 	}
 
@@ -1702,7 +1722,10 @@ func (s *Service) rollbackBuckets(ctx context.Context, buckets []*stateBucket) e
 		default:
 			err = ierrors.Wrap(s.bucketSVC.DeleteBucket(ctx, b.ID()), "rolling back new bucket")
 		}
-		return err
+		if err != nil {
+			return err
+		}
+		return ierrors.Wrap(s.rollbackBucketIngestRules(ctx, b), "rolling back bucket ingest rules")
 	}
 
 	var errs []string
@@ -1761,6 +1784,51 @@ func (s *Service) applyBucket(ctx context.Context, b *stateBucket) (influxdb.Buc
 	}
 }
 
+// applyBucketIngestRules replaces the ingest rules of a bucket with those of
+// the template. Buckets without rules in the template keep their rules.
+func (s *Service) applyBucketIngestRules(ctx context.Context, b *stateBucket) error {
+	if s.ingestSVC == nil || IsRemoval(b.stateStatus) || isSystemBucket(b.existing) || len(b.parserBkt.IngestRules) == 0 {
+		return nil
+	}
+
+	existing, err := s.ingestSVC.GetIngestRules(ctx, b.ID())
+	if err != nil && errors2.ErrorCode(err) != errors2.ENotFound {
+		return applyFailErr("find ingest rules of", b.stateIdentity(), err)
+	}
+	if existing != nil {
+		b.existingIngestRules = existing.Rules
+	}
+
+	err = s.ingestSVC.PutIngestRules(ctx, &influxdb.BucketIngestRules{
+		BucketID: b.ID(),
+		OrgID:    b.orgID,
+		Rules:    b.parserBkt.IngestRules,
+	})
+	if err != nil {
+		return applyFailErr("update ingest rules of", b.stateIdentity(), err)
+	}
+	b.ingestRulesApplied = true
+	return nil
+}
+
+func (s *Service) rollbackBucketIngestRules(ctx context.Context, b *stateBucket) error {
+	if !b.ingestRulesApplied {
+		return nil
+	}
+	if len(b.existingIngestRules) == 0 {
+		err := s.ingestSVC.DeleteIngestRules(ctx, b.ID())
+		if errors2.ErrorCode(err) == errors2.ENotFound {
+			return nil
+		}
+		return err
+	}
+	return s.ingestSVC.PutIngestRules(ctx, &influxdb.BucketIngestRules{
+		BucketID: b.ID(),
+		OrgID:    b.orgID,
+		Rules:    b.existingIngestRules,
+	})
+}
+
 func (s *Service) applyChecks(ctx context.Context, checks []*stateCheck) applier {
 	const resource = "check"
 
@@ -3048,15 +3116,7 @@ func (s *Service) getStackRemoteTemplates(ctx context.Context, stackID platform.
 			}
 		}
 
-		encoding := EncodingSource
-		switch path.Ext(u.String()) {
-		case ".jsonnet":
-			encoding = EncodingJsonnet
-		case ".json":
-			encoding = EncodingJSON
-		case ".yaml", ".yml":
-			encoding = EncodingYAML
-		}
+		encoding := encodingFromPath(u.String())
 
 		readerFn := FromHTTPRequest(u.String())
 		if u.Scheme == "file" {
@@ -3084,11 +3144,12 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion:   APIVersion,
-			ID:           b.ID(),
-			Kind:         KindBucket,
-			MetaName:     b.parserBkt.MetaName(),
-			Associations: stateLabelsToStackAssociations(b.labels()),
+			APIVersion:         APIVersion,
+			ID:                 b.ID(),
+			Kind:               KindBucket,
+			MetaName:           b.parserBkt.MetaName(),
+			TemplateDependency: b.parserBkt.TemplateDependency(),
+			Associations:       stateLabelsToStackAssociations(b.labels()),
 		})
 	}
 	for _, c := range state.mChecks {
@@ -3096,11 +3157,12 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion:   APIVersion,
-			ID:           c.ID(),
-			Kind:         KindCheck,
-			MetaName:     c.parserCheck.MetaName(),
-			Associations: stateLabelsToStackAssociations(c.labels()),
+			APIVersion:         APIVersion,
+			ID:                 c.ID(),
+			Kind:               KindCheck,
+			MetaName:           c.parserCheck.MetaName(),
+			TemplateDependency: c.parserCheck.TemplateDependency(),
+			Associations:       stateLabelsToStackAssociations(c.labels()),
 		})
 	}
 	for _, d := range state.mDashboards {
@@ -3108,11 +3170,12 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion:   APIVersion,
-			ID:           d.ID(),
-			Kind:         KindDashboard,
-			MetaName:     d.parserDash.MetaName(),
-			Associations: stateLabelsToStackAssociations(d.labels()),
+			APIVersion:         APIVersion,
+			ID:                 d.ID(),
+			Kind:               KindDashboard,
+			MetaName:           d.parserDash.MetaName(),
+			TemplateDependency: d.parserDash.TemplateDependency(),
+			Associations:       stateLabelsToStackAssociations(d.labels()),
 		})
 	}
 	for _, n := range state.mEndpoints {
@@ -3120,11 +3183,12 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion:   APIVersion,
-			ID:           n.ID(),
-			Kind:         KindNotificationEndpoint,
-			MetaName:     n.parserEndpoint.MetaName(),
-			Associations: stateLabelsToStackAssociations(n.labels()),
+			APIVersion:         APIVersion,
+			ID:                 n.ID(),
+			Kind:               KindNotificationEndpoint,
+			MetaName:           n.parserEndpoint.MetaName(),
+			TemplateDependency: n.parserEndpoint.TemplateDependency(),
+			Associations:       stateLabelsToStackAssociations(n.labels()),
 		})
 	}
 	for _, l := range state.mLabels {
@@ -3132,10 +3196,11 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion: APIVersion,
-			ID:         l.ID(),
-			Kind:       KindLabel,
-			MetaName:   l.parserLabel.MetaName(),
+			APIVersion:         APIVersion,
+			ID:                 l.ID(),
+			Kind:               KindLabel,
+			MetaName:           l.parserLabel.MetaName(),
+			TemplateDependency: l.parserLabel.TemplateDependency(),
 		})
 	}
 	for _, r := range state.mRules {
@@ -3143,10 +3208,11 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion: APIVersion,
-			ID:         r.ID(),
-			Kind:       KindNotificationRule,
-			MetaName:   r.parserRule.MetaName(),
+			APIVersion:         APIVersion,
+			ID:                 r.ID(),
+			Kind:               KindNotificationRule,
+			MetaName:           r.parserRule.MetaName(),
+			TemplateDependency: r.parserRule.TemplateDependency(),
 			Associations: append(
 				stateLabelsToStackAssociations(r.labels()),
 				r.endpointAssociation(),
@@ -3158,11 +3224,12 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion:   APIVersion,
-			ID:           t.ID(),
-			Kind:         KindTask,
-			MetaName:     t.parserTask.MetaName(),
-			Associations: stateLabelsToStackAssociations(t.labels()),
+			APIVersion:         APIVersion,
+			ID:                 t.ID(),
+			Kind:               KindTask,
+			MetaName:           t.parserTask.MetaName(),
+			TemplateDependency: t.parserTask.TemplateDependency(),
+			Associations:       stateLabelsToStackAssociations(t.labels()),
 		})
 	}
 	for _, t := range state.mTelegrafs {
@@ -3170,11 +3237,12 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion:   APIVersion,
-			ID:           t.ID(),
-			Kind:         KindTelegraf,
-			MetaName:     t.parserTelegraf.MetaName(),
-			Associations: stateLabelsToStackAssociations(t.labels()),
+			APIVersion:         APIVersion,
+			ID:                 t.ID(),
+			Kind:               KindTelegraf,
+			MetaName:           t.parserTelegraf.MetaName(),
+			TemplateDependency: t.parserTelegraf.TemplateDependency(),
+			Associations:       stateLabelsToStackAssociations(t.labels()),
 		})
 	}
 	for _, v := range state.mVariables {
@@ -3182,11 +3250,12 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			continue
 		}
 		stackResources = append(stackResources, StackResource{
-			APIVersion:   APIVersion,
-			ID:           v.ID(),
-			Kind:         KindVariable,
-			MetaName:     v.parserVar.MetaName(),
-			Associations: stateLabelsToStackAssociations(v.labels()),
+			APIVersion:         APIVersion,
+			ID:                 v.ID(),
+			Kind:               KindVariable,
+			MetaName:           v.parserVar.MetaName(),
+			TemplateDependency: v.parserVar.TemplateDependency(),
+			Associations:       stateLabelsToStackAssociations(v.labels()),
 		})
 	}
 	ev := stack.LatestEvent()
@@ -721,6 +721,11 @@ type stateBucket struct {
 
 	parserBkt *bucket
 	existing  *influxdb.Bucket
+
+	// ingestRulesApplied is set once the ingest rules of the template have
+	// replaced existingIngestRules, which are kept for rollback.
+	ingestRulesApplied  bool
+	existingIngestRules []influxdb.IngestRule
 }
 
 func (b *stateBucket) diffBucket() DiffBucket {
@@ -795,7 +800,8 @@ func (b *stateBucket) shouldApply() bool {
 		b.existing == nil ||
 		b.parserBkt.Description != b.existing.Description ||
 		b.parserBkt.Name() != b.existing.Name ||
-		b.parserBkt.RetentionRules.RP() != b.existing.RetentionPeriod
+		b.parserBkt.RetentionRules.RP() != b.existing.RetentionPeriod ||
+		len(b.parserBkt.IngestRules) > 0
 }
 
 type stateCheck struct {
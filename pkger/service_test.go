@@ -64,6 +64,7 @@ func TestService(t *testing.T) {
 			WithBucketSVC(opt.bucketSVC),
 			WithCheckSVC(opt.checkSVC),
 			WithDashboardSVC(opt.dashSVC),
+			WithIngestRulesSVC(opt.ingestSVC),
 			WithLabelSVC(opt.labelSVC),
 			WithNotificationEndpointSVC(opt.endpointSVC),
 			WithNotificationRuleSVC(opt.ruleSVC),
@@ -871,6 +872,33 @@ func TestService(t *testing.T) {
 				})
 			})
 
+			t.Run("applies bucket ingest rules", func(t *testing.T) {
+				testfileRunner(t, "testdata/bucket_ingest_rules.yml", func(t *testing.T, template *Template) {
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.FindBucketByNameFn = func(_ context.Context, id platform.ID, s string) (*influxdb.Bucket, error) {
+						// forces the bucket to be created a new
+						return nil, errors.New("an error")
+					}
+					fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+						b.ID = platform.ID(3)
+						return nil
+					}
+					fakeIngestSVC := &fakeIngestRulesSVC{}
+
+					svc := newTestService(WithBucketSVC(fakeBktSVC), WithIngestRulesSVC(fakeIngestSVC))
+
+					orgID := platform.ID(9000)
+
+					_, err := svc.Apply(context.TODO(), orgID, 0, ApplyWithTemplate(template))
+					require.NoError(t, err)
+
+					require.Len(t, fakeIngestSVC.put, 1)
+					assert.Equal(t, platform.ID(3), fakeIngestSVC.put[0].BucketID)
+					assert.Equal(t, orgID, fakeIngestSVC.put[0].OrgID)
+					assert.Equal(t, template.Summary().Buckets[0].IngestRules, fakeIngestSVC.put[0].Rules)
+				})
+			})
+
 			t.Run("rolls back all created buckets on an error", func(t *testing.T) {
 				testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
 					fakeBktSVC := mock.NewBucketService()
@@ -5366,3 +5394,18 @@ func parseURL(t *testing.T, rawAddr string) url.URL {
 	require.NoError(t, err)
 	return *u
 }
+
+type fakeIngestRulesSVC struct {
+	influxdb.IngestRulesService
+
+	put []*influxdb.BucketIngestRules
+}
+
+func (f *fakeIngestRulesSVC) GetIngestRules(ctx context.Context, bucketID platform.ID) (*influxdb.BucketIngestRules, error) {
+	return nil, &errors2.Error{Code: errors2.ENotFound}
+}
+
+func (f *fakeIngestRulesSVC) PutIngestRules(ctx context.Context, rules *influxdb.BucketIngestRules) error {
+	f.put = append(f.put, rules)
+	return nil
+}
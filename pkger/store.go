@@ -38,11 +38,12 @@ type (
 	}
 
 	entStackResource struct {
-		APIVersion   string                `json:"apiVersion"`
-		ID           string                `json:"id"`
-		Kind         string                `json:"kind"`
-		Name         string                `json:"name"`
-		Associations []entStackAssociation `json:"associations,omitempty"`
+		APIVersion         string                `json:"apiVersion"`
+		ID                 string                `json:"id"`
+		Kind               string                `json:"kind"`
+		Name               string                `json:"name"`
+		TemplateDependency string                `json:"templateDependency,omitempty"`
+		Associations       []entStackAssociation `json:"associations,omitempty"`
 	}
 
 	entStackAssociation struct {
@@ -300,11 +301,12 @@ func convertStackToEnt(stack Stack) (kv.Entity, error) {
 				})
 			}
 			resources = append(resources, entStackResource{
-				APIVersion:   res.APIVersion,
-				ID:           res.ID.String(),
-				Kind:         res.Kind.String(),
-				Name:         res.MetaName,
-				Associations: associations,
+				APIVersion:         res.APIVersion,
+				ID:                 res.ID.String(),
+				Kind:               res.Kind.String(),
+				Name:               res.MetaName,
+				TemplateDependency: res.TemplateDependency,
+				Associations:       associations,
 			})
 		}
 		stEnt.Events = append(stEnt.Events, entStackEvent{
@@ -378,9 +380,10 @@ func convertStackEntResources(entResources []entStackResource) ([]StackResource,
 	var out []StackResource
 	for _, res := range entResources {
 		stackRes := StackResource{
-			APIVersion: res.APIVersion,
-			Kind:       Kind(res.Kind),
-			MetaName:   res.Name,
+			APIVersion:         res.APIVersion,
+			Kind:               Kind(res.Kind),
+			MetaName:           res.Name,
+			TemplateDependency: res.TemplateDependency,
 		}
 		if err := stackRes.ID.DecodeFromString(res.ID); err != nil {
 			return nil, err
@@ -0,0 +1,187 @@
+// Package remote implements the Prometheus remote storage protocol, so
+// Prometheus can write samples to and read samples from a bucket.
+package remote
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+)
+
+const (
+	// nameLabel is the label holding the metric name of a series.
+	nameLabel = "__name__"
+
+	// fieldLabel is the label holding the field of a series read from a
+	// field other than the value field, such as the gauge and counter fields
+	// of a metric collected by the prometheus scraper.
+	fieldLabel = "_field"
+
+	// measurementTagKey is the key of the measurement tag of the series read
+	// from the storage engine, which also names the field tag _field.
+	measurementTagKey = "_measurement"
+
+	// valueField is the field samples are written to, as for untyped metrics
+	// collected by the prometheus scraper.
+	valueField = "value"
+)
+
+// readFields are the fields read as samples: the value field of written
+// samples, and the fields of gauges and counters collected by the
+// prometheus scraper.
+var readFields = []string{valueField, "gauge", "counter"}
+
+// Points converts the samples of the write request into points. The metric
+// name of each series becomes the measurement, the other labels its tags and
+// the samples the values of its value field. Samples that are not a number,
+// such as staleness markers, are dropped.
+func Points(req *WriteRequest) (models.Points, error) {
+	var points models.Points
+	for _, ts := range req.Timeseries {
+		var name string
+		tags := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			if l.Name == nameLabel {
+				name = l.Value
+				continue
+			}
+			tags[l.Name] = l.Value
+		}
+		if name == "" {
+			return nil, fmt.Errorf("series without %s label", nameLabel)
+		}
+
+		for _, s := range ts.Samples {
+			if math.IsNaN(s.Value) {
+				continue
+			}
+			pt, err := models.NewPoint(
+				name,
+				models.NewTags(tags),
+				models.Fields{valueField: s.Value},
+				time.Unix(0, s.Timestamp*int64(time.Millisecond)),
+			)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, pt)
+		}
+	}
+	return points, nil
+}
+
+// Predicate translates the matchers of the query into a storage predicate.
+func Predicate(q *Query) (*datatypes.Predicate, error) {
+	root := fieldNode()
+	for _, m := range q.Matchers {
+		n, err := matcherNode(m)
+		if err != nil {
+			return nil, err
+		}
+		root = &datatypes.Node{
+			NodeType: datatypes.NodeTypeLogicalExpression,
+			Value:    &datatypes.Node_Logical_{Logical: datatypes.LogicalAnd},
+			Children: []*datatypes.Node{root, n},
+		}
+	}
+	return &datatypes.Predicate{Root: root}, nil
+}
+
+// fieldNode restricts the series to the fields read as samples.
+func fieldNode() *datatypes.Node {
+	var children []*datatypes.Node
+	for _, f := range readFields {
+		children = append(children, comparisonNode(datatypes.ComparisonEqual, models.FieldKeyTagKey, stringNode(f)))
+	}
+
+	root := children[0]
+	for _, n := range children[1:] {
+		root = &datatypes.Node{
+			NodeType: datatypes.NodeTypeLogicalExpression,
+			Value:    &datatypes.Node_Logical_{Logical: datatypes.LogicalOr},
+			Children: []*datatypes.Node{root, n},
+		}
+	}
+	return root
+}
+
+func matcherNode(m *LabelMatcher) (*datatypes.Node, error) {
+	tag := m.Name
+	switch tag {
+	case nameLabel:
+		tag = models.MeasurementTagKey
+	case fieldLabel:
+		tag = models.FieldKeyTagKey
+	}
+
+	switch m.Type {
+	case LabelMatcher_EQ:
+		return comparisonNode(datatypes.ComparisonEqual, tag, stringNode(m.Value)), nil
+	case LabelMatcher_NEQ:
+		return comparisonNode(datatypes.ComparisonNotEqual, tag, stringNode(m.Value)), nil
+	case LabelMatcher_RE:
+		// Prometheus regular expressions are fully anchored.
+		return comparisonNode(datatypes.ComparisonRegex, tag, regexNode(m.Value)), nil
+	case LabelMatcher_NRE:
+		return comparisonNode(datatypes.ComparisonNotRegex, tag, regexNode(m.Value)), nil
+	default:
+		return nil, fmt.Errorf("unknown label matcher type %d", m.Type)
+	}
+}
+
+func comparisonNode(op datatypes.Node_Comparison, tag string, value *datatypes.Node) *datatypes.Node {
+	return &datatypes.Node{
+		NodeType: datatypes.NodeTypeComparisonExpression,
+		Value:    &datatypes.Node_Comparison_{Comparison: op},
+		Children: []*datatypes.Node{
+			{
+				NodeType: datatypes.NodeTypeTagRef,
+				Value:    &datatypes.Node_TagRefValue{TagRefValue: tag},
+			},
+			value,
+		},
+	}
+}
+
+func stringNode(v string) *datatypes.Node {
+	return &datatypes.Node{
+		NodeType: datatypes.NodeTypeLiteral,
+		Value:    &datatypes.Node_StringValue{StringValue: v},
+	}
+}
+
+func regexNode(v string) *datatypes.Node {
+	return &datatypes.Node{
+		NodeType: datatypes.NodeTypeLiteral,
+		Value:    &datatypes.Node_RegexValue{RegexValue: "^(?:" + v + ")$"},
+	}
+}
+
+// Labels converts the tags of a series into labels sorted by name, with the
+// measurement as metric name. The field is kept as the _field label, unless
+// it is the value field samples are written to, so the series of different
+// fields of a measurement have different labels and written series are read
+// back with the labels they were written with.
+func Labels(tags models.Tags) []Label {
+	labels := make([]Label, 0, len(tags))
+	for _, t := range tags {
+		switch string(t.Key) {
+		case models.MeasurementTagKey, measurementTagKey:
+			labels = append(labels, Label{Name: nameLabel, Value: string(t.Value)})
+		case models.FieldKeyTagKey, fieldLabel:
+			if string(t.Value) != valueField {
+				labels = append(labels, Label{Name: fieldLabel, Value: string(t.Value)})
+			}
+		default:
+			labels = append(labels, Label{Name: string(t.Key), Value: string(t.Value)})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
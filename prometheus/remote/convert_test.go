@@ -0,0 +1,107 @@
+package remote_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/prometheus/remote"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoints(t *testing.T) {
+	req := &remote.WriteRequest{
+		Timeseries: []remote.TimeSeries{
+			{
+				Labels: []remote.Label{
+					{Name: "__name__", Value: "ups_load_percent"},
+					{Name: "ups", Value: "rack1"},
+				},
+				Samples: []remote.Sample{
+					{Value: 42, Timestamp: 1000},
+					{Value: math.NaN(), Timestamp: 2000},
+					{Value: 43, Timestamp: 3000},
+				},
+			},
+		},
+	}
+
+	// the request must survive the wire format
+	b, err := proto.Marshal(req)
+	require.NoError(t, err)
+	var decoded remote.WriteRequest
+	require.NoError(t, proto.Unmarshal(b, &decoded))
+
+	points, err := remote.Points(&decoded)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+
+	assert.Equal(t, "ups_load_percent", string(points[0].Name()))
+	assert.Equal(t, "rack1", string(points[0].Tags().GetString("ups")))
+	assert.Equal(t, int64(1000*1e6), points[0].UnixNano())
+	fields, err := points[0].Fields()
+	require.NoError(t, err)
+	assert.Equal(t, models.Fields{"value": 42.0}, fields)
+	assert.Equal(t, int64(3000*1e6), points[1].UnixNano())
+}
+
+func TestPoints_MissingName(t *testing.T) {
+	_, err := remote.Points(&remote.WriteRequest{
+		Timeseries: []remote.TimeSeries{
+			{
+				Labels:  []remote.Label{{Name: "ups", Value: "rack1"}},
+				Samples: []remote.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestPredicate(t *testing.T) {
+	pred, err := remote.Predicate(&remote.Query{
+		Matchers: []*remote.LabelMatcher{
+			{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "ups_load_percent"},
+			{Type: remote.LabelMatcher_RE, Name: "ups", Value: "rack.*"},
+			{Type: remote.LabelMatcher_NEQ, Name: "site", Value: "lab"},
+			{Type: remote.LabelMatcher_EQ, Name: "_field", Value: "gauge"},
+		},
+	})
+	require.NoError(t, err)
+
+	// the printer does not parenthesize nested expressions
+	exp := "'\xff' = \"value\" OR '\xff' = \"gauge\" OR '\xff' = \"counter\"" +
+		" AND '\x00' = \"ups_load_percent\"" +
+		" AND 'ups' =~ /^(?:rack.*)$/" +
+		" AND 'site' != \"lab\"" +
+		" AND '\xff' = \"gauge\""
+	got := reads.PredicateToExprString(pred)
+	assert.Equal(t, exp, got)
+}
+
+func TestLabels(t *testing.T) {
+	tags := models.NewTags(map[string]string{
+		models.MeasurementTagKey: "ups_load_percent",
+		models.FieldKeyTagKey:    "value",
+		"ups":                    "rack1",
+	})
+	assert.Equal(t, []remote.Label{
+		{Name: "__name__", Value: "ups_load_percent"},
+		{Name: "ups", Value: "rack1"},
+	}, remote.Labels(tags))
+
+	// the series of the other fields keep their field, with the tag keys
+	// of the series read from the storage engine
+	tags = models.NewTags(map[string]string{
+		"_measurement": "ups_load_percent",
+		"_field":       "gauge",
+		"ups":          "rack1",
+	})
+	assert.Equal(t, []remote.Label{
+		{Name: "__name__", Value: "ups_load_percent"},
+		{Name: "_field", Value: "gauge"},
+		{Name: "ups", Value: "rack1"},
+	}, remote.Labels(tags))
+}
@@ -0,0 +1,317 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"go.uber.org/zap"
+)
+
+const (
+	prefixPrometheus = "/api/v2/prometheus"
+
+	// maxRequestBytes bounds the size of compressed requests.
+	maxRequestBytes = 32 << 20
+)
+
+// HTTPHandler serves the Prometheus remote_write and remote_read endpoints.
+type HTTPHandler struct {
+	chi.Router
+
+	log *zap.Logger
+	api *kithttp.API
+
+	orgs    influxdb.OrganizationService
+	buckets influxdb.BucketService
+	writer  storage.PointsWriter
+	store   reads.Store
+}
+
+// NewHTTPHandler constructs a new http server for the Prometheus remote storage protocol.
+func NewHTTPHandler(log *zap.Logger, orgs influxdb.OrganizationService, buckets influxdb.BucketService, writer storage.PointsWriter, store reads.Store) *HTTPHandler {
+	h := &HTTPHandler{
+		log:     log,
+		api:     kithttp.NewAPI(kithttp.WithLog(log)),
+		orgs:    orgs,
+		buckets: buckets,
+		writer:  writer,
+		store:   store,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Post("/write", h.handleWrite)
+		r.Post("/read", h.handleRead)
+	})
+
+	h.Router = r
+
+	return h
+}
+
+// Prefix provides the prefix to this route tree.
+func (h *HTTPHandler) Prefix() string {
+	return prefixPrometheus
+}
+
+// handleWrite writes the samples of a snappy compressed WriteRequest to the
+// bucket provided by the bucket or bucketID query parameter.
+func (h *HTTPHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bucket, err := h.findBucket(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, bucket.ID, bucket.OrgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req WriteRequest
+	if err := decodeRequest(r, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	points, err := Points(&req)
+	if err != nil {
+		h.api.Err(w, r, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "invalid write request",
+			Err:  err,
+		})
+		return
+	}
+
+	if err := h.writer.WritePoints(ctx, bucket.OrgID, bucket.ID, points); err != nil {
+		h.api.Err(w, r, &errors2.Error{
+			Code: errors2.EInternal,
+			Msg:  "unexpected error writing points to database",
+			Err:  err,
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRead answers the queries of a snappy compressed ReadRequest from the
+// bucket provided by the bucket or bucketID query parameter.
+func (h *HTTPHandler) handleRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bucket, err := h.findBucket(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, bucket.ID, bucket.OrgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req ReadRequest
+	if err := decodeRequest(r, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	resp := &ReadResponse{Results: make([]*QueryResult, 0, len(req.Queries))}
+	for _, q := range req.Queries {
+		res, err := h.read(ctx, bucket, q)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		resp.Results = append(resp.Results, res)
+	}
+
+	b, err := proto.Marshal(resp)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Header().Set("Content-Encoding", "snappy")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(snappy.Encode(nil, b)); err != nil {
+		h.log.Debug("Failed to write read response", zap.Error(err))
+	}
+}
+
+func (h *HTTPHandler) read(ctx context.Context, bucket *influxdb.Bucket, q *Query) (*QueryResult, error) {
+	pred, err := Predicate(q)
+	if err != nil {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "invalid read request",
+			Err:  err,
+		}
+	}
+
+	src, err := types.MarshalAny(h.store.GetSource(uint64(bucket.OrgID), uint64(bucket.ID)))
+	if err != nil {
+		return nil, err
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = src
+	req.Predicate = pred
+	req.Range.Start = q.StartTimestampMs * 1e6
+	// the end timestamp of the query is inclusive
+	req.Range.End = q.EndTimestampMs*1e6 + 1
+
+	rs, err := h.store.ReadFilter(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	res := &QueryResult{}
+	if rs == nil {
+		return res, nil
+	}
+	defer rs.Close()
+
+	for rs.Next() {
+		ts := &TimeSeries{Labels: Labels(rs.Tags())}
+		ts.Samples = readSamples(rs.Cursor())
+		if len(ts.Samples) > 0 {
+			res.Timeseries = append(res.Timeseries, ts)
+		}
+	}
+	return res, rs.Err()
+}
+
+// readSamples reads the numeric values of the cursor as samples.
+func readSamples(cur cursors.Cursor) []Sample {
+	defer cur.Close()
+
+	var samples []Sample
+	switch c := cur.(type) {
+	case cursors.FloatArrayCursor:
+		for a := c.Next(); a.Len() > 0; a = c.Next() {
+			for i, v := range a.Values {
+				samples = append(samples, Sample{Value: v, Timestamp: a.Timestamps[i] / 1e6})
+			}
+		}
+	case cursors.IntegerArrayCursor:
+		for a := c.Next(); a.Len() > 0; a = c.Next() {
+			for i, v := range a.Values {
+				samples = append(samples, Sample{Value: float64(v), Timestamp: a.Timestamps[i] / 1e6})
+			}
+		}
+	case cursors.UnsignedArrayCursor:
+		for a := c.Next(); a.Len() > 0; a = c.Next() {
+			for i, v := range a.Values {
+				samples = append(samples, Sample{Value: float64(v), Timestamp: a.Timestamps[i] / 1e6})
+			}
+		}
+	}
+	return samples
+}
+
+// findBucket finds the bucket provided by the bucket or bucketID query
+// parameter, in the organization provided by the org or orgID query parameter.
+func (h *HTTPHandler) findBucket(ctx context.Context, r *http.Request) (*influxdb.Bucket, error) {
+	qp := r.URL.Query()
+
+	var orgFilter influxdb.OrganizationFilter
+	if rawID := qp.Get("orgID"); rawID != "" {
+		id, err := platform.IDFromString(rawID)
+		if err != nil {
+			return nil, &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "invalid orgID provided",
+				Err:  err,
+			}
+		}
+		orgFilter.ID = id
+	} else if name := qp.Get("org"); name != "" {
+		orgFilter.Name = &name
+	} else {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "Please provide either orgID or org",
+		}
+	}
+	org, err := h.orgs.FindOrganization(ctx, orgFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketFilter := influxdb.BucketFilter{OrganizationID: &org.ID}
+	if rawID := qp.Get("bucketID"); rawID != "" {
+		id, err := platform.IDFromString(rawID)
+		if err != nil {
+			return nil, &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "invalid bucketID provided",
+				Err:  err,
+			}
+		}
+		bucketFilter.ID = id
+	} else if name := qp.Get("bucket"); name != "" {
+		bucketFilter.Name = &name
+	} else {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "Please provide either bucketID or bucket",
+		}
+	}
+	return h.buckets.FindBucket(ctx, bucketFilter)
+}
+
+// decodeRequest decodes the snappy compressed protobuf body of the request.
+func decodeRequest(r *http.Request, m proto.Message) error {
+	compressed, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(compressed) > maxRequestBytes {
+		return &errors2.Error{
+			Code: errors2.ETooLarge,
+			Msg:  fmt.Sprintf("request exceeds the maximum size of %d bytes", maxRequestBytes),
+		}
+	}
+
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "invalid snappy compressed body",
+			Err:  err,
+		}
+	}
+	if err := proto.Unmarshal(b, m); err != nil {
+		return &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "invalid protobuf body",
+			Err:  err,
+		}
+	}
+	return nil
+}
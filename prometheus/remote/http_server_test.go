@@ -0,0 +1,109 @@
+package remote_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/prometheus/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	orgID, bucketID := platform.ID(1), platform.ID(2)
+
+	bucketAuth := func(action influxdb.Action) *influxdb.Authorization {
+		p, err := influxdb.NewPermissionAtID(bucketID, action, influxdb.BucketsResourceType, orgID)
+		require.NoError(t, err)
+		return &influxdb.Authorization{OrgID: orgID, Status: influxdb.Active, Permissions: []influxdb.Permission{*p}}
+	}
+
+	encode := func(t *testing.T, m proto.Message) []byte {
+		t.Helper()
+
+		b, err := proto.Marshal(m)
+		require.NoError(t, err)
+		return snappy.Encode(nil, b)
+	}
+	writeReq := &remote.WriteRequest{
+		Timeseries: []remote.TimeSeries{
+			{
+				Labels: []remote.Label{
+					{Name: "__name__", Value: "ups_load_percent"},
+					{Name: "ups", Value: "rack1"},
+				},
+				Samples: []remote.Sample{{Value: 42, Timestamp: 1000}},
+			},
+		},
+	}
+	unnamedReq := &remote.WriteRequest{
+		Timeseries: []remote.TimeSeries{
+			{
+				Labels:  []remote.Label{{Name: "ups", Value: "rack1"}},
+				Samples: []remote.Sample{{Value: 42, Timestamp: 1000}},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		auth   *influxdb.Authorization
+		body   []byte
+		status int
+		points int
+	}{
+		{name: "write", path: "/write?org=rg&bucket=ups", auth: bucketAuth(influxdb.WriteAction), body: encode(t, writeReq), status: http.StatusNoContent, points: 1},
+		{name: "write with bucket ID", path: "/write?orgID=" + orgID.String() + "&bucketID=" + bucketID.String(), auth: bucketAuth(influxdb.WriteAction), body: encode(t, writeReq), status: http.StatusNoContent, points: 1},
+		{name: "write without org", path: "/write?bucket=ups", auth: bucketAuth(influxdb.WriteAction), body: encode(t, writeReq), status: http.StatusBadRequest},
+		{name: "write without bucket", path: "/write?org=rg", auth: bucketAuth(influxdb.WriteAction), body: encode(t, writeReq), status: http.StatusBadRequest},
+		{name: "write with a read token", path: "/write?org=rg&bucket=ups", auth: bucketAuth(influxdb.ReadAction), body: encode(t, writeReq), status: http.StatusUnauthorized},
+		{name: "write uncompressed", path: "/write?org=rg&bucket=ups", auth: bucketAuth(influxdb.WriteAction), body: []byte("ups_load_percent 42"), status: http.StatusBadRequest},
+		{name: "write invalid protobuf", path: "/write?org=rg&bucket=ups", auth: bucketAuth(influxdb.WriteAction), body: snappy.Encode(nil, []byte{0xff, 0xff, 0xff}), status: http.StatusBadRequest},
+		{name: "write series without name", path: "/write?org=rg&bucket=ups", auth: bucketAuth(influxdb.WriteAction), body: encode(t, unnamedReq), status: http.StatusBadRequest},
+		{name: "read with a write token", path: "/read?org=rg&bucket=ups", auth: bucketAuth(influxdb.WriteAction), body: encode(t, &remote.ReadRequest{}), status: http.StatusUnauthorized},
+		{name: "read uncompressed", path: "/read?org=rg&bucket=ups", auth: bucketAuth(influxdb.ReadAction), body: []byte("{}"), status: http.StatusBadRequest},
+		{name: "read invalid protobuf", path: "/read?org=rg&bucket=ups", auth: bucketAuth(influxdb.ReadAction), body: snappy.Encode(nil, []byte{0xff, 0xff, 0xff}), status: http.StatusBadRequest},
+		{name: "read without queries", path: "/read?org=rg&bucket=ups", auth: bucketAuth(influxdb.ReadAction), body: encode(t, &remote.ReadRequest{}), status: http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := &mock.OrganizationService{
+				FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+					return &influxdb.Organization{ID: orgID, Name: "rg"}, nil
+				},
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return &influxdb.Bucket{ID: bucketID, OrgID: orgID, Name: "ups"}, nil
+			}
+			writer := &mock.PointsWriter{}
+
+			h := remote.NewHTTPHandler(zaptest.NewLogger(t), orgs, buckets, writer, nil)
+			r := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader(tt.body))
+			r = r.WithContext(icontext.SetAuthorizer(context.Background(), tt.auth))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			assert.Len(t, writer.Points, tt.points)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "snappy", w.Header().Get("Content-Encoding"))
+				b, err := snappy.Decode(nil, w.Body.Bytes())
+				require.NoError(t, err)
+				var resp remote.ReadResponse
+				require.NoError(t, proto.Unmarshal(b, &resp))
+				assert.Empty(t, resp.Results)
+			}
+		})
+	}
+}
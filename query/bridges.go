@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"context"
 	"io"
+	"sync/atomic"
 
 	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/metadata"
 	platform "github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/check"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
@@ -126,6 +128,10 @@ func (i *asyncStatsResultIterator) Statistics() flux.Statistics {
 	return i.stats
 }
 
+// ResponseRowsMetadataKey is the statistics metadata key for the number of
+// rows encoded into the response by the ProxyQueryServiceAsyncBridge.
+const ResponseRowsMetadataKey = "influxdb/response-rows"
+
 // ProxyQueryServiceAsyncBridge implements ProxyQueryService while consuming an AsyncQueryService
 type ProxyQueryServiceAsyncBridge struct {
 	AsyncQueryService AsyncQueryService
@@ -143,11 +149,16 @@ func (b ProxyQueryServiceAsyncBridge) Query(ctx context.Context, w io.Writer, re
 	results := flux.NewResultIteratorFromQuery(q)
 	defer results.Release()
 
+	rows := &rowCountingResultIterator{ResultIterator: results}
 	encoder := req.Dialect.Encoder()
-	_, err = encoder.Encode(w, results)
+	_, err = encoder.Encode(w, rows)
 	// Release the results and collect the statistics regardless of the error.
 	results.Release()
 	stats := results.Statistics()
+	md := make(metadata.Metadata, len(stats.Metadata)+1)
+	md.AddAll(stats.Metadata)
+	md.Add(ResponseRowsMetadataKey, rows.Rows())
+	stats.Metadata = md
 	if err != nil {
 		return stats, tracing.LogError(span, err)
 	}
@@ -206,3 +217,50 @@ func newBufferedReadCloser(r io.ReadCloser) *bufferedReadCloser {
 func (br *bufferedReadCloser) Close() error {
 	return br.r.Close()
 }
+
+// rowCountingResultIterator counts the rows of all tables read from its results.
+type rowCountingResultIterator struct {
+	flux.ResultIterator
+	rows int64
+}
+
+func (ri *rowCountingResultIterator) Next() flux.Result {
+	return rowCountingResult{Result: ri.ResultIterator.Next(), rows: &ri.rows}
+}
+
+// Rows reports the number of rows read so far.
+func (ri *rowCountingResultIterator) Rows() int64 {
+	return atomic.LoadInt64(&ri.rows)
+}
+
+type rowCountingResult struct {
+	flux.Result
+	rows *int64
+}
+
+func (r rowCountingResult) Tables() flux.TableIterator {
+	return rowCountingTableIterator{TableIterator: r.Result.Tables(), rows: r.rows}
+}
+
+type rowCountingTableIterator struct {
+	flux.TableIterator
+	rows *int64
+}
+
+func (ti rowCountingTableIterator) Do(f func(flux.Table) error) error {
+	return ti.TableIterator.Do(func(tbl flux.Table) error {
+		return f(rowCountingTable{Table: tbl, rows: ti.rows})
+	})
+}
+
+type rowCountingTable struct {
+	flux.Table
+	rows *int64
+}
+
+func (t rowCountingTable) Do(f func(flux.ColReader) error) error {
+	return t.Table.Do(func(cr flux.ColReader) error {
+		atomic.AddInt64(t.rows, int64(cr.Len()))
+		return f(cr)
+	})
+}
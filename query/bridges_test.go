@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -25,6 +26,57 @@ func (w failWriter) Write(p []byte) (int, error) {
 	return len(p) / 2, w.Err
 }
 
+func TestProxyQueryServiceAsyncBridge_ResponseRows(t *testing.T) {
+	q := mock.NewQuery()
+	r := executetest.NewResult([]*executetest.Table{
+		{
+			KeyCols: []string{"t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "t0", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{"a", 1.0},
+				{"a", 2.0},
+			},
+		},
+		{
+			KeyCols: []string{"t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "t0", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{"b", 3.0},
+			},
+		},
+	})
+	r.Nm = "a"
+	q.SetResults(r)
+
+	bridge := query.ProxyQueryServiceAsyncBridge{
+		AsyncQueryService: &mock.AsyncQueryService{
+			QueryF: func(ctx context.Context, req *query.Request) (flux.Query, error) {
+				return q, nil
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	stats, err := bridge.Query(context.Background(), &buf, &query.ProxyRequest{
+		Request: query.Request{OrganizationID: 0x1234},
+		Dialect: csv.DefaultDialect(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := stats.Metadata[query.ResponseRowsMetadataKey]
+	if len(rows) != 1 || rows[0] != int64(3) {
+		t.Fatalf("unexpected response rows: exp [3], got %v", rows)
+	}
+}
+
 func TestProxyQueryServiceAsyncBridge_StatsOnClientDisconnect(t *testing.T) {
 	q := mock.NewQuery()
 	q.Metadata = metadata.Metadata{
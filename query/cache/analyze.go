@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// cacheableImports are the packages whose functions do not read data, or
+// side effects, so their results only depend on their arguments.
+var cacheableImports = map[string]bool{
+	"array":       true,
+	"date":        true,
+	"interpolate": true,
+	"math":        true,
+	"regexp":      true,
+	"strings":     true,
+	"types":       true,
+}
+
+// uncacheableFunctions are the universe functions whose results depend on
+// more than the data read by the query, or that have side effects.
+var uncacheableFunctions = map[string]bool{
+	"buckets":    true,
+	"now":        true,
+	"systemTime": true,
+	"to":         true,
+}
+
+// Analysis describes a cacheable query.
+type Analysis struct {
+	// Query is the normalized text of the query.
+	Query string
+	// Buckets are the buckets read by the query.
+	Buckets []Bucket
+	// Start and Stop are the absolute time bounds read by the query.
+	Start, Stop time.Time
+}
+
+// Analyze reports if the query is cacheable, which requires all data to be
+// read from literal buckets and absolute time ranges, resolving identifiers
+// against the extern options. The returned error explains why a query is not
+// cacheable.
+func Analyze(file *ast.File, extern *ast.File) (*Analysis, error) {
+	a := &analyzer{scope: make(map[string]ast.Expression)}
+	if extern != nil {
+		if err := a.declare(extern); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.declare(file); err != nil {
+		return nil, err
+	}
+
+	ast.Walk(a, file)
+	if a.err != nil {
+		return nil, a.err
+	}
+	if len(a.buckets) == 0 {
+		return nil, fmt.Errorf("query does not read from a bucket")
+	}
+	if a.start.IsZero() || a.stop.IsZero() {
+		return nil, fmt.Errorf("query does not read an absolute time range")
+	}
+
+	normalized := ast.Format(file)
+	if extern != nil {
+		normalized = ast.Format(extern) + "\n" + normalized
+	}
+	return &Analysis{
+		Query:   normalized,
+		Buckets: a.buckets,
+		Start:   a.start,
+		Stop:    a.stop,
+	}, nil
+}
+
+type analyzer struct {
+	scope map[string]ast.Expression
+
+	buckets     []Bucket
+	start, stop time.Time
+	err         error
+}
+
+// declare checks the imports of the file and records its top level variable
+// and option assignments.
+func (a *analyzer) declare(file *ast.File) error {
+	for _, imp := range file.Imports {
+		if !cacheableImports[imp.Path.Value] {
+			return fmt.Errorf("package %q is not cacheable", imp.Path.Value)
+		}
+	}
+	for _, stmt := range file.Body {
+		var assignment ast.Assignment
+		switch s := stmt.(type) {
+		case *ast.VariableAssignment:
+			assignment = s
+		case *ast.OptionStatement:
+			assignment = s.Assignment
+		}
+		if va, ok := assignment.(*ast.VariableAssignment); ok {
+			a.scope[va.ID.Name] = va.Init
+		}
+	}
+	return nil
+}
+
+func (a *analyzer) Visit(node ast.Node) ast.Visitor {
+	if a.err != nil {
+		return nil
+	}
+	if call, ok := node.(*ast.CallExpression); ok {
+		a.err = a.call(call)
+	}
+	return a
+}
+
+func (a *analyzer) Done(node ast.Node) {}
+
+func (a *analyzer) call(call *ast.CallExpression) error {
+	ident, ok := call.Callee.(*ast.Identifier)
+	if !ok {
+		// functions of imported packages have been checked with the imports
+		return nil
+	}
+	if uncacheableFunctions[ident.Name] {
+		return fmt.Errorf("function %q is not cacheable", ident.Name)
+	}
+
+	switch ident.Name {
+	case "from":
+		return a.from(arguments(call))
+	case "range":
+		return a.timeRange(arguments(call))
+	}
+	return nil
+}
+
+func (a *analyzer) from(args map[string]ast.Expression) error {
+	for k := range args {
+		if k != "bucket" && k != "bucketID" {
+			return fmt.Errorf("from parameter %q is not cacheable", k)
+		}
+	}
+
+	if lit, ok := args["bucket"].(*ast.StringLiteral); ok {
+		a.buckets = append(a.buckets, Bucket{Name: lit.Value})
+		return nil
+	}
+	if lit, ok := args["bucketID"].(*ast.StringLiteral); ok {
+		id, err := platform.IDFromString(lit.Value)
+		if err != nil {
+			return err
+		}
+		a.buckets = append(a.buckets, Bucket{ID: *id})
+		return nil
+	}
+	return fmt.Errorf("from must read a literal bucket to be cacheable")
+}
+
+func (a *analyzer) timeRange(args map[string]ast.Expression) error {
+	start, err := a.time(args["start"])
+	if err != nil {
+		return fmt.Errorf("range start: %v", err)
+	}
+	stop, err := a.time(args["stop"])
+	if err != nil {
+		return fmt.Errorf("range stop: %v", err)
+	}
+
+	if a.start.IsZero() || start.Before(a.start) {
+		a.start = start
+	}
+	if stop.After(a.stop) {
+		a.stop = stop
+	}
+	return nil
+}
+
+// time resolves the expression to an absolute time.
+func (a *analyzer) time(expr ast.Expression) (time.Time, error) {
+	switch e := a.resolve(expr, 0).(type) {
+	case *ast.DateTimeLiteral:
+		return e.Value, nil
+	case nil:
+		return time.Time{}, fmt.Errorf("time is not set")
+	default:
+		return time.Time{}, fmt.Errorf("%s is not an absolute time", ast.Format(e))
+	}
+}
+
+// maxResolveDepth prevents cycles between assignments.
+const maxResolveDepth = 8
+
+// resolve follows identifiers and object members to their assigned expressions.
+func (a *analyzer) resolve(expr ast.Expression, depth int) ast.Expression {
+	if depth > maxResolveDepth {
+		return expr
+	}
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		if v, ok := a.scope[e.Name]; ok {
+			return a.resolve(v, depth+1)
+		}
+	case *ast.MemberExpression:
+		obj, ok := a.resolve(e.Object, depth+1).(*ast.ObjectExpression)
+		if !ok {
+			return expr
+		}
+		for _, p := range obj.Properties {
+			if p.Key.Key() == e.Property.Key() {
+				return a.resolve(p.Value, depth+1)
+			}
+		}
+	}
+	return expr
+}
+
+// arguments returns the arguments of the call by name.
+func arguments(call *ast.CallExpression) map[string]ast.Expression {
+	args := make(map[string]ast.Expression)
+	if len(call.Arguments) == 0 {
+		return args
+	}
+	if obj, ok := call.Arguments[0].(*ast.ObjectExpression); ok {
+		for _, p := range obj.Properties {
+			args[p.Key.Key()] = p.Value
+		}
+	}
+	return args
+}
@@ -0,0 +1,292 @@
+// Package cache implements a result cache for Flux queries over absolute,
+// past time ranges, such as the queries of dashboards refreshing repeatedly.
+//
+// Cached results are invalidated when data is written into or deleted from
+// the buckets and time ranges they cover.
+package cache
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Config configures a Cache.
+type Config struct {
+	// MaxBytes bounds the memory used by the cached responses.
+	MaxBytes int64
+	// MaxAge bounds the time a result stays cached, to limit the staleness
+	// caused by changes not observed by the cache, such as retention
+	// enforcement. A zero MaxAge keeps results until they are invalidated or
+	// evicted.
+	MaxAge time.Duration
+}
+
+// Entry is a cached query result.
+type Entry struct {
+	// OrgID is the organization that issued the query.
+	OrgID platform.ID
+	// Buckets are the buckets read by the query, by name as referenced
+	// by the query when available.
+	Buckets []Bucket
+	// Start and Stop are the absolute time bounds read by the query.
+	Start, Stop time.Time
+
+	// Response is the encoded response of the query.
+	Response []byte
+	// Statistics are the statistics of the execution that produced the response.
+	Statistics flux.Statistics
+
+	created time.Time
+}
+
+// Bucket is a bucket read by a cached query.
+type Bucket struct {
+	// Name is the name the query referenced the bucket with, empty if the
+	// query referenced the bucket by ID.
+	Name string
+	ID   platform.ID
+}
+
+func (e *Entry) size() int64 {
+	return int64(len(e.Response))
+}
+
+// overlaps reports if the entry covers any time between min and max,
+// given as inclusive unix nanosecond bounds.
+func (e *Entry) overlaps(min, max int64) bool {
+	return min < e.Stop.UnixNano() && max >= e.Start.UnixNano()
+}
+
+type item struct {
+	key   string
+	entry *Entry
+}
+
+// Cache is a memory bounded least recently used cache of query results.
+type Cache struct {
+	config Config
+	now    func() time.Time
+
+	mu       sync.Mutex
+	lru      *list.List
+	items    map[string]*list.Element
+	byBucket map[platform.ID]map[*list.Element]struct{}
+	size     int64
+
+	// filling counts the queries filling the cache by bucket, epoch
+	// advances whenever any such bucket is invalidated.
+	filling map[platform.ID]int
+	epoch   uint64
+
+	metrics *metrics
+}
+
+// New constructs an empty Cache.
+func New(config Config) *Cache {
+	return &Cache{
+		config:   config,
+		now:      time.Now,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		byBucket: make(map[platform.ID]map[*list.Element]struct{}),
+		filling:  make(map[platform.ID]int),
+		metrics:  newMetrics(),
+	}
+}
+
+// Get returns the entry cached under the key.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if ok && c.config.MaxAge > 0 && c.now().Sub(el.Value.(*item).entry.created) > c.config.MaxAge {
+		c.remove(el)
+		c.updateSize()
+		ok = false
+	}
+	if !ok {
+		c.metrics.misses.Inc()
+		return nil, false
+	}
+
+	c.metrics.hits.Inc()
+	c.lru.MoveToFront(el)
+	return el.Value.(*item).entry, true
+}
+
+// Fill tracks a query executing to fill the cache, so that writes into its
+// buckets during its execution prevent caching a stale response.
+type Fill struct {
+	c       *Cache
+	buckets []Bucket
+	epoch   uint64
+	done    bool
+}
+
+// Begin starts filling the cache with the result of a query reading the buckets.
+// Done must be called on the returned Fill once the query is done.
+func (c *Cache) Begin(buckets []Bucket) *Fill {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, b := range buckets {
+		c.filling[b.ID]++
+	}
+	return &Fill{c: c, buckets: buckets, epoch: c.epoch}
+}
+
+// Add caches the entry under the key unless the buckets of the fill have
+// been invalidated since it began.
+func (f *Fill) Add(key string, e *Entry) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+
+	if f.c.epoch != f.epoch {
+		return
+	}
+	f.c.add(key, e)
+}
+
+// Done ends the fill.
+func (f *Fill) Done() {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+
+	if f.done {
+		return
+	}
+	f.done = true
+	for _, b := range f.buckets {
+		if f.c.filling[b.ID]--; f.c.filling[b.ID] <= 0 {
+			delete(f.c.filling, b.ID)
+		}
+	}
+}
+
+// Add caches the entry under the key, evicting the least recently used
+// entries to stay within the memory bounds. Entries larger than the bounds
+// are not cached.
+func (c *Cache) Add(key string, e *Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(key, e)
+}
+
+// add adds the entry to the cache; c.mu must be held.
+func (c *Cache) add(key string, e *Entry) {
+	if e.size() > c.config.MaxBytes {
+		return
+	}
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	e.created = c.now()
+	el := c.lru.PushFront(&item{key: key, entry: e})
+	c.items[key] = el
+	for _, b := range e.Buckets {
+		els, ok := c.byBucket[b.ID]
+		if !ok {
+			els = make(map[*list.Element]struct{})
+			c.byBucket[b.ID] = els
+		}
+		els[el] = struct{}{}
+	}
+	c.size += e.size()
+
+	for c.size > c.config.MaxBytes {
+		c.remove(c.lru.Back())
+		c.metrics.evictions.Inc()
+	}
+	c.updateSize()
+}
+
+// Covers reports if any entry reads the bucket, or any query filling the
+// cache. It allows writers to skip computing the time bounds of writes into
+// buckets without cached results.
+func (c *Cache) Covers(bucketID platform.ID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.byBucket[bucketID]) > 0 || c.filling[bucketID] > 0
+}
+
+// Invalidate removes the entries reading the bucket between min and max,
+// given as inclusive unix nanosecond bounds.
+func (c *Cache) Invalidate(bucketID platform.ID, min, max int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.filling[bucketID] > 0 {
+		c.epoch++
+	}
+
+	var n int
+	for el := range c.byBucket[bucketID] {
+		if el.Value.(*item).entry.overlaps(min, max) {
+			c.remove(el)
+			n++
+		}
+	}
+	if n > 0 {
+		c.metrics.invalidations.Add(float64(n))
+		c.updateSize()
+	}
+}
+
+// InvalidateBucket removes the entries reading the bucket.
+func (c *Cache) InvalidateBucket(bucketID platform.ID) {
+	c.Invalidate(bucketID, math.MinInt64, math.MaxInt64)
+}
+
+// InvalidateAll removes all the entries.
+func (c *Cache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.filling) > 0 {
+		c.epoch++
+	}
+
+	n := c.lru.Len()
+	for c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+	if n > 0 {
+		c.metrics.invalidations.Add(float64(n))
+		c.updateSize()
+	}
+}
+
+// remove removes the element from the cache; c.mu must be held.
+func (c *Cache) remove(el *list.Element) {
+	it := el.Value.(*item)
+	c.lru.Remove(el)
+	delete(c.items, it.key)
+	for _, b := range it.entry.Buckets {
+		els := c.byBucket[b.ID]
+		delete(els, el)
+		if len(els) == 0 {
+			delete(c.byBucket, b.ID)
+		}
+	}
+	c.size -= it.entry.size()
+}
+
+// updateSize updates the size metrics; c.mu must be held.
+func (c *Cache) updateSize() {
+	c.metrics.size.Set(float64(c.size))
+	c.metrics.entries.Set(float64(len(c.items)))
+}
+
+// PrometheusCollectors implements prom.PrometheusCollector.
+func (c *Cache) PrometheusCollectors() []prometheus.Collector {
+	return c.metrics.collectors()
+}
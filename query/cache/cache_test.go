@@ -0,0 +1,189 @@
+package cache_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bucketA = platform.ID(1)
+	bucketB = platform.ID(2)
+)
+
+func newEntry(bucketID platform.ID, start, stop int64, size int) *cache.Entry {
+	return &cache.Entry{
+		Buckets:  []cache.Bucket{{ID: bucketID}},
+		Start:    time.Unix(0, start),
+		Stop:     time.Unix(0, stop),
+		Response: make([]byte, size),
+	}
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	c := cache.New(cache.Config{MaxBytes: 1024})
+	c.Add("a", newEntry(bucketA, 100, 200, 10))
+	c.Add("b", newEntry(bucketB, 100, 200, 10))
+
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	// the stop bound is exclusive
+	c.Invalidate(bucketA, 200, 300)
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+
+	c.Invalidate(bucketA, 50, 100)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	_, ok = c.Get("b")
+	assert.True(t, ok, "entries of other buckets must not be invalidated")
+	assert.False(t, c.Covers(bucketA))
+	assert.True(t, c.Covers(bucketB))
+}
+
+func TestCache_Evict(t *testing.T) {
+	c := cache.New(cache.Config{MaxBytes: 25})
+	c.Add("a", newEntry(bucketA, 100, 200, 10))
+	c.Add("b", newEntry(bucketA, 100, 200, 10))
+
+	// "a" becomes the most recently used
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	c.Add("c", newEntry(bucketA, 100, 200, 10))
+	_, ok = c.Get("b")
+	assert.False(t, ok, "the least recently used entry should be evicted")
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+
+	c.Add("d", newEntry(bucketA, 100, 200, 30))
+	_, ok = c.Get("d")
+	assert.False(t, ok, "entries larger than the cache must not be cached")
+}
+
+func TestCache_Fill(t *testing.T) {
+	c := cache.New(cache.Config{MaxBytes: 1024})
+
+	fill := c.Begin([]cache.Bucket{{ID: bucketA}})
+	assert.True(t, c.Covers(bucketA))
+	c.Invalidate(bucketA, 500, 600)
+	fill.Add("a", newEntry(bucketA, 100, 200, 10))
+	fill.Done()
+
+	_, ok := c.Get("a")
+	assert.False(t, ok, "writes during the fill must prevent caching the result")
+	assert.False(t, c.Covers(bucketA))
+
+	fill = c.Begin([]cache.Bucket{{ID: bucketA}})
+	fill.Add("a", newEntry(bucketA, 100, 200, 10))
+	fill.Done()
+
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+}
+
+func TestPointsWriter(t *testing.T) {
+	c := cache.New(cache.Config{MaxBytes: 1024})
+	c.Add("a", newEntry(bucketA, 100, 200, 10))
+
+	pw := &cache.PointsWriter{
+		Underlying: &mock.PointsWriter{},
+		Cache:      c,
+	}
+
+	write := func(times ...int64) {
+		t.Helper()
+		points := make([]models.Point, 0, len(times))
+		for _, ts := range times {
+			points = append(points, models.MustNewPoint("m", nil, models.Fields{"f": 1.0}, time.Unix(0, ts)))
+		}
+		require.NoError(t, pw.WritePoints(context.Background(), 1, bucketA, points))
+	}
+
+	write(10, 50)
+	write(300, 250)
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	write(250, 150, 300)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+}
+
+type storageService struct {
+	influxdb.StorageService
+}
+
+func (storageService) ImportShard(ctx context.Context, bucketID platform.ID, start, stop time.Time, r io.Reader) error {
+	return nil
+}
+
+func (storageService) ReshardBucket(ctx context.Context, bucketID platform.ID) (*influxdb.StorageReshardStep, error) {
+	return &influxdb.StorageReshardStep{}, nil
+}
+
+func TestStorageService(t *testing.T) {
+	c := cache.New(cache.Config{MaxBytes: 1024})
+	c.Add("a", newEntry(bucketA, 100, 200, 10))
+	c.Add("b", newEntry(bucketB, 100, 200, 10))
+
+	s := &cache.StorageService{StorageService: storageService{}, Cache: c}
+
+	require.NoError(t, s.ImportShard(context.Background(), bucketA, time.Unix(0, 300), time.Unix(0, 400), nil))
+	_, ok := c.Get("a")
+	require.True(t, ok, "imports outside the time range must not invalidate")
+
+	require.NoError(t, s.ImportShard(context.Background(), bucketA, time.Unix(0, 0), time.Unix(0, 150), nil))
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+
+	_, err := s.ReshardBucket(context.Background(), bucketB)
+	require.NoError(t, err)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+}
+
+type restoreService struct{}
+
+func (restoreService) RestoreKVStore(ctx context.Context, r io.Reader) error { return nil }
+
+func (restoreService) RestoreBucket(ctx context.Context, id platform.ID, rpiData []byte) (map[uint64]uint64, error) {
+	return nil, nil
+}
+
+func (restoreService) RestoreShard(ctx context.Context, shardID uint64, r io.Reader) error {
+	return nil
+}
+
+func TestRestoreService(t *testing.T) {
+	c := cache.New(cache.Config{MaxBytes: 1024})
+	c.Add("a", newEntry(bucketA, 100, 200, 10))
+	c.Add("b", newEntry(bucketB, 100, 200, 10))
+
+	s := &cache.RestoreService{Underlying: restoreService{}, Cache: c}
+
+	_, err := s.RestoreBucket(context.Background(), bucketA, nil)
+	require.NoError(t, err)
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	_, ok = c.Get("b")
+	require.True(t, ok, "entries of other buckets must not be invalidated")
+
+	// The bucket of a restored shard isn't known, so all the entries go.
+	require.NoError(t, s.RestoreShard(context.Background(), 1, nil))
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	assert.False(t, c.Covers(bucketB))
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+// PointsWriter invalidates the cached results covering the points written
+// through the underlying points writer.
+type PointsWriter struct {
+	Underlying storage.PointsWriter
+	Cache      *Cache
+}
+
+// WritePoints writes the points and invalidates the results covering them.
+func (w *PointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error {
+	// Invalidate even if the write fails, as some points may have been written.
+	defer w.invalidate(bucketID, points)
+	return w.Underlying.WritePoints(ctx, orgID, bucketID, points)
+}
+
+func (w *PointsWriter) invalidate(bucketID platform.ID, points []models.Point) {
+	if len(points) == 0 || !w.Cache.Covers(bucketID) {
+		return
+	}
+
+	min, max := points[0].UnixNano(), points[0].UnixNano()
+	for _, p := range points[1:] {
+		if t := p.UnixNano(); t < min {
+			min = t
+		} else if t > max {
+			max = t
+		}
+	}
+	w.Cache.Invalidate(bucketID, min, max)
+}
+
+// DeleteService invalidates the cached results covering the data deleted
+// through the underlying delete service.
+type DeleteService struct {
+	Underlying influxdb.DeleteService
+	Cache      *Cache
+}
+
+// DeleteBucketRangePredicate deletes the data and invalidates the results covering it.
+func (s *DeleteService) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate) error {
+	defer s.Cache.Invalidate(bucketID, min, max)
+	return s.Underlying.DeleteBucketRangePredicate(ctx, orgID, bucketID, min, max, pred)
+}
+
+// RestoreService invalidates the cached results of the data restored through
+// the underlying restore service.
+type RestoreService struct {
+	Underlying influxdb.RestoreService
+	Cache      *Cache
+}
+
+// RestoreKVStore restores the metadata and invalidates all the results, as
+// any bucket may be replaced.
+func (s *RestoreService) RestoreKVStore(ctx context.Context, r io.Reader) error {
+	defer s.Cache.InvalidateAll()
+	return s.Underlying.RestoreKVStore(ctx, r)
+}
+
+// RestoreBucket restores a bucket and invalidates the results reading it.
+func (s *RestoreService) RestoreBucket(ctx context.Context, id platform.ID, rpiData []byte) (map[uint64]uint64, error) {
+	defer s.Cache.InvalidateBucket(id)
+	return s.Underlying.RestoreBucket(ctx, id, rpiData)
+}
+
+// RestoreShard restores a shard and invalidates all the results, as the
+// bucket of the shard isn't known.
+func (s *RestoreService) RestoreShard(ctx context.Context, shardID uint64, r io.Reader) error {
+	defer s.Cache.InvalidateAll()
+	return s.Underlying.RestoreShard(ctx, shardID, r)
+}
+
+// StorageService invalidates the cached results of the data imported or
+// resharded through the underlying storage service.
+type StorageService struct {
+	influxdb.StorageService
+	Cache *Cache
+}
+
+// ImportShard imports the data and invalidates the results covering it.
+func (s *StorageService) ImportShard(ctx context.Context, bucketID platform.ID, start, stop time.Time, r io.Reader) error {
+	defer s.Cache.Invalidate(bucketID, start.UnixNano(), stop.UnixNano())
+	return s.StorageService.ImportShard(ctx, bucketID, start, stop, r)
+}
+
+// ReshardBucket reshards the bucket and invalidates the results reading it.
+func (s *StorageService) ReshardBucket(ctx context.Context, bucketID platform.ID) (*influxdb.StorageReshardStep, error) {
+	defer s.Cache.InvalidateBucket(bucketID)
+	return s.StorageService.ReshardBucket(ctx, bucketID)
+}
@@ -0,0 +1,54 @@
+package cache
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// metrics holds metrics related to the query result cache.
+type metrics struct {
+	hits          prometheus.Counter
+	misses        prometheus.Counter
+	evictions     prometheus.Counter
+	invalidations prometheus.Counter
+
+	size    prometheus.Gauge
+	entries prometheus.Gauge
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "qc_cache_hits_total",
+			Help: "Count of queries answered from the result cache",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "qc_cache_misses_total",
+			Help: "Count of cacheable queries not found in the result cache",
+		}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "qc_cache_evictions_total",
+			Help: "Count of results evicted from the result cache to stay within its memory bounds",
+		}),
+		invalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "qc_cache_invalidations_total",
+			Help: "Count of results invalidated by writes and deletes",
+		}),
+		size: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "qc_cache_size_bytes",
+			Help: "The memory used by the results in the result cache",
+		}),
+		entries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "qc_cache_entries",
+			Help: "Number of results in the result cache",
+		}),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.hits,
+		m.misses,
+		m.evictions,
+		m.invalidations,
+		m.size,
+		m.entries,
+	}
+}
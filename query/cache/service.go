@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/check"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/query"
+	"go.uber.org/zap"
+)
+
+// BucketFinder resolves the buckets referenced by name in queries.
+type BucketFinder interface {
+	FindBucketByName(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error)
+}
+
+// ProxyQueryService answers cacheable queries from the cache, and caches
+// the responses of the wrapped ProxyQueryService.
+type ProxyQueryService struct {
+	log     *zap.Logger
+	cache   *Cache
+	buckets BucketFinder
+	next    query.ProxyQueryService
+
+	now func() time.Time
+}
+
+// NewProxyQueryService wraps the ProxyQueryService with the cache.
+func NewProxyQueryService(log *zap.Logger, c *Cache, buckets BucketFinder, next query.ProxyQueryService) *ProxyQueryService {
+	return &ProxyQueryService{
+		log:     log,
+		cache:   c,
+		buckets: buckets,
+		next:    next,
+		now:     time.Now,
+	}
+}
+
+// Query answers the query from the cache when possible. Queries are only
+// cached when they read absolute time ranges lying entirely in the past.
+func (s *ProxyQueryService) Query(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	key, analysis, err := s.analyze(req)
+	if err != nil {
+		s.log.Debug("Query is not cacheable", zap.Error(err))
+		return s.next.Query(ctx, w, req)
+	}
+	orgID := req.Request.OrganizationID
+
+	if e, ok := s.cache.Get(key); ok {
+		if s.valid(ctx, e) {
+			if _, err := w.Write(e.Response); err != nil {
+				return flux.Statistics{}, err
+			}
+			return flux.Statistics{Metadata: e.Statistics.Metadata}, nil
+		}
+	}
+
+	buckets, err := s.findBuckets(ctx, orgID, analysis.Buckets)
+	if err != nil {
+		return s.next.Query(ctx, w, req)
+	}
+
+	fill := s.cache.Begin(buckets)
+	defer fill.Done()
+
+	buf := &limitedBuffer{max: s.cache.config.MaxBytes}
+	stats, err := s.next.Query(ctx, io.MultiWriter(w, buf), req)
+	if err != nil || buf.exceeded {
+		return stats, err
+	}
+
+	fill.Add(key, &Entry{
+		OrgID:      orgID,
+		Buckets:    buckets,
+		Start:      analysis.Start,
+		Stop:       analysis.Stop,
+		Response:   buf.Bytes(),
+		Statistics: stats,
+	})
+	return stats, nil
+}
+
+// Check checks the health of the wrapped ProxyQueryService.
+func (s *ProxyQueryService) Check(ctx context.Context) check.Response {
+	return s.next.Check(ctx)
+}
+
+// analyze returns the cache key of the request if it is cacheable.
+func (s *ProxyQueryService) analyze(req *query.ProxyRequest) (string, *Analysis, error) {
+	var (
+		file, extern *ast.File
+		now          time.Time
+	)
+	switch c := req.Request.Compiler.(type) {
+	case lang.FluxCompiler:
+		pkg := parser.ParseSource(c.Query)
+		if ast.Check(pkg) > 0 {
+			return "", nil, ast.GetError(pkg)
+		}
+		file = pkg.Files[0]
+		if len(c.Extern) > 0 {
+			extern = &ast.File{}
+			if err := json.Unmarshal(c.Extern, extern); err != nil {
+				return "", nil, err
+			}
+		}
+		now = c.Now
+	default:
+		return "", nil, fmt.Errorf("compiler type %q is not cacheable", req.Request.Compiler.CompilerType())
+	}
+	analysis, err := Analyze(file, extern)
+	if err != nil {
+		return "", nil, err
+	}
+	if now.IsZero() {
+		now = s.now()
+	}
+	if analysis.Stop.After(now) {
+		return "", nil, fmt.Errorf("query reads data up to %s, which is not in the past", analysis.Stop)
+	}
+
+	dialect, err := json.Marshal(req.Dialect)
+	if err != nil {
+		return "", nil, err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%T%s\n%s", req.Request.OrganizationID, req.Dialect, dialect, analysis.Query)
+	return hex.EncodeToString(h.Sum(nil)), analysis, nil
+}
+
+// findBuckets resolves the buckets referenced by name.
+func (s *ProxyQueryService) findBuckets(ctx context.Context, orgID platform.ID, refs []Bucket) ([]Bucket, error) {
+	buckets := make([]Bucket, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Name != "" {
+			b, err := s.buckets.FindBucketByName(ctx, orgID, ref.Name)
+			if err != nil {
+				return nil, err
+			}
+			ref.ID = b.ID
+		}
+		buckets = append(buckets, ref)
+	}
+	return buckets, nil
+}
+
+// valid reports if the cached entry can be served to the caller, which
+// must be allowed to read all its buckets. The buckets referenced by name
+// must still resolve to the same buckets.
+func (s *ProxyQueryService) valid(ctx context.Context, e *Entry) bool {
+	for _, ref := range e.Buckets {
+		if ref.Name != "" {
+			b, err := s.buckets.FindBucketByName(ctx, e.OrgID, ref.Name)
+			if err != nil || b.ID != ref.ID {
+				return false
+			}
+		}
+		if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, ref.ID, e.OrgID); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// limitedBuffer buffers writes until they exceed max bytes.
+type limitedBuffer struct {
+	bytes.Buffer
+	max      int64
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.exceeded {
+		return len(p), nil
+	}
+	if int64(b.Len()+len(p)) > b.max {
+		b.exceeded = true
+		b.Reset()
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
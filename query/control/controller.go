@@ -23,6 +23,7 @@ import (
 	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 
@@ -34,10 +35,12 @@ import (
 	"github.com/influxdata/flux/runtime"
 	"github.com/influxdata/influxdb/v2/kit/errors"
 	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/registry"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -60,6 +63,8 @@ type Controller struct {
 	abortOnce  sync.Once
 	abort      chan struct{}
 	memory     *memoryManager
+	orgs       *orgQuotaManager
+	registry   *registry.Registry
 
 	metrics   *controllerMetrics
 	labelKeys []string
@@ -114,6 +119,15 @@ type Config struct {
 	// The context value must be a string or an implementation of the Stringer interface.
 	MetricLabelKeys []string
 
+	// PerOrgQuota is the quota applied to the queries of each organization.
+	// It keeps the queries of a single organization from starving the
+	// queries of all other organizations.
+	PerOrgQuota OrgQuota
+
+	// Registry, when set, is where running queries are registered
+	// so they can be listed and killed.
+	Registry *registry.Registry
+
 	ExecutorDependencies []flux.Dependency
 }
 
@@ -166,7 +180,7 @@ func (c *Config) validate() error {
 			return fmt.Errorf("MaxMemoryBytes must be greater than or equal to the ConcurrencyQuota * InitialMemoryBytesQuotaPerQuery: %d < %d (%d * %d)", c.MaxMemoryBytes, minMemory, c.ConcurrencyQuota, c.InitialMemoryBytesQuotaPerQuery)
 		}
 	}
-	return nil
+	return c.PerOrgQuota.validate(c.InitialMemoryBytesQuotaPerQuery)
 }
 
 type QueryID uint64
@@ -185,7 +199,12 @@ func New(config Config, logger *zap.Logger) (*Controller, error) {
 		zap.Int64("initial_memory_bytes_quota_per_query", c.InitialMemoryBytesQuotaPerQuery),
 		zap.Int64("memory_bytes_quota_per_query", c.MemoryBytesQuotaPerQuery),
 		zap.Int64("max_memory_bytes", c.MaxMemoryBytes),
-		zap.Int32("queue_size", c.QueueSize))
+		zap.Int32("queue_size", c.QueueSize),
+		zap.Int32("org_concurrency_quota", c.PerOrgQuota.ConcurrencyQuota),
+		zap.Int32("org_queue_size", c.PerOrgQuota.QueueSize),
+		zap.Int64("org_memory_bytes", c.PerOrgQuota.MemoryBytes),
+		zap.Duration("org_query_time", c.PerOrgQuota.QueryTime),
+		zap.Duration("org_query_time_window", c.PerOrgQuota.QueryTimeWindow))
 
 	mm := &memoryManager{
 		initialBytesQuotaPerQuery: c.InitialMemoryBytesQuotaPerQuery,
@@ -200,6 +219,7 @@ func New(config Config, logger *zap.Logger) (*Controller, error) {
 	if c.ConcurrencyQuota == 0 {
 		queryQueue = nil
 	}
+	metrics := newControllerMetrics(metricLabelKeys)
 	ctrl := &Controller{
 		config:       c,
 		queries:      make(map[QueryID]*Query),
@@ -207,8 +227,10 @@ func New(config Config, logger *zap.Logger) (*Controller, error) {
 		done:         make(chan struct{}),
 		abort:        make(chan struct{}),
 		memory:       mm,
+		orgs:         newOrgQuotaManager(c.PerOrgQuota, c.InitialMemoryBytesQuotaPerQuery, metrics),
+		registry:     c.Registry,
 		log:          logger,
-		metrics:      newControllerMetrics(metricLabelKeys),
+		metrics:      metrics,
 		labelKeys:    metricLabelKeys,
 		dependencies: c.ExecutorDependencies,
 	}
@@ -258,6 +280,17 @@ func (c *Controller) query(ctx context.Context, compiler flux.Compiler) (flux.Qu
 		return nil, handleFluxError(err)
 	}
 
+	if c.registry != nil {
+		language, text := query.QueryText(compiler)
+		_, q.unregister = c.registry.Register(registry.Query{
+			OrgID:    q.orgID,
+			Language: language,
+			Query:    text,
+		}, q.Cancel, func() string {
+			return q.State().String()
+		})
+	}
+
 	if err := c.compileQuery(q, compiler); err != nil {
 		q.setErr(err)
 		c.finish(q)
@@ -305,8 +338,14 @@ func (c *Controller) createQuery(ctx context.Context, ct flux.CompilerType) (*Qu
 		c.metrics.allDur.WithLabelValues(labelValues...),
 		c.metrics.all.WithLabelValues(labelValues...),
 	)
+	var orgID platform.ID
+	if req := query.RequestFromContext(ctx); req != nil {
+		orgID = req.OrganizationID
+	}
+
 	q := &Query{
 		id:                 id,
+		orgID:              orgID,
 		labelValues:        labelValues,
 		compileLabelValues: compileLabelValues,
 		state:              Created,
@@ -398,11 +437,16 @@ func (c *Controller) enqueueQuery(q *Query) error {
 		}
 	}
 
+	if err := c.orgs.admit(q); err != nil {
+		return err
+	}
+
 	if c.queryQueue == nil {
 		// unlimited queries case
 		c.queriesMu.RLock()
 		defer c.queriesMu.RUnlock()
 		if c.shutdown {
+			c.orgs.unadmit(q)
 			return &flux.Error{
 				Code: codes.Internal,
 				Msg:  "controller is shutting down, query not runnable",
@@ -414,12 +458,13 @@ func (c *Controller) enqueueQuery(q *Query) error {
 		// unlimited queries, so start a goroutine for every query
 		go func() {
 			defer c.wg.Done()
-			c.executeQuery(q)
+			c.runQuery(q)
 		}()
 	} else {
 		select {
 		case c.queryQueue <- q:
 		default:
+			c.orgs.unadmit(q)
 			return &flux.Error{
 				Code: codes.ResourceExhausted,
 				Msg:  "queue length exceeded",
@@ -436,11 +481,24 @@ func (c *Controller) processQueryQueue() {
 		case <-c.done:
 			return
 		case q := <-c.queryQueue:
-			c.executeQuery(q)
+			c.runQuery(q)
 		}
 	}
 }
 
+// runQuery will execute the query once its organization is within its quota.
+// Otherwise the query is parked and executed by the runQuery call of the
+// query from the same organization that frees up the quota.
+func (c *Controller) runQuery(q *Query) {
+	if !c.orgs.tryStart(q) {
+		return
+	}
+	for q != nil {
+		c.executeQuery(q)
+		q = c.orgs.finish(q, q.executeDuration())
+	}
+}
+
 // executeQuery will execute a compiled program and wait for its completion.
 func (c *Controller) executeQuery(q *Query) {
 
@@ -475,7 +533,9 @@ func (c *Controller) executeQuery(q *Query) {
 		return
 	}
 
+	q.stateMu.Lock()
 	q.c.createAllocator(q)
+	q.stateMu.Unlock()
 	// Record unused memory before start.
 	q.recordUnusedMemory()
 	exec, err := q.program.Start(ctx, q.alloc)
@@ -496,6 +556,10 @@ func (c *Controller) waitForQuery(q *Query) {
 }
 
 func (c *Controller) finish(q *Query) {
+	if q.unregister != nil {
+		q.unregister()
+	}
+
 	c.queriesMu.Lock()
 	delete(c.queries, q.id)
 	if len(c.queries) == 0 && c.shutdown {
@@ -573,6 +637,25 @@ func (c *Controller) PrometheusCollectors() []prometheus.Collector {
 	return collectors
 }
 
+// OrgUsage reports the resource usage of every organization with queries
+// awaiting execution, executing queries, or query time used within the
+// current PerOrgQuota.QueryTimeWindow.
+func (c *Controller) OrgUsage() []OrgUsage {
+	usages := c.orgs.usage()
+
+	allocated := make(map[platform.ID]int64, len(usages))
+	c.queriesMu.RLock()
+	for _, q := range c.queries {
+		allocated[q.orgID] += q.allocatedMemory()
+	}
+	c.queriesMu.RUnlock()
+
+	for i := range usages {
+		usages[i].MemoryBytes = allocated[usages[i].OrgID]
+	}
+	return usages
+}
+
 func (c *Controller) GetUnusedMemoryBytes() int64 {
 	return c.memory.getUnusedMemoryBytes()
 }
@@ -583,7 +666,8 @@ func (c *Controller) GetUsedMemoryBytes() int64 {
 
 // Query represents a single request.
 type Query struct {
-	id QueryID
+	id    QueryID
+	orgID platform.ID
 
 	labelValues        []string
 	compileLabelValues []string
@@ -608,8 +692,15 @@ type Query struct {
 	exec    flux.Query
 	results chan flux.Result
 
+	// unregister removes the query from the registry of the controller.
+	unregister func()
+
 	memoryManager *queryMemoryManager
 	alloc         *memory.Allocator
+
+	// orgReservedMemoryBytes is the memory reserved against the memory
+	// quota of the organization. It is guarded by the orgQuotaManager.
+	orgReservedMemoryBytes int64
 }
 
 func (q *Query) ProfilerResults() (flux.ResultIterator, error) {
@@ -634,6 +725,27 @@ func (q *Query) ID() QueryID {
 	return q.id
 }
 
+// OrganizationID reports the ID of the organization that issued the query.
+func (q *Query) OrganizationID() platform.ID {
+	return q.orgID
+}
+
+func (q *Query) executeDuration() time.Duration {
+	q.stateMu.RLock()
+	defer q.stateMu.RUnlock()
+	return q.stats.ExecuteDuration
+}
+
+// allocatedMemory reports the memory currently allocated by an executing query.
+func (q *Query) allocatedMemory() int64 {
+	q.stateMu.RLock()
+	defer q.stateMu.RUnlock()
+	if q.alloc == nil || q.state != Executing {
+		return 0
+	}
+	return q.alloc.Allocated()
+}
+
 // Cancel will stop the query execution.
 func (q *Query) Cancel() {
 	// Call the cancel function to signal that execution should
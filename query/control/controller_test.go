@@ -23,6 +23,8 @@ import (
 	"github.com/influxdata/flux/stdlib/universe"
 	_ "github.com/influxdata/influxdb/v2/fluxinit/static"
 	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	pmock "github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/query/control"
@@ -868,6 +870,302 @@ func TestController_QueueSize(t *testing.T) {
 	}
 }
 
+func TestController_OrgQueueSize(t *testing.T) {
+	const (
+		orgA = platform.ID(1)
+		orgB = platform.ID(2)
+	)
+
+	config := config
+	config.ConcurrencyQuota = 1
+	config.QueueSize = 10
+	config.PerOrgQuota.QueueSize = 2
+	ctrl, err := control.New(config, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	executing := make(chan struct{}, 1)
+	compiler := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+					select {
+					case executing <- struct{}{}:
+					default:
+					}
+					<-done
+				},
+			}, nil
+		},
+	}
+
+	runQuery := func(orgID platform.ID) error {
+		q, err := ctrl.Query(context.Background(), makeOrgRequest(compiler, orgID))
+		if err != nil {
+			return err
+		}
+		go func() {
+			for range q.Results() {
+				// discard the results
+			}
+			q.Done()
+		}()
+		return nil
+	}
+
+	// Occupy the only executor so that every other query remains queued.
+	if err := runQuery(orgB); err != nil {
+		t.Fatal(err)
+	}
+	<-executing
+
+	for i := int32(0); i < config.PerOrgQuota.QueueSize; i++ {
+		if err := runQuery(orgA); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = runQuery(orgA)
+	if err == nil {
+		t.Fatal("expected an error about the organization queue length")
+	}
+	if got, want := errors2.ErrorCode(err), errors2.ETooManyRequests; got != want {
+		t.Fatalf("unexpected error code -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+
+	// Another organization is not affected by the queue of orgA.
+	if err := runQuery(orgB); err != nil {
+		t.Fatalf("unexpected error for another organization: %s", err)
+	}
+
+	usage := make(map[platform.ID]control.OrgUsage)
+	for _, u := range ctrl.OrgUsage() {
+		usage[u.OrgID] = u
+	}
+	if got, want := usage[orgA].Queued, int32(2); got != want {
+		t.Errorf("unexpected queued queries for orgA -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if got, want := usage[orgB].Executing, int32(1); got != want {
+		t.Errorf("unexpected executing queries for orgB -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	reg := setupPromRegistry(ctrl)
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := FindMetric(mfs, "qc_org_quota_rejections_total", map[string]string{
+		"reason": "queue_size",
+	})
+	if m == nil {
+		t.Fatal("expected a rejection to be recorded for orgA")
+	}
+	if got, want := m.GetCounter().GetValue(), float64(1); got != want {
+		t.Errorf("unexpected rejections -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+}
+
+func TestController_OrgForgottenWhenIdle(t *testing.T) {
+	ctrl, err := control.New(config, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	done := make(chan struct{})
+	executing := make(chan struct{})
+	compiler := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+					close(executing)
+					<-done
+				},
+			}, nil
+		},
+	}
+
+	const orgID = platform.ID(1)
+	q, err := ctrl.Query(context.Background(), makeOrgRequest(compiler, orgID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-executing
+
+	reg := setupPromRegistry(ctrl)
+	executingMetric := func() *dto.Metric {
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return FindMetric(mfs, "qc_org_executing_active", map[string]string{"org": orgID.String()})
+	}
+	if m := executingMetric(); m == nil || m.GetGauge().GetValue() != 1 {
+		t.Fatalf("expected one query executing for the organization, got %v", m)
+	}
+
+	close(done)
+	consumeResults(t, q)
+
+	// The organization is forgotten once its last query is done.
+	deadline := time.Now().Add(5 * time.Second)
+	for executingMetric() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the organization to be forgotten")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if usage := ctrl.OrgUsage(); len(usage) != 0 {
+		t.Fatalf("unexpected usage for an idle organization: %+v", usage)
+	}
+}
+
+func TestController_OrgConcurrencyQuota(t *testing.T) {
+	const (
+		orgA = platform.ID(1)
+		orgB = platform.ID(2)
+	)
+
+	config := config
+	config.ConcurrencyQuota = 2
+	config.QueueSize = 10
+	config.PerOrgQuota.ConcurrencyQuota = 1
+	ctrl, err := control.New(config, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	executing := make(chan platform.ID, 3)
+	release := map[platform.ID]chan struct{}{
+		orgA: make(chan struct{}),
+		orgB: make(chan struct{}),
+	}
+	compiler := func(orgID platform.ID) flux.Compiler {
+		return &mock.Compiler{
+			CompileFn: func(ctx context.Context) (flux.Program, error) {
+				return &mock.Program{
+					ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+						executing <- orgID
+						<-release[orgID]
+					},
+				}, nil
+			},
+		}
+	}
+
+	var wg sync.WaitGroup
+	runQuery := func(orgID platform.ID) {
+		q, err := ctrl.Query(context.Background(), makeOrgRequest(compiler(orgID), orgID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range q.Results() {
+				// discard the results
+			}
+			q.Done()
+		}()
+	}
+
+	// The second query of orgA must wait for the first one,
+	// even though there is a free executor.
+	runQuery(orgA)
+	if got := <-executing; got != orgA {
+		t.Fatalf("expected orgA to be executing, got %s", got)
+	}
+	runQuery(orgA)
+	runQuery(orgB)
+	if got := <-executing; got != orgB {
+		t.Fatalf("expected orgB to be executing, got %s", got)
+	}
+
+	select {
+	case <-executing:
+		t.Fatal("expected the second query of orgA to wait for the concurrency quota")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Finishing the first query of orgA starts the parked query.
+	release[orgA] <- struct{}{}
+	select {
+	case got := <-executing:
+		if got != orgA {
+			t.Fatalf("expected orgA to be executing, got %s", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the parked query of orgA to execute")
+	}
+
+	close(release[orgA])
+	close(release[orgB])
+	wg.Wait()
+}
+
+func TestController_OrgQueryTime(t *testing.T) {
+	config := config
+	config.PerOrgQuota.QueryTime = time.Millisecond
+	config.PerOrgQuota.QueryTimeWindow = time.Hour
+	ctrl, err := control.New(config, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	compiler := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+					time.Sleep(10 * time.Millisecond)
+				},
+			}, nil
+		},
+	}
+
+	const orgID = platform.ID(1)
+	q, err := ctrl.Query(context.Background(), makeOrgRequest(compiler, orgID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumeResults(t, q)
+
+	// The query time is accounted after the query is done,
+	// so wait for the controller to be notified.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		usage := ctrl.OrgUsage()
+		if len(usage) == 1 && usage[0].QueryTime >= config.PerOrgQuota.QueryTime {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for query time to be accounted: %+v", usage)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = ctrl.Query(context.Background(), makeOrgRequest(compiler, orgID))
+	if err == nil {
+		t.Fatal("expected an error about the organization query time quota")
+	}
+	if got, want := errors2.ErrorCode(err), errors2.ETooManyRequests; got != want {
+		t.Fatalf("unexpected error code -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+
+	// Another organization still has its full query time.
+	q, err = ctrl.Query(context.Background(), makeOrgRequest(compiler, platform.ID(2)))
+	if err != nil {
+		t.Fatalf("unexpected error for another organization: %s", err)
+	}
+	consumeResults(t, q)
+}
+
 // Test that rapidly starting and canceling the query and then calling done will correctly
 // cancel the query and not result in a race condition.
 func TestController_CancelDone_Unlimited(t *testing.T) {
@@ -1571,3 +1869,10 @@ func makeRequest(c flux.Compiler) *query.Request {
 		Compiler: c,
 	}
 }
+
+func makeOrgRequest(c flux.Compiler, orgID platform.ID) *query.Request {
+	return &query.Request{
+		OrganizationID: orgID,
+		Compiler:       c,
+	}
+}
@@ -0,0 +1,222 @@
+package control
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/query/registry"
+	"go.uber.org/zap"
+)
+
+// HTTPHandler exposes the state of the queries managed by a Controller, and
+// lists and kills the running queries of its registry.
+type HTTPHandler struct {
+	chi.Router
+
+	log *zap.Logger
+	api *kithttp.API
+
+	controller *Controller
+}
+
+// NewHTTPHandler constructs a new http server for the queries of the controller.
+func NewHTTPHandler(log *zap.Logger, c *Controller) *HTTPHandler {
+	h := &HTTPHandler{
+		log:        log,
+		api:        kithttp.NewAPI(kithttp.WithLog(log)),
+		controller: c,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetQueries)
+		r.Delete("/{id}", h.handleDeleteQuery)
+		r.Get("/usage", h.handleGetUsage)
+	})
+
+	h.Router = r
+
+	return h
+}
+
+// Prefix provides the prefix to this route tree.
+func (h *HTTPHandler) Prefix() string {
+	return registry.PrefixQueries
+}
+
+// authorizeOrg authorizes the action on the organization provided by the
+// orgID query parameter. Without an orgID, the request applies to all
+// organizations, which requires operator access, and a nil ID is returned.
+func (h *HTTPHandler) authorizeOrg(r *http.Request, action influxdb.Action) (*platform.ID, error) {
+	ctx := r.Context()
+
+	rawID := r.URL.Query().Get("orgID")
+	if rawID == "" {
+		if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+			return nil, forbidden(err)
+		}
+		return nil, nil
+	}
+
+	id, err := platform.IDFromString(rawID)
+	if err != nil {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "invalid orgID provided",
+			Err:  err,
+		}
+	}
+	p := influxdb.Permission{
+		Action:   action,
+		Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: id},
+	}
+	if err := authorizer.IsAllowed(ctx, p); err != nil {
+		return nil, forbidden(err)
+	}
+	return id, nil
+}
+
+// forbidden turns the error of a failed permission check into a forbidden
+// error, like the write and delete handlers do.
+func forbidden(err error) error {
+	if errors2.ErrorCode(err) != errors2.EUnauthorized {
+		return err
+	}
+	return &errors2.Error{
+		Code: errors2.EForbidden,
+		Msg:  "insufficient permissions for the queries",
+		Err:  err,
+	}
+}
+
+// handleGetQueries lists the running queries of the organization provided by
+// the orgID query parameter, or of all organizations without an orgID.
+func (h *HTTPHandler) handleGetQueries(w http.ResponseWriter, r *http.Request) {
+	orgID, err := h.authorizeOrg(r, influxdb.ReadAction)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	resp := registry.QueriesResponse{Queries: []registry.Query{}}
+	if reg := h.controller.registry; reg != nil {
+		resp.Queries = append(resp.Queries, reg.Queries(registry.Filter{OrgID: orgID})...)
+	}
+	h.api.Respond(w, r, http.StatusOK, resp)
+}
+
+// handleDeleteQuery kills a running query of the organization provided by
+// the orgID query parameter, which requires permission to write the
+// organization, or of any organization without an orgID.
+func (h *HTTPHandler) handleDeleteQuery(w http.ResponseWriter, r *http.Request) {
+	orgID, err := h.authorizeOrg(r, influxdb.WriteAction)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.api.Err(w, r, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "invalid query id provided",
+			Err:  err,
+		})
+		return
+	}
+
+	reg := h.controller.registry
+	if reg == nil {
+		h.api.Err(w, r, registry.ErrQueryNotFound)
+		return
+	}
+	if err := reg.Kill(id, registry.Filter{OrgID: orgID}); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type (
+	respOrgQuota struct {
+		ConcurrencyQuota int32  `json:"concurrency"`
+		QueueSize        int32  `json:"queueSize"`
+		MemoryBytes      int64  `json:"memoryBytes"`
+		QueryTime        string `json:"queryTime"`
+		QueryTimeWindow  string `json:"queryTimeWindow"`
+	}
+
+	respOrgUsage struct {
+		OrgID       platform.ID  `json:"orgID"`
+		Queued      int32        `json:"queued"`
+		Executing   int32        `json:"executing"`
+		MemoryBytes int64        `json:"memoryBytes"`
+		QueryTime   string       `json:"queryTime"`
+		WindowStart time.Time    `json:"windowStart"`
+		Quota       respOrgQuota `json:"quota"`
+	}
+
+	respOrgUsages struct {
+		Usage []respOrgUsage `json:"usage"`
+	}
+)
+
+// handleGetUsage reports the usage of the organization provided by the orgID
+// query parameter. Without an orgID, the usage of all organizations is reported,
+// which requires operator access.
+func (h *HTTPHandler) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	orgID, err := h.authorizeOrg(r, influxdb.ReadAction)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	resp := respOrgUsages{Usage: []respOrgUsage{}}
+	for _, u := range h.controller.OrgUsage() {
+		if orgID != nil && u.OrgID != *orgID {
+			continue
+		}
+		resp.Usage = append(resp.Usage, newRespOrgUsage(u))
+	}
+	if orgID != nil && len(resp.Usage) == 0 {
+		// an idle org still has a quota to report
+		resp.Usage = append(resp.Usage, newRespOrgUsage(OrgUsage{
+			OrgID: *orgID,
+			Quota: h.controller.config.PerOrgQuota,
+		}))
+	}
+
+	h.api.Respond(w, r, http.StatusOK, resp)
+}
+
+func newRespOrgUsage(u OrgUsage) respOrgUsage {
+	return respOrgUsage{
+		OrgID:       u.OrgID,
+		Queued:      u.Queued,
+		Executing:   u.Executing,
+		MemoryBytes: u.MemoryBytes,
+		QueryTime:   u.QueryTime.String(),
+		WindowStart: u.WindowStart,
+		Quota: respOrgQuota{
+			ConcurrencyQuota: u.Quota.ConcurrencyQuota,
+			QueueSize:        u.Quota.QueueSize,
+			MemoryBytes:      u.Quota.MemoryBytes,
+			QueryTime:        u.Quota.QueryTime.String(),
+			QueryTimeWindow:  u.Quota.QueryTimeWindow.String(),
+		},
+	}
+}
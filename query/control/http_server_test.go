@@ -0,0 +1,81 @@
+package control_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/query/control"
+	"github.com/influxdata/influxdb/v2/query/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHTTPHandler_KillQuery(t *testing.T) {
+	orgID, otherOrgID := platform.ID(0xff00), platform.ID(0xff01)
+
+	orgAuth := func(action influxdb.Action) *influxdb.Authorization {
+		return &influxdb.Authorization{
+			OrgID:  orgID,
+			Status: influxdb.Active,
+			Permissions: []influxdb.Permission{{
+				Action:   action,
+				Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &orgID},
+			}},
+		}
+	}
+	readAllAuth := &influxdb.Authorization{OrgID: orgID, Status: influxdb.Active, Permissions: influxdb.ReadAllPermissions()}
+	operAuth := &influxdb.Authorization{OrgID: orgID, Status: influxdb.Active, Permissions: influxdb.OperPermissions()}
+
+	for _, tt := range []struct {
+		name   string
+		auth   *influxdb.Authorization
+		method string
+		orgID  *platform.ID
+		status int
+		killed bool
+	}{
+		{name: "read-only token lists", auth: orgAuth(influxdb.ReadAction), method: http.MethodGet, orgID: &orgID, status: http.StatusOK},
+		{name: "read-only token kills", auth: orgAuth(influxdb.ReadAction), method: http.MethodDelete, orgID: &orgID, status: http.StatusForbidden},
+		{name: "write token kills", auth: orgAuth(influxdb.WriteAction), method: http.MethodDelete, orgID: &orgID, status: http.StatusNoContent, killed: true},
+		{name: "write token kills in another org", auth: orgAuth(influxdb.WriteAction), method: http.MethodDelete, orgID: &otherOrgID, status: http.StatusForbidden},
+		{name: "read all token lists all orgs", auth: readAllAuth, method: http.MethodGet, status: http.StatusForbidden},
+		{name: "read all token kills in all orgs", auth: readAllAuth, method: http.MethodDelete, status: http.StatusForbidden},
+		{name: "operator lists all orgs", auth: operAuth, method: http.MethodGet, status: http.StatusOK},
+		{name: "operator kills in all orgs", auth: operAuth, method: http.MethodDelete, status: http.StatusNoContent, killed: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := registry.New()
+			var killed bool
+			id, done := reg.Register(registry.Query{OrgID: orgID}, func() { killed = true }, nil)
+			defer done()
+
+			cfg := config
+			cfg.Registry = reg
+			ctrl, err := control.New(cfg, zaptest.NewLogger(t))
+			require.NoError(t, err)
+			defer shutdown(t, ctrl)
+
+			target := "/"
+			if tt.method == http.MethodDelete {
+				target += strconv.FormatUint(id, 10)
+			}
+			if tt.orgID != nil {
+				target += "?orgID=" + tt.orgID.String()
+			}
+			r := httptest.NewRequest(tt.method, target, nil)
+			r = r.WithContext(icontext.SetAuthorizer(context.Background(), tt.auth))
+			w := httptest.NewRecorder()
+			control.NewHTTPHandler(zaptest.NewLogger(t), ctrl).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			assert.Equal(t, tt.killed, killed)
+		})
+	}
+}
@@ -45,6 +45,8 @@ func (m *memoryManager) addUnusedMemoryBytes(amount int64) int64 {
 func (c *Controller) createAllocator(q *Query) {
 	q.memoryManager = &queryMemoryManager{
 		m:     c.memory,
+		orgs:  c.orgs,
+		q:     q,
 		limit: c.memory.initialBytesQuotaPerQuery,
 	}
 	q.alloc = &memory.Allocator{
@@ -57,6 +59,8 @@ func (c *Controller) createAllocator(q *Query) {
 // queryMemoryManager is a memory manager for a specific query.
 type queryMemoryManager struct {
 	m     *memoryManager
+	orgs  *orgQuotaManager
+	q     *Query
 	limit int64
 	given int64
 }
@@ -103,6 +107,21 @@ func (q *queryMemoryManager) RequestMemory(want int64) (got int64, err error) {
 			}
 		}
 
+		// The memory must also fit into the quota of the organization.
+		// Fall back to the bare amount wanted before giving up.
+		if !q.orgs.reserveMemory(q.q, given) {
+			if given == want || !q.orgs.reserveMemory(q.q, want) {
+				if !q.m.unlimited {
+					q.m.addUnusedMemoryBytes(given)
+				}
+				return 0, errors.New("organization memory quota exceeded")
+			}
+			if !q.m.unlimited {
+				q.m.addUnusedMemoryBytes(given - want)
+			}
+			given = want
+		}
+
 		// Successfully reserved the memory so update our own internal
 		// counter for the limit.
 		q.limit += given
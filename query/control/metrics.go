@@ -17,6 +17,11 @@ type controllerMetrics struct {
 	compilingDur *prometheus.HistogramVec
 	queueingDur  *prometheus.HistogramVec
 	executingDur *prometheus.HistogramVec
+
+	orgQueueing        *prometheus.GaugeVec
+	orgExecuting       *prometheus.GaugeVec
+	orgQueryTime       prometheus.Counter
+	orgQuotaRejections *prometheus.CounterVec
 }
 
 type requestsLabel string
@@ -88,6 +93,29 @@ func newControllerMetrics(labels []string) *controllerMetrics {
 			Help:    "Histogram of times spent executing queries",
 			Buckets: prometheus.ExponentialBuckets(1e-3, 5, 7),
 		}, labels),
+
+		orgQueueing: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "qc_org_queueing_active",
+			Help: "Number of queries of an organization awaiting execution",
+		}, []string{orgLabel}),
+
+		orgExecuting: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "qc_org_executing_active",
+			Help: "Number of queries of an organization actively executing",
+		}, []string{orgLabel}),
+
+		// The per organization gauges only have a series for the
+		// organizations with queries in flight, the counters are summed
+		// over all the organizations to keep the number of series bounded.
+		orgQueryTime: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "qc_org_query_time_seconds_total",
+			Help: "Total time spent executing the queries accounted against the organization quotas",
+		}),
+
+		orgQuotaRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "qc_org_quota_rejections_total",
+			Help: "Count of the queries rejected for exceeding the quota of their organization",
+		}, []string{"reason"}),
 	}
 }
 
@@ -107,5 +135,10 @@ func (cm *controllerMetrics) PrometheusCollectors() []prometheus.Collector {
 		cm.compilingDur,
 		cm.queueingDur,
 		cm.executingDur,
+
+		cm.orgQueueing,
+		cm.orgExecuting,
+		cm.orgQueryTime,
+		cm.orgQuotaRejections,
 	}
 }
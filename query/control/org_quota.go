@@ -0,0 +1,330 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// OrgQuota holds the limits that apply to the queries of each organization.
+// A zero value for any of the limits means that limit is disabled.
+type OrgQuota struct {
+	// ConcurrencyQuota is the number of queries from a single organization
+	// that are allowed to execute concurrently.
+	ConcurrencyQuota int32
+
+	// QueueSize is the number of queries from a single organization that are
+	// allowed to be awaiting execution before new queries from that organization
+	// are rejected.
+	QueueSize int32
+
+	// MemoryBytes is the maximum number of bytes the executing queries of a
+	// single organization are allowed to hold at any given time.
+	MemoryBytes int64
+
+	// QueryTime is the total execution time the queries of a single organization
+	// are allowed to use within each QueryTimeWindow. Once it is used up, new
+	// queries from that organization are rejected until the window ends.
+	QueryTime time.Duration
+
+	// QueryTimeWindow is the length of the window QueryTime is accounted in.
+	QueryTimeWindow time.Duration
+}
+
+func (q OrgQuota) validate(initialMemoryBytesQuotaPerQuery int64) error {
+	if q.ConcurrencyQuota < 0 {
+		return errors.New("PerOrgQuota.ConcurrencyQuota must not be negative")
+	}
+	if q.QueueSize < 0 {
+		return errors.New("PerOrgQuota.QueueSize must not be negative")
+	}
+	if q.MemoryBytes < 0 {
+		return errors.New("PerOrgQuota.MemoryBytes must not be negative")
+	}
+	if q.MemoryBytes > 0 && initialMemoryBytesQuotaPerQuery > q.MemoryBytes {
+		return fmt.Errorf("PerOrgQuota.MemoryBytes must be greater than or equal to the InitialMemoryBytesQuotaPerQuery: %d < %d", q.MemoryBytes, initialMemoryBytesQuotaPerQuery)
+	}
+	if q.QueryTime < 0 {
+		return errors.New("PerOrgQuota.QueryTime must not be negative")
+	}
+	if q.QueryTime > 0 && q.QueryTimeWindow <= 0 {
+		return errors.New("PerOrgQuota.QueryTimeWindow must be positive when PerOrgQuota.QueryTime is limited")
+	}
+	return nil
+}
+
+// OrgUsage is a snapshot of the resources used by the queries of an organization.
+type OrgUsage struct {
+	OrgID platform.ID
+
+	// Queued is the number of queries awaiting execution.
+	Queued int32
+	// Executing is the number of queries currently executing.
+	Executing int32
+	// MemoryBytes is the number of bytes currently allocated by the executing queries.
+	MemoryBytes int64
+	// QueryTime is the execution time used within the current window.
+	QueryTime time.Duration
+	// WindowStart is the start of the current query time window.
+	WindowStart time.Time
+
+	// Quota is the quota the usage is measured against.
+	Quota OrgQuota
+}
+
+type orgQuotaRejection string
+
+const (
+	rejectQueueSize orgQuotaRejection = "queue_size"
+	rejectQueryTime orgQuotaRejection = "query_time"
+)
+
+// orgState is the resource accounting for a single organization.
+type orgState struct {
+	queued    int32
+	executing int32
+
+	// reservedMemoryBytes is the memory reserved by the executing queries of the org.
+	// It is only tracked when the memory quota is enabled.
+	reservedMemoryBytes int64
+
+	queryTime   time.Duration
+	windowStart time.Time
+
+	// parked holds queries that were dequeued while the org was at its
+	// concurrency or memory limit. They are started when an executing
+	// query of the org finishes.
+	parked []*Query
+}
+
+// orgQuotaManager enforces the OrgQuota for every organization.
+type orgQuotaManager struct {
+	quota                     OrgQuota
+	initialBytesQuotaPerQuery int64
+	metrics                   *controllerMetrics
+	now                       func() time.Time
+
+	mu   sync.Mutex
+	orgs map[platform.ID]*orgState
+	// nextSweep is when the idle orgs whose query time window has ended
+	// are forgotten next.
+	nextSweep time.Time
+}
+
+func newOrgQuotaManager(quota OrgQuota, initialBytesQuotaPerQuery int64, metrics *controllerMetrics) *orgQuotaManager {
+	return &orgQuotaManager{
+		quota:                     quota,
+		initialBytesQuotaPerQuery: initialBytesQuotaPerQuery,
+		metrics:                   metrics,
+		now:                       time.Now,
+		orgs:                      make(map[platform.ID]*orgState),
+	}
+}
+
+// state returns the state for the org, rolling over the query time window
+// when it has ended. Must be called with the lock held.
+func (m *orgQuotaManager) state(orgID platform.ID) *orgState {
+	st, ok := m.orgs[orgID]
+	if !ok {
+		st = &orgState{windowStart: m.now()}
+		m.orgs[orgID] = st
+	}
+	if m.quota.QueryTimeWindow > 0 {
+		if now := m.now(); now.Sub(st.windowStart) >= m.quota.QueryTimeWindow {
+			st.windowStart = now.Truncate(m.quota.QueryTimeWindow)
+			st.queryTime = 0
+		}
+	}
+	return st
+}
+
+// admit accounts for a query that is about to be queued. It returns an
+// error when the org has exhausted its queue or query time quota.
+func (m *orgQuotaManager) admit(q *Query) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	st := m.state(q.orgID)
+	if m.quota.QueryTime > 0 && st.queryTime >= m.quota.QueryTime {
+		m.reject(rejectQueryTime)
+		return &errors2.Error{
+			Code: errors2.ETooManyRequests,
+			Msg: fmt.Sprintf("organization query time quota of %s exceeded; quota resets at %s",
+				m.quota.QueryTime, st.windowStart.Add(m.quota.QueryTimeWindow).UTC().Format(time.RFC3339)),
+		}
+	}
+	if m.quota.QueueSize > 0 && st.queued >= m.quota.QueueSize {
+		m.reject(rejectQueueSize)
+		return &errors2.Error{
+			Code: errors2.ETooManyRequests,
+			Msg:  fmt.Sprintf("organization query queue length of %d exceeded", m.quota.QueueSize),
+		}
+	}
+	st.queued++
+	m.record(q.orgID, st)
+	return nil
+}
+
+// unadmit reverts admit for a query that could not be queued.
+func (m *orgQuotaManager) unadmit(q *Query) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.state(q.orgID)
+	st.queued--
+	m.record(q.orgID, st)
+}
+
+// tryStart reports if the query may start executing. When the org is at its
+// concurrency or memory limit, the query is parked and will be handed out
+// by finish once an executing query of the org is done.
+func (m *orgQuotaManager) tryStart(q *Query) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.state(q.orgID)
+	if !m.canStart(st) {
+		st.parked = append(st.parked, q)
+		return false
+	}
+	m.start(q, st)
+	return true
+}
+
+func (m *orgQuotaManager) canStart(st *orgState) bool {
+	if m.quota.ConcurrencyQuota > 0 && st.executing >= m.quota.ConcurrencyQuota {
+		return false
+	}
+	// A single query is always allowed to execute as the initial
+	// memory is validated to fit into the quota.
+	if m.quota.MemoryBytes > 0 && st.executing > 0 &&
+		st.reservedMemoryBytes+m.initialBytesQuotaPerQuery > m.quota.MemoryBytes {
+		return false
+	}
+	return true
+}
+
+func (m *orgQuotaManager) start(q *Query, st *orgState) {
+	st.queued--
+	st.executing++
+	if m.quota.MemoryBytes > 0 {
+		st.reservedMemoryBytes += m.initialBytesQuotaPerQuery
+		q.orgReservedMemoryBytes = m.initialBytesQuotaPerQuery
+	}
+	m.record(q.orgID, st)
+}
+
+// reserveMemory reserves additional memory for an executing query
+// against the memory quota of its org.
+func (m *orgQuotaManager) reserveMemory(q *Query, n int64) bool {
+	if m.quota.MemoryBytes <= 0 {
+		return true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.state(q.orgID)
+	if st.reservedMemoryBytes+n > m.quota.MemoryBytes {
+		return false
+	}
+	st.reservedMemoryBytes += n
+	q.orgReservedMemoryBytes += n
+	return true
+}
+
+// finish releases the resources held by a query that has finished executing
+// and returns the next parked query of the org that may be started, if any.
+func (m *orgQuotaManager) finish(q *Query, executeDuration time.Duration) *Query {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.state(q.orgID)
+	st.executing--
+	st.reservedMemoryBytes -= q.orgReservedMemoryBytes
+	q.orgReservedMemoryBytes = 0
+	st.queryTime += executeDuration
+	m.metrics.orgQueryTime.Add(executeDuration.Seconds())
+
+	var next *Query
+	if len(st.parked) > 0 && m.canStart(st) {
+		next, st.parked = st.parked[0], st.parked[1:]
+		m.start(next, st)
+	}
+	m.record(q.orgID, st)
+	return next
+}
+
+// usage returns a snapshot of the usage of every org with queries
+// in flight or with query time used in the current window.
+func (m *orgQuotaManager) usage() []OrgUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usages := make([]OrgUsage, 0, len(m.orgs))
+	for orgID := range m.orgs {
+		st := m.state(orgID)
+		if st.queued == 0 && st.executing == 0 && st.queryTime == 0 {
+			// nothing left to report, forget about the org
+			delete(m.orgs, orgID)
+			continue
+		}
+		usages = append(usages, OrgUsage{
+			OrgID:       orgID,
+			Queued:      st.queued,
+			Executing:   st.executing,
+			QueryTime:   st.queryTime,
+			WindowStart: st.windowStart,
+			Quota:       m.quota,
+		})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].OrgID < usages[j].OrgID
+	})
+	return usages
+}
+
+// record updates the metrics of the org. An org without queries in flight
+// is forgotten, unless the query time it used in the current window is still
+// needed to enforce the query time quota. Must be called with the lock held.
+func (m *orgQuotaManager) record(orgID platform.ID, st *orgState) {
+	org := orgID.String()
+	if st.queued == 0 && st.executing == 0 {
+		m.metrics.orgQueueing.DeleteLabelValues(org)
+		m.metrics.orgExecuting.DeleteLabelValues(org)
+		if m.quota.QueryTime <= 0 || st.queryTime == 0 {
+			delete(m.orgs, orgID)
+		}
+		return
+	}
+	m.metrics.orgQueueing.WithLabelValues(org).Set(float64(st.queued))
+	m.metrics.orgExecuting.WithLabelValues(org).Set(float64(st.executing))
+}
+
+// sweep forgets the idle orgs whose query time window has ended, at most once
+// per window. Must be called with the lock held.
+func (m *orgQuotaManager) sweep() {
+	if m.quota.QueryTimeWindow <= 0 {
+		return
+	}
+	now := m.now()
+	if now.Before(m.nextSweep) {
+		return
+	}
+	m.nextSweep = now.Add(m.quota.QueryTimeWindow)
+	for orgID, st := range m.orgs {
+		if st.queued == 0 && st.executing == 0 && now.Sub(st.windowStart) >= m.quota.QueryTimeWindow {
+			delete(m.orgs, orgID)
+		}
+	}
+}
+
+func (m *orgQuotaManager) reject(reason orgQuotaRejection) {
+	m.metrics.orgQuotaRejections.WithLabelValues(string(reason)).Inc()
+}
@@ -80,6 +80,11 @@ func (c *Compiler) CompilerType() flux.CompilerType {
 	return CompilerType
 }
 
+// QueryText returns the InfluxQL text of the query.
+func (c *Compiler) QueryText() string {
+	return c.Query
+}
+
 func (c *Compiler) WithLogicalPlannerOptions(opts ...plan.LogicalOption) {
 	c.logicalPlannerOptions = opts
 }
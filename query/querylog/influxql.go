@@ -0,0 +1,58 @@
+package querylog
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/influxdata/flux/iocounter"
+	"github.com/influxdata/influxdb/v2/influxql"
+	"go.uber.org/zap"
+)
+
+// InfluxQLService records the queries executed by the wrapped InfluxQL
+// proxy query service.
+type InfluxQLService struct {
+	influxql.ProxyQueryService
+
+	recorder *Recorder
+	now      func() time.Time
+}
+
+var _ influxql.ProxyQueryService = (*InfluxQLService)(nil)
+
+// NewInfluxQLService constructs an InfluxQLService recording every query to the recorder.
+func NewInfluxQLService(recorder *Recorder, svc influxql.ProxyQueryService) *InfluxQLService {
+	return &InfluxQLService{
+		ProxyQueryService: svc,
+		recorder:          recorder,
+		now:               time.Now,
+	}
+}
+
+// Query executes and records the query.
+func (s *InfluxQLService) Query(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+	wc := &iocounter.Writer{Writer: w}
+	stats, err := s.ProxyQueryService.Query(ctx, wc, req)
+
+	e := Entry{
+		Time:            s.now(),
+		OrgID:           req.OrganizationID,
+		Language:        LanguageInfluxQL,
+		Query:           req.Query,
+		CompileDuration: stats.PlanDuration,
+		ExecuteDuration: stats.ExecuteDuration,
+		Rows:            int64(stats.ReturnedRows),
+		ResponseBytes:   wc.Count(),
+		Err:             err,
+	}
+	if req.Authorization != nil {
+		e.TokenID = req.Authorization.ID
+	}
+
+	// The request context may already be canceled once the query is done.
+	if rerr := s.recorder.Record(context.Background(), e); rerr != nil {
+		s.recorder.log.Error("Failed to record query", zap.Stringer("org_id", e.OrgID), zap.Error(rerr))
+	}
+	return stats, err
+}
@@ -0,0 +1,259 @@
+// Package querylog records completed queries into the _queries system
+// bucket of the organization that issued them.
+package querylog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// LanguageFlux marks entries of Flux queries.
+	LanguageFlux = "flux"
+	// LanguageInfluxQL marks entries of InfluxQL queries.
+	LanguageInfluxQL = "influxql"
+)
+
+const (
+	queriesMeasurement     = "queries"
+	slowQueriesMeasurement = "slow_queries"
+
+	languageTag = "language"
+	statusTag   = "status"
+
+	tokenIDField         = "tokenID"
+	queryHashField       = "queryHash"
+	queryField           = "query"
+	compileDurationField = "compileDuration"
+	queueDurationField   = "queueDuration"
+	executeDurationField = "executeDuration"
+	rowsField            = "rows"
+	responseBytesField   = "responseBytes"
+	memoryBytesField     = "memoryBytes"
+	errorField           = "error"
+
+	statusSuccess = "success"
+	statusFailed  = "failed"
+)
+
+// Entry describes a single completed query.
+type Entry struct {
+	// Time is the time the query completed.
+	Time time.Time
+	// OrgID is the organization that issued the query.
+	OrgID platform.ID
+	// TokenID is the ID of the authorization used for the query, if any.
+	TokenID platform.ID
+	// Language is the query language, either LanguageFlux or LanguageInfluxQL.
+	Language string
+	// Query is the text of the query.
+	Query string
+
+	CompileDuration time.Duration
+	QueueDuration   time.Duration
+	ExecuteDuration time.Duration
+
+	// Rows is the number of rows returned to the client.
+	Rows int64
+	// ResponseBytes is the size of the response in bytes.
+	ResponseBytes int64
+	// MemoryBytes is the maximum memory allocated by the query.
+	MemoryBytes int64
+
+	// Err is the error the query failed with, if any.
+	Err error
+}
+
+// TotalDuration returns the sum of all durations of the entry.
+func (e Entry) TotalDuration() time.Duration {
+	return e.CompileDuration + e.QueueDuration + e.ExecuteDuration
+}
+
+// Recorder writes query entries to the _queries system bucket of the
+// organization of each entry. The bucket is created on first use.
+type Recorder struct {
+	log     *zap.Logger
+	pw      storage.PointsWriter
+	buckets influxdb.BucketService
+
+	slowQueryThreshold time.Duration
+
+	mu        sync.Mutex
+	bucketIDs map[platform.ID]platform.ID
+}
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(r *Recorder)
+
+// WithSlowQueryThreshold sets the total duration above which a query is
+// additionally recorded as a slow query, along with its full text. A zero
+// threshold disables the slow query log.
+func WithSlowQueryThreshold(d time.Duration) RecorderOption {
+	return func(r *Recorder) {
+		r.slowQueryThreshold = d
+	}
+}
+
+// NewRecorder constructs a Recorder writing through the provided points writer.
+func NewRecorder(log *zap.Logger, pw storage.PointsWriter, buckets influxdb.BucketService, opts ...RecorderOption) *Recorder {
+	r := &Recorder{
+		log:       log,
+		pw:        pw,
+		buckets:   buckets,
+		bucketIDs: make(map[platform.ID]platform.ID),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// Record writes the entry to the _queries bucket of the organization of the entry.
+func (r *Recorder) Record(ctx context.Context, e Entry) error {
+	if !e.OrgID.Valid() {
+		return nil
+	}
+
+	bucketID, err := r.bucketID(ctx, e.OrgID)
+	if err != nil {
+		return err
+	}
+
+	points, err := r.points(e)
+	if err != nil {
+		return err
+	}
+
+	if err := r.pw.WritePoints(ctx, e.OrgID, bucketID, points); err != nil {
+		// The bucket may have been deleted, look it up again next time.
+		r.mu.Lock()
+		delete(r.bucketIDs, e.OrgID)
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Log records a Flux query. It satisfies the query.Logger interface so the
+// Recorder can be used with a query.LoggingProxyQueryService.
+func (r *Recorder) Log(l query.Log) error {
+	e := Entry{
+		Time:            l.Time,
+		OrgID:           l.OrganizationID,
+		Language:        LanguageFlux,
+		CompileDuration: l.Statistics.CompileDuration,
+		QueueDuration:   l.Statistics.QueueDuration,
+		ExecuteDuration: l.Statistics.ExecuteDuration,
+		ResponseBytes:   l.ResponseSize,
+		MemoryBytes:     l.Statistics.MaxAllocated,
+		Err:             l.Error,
+	}
+	if l.ProxyRequest != nil {
+		if a := l.ProxyRequest.Request.Authorization; a != nil {
+			e.TokenID = a.ID
+		}
+		e.Language, e.Query = query.QueryText(l.ProxyRequest.Request.Compiler)
+	}
+	if rows := l.Statistics.Metadata[query.ResponseRowsMetadataKey]; len(rows) > 0 {
+		e.Rows, _ = rows[len(rows)-1].(int64)
+	}
+
+	// The request context may already be canceled once the query is done.
+	if err := r.Record(context.Background(), e); err != nil {
+		r.log.Error("Failed to record query", zap.Stringer("org_id", e.OrgID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *Recorder) bucketID(ctx context.Context, orgID platform.ID) (platform.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id, ok := r.bucketIDs[orgID]; ok {
+		return id, nil
+	}
+
+	b, err := r.buckets.FindBucketByName(ctx, orgID, influxdb.QueriesSystemBucketName)
+	if errors2.ErrorCode(err) == errors2.ENotFound {
+		b = &influxdb.Bucket{
+			OrgID:           orgID,
+			Type:            influxdb.BucketTypeSystem,
+			Name:            influxdb.QueriesSystemBucketName,
+			RetentionPeriod: influxdb.QueriesSystemBucketRetention,
+			Description:     "System bucket for query logs",
+		}
+		err = r.buckets.CreateBucket(ctx, b)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	r.bucketIDs[orgID] = b.ID
+	return b.ID, nil
+}
+
+func (r *Recorder) points(e Entry) (models.Points, error) {
+	status := statusSuccess
+	if e.Err != nil {
+		status = statusFailed
+	}
+	tags := models.NewTags(map[string]string{
+		languageTag: e.Language,
+		statusTag:   status,
+	})
+
+	hash := sha256.Sum256([]byte(e.Query))
+	fields := map[string]interface{}{
+		queryHashField:       hex.EncodeToString(hash[:]),
+		compileDurationField: int64(e.CompileDuration),
+		queueDurationField:   int64(e.QueueDuration),
+		executeDurationField: int64(e.ExecuteDuration),
+		rowsField:            e.Rows,
+		responseBytesField:   e.ResponseBytes,
+		memoryBytesField:     e.MemoryBytes,
+	}
+	if e.TokenID.Valid() {
+		fields[tokenIDField] = e.TokenID.String()
+	}
+	if e.Err != nil {
+		fields[errorField] = e.Err.Error()
+	}
+
+	t := e.Time
+	if t.IsZero() {
+		t = time.Now().UTC()
+	}
+
+	pt, err := models.NewPoint(queriesMeasurement, tags, fields, t)
+	if err != nil {
+		return nil, err
+	}
+	points := models.Points{pt}
+
+	if r.slowQueryThreshold > 0 && e.TotalDuration() >= r.slowQueryThreshold {
+		slowFields := make(map[string]interface{}, len(fields)+1)
+		for k, v := range fields {
+			slowFields[k] = v
+		}
+		slowFields[queryField] = e.Query
+
+		pt, err := models.NewPoint(slowQueriesMeasurement, tags, slowFields, t)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, pt)
+	}
+	return points, nil
+}
@@ -0,0 +1,126 @@
+package querylog_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/querylog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	orgID    = platform.ID(1)
+	bucketID = platform.ID(2)
+	tokenID  = platform.ID(3)
+)
+
+func newBucketService(created *int) *mock.BucketService {
+	buckets := mock.NewBucketService()
+	var bucket *influxdb.Bucket
+	buckets.FindBucketByNameFn = func(ctx context.Context, oid platform.ID, name string) (*influxdb.Bucket, error) {
+		if bucket == nil {
+			return nil, &errors2.Error{Code: errors2.ENotFound, Msg: "bucket not found"}
+		}
+		return bucket, nil
+	}
+	buckets.CreateBucketFn = func(ctx context.Context, b *influxdb.Bucket) error {
+		*created++
+		b.ID = bucketID
+		bucket = b
+		return nil
+	}
+	return buckets
+}
+
+func TestRecorder_Log(t *testing.T) {
+	var created int
+	pw := &mock.PointsWriter{}
+	rec := querylog.NewRecorder(zaptest.NewLogger(t), pw, newBucketService(&created),
+		querylog.WithSlowQueryThreshold(time.Second))
+
+	now := time.Unix(100, 0).UTC()
+	log := query.Log{
+		Time:           now,
+		OrganizationID: orgID,
+		ProxyRequest: &query.ProxyRequest{
+			Request: query.Request{
+				Authorization:  &influxdb.Authorization{ID: tokenID},
+				OrganizationID: orgID,
+				Compiler:       lang.FluxCompiler{Query: `from(bucket: "b")`},
+			},
+		},
+		ResponseSize: 1024,
+		Statistics: flux.Statistics{
+			CompileDuration: time.Millisecond,
+			QueueDuration:   2 * time.Millisecond,
+			ExecuteDuration: 3 * time.Millisecond,
+			MaxAllocated:    4096,
+			Metadata: metadata.Metadata{
+				query.ResponseRowsMetadataKey: []interface{}{int64(12)},
+			},
+		},
+	}
+	require.NoError(t, rec.Log(log))
+	require.NoError(t, rec.Log(log))
+
+	assert.Equal(t, 1, created, "the _queries bucket should be created once")
+	require.Len(t, pw.Points, 2)
+
+	pt := pw.Points[0]
+	assert.Equal(t, "queries", string(pt.Name()))
+	assert.Equal(t, "flux", string(pt.Tags().GetString("language")))
+	assert.Equal(t, "success", string(pt.Tags().GetString("status")))
+	assert.True(t, now.Equal(pt.Time()))
+
+	fields, err := pt.Fields()
+	require.NoError(t, err)
+	assert.Equal(t, tokenID.String(), fields["tokenID"])
+	assert.Equal(t, int64(time.Millisecond), fields["compileDuration"])
+	assert.Equal(t, int64(2*time.Millisecond), fields["queueDuration"])
+	assert.Equal(t, int64(3*time.Millisecond), fields["executeDuration"])
+	assert.Equal(t, int64(12), fields["rows"])
+	assert.Equal(t, int64(1024), fields["responseBytes"])
+	assert.Equal(t, int64(4096), fields["memoryBytes"])
+	assert.NotEmpty(t, fields["queryHash"])
+	assert.NotContains(t, fields, "query")
+	assert.NotContains(t, fields, "error")
+}
+
+func TestRecorder_SlowQuery(t *testing.T) {
+	var created int
+	pw := &mock.PointsWriter{}
+	rec := querylog.NewRecorder(zaptest.NewLogger(t), pw, newBucketService(&created),
+		querylog.WithSlowQueryThreshold(time.Second))
+
+	err := rec.Record(context.Background(), querylog.Entry{
+		OrgID:           orgID,
+		Language:        querylog.LanguageInfluxQL,
+		Query:           "SELECT * FROM cpu",
+		ExecuteDuration: 2 * time.Second,
+		Err:             errors.New("expected error"),
+	})
+	require.NoError(t, err)
+	require.Len(t, pw.Points, 2)
+
+	assert.Equal(t, "queries", string(pw.Points[0].Name()))
+	slow := pw.Points[1]
+	assert.Equal(t, "slow_queries", string(slow.Name()))
+	assert.Equal(t, "failed", string(slow.Tags().GetString("status")))
+
+	fields, err := slow.Fields()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM cpu", fields["query"])
+	assert.Equal(t, "expected error", fields["error"])
+}
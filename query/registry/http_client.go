@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+)
+
+// PrefixQueries is the route prefix of the running queries API.
+const PrefixQueries = "/api/v2/queries"
+
+// QueriesResponse is the response body listing the running queries.
+type QueriesResponse struct {
+	Queries []Query `json:"queries"`
+}
+
+// Client lists and kills running queries over HTTP.
+type Client struct {
+	Client *httpc.Client
+}
+
+// Queries returns the running queries of the organization. A zero orgID
+// lists the queries of all organizations, which requires operator access.
+func (s *Client) Queries(ctx context.Context, orgID platform.ID) ([]Query, error) {
+	var resp QueriesResponse
+	err := s.Client.
+		Get(PrefixQueries).
+		QueryParams(orgIDParams(orgID)...).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Queries, nil
+}
+
+// Kill kills the running query with the given ID. A zero orgID
+// allows to kill queries of all organizations, which requires operator access.
+func (s *Client) Kill(ctx context.Context, orgID platform.ID, id uint64) error {
+	return s.Client.
+		Delete(PrefixQueries, strconv.FormatUint(id, 10)).
+		QueryParams(orgIDParams(orgID)...).
+		Do(ctx)
+}
+
+func orgIDParams(orgID platform.ID) [][2]string {
+	if !orgID.Valid() {
+		return nil
+	}
+	return [][2]string{{"orgID", orgID.String()}}
+}
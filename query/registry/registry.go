@@ -0,0 +1,136 @@
+// Package registry keeps track of the queries running on a server, regardless
+// of the query language, so they can be listed and killed.
+package registry
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+const (
+	// LanguageFlux marks Flux queries.
+	LanguageFlux = "flux"
+	// LanguageInfluxQL marks InfluxQL queries.
+	LanguageInfluxQL = "influxql"
+
+	// StatusRunning is the status of a query without a status func.
+	StatusRunning = "running"
+)
+
+// ErrQueryNotFound is returned when killing a query that is not registered,
+// or that is not visible through the filter.
+var ErrQueryNotFound = &errors2.Error{
+	Code: errors2.ENotFound,
+	Msg:  "query not found",
+}
+
+// Query describes a registered query.
+type Query struct {
+	ID        uint64      `json:"id"`
+	OrgID     platform.ID `json:"orgID"`
+	Language  string      `json:"language"`
+	Query     string      `json:"query"`
+	Database  string      `json:"database,omitempty"`
+	Status    string      `json:"status"`
+	StartTime time.Time   `json:"startTime"`
+}
+
+// Filter restricts the queries visible to a caller.
+type Filter struct {
+	// OrgID restricts the queries to those of the organization. All
+	// queries are visible without an OrgID.
+	OrgID *platform.ID
+}
+
+func (f Filter) matches(q Query) bool {
+	return f.OrgID == nil || *f.OrgID == q.OrgID
+}
+
+type entry struct {
+	query  Query
+	cancel func()
+	status func() string
+}
+
+// Registry holds the running queries.
+type Registry struct {
+	mu      sync.RWMutex
+	nextID  uint64
+	queries map[uint64]*entry
+}
+
+// New constructs an empty Registry.
+func New() *Registry {
+	return &Registry{
+		queries: make(map[uint64]*entry),
+	}
+}
+
+// Register adds a query to the registry, assigning it a new ID. The cancel
+// func is called when the query is killed, the optional status func reports
+// the current status of the query. The returned func removes the query from
+// the registry and must be called once the query is done.
+func (r *Registry) Register(q Query, cancel func(), status func() string) (uint64, func()) {
+	if q.StartTime.IsZero() {
+		q.StartTime = time.Now()
+	}
+
+	r.mu.Lock()
+	r.nextID++
+	q.ID = r.nextID
+	r.queries[q.ID] = &entry{
+		query:  q,
+		cancel: cancel,
+		status: status,
+	}
+	r.mu.Unlock()
+
+	var once sync.Once
+	return q.ID, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.queries, q.ID)
+			r.mu.Unlock()
+		})
+	}
+}
+
+// Queries returns the registered queries matching the filter ordered by ID.
+func (r *Registry) Queries(filter Filter) []Query {
+	r.mu.RLock()
+	queries := make([]Query, 0, len(r.queries))
+	for _, e := range r.queries {
+		if !filter.matches(e.query) {
+			continue
+		}
+		q := e.query
+		q.Status = StatusRunning
+		if e.status != nil {
+			q.Status = e.status()
+		}
+		queries = append(queries, q)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].ID < queries[j].ID
+	})
+	return queries
+}
+
+// Kill cancels the query with the given ID, if it matches the filter.
+func (r *Registry) Kill(id uint64, filter Filter) error {
+	r.mu.RLock()
+	e, ok := r.queries[id]
+	r.mu.RUnlock()
+	if !ok || !filter.matches(e.query) {
+		return ErrQueryNotFound
+	}
+
+	e.cancel()
+	return nil
+}
@@ -0,0 +1,74 @@
+package registry_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/query/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	var (
+		orgA = platform.ID(1)
+		orgB = platform.ID(2)
+	)
+
+	r := registry.New()
+
+	var canceledA, canceledB bool
+	idA, doneA := r.Register(registry.Query{
+		OrgID:    orgA,
+		Language: registry.LanguageFlux,
+		Query:    `from(bucket: "a")`,
+	}, func() { canceledA = true }, func() string { return "executing" })
+	idB, doneB := r.Register(registry.Query{
+		OrgID:    orgB,
+		Language: registry.LanguageInfluxQL,
+		Query:    "SELECT * FROM cpu",
+		Database: "db",
+	}, func() { canceledB = true }, nil)
+	require.NotEqual(t, idA, idB)
+
+	t.Run("list all queries", func(t *testing.T) {
+		queries := r.Queries(registry.Filter{})
+		require.Len(t, queries, 2)
+		assert.Equal(t, idA, queries[0].ID)
+		assert.Equal(t, "executing", queries[0].Status)
+		assert.False(t, queries[0].StartTime.IsZero())
+		assert.Equal(t, idB, queries[1].ID)
+		assert.Equal(t, registry.StatusRunning, queries[1].Status)
+		assert.Equal(t, "db", queries[1].Database)
+	})
+
+	t.Run("list queries of an org", func(t *testing.T) {
+		queries := r.Queries(registry.Filter{OrgID: &orgB})
+		require.Len(t, queries, 1)
+		assert.Equal(t, idB, queries[0].ID)
+	})
+
+	t.Run("kill query of another org", func(t *testing.T) {
+		err := r.Kill(idA, registry.Filter{OrgID: &orgB})
+		assert.Equal(t, errors2.ENotFound, errors2.ErrorCode(err))
+		assert.False(t, canceledA)
+	})
+
+	t.Run("kill query", func(t *testing.T) {
+		require.NoError(t, r.Kill(idA, registry.Filter{OrgID: &orgA}))
+		assert.True(t, canceledA)
+		require.NoError(t, r.Kill(idB, registry.Filter{}))
+		assert.True(t, canceledB)
+	})
+
+	t.Run("unregister", func(t *testing.T) {
+		doneA()
+		doneA()
+		doneB()
+		assert.Empty(t, r.Queries(registry.Filter{}))
+
+		err := r.Kill(idA, registry.Filter{})
+		assert.Equal(t, errors2.ENotFound, errors2.ErrorCode(err))
+	})
+}
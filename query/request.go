@@ -9,6 +9,7 @@ import (
 	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
 
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
 	platform "github.com/influxdata/influxdb/v2"
 )
 
@@ -41,6 +42,24 @@ type Request struct {
 	options []RequestHeaderOption
 }
 
+// QueryText returns the language and the text of the query held by the compiler.
+// Compilers of other languages report their text by implementing a QueryText method.
+func QueryText(c flux.Compiler) (language, text string) {
+	switch t := c.(type) {
+	case lang.FluxCompiler:
+		return "flux", t.Query
+	case *lang.FluxCompiler:
+		return "flux", t.Query
+	case lang.ASTCompiler:
+		return "flux", string(t.AST)
+	case *lang.ASTCompiler:
+		return "flux", string(t.AST)
+	case interface{ QueryText() string }:
+		return string(c.CompilerType()), t.QueryText()
+	}
+	return "flux", ""
+}
+
 // SetReturnNoContent sets the header for a Request to return no content.
 func SetReturnNoContent(header http.Header, withError bool) {
 	if withError {
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+)
+
+// ListCompactions returns the queued and in progress compactions of the shards
+// of the engine matching the filter. Queued compactions are ordered by bucket,
+// shard and level, compactions in progress by their start time.
+func (e *Engine) ListCompactions(ctx context.Context, filter influxdb.StorageShardFilter) (*influxdb.StorageCompactions, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	compactions := &influxdb.StorageCompactions{
+		Queued:     []*influxdb.StorageCompaction{},
+		InProgress: []*influxdb.StorageCompaction{},
+	}
+	e.walkShards(func(bucketID platform.ID, _ *meta.ShardGroupInfo, sh *tsdb.Shard) bool {
+		if filter.BucketID != nil && *filter.BucketID != bucketID {
+			return true
+		}
+
+		// Shards that are closed have no compactions.
+		queued, active, err := sh.CompactionStats()
+		if err != nil {
+			return true
+		}
+		for _, c := range queued {
+			compactions.Queued = append(compactions.Queued, describeCompaction(bucketID, sh.ID(), c))
+		}
+		for _, c := range active {
+			compactions.InProgress = append(compactions.InProgress, describeCompaction(bucketID, sh.ID(), c))
+		}
+		return true
+	})
+
+	sort.SliceStable(compactions.Queued, func(i, j int) bool {
+		a, b := compactions.Queued[i], compactions.Queued[j]
+		if a.BucketID != b.BucketID {
+			return a.BucketID < b.BucketID
+		}
+		if a.ShardID != b.ShardID {
+			return a.ShardID < b.ShardID
+		}
+		return a.Level < b.Level
+	})
+	sort.SliceStable(compactions.InProgress, func(i, j int) bool {
+		return compactions.InProgress[i].StartTime.Before(*compactions.InProgress[j].StartTime)
+	})
+	return compactions, nil
+}
+
+// FindCompactionLimits returns the limits shared by all compactions.
+func (e *Engine) FindCompactionLimits(ctx context.Context) (*influxdb.StorageCompactionLimits, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	limits, err := e.tsdbStore.CompactionLimits()
+	if err != nil {
+		return nil, err
+	}
+	return describeCompactionLimits(limits), nil
+}
+
+// UpdateCompactionLimits changes the limits shared by all compactions. The
+// limits are reset to the configuration of the engine when it is reopened.
+func (e *Engine) UpdateCompactionLimits(ctx context.Context, upd influxdb.StorageCompactionLimitsUpdate) (*influxdb.StorageCompactionLimits, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	limits, err := e.tsdbStore.CompactionLimits()
+	if err != nil {
+		return nil, err
+	}
+	if upd.Throughput != nil {
+		limits.Throughput = int(*upd.Throughput)
+	}
+	if upd.ThroughputBurst != nil {
+		limits.ThroughputBurst = int(*upd.ThroughputBurst)
+	}
+	if upd.MaxConcurrent != nil {
+		limits.MaxConcurrent = *upd.MaxConcurrent
+	}
+
+	limits, err = e.tsdbStore.SetCompactionLimits(limits)
+	if err != nil {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  err.Error(),
+		}
+	}
+	return describeCompactionLimits(limits), nil
+}
+
+func describeCompaction(bucketID platform.ID, shardID uint64, c tsdb.CompactionStat) *influxdb.StorageCompaction {
+	compaction := &influxdb.StorageCompaction{
+		ShardID:      shardID,
+		BucketID:     bucketID,
+		Level:        c.Level,
+		Files:        make([]string, 0, len(c.Files)),
+		Size:         c.Size,
+		BytesWritten: c.BytesWritten,
+	}
+	for _, f := range c.Files {
+		compaction.Files = append(compaction.Files, filepath.Base(f))
+	}
+	if !c.StartTime.IsZero() {
+		start := c.StartTime.UTC()
+		compaction.StartTime = &start
+	}
+	return compaction
+}
+
+func describeCompactionLimits(limits tsdb.CompactionLimits) *influxdb.StorageCompactionLimits {
+	return &influxdb.StorageCompactionLimits{
+		Throughput:      int64(limits.Throughput),
+		ThroughputBurst: int64(limits.ThroughputBurst),
+		MaxConcurrent:   limits.MaxConcurrent,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/influxdata/influxdb/v2/v1/services/precreator"
 	"github.com/influxdata/influxdb/v2/v1/services/retention"
+	"github.com/influxdata/influxdb/v2/v1/services/tiering"
 )
 
 // Config holds the configuration for an Engine.
@@ -12,6 +13,7 @@ type Config struct {
 
 	RetentionService retention.Config
 	PrecreatorConfig precreator.Config
+	TieringService   tiering.Config
 }
 
 // NewConfig initialises a new config for an Engine.
@@ -20,5 +22,6 @@ func NewConfig() Config {
 		Data:             tsdb.NewConfig(),
 		RetentionService: retention.NewConfig(),
 		PrecreatorConfig: precreator.NewConfig(),
+		TieringService:   tiering.NewConfig(),
 	}
 }
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"go.uber.org/zap"
+)
+
+// FindDurabilityPolicy returns the durability policy of a bucket.
+func (e *Engine) FindDurabilityPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageDurabilityPolicy, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+	return e.findDurabilityPolicy(bucketID)
+}
+
+// UpdateDurabilityPolicy sets the durability policy of a bucket, and reopens
+// the shards of the bucket to apply it.
+func (e *Engine) UpdateDurabilityPolicy(ctx context.Context, policy influxdb.StorageDurabilityPolicy) (*influxdb.StorageDurabilityPolicy, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	d := tsdb.Durability{
+		Mode:             tsdb.DurabilityMode(policy.Mode),
+		FsyncDelay:       policy.FsyncDelay.Duration,
+		SnapshotInterval: policy.SnapshotInterval.Duration,
+	}
+	if err := d.Validate(); err != nil {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  err.Error(),
+		}
+	}
+
+	db := policy.BucketID.String()
+	if e.metaClient.Database(db) == nil {
+		return nil, errBucketNotFound(policy.BucketID)
+	}
+
+	rpu := meta.RetentionPolicyUpdate{}
+	rpu.SetDurabilityMode(string(d.Mode))
+	rpu.SetFsyncDelay(d.FsyncDelay)
+	rpu.SetSnapshotInterval(d.SnapshotInterval)
+	if err := e.metaClient.UpdateRetentionPolicy(db, meta.DefaultRetentionPolicyName, &rpu, true); err != nil {
+		return nil, err
+	}
+	if err := e.tsdbStore.ApplyDurability(db, meta.DefaultRetentionPolicyName); err != nil {
+		return nil, err
+	}
+	return e.findDurabilityPolicy(policy.BucketID)
+}
+
+func (e *Engine) findDurabilityPolicy(bucketID platform.ID) (*influxdb.StorageDurabilityPolicy, error) {
+	db := bucketID.String()
+	if e.metaClient.Database(db) == nil {
+		return nil, errBucketNotFound(bucketID)
+	}
+
+	rpi, err := e.metaClient.RetentionPolicy(db, meta.DefaultRetentionPolicyName)
+	if err != nil {
+		return nil, err
+	} else if rpi == nil {
+		return nil, errBucketNotFound(bucketID)
+	}
+
+	return &influxdb.StorageDurabilityPolicy{
+		BucketID:         bucketID,
+		Mode:             rpi.DurabilityMode,
+		FsyncDelay:       influxdb.Duration{Duration: rpi.FsyncDelay},
+		SnapshotInterval: influxdb.Duration{Duration: rpi.SnapshotInterval},
+	}, nil
+}
+
+// durabilityPolicy returns the durability of the shards of a retention
+// policy, as set in the meta store.
+func (e *Engine) durabilityPolicy(database, rp string) tsdb.Durability {
+	rpi, err := e.metaClient.RetentionPolicy(database, rp)
+	if err != nil || rpi == nil {
+		return tsdb.Durability{}
+	}
+
+	d := tsdb.Durability{
+		Mode:             tsdb.DurabilityMode(rpi.DurabilityMode),
+		FsyncDelay:       rpi.FsyncDelay,
+		SnapshotInterval: rpi.SnapshotInterval,
+	}
+	// Shards are still opened if the meta store holds an invalid policy.
+	if err := d.Validate(); err != nil {
+		e.logger.Warn("Ignoring invalid durability policy", logger.Database(database), zap.Error(err))
+		return tsdb.Durability{}
+	}
+	return d
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	"github.com/influxdata/influxdb/v2/v1/services/precreator"
 	"github.com/influxdata/influxdb/v2/v1/services/retention"
+	"github.com/influxdata/influxdb/v2/v1/services/tiering"
 	"github.com/influxdata/influxql"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -44,6 +45,8 @@ type Engine struct {
 
 	mu           sync.RWMutex
 	closing      chan struct{} // closing returns the zero value when the engine is shutting down.
+	reshardMu    sync.Mutex    // reshardMu serializes the reshard steps of the engine.
+	reshardFence *reshardFence // reshardFence holds the writes and deletes to shard groups being resharded.
 	tsdbStore    *tsdb.Store
 	metaClient   MetaClient
 	pointsWriter interface {
@@ -53,6 +56,7 @@ type Engine struct {
 
 	retentionService  *retention.Service
 	precreatorService *precreator.Service
+	tieringService    *tiering.Service
 
 	defaultMetricLabels prometheus.Labels
 
@@ -78,7 +82,9 @@ type MetaClient interface {
 	DeleteShardGroup(database, policy string, id uint64) error
 	PrecreateShardGroups(now, cutoff time.Time) error
 	PruneShardGroups() error
+	ReplaceShardGroups(database, policy string, ids []uint64, groups []meta.ShardGroupInfo) ([]meta.ShardGroupInfo, error)
 	RetentionPolicy(database, policy string) (*meta.RetentionPolicyInfo, error)
+	SetShardTier(id uint64, tier string) error
 	ShardGroupsByTimeRange(database, policy string, min, max time.Time) (a []meta.ShardGroupInfo, err error)
 	UpdateRetentionPolicy(database, name string, rpu *meta.RetentionPolicyUpdate, makeDefault bool) error
 	Backup(ctx context.Context, w io.Writer) error
@@ -108,6 +114,7 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 		path:                path,
 		defaultMetricLabels: prometheus.Labels{},
 		tsdbStore:           tsdb.NewStore(c.Data.Dir),
+		reshardFence:        newReshardFence(),
 		logger:              zap.NewNop(),
 
 		writePointsValidationEnabled: true,
@@ -123,6 +130,11 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 	e.tsdbStore.EngineOptions.EngineVersion = c.Data.Engine
 	e.tsdbStore.EngineOptions.IndexVersion = c.Data.Index
 
+	// Shards use the durability set for their bucket.
+	if e.metaClient != nil {
+		e.tsdbStore.EngineOptions.DurabilityPolicy = e.durabilityPolicy
+	}
+
 	pw := coordinator.NewPointsWriter()
 	pw.TSDBStore = e.tsdbStore
 	pw.MetaClient = e.metaClient
@@ -135,6 +147,13 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 	e.precreatorService = precreator.NewService(c.PrecreatorConfig)
 	e.precreatorService.MetaClient = e.metaClient
 
+	// Shards are only moved to the cold tier if it has a directory.
+	tieringConfig := c.TieringService
+	tieringConfig.Enabled = tieringConfig.Enabled && c.Data.ColdDir != ""
+	e.tieringService = tiering.NewService(tieringConfig)
+	e.tieringService.TSDBStore = e.tsdbStore
+	e.tieringService.MetaClient = e.metaClient
+
 	return e
 }
 
@@ -154,6 +173,10 @@ func (e *Engine) WithLogger(log *zap.Logger) {
 	if e.precreatorService != nil {
 		e.precreatorService.WithLogger(log)
 	}
+
+	if e.tieringService != nil {
+		e.tieringService.WithLogger(log)
+	}
 }
 
 // PrometheusCollectors returns all the prometheus collectors associated with
@@ -179,6 +202,12 @@ func (e *Engine) Open(ctx context.Context) (err error) {
 		return err
 	}
 
+	// Shards are only missing data until their interrupted reshard step is
+	// resumed, so a failure doesn't prevent the engine from opening.
+	if err := e.resumeReshards(); err != nil {
+		e.logger.Error("Failed to resume reshard", zap.Error(err))
+	}
+
 	if err := e.retentionService.Open(ctx); err != nil {
 		return err
 	}
@@ -187,6 +216,10 @@ func (e *Engine) Open(ctx context.Context) (err error) {
 		return err
 	}
 
+	if err := e.tieringService.Open(ctx); err != nil {
+		return err
+	}
+
 	e.closing = make(chan struct{})
 
 	return nil
@@ -227,6 +260,10 @@ func (e *Engine) Close() error {
 		retErr = multierr.Append(retErr, fmt.Errorf("error closing retention service: %w", err))
 	}
 
+	if err := e.tieringService.Close(); err != nil {
+		retErr = multierr.Append(retErr, fmt.Errorf("error closing tiering service: %w", err))
+	}
+
 	if err := e.tsdbStore.Close(); err != nil {
 		retErr = multierr.Append(retErr, fmt.Errorf("error closing TSDB store: %w", err))
 	}
@@ -258,6 +295,9 @@ func (e *Engine) WritePoints(ctx context.Context, orgID platform.ID, bucketID pl
 		return ErrEngineClosed
 	}
 
+	release := e.reshardFence.enter(bucketID.String(), points)
+	defer release()
+
 	return e.pointsWriter.WritePoints(bucketID.String(), meta.DefaultRetentionPolicyName, models.ConsistencyLevelAll, &meta.UserInfo{}, points)
 }
 
@@ -315,6 +355,11 @@ func (e *Engine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID
 	if e.closing == nil {
 		return ErrEngineClosed
 	}
+
+	// A delete to the shards being replaced by a reshard would be lost.
+	release := e.reshardFence.enterRange(bucketID.String(), min, max)
+	defer release()
+
 	return e.tsdbStore.DeleteSeriesWithPredicate(bucketID.String(), min, max, pred)
 }
 
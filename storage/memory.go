@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+)
+
+// ListMemoryUsage returns the memory used by the shards and series files of
+// the buckets matching the filter, ordered by bucket. Every key of the TSM
+// files is read to break down the memory by measurement, so it is slow for
+// large buckets.
+func (e *Engine) ListMemoryUsage(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageBucketMemoryUsage, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	buckets := make(map[platform.ID]*influxdb.StorageBucketMemoryUsage)
+	for _, dbi := range e.metaClient.Databases() {
+		bucketID, err := platform.IDFromString(dbi.Name)
+		if err != nil || (filter.BucketID != nil && *filter.BucketID != *bucketID) {
+			continue
+		}
+		sfile := e.tsdbStore.SeriesFileMemoryUsage(dbi.Name)
+		buckets[*bucketID] = &influxdb.StorageBucketMemoryUsage{
+			BucketID: *bucketID,
+			Budget:   int64(e.config.Data.BucketMemoryBudget),
+			SeriesFile: influxdb.StorageSeriesFileMemoryUsage{
+				Index: sfile.Index,
+				Mmap:  sfile.Mmap,
+			},
+			Shards:       []*influxdb.StorageShardMemoryUsage{},
+			Measurements: []*influxdb.StorageMeasurementMemoryUsage{},
+		}
+	}
+
+	measurements := make(map[platform.ID]map[string]*influxdb.StorageMeasurementMemoryUsage)
+	e.walkShards(func(bucketID platform.ID, _ *meta.ShardGroupInfo, sh *tsdb.Shard) bool {
+		bucket := buckets[bucketID]
+		if bucket == nil {
+			return true
+		}
+
+		// Shards that are closed use no memory.
+		usage, err := sh.MemoryUsage()
+		if err != nil {
+			return true
+		}
+		byMeasurement, err := sh.MeasurementsMemoryUsage()
+		if err != nil {
+			return true
+		}
+
+		addMemoryUsage(&bucket.StorageMemoryUsage, usage)
+		bucket.Heap += usage.Heap()
+		shard := &influxdb.StorageShardMemoryUsage{ShardID: sh.ID()}
+		addMemoryUsage(&shard.StorageMemoryUsage, usage)
+		bucket.Shards = append(bucket.Shards, shard)
+
+		if measurements[bucketID] == nil {
+			measurements[bucketID] = make(map[string]*influxdb.StorageMeasurementMemoryUsage)
+		}
+		for name, usage := range byMeasurement {
+			m := measurements[bucketID][name]
+			if m == nil {
+				m = &influxdb.StorageMeasurementMemoryUsage{Name: name}
+				measurements[bucketID][name] = m
+				bucket.Measurements = append(bucket.Measurements, m)
+			}
+			addMemoryUsage(&m.StorageMemoryUsage, usage)
+		}
+		return true
+	})
+
+	usages := make([]*influxdb.StorageBucketMemoryUsage, 0, len(buckets))
+	for _, bucket := range buckets {
+		sort.Slice(bucket.Shards, func(i, j int) bool {
+			return bucket.Shards[i].ShardID < bucket.Shards[j].ShardID
+		})
+		sort.Slice(bucket.Measurements, func(i, j int) bool {
+			a, b := bucket.Measurements[i], bucket.Measurements[j]
+			if na, nb := memoryUsageTotal(a.StorageMemoryUsage), memoryUsageTotal(b.StorageMemoryUsage); na != nb {
+				return na > nb
+			}
+			return a.Name < b.Name
+		})
+		usages = append(usages, bucket)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].BucketID < usages[j].BucketID
+	})
+	return usages, nil
+}
+
+func addMemoryUsage(dst *influxdb.StorageMemoryUsage, u tsdb.MemoryUsage) {
+	dst.Index += u.Index
+	dst.SeriesIDSetCache += u.SeriesIDSetCache
+	dst.Cache += u.Cache
+	dst.TSMIndex += u.TSMIndex
+}
+
+func memoryUsageTotal(u influxdb.StorageMemoryUsage) int64 {
+	return u.Index + u.SeriesIDSetCache + u.Cache + u.TSMIndex
+}
@@ -0,0 +1,760 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/file"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"go.uber.org/zap"
+)
+
+const (
+	// reshardDirName is the directory under the engine path holding the
+	// files staged by the reshard of each bucket.
+	reshardDirName = ".reshard"
+
+	// reshardJournalName is the journal written to the staging directory of
+	// a bucket before its shard groups are replaced.
+	reshardJournalName = "reshard.json"
+)
+
+// ReshardBucket replaces the next shard groups of a bucket that don't have its
+// shard group duration by groups that do.
+//
+// The data of the shard groups is first copied to a staging directory while
+// they are still written to. The writes to their time range are then held
+// while the data written since is copied, and the shard groups are replaced
+// in a single update of the meta store. The writes are let through once the
+// staged data is imported into the new shards. A journal lets a step
+// interrupted after the update complete on the next call or when the engine
+// is opened.
+func (e *Engine) ReshardBucket(ctx context.Context, bucketID platform.ID) (*influxdb.StorageReshardStep, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.reshardMu.Lock()
+	defer e.reshardMu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		r, step, err := e.stageReshard(ctx, bucketID)
+		if err != nil || r == nil {
+			return step, err
+		}
+		step, err = e.commitReshard(r, step)
+		if err != errReshardRestage {
+			return step, err
+		}
+		if attempt == maxReshardAttempts {
+			return nil, &errors2.Error{
+				Code: errors2.EUnavailable,
+				Msg:  fmt.Sprintf("shard groups of bucket %s kept changing while they were staged, try again later", bucketID),
+			}
+		}
+		e.logger.Info("Shard groups changed while they were staged, staging them again", zap.String("bucket_id", r.db))
+	}
+}
+
+// maxReshardAttempts is the number of times a reshard step is staged before
+// giving up when the shard groups keep changing.
+const maxReshardAttempts = 3
+
+// stageReshard plans the next step of the reshard of a bucket and copies the
+// data of its shard groups to the staging directory. It returns a nil reshard
+// if the bucket has no shard groups left to reshard.
+func (e *Engine) stageReshard(ctx context.Context, bucketID platform.ID) (*reshard, *influxdb.StorageReshardStep, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, nil, ErrEngineClosed
+	}
+
+	db := bucketID.String()
+	if e.metaClient.Database(db) == nil {
+		return nil, nil, errBucketNotFound(bucketID)
+	}
+
+	dir := filepath.Join(e.path, reshardDirName, db)
+	if err := e.resumeReshard(db, dir); err != nil {
+		return nil, nil, err
+	}
+
+	rpi, err := e.metaClient.RetentionPolicy(db, meta.DefaultRetentionPolicyName)
+	if err != nil {
+		return nil, nil, err
+	} else if rpi == nil {
+		return nil, nil, errBucketNotFound(bucketID)
+	}
+
+	step := &influxdb.StorageReshardStep{
+		BucketID:           bucketID,
+		ShardGroupDuration: influxdb.Duration{Duration: rpi.ShardGroupDuration},
+		SourceShardIDs:     []uint64{},
+		ShardIDs:           []uint64{},
+	}
+	r := newReshard(db, dir, rpi)
+	if r == nil {
+		return nil, step, nil
+	}
+
+	if err := r.stage(ctx, e.tsdbStore); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	return r, step, nil
+}
+
+// commitReshard copies the data written to the shard groups of r since they
+// were staged, and replaces them by the groups of r. The writes to the time
+// range of r are held from the copy until the staged files are imported, so
+// that they are written to the new shards after the imported data. It returns
+// errReshardRestage if the shard groups or their files changed since they
+// were staged. The staged files are kept if the step fails after the meta
+// store is updated, to be imported when the step is resumed.
+func (e *Engine) commitReshard(r *reshard, step *influxdb.StorageReshardStep) (*influxdb.StorageReshardStep, error) {
+	var committed bool
+	defer func() {
+		if !committed {
+			os.RemoveAll(r.dir)
+		}
+	}()
+	defer e.reshardFence.lower()
+
+	journal, created, err := e.replaceReshardGroups(r, step)
+	if err != nil {
+		return nil, err
+	} else if journal == nil {
+		return step, nil
+	}
+	committed = true
+	if err := e.completeReshard(r.db, r.dir, journal); err != nil {
+		return nil, err
+	}
+
+	step.StartTime, step.EndTime = r.start, r.end
+	step.SourceShardIDs = journal.ShardIDs
+	for _, sgi := range created {
+		for _, si := range sgi.Shards {
+			step.ShardIDs = append(step.ShardIDs, si.ID)
+		}
+	}
+	if rpi, err := e.metaClient.RetentionPolicy(r.db, meta.DefaultRetentionPolicyName); err == nil && rpi != nil {
+		step.Remaining = reshardRemaining(rpi)
+	}
+
+	e.logger.Info("Resharded shard groups",
+		zap.String("bucket_id", r.db),
+		zap.Time("start", r.start),
+		zap.Time("end", r.end),
+		zap.Uint64s("source_shards", step.SourceShardIDs),
+		zap.Uint64s("shards", step.ShardIDs))
+	return step, nil
+}
+
+// replaceReshardGroups holds the writes to the time range of r, copies the
+// data written to its shard groups since they were staged, and replaces them
+// in the meta store. It returns the journal of the step and the shard groups
+// created, or a nil journal if the bucket has no shard groups left to
+// reshard. The writes are still held when it returns.
+func (e *Engine) replaceReshardGroups(r *reshard, step *influxdb.StorageReshardStep) (*reshardJournal, []meta.ShardGroupInfo, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, nil, ErrEngineClosed
+	}
+
+	e.reshardFence.raise(r.db, r.start.UnixNano(), r.end.UnixNano())
+
+	rpi, err := e.metaClient.RetentionPolicy(r.db, meta.DefaultRetentionPolicyName)
+	if err != nil {
+		return nil, nil, err
+	} else if rpi == nil {
+		return nil, nil, errBucketNotFound(step.BucketID)
+	}
+
+	// The shard groups may have changed while they were staged, in which
+	// case the step is staged again without holding the writes.
+	if next := newReshard(r.db, r.dir, rpi); next == nil {
+		return nil, nil, nil
+	} else if !next.sameGroups(r) {
+		return nil, nil, errReshardRestage
+	}
+	if err := r.stage(context.Background(), e.tsdbStore); err != nil {
+		return nil, nil, err
+	}
+
+	journal := r.journal()
+	if err := writeReshardJournal(r.dir, journal); err != nil {
+		return nil, nil, err
+	}
+	groups := make([]meta.ShardGroupInfo, 0, len(journal.Windows))
+	for _, w := range journal.Windows {
+		groups = append(groups, meta.ShardGroupInfo{StartTime: w.StartTime, EndTime: w.EndTime})
+	}
+	created, err := e.metaClient.ReplaceShardGroups(r.db, meta.DefaultRetentionPolicyName, journal.ShardGroupIDs, groups)
+	if err != nil {
+		return nil, nil, err
+	}
+	return journal, created, nil
+}
+
+// resumeReshards completes the reshard steps that were interrupted after the
+// meta store was updated, and removes the files staged by the others.
+func (e *Engine) resumeReshards() error {
+	dirs, err := ioutil.ReadDir(filepath.Join(e.path, reshardDirName))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, fi := range dirs {
+		if err := e.resumeReshard(fi.Name(), filepath.Join(e.path, reshardDirName, fi.Name())); err != nil {
+			return fmt.Errorf("failed to resume reshard of bucket %s: %w", fi.Name(), err)
+		}
+	}
+	return nil
+}
+
+// resumeReshard completes the reshard step of a database staged in dir if
+// the meta store was updated, and removes dir.
+func (e *Engine) resumeReshard(db, dir string) error {
+	journal, err := readReshardJournal(dir)
+	if os.IsNotExist(err) {
+		return os.RemoveAll(dir)
+	} else if err != nil {
+		return err
+	}
+
+	rpi, err := e.metaClient.RetentionPolicy(db, meta.DefaultRetentionPolicyName)
+	if err != nil {
+		return err
+	} else if rpi == nil || !journal.committed(rpi) {
+		return os.RemoveAll(dir)
+	}
+
+	e.logger.Info("Resuming interrupted reshard", zap.String("bucket_id", db))
+	start, end := journal.timeRange()
+	e.reshardFence.raise(db, start, end)
+	defer e.reshardFence.lower()
+	return e.completeReshard(db, dir, journal)
+}
+
+// completeReshard imports the files staged in dir into the shards of the
+// shard groups created by a reshard step, then deletes the shards replaced.
+// The caller holds the writes to the time range of the step, which are let
+// through once the files are imported.
+func (e *Engine) completeReshard(db, dir string, journal *reshardJournal) error {
+	rpi, err := e.metaClient.RetentionPolicy(db, meta.DefaultRetentionPolicyName)
+	if err != nil {
+		return err
+	} else if rpi == nil {
+		return fmt.Errorf("retention policy of bucket %s not found", db)
+	}
+
+	for _, w := range journal.Windows {
+		sgi := w.shardGroup(rpi)
+		if sgi == nil || len(sgi.Shards) == 0 {
+			return fmt.Errorf("shard group for %s to %s not found", w.StartTime, w.EndTime)
+		}
+		shardID := sgi.Shards[0].ID
+
+		// Windows whose files were imported have no directory left.
+		path := filepath.Join(dir, w.Dir)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := e.tsdbStore.CreateShard(db, meta.DefaultRetentionPolicyName, shardID, true); err != nil {
+			return err
+		}
+		if err := e.importShardFiles(shardID, path); err != nil {
+			return fmt.Errorf("failed to import data into shard %d: %w", shardID, err)
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+
+	e.reshardFence.lower()
+
+	// The series of the shards replaced are in the new shards, so they are
+	// kept in the series file.
+	for _, id := range journal.ShardIDs {
+		if err := e.tsdbStore.DeleteShard(id); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// reshard is a step of the reshard of a database: the shard groups
+// overlapping a time range are replaced by groups of the shard group duration
+// of the retention policy.
+type reshard struct {
+	db         string
+	dir        string
+	start, end time.Time
+	groups     []meta.ShardGroupInfo
+	windows    []reshardWindow
+
+	splitter *shardSplitter
+	// files are the TSM files of each shard staged so far, with the sizes of
+	// the TSM file and of its tombstone file.
+	files map[uint64]map[string]int64
+}
+
+// newReshard returns the next step of the reshard of a retention policy, or
+// nil if all its shard groups have its shard group duration. The time range
+// of the step starts with the first shard group without that duration, and
+// is extended to whole groups of the new duration and to the groups
+// overlapping them.
+func newReshard(db, dir string, rpi *meta.RetentionPolicyInfo) *reshard {
+	d := rpi.ShardGroupDuration
+	if d <= 0 {
+		return nil
+	}
+
+	var groups []meta.ShardGroupInfo
+	first := -1
+	for _, sgi := range rpi.ShardGroups {
+		if sgi.Deleted() {
+			continue
+		}
+		if first < 0 && !reshardAligned(sgi, d) {
+			first = len(groups)
+		}
+		groups = append(groups, sgi)
+	}
+	if first < 0 {
+		return nil
+	}
+
+	r := &reshard{db: db, dir: dir, start: groups[first].StartTime, end: groups[first].EndTime}
+	for {
+		r.start, r.end = r.start.Truncate(d), r.end.Add(-1).Truncate(d).Add(d)
+		r.groups = r.groups[:0]
+		var extended bool
+		for _, sgi := range groups {
+			if !sgi.Overlaps(r.start, r.end.Add(-1)) {
+				continue
+			}
+			r.groups = append(r.groups, sgi)
+			if sgi.StartTime.Before(r.start) {
+				r.start, extended = sgi.StartTime, true
+			}
+			if sgi.EndTime.After(r.end) {
+				r.end, extended = sgi.EndTime, true
+			}
+		}
+		if !extended {
+			break
+		}
+	}
+
+	maxTime := time.Unix(0, models.MaxNanoTime+1).UTC()
+	for t := r.start; t.Before(r.end) && t.Before(maxTime); t = t.Add(d) {
+		w := reshardWindow{
+			Dir:       strconv.Itoa(len(r.windows) + 1),
+			StartTime: t.UTC(),
+			EndTime:   t.Add(d).UTC(),
+		}
+		if w.EndTime.After(maxTime) {
+			w.EndTime = maxTime
+		}
+		r.windows = append(r.windows, w)
+	}
+	return r
+}
+
+// reshardAligned returns whether sgi has the shard group duration d.
+func reshardAligned(sgi meta.ShardGroupInfo, d time.Duration) bool {
+	if !sgi.StartTime.Equal(sgi.StartTime.Truncate(d)) {
+		return false
+	}
+	end := sgi.StartTime.Add(d)
+	if maxTime := time.Unix(0, models.MaxNanoTime+1); end.After(maxTime) {
+		end = maxTime
+	}
+	return sgi.EndTime.Equal(end)
+}
+
+// reshardRemaining returns the number of shard groups of rpi that don't have
+// its shard group duration.
+func reshardRemaining(rpi *meta.RetentionPolicyInfo) int {
+	var n int
+	for _, sgi := range rpi.ShardGroups {
+		if !sgi.Deleted() && !reshardAligned(sgi, rpi.ShardGroupDuration) {
+			n++
+		}
+	}
+	return n
+}
+
+// sameGroups returns whether r and other replace the same shard groups.
+func (r *reshard) sameGroups(other *reshard) bool {
+	if len(r.groups) != len(other.groups) || !r.start.Equal(other.start) || !r.end.Equal(other.end) {
+		return false
+	}
+	for i := range r.groups {
+		if r.groups[i].ID != other.groups[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// stage splits the TSM files of the shards of r by the windows of r, into
+// one directory per window. Files staged by a previous call are skipped. It
+// returns errReshardRestage if a file was compacted or deleted from since.
+func (r *reshard) stage(ctx context.Context, store *tsdb.Store) error {
+	if r.splitter == nil {
+		r.reset()
+	}
+
+	for _, sgi := range r.groups {
+		for _, si := range sgi.Shards {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			path, err := store.CreateShardSnapshot(si.ID, false)
+			if err != nil {
+				// Shards that aren't stored locally have no data to stage.
+				continue
+			}
+			err = r.stageShard(si.ID, path)
+			os.RemoveAll(path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// errReshardRestage is returned when files staged for a shard changed since.
+var errReshardRestage = fmt.Errorf("shard files changed since they were staged")
+
+// stageShard splits the TSM files of the snapshot of a shard in dir that
+// were not staged yet.
+func (r *reshard) stageShard(id uint64, dir string) error {
+	files, err := snapshotFileSizes(dir)
+	if err != nil {
+		return err
+	}
+
+	staged := r.files[id]
+	for name, size := range staged {
+		if files[name] != size {
+			return errReshardRestage
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if _, ok := staged[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if staged == nil {
+		staged = make(map[string]int64, len(names))
+		r.files[id] = staged
+	}
+	for _, name := range names {
+		if err := r.splitter.split(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to stage shard %d: %w", id, err)
+		}
+		staged[name] = files[name]
+	}
+	return nil
+}
+
+// reset discards the files staged by r.
+func (r *reshard) reset() {
+	r.files = make(map[uint64]map[string]int64)
+	r.splitter = &shardSplitter{
+		dir:   r.dir,
+		start: r.start.UnixNano(),
+		stop:  r.end.UnixNano() - 1,
+		groupFn: func(t int64) (*meta.ShardGroupInfo, error) {
+			// The shards of the groups are numbered by window until the
+			// groups are created in the meta store.
+			for i, w := range r.windows {
+				if sgi := w.shardGroupInfo(uint64(i + 1)); sgi.Contains(time.Unix(0, t)) {
+					return sgi, nil
+				}
+			}
+			return nil, fmt.Errorf("no shard group for time %d", t)
+		},
+	}
+}
+
+// journal returns the journal of r, listing the windows with staged files.
+func (r *reshard) journal() *reshardJournal {
+	j := &reshardJournal{
+		ShardGroupIDs: make([]uint64, 0, len(r.groups)),
+		ShardIDs:      []uint64{},
+		Windows:       []reshardWindow{},
+	}
+	for _, sgi := range r.groups {
+		j.ShardGroupIDs = append(j.ShardGroupIDs, sgi.ID)
+		for _, si := range sgi.Shards {
+			j.ShardIDs = append(j.ShardIDs, si.ID)
+		}
+	}
+	for _, id := range r.splitter.shardIDs() {
+		j.Windows = append(j.Windows, r.windows[id-1])
+	}
+	return j
+}
+
+// snapshotFileSizes returns the TSM files of a shard snapshot, with the sizes
+// of the TSM file and of its tombstone file.
+func snapshotFileSizes(dir string) (map[string]int64, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]int64)
+	for _, fi := range fis {
+		name := fi.Name()
+		switch filepath.Ext(name) {
+		case "." + tsm1.TSMFileExtension:
+			files[name] += fi.Size()
+		case "." + tsm1.TombstoneFileExtension:
+			files[strings.TrimSuffix(name, tsm1.TombstoneFileExtension)+tsm1.TSMFileExtension] += fi.Size()
+		}
+	}
+	return files, nil
+}
+
+// reshardJournal records the shard groups replaced by a reshard step and the
+// windows of the groups replacing them.
+type reshardJournal struct {
+	ShardGroupIDs []uint64        `json:"shardGroupIDs"`
+	ShardIDs      []uint64        `json:"shardIDs"`
+	Windows       []reshardWindow `json:"windows"`
+}
+
+// timeRange returns the time range covered by the windows of the journal, in
+// nanoseconds, the end excluded.
+func (j *reshardJournal) timeRange() (start, end int64) {
+	for i, w := range j.Windows {
+		if t := w.StartTime.UnixNano(); i == 0 || t < start {
+			start = t
+		}
+		if t := w.EndTime.UnixNano(); i == 0 || t > end {
+			end = t
+		}
+	}
+	return start, end
+}
+
+// committed returns whether the shard groups of the journal were replaced in
+// rpi.
+func (j *reshardJournal) committed(rpi *meta.RetentionPolicyInfo) bool {
+	for _, id := range j.ShardGroupIDs {
+		for _, sgi := range rpi.ShardGroups {
+			if sgi.ID == id && !sgi.Deleted() {
+				return false
+			}
+		}
+	}
+	for _, w := range j.Windows {
+		if w.shardGroup(rpi) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// reshardWindow is the time range of a shard group created by a reshard
+// step, and the directory of its staged files.
+type reshardWindow struct {
+	Dir       string    `json:"dir"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+// shardGroup returns the shard group of rpi with the time range of w.
+func (w reshardWindow) shardGroup(rpi *meta.RetentionPolicyInfo) *meta.ShardGroupInfo {
+	for i := range rpi.ShardGroups {
+		sgi := &rpi.ShardGroups[i]
+		if !sgi.Deleted() && sgi.StartTime.Equal(w.StartTime) && sgi.EndTime.Equal(w.EndTime) {
+			return sgi
+		}
+	}
+	return nil
+}
+
+func (w reshardWindow) shardGroupInfo(shardID uint64) *meta.ShardGroupInfo {
+	return &meta.ShardGroupInfo{
+		StartTime: w.StartTime,
+		EndTime:   w.EndTime,
+		Shards:    []meta.ShardInfo{{ID: shardID}},
+	}
+}
+
+func writeReshardJournal(dir string, j *reshardJournal) error {
+	buf, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, reshardJournalName)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := file.RenameFile(path+".tmp", path); err != nil {
+		return err
+	}
+	return file.SyncDir(dir)
+}
+
+func readReshardJournal(dir string) (*reshardJournal, error) {
+	buf, err := ioutil.ReadFile(filepath.Join(dir, reshardJournalName))
+	if err != nil {
+		return nil, err
+	}
+
+	var j reshardJournal
+	if err := json.Unmarshal(buf, &j); err != nil {
+		return nil, fmt.Errorf("invalid reshard journal: %w", err)
+	}
+	return &j, nil
+}
+
+// reshardFence holds the writes and deletes to the time range of a database
+// while the shard groups of a reshard step are replaced.
+type reshardFence struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	raised     bool
+	db         string
+	start, end int64
+
+	// writes are the writes and deletes in progress.
+	writes map[*fencedWrite]struct{}
+}
+
+// fencedWrite is the time range of the points of a write, or of a delete,
+// the end included.
+type fencedWrite struct {
+	db       string
+	min, max int64
+}
+
+func newReshardFence() *reshardFence {
+	f := &reshardFence{writes: make(map[*fencedWrite]struct{})}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// enter waits until the writes to the time range of points in db are let
+// through, and returns a function to call once the points are written.
+func (f *reshardFence) enter(db string, points []models.Point) func() {
+	if len(points) == 0 {
+		return func() {}
+	}
+
+	min, max := points[0].UnixNano(), points[0].UnixNano()
+	for _, p := range points[1:] {
+		if t := p.UnixNano(); t < min {
+			min = t
+		} else if t > max {
+			max = t
+		}
+	}
+	return f.enterRange(db, min, max)
+}
+
+// enterRange waits until the writes or deletes from min until max, included,
+// in db are let through, and returns a function to call once they are done.
+func (f *reshardFence) enterRange(db string, min, max int64) func() {
+	w := &fencedWrite{db: db, min: min, max: max}
+	f.mu.Lock()
+	for f.holds(w) {
+		f.cond.Wait()
+	}
+	f.writes[w] = struct{}{}
+	f.mu.Unlock()
+
+	return func() {
+		f.mu.Lock()
+		delete(f.writes, w)
+		f.mu.Unlock()
+		f.cond.Broadcast()
+	}
+}
+
+// raise holds the writes to db from start until end, excluded, and waits for
+// those in progress.
+func (f *reshardFence) raise(db string, start, end int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.raised, f.db, f.start, f.end = true, db, start, end
+	for {
+		var busy bool
+		for w := range f.writes {
+			if f.holds(w) {
+				busy = true
+				break
+			}
+		}
+		if !busy {
+			return
+		}
+		f.cond.Wait()
+	}
+}
+
+// lower lets the writes held through.
+func (f *reshardFence) lower() {
+	f.mu.Lock()
+	f.raised = false
+	f.mu.Unlock()
+	f.cond.Broadcast()
+}
+
+// holds returns whether w overlaps the time range held.
+func (f *reshardFence) holds(w *fencedWrite) bool {
+	return f.raised && w.db == f.db && w.min < f.end && w.max >= f.start
+}
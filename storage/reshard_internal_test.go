@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNewReshard(t *testing.T) {
+	day := 24 * time.Hour
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	group := func(id uint64, start time.Time, d time.Duration) meta.ShardGroupInfo {
+		return meta.ShardGroupInfo{
+			ID:        id,
+			StartTime: start,
+			EndTime:   start.Add(d),
+			Shards:    []meta.ShardInfo{{ID: id}},
+		}
+	}
+
+	for _, tt := range []struct {
+		name       string
+		duration   time.Duration
+		groups     []meta.ShardGroupInfo
+		ids        []uint64
+		start, end time.Time
+		windows    int
+	}{
+		{
+			name:     "aligned",
+			duration: day,
+			groups:   []meta.ShardGroupInfo{group(1, t0, day), group(2, t0.Add(day), day)},
+		},
+		{
+			name:     "merge",
+			duration: day,
+			groups: []meta.ShardGroupInfo{
+				group(1, t0, time.Hour),
+				group(2, t0.Add(time.Hour), time.Hour),
+				group(3, t0.Add(day), time.Hour),
+			},
+			ids:     []uint64{1, 2},
+			start:   t0,
+			end:     t0.Add(day),
+			windows: 1,
+		},
+		{
+			name:     "split",
+			duration: time.Hour,
+			groups:   []meta.ShardGroupInfo{group(1, t0, day), group(2, t0.Add(day), day)},
+			ids:      []uint64{1},
+			start:    t0,
+			end:      t0.Add(day),
+			windows:  24,
+		},
+		{
+			// The 36 hour group overlaps the second day, and so the 12
+			// hour group in it.
+			name:     "overlapping",
+			duration: day,
+			groups: []meta.ShardGroupInfo{
+				group(1, t0, 36*time.Hour),
+				group(2, t0.Add(36*time.Hour), 12*time.Hour),
+				group(3, t0.Add(2*day), day),
+			},
+			ids:     []uint64{1, 2},
+			start:   t0,
+			end:     t0.Add(2 * day),
+			windows: 2,
+		},
+		{
+			name:     "deleted",
+			duration: day,
+			groups: []meta.ShardGroupInfo{
+				{ID: 1, StartTime: t0, EndTime: t0.Add(time.Hour), DeletedAt: t0},
+				group(2, t0.Add(day), time.Hour),
+			},
+			ids:     []uint64{2},
+			start:   t0.Add(day),
+			end:     t0.Add(2 * day),
+			windows: 1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rpi := &meta.RetentionPolicyInfo{ShardGroupDuration: tt.duration, ShardGroups: tt.groups}
+			r := newReshard("db", "dir", rpi)
+			if tt.ids == nil {
+				require.Nil(t, r)
+				require.Zero(t, reshardRemaining(rpi))
+				return
+			}
+
+			require.NotNil(t, r)
+			var ids []uint64
+			for _, sgi := range r.groups {
+				ids = append(ids, sgi.ID)
+			}
+			require.Equal(t, tt.ids, ids)
+			require.Equal(t, tt.start, r.start)
+			require.Equal(t, tt.end, r.end)
+			require.Len(t, r.windows, tt.windows)
+			for i, w := range r.windows {
+				require.Equal(t, tt.start.Add(time.Duration(i)*tt.duration), w.StartTime)
+				require.Equal(t, tt.duration, w.EndTime.Sub(w.StartTime))
+			}
+		})
+	}
+}
+
+func TestReshardFence(t *testing.T) {
+	point := func(t *testing.T, ts int64) models.Point {
+		p, err := models.NewPoint("cpu", nil, models.Fields{"value": 1.0}, time.Unix(0, ts))
+		require.NoError(t, err)
+		return p
+	}
+	entered := func(f *reshardFence, db string, ts int64) <-chan func() {
+		ch := make(chan func(), 1)
+		go func() { ch <- f.enter(db, []models.Point{point(t, ts)}) }()
+		return ch
+	}
+
+	f := newReshardFence()
+
+	// A write in progress to the range holds the fence until it's done.
+	release := f.enter("db", []models.Point{point(t, 5), point(t, 50)})
+	raised := make(chan struct{})
+	go func() {
+		f.raise("db", 10, 20)
+		close(raised)
+	}()
+	select {
+	case <-raised:
+		t.Fatal("fence raised while a write to its range is in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	<-raised
+
+	// Writes outside the range or to another database are let through.
+	(<-entered(f, "db", 20))()
+	(<-entered(f, "db", 9))()
+	(<-entered(f, "other", 15))()
+
+	// Writes to the range are held until the fence is lowered.
+	held := entered(f, "db", 19)
+	select {
+	case <-held:
+		t.Fatal("write to the range let through while the fence is raised")
+	case <-time.After(50 * time.Millisecond):
+	}
+	f.lower()
+	(<-held)()
+}
+
+func TestEngine_DeleteDuringReshardCommit(t *testing.T) {
+	ctx := context.Background()
+	kvStore := inmem.NewKVStore()
+	require.NoError(t, kvStore.CreateBucket(ctx, meta.BucketName))
+	metaClient := meta.NewClient(meta.NewConfig(), kvStore)
+	require.NoError(t, metaClient.Open())
+	defer metaClient.Close()
+
+	e := NewEngine(t.TempDir(), NewConfig(), WithMetaClient(metaClient))
+	e.WithLogger(zaptest.NewLogger(t))
+	require.NoError(t, e.Open(ctx))
+	defer e.Close()
+
+	orgID, bucketID := platform.ID(1), platform.ID(2)
+	require.NoError(t, e.CreateBucket(ctx, &influxdb.Bucket{ID: bucketID, OrgID: orgID}))
+	var points []models.Point
+	for _, ts := range []int64{5, 15} {
+		p, err := models.NewPoint("cpu", nil, models.Fields{"value": 1.0}, time.Unix(0, ts))
+		require.NoError(t, err)
+		points = append(points, p)
+	}
+	require.NoError(t, e.WritePoints(ctx, orgID, bucketID, points))
+
+	// The commit of a reshard step holds its time range.
+	e.reshardFence.raise(bucketID.String(), 10, 20)
+
+	// A delete overlapping the range waits for the commit.
+	deleted := make(chan error, 1)
+	go func() { deleted <- e.DeleteBucketRangePredicate(ctx, orgID, bucketID, 0, 12, nil) }()
+	select {
+	case err := <-deleted:
+		t.Fatalf("delete let through while the shard groups are replaced: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A delete outside the range is let through.
+	require.NoError(t, e.DeleteBucketRangePredicate(ctx, orgID, bucketID, 20, 30, nil))
+
+	e.reshardFence.lower()
+	require.NoError(t, <-deleted)
+}
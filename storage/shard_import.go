@@ -0,0 +1,355 @@
+package storage
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	intar "github.com/influxdata/influxdb/v2/pkg/tar"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"go.uber.org/zap"
+)
+
+// importDirPrefix prefixes the temporary directories holding the files of an
+// import under the engine path.
+const importDirPrefix = ".import"
+
+// ImportShard adds the data between start and stop of a tar archive written
+// by ExportShard to the shards of a bucket. The blocks of the archive are
+// split by the shard groups of the bucket, which are created as needed, and
+// the series they hold are added to the series file of the bucket.
+func (e *Engine) ImportShard(ctx context.Context, bucketID platform.ID, start, stop time.Time, r io.Reader) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return ErrEngineClosed
+	}
+
+	db := bucketID.String()
+	if e.metaClient.Database(db) == nil {
+		return errBucketNotFound(bucketID)
+	}
+
+	dir, err := ioutil.TempDir(e.path, importDirPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := unpackShardArchive(r, filepath.Join(dir, "archive"))
+	if err != nil {
+		return &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "invalid shard archive",
+			Err:  err,
+		}
+	}
+
+	s := &shardSplitter{
+		dir:   filepath.Join(dir, "shards"),
+		start: start.UnixNano(),
+		stop:  stop.UnixNano() - 1,
+		groupFn: func(t int64) (*meta.ShardGroupInfo, error) {
+			return e.metaClient.CreateShardGroup(db, meta.DefaultRetentionPolicyName, time.Unix(0, t))
+		},
+	}
+	for _, path := range files {
+		if err := s.split(path); err != nil {
+			return err
+		}
+	}
+
+	for _, shardID := range s.shardIDs() {
+		if err := e.tsdbStore.CreateShard(db, meta.DefaultRetentionPolicyName, shardID, true); err != nil {
+			return err
+		}
+		if err := e.importShardFiles(shardID, filepath.Join(s.dir, strconv.FormatUint(shardID, 10))); err != nil {
+			return fmt.Errorf("failed to import data into shard %d: %w", shardID, err)
+		}
+		e.logger.Info("Imported shard data",
+			zap.String("bucket_id", db),
+			zap.Uint64("shard_id", shardID))
+	}
+	return nil
+}
+
+// importShardFiles adds the TSM files of dir to a shard as new files.
+func (e *Engine) importShardFiles(shardID uint64, dir string) error {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(intar.Stream(pw, dir, "", nil))
+	}()
+
+	err := e.tsdbStore.ImportShard(shardID, pr)
+	pr.CloseWithError(err)
+	<-done
+	return err
+}
+
+// unpackShardArchive writes the TSM and tombstone files of a tar archive to
+// dir, and returns the paths of the TSM files.
+func unpackShardArchive(r io.Reader, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Base(filepath.FromSlash(hdr.Name))
+		ext := strings.TrimPrefix(filepath.Ext(name), ".")
+		if hdr.Typeflag != tar.TypeReg || (ext != tsm1.TSMFileExtension && ext != tsm1.TombstoneFileExtension) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if err := writeArchiveFile(path, tr); err != nil {
+			return nil, err
+		}
+		if ext == tsm1.TSMFileExtension {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func writeArchiveFile(path string, r io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// shardSplitter rewrites TSM files into one set of files per shard group,
+// keeping the values between start and stop that aren't deleted.
+type shardSplitter struct {
+	dir         string
+	start, stop int64
+	groupFn     func(t int64) (*meta.ShardGroupInfo, error)
+
+	groups []*meta.ShardGroupInfo
+	files  map[uint64]int
+}
+
+// shardIDs returns the IDs of the shards files were written for.
+func (s *shardSplitter) shardIDs() []uint64 {
+	ids := make([]uint64, 0, len(s.files))
+	for id := range s.files {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// group returns the shard group holding the time t.
+func (s *shardSplitter) group(t int64) (*meta.ShardGroupInfo, error) {
+	for _, sgi := range s.groups {
+		if sgi.Contains(time.Unix(0, t)) {
+			return sgi, nil
+		}
+	}
+
+	sgi, err := s.groupFn(t)
+	if err != nil {
+		return nil, err
+	} else if len(sgi.Shards) == 0 {
+		return nil, fmt.Errorf("shard group %d has no shards", sgi.ID)
+	}
+	s.groups = append(s.groups, sgi)
+	return sgi, nil
+}
+
+// split writes the blocks of the TSM file at path to the shards of their
+// shard groups. Blocks are copied as is unless they have to be trimmed or
+// span several shard groups.
+func (s *shardSplitter) split(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	r, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	defer r.Close()
+
+	writers := make(map[uint64]*splitWriter)
+	defer func() {
+		for _, w := range writers {
+			w.abort()
+		}
+	}()
+
+	writeBlock := func(sgi *meta.ShardGroupInfo, key []byte, minTime, maxTime int64, block []byte) error {
+		shardID := sgi.Shards[0].ID
+		w := writers[shardID]
+		if w == nil {
+			w = &splitWriter{dir: filepath.Join(s.dir, strconv.FormatUint(shardID, 10))}
+			writers[shardID] = w
+		}
+		return w.writeBlock(s.nextFile, shardID, key, minTime, maxTime, block)
+	}
+
+	var values []tsm1.Value
+	bi := r.BlockIterator()
+	for bi.Next() {
+		key, minTime, maxTime, _, _, block, err := bi.Read()
+		if err != nil {
+			return err
+		}
+		if maxTime < s.start || minTime > s.stop {
+			continue
+		}
+
+		sgi, err := s.group(minTime)
+		if err != nil {
+			return err
+		}
+
+		tombstones := r.TombstoneRange(key)
+		if minTime >= s.start && maxTime <= s.stop && maxTime < sgi.EndTime.UnixNano() && !overlapsAny(tombstones, minTime, maxTime) {
+			if err := writeBlock(sgi, key, minTime, maxTime, block); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if values, err = tsm1.DecodeBlock(block, values[:0]); err != nil {
+			return err
+		}
+		values = tsm1.Values(values).Include(s.start, s.stop)
+		for _, tr := range tombstones {
+			values = tsm1.Values(values).Exclude(tr.Min, tr.Max)
+		}
+
+		for len(values) > 0 {
+			if sgi, err = s.group(values[0].UnixNano()); err != nil {
+				return err
+			}
+			n := sort.Search(len(values), func(i int) bool {
+				return values[i].UnixNano() >= sgi.EndTime.UnixNano()
+			})
+
+			b, err := tsm1.Values(values[:n]).Encode(nil)
+			if err != nil {
+				return err
+			}
+			if err := writeBlock(sgi, key, values[0].UnixNano(), values[n-1].UnixNano(), b); err != nil {
+				return err
+			}
+			values = values[n:]
+		}
+	}
+	if err := bi.Err(); err != nil {
+		return err
+	}
+
+	for shardID, w := range writers {
+		if err := w.close(); err != nil {
+			return err
+		}
+		delete(writers, shardID)
+	}
+	return nil
+}
+
+// nextFile returns the path of a new TSM file of a shard.
+func (s *shardSplitter) nextFile(shardID uint64) string {
+	if s.files == nil {
+		s.files = make(map[uint64]int)
+	}
+	s.files[shardID]++
+	return fmt.Sprintf("%09d.%s", s.files[shardID], tsm1.TSMFileExtension)
+}
+
+func overlapsAny(trs []tsm1.TimeRange, min, max int64) bool {
+	for _, tr := range trs {
+		if tr.Overlaps(min, max) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitWriter writes the blocks of a shard to TSM files, starting a new file
+// whenever a key has as many blocks as a file can hold.
+type splitWriter struct {
+	dir string
+	f   *os.File
+	w   tsm1.TSMWriter
+}
+
+func (w *splitWriter) writeBlock(nextFile func(uint64) string, shardID uint64, key []byte, minTime, maxTime int64, block []byte) error {
+	if w.w == nil {
+		if err := os.MkdirAll(w.dir, 0777); err != nil {
+			return err
+		}
+		f, err := os.Create(filepath.Join(w.dir, nextFile(shardID)))
+		if err != nil {
+			return err
+		}
+		if w.w, err = tsm1.NewTSMWriter(f); err != nil {
+			f.Close()
+			return err
+		}
+		w.f = f
+	}
+
+	err := w.w.WriteBlock(key, minTime, maxTime, block)
+	if err == tsm1.ErrMaxBlocksExceeded {
+		return w.close()
+	}
+	return err
+}
+
+func (w *splitWriter) close() error {
+	if w.w == nil {
+		return nil
+	}
+	defer func() { w.f, w.w = nil, nil }()
+
+	if err := w.w.WriteIndex(); err != nil {
+		w.w.Close()
+		return err
+	}
+	return w.w.Close()
+}
+
+func (w *splitWriter) abort() {
+	if w.w != nil {
+		w.w.Close()
+		os.Remove(w.f.Name())
+	}
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+)
+
+var _ influxdb.StorageService = (*Engine)(nil)
+
+// ListShards returns the shards of the engine matching the filter.
+func (e *Engine) ListShards(ctx context.Context, filter influxdb.StorageShardFilter) ([]*influxdb.StorageShard, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	shards := []*influxdb.StorageShard{}
+	e.walkShards(func(bucketID platform.ID, sgi *meta.ShardGroupInfo, sh *tsdb.Shard) bool {
+		if filter.BucketID == nil || *filter.BucketID == bucketID {
+			shards = append(shards, e.describeShard(bucketID, sgi, sh))
+		}
+		return true
+	})
+
+	sort.Slice(shards, func(i, j int) bool {
+		a, b := shards[i], shards[j]
+		if a.BucketID != b.BucketID {
+			return a.BucketID < b.BucketID
+		}
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		return a.ID < b.ID
+	})
+	return shards, nil
+}
+
+// FindShardByID returns the shard of the engine with the given ID.
+func (e *Engine) FindShardByID(ctx context.Context, id uint64) (*influxdb.StorageShard, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	var shard *influxdb.StorageShard
+	e.walkShards(func(bucketID platform.ID, sgi *meta.ShardGroupInfo, sh *tsdb.Shard) bool {
+		if sh.ID() != id {
+			return true
+		}
+		shard = e.describeShard(bucketID, sgi, sh)
+		return false
+	})
+	if shard == nil {
+		return nil, errShardNotFound(id)
+	}
+	return shard, nil
+}
+
+// CompactShard schedules a full compaction of a shard.
+func (e *Engine) CompactShard(ctx context.Context, id uint64) error {
+	return e.withShard(ctx, id, (*tsdb.Shard).ScheduleFullCompaction)
+}
+
+// SnapshotShard writes the cache of a shard to a new TSM file.
+func (e *Engine) SnapshotShard(ctx context.Context, id uint64) error {
+	return e.withShard(ctx, id, (*tsdb.Shard).WriteSnapshot)
+}
+
+// RebuildShardIndex rebuilds the index of a shard from its data.
+func (e *Engine) RebuildShardIndex(ctx context.Context, id uint64) error {
+	return e.withShard(ctx, id, (*tsdb.Shard).RebuildIndex)
+}
+
+// ExportShard writes a tar archive of the TSM files of a shard, keeping the
+// blocks with values between start and stop.
+func (e *Engine) ExportShard(ctx context.Context, id uint64, start, stop time.Time, w io.Writer) error {
+	return e.withShard(ctx, id, func(sh *tsdb.Shard) error {
+		return e.tsdbStore.ExportShard(sh.ID(), start, stop.Add(-1), w)
+	})
+}
+
+func (e *Engine) withShard(ctx context.Context, id uint64, fn func(*tsdb.Shard) error) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return ErrEngineClosed
+	}
+
+	sh := e.tsdbStore.Shard(id)
+	if sh == nil {
+		return errShardNotFound(id)
+	}
+	return fn(sh)
+}
+
+// walkShards calls fn with the shards of the engine that are not deleted,
+// until fn returns false.
+func (e *Engine) walkShards(fn func(bucketID platform.ID, sgi *meta.ShardGroupInfo, sh *tsdb.Shard) bool) {
+	data := e.metaClient.Data()
+	for _, dbi := range data.Databases {
+		bucketID, err := platform.IDFromString(dbi.Name)
+		if err != nil {
+			continue
+		}
+		for _, rpi := range dbi.RetentionPolicies {
+			for i := range rpi.ShardGroups {
+				sgi := &rpi.ShardGroups[i]
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					sh := e.tsdbStore.Shard(si.ID)
+					if sh == nil {
+						continue
+					}
+					if !fn(*bucketID, sgi, sh) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// describeShard returns the description of a shard. The statistics of shards
+// that are closed, for instance while their index is rebuilt, are left empty.
+func (e *Engine) describeShard(bucketID platform.ID, sgi *meta.ShardGroupInfo, sh *tsdb.Shard) *influxdb.StorageShard {
+	shard := &influxdb.StorageShard{
+		ID:           sh.ID(),
+		BucketID:     bucketID,
+		ShardGroupID: sgi.ID,
+		StartTime:    sgi.StartTime,
+		EndTime:      sgi.EndTime,
+		SeriesN:      sh.SeriesN(),
+		State:        influxdb.StorageShardHot,
+		Tier:         influxdb.StorageTierPrimary,
+		LastModified: sh.LastModified(),
+		TSMFiles:     []influxdb.StorageTSMFile{},
+	}
+	shard.Size, _ = sh.DiskSize()
+
+	for _, si := range sgi.Shards {
+		if si.ID == shard.ID && si.Cold() {
+			shard.Tier = influxdb.StorageTierCold
+		}
+	}
+
+	// Shards are cold once the compaction planner considers them so.
+	cold := time.Duration(e.config.Data.CompactFullWriteColdDuration)
+	if !shard.LastModified.IsZero() && time.Since(shard.LastModified) >= cold {
+		shard.State = influxdb.StorageShardCold
+	}
+
+	files, _ := sh.FileStats()
+	for _, f := range files {
+		shard.TSMFiles = append(shard.TSMFiles, influxdb.StorageTSMFile{
+			Name:         filepath.Base(f.Path),
+			Level:        f.Level,
+			Size:         f.Size,
+			MinTime:      time.Unix(0, f.MinTime).UTC(),
+			MaxTime:      time.Unix(0, f.MaxTime).UTC(),
+			HasTombstone: f.HasTombstone,
+		})
+	}
+	return shard
+}
+
+func errShardNotFound(id uint64) error {
+	return &errors2.Error{
+		Code: errors2.ENotFound,
+		Msg:  fmt.Sprintf("shard %d not found", id),
+	}
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+)
+
+// MoveShardToColdTier moves the files of a shard to the cold directory of the
+// engine, and records the new tier of the shard in the meta store.
+func (e *Engine) MoveShardToColdTier(ctx context.Context, id uint64) error {
+	return e.withShard(ctx, id, func(sh *tsdb.Shard) error {
+		if err := e.tsdbStore.MoveShardToColdTier(sh.ID()); err == tsdb.ErrColdTierDisabled {
+			return errColdTierDisabled()
+		} else if err != nil {
+			return err
+		}
+		return e.metaClient.SetShardTier(sh.ID(), meta.ShardTierCold)
+	})
+}
+
+// FindTieringPolicy returns the tiering policy of a bucket.
+func (e *Engine) FindTieringPolicy(ctx context.Context, bucketID platform.ID) (*influxdb.StorageTieringPolicy, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+	return e.findTieringPolicy(bucketID)
+}
+
+// UpdateTieringPolicy sets the tiering policy of a bucket. The shards of the
+// bucket are moved to the cold tier by the tiering service.
+func (e *Engine) UpdateTieringPolicy(ctx context.Context, policy influxdb.StorageTieringPolicy) (*influxdb.StorageTieringPolicy, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	if policy.ColdAfter.Duration < 0 {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "cold after duration must not be negative",
+		}
+	} else if policy.ColdAfter.Duration > 0 && e.config.Data.ColdDir == "" {
+		return nil, errColdTierDisabled()
+	}
+
+	db := policy.BucketID.String()
+	if e.metaClient.Database(db) == nil {
+		return nil, errBucketNotFound(policy.BucketID)
+	}
+
+	rpu := meta.RetentionPolicyUpdate{}
+	rpu.SetColdDuration(policy.ColdAfter.Duration)
+	if err := e.metaClient.UpdateRetentionPolicy(db, meta.DefaultRetentionPolicyName, &rpu, true); err != nil {
+		return nil, err
+	}
+	return e.findTieringPolicy(policy.BucketID)
+}
+
+func (e *Engine) findTieringPolicy(bucketID platform.ID) (*influxdb.StorageTieringPolicy, error) {
+	db := bucketID.String()
+	if e.metaClient.Database(db) == nil {
+		return nil, errBucketNotFound(bucketID)
+	}
+
+	rpi, err := e.metaClient.RetentionPolicy(db, meta.DefaultRetentionPolicyName)
+	if err != nil {
+		return nil, err
+	} else if rpi == nil {
+		return nil, errBucketNotFound(bucketID)
+	}
+
+	return &influxdb.StorageTieringPolicy{
+		BucketID:  bucketID,
+		ColdAfter: influxdb.Duration{Duration: rpi.ColdDuration},
+	}, nil
+}
+
+func errBucketNotFound(id platform.ID) error {
+	return &errors2.Error{
+		Code: errors2.ENotFound,
+		Msg:  fmt.Sprintf("bucket %s not found", id),
+	}
+}
+
+func errColdTierDisabled() error {
+	return &errors2.Error{
+		Code: errors2.EUnprocessableEntity,
+		Msg:  "cold tier is disabled, its directory must be set with storage-cold-dir",
+	}
+}
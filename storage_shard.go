@@ -0,0 +1,284 @@
+package influxdb
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// StorageShardState is whether a shard is still written to.
+type StorageShardState string
+
+const (
+	// StorageShardHot is the state of shards written to recently.
+	StorageShardHot StorageShardState = "hot"
+	// StorageShardCold is the state of shards that haven't been written to
+	// for long enough to be fully compacted.
+	StorageShardCold StorageShardState = "cold"
+)
+
+// StorageShardTier is the storage tier holding the files of a shard.
+type StorageShardTier string
+
+const (
+	// StorageTierPrimary is the tier of shards stored in the engine path.
+	StorageTierPrimary StorageShardTier = "primary"
+	// StorageTierCold is the tier of shards moved to the cold directory of
+	// the engine. Shards on the cold tier are read-only.
+	StorageTierCold StorageShardTier = "cold"
+)
+
+// StorageShard describes a shard of the storage engine.
+type StorageShard struct {
+	ID           uint64            `json:"id"`
+	BucketID     platform.ID       `json:"bucketID"`
+	ShardGroupID uint64            `json:"shardGroupID"`
+	StartTime    time.Time         `json:"startTime"`
+	EndTime      time.Time         `json:"endTime"`
+	Size         int64             `json:"size"`
+	SeriesN      int64             `json:"series"`
+	State        StorageShardState `json:"state"`
+	Tier         StorageShardTier  `json:"tier"`
+	LastModified time.Time         `json:"lastModified"`
+	TSMFiles     []StorageTSMFile  `json:"tsmFiles"`
+}
+
+// StorageTSMFile describes a TSM file of a shard.
+type StorageTSMFile struct {
+	Name         string    `json:"name"`
+	Level        int       `json:"level"`
+	Size         int64     `json:"size"`
+	MinTime      time.Time `json:"minTime"`
+	MaxTime      time.Time `json:"maxTime"`
+	HasTombstone bool      `json:"hasTombstone"`
+}
+
+// StorageTieringPolicy is when the shards of a bucket move to the cold tier.
+type StorageTieringPolicy struct {
+	BucketID platform.ID `json:"bucketID"`
+	// ColdAfter is how long after the end time of their shard group shards
+	// move to the cold tier. Zero keeps the shards on the primary tier.
+	ColdAfter Duration `json:"coldAfter"`
+}
+
+// Durability modes of a bucket.
+const (
+	// DurabilityDefault uses the WAL configuration of the storage engine.
+	DurabilityDefault = ""
+	// DurabilityFsync fsyncs the WAL before each write is acknowledged.
+	DurabilityFsync = "fsync"
+	// DurabilityGroupCommit fsyncs the WAL once for the writes made within
+	// a delay.
+	DurabilityGroupCommit = "group-commit"
+	// DurabilityNoWAL only writes to the cache, which is written to TSM files
+	// periodically. Writes since the last snapshot are lost in a crash.
+	DurabilityNoWAL = "no-wal"
+)
+
+// StorageDurabilityPolicy is how writes to the shards of a bucket are made
+// durable.
+type StorageDurabilityPolicy struct {
+	BucketID platform.ID `json:"bucketID"`
+	Mode     string      `json:"mode"`
+	// FsyncDelay is how long the WAL waits before an fsync in the
+	// group-commit mode.
+	FsyncDelay Duration `json:"fsyncDelay"`
+	// SnapshotInterval is the longest the cache holds writes in the no-wal
+	// mode. Zero only writes the cache to TSM files when it is too large
+	// or cold.
+	SnapshotInterval Duration `json:"snapshotInterval"`
+}
+
+// StorageCompaction describes a compaction of the TSM files of a shard,
+// either queued or in progress.
+type StorageCompaction struct {
+	ShardID  uint64      `json:"shardID"`
+	BucketID platform.ID `json:"bucketID"`
+	// Level is the level of the compaction, 1 to 3 for level compactions
+	// and 4 for full compactions.
+	Level int      `json:"level"`
+	Files []string `json:"files"`
+	// Size is the total size of the files, an estimate of the bytes the
+	// compaction writes.
+	Size int64 `json:"size"`
+
+	// StartTime and BytesWritten are only set for compactions in progress.
+	StartTime    *time.Time `json:"startTime,omitempty"`
+	BytesWritten int64      `json:"bytesWritten"`
+}
+
+// StorageCompactions are the compactions of the storage engine.
+type StorageCompactions struct {
+	Queued     []*StorageCompaction `json:"queued"`
+	InProgress []*StorageCompaction `json:"inProgress"`
+}
+
+// StorageCompactionLimits are the limits shared by all compactions of the
+// storage engine.
+type StorageCompactionLimits struct {
+	// Throughput is the number of bytes per second compactions write to
+	// disk. Zero is unlimited.
+	Throughput int64 `json:"throughput"`
+	// ThroughputBurst is the number of bytes compactions may write at once.
+	ThroughputBurst int64 `json:"throughputBurst"`
+	// MaxConcurrent is the number of compactions that may run at once.
+	MaxConcurrent int `json:"maxConcurrent"`
+}
+
+// StorageCompactionLimitsUpdate represents updates to the compaction limits.
+// Only fields which are set are updated.
+type StorageCompactionLimitsUpdate struct {
+	Throughput      *int64 `json:"throughput,omitempty"`
+	ThroughputBurst *int64 `json:"throughputBurst,omitempty"`
+	MaxConcurrent   *int   `json:"maxConcurrent,omitempty"`
+}
+
+// StorageMemoryUsage estimates the memory used by shards of the storage
+// engine, in bytes.
+type StorageMemoryUsage struct {
+	// Index is the memory of the in-memory structures of the TSI indexes,
+	// such as their log files not yet compacted into index files.
+	Index int64 `json:"index"`
+	// SeriesIDSetCache is the memory of the series ID sets cached by the
+	// TSI indexes.
+	SeriesIDSetCache int64 `json:"seriesIDSetCache"`
+	// Cache is the memory of the writes not yet written to TSM files.
+	Cache int64 `json:"cache"`
+	// TSMIndex is the size of the indexes of the TSM files. They are
+	// mmapped, so the kernel reclaims their pages under memory pressure.
+	TSMIndex int64 `json:"tsmIndex"`
+}
+
+// StorageShardMemoryUsage estimates the memory used by a shard.
+type StorageShardMemoryUsage struct {
+	ShardID uint64 `json:"shardID"`
+	StorageMemoryUsage
+}
+
+// StorageMeasurementMemoryUsage estimates the memory used by a measurement
+// across the shards of a bucket.
+type StorageMeasurementMemoryUsage struct {
+	Name string `json:"name"`
+	StorageMemoryUsage
+}
+
+// StorageSeriesFileMemoryUsage estimates the memory used by the series file
+// of a bucket, in bytes.
+type StorageSeriesFileMemoryUsage struct {
+	// Index is the memory of the series indexed in memory by the partitions
+	// of the series file since their last compaction.
+	Index int64 `json:"index"`
+	// Mmap is the size of the index files and segments of the partitions,
+	// which are mmapped.
+	Mmap int64 `json:"mmap"`
+}
+
+// StorageBucketMemoryUsage estimates the memory used by the storage engine
+// for a bucket.
+type StorageBucketMemoryUsage struct {
+	BucketID platform.ID `json:"bucketID"`
+	StorageMemoryUsage
+	// Heap is the memory of the shards counted against the budget: their
+	// index, series ID set cache and cache.
+	Heap int64 `json:"heap"`
+	// Budget is the heap memory above which the shards of the bucket write
+	// their caches to disk and empty their series ID set caches. Zero is
+	// unlimited.
+	Budget     int64                        `json:"budget"`
+	SeriesFile StorageSeriesFileMemoryUsage `json:"seriesFile"`
+	Shards     []*StorageShardMemoryUsage   `json:"shards"`
+	// Measurements are ordered by the memory they use, largest first.
+	Measurements []*StorageMeasurementMemoryUsage `json:"measurements"`
+}
+
+// StorageReshardStep describes a step of the reshard of a bucket, which
+// replaces the shard groups overlapping a time range by groups of the shard
+// group duration of the bucket.
+type StorageReshardStep struct {
+	BucketID           platform.ID `json:"bucketID"`
+	ShardGroupDuration Duration    `json:"shardGroupDuration"`
+	StartTime          time.Time   `json:"startTime"`
+	EndTime            time.Time   `json:"endTime"`
+	// SourceShardIDs are the shards whose data was merged or split by the
+	// step, and ShardIDs the shards it was written to. Both are empty once
+	// all the shard groups of the bucket have its shard group duration.
+	SourceShardIDs []uint64 `json:"sourceShardIDs"`
+	ShardIDs       []uint64 `json:"shardIDs"`
+	// Remaining is the number of shard groups left to reshard.
+	Remaining int `json:"remaining"`
+}
+
+// StorageShardFilter represents a set of filters that restrict the shards
+// returned by StorageService.ListShards.
+type StorageShardFilter struct {
+	BucketID *platform.ID
+}
+
+// StorageService represents the operator functions on the shards of the
+// storage engine.
+type StorageService interface {
+	// ListShards returns the shards matching the filter, ordered by bucket
+	// and start time.
+	ListShards(ctx context.Context, filter StorageShardFilter) ([]*StorageShard, error)
+
+	// FindShardByID returns a single shard by ID.
+	FindShardByID(ctx context.Context, id uint64) (*StorageShard, error)
+
+	// CompactShard schedules a full compaction of a shard.
+	CompactShard(ctx context.Context, id uint64) error
+
+	// SnapshotShard writes the cache of a shard to a new TSM file.
+	SnapshotShard(ctx context.Context, id uint64) error
+
+	// RebuildShardIndex rebuilds the index of a shard from its data. The
+	// shard rejects writes and queries until its index is rebuilt.
+	RebuildShardIndex(ctx context.Context, id uint64) error
+
+	// ExportShard writes a tar archive of the TSM files of a shard, keeping
+	// the blocks with values between start and stop.
+	ExportShard(ctx context.Context, id uint64, start, stop time.Time, w io.Writer) error
+
+	// ImportShard adds the values between start and stop of an archive
+	// written by ExportShard to a bucket, creating its shards as needed.
+	ImportShard(ctx context.Context, bucketID platform.ID, start, stop time.Time, r io.Reader) error
+
+	// MoveShardToColdTier moves the files of a shard to the cold tier, where
+	// it is reopened read-only.
+	MoveShardToColdTier(ctx context.Context, id uint64) error
+
+	// FindTieringPolicy returns the tiering policy of a bucket.
+	FindTieringPolicy(ctx context.Context, bucketID platform.ID) (*StorageTieringPolicy, error)
+
+	// UpdateTieringPolicy sets the tiering policy of a bucket.
+	UpdateTieringPolicy(ctx context.Context, policy StorageTieringPolicy) (*StorageTieringPolicy, error)
+
+	// ListCompactions returns the queued and in progress compactions of the
+	// shards matching the filter.
+	ListCompactions(ctx context.Context, filter StorageShardFilter) (*StorageCompactions, error)
+
+	// FindCompactionLimits returns the limits shared by all compactions.
+	FindCompactionLimits(ctx context.Context) (*StorageCompactionLimits, error)
+
+	// UpdateCompactionLimits changes the limits shared by all compactions
+	// until the storage engine is restarted.
+	UpdateCompactionLimits(ctx context.Context, upd StorageCompactionLimitsUpdate) (*StorageCompactionLimits, error)
+
+	// FindDurabilityPolicy returns the durability policy of a bucket.
+	FindDurabilityPolicy(ctx context.Context, bucketID platform.ID) (*StorageDurabilityPolicy, error)
+
+	// UpdateDurabilityPolicy sets the durability policy of a bucket and
+	// reopens the shards of the bucket to apply it.
+	UpdateDurabilityPolicy(ctx context.Context, policy StorageDurabilityPolicy) (*StorageDurabilityPolicy, error)
+
+	// ListMemoryUsage returns the memory used by the shards and series files
+	// of the buckets matching the filter, ordered by bucket.
+	ListMemoryUsage(ctx context.Context, filter StorageShardFilter) ([]*StorageBucketMemoryUsage, error)
+
+	// ReshardBucket replaces the next shard groups of a bucket that don't
+	// have its shard group duration by groups that do, merging or splitting
+	// their data. Readers see either the old or the new shard groups, and a
+	// bucket is resharded by calling ReshardBucket until no groups remain.
+	ReshardBucket(ctx context.Context, bucketID platform.ID) (*StorageReshardStep, error)
+}
@@ -69,6 +69,15 @@ const (
 	// partition snapshot compactions that can run at one time.
 	// A value of 0 results in runtime.GOMAXPROCS(0).
 	DefaultSeriesFileMaxConcurrentSnapshotCompactions = 0
+
+	// DefaultTSMBlockCompression is the default compression of the values of
+	// TSM float and string blocks: gorilla for floats and snappy for strings.
+	DefaultTSMBlockCompression = "default"
+
+	// TSMBlockCompressionZstd compresses the values of TSM string blocks with
+	// zstd, and those of float blocks when zstd compresses them better than
+	// gorilla. Blocks compressed with zstd can't be read by older versions.
+	TSMBlockCompressionZstd = "zstd"
 )
 
 // Config holds the configuration for the tsbd package.
@@ -77,6 +86,10 @@ type Config struct {
 	Engine string `toml:"-"`
 	Index  string `toml:"index-version"`
 
+	// ColdDir is the directory shards are moved to when they reach the cold
+	// tier of their bucket. Shards on the cold tier are read-only.
+	ColdDir string `toml:"cold-dir"`
+
 	// General WAL configuration options
 	WALDir string `toml:"wal-dir"`
 
@@ -99,6 +112,11 @@ type Config struct {
 	CompactThroughput              toml.Size     `toml:"compact-throughput"`
 	CompactThroughputBurst         toml.Size     `toml:"compact-throughput-burst"`
 
+	// TSMBlockCompression is the compression of the values of the float and
+	// string blocks written by snapshots and compactions, "default" or "zstd".
+	// Blocks of existing files are compressed again as they are compacted.
+	TSMBlockCompression string `toml:"tsm-block-compression"`
+
 	// Limits
 
 	// MaxConcurrentCompactions is the maximum number of concurrent level and full compactions
@@ -118,6 +136,14 @@ type Config struct {
 	// Setting series-id-set-cache-size to 0 disables the cache.
 	SeriesIDSetCacheSize int `toml:"series-id-set-cache-size"`
 
+	// BucketMemoryBudget is the heap memory, in bytes, the shards of a bucket may use for their index,
+	// series ID set cache and cache. The shards of a bucket using more memory write their caches to
+	// TSM files, compact their index log files and empty their series ID set caches. The budget is the
+	// same for every bucket and applies to each of them on its own, it does not bound the memory of all
+	// the buckets together. A bucket that stays over budget is only freed again once its memory grew
+	// by a tenth of the budget. A value of 0 disables the budget.
+	BucketMemoryBudget toml.Size `toml:"bucket-memory-budget"`
+
 	// SeriesFileMaxConcurrentSnapshotCompactions is the maximum number of concurrent snapshot compactions
 	// that can be running at one time across all series partitions in a database. Snapshots scheduled
 	// to run when the limit is reached are blocked until a running snapshot completes.  Only snapshot
@@ -148,6 +174,7 @@ func NewConfig() Config {
 		CompactFullWriteColdDuration:   toml.Duration(DefaultCompactFullWriteColdDuration),
 		CompactThroughput:              toml.Size(DefaultCompactThroughput),
 		CompactThroughputBurst:         toml.Size(DefaultCompactThroughputBurst),
+		TSMBlockCompression:            DefaultTSMBlockCompression,
 
 		MaxConcurrentCompactions: DefaultMaxConcurrentCompactions,
 
@@ -181,6 +208,12 @@ func (c *Config) Validate() error {
 		return errors.New("series-file-max-concurrent-compactions must be non-negative")
 	}
 
+	switch c.TSMBlockCompression {
+	case "", DefaultTSMBlockCompression, TSMBlockCompressionZstd:
+	default:
+		return fmt.Errorf("unrecognized tsm-block-compression %s", c.TSMBlockCompression)
+	}
+
 	valid := false
 	for _, e := range RegisteredEngines() {
 		if e == c.Engine {
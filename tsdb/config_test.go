@@ -70,6 +70,17 @@ func TestConfig_Validate_Error(t *testing.T) {
 	if err := c.Validate(); err == nil || err.Error() != "series-id-set-cache-size must be non-negative" {
 		t.Errorf("unexpected error: %s", err)
 	}
+
+	c.SeriesIDSetCacheSize = tsdb.DefaultSeriesIDSetCacheSize
+	c.TSMBlockCompression = "lz4"
+	if err := c.Validate(); err == nil || err.Error() != "unrecognized tsm-block-compression lz4" {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	c.TSMBlockCompression = tsdb.TSMBlockCompressionZstd
+	if err := c.Validate(); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestConfig_ByteSizes(t *testing.T) {
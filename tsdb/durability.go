@@ -0,0 +1,67 @@
+package tsdb
+
+import (
+	"fmt"
+	"time"
+)
+
+// DurabilityMode controls when writes to the shards of a retention policy are
+// durable.
+type DurabilityMode string
+
+const (
+	// DurabilityDefault uses the WAL settings of the configuration.
+	DurabilityDefault DurabilityMode = ""
+
+	// DurabilityFsync fsyncs the WAL before each write is acknowledged.
+	DurabilityFsync DurabilityMode = "fsync"
+
+	// DurabilityGroupCommit waits for a delay before fsyncing the WAL, so
+	// that concurrent writes are fsynced together. Writes are acknowledged
+	// once they are fsynced.
+	DurabilityGroupCommit DurabilityMode = "group-commit"
+
+	// DurabilityNoWAL keeps writes in the cache only, which is written to a
+	// TSM file periodically. Writes since the last snapshot are lost if the
+	// process crashes.
+	DurabilityNoWAL DurabilityMode = "no-wal"
+)
+
+// Durability describes how the engine of a shard makes writes durable.
+type Durability struct {
+	Mode DurabilityMode
+
+	// FsyncDelay is how long the WAL waits before fsyncing in the group
+	// commit mode.
+	FsyncDelay time.Duration
+
+	// SnapshotInterval is the longest the cache holds writes before it is
+	// written to a TSM file in the no-WAL mode. Zero only writes the cache
+	// when it exceeds the snapshot thresholds of the configuration.
+	SnapshotInterval time.Duration
+}
+
+// Validate returns an error if d isn't a valid durability.
+func (d Durability) Validate() error {
+	switch d.Mode {
+	case DurabilityDefault, DurabilityFsync:
+		if d.FsyncDelay != 0 || d.SnapshotInterval != 0 {
+			return fmt.Errorf("durability mode %q takes no fsync delay or snapshot interval", d.Mode)
+		}
+	case DurabilityGroupCommit:
+		if d.FsyncDelay <= 0 {
+			return fmt.Errorf("durability mode %q requires a positive fsync delay", d.Mode)
+		} else if d.SnapshotInterval != 0 {
+			return fmt.Errorf("durability mode %q takes no snapshot interval", d.Mode)
+		}
+	case DurabilityNoWAL:
+		if d.SnapshotInterval < 0 {
+			return fmt.Errorf("durability mode %q requires a snapshot interval that is not negative", d.Mode)
+		} else if d.FsyncDelay != 0 {
+			return fmt.Errorf("durability mode %q takes no fsync delay", d.Mode)
+		}
+	default:
+		return fmt.Errorf("unknown durability mode %q", d.Mode)
+	}
+	return nil
+}
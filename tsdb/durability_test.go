@@ -0,0 +1,29 @@
+package tsdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+func TestDurability_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		d   tsdb.Durability
+		err bool
+	}{
+		{d: tsdb.Durability{}},
+		{d: tsdb.Durability{Mode: tsdb.DurabilityFsync}},
+		{d: tsdb.Durability{Mode: tsdb.DurabilityFsync, FsyncDelay: time.Second}, err: true},
+		{d: tsdb.Durability{Mode: tsdb.DurabilityGroupCommit, FsyncDelay: time.Millisecond}},
+		{d: tsdb.Durability{Mode: tsdb.DurabilityGroupCommit}, err: true},
+		{d: tsdb.Durability{Mode: tsdb.DurabilityNoWAL}},
+		{d: tsdb.Durability{Mode: tsdb.DurabilityNoWAL, SnapshotInterval: time.Minute}},
+		{d: tsdb.Durability{Mode: tsdb.DurabilityNoWAL, SnapshotInterval: -time.Minute}, err: true},
+		{d: tsdb.Durability{Mode: "async"}, err: true},
+	} {
+		if err := tt.d.Validate(); tt.err != (err != nil) {
+			t.Fatalf("unexpected error for durability %+v: %v", tt.d, err)
+		}
+	}
+}
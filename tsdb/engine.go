@@ -29,6 +29,32 @@ var (
 	ErrUnknownEngineFormat = errors.New("unknown engine format")
 )
 
+// FileStat describes a data file of an engine.
+type FileStat struct {
+	Path string
+	Size int64
+	// Level is the compaction level of the file, 1 for files written from
+	// the cache.
+	Level            int
+	MinTime, MaxTime int64
+	HasTombstone     bool
+}
+
+// CompactionStat describes a compaction of the data files of an engine,
+// either planned or in progress.
+type CompactionStat struct {
+	// Level is the level of the compaction, 1 to 3 for level compactions
+	// and 4 for full compactions.
+	Level int
+	Files []string
+	// Size is the total size of the files.
+	Size int64
+
+	// StartTime and BytesWritten are only set for compactions in progress.
+	StartTime    time.Time
+	BytesWritten int64
+}
+
 // Engine represents a swappable storage engine for the shard.
 type Engine interface {
 	Open() error
@@ -36,6 +62,7 @@ type Engine interface {
 	SetEnabled(enabled bool)
 	SetCompactionsEnabled(enabled bool)
 	ScheduleFullCompaction() error
+	WriteSnapshot() error
 
 	WithLogger(*zap.Logger)
 
@@ -78,9 +105,15 @@ type Engine interface {
 	Statistics(tags map[string]string) []models.Statistic
 	LastModified() time.Time
 	DiskSize() int64
+	FileStats() []FileStat
+	CompactionStats() (queued, active []CompactionStat)
 	IsIdle() bool
 	Free() error
 
+	MemoryUsage() MemoryUsage
+	MeasurementsMemoryUsage() map[string]MemoryUsage
+	FreeMemory() error
+
 	Reindex() error
 
 	io.WriterTo
@@ -166,7 +199,7 @@ type EngineOptions struct {
 	// This option is intended for offline tooling.
 	CompactionDisabled          bool
 	CompactionPlannerCreator    CompactionPlannerCreator
-	CompactionLimiter           limiter.Fixed
+	CompactionLimiter           *limiter.Adjustable
 	CompactionThroughputLimiter limiter.Rate
 	WALEnabled                  bool
 	MonitorDisabled             bool
@@ -183,6 +216,13 @@ type EngineOptions struct {
 	// nil will allow all combinations to pass.
 	ShardFilter func(database, rp string, id uint64) bool
 
+	// DurabilityPolicy returns the durability of the shards of a combination of database and retention policy.
+	// nil uses the default durability for all shards.
+	DurabilityPolicy func(database, rp string) Durability
+
+	// Durability is the durability of the shard the engine is created for.
+	Durability Durability
+
 	Config         Config
 	SeriesIDSets   SeriesIDSets
 	FieldValidator FieldValidator
@@ -201,6 +241,9 @@ func NewEngineOptions() EngineOptions {
 		Config:        NewConfig(),
 		WALEnabled:    true,
 		OpenLimiter:   limiter.NewFixed(runtime.GOMAXPROCS(0)),
+
+		// Engines opened outside of a Store don't run background compactions.
+		CompactionLimiter: limiter.NewAdjustable(0),
 	}
 }
 
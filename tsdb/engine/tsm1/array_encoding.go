@@ -31,7 +31,7 @@ func DecodeBooleanArrayBlock(block []byte, a *tsdb.BooleanArray) error {
 // and writes the values to a.
 func DecodeFloatArrayBlock(block []byte, a *tsdb.FloatArray) error {
 	blockType := block[0]
-	if blockType != BlockFloat64 {
+	if blockTypeOf(blockType) != BlockFloat64 {
 		return fmt.Errorf("invalid block type: exp %d, got %d", BlockFloat64, blockType)
 	}
 
@@ -94,7 +94,7 @@ func DecodeUnsignedArrayBlock(block []byte, a *tsdb.UnsignedArray) error {
 // and writes the values to a.
 func DecodeStringArrayBlock(block []byte, a *tsdb.StringArray) error {
 	blockType := block[0]
-	if blockType != BlockString {
+	if blockTypeOf(blockType) != BlockString {
 		return fmt.Errorf("invalid block type: exp %d, got %d", BlockString, blockType)
 	}
 
@@ -647,6 +647,28 @@ func (c *Cache) ApplyEntryFn(f func(key []byte, entry *entry) error) error {
 	return store.applySerial(f)
 }
 
+// MeasurementSizes returns the size of the values of each measurement in the
+// cache and its snapshot, counted like Size, in bytes.
+func (c *Cache) MeasurementSizes() map[string]int64 {
+	c.mu.RLock()
+	stores := []storer{c.store}
+	if c.snapshot != nil {
+		stores = append(stores, c.snapshot.store)
+	}
+	c.mu.RUnlock()
+
+	sizes := make(map[string]int64)
+	for _, store := range stores {
+		// applySerial can't fail with this function.
+		_ = store.applySerial(func(key []byte, e *entry) error {
+			seriesKey, _ := SeriesAndFieldFromCompositeKey(key)
+			sizes[string(models.ParseName(seriesKey))] += int64(len(key) + e.size())
+			return nil
+		})
+	}
+	return sizes
+}
+
 // CacheLoader processes a set of WAL segment files, and loads a cache with the data
 // contained within those files.  Processing of the supplied files take place in the
 // order they exist in the files slice.
@@ -740,6 +762,13 @@ func (c *Cache) LastWriteTime() time.Time {
 	return c.lastWriteTime
 }
 
+// LastSnapshotTime returns the time the cache was last snapshotted.
+func (c *Cache) LastSnapshotTime() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastSnapshot
+}
+
 // UpdateAge updates the age statistic based on the current time.
 func (c *Cache) UpdateAge() {
 	c.mu.RLock()
@@ -1148,7 +1148,8 @@ func (k *tsmBatchKeyIterator) combineFloat(dedup bool) blocks {
 			continue
 		}
 
-		if count < k.size {
+		// blocks with another encoding than the configured one are decoded and encoded again
+		if count < k.size || recodeBlock(k.blocks[i].b, k.encoding) {
 			break
 		}
 
@@ -1169,7 +1170,7 @@ func (k *tsmBatchKeyIterator) combineFloat(dedup bool) blocks {
 	}
 
 	// if we only have 1 blocks left, just append it as is and avoid decoding/recoding
-	if i == len(k.blocks)-1 {
+	if i == len(k.blocks)-1 && !recodeBlock(k.blocks[i].b, k.encoding) {
 		if !k.blocks[i].read() {
 			k.merged = append(k.merged, k.blocks[i])
 		}
@@ -1218,7 +1219,7 @@ func (k *tsmBatchKeyIterator) chunkFloat(dst blocks) blocks {
 		minTime, maxTime := values.Timestamps[0], values.Timestamps[len(values.Timestamps)-1]
 		values.Values = k.mergedFloatValues.Values[:k.size]
 
-		cb, err := EncodeFloatArrayBlock(&values, nil) // TODO(edd): pool this buffer
+		cb, err := encodeFloatArrayBlockUsing(&values, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "float")
 			return nil
@@ -1238,7 +1239,7 @@ func (k *tsmBatchKeyIterator) chunkFloat(dst blocks) blocks {
 	// Re-encode the remaining values into the last block
 	if k.mergedFloatValues.Len() > 0 {
 		minTime, maxTime := k.mergedFloatValues.Timestamps[0], k.mergedFloatValues.Timestamps[len(k.mergedFloatValues.Timestamps)-1]
-		cb, err := EncodeFloatArrayBlock(k.mergedFloatValues, nil) // TODO(edd): pool this buffer
+		cb, err := encodeFloatArrayBlockUsing(k.mergedFloatValues, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "float")
 			return nil
@@ -1372,7 +1373,8 @@ func (k *tsmBatchKeyIterator) combineInteger(dedup bool) blocks {
 			continue
 		}
 
-		if count < k.size {
+		// blocks with another encoding than the configured one are decoded and encoded again
+		if count < k.size || recodeBlock(k.blocks[i].b, k.encoding) {
 			break
 		}
 
@@ -1393,7 +1395,7 @@ func (k *tsmBatchKeyIterator) combineInteger(dedup bool) blocks {
 	}
 
 	// if we only have 1 blocks left, just append it as is and avoid decoding/recoding
-	if i == len(k.blocks)-1 {
+	if i == len(k.blocks)-1 && !recodeBlock(k.blocks[i].b, k.encoding) {
 		if !k.blocks[i].read() {
 			k.merged = append(k.merged, k.blocks[i])
 		}
@@ -1442,7 +1444,7 @@ func (k *tsmBatchKeyIterator) chunkInteger(dst blocks) blocks {
 		minTime, maxTime := values.Timestamps[0], values.Timestamps[len(values.Timestamps)-1]
 		values.Values = k.mergedIntegerValues.Values[:k.size]
 
-		cb, err := EncodeIntegerArrayBlock(&values, nil) // TODO(edd): pool this buffer
+		cb, err := encodeIntegerArrayBlockUsing(&values, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "integer")
 			return nil
@@ -1462,7 +1464,7 @@ func (k *tsmBatchKeyIterator) chunkInteger(dst blocks) blocks {
 	// Re-encode the remaining values into the last block
 	if k.mergedIntegerValues.Len() > 0 {
 		minTime, maxTime := k.mergedIntegerValues.Timestamps[0], k.mergedIntegerValues.Timestamps[len(k.mergedIntegerValues.Timestamps)-1]
-		cb, err := EncodeIntegerArrayBlock(k.mergedIntegerValues, nil) // TODO(edd): pool this buffer
+		cb, err := encodeIntegerArrayBlockUsing(k.mergedIntegerValues, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "integer")
 			return nil
@@ -1596,7 +1598,8 @@ func (k *tsmBatchKeyIterator) combineUnsigned(dedup bool) blocks {
 			continue
 		}
 
-		if count < k.size {
+		// blocks with another encoding than the configured one are decoded and encoded again
+		if count < k.size || recodeBlock(k.blocks[i].b, k.encoding) {
 			break
 		}
 
@@ -1617,7 +1620,7 @@ func (k *tsmBatchKeyIterator) combineUnsigned(dedup bool) blocks {
 	}
 
 	// if we only have 1 blocks left, just append it as is and avoid decoding/recoding
-	if i == len(k.blocks)-1 {
+	if i == len(k.blocks)-1 && !recodeBlock(k.blocks[i].b, k.encoding) {
 		if !k.blocks[i].read() {
 			k.merged = append(k.merged, k.blocks[i])
 		}
@@ -1666,7 +1669,7 @@ func (k *tsmBatchKeyIterator) chunkUnsigned(dst blocks) blocks {
 		minTime, maxTime := values.Timestamps[0], values.Timestamps[len(values.Timestamps)-1]
 		values.Values = k.mergedUnsignedValues.Values[:k.size]
 
-		cb, err := EncodeUnsignedArrayBlock(&values, nil) // TODO(edd): pool this buffer
+		cb, err := encodeUnsignedArrayBlockUsing(&values, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "unsigned")
 			return nil
@@ -1686,7 +1689,7 @@ func (k *tsmBatchKeyIterator) chunkUnsigned(dst blocks) blocks {
 	// Re-encode the remaining values into the last block
 	if k.mergedUnsignedValues.Len() > 0 {
 		minTime, maxTime := k.mergedUnsignedValues.Timestamps[0], k.mergedUnsignedValues.Timestamps[len(k.mergedUnsignedValues.Timestamps)-1]
-		cb, err := EncodeUnsignedArrayBlock(k.mergedUnsignedValues, nil) // TODO(edd): pool this buffer
+		cb, err := encodeUnsignedArrayBlockUsing(k.mergedUnsignedValues, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "unsigned")
 			return nil
@@ -1820,7 +1823,8 @@ func (k *tsmBatchKeyIterator) combineString(dedup bool) blocks {
 			continue
 		}
 
-		if count < k.size {
+		// blocks with another encoding than the configured one are decoded and encoded again
+		if count < k.size || recodeBlock(k.blocks[i].b, k.encoding) {
 			break
 		}
 
@@ -1841,7 +1845,7 @@ func (k *tsmBatchKeyIterator) combineString(dedup bool) blocks {
 	}
 
 	// if we only have 1 blocks left, just append it as is and avoid decoding/recoding
-	if i == len(k.blocks)-1 {
+	if i == len(k.blocks)-1 && !recodeBlock(k.blocks[i].b, k.encoding) {
 		if !k.blocks[i].read() {
 			k.merged = append(k.merged, k.blocks[i])
 		}
@@ -1890,7 +1894,7 @@ func (k *tsmBatchKeyIterator) chunkString(dst blocks) blocks {
 		minTime, maxTime := values.Timestamps[0], values.Timestamps[len(values.Timestamps)-1]
 		values.Values = k.mergedStringValues.Values[:k.size]
 
-		cb, err := EncodeStringArrayBlock(&values, nil) // TODO(edd): pool this buffer
+		cb, err := encodeStringArrayBlockUsing(&values, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "string")
 			return nil
@@ -1910,7 +1914,7 @@ func (k *tsmBatchKeyIterator) chunkString(dst blocks) blocks {
 	// Re-encode the remaining values into the last block
 	if k.mergedStringValues.Len() > 0 {
 		minTime, maxTime := k.mergedStringValues.Timestamps[0], k.mergedStringValues.Timestamps[len(k.mergedStringValues.Timestamps)-1]
-		cb, err := EncodeStringArrayBlock(k.mergedStringValues, nil) // TODO(edd): pool this buffer
+		cb, err := encodeStringArrayBlockUsing(k.mergedStringValues, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "string")
 			return nil
@@ -2044,7 +2048,8 @@ func (k *tsmBatchKeyIterator) combineBoolean(dedup bool) blocks {
 			continue
 		}
 
-		if count < k.size {
+		// blocks with another encoding than the configured one are decoded and encoded again
+		if count < k.size || recodeBlock(k.blocks[i].b, k.encoding) {
 			break
 		}
 
@@ -2065,7 +2070,7 @@ func (k *tsmBatchKeyIterator) combineBoolean(dedup bool) blocks {
 	}
 
 	// if we only have 1 blocks left, just append it as is and avoid decoding/recoding
-	if i == len(k.blocks)-1 {
+	if i == len(k.blocks)-1 && !recodeBlock(k.blocks[i].b, k.encoding) {
 		if !k.blocks[i].read() {
 			k.merged = append(k.merged, k.blocks[i])
 		}
@@ -2114,7 +2119,7 @@ func (k *tsmBatchKeyIterator) chunkBoolean(dst blocks) blocks {
 		minTime, maxTime := values.Timestamps[0], values.Timestamps[len(values.Timestamps)-1]
 		values.Values = k.mergedBooleanValues.Values[:k.size]
 
-		cb, err := EncodeBooleanArrayBlock(&values, nil) // TODO(edd): pool this buffer
+		cb, err := encodeBooleanArrayBlockUsing(&values, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "boolean")
 			return nil
@@ -2134,7 +2139,7 @@ func (k *tsmBatchKeyIterator) chunkBoolean(dst blocks) blocks {
 	// Re-encode the remaining values into the last block
 	if k.mergedBooleanValues.Len() > 0 {
 		minTime, maxTime := k.mergedBooleanValues.Timestamps[0], k.mergedBooleanValues.Timestamps[len(k.mergedBooleanValues.Timestamps)-1]
-		cb, err := EncodeBooleanArrayBlock(k.mergedBooleanValues, nil) // TODO(edd): pool this buffer
+		cb, err := encodeBooleanArrayBlockUsing(k.mergedBooleanValues, k.encoding, nil) // TODO(edd): pool this buffer
 		if err != nil {
 			k.handleEncodeError(err, "boolean")
 			return nil
@@ -705,6 +705,10 @@ type Compactor struct {
 	// RateLimit is the limit for disk writes for all concurrent compactions.
 	RateLimit limiter.Rate
 
+	// BlockEncoding is the encoding of the float and string blocks written by
+	// snapshots and compactions.
+	BlockEncoding byte
+
 	formatFileName FormatFileNameFunc
 	parseFileName  ParseFileNameFunc
 
@@ -856,7 +860,7 @@ func (c *Compactor) WriteSnapshot(cache *Cache) ([]string, error) {
 	resC := make(chan res, concurrency)
 	for i := 0; i < concurrency; i++ {
 		go func(sp *Cache) {
-			iter := NewCacheKeyIterator(sp, tsdb.DefaultMaxPointsPerBlock, intC)
+			iter := newCacheKeyIterator(sp, tsdb.DefaultMaxPointsPerBlock, c.BlockEncoding, intC)
 			files, err := c.writeNewFiles(c.FileStore.NextGeneration(), 0, nil, iter, throttle)
 			resC <- res{files: files, err: err}
 
@@ -891,7 +895,7 @@ func (c *Compactor) WriteSnapshot(cache *Cache) ([]string, error) {
 }
 
 // compact writes multiple smaller TSM files into 1 or more larger files.
-func (c *Compactor) compact(fast bool, tsmFiles []string) ([]string, error) {
+func (c *Compactor) compact(fast bool, tsmFiles []string, written *int64) ([]string, error) {
 	size := c.Size
 	if size <= 0 {
 		size = tsdb.DefaultMaxPointsPerBlock
@@ -945,48 +949,31 @@ func (c *Compactor) compact(fast bool, tsmFiles []string) ([]string, error) {
 		return nil, nil
 	}
 
-	tsm, err := NewTSMBatchKeyIterator(size, fast, intC, tsmFiles, trs...)
+	tsm, err := newTSMBatchKeyIterator(size, fast, c.BlockEncoding, intC, tsmFiles, trs...)
 	if err != nil {
 		return nil, err
 	}
+	if written != nil {
+		tsm = &progressKeyIterator{KeyIterator: tsm, written: written}
+	}
 
 	return c.writeNewFiles(maxGeneration, maxSequence, tsmFiles, tsm, true)
 }
 
 // CompactFull writes multiple smaller TSM files into 1 or more larger files.
 func (c *Compactor) CompactFull(tsmFiles []string) ([]string, error) {
-	c.mu.RLock()
-	enabled := c.compactionsEnabled
-	c.mu.RUnlock()
-
-	if !enabled {
-		return nil, errCompactionsDisabled
-	}
-
-	if !c.add(tsmFiles) {
-		return nil, errCompactionInProgress{}
-	}
-	defer c.remove(tsmFiles)
-
-	files, err := c.compact(false, tsmFiles)
-
-	// See if we were disabled while writing a snapshot
-	c.mu.RLock()
-	enabled = c.compactionsEnabled
-	c.mu.RUnlock()
-
-	if !enabled {
-		if err := c.removeTmpFiles(files); err != nil {
-			return nil, err
-		}
-		return nil, errCompactionsDisabled
-	}
-
-	return files, err
+	return c.compactFiles(false, tsmFiles, nil)
 }
 
 // CompactFast writes multiple smaller TSM files into 1 or more larger files.
 func (c *Compactor) CompactFast(tsmFiles []string) ([]string, error) {
+	return c.compactFiles(true, tsmFiles, nil)
+}
+
+// compactFiles writes multiple smaller TSM files into 1 or more larger files.
+// If written is not nil, the size of the blocks written is added to it as the
+// compaction progresses.
+func (c *Compactor) compactFiles(fast bool, tsmFiles []string, written *int64) ([]string, error) {
 	c.mu.RLock()
 	enabled := c.compactionsEnabled
 	c.mu.RUnlock()
@@ -1000,7 +987,7 @@ func (c *Compactor) CompactFast(tsmFiles []string) ([]string, error) {
 	}
 	defer c.remove(tsmFiles)
 
-	files, err := c.compact(true, tsmFiles)
+	files, err := c.compact(fast, tsmFiles, written)
 
 	// See if we were disabled while writing a snapshot
 	c.mu.RLock()
@@ -1015,7 +1002,6 @@ func (c *Compactor) CompactFast(tsmFiles []string) ([]string, error) {
 	}
 
 	return files, err
-
 }
 
 // removeTmpFiles is responsible for cleaning up a compaction that
@@ -1217,6 +1203,19 @@ func (c *Compactor) remove(files []string) {
 	}
 }
 
+// progressKeyIterator adds the size of the blocks read from a KeyIterator to
+// written.
+type progressKeyIterator struct {
+	KeyIterator
+	written *int64
+}
+
+func (k *progressKeyIterator) Read() ([]byte, int64, int64, []byte, error) {
+	key, minTime, maxTime, block, err := k.KeyIterator.Read()
+	atomic.AddInt64(k.written, int64(len(block)))
+	return key, minTime, maxTime, block, err
+}
+
 // KeyIterator allows iteration over set of keys and values in sorted order.
 type KeyIterator interface {
 	// Next returns true if there are any values remaining in the iterator.
@@ -1617,6 +1616,9 @@ type tsmBatchKeyIterator struct {
 	// size is the maximum number of values to encode in a single block
 	size int
 
+	// encoding is the block encoding of the float and string blocks that are encoded.
+	encoding byte
+
 	// key is the current key lowest key across all readers that has not be fully exhausted
 	// of values.
 	key []byte
@@ -1653,6 +1655,10 @@ func (t *tsmBatchKeyIterator) AppendError(err error) {
 // NewTSMBatchKeyIterator returns a new TSM key iterator from readers.
 // size indicates the maximum number of values to encode in a single block.
 func NewTSMBatchKeyIterator(size int, fast bool, interrupt chan struct{}, tsmFiles []string, readers ...*TSMReader) (KeyIterator, error) {
+	return newTSMBatchKeyIterator(size, fast, BlockEncodingDefault, interrupt, tsmFiles, readers...)
+}
+
+func newTSMBatchKeyIterator(size int, fast bool, encoding byte, interrupt chan struct{}, tsmFiles []string, readers ...*TSMReader) (KeyIterator, error) {
 	var iter []*BlockIterator
 	for _, r := range readers {
 		iter = append(iter, r.BlockIterator())
@@ -1663,6 +1669,7 @@ func NewTSMBatchKeyIterator(size int, fast bool, interrupt chan struct{}, tsmFil
 		values:               map[string][]Value{},
 		pos:                  make([]int, len(readers)),
 		size:                 size,
+		encoding:             encoding,
 		iterators:            iter,
 		fast:                 fast,
 		tsmFiles:             tsmFiles,
@@ -1904,9 +1911,10 @@ func (k *tsmBatchKeyIterator) Err() error {
 }
 
 type cacheKeyIterator struct {
-	cache *Cache
-	size  int
-	order [][]byte
+	cache    *Cache
+	size     int
+	encoding byte
+	order    [][]byte
 
 	i         int
 	blocks    [][]cacheBlock
@@ -1924,6 +1932,10 @@ type cacheBlock struct {
 
 // NewCacheKeyIterator returns a new KeyIterator from a Cache.
 func NewCacheKeyIterator(cache *Cache, size int, interrupt chan struct{}) KeyIterator {
+	return newCacheKeyIterator(cache, size, BlockEncodingDefault, interrupt)
+}
+
+func newCacheKeyIterator(cache *Cache, size int, encoding byte, interrupt chan struct{}) KeyIterator {
 	keys := cache.Keys()
 
 	chans := make([]chan struct{}, len(keys))
@@ -1934,6 +1946,7 @@ func NewCacheKeyIterator(cache *Cache, size int, interrupt chan struct{}) KeyIte
 	cki := &cacheKeyIterator{
 		i:         -1,
 		size:      size,
+		encoding:  encoding,
 		cache:     cache,
 		order:     keys,
 		ready:     chans,
@@ -2000,7 +2013,11 @@ func (c *cacheKeyIterator) encode() {
 
 					switch values[0].(type) {
 					case FloatValue:
-						b, err = encodeFloatBlockUsing(nil, values[:end], tenc, fenc)
+						if c.encoding == BlockEncodingZstd {
+							b, err = encodeValuesBlockZstd(nil, values[:end], tenc)
+						} else {
+							b, err = encodeFloatBlockUsing(nil, values[:end], tenc, fenc)
+						}
 					case IntegerValue:
 						b, err = encodeIntegerBlockUsing(nil, values[:end], tenc, ienc)
 					case UnsignedValue:
@@ -2008,7 +2025,11 @@ func (c *cacheKeyIterator) encode() {
 					case BooleanValue:
 						b, err = encodeBooleanBlockUsing(nil, values[:end], tenc, benc)
 					case StringValue:
-						b, err = encodeStringBlockUsing(nil, values[:end], tenc, senc)
+						if c.encoding == BlockEncodingZstd {
+							b, err = encodeValuesBlockZstd(nil, values[:end], tenc)
+						} else {
+							b, err = encodeStringBlockUsing(nil, values[:end], tenc, senc)
+						}
 					default:
 						b, err = Values(values[:end]).Encode(nil)
 					}
@@ -3,6 +3,7 @@ package tsm1_test
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"sort"
@@ -89,6 +90,101 @@ func TestCompactor_Snapshot(t *testing.T) {
 	}
 }
 
+// Tests that snapshots and compactions write float and string blocks with the
+// block encoding of the compactor, and encode full blocks again when their
+// encoding differs.
+func TestCompactor_BlockEncoding(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	statuses := []string{"OL", "OL CHRG", "OB DISCHRG", "OB LB"}
+	rnd := rand.New(rand.NewSource(1))
+	var strs, floats []tsm1.Value
+	for i := 0; i < tsdb.DefaultMaxPointsPerBlock; i++ {
+		strs = append(strs, tsm1.NewValue(int64(i), statuses[i/100%len(statuses)]))
+		floats = append(floats, tsm1.NewValue(int64(i), rnd.Float64()))
+	}
+	points := map[string][]tsm1.Value{
+		"ups,host=A#!~#status": strs,
+		"ups,host=A#!~#load":   floats,
+	}
+
+	c := tsm1.NewCache(0)
+	for k, v := range points {
+		if err := c.Write([]byte(k), v); err != nil {
+			t.Fatalf("failed to write key %s to cache: %s", k, err.Error())
+		}
+	}
+
+	fs := &fakeFileStore{}
+	defer fs.Close()
+	compactor := tsm1.NewCompactor()
+	compactor.Dir = dir
+	compactor.FileStore = fs
+	compactor.BlockEncoding = tsm1.BlockEncodingZstd
+	compactor.Open()
+
+	assertFile := func(files []string, err error, encodings map[string]byte) string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("unexpected error compacting: %v", err)
+		}
+		if got, exp := len(files), 1; got != exp {
+			t.Fatalf("files length mismatch: got %v, exp %v", got, exp)
+		}
+
+		r := MustOpenTSMReader(files[0])
+		defer r.Close()
+
+		iter := r.BlockIterator()
+		for iter.Next() {
+			key, _, _, _, _, b, err := iter.Read()
+			if err != nil {
+				t.Fatalf("unexpected error reading block: %v", err)
+			}
+			if got, exp := tsm1.BlockEncoding(b), encodings[string(key)]; got != exp {
+				t.Fatalf("block encoding mismatch for %s: got %v, exp %v", key, got, exp)
+			}
+		}
+
+		for k, points := range points {
+			values, err := r.ReadAll([]byte(k))
+			if err != nil {
+				t.Fatalf("unexpected error reading: %v", err)
+			}
+			if got, exp := len(values), len(points); got != exp {
+				t.Fatalf("values length mismatch: got %v, exp %v", got, exp)
+			}
+			for i, point := range points {
+				assertValueEqual(t, values[i], point)
+			}
+		}
+		return files[0]
+	}
+
+	// zstd compresses the random floats better than gorilla.
+	files, err := compactor.WriteSnapshot(c)
+	f := assertFile(files, err, map[string]byte{
+		"ups,host=A#!~#status": tsm1.BlockEncodingZstd,
+		"ups,host=A#!~#load":   tsm1.BlockEncodingZstd,
+	})
+
+	compactor.BlockEncoding = tsm1.BlockEncodingDefault
+	files, err = compactor.CompactFull([]string{f})
+	f = assertFile(files, err, map[string]byte{
+		"ups,host=A#!~#status": tsm1.BlockEncodingDefault,
+		"ups,host=A#!~#load":   tsm1.BlockEncodingDefault,
+	})
+
+	// Full float blocks aren't encoded again to try zstd.
+	compactor.BlockEncoding = tsm1.BlockEncodingZstd
+	files, err = compactor.CompactFull([]string{f})
+	assertFile(files, err, map[string]byte{
+		"ups,host=A#!~#status": tsm1.BlockEncodingZstd,
+		"ups,host=A#!~#load":   tsm1.BlockEncodingDefault,
+	})
+}
+
 func TestCompactor_CompactFullLastTimestamp(t *testing.T) {
 	dir := MustTempDir()
 	defer os.RemoveAll(dir)
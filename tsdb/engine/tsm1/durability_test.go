@@ -0,0 +1,180 @@
+package tsm1
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEngine_Durability_CrashRecovery writes to a shard and opens it again
+// without closing it first, like influxd does after a crash.
+func TestEngine_Durability_CrashRecovery(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		durability tsdb.Durability
+		// recovered is whether the writes survive the crash.
+		recovered bool
+		// snapshotted is whether a snapshot is awaited before the crash.
+		snapshotted bool
+	}{
+		{name: "default", recovered: true},
+		{name: "fsync", durability: tsdb.Durability{Mode: tsdb.DurabilityFsync}, recovered: true},
+		{name: "group commit", durability: tsdb.Durability{Mode: tsdb.DurabilityGroupCommit, FsyncDelay: 50 * time.Millisecond}, recovered: true},
+		{name: "no wal", durability: tsdb.Durability{Mode: tsdb.DurabilityNoWAL}, recovered: false},
+		{name: "no wal snapshotted", durability: tsdb.Durability{Mode: tsdb.DurabilityNoWAL, SnapshotInterval: 100 * time.Millisecond}, recovered: true, snapshotted: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "durability_test")
+			require.NoError(t, err, "error creating temporary directory")
+			defer os.RemoveAll(dir)
+
+			sfile := NewSeriesFile(t, dir)
+			defer sfile.Close()
+
+			sh, e := openDurabilityShard(t, dir, sfile, tt.durability)
+
+			start := time.Now()
+			require.NoError(t, sh.WritePoints(durabilityPoints()))
+			if tt.durability.Mode == tsdb.DurabilityGroupCommit {
+				// The write is acknowledged once it is fsynced.
+				require.True(t, time.Since(start) >= tt.durability.FsyncDelay, "write acknowledged before the fsync delay")
+			}
+			if tt.durability.Mode == tsdb.DurabilityNoWAL {
+				require.Nil(t, e.WAL)
+				require.Empty(t, durabilityWALSegments(t, dir))
+			}
+			if tt.snapshotted {
+				require.Eventually(t, func() bool { return e.FileStore.Count() > 0 }, 10*time.Second, 10*time.Millisecond)
+			}
+
+			// Crash: stop the background snapshots without closing the shard.
+			sh.SetEnabled(false)
+
+			_, recovered := openDurabilityShard(t, dir, sfile, tt.durability)
+			if tt.recovered {
+				require.Equal(t, len(durabilityPoints()), durabilityValues(t, recovered))
+			} else {
+				require.Equal(t, 0, durabilityValues(t, recovered))
+			}
+		})
+	}
+}
+
+// TestEngine_Durability_Change reopens a shard with each durability in turn,
+// which keeps the writes made with the previous durability.
+func TestEngine_Durability_Change(t *testing.T) {
+	dir, err := ioutil.TempDir("", "durability_test")
+	require.NoError(t, err, "error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	sfile := NewSeriesFile(t, dir)
+	defer sfile.Close()
+
+	// The writes are only in the WAL when the shard is closed.
+	sh, _ := openDurabilityShard(t, dir, sfile, tsdb.Durability{Mode: tsdb.DurabilityFsync})
+	require.NoError(t, sh.WritePoints(durabilityPoints()))
+	require.NoError(t, sh.Close())
+	require.NotEmpty(t, durabilityWALSegments(t, dir))
+
+	// Disabling the WAL writes its segments to a TSM file.
+	sh, e := openDurabilityShard(t, dir, sfile, tsdb.Durability{Mode: tsdb.DurabilityNoWAL})
+	require.Empty(t, durabilityWALSegments(t, dir))
+	require.Equal(t, 1, e.FileStore.Count())
+	require.Equal(t, len(durabilityPoints()), durabilityValues(t, e))
+
+	// Closing a shard without a WAL writes its cache to a TSM file.
+	points := durabilityPoints()
+	for i := range points {
+		points[i].SetTime(points[i].Time().Add(time.Hour))
+	}
+	require.NoError(t, sh.WritePoints(points))
+	require.NoError(t, sh.Close())
+
+	sh, e = openDurabilityShard(t, dir, sfile, tsdb.Durability{})
+	defer sh.Close()
+	require.Equal(t, 2, e.FileStore.Count())
+	require.Equal(t, uint64(0), e.Cache.Size())
+}
+
+// TestEngine_Durability_CloseFailure keeps a shard without a WAL open when
+// its cache cannot be written to a TSM file on close.
+func TestEngine_Durability_CloseFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "durability_test")
+	require.NoError(t, err, "error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	sfile := NewSeriesFile(t, dir)
+	defer sfile.Close()
+
+	sh, e := openDurabilityShard(t, dir, sfile, tsdb.Durability{Mode: tsdb.DurabilityNoWAL})
+	require.NoError(t, sh.WritePoints(durabilityPoints()))
+
+	// The TSM file cannot be created without the shard directory.
+	shardDir := filepath.Join(dir, "shard")
+	require.NoError(t, os.RemoveAll(shardDir))
+	require.Error(t, sh.Close())
+	engine, err := sh.Engine()
+	require.NoError(t, err, "shard closed with its cache")
+	require.Equal(t, e, engine)
+	require.Equal(t, len(durabilityPoints()), durabilityValues(t, e))
+
+	// Closing again once the directory is back persists the cache.
+	require.NoError(t, os.MkdirAll(shardDir, 0777))
+	require.NoError(t, sh.Close())
+
+	sh, e = openDurabilityShard(t, dir, sfile, tsdb.Durability{Mode: tsdb.DurabilityNoWAL})
+	defer sh.Close()
+	require.Equal(t, 1, e.FileStore.Count())
+	require.Equal(t, len(durabilityPoints()), durabilityValues(t, e))
+}
+
+// openDurabilityShard opens the shard in dir with the durability d.
+func openDurabilityShard(t *testing.T, dir string, sfile *tsdb.SeriesFile, d tsdb.Durability) (*tsdb.Shard, *Engine) {
+	t.Helper()
+
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(dir, "wal")
+	opts.SeriesIDSets = seriesIDSets([]*tsdb.SeriesIDSet{})
+	opts.Durability = d
+
+	sh := tsdb.NewShard(1, filepath.Join(dir, "shard"), filepath.Join(dir, "wal"), sfile, opts)
+	require.NoError(t, sh.Open(), "error opening shard")
+
+	engine, err := sh.Engine()
+	require.NoError(t, err, "error retrieving shard engine")
+	return sh, engine.(*Engine)
+}
+
+func durabilityPoints() []models.Point {
+	points := make([]models.Point, 0, 10)
+	for i := 0; i < cap(points); i++ {
+		points = append(points, models.MustNewPoint(
+			"ups",
+			models.NewTags(map[string]string{"host": "server"}),
+			map[string]interface{}{"load": float64(i)},
+			time.Unix(int64(i), 0),
+		))
+	}
+	return points
+}
+
+// durabilityValues returns the number of values written by durabilityPoints
+// that are in the cache or the TSM files of e.
+func durabilityValues(t *testing.T, e *Engine) int {
+	key := SeriesFieldKeyBytes("ups,host=server", "load")
+	values, err := e.FileStore.Read(key, 0)
+	require.NoError(t, err)
+	return len(e.Cache.Values(key)) + len(values)
+}
+
+func durabilityWALSegments(t *testing.T, dir string) []string {
+	segments, err := segmentFileNames(filepath.Join(dir, "wal"))
+	require.NoError(t, err)
+	return segments
+}
@@ -479,6 +479,14 @@ func EncodeFloatArrayBlock(a *tsdb.FloatArray, b []byte) ([]byte, error) {
 	return packBlock(b, BlockFloat64, tb, vb), nil
 }
 
+// encodeFloatArrayBlockUsing encodes a into a block using the block encoding enc.
+func encodeFloatArrayBlockUsing(a *tsdb.FloatArray, enc byte, b []byte) ([]byte, error) {
+	if enc == BlockEncodingZstd {
+		return encodeFloatArrayBlockZstd(a, b)
+	}
+	return EncodeFloatArrayBlock(a, b)
+}
+
 func encodeFloatValuesBlock(buf []byte, values []FloatValue) ([]byte, error) {
 	if len(values) == 0 {
 		return nil, nil
@@ -772,6 +780,11 @@ func EncodeIntegerArrayBlock(a *tsdb.IntegerArray, b []byte) ([]byte, error) {
 	return packBlock(b, BlockInteger, tb, vb), nil
 }
 
+// encodeIntegerArrayBlockUsing encodes a into a block using the block encoding enc.
+func encodeIntegerArrayBlockUsing(a *tsdb.IntegerArray, enc byte, b []byte) ([]byte, error) {
+	return EncodeIntegerArrayBlock(a, b)
+}
+
 func encodeIntegerValuesBlock(buf []byte, values []IntegerValue) ([]byte, error) {
 	if len(values) == 0 {
 		return nil, nil
@@ -1065,6 +1078,11 @@ func EncodeUnsignedArrayBlock(a *tsdb.UnsignedArray, b []byte) ([]byte, error) {
 	return packBlock(b, BlockUnsigned, tb, vb), nil
 }
 
+// encodeUnsignedArrayBlockUsing encodes a into a block using the block encoding enc.
+func encodeUnsignedArrayBlockUsing(a *tsdb.UnsignedArray, enc byte, b []byte) ([]byte, error) {
+	return EncodeUnsignedArrayBlock(a, b)
+}
+
 func encodeUnsignedValuesBlock(buf []byte, values []UnsignedValue) ([]byte, error) {
 	if len(values) == 0 {
 		return nil, nil
@@ -1358,6 +1376,14 @@ func EncodeStringArrayBlock(a *tsdb.StringArray, b []byte) ([]byte, error) {
 	return packBlock(b, BlockString, tb, vb), nil
 }
 
+// encodeStringArrayBlockUsing encodes a into a block using the block encoding enc.
+func encodeStringArrayBlockUsing(a *tsdb.StringArray, enc byte, b []byte) ([]byte, error) {
+	if enc == BlockEncodingZstd {
+		return encodeStringArrayBlockZstd(a, b)
+	}
+	return EncodeStringArrayBlock(a, b)
+}
+
 func encodeStringValuesBlock(buf []byte, values []StringValue) ([]byte, error) {
 	if len(values) == 0 {
 		return nil, nil
@@ -1651,6 +1677,11 @@ func EncodeBooleanArrayBlock(a *tsdb.BooleanArray, b []byte) ([]byte, error) {
 	return packBlock(b, BlockBoolean, tb, vb), nil
 }
 
+// encodeBooleanArrayBlockUsing encodes a into a block using the block encoding enc.
+func encodeBooleanArrayBlockUsing(a *tsdb.BooleanArray, enc byte, b []byte) ([]byte, error) {
+	return EncodeBooleanArrayBlock(a, b)
+}
+
 func encodeBooleanValuesBlock(buf []byte, values []BooleanValue) ([]byte, error) {
 	if len(values) == 0 {
 		return nil, nil
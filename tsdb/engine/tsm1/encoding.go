@@ -27,6 +27,14 @@ const (
 	// BlockUnsigned designates a block encodes uint64 values.
 	BlockUnsigned = byte(4)
 
+	// BlockEncodingDefault designates a block whose values are encoded with the
+	// default encoding of their type.
+	BlockEncodingDefault = byte(0)
+
+	// BlockEncodingZstd designates a float or string block whose values are
+	// compressed with zstd.
+	BlockEncodingZstd = byte(1)
+
 	// encodedBlockHeaderSize is the size of the header for an encoded block.  There is one
 	// byte encoding the type of the block.
 	encodedBlockHeaderSize = 1
@@ -224,15 +232,39 @@ func (a Values) InfluxQLType() (influxql.DataType, error) {
 // BlockType returns the type of value encoded in a block or an error
 // if the block type is unknown.
 func BlockType(block []byte) (byte, error) {
-	blockType := block[0]
+	blockType, encoding := blockTypeOf(block[0]), blockEncodingOf(block[0])
 	switch blockType {
 	case BlockFloat64, BlockInteger, BlockUnsigned, BlockBoolean, BlockString:
-		return blockType, nil
 	default:
-		return 0, fmt.Errorf("unknown block type: %d", blockType)
+		return 0, fmt.Errorf("unknown block type: %d", block[0])
 	}
+
+	switch {
+	case encoding == BlockEncodingDefault:
+	case encoding == BlockEncodingZstd && (blockType == BlockFloat64 || blockType == BlockString):
+	default:
+		return 0, fmt.Errorf("unknown block encoding %d for block type %d", encoding, blockType)
+	}
+	return blockType, nil
 }
 
+// BlockEncoding returns the encoding of the values of a block.
+func BlockEncoding(block []byte) byte {
+	return blockEncodingOf(block[0])
+}
+
+// blockTypeOf returns the type of the values of a block from its first byte,
+// which holds the type in its lower 4 bits and the encoding of the values in
+// its upper 4 bits. Versions unaware of block encodings reject blocks with an
+// encoding other than the default as blocks of an unknown type.
+func blockTypeOf(b byte) byte { return b & 0x0f }
+
+// blockEncodingOf returns the encoding of the values of a block from its first byte.
+func blockEncodingOf(b byte) byte { return b >> 4 }
+
+// packBlockType returns the first byte of a block of type typ encoded with enc.
+func packBlockType(typ, enc byte) byte { return typ | enc<<4 }
+
 // BlockCount returns the number of timestamps encoded in block.
 func BlockCount(block []byte) (int, error) {
 	if len(block) <= encodedBlockHeaderSize {
@@ -401,7 +433,7 @@ func encodeFloatBlockUsing(buf []byte, values []Value, tsenc TimeEncoder, venc *
 func DecodeFloatBlock(block []byte, a *[]FloatValue) ([]FloatValue, error) {
 	// Block type is the next block, make sure we actually have a float block
 	blockType := block[0]
-	if blockType != BlockFloat64 {
+	if blockTypeOf(blockType) != BlockFloat64 {
 		return nil, fmt.Errorf("invalid block type: exp %d, got %d", BlockFloat64, blockType)
 	}
 	block = block[1:]
@@ -411,6 +443,10 @@ func DecodeFloatBlock(block []byte, a *[]FloatValue) ([]FloatValue, error) {
 		return nil, err
 	}
 
+	if blockEncodingOf(blockType) == BlockEncodingZstd {
+		return decodeFloatBlockZstd(tb, vb, a)
+	}
+
 	sz := CountTimestamps(tb)
 
 	if cap(*a) < sz {
@@ -895,7 +931,7 @@ func encodeStringBlockUsing(buf []byte, values []Value, tenc TimeEncoder, venc S
 // and appends the string values to a.
 func DecodeStringBlock(block []byte, a *[]StringValue) ([]StringValue, error) {
 	blockType := block[0]
-	if blockType != BlockString {
+	if blockTypeOf(blockType) != BlockString {
 		return nil, fmt.Errorf("invalid block type: exp %d, got %d", BlockString, blockType)
 	}
 
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -178,10 +179,19 @@ type Engine struct {
 	// a snapshot of the cache to a TSM file
 	CacheFlushWriteColdDuration time.Duration
 
+	// CacheFlushInterval specifies the longest time the cache holds writes
+	// before the engine writes a snapshot of the cache to a TSM file. Zero
+	// only writes snapshots when the other thresholds are exceeded.
+	CacheFlushInterval time.Duration
+
 	// WALEnabled determines whether writes to the WAL are enabled.  If this is false,
 	// writes will only exist in the cache and can be lost if a snapshot has not occurred.
 	WALEnabled bool
 
+	// durability is the durability of the shard, which may disable the WAL.
+	durability tsdb.Durability
+	walPath    string
+
 	// Invoked when creating a backup file "as new".
 	formatFileName FormatFileNameFunc
 
@@ -191,10 +201,15 @@ type Engine struct {
 	stats *EngineStatistics
 
 	// Limiter for concurrent compactions.
-	compactionLimiter limiter.Fixed
+	compactionLimiter *limiter.Adjustable
 
 	scheduler *scheduler
 
+	// compactionsMu guards the compactions that are planned and in progress.
+	compactionsMu     sync.Mutex
+	compactionQueue   [4][]CompactionGroup
+	activeCompactions map[*compactionStrategy]struct{}
+
 	// provides access to the total set of series IDs
 	seriesIDSets tsdb.SeriesIDSets
 
@@ -207,10 +222,21 @@ type Engine struct {
 
 // NewEngine returns a new instance of Engine.
 func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *tsdb.SeriesFile, opt tsdb.EngineOptions) tsdb.Engine {
+	walEnabled := opt.WALEnabled
+	syncDelay := time.Duration(opt.Config.WALFsyncDelay)
+	switch opt.Durability.Mode {
+	case tsdb.DurabilityFsync:
+		syncDelay = 0
+	case tsdb.DurabilityGroupCommit:
+		syncDelay = opt.Durability.FsyncDelay
+	case tsdb.DurabilityNoWAL:
+		walEnabled = false
+	}
+
 	var wal *WAL
-	if opt.WALEnabled {
+	if walEnabled {
 		wal = NewWAL(walPath)
-		wal.syncDelay = time.Duration(opt.Config.WALFsyncDelay)
+		wal.syncDelay = syncDelay
 	}
 
 	fs := NewFileStore(path)
@@ -226,6 +252,9 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 	c.Dir = path
 	c.FileStore = fs
 	c.RateLimit = opt.CompactionThroughputLimiter
+	if opt.Config.TSMBlockCompression == tsdb.TSMBlockCompressionZstd {
+		c.BlockEncoding = BlockEncodingZstd
+	}
 
 	var planner CompactionPlanner = NewDefaultPlanner(fs, time.Duration(opt.Config.CompactFullWriteColdDuration))
 	if opt.CompactionPlannerCreator != nil {
@@ -252,12 +281,16 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 
 		CacheFlushMemorySizeThreshold: uint64(opt.Config.CacheSnapshotMemorySize),
 		CacheFlushWriteColdDuration:   time.Duration(opt.Config.CacheSnapshotWriteColdDuration),
+		CacheFlushInterval:            opt.Durability.SnapshotInterval,
 		enableCompactionsOnOpen:       true,
-		WALEnabled:                    opt.WALEnabled,
+		WALEnabled:                    walEnabled,
+		durability:                    opt.Durability,
+		walPath:                       walPath,
 		formatFileName:                DefaultFormatFileName,
 		stats:                         stats,
 		compactionLimiter:             opt.CompactionLimiter,
 		scheduler:                     newScheduler(stats, opt.CompactionLimiter.Capacity()),
+		activeCompactions:             make(map[*compactionStrategy]struct{}),
 		seriesIDSets:                  opt.SeriesIDSets,
 	}
 
@@ -715,6 +748,80 @@ func (e *Engine) DiskSize() int64 {
 	return e.FileStore.DiskSizeBytes() + walDiskSizeBytes
 }
 
+// FileStats returns a description of the TSM files of the engine.
+func (e *Engine) FileStats() []tsdb.FileStat {
+	stats := e.FileStore.Stats()
+	files := make([]tsdb.FileStat, 0, len(stats))
+	for _, s := range stats {
+		// Files named incorrectly are reported at level 0.
+		_, level, _ := e.FileStore.ParseFileName(s.Path)
+		files = append(files, tsdb.FileStat{
+			Path:         s.Path,
+			Size:         int64(s.Size),
+			Level:        level,
+			MinTime:      s.MinTime,
+			MaxTime:      s.MaxTime,
+			HasTombstone: s.HasTombstone,
+		})
+	}
+	return files
+}
+
+// MemoryUsage estimates the memory used by the engine, in bytes.
+func (e *Engine) MemoryUsage() tsdb.MemoryUsage {
+	u := tsdb.MemoryUsage{
+		Index:    int64(e.index.Bytes()),
+		Cache:    int64(e.Cache.Size()),
+		TSMIndex: e.FileStore.IndexSize(),
+	}
+	for _, n := range e.index.SeriesIDSetCacheBytes() {
+		u.SeriesIDSetCache += int64(n)
+	}
+	return u
+}
+
+// MeasurementsMemoryUsage estimates the memory used by each measurement of
+// the engine, in bytes. The TSM index of a measurement is the size of its
+// keys and index entries.
+func (e *Engine) MeasurementsMemoryUsage() map[string]tsdb.MemoryUsage {
+	usage := make(map[string]tsdb.MemoryUsage)
+	add := func(name string, u tsdb.MemoryUsage) {
+		sum := usage[name]
+		sum.Add(u)
+		usage[name] = sum
+	}
+
+	for name, n := range e.index.MeasurementsBytes() {
+		add(name, tsdb.MemoryUsage{Index: int64(n)})
+	}
+	for name, n := range e.index.SeriesIDSetCacheBytes() {
+		add(name, tsdb.MemoryUsage{SeriesIDSetCache: int64(n)})
+	}
+	for name, n := range e.Cache.MeasurementSizes() {
+		add(name, tsdb.MemoryUsage{Cache: n})
+	}
+	for name, n := range e.FileStore.MeasurementIndexSizes() {
+		add(name, tsdb.MemoryUsage{TSMIndex: n})
+	}
+	return usage
+}
+
+// FreeMemory writes the cache to a TSM file, empties the caches of the index
+// and compacts its log files, and releases the pages of the mmapped TSM files.
+func (e *Engine) FreeMemory() error {
+	if err := e.index.FreeMemory(); err != nil {
+		return err
+	}
+
+	// The cache is written by the snapshot already in progress, if any.
+	if e.Cache.Size() > 0 {
+		if err := e.WriteSnapshot(); err != nil && err != ErrSnapshotInProgress && err != errSnapshotsDisabled {
+			return err
+		}
+	}
+	return e.FileStore.Free()
+}
+
 // Open opens and initializes the engine.
 // TODO(edd): plumb context
 func (e *Engine) Open() error {
@@ -747,14 +854,32 @@ func (e *Engine) Open() error {
 		return err
 	}
 
+	var walSegments []string
 	if e.WALEnabled {
 		if err := e.reloadCache(); err != nil {
 			return err
 		}
+	} else if e.durability.Mode == tsdb.DurabilityNoWAL {
+		if walSegments, err = e.reloadDisabledWAL(); err != nil {
+			return err
+		}
 	}
 
 	e.Compactor.Open()
 
+	// The segments of a WAL that was disabled are only removed once their
+	// writes are in a TSM file.
+	if len(walSegments) > 0 {
+		if err := e.WriteSnapshot(); err != nil {
+			return err
+		}
+		for _, fn := range walSegments {
+			if err := os.Remove(fn); err != nil {
+				return err
+			}
+		}
+	}
+
 	if e.enableCompactionsOnOpen {
 		e.SetCompactionsEnabled(true)
 	}
@@ -762,8 +887,19 @@ func (e *Engine) Open() error {
 	return nil
 }
 
-// Close closes the engine. Subsequent calls to Close are a nop.
+// Close closes the engine. Subsequent calls to Close are a nop. Without a
+// WAL, the engine is left open if its cache cannot be written to a TSM file,
+// and Close can be called again.
 func (e *Engine) Close() error {
+	// Without a WAL, the cache is lost unless it is written to a TSM file,
+	// including when snapshots are disabled.
+	if e.durability.Mode == tsdb.DurabilityNoWAL && e.Cache.Size() > 0 {
+		e.Compactor.EnableSnapshots()
+		if err := e.WriteSnapshot(); err != nil {
+			return fmt.Errorf("cannot write the cache of engine without a WAL: %w", err)
+		}
+	}
+
 	e.SetCompactionsEnabled(false)
 
 	// Lock now and close everything else down.
@@ -925,6 +1061,7 @@ func (e *Engine) Backup(w io.Writer, basePath string, since time.Time) error {
 
 func (e *Engine) timeStampFilterTarFile(start, end time.Time) func(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
 	return func(fi os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
+		// Tombstone files are streamed as is, next to the TSM files they apply to.
 		if !strings.HasSuffix(fi.Name(), ".tsm") {
 			return intar.StreamFile(fi, shardRelativePath, fullPath, tw)
 		}
@@ -938,11 +1075,6 @@ func (e *Engine) timeStampFilterTarFile(start, end time.Time) func(f os.FileInfo
 			return err
 		}
 
-		// Grab the tombstone file if one exists.
-		if ts := r.TombstoneStats(); ts.TombstoneExists {
-			return intar.StreamFile(fi, shardRelativePath, filepath.Base(ts.Path), tw)
-		}
-
 		min, max := r.TimeRange()
 		stun := start.UnixNano()
 		eun := end.UnixNano()
@@ -1974,6 +2106,10 @@ func (e *Engine) ShouldCompactCache(t time.Time) bool {
 		return true
 	}
 
+	if e.CacheFlushInterval > 0 && t.Sub(e.Cache.LastSnapshotTime()) > e.CacheFlushInterval {
+		return true
+	}
+
 	return t.Sub(e.Cache.LastWriteTime()) > e.CacheFlushWriteColdDuration
 }
 
@@ -1988,6 +2124,7 @@ func (e *Engine) compact(wg *sync.WaitGroup) {
 
 		select {
 		case <-quit:
+			e.setCompactionQueue(nil, nil, nil, nil)
 			return
 
 		case <-t.C:
@@ -2016,6 +2153,9 @@ func (e *Engine) compact(wg *sync.WaitGroup) {
 			e.scheduler.setDepth(3, len(level3Groups))
 			e.scheduler.setDepth(4, len(level4Groups))
 
+			// The concurrency limit can change while the engine is open.
+			e.scheduler.setMaxConcurrency(e.compactionLimiter.Capacity())
+
 			// Find the next compaction that can run and try to kick it off
 			if level, runnable := e.scheduler.next(); runnable {
 				switch level {
@@ -2038,6 +2178,8 @@ func (e *Engine) compact(wg *sync.WaitGroup) {
 				}
 			}
 
+			e.setCompactionQueue(level1Groups, level2Groups, level3Groups, level4Groups)
+
 			// Release all the plans we didn't start.
 			e.CompactionPlan.Release(level1Groups)
 			e.CompactionPlan.Release(level2Groups)
@@ -2143,11 +2285,20 @@ type compactionStrategy struct {
 	fileStore *FileStore
 
 	engine *Engine
+
+	// startTime and size are set when the compaction starts. written is the
+	// size of the blocks written so far, and is accessed atomically.
+	startTime time.Time
+	size      int64
+	written   int64
 }
 
 // Apply concurrently compacts all the groups in a compaction strategy.
 func (s *compactionStrategy) Apply() {
 	start := time.Now()
+	s.engine.addActiveCompaction(s, start)
+	defer s.engine.removeActiveCompaction(s)
+
 	s.compactGroup()
 	atomic.AddInt64(s.durationStat, time.Since(start).Nanoseconds())
 }
@@ -2168,11 +2319,7 @@ func (s *compactionStrategy) compactGroup() {
 		files []string
 	)
 
-	if s.fast {
-		files, err = s.compactor.CompactFast(group)
-	} else {
-		files, err = s.compactor.CompactFull(group)
-	}
+	files, err = s.compactor.compactFiles(s.fast, group, &s.written)
 
 	if err != nil {
 		_, inProgress := err.(errCompactionInProgress)
@@ -2225,6 +2372,82 @@ func (s *compactionStrategy) compactGroup() {
 	atomic.AddInt64(s.successStat, 1)
 }
 
+// setCompactionQueue records the groups of each level that are planned to be
+// compacted.
+func (e *Engine) setCompactionQueue(level1, level2, level3, level4 []CompactionGroup) {
+	e.compactionsMu.Lock()
+	defer e.compactionsMu.Unlock()
+	e.compactionQueue = [4][]CompactionGroup{level1, level2, level3, level4}
+}
+
+func (e *Engine) addActiveCompaction(s *compactionStrategy, start time.Time) {
+	s.startTime = start
+	s.size = compactionGroupSize(s.group, e.tsmFileSizes())
+
+	e.compactionsMu.Lock()
+	defer e.compactionsMu.Unlock()
+	e.activeCompactions[s] = struct{}{}
+}
+
+func (e *Engine) removeActiveCompaction(s *compactionStrategy) {
+	e.compactionsMu.Lock()
+	defer e.compactionsMu.Unlock()
+	delete(e.activeCompactions, s)
+}
+
+// tsmFileSizes returns the sizes of the TSM files of the engine by path.
+func (e *Engine) tsmFileSizes() map[string]int64 {
+	stats := e.FileStore.Stats()
+	sizes := make(map[string]int64, len(stats))
+	for _, f := range stats {
+		sizes[f.Path] = int64(f.Size)
+	}
+	return sizes
+}
+
+// compactionGroupSize returns the total size of the files of group.
+func compactionGroupSize(group CompactionGroup, sizes map[string]int64) int64 {
+	var size int64
+	for _, f := range group {
+		size += sizes[f]
+	}
+	return size
+}
+
+// CompactionStats returns the compactions that are planned, as of the last
+// run of the compaction planner, and the compactions in progress ordered by
+// their start time.
+func (e *Engine) CompactionStats() (queued, active []tsdb.CompactionStat) {
+	sizes := e.tsmFileSizes()
+
+	e.compactionsMu.Lock()
+	defer e.compactionsMu.Unlock()
+
+	for i, groups := range e.compactionQueue {
+		for _, group := range groups {
+			queued = append(queued, tsdb.CompactionStat{
+				Level: i + 1,
+				Files: append([]string(nil), group...),
+				Size:  compactionGroupSize(group, sizes),
+			})
+		}
+	}
+
+	for s := range e.activeCompactions {
+		active = append(active, tsdb.CompactionStat{
+			Level:        s.level,
+			Files:        append([]string(nil), s.group...),
+			Size:         s.size,
+			StartTime:    s.startTime,
+			BytesWritten: atomic.LoadInt64(&s.written),
+		})
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].StartTime.Before(active[j].StartTime)
+	})
+	return queued, active
+}
+
 // levelCompactionStrategy returns a compactionStrategy for the given level.
 // It returns nil if there are no TSM files to compact.
 func (e *Engine) levelCompactionStrategy(group CompactionGroup, fast bool, level int) *compactionStrategy {
@@ -2299,6 +2522,31 @@ func (e *Engine) reloadCache() error {
 	return nil
 }
 
+// reloadDisabledWAL loads the segment files left by the WAL of the engine
+// before it was disabled into the cache, and returns their names.
+func (e *Engine) reloadDisabledWAL() ([]string, error) {
+	files, err := segmentFileNames(e.walPath)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	limit := e.Cache.MaxSize()
+	defer func() {
+		e.Cache.SetMaxSize(limit)
+	}()
+
+	// Disable the max size during loading
+	e.Cache.SetMaxSize(0)
+
+	loader := NewCacheLoader(files)
+	loader.WithLogger(e.logger)
+	if err := loader.Load(e.Cache); err != nil {
+		return nil, err
+	}
+	e.logger.Info("Reloaded segments of disabled WAL", zap.String("path", e.walPath), zap.Int("segments", len(files)))
+	return files, nil
+}
+
 // cleanup removes all temp files and dirs that exist on disk.  This is should only be run at startup to avoid
 // removing tmp files that are still in use.
 func (e *Engine) cleanup() error {
@@ -102,3 +102,68 @@ func (a seriesIDSets) ForEach(f func(ids *tsdb.SeriesIDSet)) error {
 	}
 	return nil
 }
+
+func TestEngine_CompactionStats(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "shard_test")
+	require.NoError(t, err, "error creating temporary directory")
+	defer os.RemoveAll(tmpDir)
+
+	sfile := NewSeriesFile(t, tmpDir)
+	defer sfile.Close()
+
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+	opts.SeriesIDSets = seriesIDSets([]*tsdb.SeriesIDSet{})
+
+	sh := tsdb.NewShard(1, filepath.Join(tmpDir, "shard"), filepath.Join(tmpDir, "wal"), sfile, opts)
+	require.NoError(t, sh.Open(), "error opening shard")
+	defer sh.Close()
+
+	// Write 4 level 1 files.
+	for i := 0; i < 4; i++ {
+		points := make([]models.Point, 0, 1000)
+		for j := 0; j < cap(points); j++ {
+			points = append(points, models.MustNewPoint(
+				"cpu",
+				models.NewTags(map[string]string{"host": "server"}),
+				map[string]interface{}{"value": float64(j)},
+				time.Unix(int64(i*cap(points)+j), 0),
+			))
+		}
+		require.NoError(t, sh.WritePoints(points))
+		require.NoError(t, sh.WriteSnapshot())
+	}
+
+	engine, err := sh.Engine()
+	require.NoError(t, err, "error retrieving shard engine")
+	e := engine.(*Engine)
+
+	var (
+		group CompactionGroup
+		size  int64
+	)
+	for _, f := range e.FileStore.Stats() {
+		group = append(group, f.Path)
+		size += int64(f.Size)
+	}
+	require.Len(t, group, 4)
+
+	// The compaction planner doesn't run until the shard is enabled.
+	e.setCompactionQueue([]CompactionGroup{group}, nil, nil, nil)
+	queued, active := e.CompactionStats()
+	require.Equal(t, []tsdb.CompactionStat{{Level: 1, Files: group, Size: size}}, queued)
+	require.Empty(t, active)
+
+	s := e.levelCompactionStrategy(group, false, 1)
+	start := time.Now()
+	e.addActiveCompaction(s, start)
+	_, active = e.CompactionStats()
+	require.Equal(t, []tsdb.CompactionStat{{Level: 1, Files: group, Size: size, StartTime: start}}, active)
+	e.removeActiveCompaction(s)
+
+	s.Apply()
+	require.Greater(t, s.written, int64(0), "expected compaction progress")
+	_, active = e.CompactionStats()
+	require.Empty(t, active)
+	require.Len(t, e.FileStore.Files(), 1)
+}
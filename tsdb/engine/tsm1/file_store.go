@@ -120,6 +120,9 @@ type TSMFile interface {
 	// Size returns the size of the file on disk in bytes.
 	Size() uint32
 
+	// IndexSize returns the size of the index of the file in bytes.
+	IndexSize() uint32
+
 	// Rename renames the existing TSM file to a new name and replaces the mmap backing slice using the new
 	// file name. Index and Reader state are not re-initialized.
 	Rename(path string) error
@@ -326,6 +329,40 @@ func (f *FileStore) Free() error {
 	return nil
 }
 
+// IndexSize returns the total size of the indexes of the TSM files, which are
+// mmapped, in bytes.
+func (f *FileStore) IndexSize() int64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var n int64
+	for _, f := range f.files {
+		n += int64(f.IndexSize())
+	}
+	return n
+}
+
+// MeasurementIndexSizes returns the size of the index entries of each
+// measurement in the TSM files, in bytes. Every key of every file is read, so
+// it is slow for large shards.
+func (f *FileStore) MeasurementIndexSizes() map[string]int64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	sizes := make(map[string]int64)
+	var entries []IndexEntry
+	for _, r := range f.files {
+		for i := 0; i < r.KeyCount(); i++ {
+			key, _ := r.KeyAt(i)
+			entries = r.ReadEntries(key, &entries)
+			seriesKey, _ := SeriesAndFieldFromCompositeKey(key)
+			name := models.ParseName(seriesKey)
+			sizes[string(name)] += int64(2 + len(key) + indexTypeSize + indexCountSize + len(entries)*indexEntrySize)
+		}
+	}
+	return sizes
+}
+
 // CurrentGeneration returns the current generation of the TSM files.
 func (f *FileStore) CurrentGeneration() int {
 	f.mu.RLock()
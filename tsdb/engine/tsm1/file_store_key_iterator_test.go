@@ -171,6 +171,7 @@ func (*mockTSMFile) HasTombstones() bool                             { panic("im
 func (*mockTSMFile) TombstoneStats() TombstoneStat                   { panic("implement me") }
 func (*mockTSMFile) Close() error                                    { panic("implement me") }
 func (*mockTSMFile) Size() uint32                                    { panic("implement me") }
+func (*mockTSMFile) IndexSize() uint32                               { panic("implement me") }
 func (*mockTSMFile) Rename(path string) error                        { panic("implement me") }
 func (*mockTSMFile) Remove() error                                   { panic("implement me") }
 func (*mockTSMFile) InUse() bool                                     { panic("implement me") }
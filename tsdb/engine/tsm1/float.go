@@ -19,8 +19,14 @@ import (
 )
 
 // Note: an uncompressed format is not yet implemented.
-// floatCompressedGorilla is a compressed format using the gorilla paper encoding
-const floatCompressedGorilla = 1
+const (
+	// floatCompressedGorilla is a compressed format using the gorilla paper encoding
+	floatCompressedGorilla = 1
+
+	// floatCompressedZstd is a compressed format using zstd compression of the
+	// byte-transposed values, used for blocks the gorilla encoding compresses poorly.
+	floatCompressedZstd = 2
+)
 
 // uvnan is the constant returned from math.NaN().
 const uvnan = 0x7FF8000000000001
@@ -161,7 +167,10 @@ func (it *FloatDecoder) SetBytes(b []byte) error {
 		v = uvnan
 	} else {
 		// first byte is the compression type.
-		// we currently just have gorilla compression.
+		// zstd compressed values are only decoded by FloatArrayDecodeAll.
+		if b[0]>>4 == floatCompressedZstd {
+			return fmt.Errorf("FloatDecoder: unsupported compression type %d", b[0]>>4)
+		}
 		it.br.Reset(b[1:])
 
 		var err error
@@ -0,0 +1,49 @@
+package tsm1
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEngine_FreeMemory writes to a shard and frees its memory, which moves
+// the writes from the cache to a TSM file.
+func TestEngine_FreeMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "memory_test")
+	require.NoError(t, err, "error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	sfile := NewSeriesFile(t, dir)
+	defer sfile.Close()
+
+	sh, e := openDurabilityShard(t, dir, sfile, tsdb.Durability{})
+	defer sh.Close()
+	require.NoError(t, sh.WritePoints(durabilityPoints()))
+
+	usage := e.MemoryUsage()
+	require.True(t, usage.Cache > 0, "cache memory not reported")
+	require.True(t, usage.Index > 0, "index memory not reported")
+	require.Equal(t, int64(0), usage.TSMIndex)
+
+	byMeasurement := e.MeasurementsMemoryUsage()
+	require.Len(t, byMeasurement, 1)
+	require.Equal(t, usage.Cache, byMeasurement["ups"].Cache)
+
+	require.NoError(t, e.FreeMemory())
+	require.Equal(t, 1, e.FileStore.Count())
+	require.Equal(t, len(durabilityPoints()), durabilityValues(t, e))
+
+	freed := e.MemoryUsage()
+	require.Equal(t, int64(0), freed.Cache)
+	require.True(t, freed.TSMIndex > 0, "TSM index memory not reported")
+	require.Equal(t, freed.TSMIndex, e.MeasurementsMemoryUsage()["ups"].TSMIndex)
+
+	// The log files of the index are compacted in the background.
+	require.Eventually(t, func() bool {
+		return e.MeasurementsMemoryUsage()["ups"].Index == 0
+	}, 10*time.Second, 10*time.Millisecond)
+}
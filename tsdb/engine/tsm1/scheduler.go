@@ -32,6 +32,10 @@ func (s *scheduler) setDepth(level, depth int) {
 	s.queues[level] = depth
 }
 
+func (s *scheduler) setMaxConcurrency(maxConcurrency int) {
+	s.maxConcurrency = maxConcurrency
+}
+
 func (s *scheduler) next() (int, bool) {
 	level1Running := int(atomic.LoadInt64(&s.stats.TSMCompactionsActive[0]))
 	level2Running := int(atomic.LoadInt64(&s.stats.TSMCompactionsActive[1]))
@@ -14,8 +14,13 @@ import (
 
 // Note: an uncompressed format is not yet implemented.
 
-// stringCompressedSnappy is a compressed encoding using Snappy compression
-const stringCompressedSnappy = 1
+const (
+	// stringCompressedSnappy is a compressed encoding using Snappy compression
+	stringCompressedSnappy = 1
+
+	// stringCompressedZstd is a compressed encoding using zstd compression
+	stringCompressedZstd = 2
+)
 
 // StringEncoder encodes multiple strings into a byte slice.
 type StringEncoder struct {
@@ -68,12 +73,11 @@ type StringDecoder struct {
 // SetBytes initializes the decoder with bytes to read from.
 // This must be called before calling any other method.
 func (e *StringDecoder) SetBytes(b []byte) error {
-	// First byte stores the encoding type, only have snappy format
-	// currently so ignore for now.
+	// First byte stores the encoding type.
 	var data []byte
 	if len(b) > 0 {
 		var err error
-		data, err = snappy.Decode(nil, b[1:])
+		data, err = decompressStrings(b)
 		if err != nil {
 			return fmt.Errorf("failed to decode string block: %v", err.Error())
 		}
@@ -87,6 +91,16 @@ func (e *StringDecoder) SetBytes(b []byte) error {
 	return nil
 }
 
+// decompressStrings returns the length prefixed strings of the encoded bytes
+// b. The returned slice is always newly allocated.
+func decompressStrings(b []byte) ([]byte, error) {
+	if b[0]>>4 == stringCompressedZstd {
+		return zstdDecompress(b[1:])
+	}
+	// Blocks written before zstd was supported always use snappy.
+	return snappy.Decode(nil, b[1:])
+}
+
 // Next returns true if there are any values remaining to be decoded.
 func (e *StringDecoder) Next() bool {
 	if e.err != nil {
@@ -0,0 +1,215 @@
+package tsm1
+
+// Zstd encoding compresses the values of string and float blocks with zstd.
+// String values are the same length prefixed strings the snappy encoding
+// compresses. Float values are stored big endian and transposed by byte, so
+// that the bytes of the same significance of all values are compressed
+// together. Float blocks only use zstd when it compresses the values better
+// than the gorilla encoding.
+//
+// Blocks using zstd record it in their block type byte, see BlockEncodingZstd,
+// and in the 1 byte header of their values.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	// Blocks are covered by the checksum of the TSM file, so the frames
+	// don't need their own.
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderCRC(false))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+)
+
+// zstdDecompress returns the decompressed bytes of b in a newly allocated slice.
+func zstdDecompress(b []byte) ([]byte, error) {
+	return zstdDecoder.DecodeAll(b, nil)
+}
+
+// stringArrayEncodeAllZstd encodes src into b with zstd, returning b and any
+// error encountered.
+func stringArrayEncodeAllZstd(src []string, b []byte) ([]byte, error) {
+	sz := 0
+	for i := range src {
+		sz += binary.MaxVarintLen32 + len(src[i])
+	}
+
+	data := make([]byte, sz)
+	n := 0
+	for i := range src {
+		n += binary.PutUvarint(data[n:], uint64(len(src[i])))
+		n += copy(data[n:], src[i])
+	}
+
+	b = append(b[:0], stringCompressedZstd<<4)
+	return zstdEncoder.EncodeAll(data[:n], b), nil
+}
+
+// floatArrayEncodeAllZstd encodes src into b with zstd and returns b.
+func floatArrayEncodeAllZstd(src []float64, b []byte) []byte {
+	n := len(src)
+	data := make([]byte, 8*n)
+	for i, v := range src {
+		u := math.Float64bits(v)
+		for j := 0; j < 8; j++ {
+			data[j*n+i] = byte(u >> (56 - 8*uint(j)))
+		}
+	}
+
+	b = append(b[:0], floatCompressedZstd<<4)
+	return zstdEncoder.EncodeAll(data, b)
+}
+
+// floatArrayDecodeAllZstd decodes the zstd compressed values of b, without
+// their header, into buf.
+func floatArrayDecodeAllZstd(b []byte, buf []float64) ([]float64, error) {
+	data, err := zstdDecompress(b)
+	if err != nil {
+		return []float64{}, fmt.Errorf("failed to decode float block: %v", err)
+	}
+	if len(data)%8 != 0 {
+		return []float64{}, fmt.Errorf("FloatArrayDecodeAll: invalid zstd data length %d", len(data))
+	}
+
+	n := len(data) / 8
+	if cap(buf) < n {
+		buf = make([]float64, n)
+	} else {
+		buf = buf[:n]
+	}
+	for i := range buf {
+		var u uint64
+		for j := 0; j < 8; j++ {
+			u = u<<8 | uint64(data[j*n+i])
+		}
+		buf[i] = math.Float64frombits(u)
+	}
+	return buf, nil
+}
+
+// encodeFloatArrayBlockZstd encodes a into a float block, compressing its
+// values with zstd if that is smaller than the gorilla encoding.
+func encodeFloatArrayBlockZstd(a *tsdb.FloatArray, b []byte) ([]byte, error) {
+	if a.Len() == 0 {
+		return nil, nil
+	}
+
+	tb, err := TimeArrayEncodeAll(a.Timestamps, nil)
+	if err != nil {
+		return nil, err
+	}
+	return packFloatBlockZstd(b, tb, a.Values)
+}
+
+// encodeStringArrayBlockZstd encodes a into a string block, compressing its
+// values with zstd.
+func encodeStringArrayBlockZstd(a *tsdb.StringArray, b []byte) ([]byte, error) {
+	if a.Len() == 0 {
+		return nil, nil
+	}
+
+	tb, err := TimeArrayEncodeAll(a.Timestamps, nil)
+	if err != nil {
+		return nil, err
+	}
+	vb, err := stringArrayEncodeAllZstd(a.Values, nil)
+	if err != nil {
+		return nil, err
+	}
+	return packBlock(b, packBlockType(BlockString, BlockEncodingZstd), tb, vb), nil
+}
+
+// encodeValuesBlockZstd encodes float or string values into a block using
+// the zstd block encoding. The timestamps are encoded with tenc.
+func encodeValuesBlockZstd(buf []byte, values []Value, tenc TimeEncoder) ([]byte, error) {
+	tenc.Reset()
+	for _, v := range values {
+		tenc.Write(v.UnixNano())
+	}
+	tb, err := tenc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	switch values[0].(type) {
+	case FloatValue:
+		a := make([]float64, len(values))
+		for i, v := range values {
+			a[i] = v.(FloatValue).value
+		}
+		return packFloatBlockZstd(buf, tb, a)
+	case StringValue:
+		a := make([]string, len(values))
+		for i, v := range values {
+			a[i] = v.(StringValue).value
+		}
+		vb, err := stringArrayEncodeAllZstd(a, nil)
+		if err != nil {
+			return nil, err
+		}
+		return packBlock(buf, packBlockType(BlockString, BlockEncodingZstd), tb, vb), nil
+	}
+	return Values(values).Encode(buf)
+}
+
+// packFloatBlockZstd packs the encoded timestamps tb and values into a block.
+// The gorilla encoding is kept unless zstd compresses the values better,
+// which also keeps the block readable by versions without zstd support.
+func packFloatBlockZstd(buf, tb []byte, values []float64) ([]byte, error) {
+	vb, err := FloatArrayEncodeAll(values, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	typ := BlockFloat64
+	if zb := floatArrayEncodeAllZstd(values, nil); len(zb) < len(vb) {
+		vb, typ = zb, packBlockType(BlockFloat64, BlockEncodingZstd)
+	}
+	return packBlock(buf, typ, tb, vb), nil
+}
+
+// decodeFloatBlockZstd decodes the timestamps tb and zstd compressed values vb
+// of a float block into a.
+func decodeFloatBlockZstd(tb, vb []byte, a *[]FloatValue) ([]FloatValue, error) {
+	ts, err := TimeArrayDecodeAll(tb, nil)
+	if err != nil {
+		return nil, err
+	}
+	vs, err := FloatArrayDecodeAll(vb, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(ts) != len(vs) {
+		return nil, fmt.Errorf("DecodeFloatBlock: %d timestamps for %d values", len(ts), len(vs))
+	}
+
+	if cap(*a) < len(ts) {
+		*a = make([]FloatValue, len(ts))
+	} else {
+		*a = (*a)[:len(ts)]
+	}
+	for i := range ts {
+		(*a)[i] = FloatValue{unixnano: ts[i], value: vs[i]}
+	}
+	return *a, nil
+}
+
+// recodeBlock returns true if block must be decoded by a compaction to be
+// encoded with the block encoding enc. Float blocks that kept the default
+// encoding with zstd enabled aren't decoded again, since zstd didn't
+// compress them better.
+func recodeBlock(block []byte, enc byte) bool {
+	switch blockEncodingOf(block[0]) {
+	case enc:
+		return false
+	case BlockEncodingDefault:
+		return enc == BlockEncodingZstd && blockTypeOf(block[0]) == BlockString
+	default:
+		return true
+	}
+}
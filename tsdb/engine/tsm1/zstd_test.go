@@ -0,0 +1,276 @@
+package tsm1
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+func TestZstd_StringBlock(t *testing.T) {
+	statuses := []string{"OL", "OL CHRG", "", "OB DISCHRG", "OB LB ☃"}
+	a := tsdb.NewStringArrayLen(tsdb.DefaultMaxPointsPerBlock)
+	for i := range a.Values {
+		a.Timestamps[i] = int64(i) * 1e9
+		a.Values[i] = statuses[i/10%len(statuses)]
+	}
+	// Encoding the timestamps modifies them.
+	exp := append([]int64(nil), a.Timestamps...)
+
+	block, err := encodeStringArrayBlockUsing(a, BlockEncodingZstd, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if typ, err := BlockType(block); err != nil || typ != BlockString {
+		t.Fatalf("unexpected block type: got %v, %v", typ, err)
+	}
+	if got, exp := BlockEncoding(block), BlockEncodingZstd; got != exp {
+		t.Fatalf("unexpected block encoding: got %v, exp %v", got, exp)
+	}
+	// Versions without block encodings only accept known block types.
+	if block[0] <= BlockUnsigned {
+		t.Fatalf("block type %d is readable by versions without zstd", block[0])
+	}
+
+	var got tsdb.StringArray
+	if err := DecodeStringArrayBlock(block, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Timestamps, exp) || !reflect.DeepEqual(got.Values, a.Values) {
+		t.Fatal("unexpected decoded array")
+	}
+
+	var values []StringValue
+	if values, err = DecodeStringBlock(block, &values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(values) != a.Len() {
+		t.Fatalf("unexpected values length: got %d, exp %d", len(values), a.Len())
+	}
+	for i, v := range values {
+		if v.unixnano != exp[i] || v.value != a.Values[i] {
+			t.Fatalf("unexpected value %d: got %v", i, v)
+		}
+	}
+}
+
+func TestZstd_FloatBlock(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, tt := range []struct {
+		name string
+		n    int
+		fn   func(i int) float64
+		exp  byte
+	}{
+		{name: "random", n: tsdb.DefaultMaxPointsPerBlock, fn: func(int) float64 { return rnd.NormFloat64() * 1e3 }, exp: BlockEncodingZstd},
+		{name: "periodic", n: tsdb.DefaultMaxPointsPerBlock, fn: func(i int) float64 { return 230 + float64(i%10)/10 }, exp: BlockEncodingZstd},
+		// The zstd frame overhead outweighs its gains on short blocks.
+		{name: "short", n: 3, fn: func(i int) float64 { return float64(i) + 0.5 }, exp: BlockEncodingDefault},
+		{name: "empty", n: 0, exp: BlockEncodingDefault},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tsdb.NewFloatArrayLen(tt.n)
+			for i := range a.Values {
+				a.Timestamps[i] = int64(i) * 1e9
+				a.Values[i] = tt.fn(i)
+			}
+			// Encoding the timestamps modifies them.
+			exp := append([]int64(nil), a.Timestamps...)
+
+			block, err := encodeFloatArrayBlockUsing(a, BlockEncodingZstd, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if a.Len() == 0 {
+				if block != nil {
+					t.Fatalf("unexpected block for empty array: %v", block)
+				}
+				return
+			}
+			if got := BlockEncoding(block); got != tt.exp {
+				t.Fatalf("unexpected block encoding: got %v, exp %v", got, tt.exp)
+			}
+
+			var got tsdb.FloatArray
+			if err := DecodeFloatArrayBlock(block, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got.Timestamps, exp) || !reflect.DeepEqual(got.Values, a.Values) {
+				t.Fatal("unexpected decoded array")
+			}
+
+			values, err := DecodeBlock(block, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(values) != a.Len() {
+				t.Fatalf("unexpected values length: got %d, exp %d", len(values), a.Len())
+			}
+			for i, v := range values {
+				if v.UnixNano() != exp[i] || v.Value() != a.Values[i] {
+					t.Fatalf("unexpected value %d: got %v", i, v)
+				}
+			}
+		})
+	}
+}
+
+func TestZstd_FloatBlock_NaN(t *testing.T) {
+	a := tsdb.NewFloatArrayLen(2)
+	a.Values[1] = math.NaN()
+	if _, err := encodeFloatArrayBlockUsing(a, BlockEncodingZstd, nil); err == nil {
+		t.Fatal("expected error encoding NaN")
+	}
+}
+
+func TestZstd_FloatDecoder(t *testing.T) {
+	b := floatArrayEncodeAllZstd([]float64{1, 2, 3}, nil)
+	var dec FloatDecoder
+	if err := dec.SetBytes(b); err == nil {
+		t.Fatal("expected error decoding zstd values")
+	}
+}
+
+func TestBlockType_Encoding(t *testing.T) {
+	for _, tt := range []struct {
+		b   byte
+		exp byte
+		err bool
+	}{
+		{b: BlockFloat64, exp: BlockFloat64},
+		{b: packBlockType(BlockFloat64, BlockEncodingZstd), exp: BlockFloat64},
+		{b: packBlockType(BlockString, BlockEncodingZstd), exp: BlockString},
+		{b: packBlockType(BlockInteger, BlockEncodingZstd), err: true},
+		{b: packBlockType(BlockString, 2), err: true},
+		{b: 5, err: true},
+	} {
+		typ, err := BlockType([]byte{tt.b})
+		if tt.err != (err != nil) {
+			t.Fatalf("unexpected error for block type %d: %v", tt.b, err)
+		}
+		if typ != tt.exp {
+			t.Fatalf("unexpected type for block type %d: got %d, exp %d", tt.b, typ, tt.exp)
+		}
+	}
+
+	var a tsdb.IntegerArray
+	if err := DecodeIntegerArrayBlock([]byte{packBlockType(BlockInteger, BlockEncodingZstd), 0}, &a); err == nil {
+		t.Fatal("expected error decoding integer block with zstd encoding")
+	}
+}
+
+func TestRecodeBlock(t *testing.T) {
+	for _, tt := range []struct {
+		b   byte
+		enc byte
+		exp bool
+	}{
+		{b: BlockString, enc: BlockEncodingDefault, exp: false},
+		{b: BlockString, enc: BlockEncodingZstd, exp: true},
+		{b: BlockFloat64, enc: BlockEncodingZstd, exp: false},
+		{b: BlockInteger, enc: BlockEncodingZstd, exp: false},
+		{b: packBlockType(BlockString, BlockEncodingZstd), enc: BlockEncodingZstd, exp: false},
+		{b: packBlockType(BlockString, BlockEncodingZstd), enc: BlockEncodingDefault, exp: true},
+		{b: packBlockType(BlockFloat64, BlockEncodingZstd), enc: BlockEncodingDefault, exp: true},
+	} {
+		if got := recodeBlock([]byte{tt.b}, tt.enc); got != tt.exp {
+			t.Fatalf("unexpected recode for block type %d and encoding %d: got %v, exp %v", tt.b, tt.enc, got, tt.exp)
+		}
+	}
+}
+
+// BenchmarkBlockEncoding compares the default encodings of float and string
+// blocks with zstd. The size of the encoded blocks is reported as bytes/block.
+func BenchmarkBlockEncoding(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	n := tsdb.DefaultMaxPointsPerBlock
+	timestamps := make([]int64, n)
+	for i := range timestamps {
+		timestamps[i] = int64(i) * 10e9
+	}
+
+	statuses := []string{"OL", "OL CHRG", "OB DISCHRG", "OB LB", "OL BOOST", "OL TRIM"}
+	status := tsdb.NewStringArrayLen(n)
+	words := tsdb.NewStringArrayLen(n)
+	voltage := tsdb.NewFloatArrayLen(n)
+	load := tsdb.NewFloatArrayLen(n)
+	for i := 0; i < n; i++ {
+		status.Values[i] = statuses[i/50%len(statuses)]
+		words.Values[i] = fmt.Sprintf("battery %d runtime %d", rnd.Intn(100), rnd.Intn(3600))
+		voltage.Values[i] = math.Round((230+rnd.NormFloat64())*10) / 10
+		load.Values[i] = rnd.Float64() * 100
+	}
+
+	// Encoding the timestamps modifies them, so they are copied before each
+	// encoding.
+	type codec struct {
+		encode func(enc byte) ([]byte, error)
+		decode func(block []byte) error
+	}
+	stringCodec := func(a *tsdb.StringArray) codec {
+		var dst tsdb.StringArray
+		return codec{
+			encode: func(enc byte) ([]byte, error) {
+				copy(a.Timestamps, timestamps)
+				return encodeStringArrayBlockUsing(a, enc, nil)
+			},
+			decode: func(block []byte) error { return DecodeStringArrayBlock(block, &dst) },
+		}
+	}
+	floatCodec := func(a *tsdb.FloatArray) codec {
+		var dst tsdb.FloatArray
+		return codec{
+			encode: func(enc byte) ([]byte, error) {
+				copy(a.Timestamps, timestamps)
+				return encodeFloatArrayBlockUsing(a, enc, nil)
+			},
+			decode: func(block []byte) error { return DecodeFloatArrayBlock(block, &dst) },
+		}
+	}
+
+	for _, data := range []struct {
+		name  string
+		codec codec
+	}{
+		{"string/status", stringCodec(status)},
+		{"string/words", stringCodec(words)},
+		{"float/voltage", floatCodec(voltage)},
+		{"float/random", floatCodec(load)},
+	} {
+		for _, enc := range []struct {
+			name string
+			enc  byte
+		}{
+			{"default", BlockEncodingDefault},
+			{"zstd", BlockEncodingZstd},
+		} {
+			block, err := data.codec.encode(enc.enc)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.Run(data.name+"/"+enc.name+"/encode", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := data.codec.encode(enc.enc); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(block)), "bytes/block")
+			})
+
+			b.Run(data.name+"/"+enc.name+"/decode", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := data.codec.decode(block); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(block)), "bytes/block")
+			})
+		}
+	}
+}
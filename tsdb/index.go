@@ -73,6 +73,18 @@ type Index interface {
 	// Bytes estimates the memory footprint of this Index, in bytes.
 	Bytes() int
 
+	// MeasurementsBytes estimates the memory footprint of the in-memory
+	// structures of each measurement of this Index, in bytes.
+	MeasurementsBytes() map[string]int
+
+	// SeriesIDSetCacheBytes estimates the memory footprint of the series ID
+	// sets cached by this Index for each measurement, in bytes.
+	SeriesIDSetCacheBytes() map[string]int
+
+	// FreeMemory writes the in-memory structures of this Index to disk and
+	// empties its caches.
+	FreeMemory() error
+
 	Type() string
 
 	// Returns a unique reference ID to the index instance.
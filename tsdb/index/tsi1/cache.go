@@ -3,6 +3,7 @@ package tsi1
 import (
 	"container/list"
 	"sync"
+	"unsafe"
 
 	"github.com/influxdata/influxdb/v2/tsdb"
 )
@@ -164,6 +165,37 @@ func (c *TagValueSeriesIDCache) delete(name, key, value []byte, x uint64) {
 	}
 }
 
+// Clear removes all items from the cache.
+func (c *TagValueSeriesIDCache) Clear() {
+	c.Lock()
+	defer c.Unlock()
+	c.cache = map[string]map[string]map[string]*list.Element{}
+	c.evictor.Init()
+}
+
+// measurementBytes estimates the memory footprint of the items of each
+// measurement in the cache, in bytes.
+func (c *TagValueSeriesIDCache) measurementBytes() map[string]int {
+	c.RLock()
+	defer c.RUnlock()
+
+	m := make(map[string]int, len(c.cache))
+	for name, mmap := range c.cache {
+		b := len(name)
+		for key, tkmap := range mmap {
+			b += len(key)
+			for value, ele := range tkmap {
+				b += len(value) + int(unsafe.Sizeof(*ele)) + int(unsafe.Sizeof(seriesIDCacheElement{}))
+				if ss := ele.Value.(*seriesIDCacheElement).SeriesIDSet; ss != nil {
+					b += ss.Bytes()
+				}
+			}
+		}
+		m[name] = b
+	}
+	return m
+}
+
 // checkEviction checks if the cache is too big, and evicts the least recently used
 // item if it is.
 func (c *TagValueSeriesIDCache) checkEviction() {
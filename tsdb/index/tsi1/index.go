@@ -207,6 +207,41 @@ func (i *Index) Bytes() int {
 	return b
 }
 
+// MeasurementsBytes estimates the memory footprint of each measurement in the
+// log files of this Index, in bytes. Index files are mmapped, so they aren't
+// counted.
+func (i *Index) MeasurementsBytes() map[string]int {
+	m := make(map[string]int)
+	i.mu.RLock()
+	for _, p := range i.partitions {
+		p.measurementBytes(m)
+	}
+	i.mu.RUnlock()
+	return m
+}
+
+// SeriesIDSetCacheBytes estimates the memory footprint of the series ID sets
+// cached for each measurement of this Index, in bytes.
+func (i *Index) SeriesIDSetCacheBytes() map[string]int {
+	return i.tagValueCache.measurementBytes()
+}
+
+// FreeMemory empties the series ID set cache of this Index and compacts the
+// log files of its partitions into index files, whatever their size. The log
+// files are compacted in the background.
+func (i *Index) FreeMemory() error {
+	i.tagValueCache.Clear()
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, p := range i.partitions {
+		if err := p.CompactLogFile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Database returns the name of the database the index was initialized with.
 func (i *Index) Database() string {
 	return i.database
@@ -106,6 +106,16 @@ func (f *LogFile) bytes() int {
 	return b
 }
 
+// measurementBytes adds the estimated memory footprint of each measurement
+// of this LogFile to m, in bytes.
+func (f *LogFile) measurementBytes(m map[string]int) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for name, mm := range f.mms {
+		m[name] += len(name) + mm.bytes()
+	}
+}
+
 // Open reads the log from a file and validates all the checksums.
 func (f *LogFile) Open() error {
 	f.mu.Lock()
@@ -105,6 +105,18 @@ func NewPartition(sfile *tsdb.SeriesFile, path string) *Partition {
 	}
 }
 
+// measurementBytes adds the estimated memory footprint of each measurement
+// in the log files of this Partition to m, in bytes.
+func (p *Partition) measurementBytes(m map[string]int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, f := range p.fileSet.files {
+		if f, ok := f.(*LogFile); ok {
+			f.measurementBytes(m)
+		}
+	}
+}
+
 // bytes estimates the memory footprint of this Partition, in bytes.
 func (p *Partition) bytes() int {
 	var b int
@@ -1080,7 +1092,24 @@ func (p *Partition) checkLogFile() error {
 	if p.activeLogFile.Size() < p.MaxLogFileSize {
 		return nil
 	}
+	return p.swapLogFile()
+}
+
+// CompactLogFile compacts the active log file into an index file whatever
+// its size, releasing the memory it holds.
+func (p *Partition) CompactLogFile() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isClosing() || p.activeLogFile.Size() == 0 {
+		return nil
+	}
+	return p.swapLogFile()
+}
 
+// swapLogFile replaces the active log file with a new one and compacts it
+// in the background.
+func (p *Partition) swapLogFile() error {
 	// Swap current log file.
 	logFile := p.activeLogFile
 
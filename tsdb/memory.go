@@ -0,0 +1,47 @@
+package tsdb
+
+// MemoryUsage estimates the memory used by a shard, or by a measurement of a
+// shard, in bytes.
+type MemoryUsage struct {
+	// Index is the memory of the in-memory structures of the index, such as
+	// the TSI log files not yet compacted into index files.
+	Index int64
+
+	// SeriesIDSetCache is the memory of the series ID sets cached by the
+	// index, up to series-id-set-cache-size sets.
+	SeriesIDSetCache int64
+
+	// Cache is the memory of the writes in the cache, not yet written to
+	// TSM files.
+	Cache int64
+
+	// TSMIndex is the size of the indexes of the TSM files, which are
+	// mmapped. The kernel reclaims their pages under memory pressure.
+	TSMIndex int64
+}
+
+// Heap returns the memory of u that is allocated on the heap, which is all
+// of it except the mmapped TSM indexes.
+func (u MemoryUsage) Heap() int64 {
+	return u.Index + u.SeriesIDSetCache + u.Cache
+}
+
+// Add adds the memory of other to u.
+func (u *MemoryUsage) Add(other MemoryUsage) {
+	u.Index += other.Index
+	u.SeriesIDSetCache += other.SeriesIDSetCache
+	u.Cache += other.Cache
+	u.TSMIndex += other.TSMIndex
+}
+
+// SeriesFileMemoryUsage estimates the memory used by the series file of a
+// database, in bytes.
+type SeriesFileMemoryUsage struct {
+	// Index is the memory of the series indexed in memory by the partitions
+	// since their last compaction.
+	Index int64
+
+	// Mmap is the size of the index files and segments of the partitions,
+	// which are mmapped.
+	Mmap int64
+}
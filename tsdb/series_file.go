@@ -173,6 +173,18 @@ func (f *SeriesFile) FileSize() (n int64, err error) {
 	return n, err
 }
 
+// MemoryUsage estimates the memory used by the partitions of the series file,
+// in bytes.
+func (f *SeriesFile) MemoryUsage() SeriesFileMemoryUsage {
+	var u SeriesFileMemoryUsage
+	for _, p := range f.partitions {
+		inMem, mmapped := p.MemoryUsage()
+		u.Index += inMem
+		u.Mmap += mmapped
+	}
+	return u
+}
+
 // CreateSeriesListIfNotExists creates a list of series in bulk if they don't exist.
 // The returned ids slice returns IDs for every name+tags, creating new series IDs as needed.
 func (f *SeriesFile) CreateSeriesListIfNotExists(names [][]byte, tagsSlice []models.Tags) ([]uint64, error) {
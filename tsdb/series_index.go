@@ -144,6 +144,21 @@ func (idx *SeriesIndex) OnDiskCount() uint64 { return idx.count }
 // InMemCount returns the number of series in the in-memory index.
 func (idx *SeriesIndex) InMemCount() uint64 { return uint64(len(idx.idOffsetMap)) }
 
+// InMemBytes estimates the memory footprint of the in-memory index, in bytes.
+func (idx *SeriesIndex) InMemBytes() int {
+	var b int
+	if idx.keyIDMap != nil {
+		// Series IDs are boxed in the values of the map.
+		b += idx.keyIDMap.Bytes() + int(idx.keyIDMap.Len())*8
+	}
+	b += len(idx.idOffsetMap) * 16
+	b += len(idx.tombstones) * 8
+	return b
+}
+
+// MmapBytes returns the size of the mmapped on-disk index, in bytes.
+func (idx *SeriesIndex) MmapBytes() int { return len(idx.data) }
+
 func (idx *SeriesIndex) Insert(key []byte, id uint64, offset int64) {
 	idx.execEntry(SeriesEntryInsertFlag, id, offset, key)
 }
@@ -195,6 +195,22 @@ func (p *SeriesPartition) FileSize() (n int64, err error) {
 	return n, err
 }
 
+// MemoryUsage estimates the memory used by the index of the partition, and
+// the size of its mmapped index and segments, in bytes.
+func (p *SeriesPartition) MemoryUsage() (inMem, mmapped int64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.index != nil {
+		inMem = int64(p.index.InMemBytes())
+		mmapped = int64(p.index.MmapBytes())
+	}
+	for _, s := range p.segments {
+		mmapped += int64(len(s.Data()))
+	}
+	return inMem, mmapped
+}
+
 // CreateSeriesListIfNotExists creates a list of series in bulk if they don't exist.
 // The ids parameter is modified to contain series IDs for all keys belonging to this partition.
 func (p *SeriesPartition) CreateSeriesListIfNotExists(keys [][]byte, keyPartitionIDs []int, ids []uint64) error {
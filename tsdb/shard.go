@@ -67,6 +67,10 @@ var (
 	// queries or writes.
 	ErrShardDisabled = errors.New("shard is disabled")
 
+	// ErrShardReadOnly is returned when writing to a shard moved to the cold
+	// tier.
+	ErrShardReadOnly = errors.New("shard is read-only")
+
 	// ErrUnknownFieldsFormat is returned when the fields index file is not identifiable by
 	// the file's magic number.
 	ErrUnknownFieldsFormat = errors.New("unknown field index format")
@@ -115,12 +119,58 @@ type PartialWriteError struct {
 
 	// A sorted slice of series keys that were dropped.
 	DroppedKeys [][]byte
+
+	// The points that were dropped, when known, with the cause of each drop.
+	DroppedPoints []DroppedPoint
 }
 
 func (e PartialWriteError) Error() string {
 	return fmt.Sprintf("partial write: %s dropped=%d", e.Reason, e.Dropped)
 }
 
+// MergePartialWriteErrors adds the points dropped by err to the partial write
+// error into, which may be nil. The reason of into is kept.
+func MergePartialWriteErrors(into *PartialWriteError, err PartialWriteError) *PartialWriteError {
+	if into == nil {
+		return &err
+	}
+	into.Dropped += err.Dropped
+	into.DroppedKeys = append(into.DroppedKeys, err.DroppedKeys...)
+	sort.Slice(into.DroppedKeys, func(i, j int) bool {
+		return bytes.Compare(into.DroppedKeys[i], into.DroppedKeys[j]) < 0
+	})
+	into.DroppedPoints = append(into.DroppedPoints, err.DroppedPoints...)
+	return into
+}
+
+// DropCause classifies why a point was dropped by a partial write.
+type DropCause string
+
+const (
+	// DropSchemaViolation is the cause of points with an invalid series key or
+	// without any valid field.
+	DropSchemaViolation DropCause = "schema violation"
+
+	// DropFieldTypeConflict is the cause of points with a field of another
+	// type than the one already stored.
+	DropFieldTypeConflict DropCause = "field type conflict"
+
+	// DropBeyondRetention is the cause of points outside of the retention
+	// period of their bucket.
+	DropBeyondRetention DropCause = "beyond retention"
+
+	// DropShardDeleted is the cause of points written to a shard that is
+	// being deleted.
+	DropShardDeleted DropCause = "shard deleted"
+)
+
+// DroppedPoint is a point dropped by a partial write.
+type DroppedPoint struct {
+	Point  models.Point
+	Cause  DropCause
+	Reason string
+}
+
 // Shard represents a self-contained time series database. An inverted index of
 // the measurement and tag data is kept along with the raw time series data.
 // Data can be split across many shards. The query engine in TSDB is responsible
@@ -141,6 +191,10 @@ type Shard struct {
 	index   Index
 	enabled bool
 
+	// readOnly is set on shards of the cold tier, which reject writes and
+	// don't compact.
+	readOnly bool
+
 	// expvar-based stats.
 	stats       *ShardStatistics
 	defaultTags models.StatisticTags
@@ -207,13 +261,46 @@ func (s *Shard) SetEnabled(enabled bool) {
 	s.mu.Lock()
 	// Prevent writes and queries
 	s.enabled = enabled
-	if s._engine != nil && !s.CompactionDisabled {
+	if s._engine != nil && !s.CompactionDisabled && !s.readOnly {
 		// Disable background compactions and snapshotting
 		s._engine.SetEnabled(enabled)
 	}
 	s.mu.Unlock()
 }
 
+// setDurability changes the durability of the shard and reopens its engine,
+// which applies it. Writes to the shard fail while it is reopened.
+func (s *Shard) setDurability(d Durability) error {
+	s.mu.Lock()
+	if s.options.Durability == d {
+		s.mu.Unlock()
+		return nil
+	}
+	prev := s.options.Durability
+	s.options.Durability = d
+	if s._engine == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	enabled := s.enabled
+	err := s.close()
+	if err != nil {
+		// The shard is still open with its previous durability.
+		s.options.Durability = prev
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := s.Open(); err != nil {
+		return err
+	}
+	// Restore the state of the engine, which Open only enables on request.
+	s.SetEnabled(enabled)
+	return nil
+}
+
 // ScheduleFullCompaction forces a full compaction to be schedule on the shard.
 func (s *Shard) ScheduleFullCompaction() error {
 	engine, err := s.Engine()
@@ -223,6 +310,102 @@ func (s *Shard) ScheduleFullCompaction() error {
 	return engine.ScheduleFullCompaction()
 }
 
+// WriteSnapshot writes the cache of the shard to a new TSM file.
+func (s *Shard) WriteSnapshot() error {
+	engine, err := s.Engine()
+	if err != nil {
+		return err
+	}
+	return engine.WriteSnapshot()
+}
+
+// FileStats returns a description of the data files of the shard.
+func (s *Shard) FileStats() ([]FileStat, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.FileStats(), nil
+}
+
+// CompactionStats returns the compactions of the shard that are planned and
+// in progress.
+func (s *Shard) CompactionStats() (queued, active []CompactionStat, err error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return nil, nil, err
+	}
+	queued, active = engine.CompactionStats()
+	return queued, active, nil
+}
+
+// MemoryUsage estimates the memory used by the shard, in bytes.
+func (s *Shard) MemoryUsage() (MemoryUsage, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return MemoryUsage{}, err
+	}
+	return engine.MemoryUsage(), nil
+}
+
+// MeasurementsMemoryUsage estimates the memory used by each measurement of
+// the shard, in bytes.
+func (s *Shard) MeasurementsMemoryUsage() (map[string]MemoryUsage, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.MeasurementsMemoryUsage(), nil
+}
+
+// FreeMemory writes the cache and the index log files of the shard to disk,
+// and empties the caches of its index.
+func (s *Shard) FreeMemory() error {
+	engine, err := s.Engine()
+	if err != nil {
+		return err
+	}
+	return engine.FreeMemory()
+}
+
+// RebuildIndex discards the index of the shard and rebuilds it from its TSM
+// files and WAL. The shard is closed, and so rejects writes and queries,
+// until its index is rebuilt.
+func (s *Shard) RebuildIndex() error {
+	var enabled bool
+	if err := func() error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s._engine == nil {
+			return ErrEngineClosed
+		}
+		enabled = s.enabled
+		if err := s.close(); err != nil {
+			return err
+		}
+		// The index is rebuilt by Open when it's missing.
+		return os.RemoveAll(filepath.Join(s.path, "index"))
+	}(); err != nil {
+		return err
+	}
+
+	if err := s.Open(); err != nil {
+		return err
+	}
+	// Restore the state of the engine, which Open only enables on request.
+	s.SetEnabled(enabled)
+	return nil
+}
+
+// ReadOnly returns true if the shard rejects writes because it is on the cold
+// tier.
+func (s *Shard) ReadOnly() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readOnly
+}
+
 // ID returns the shards ID.
 func (s *Shard) ID() uint64 {
 	return s.id
@@ -386,15 +569,17 @@ func (s *Shard) close() error {
 		return nil
 	}
 
-	err := s._engine.Close()
-	if err == nil {
-		s._engine = nil
+	// The shard is left open if its engine is, which it does when its writes
+	// would be lost otherwise.
+	if err := s._engine.Close(); err != nil {
+		return err
 	}
+	s._engine = nil
 
 	if e := s.index.Close(); e == nil {
 		s.index = nil
 	}
-	return err
+	return nil
 }
 
 // IndexType returns the index version being used for this shard.
@@ -477,7 +662,7 @@ func (s *Shard) Free() error {
 // SetCompactionsEnabled enables or disable shard background compactions.
 func (s *Shard) SetCompactionsEnabled(enabled bool) {
 	engine, err := s.Engine()
-	if err != nil {
+	if err != nil || (enabled && s.ReadOnly()) {
 		return
 	}
 	engine.SetCompactionsEnabled(enabled)
@@ -511,6 +696,8 @@ func (s *Shard) WritePoints(points []models.Point) error {
 	engine, err := s.engineNoLock()
 	if err != nil {
 		return err
+	} else if s.readOnly {
+		return ErrShardReadOnly
 	}
 
 	var writeError error
@@ -550,6 +737,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 		fieldsToCreate []*FieldCreate
 		err            error
 		dropped        int
+		droppedPoints  []DroppedPoint
 		reason         string // only first error reason is set unless returned from CreateSeriesListIfNotExists
 	)
 
@@ -568,20 +756,24 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 		// Drop any series w/ a "time" tag, these are illegal
 		if v := tags.Get(timeBytes); v != nil {
 			dropped++
+			msg := fmt.Sprintf(
+				"invalid tag key: input tag \"%s\" on measurement \"%s\" is invalid",
+				"time", string(p.Name()))
 			if reason == "" {
-				reason = fmt.Sprintf(
-					"invalid tag key: input tag \"%s\" on measurement \"%s\" is invalid",
-					"time", string(p.Name()))
+				reason = msg
 			}
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Cause: DropSchemaViolation, Reason: msg})
 			continue
 		}
 
 		// Drop any series with invalid unicode characters in the key.
 		if validateKeys && !models.ValidKeyTokens(string(p.Name()), tags) {
 			dropped++
+			msg := fmt.Sprintf("key contains invalid unicode: \"%s\"", string(p.Key()))
 			if reason == "" {
-				reason = fmt.Sprintf("key contains invalid unicode: \"%s\"", string(p.Key()))
+				reason = msg
 			}
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Cause: DropSchemaViolation, Reason: msg})
 			continue
 		}
 
@@ -600,6 +792,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 
 	// Add new series. Check for partial writes.
 	var droppedKeys [][]byte
+	var droppedKeysReason string
 	if err := engine.CreateSeriesListIfNotExists(keys, names, tagsSlice); err != nil {
 		switch err := err.(type) {
 		// TODO(jmw): why is this a *PartialWriteError when everything else is not a pointer?
@@ -607,6 +800,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 		// the places that construct it.
 		case *PartialWriteError:
 			reason = err.Reason
+			droppedKeysReason = err.Reason
 			dropped += err.Dropped
 			droppedKeys = err.DroppedKeys
 			atomic.AddInt64(&s.stats.WritePointsDropped, int64(err.Dropped))
@@ -628,17 +822,20 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 			break
 		}
 		if !validField {
+			msg := fmt.Sprintf(
+				"invalid field name: input field \"%s\" on measurement \"%s\" is invalid",
+				"time", string(p.Name()))
 			if reason == "" {
-				reason = fmt.Sprintf(
-					"invalid field name: input field \"%s\" on measurement \"%s\" is invalid",
-					"time", string(p.Name()))
+				reason = msg
 			}
 			dropped++
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Cause: DropSchemaViolation, Reason: msg})
 			continue
 		}
 
 		// Skip any points whos keys have been dropped. Dropped has already been incremented for them.
 		if len(droppedKeys) > 0 && bytesutil.Contains(droppedKeys, keys[i]) {
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Cause: DropSchemaViolation, Reason: droppedKeysReason})
 			continue
 		}
 
@@ -653,6 +850,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 					reason = err.Reason
 				}
 				dropped += err.Dropped
+				droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Cause: DropFieldTypeConflict, Reason: err.Reason})
 				atomic.AddInt64(&s.stats.WritePointsDropped, int64(err.Dropped))
 			default:
 				return nil, nil, err
@@ -693,7 +891,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 	}
 
 	if dropped > 0 {
-		err = PartialWriteError{Reason: reason, Dropped: dropped}
+		err = PartialWriteError{Reason: reason, Dropped: dropped, DroppedPoints: droppedPoints}
 	}
 
 	return points[:j], fieldsToCreate, err
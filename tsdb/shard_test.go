@@ -360,6 +360,55 @@ func TestShardWriteAddNewField(t *testing.T) {
 	}
 }
 
+func TestShard_WritePoints_DroppedPoints(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := filepath.Join(tmpDir, "shard")
+	tmpWal := filepath.Join(tmpDir, "wal")
+
+	sfile := MustOpenSeriesFile(t)
+	defer sfile.Close()
+
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+
+	sh := tsdb.NewShard(1, tmpShard, tmpWal, sfile.SeriesFile, opts)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	}
+	defer sh.Close()
+
+	if err := sh.WritePoints([]models.Point{
+		models.MustNewPoint("cpu", nil, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	conflict := models.MustNewPoint("cpu", nil, map[string]interface{}{"value": int64(1)}, time.Unix(2, 0))
+	timeTag := models.MustNewPoint("cpu", models.NewTags(map[string]string{"time": "now"}), map[string]interface{}{"value": 1.0}, time.Unix(3, 0))
+	valid := models.MustNewPoint("cpu", nil, map[string]interface{}{"value": 2.0}, time.Unix(4, 0))
+
+	err := sh.WritePoints([]models.Point{conflict, timeTag, valid})
+	perr, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		t.Fatalf("expected partial write error, got %v", err)
+	}
+	if perr.Dropped != 2 || len(perr.DroppedPoints) != 2 {
+		t.Fatalf("got %d dropped and %d dropped points, exp 2", perr.Dropped, len(perr.DroppedPoints))
+	}
+
+	causes := map[models.Point]tsdb.DropCause{}
+	for _, d := range perr.DroppedPoints {
+		causes[d.Point] = d.Cause
+	}
+	if got, exp := causes[conflict], tsdb.DropFieldTypeConflict; got != exp {
+		t.Errorf("got cause %q for the field type conflict, exp %q", got, exp)
+	}
+	if got, exp := causes[timeTag], tsdb.DropSchemaViolation; got != exp {
+		t.Errorf("got cause %q for the time tag, exp %q", got, exp)
+	}
+}
+
 // Tests concurrently writing to the same shard with different field types which
 // can trigger a panic when the shard is snapshotted to TSM files.
 func TestShard_WritePoints_FieldConflictConcurrent(t *testing.T) {
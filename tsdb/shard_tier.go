@@ -0,0 +1,168 @@
+package tsdb
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/pkg/file"
+)
+
+// moveTo moves the files of the shard to path, and reopens the shard from
+// there as read-only. The TSM files are copied while the shard still serves
+// queries. The shard is then closed while the remaining files are copied.
+func (s *Shard) moveTo(path string) (err error) {
+	s.mu.Lock()
+	if s._engine == nil {
+		s.mu.Unlock()
+		return ErrEngineClosed
+	} else if s.readOnly {
+		s.mu.Unlock()
+		return nil
+	}
+	// Reject writes from now on, so that the snapshot below holds the last
+	// data of the shard.
+	s.readOnly = true
+	engine, enabled := s._engine, s.enabled
+	s.mu.Unlock()
+
+	tmpPath := path + ".tmp"
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmpPath)
+		}
+	}()
+
+	if err := os.RemoveAll(tmpPath); err != nil {
+		s.setReadOnly(false)
+		return err
+	}
+	if err := func() error {
+		dir, err := engine.CreateSnapshot(false)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		return syncShardDir(dir, tmpPath)
+	}(); err != nil {
+		s.setReadOnly(false)
+		return err
+	}
+
+	err = func() error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if err := s.close(); err != nil {
+			return err
+		}
+		if err := syncShardDir(s.path, tmpPath); err != nil {
+			return err
+		}
+		if err := file.RenameFile(tmpPath, path); err != nil {
+			return err
+		}
+		if err := file.SyncDir(filepath.Dir(path)); err != nil {
+			return err
+		}
+
+		oldPath := s.path
+		s.path = path
+		return os.RemoveAll(oldPath)
+	}()
+
+	// The shard is reopened from its old path if it couldn't be moved.
+	s.mu.Lock()
+	s.readOnly = s.path == path
+	s.mu.Unlock()
+
+	if e := s.Open(); e != nil && err == nil {
+		err = e
+	}
+	// Restore the state of the engine, which Open only enables on request.
+	s.SetEnabled(enabled)
+	return err
+}
+
+func (s *Shard) setReadOnly(readOnly bool) {
+	s.mu.Lock()
+	s.readOnly = readOnly
+	s.mu.Unlock()
+}
+
+// syncShardDir makes dst a copy of the shard files of src. TSM files already
+// in dst with the same size are kept as is, since they are never modified
+// once written. Temporary files and directories of src are skipped.
+func syncShardDir(src, dst string) error {
+	if err := os.MkdirAll(dst, 0777); err != nil {
+		return err
+	}
+
+	fis, err := ioutil.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{}, len(fis))
+	for _, fi := range fis {
+		name := fi.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		names[name] = struct{}{}
+
+		srcPath, dstPath := filepath.Join(src, name), filepath.Join(dst, name)
+		if fi.IsDir() {
+			if err := syncShardDir(srcPath, dstPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if filepath.Ext(name) == ".tsm" {
+			if dfi, err := os.Stat(dstPath); err == nil && dfi.Size() == fi.Size() {
+				continue
+			}
+		}
+		if err := copyShardFile(srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+
+	// Remove the files of dst compacted away since it was last synced.
+	dfis, err := ioutil.ReadDir(dst)
+	if err != nil {
+		return err
+	}
+	for _, fi := range dfis {
+		if _, ok := names[fi.Name()]; !ok {
+			if err := os.RemoveAll(filepath.Join(dst, fi.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return file.SyncDir(dst)
+}
+
+func copyShardFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -36,6 +36,9 @@ var (
 	ErrShardNotFound = fmt.Errorf("shard not found")
 	// ErrStoreClosed is returned when trying to use a closed Store.
 	ErrStoreClosed = fmt.Errorf("store is closed")
+	// ErrColdTierDisabled is returned when moving a shard to the cold tier
+	// of a Store without a cold directory.
+	ErrColdTierDisabled = fmt.Errorf("cold tier is disabled")
 	// ErrShardDeletion is returned when trying to create a shard that is being deleted
 	ErrShardDeletion = errors.New("shard is being deleted")
 	// ErrMultipleIndexTypes is returned when trying to do deletes on a database with
@@ -96,6 +99,10 @@ type Store struct {
 
 	EngineOptions EngineOptions
 
+	// compactionThroughput limits the disk writes of the compactions of all
+	// shards, see SetCompactionLimits.
+	compactionThroughput *limiter.AdjustableRate
+
 	baseLogger *zap.Logger
 	Logger     *zap.Logger
 
@@ -259,7 +266,7 @@ func (s *Store) loadShards() error {
 		lim = runtime.GOMAXPROCS(0)
 	}
 
-	s.EngineOptions.CompactionLimiter = limiter.NewFixed(lim)
+	s.EngineOptions.CompactionLimiter = limiter.NewAdjustable(lim)
 
 	compactionSettings := []zapcore.Field{zap.Int("max_concurrent_compactions", lim)}
 	throughput := int(s.EngineOptions.Config.CompactThroughput)
@@ -274,14 +281,18 @@ func (s *Store) loadShards() error {
 			zap.Int("throughput_bytes_per_second", throughput),
 			zap.Int("throughput_bytes_per_second_burst", throughputBurst),
 		)
-		s.EngineOptions.CompactionThroughputLimiter = limiter.NewRate(throughput, throughputBurst)
 	} else {
+		throughput, throughputBurst = 0, 0
 		compactionSettings = append(
 			compactionSettings,
 			zap.String("throughput_bytes_per_second", "unlimited"),
 			zap.String("throughput_bytes_per_second_burst", "unlimited"),
 		)
 	}
+	// The limiter is shared even when unlimited, so a limit can be set while
+	// the store is open.
+	s.compactionThroughput = limiter.NewAdjustableRate(throughput, throughputBurst)
+	s.EngineOptions.CompactionThroughputLimiter = s.compactionThroughput
 
 	s.Logger.Info("Compaction settings", compactionSettings...)
 
@@ -339,25 +350,25 @@ func (s *Store) loadShards() error {
 				continue
 			}
 
-			shardDirs, err := ioutil.ReadDir(rpPath)
+			shardDirs, err := s.readShardDirs(log, db.Name(), rp.Name())
 			if err != nil {
 				return err
 			}
 
-			for _, sh := range shardDirs {
+			for _, shd := range shardDirs {
 				// Series file should not be in a retention policy but skip just in case.
-				if sh.Name() == SeriesFileDirectory {
-					log.Warn("Skipping series file in retention policy dir", zap.String("path", filepath.Join(s.path, db.Name(), rp.Name())))
+				if shd.name == SeriesFileDirectory {
+					log.Warn("Skipping series file in retention policy dir", zap.String("path", rpPath))
 					continue
 				}
 
 				n++
-				go func(db, rp, sh string) {
+				go func(db, rp string, shd shardDir) {
 					t.Take()
 					defer t.Release()
 
 					start := time.Now()
-					path := filepath.Join(s.path, db, rp, sh)
+					sh, path := shd.name, shd.path
 					walPath := filepath.Join(s.EngineOptions.Config.WALDir, db, rp, sh)
 
 					// Shard file names are numeric shardIDs
@@ -379,6 +390,7 @@ func (s *Store) loadShards() error {
 
 					// Provide an implementation of the ShardIDSets
 					opt.SeriesIDSets = shardSet{store: s, db: db}
+					opt.Durability = s.durability(db, rp)
 
 					// Open engine.
 					shard := NewShard(shardID, path, walPath, sfile, opt)
@@ -386,6 +398,7 @@ func (s *Store) loadShards() error {
 					// Disable compactions, writes and queries until all shards are loaded
 					shard.EnableOnOpen = false
 					shard.CompactionDisabled = s.EngineOptions.CompactionDisabled
+					shard.readOnly = shd.cold
 					shard.WithLogger(s.baseLogger)
 
 					err = shard.Open()
@@ -397,7 +410,7 @@ func (s *Store) loadShards() error {
 
 					resC <- &res{s: shard}
 					log.Info("Opened shard", zap.String("index_version", shard.IndexType()), zap.String("path", path), zap.Duration("duration", time.Since(start)))
-				}(db.Name(), rp.Name(), sh.Name())
+				}(db.Name(), rp.Name(), shd)
 			}
 		}
 	}
@@ -442,6 +455,65 @@ func (s *Store) loadShards() error {
 	return nil
 }
 
+// shardDir is the directory of a shard on the primary or cold tier.
+type shardDir struct {
+	name string
+	path string
+	cold bool
+}
+
+// readShardDirs returns the shard directories of a retention policy on both
+// tiers. The move of a shard to the cold tier ends with the removal of its
+// primary directory, which is completed here if it was interrupted, while
+// incomplete copies on the cold tier are removed.
+func (s *Store) readShardDirs(log *zap.Logger, db, rp string) ([]shardDir, error) {
+	fis, err := ioutil.ReadDir(filepath.Join(s.path, db, rp))
+	if err != nil {
+		return nil, err
+	}
+
+	coldDir := s.EngineOptions.Config.ColdDir
+	if coldDir == "" {
+		dirs := make([]shardDir, 0, len(fis))
+		for _, fi := range fis {
+			dirs = append(dirs, shardDir{name: fi.Name(), path: filepath.Join(s.path, db, rp, fi.Name())})
+		}
+		return dirs, nil
+	}
+
+	coldFis, err := ioutil.ReadDir(filepath.Join(coldDir, db, rp))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	dirs := make([]shardDir, 0, len(fis)+len(coldFis))
+	cold := make(map[string]struct{}, len(coldFis))
+	for _, fi := range coldFis {
+		path := filepath.Join(coldDir, db, rp, fi.Name())
+		if strings.HasSuffix(fi.Name(), ".tmp") {
+			log.Info("Removing incomplete cold shard", zap.String("path", path))
+			if err := os.RemoveAll(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		cold[fi.Name()] = struct{}{}
+		dirs = append(dirs, shardDir{name: fi.Name(), path: path, cold: true})
+	}
+	for _, fi := range fis {
+		path := filepath.Join(s.path, db, rp, fi.Name())
+		if _, ok := cold[fi.Name()]; ok {
+			log.Info("Removing shard moved to the cold tier", zap.String("path", path))
+			if err := os.RemoveAll(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		dirs = append(dirs, shardDir{name: fi.Name(), path: path})
+	}
+	return dirs, nil
+}
+
 // Close closes the store and all associated shards. After calling Close accessing
 // shards through the Store will result in ErrStoreClosed being returned.
 func (s *Store) Close() error {
@@ -479,6 +551,65 @@ func (s *Store) Close() error {
 	return nil
 }
 
+// CompactionLimits are the limits shared by the compactions of all shards.
+type CompactionLimits struct {
+	// Throughput is the number of bytes per second compactions write to
+	// disk. Zero is unlimited.
+	Throughput int
+	// ThroughputBurst is the number of bytes compactions may write at once.
+	ThroughputBurst int
+	// MaxConcurrent is the number of compactions that may run at once.
+	MaxConcurrent int
+}
+
+// CompactionLimits returns the limits shared by the compactions of all shards.
+func (s *Store) CompactionLimits() (CompactionLimits, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.opened {
+		return CompactionLimits{}, ErrStoreClosed
+	}
+
+	var limits CompactionLimits
+	limits.Throughput, limits.ThroughputBurst = s.compactionThroughput.Limit()
+	limits.MaxConcurrent = s.EngineOptions.CompactionLimiter.Capacity()
+	return limits, nil
+}
+
+// SetCompactionLimits changes the limits shared by the compactions of all
+// shards until the store is reopened. Compactions in progress keep running
+// when the number of concurrent compactions is lowered, but no more are
+// started until enough of them finish.
+func (s *Store) SetCompactionLimits(limits CompactionLimits) (CompactionLimits, error) {
+	if max := runtime.GOMAXPROCS(0); limits.MaxConcurrent < 1 || limits.MaxConcurrent > max {
+		return CompactionLimits{}, fmt.Errorf("max concurrent compactions must be between 1 and %d", max)
+	}
+	if limits.Throughput < 0 || limits.ThroughputBurst < 0 {
+		return CompactionLimits{}, errors.New("compaction throughput must not be negative")
+	}
+	if limits.Throughput == 0 {
+		limits.ThroughputBurst = 0
+	} else if limits.ThroughputBurst < limits.Throughput {
+		limits.ThroughputBurst = limits.Throughput
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.opened {
+		return CompactionLimits{}, ErrStoreClosed
+	}
+
+	s.compactionThroughput.SetLimit(limits.Throughput, limits.ThroughputBurst)
+	s.EngineOptions.CompactionLimiter.SetCapacity(limits.MaxConcurrent)
+	s.Logger.Info("Compaction limits changed",
+		zap.Int("max_concurrent_compactions", limits.MaxConcurrent),
+		zap.Int("throughput_bytes_per_second", limits.Throughput),
+		zap.Int("throughput_bytes_per_second_burst", limits.ThroughputBurst))
+	return limits, nil
+}
+
 // epochsForShards returns a copy of the epoch trackers only including what is necessary
 // for the provided shards. Must be called under the lock.
 func (s *Store) epochsForShards(shards []*Shard) map[uint64]*epochTracker {
@@ -602,6 +733,7 @@ func (s *Store) CreateShard(database, retentionPolicy string, shardID uint64, en
 	// Copy index options and pass in shared index.
 	opt := s.EngineOptions
 	opt.SeriesIDSets = shardSet{store: s, db: database}
+	opt.Durability = s.durability(database, retentionPolicy)
 
 	path := filepath.Join(s.path, database, retentionPolicy, strconv.FormatUint(shardID, 10))
 	shard := NewShard(shardID, path, walPath, sfile, opt)
@@ -650,6 +782,41 @@ func (s *Store) SetShardEnabled(shardID uint64, enabled bool) error {
 	return nil
 }
 
+// ApplyDurability reopens the shards of a retention policy whose durability
+// differs from the one returned by the durability policy of the store.
+func (s *Store) ApplyDurability(database, retentionPolicy string) error {
+	d := s.durability(database, retentionPolicy)
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	shards := s.filterShards(func(sh *Shard) bool {
+		return sh.database == database && sh.retentionPolicy == retentionPolicy
+	})
+	s.mu.RUnlock()
+
+	for _, sh := range shards {
+		if err := sh.setDurability(d); err != nil {
+			return err
+		}
+	}
+	s.Logger.Info("Durability applied",
+		logger.Database(database),
+		logger.RetentionPolicy(retentionPolicy),
+		zap.String("mode", string(d.Mode)),
+		zap.Int("shards", len(shards)))
+	return nil
+}
+
+// durability returns the durability of the shards of a retention policy.
+func (s *Store) durability(database, retentionPolicy string) Durability {
+	if s.EngineOptions.DurabilityPolicy == nil {
+		return Durability{}
+	}
+	return s.EngineOptions.DurabilityPolicy(database, retentionPolicy)
+}
+
 // DeleteShards removes all shards from disk.
 func (s *Store) DeleteShards() error {
 	for _, id := range s.ShardIDs() {
@@ -791,6 +958,11 @@ func (s *Store) DeleteDatabase(name string) error {
 	if err := os.RemoveAll(filepath.Join(s.EngineOptions.Config.WALDir, name)); err != nil {
 		return err
 	}
+	if coldDir := s.EngineOptions.Config.ColdDir; coldDir != "" {
+		if err := os.RemoveAll(filepath.Join(coldDir, name)); err != nil {
+			return err
+		}
+	}
 
 	for _, sh := range shards {
 		delete(s.shards, sh.id)
@@ -848,6 +1020,13 @@ func (s *Store) DeleteRetentionPolicy(database, name string) error {
 		return err
 	}
 
+	// Remove the retention policy folder from the cold tier.
+	if coldDir := s.EngineOptions.Config.ColdDir; coldDir != "" {
+		if err := os.RemoveAll(filepath.Join(coldDir, database, name)); err != nil {
+			return err
+		}
+	}
+
 	s.mu.Lock()
 	state := s.databases[database]
 	for _, sh := range shards {
@@ -1153,7 +1332,7 @@ func (s *Store) BackupShard(id uint64, since time.Time, w io.Writer) error {
 		}
 	}
 
-	path, err := relativePath(s.path, shard.path)
+	path, err := s.shardRelativePath(shard)
 	if err != nil {
 		return err
 	}
@@ -1170,7 +1349,7 @@ func (s *Store) ExportShard(id uint64, start time.Time, end time.Time, w io.Writ
 		}
 	}
 
-	path, err := relativePath(s.path, shard.path)
+	path, err := s.shardRelativePath(shard)
 	if err != nil {
 		return err
 	}
@@ -1186,7 +1365,7 @@ func (s *Store) RestoreShard(id uint64, r io.Reader) error {
 		return fmt.Errorf("shard %d doesn't exist on this server", id)
 	}
 
-	path, err := relativePath(s.path, shard.path)
+	path, err := s.shardRelativePath(shard)
 	if err != nil {
 		return err
 	}
@@ -1204,7 +1383,7 @@ func (s *Store) ImportShard(id uint64, r io.Reader) error {
 		return fmt.Errorf("shard %d doesn't exist on this server", id)
 	}
 
-	path, err := relativePath(s.path, shard.path)
+	path, err := s.shardRelativePath(shard)
 	if err != nil {
 		return err
 	}
@@ -1219,7 +1398,46 @@ func (s *Store) ShardRelativePath(id uint64) (string, error) {
 	if shard == nil {
 		return "", fmt.Errorf("shard %d doesn't exist on this server", id)
 	}
-	return relativePath(s.path, shard.path)
+	return s.shardRelativePath(shard)
+}
+
+// shardRelativePath returns the path of a shard relative to the directory of
+// its tier.
+func (s *Store) shardRelativePath(shard *Shard) (string, error) {
+	root, path := s.path, shard.Path()
+	if coldDir := s.EngineOptions.Config.ColdDir; coldDir != "" {
+		if filepath.Dir(filepath.Dir(filepath.Dir(path))) == filepath.Clean(coldDir) {
+			root = coldDir
+		}
+	}
+	return relativePath(root, path)
+}
+
+// MoveShardToColdTier moves the files of a shard to the cold directory of the
+// store. The shard is reopened from there as read-only.
+func (s *Store) MoveShardToColdTier(id uint64) error {
+	coldDir := s.EngineOptions.Config.ColdDir
+	if coldDir == "" {
+		return ErrColdTierDisabled
+	}
+
+	shard := s.Shard(id)
+	if shard == nil {
+		return &errors2.Error{
+			Code: errors2.ENotFound,
+			Msg:  fmt.Sprintf("shard %d not found", id),
+		}
+	}
+
+	path := filepath.Join(coldDir, shard.database, shard.retentionPolicy, strconv.FormatUint(id, 10))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := shard.moveTo(path); err != nil {
+		return err
+	}
+	s.Logger.Info("Moved shard to the cold tier", logger.Shard(id), zap.String("path", path))
+	return nil
 }
 
 // DeleteSeries loops through the local shards and deletes the series data for
@@ -1969,6 +2187,7 @@ func mergeTagValues(valueIdxs [][2]int, tvs ...tagValues) TagValues {
 func (s *Store) monitorShards() {
 	t := time.NewTicker(10 * time.Second)
 	defer t.Stop()
+	budget := newMemoryBudget(int64(s.EngineOptions.Config.BucketMemoryBudget))
 	for {
 		select {
 		case <-s.closing:
@@ -1987,10 +2206,104 @@ func (s *Store) monitorShards() {
 				}
 			}
 			s.mu.RUnlock()
+
+			s.enforceMemoryBudget(budget)
 		}
 	}
 }
 
+// memoryBudget decides when to free the memory of the shards of a database
+// using more heap memory than the bucket memory budget. The budget applies to
+// each database on its own.
+//
+// Part of the memory of a shard, such as the series of its index, can't be
+// freed, so a database may stay over budget once its shards are freed. Its
+// shards are only freed again once its usage grew by a tenth of the budget
+// since, so that they don't write a small cache to a new TSM file every time
+// they are checked.
+type memoryBudget struct {
+	budget int64
+	// freed is the usage of each database over budget when its shards were
+	// last freed.
+	freed map[string]int64
+}
+
+func newMemoryBudget(budget int64) *memoryBudget {
+	return &memoryBudget{budget: budget, freed: make(map[string]int64)}
+}
+
+// exceeded reports whether the shards of db, which use n bytes of heap
+// memory, must be freed.
+func (b *memoryBudget) exceeded(db string, n int64) bool {
+	if b.budget <= 0 {
+		return false
+	}
+	if n <= b.budget {
+		delete(b.freed, db)
+		return false
+	}
+	if freed, ok := b.freed[db]; ok && n < freed+b.budget/10 {
+		return false
+	}
+	b.freed[db] = n
+	return true
+}
+
+// enforceMemoryBudget frees the memory of the shards of the databases whose
+// shards use more heap memory than the bucket memory budget.
+func (s *Store) enforceMemoryBudget(budget *memoryBudget) {
+	if budget.budget <= 0 {
+		return
+	}
+
+	usage := make(map[string]int64)
+	shards := make(map[string][]*Shard)
+	s.mu.RLock()
+	for _, sh := range s.shards {
+		u, err := sh.MemoryUsage()
+		if err != nil {
+			continue
+		}
+		usage[sh.database] += u.Heap()
+		shards[sh.database] = append(shards[sh.database], sh)
+	}
+	s.mu.RUnlock()
+
+	// Forget the databases that were deleted.
+	for db := range budget.freed {
+		if _, ok := usage[db]; !ok {
+			delete(budget.freed, db)
+		}
+	}
+	for db, n := range usage {
+		if !budget.exceeded(db, n) {
+			continue
+		}
+
+		s.Logger.Warn("Bucket exceeds its memory budget, freeing the memory of its shards",
+			logger.Database(db),
+			zap.Int64("bytes", n),
+			zap.Int64("budget", budget.budget))
+		for _, sh := range shards[db] {
+			if err := sh.FreeMemory(); err != nil {
+				s.Logger.Warn("Error while freeing shard memory",
+					zap.Error(err),
+					logger.Shard(sh.ID()))
+			}
+		}
+	}
+}
+
+// SeriesFileMemoryUsage estimates the memory used by the series file of a
+// database, in bytes.
+func (s *Store) SeriesFileMemoryUsage(database string) SeriesFileMemoryUsage {
+	sfile := s.seriesFile(database)
+	if sfile == nil {
+		return SeriesFileMemoryUsage{}
+	}
+	return sfile.MemoryUsage()
+}
+
 // KeyValue holds a string key and a string value.
 type KeyValue struct {
 	Key, Value string
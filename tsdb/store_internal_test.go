@@ -165,3 +165,38 @@ func createTagValues(mname string, kvs map[string][]string) TagValues {
 
 	return out
 }
+
+func TestMemoryBudget_exceeded(t *testing.T) {
+	b := newMemoryBudget(1000)
+
+	steps := []struct {
+		db   string
+		n    int64
+		want bool
+	}{
+		{db: "db0", n: 900, want: false},
+		{db: "db0", n: 1200, want: true},
+		// The shards stay over budget once freed, and are not freed again
+		// until the usage grew by a tenth of the budget.
+		{db: "db0", n: 1050, want: false},
+		{db: "db0", n: 1050, want: false},
+		{db: "db0", n: 1250, want: false},
+		{db: "db0", n: 1300, want: true},
+		{db: "db0", n: 1300, want: false},
+		// Each database has its own budget.
+		{db: "db1", n: 1100, want: true},
+		{db: "db1", n: 1100, want: false},
+		// Going under budget resets the database.
+		{db: "db0", n: 800, want: false},
+		{db: "db0", n: 1001, want: true},
+	}
+	for i, step := range steps {
+		if got := b.exceeded(step.db, step.n); got != step.want {
+			t.Fatalf("step %d: exceeded(%s, %d) = %t, want %t", i, step.db, step.n, got, step.want)
+		}
+	}
+
+	if newMemoryBudget(0).exceeded("db0", 1<<40) {
+		t.Fatal("a budget of 0 must never be exceeded")
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -747,6 +749,238 @@ func TestStore_BackupRestoreShard(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_MoveShardToColdTier(t *testing.T) {
+	test := func(t *testing.T, index string) {
+		coldDir, err := ioutil.TempDir("", "influxdb-tsdb-cold-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(coldDir)
+
+		s := NewStore(t, index)
+		s.EngineOptions.Config.ColdDir = coldDir
+		if err := s.Open(); err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		s.MustCreateShardWithData("db0", "rp0", 1,
+			`cpu value=1 0`,
+			`cpu value=2 10`,
+		)
+		s.MustCreateShardWithData("db0", "rp0", 2,
+			`cpu value=3 20`,
+		)
+
+		hotPath := filepath.Join(s.Path(), "db0", "rp0", "1")
+		coldPath := filepath.Join(coldDir, "db0", "rp0", "1")
+		checkCold := func(t *testing.T) {
+			t.Helper()
+
+			if sh := s.Shard(1); !sh.ReadOnly() {
+				t.Fatal("expected shard 1 to be read-only")
+			} else if sh.Path() != coldPath {
+				t.Fatalf("unexpected path of shard 1: %s", sh.Path())
+			} else if dirExists(hotPath) {
+				t.Fatalf("expected %s to be removed", hotPath)
+			} else if s.Shard(2).ReadOnly() {
+				t.Fatal("expected shard 2 not to be read-only")
+			}
+			if got, exp := readShardValues(t, s.Shard(1)), []float64{1, 2}; !reflect.DeepEqual(got, exp) {
+				t.Fatalf("unexpected values: got %v, exp %v", got, exp)
+			}
+		}
+
+		if err := s.MoveShardToColdTier(1); err != nil {
+			t.Fatal(err)
+		}
+		checkCold(t)
+
+		// Moving a shard twice is a no-op, and cold shards reject writes.
+		if err := s.MoveShardToColdTier(1); err != nil {
+			t.Fatal(err)
+		}
+		points, err := models.ParsePointsString(`cpu value=4 30`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.WriteToShard(1, points); err != tsdb.ErrShardReadOnly {
+			t.Fatalf("unexpected error: got %v, exp %v", err, tsdb.ErrShardReadOnly)
+		}
+
+		// The shard is loaded from the cold tier on open. A primary copy
+		// left by an interrupted move is removed, as is an incomplete copy on
+		// the cold tier.
+		if err := os.MkdirAll(hotPath, 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(coldDir, "db0", "rp0", "2.tmp"), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Reopen(t); err != nil {
+			t.Fatal(err)
+		}
+		checkCold(t)
+		if dirExists(filepath.Join(coldDir, "db0", "rp0", "2.tmp")) {
+			t.Fatal("expected incomplete cold shard to be removed")
+		}
+
+		// Deleting the shard removes it from the cold tier.
+		if err := s.DeleteShard(1); err != nil {
+			t.Fatal(err)
+		} else if dirExists(coldPath) {
+			t.Fatalf("expected %s to be removed", coldPath)
+		}
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) { test(t, index) })
+	}
+}
+
+func TestStore_MoveShardToColdTier_Disabled(t *testing.T) {
+	s := MustOpenStore(t, tsdb.DefaultIndex)
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 1, `cpu value=1 0`)
+	if err := s.MoveShardToColdTier(1); err != tsdb.ErrColdTierDisabled {
+		t.Fatalf("unexpected error: got %v, exp %v", err, tsdb.ErrColdTierDisabled)
+	}
+}
+
+func TestStore_SetCompactionLimits(t *testing.T) {
+	s := NewStore(t, tsdb.DefaultIndex)
+	if _, err := s.CompactionLimits(); err != tsdb.ErrStoreClosed {
+		t.Fatalf("unexpected error: got %v, exp %v", err, tsdb.ErrStoreClosed)
+	}
+
+	s.EngineOptions.Config.MaxConcurrentCompactions = 1
+	s.EngineOptions.Config.CompactThroughput = 0
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	limits, err := s.CompactionLimits()
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := (tsdb.CompactionLimits{MaxConcurrent: 1}); limits != exp {
+		t.Fatalf("unexpected limits: got %+v, exp %+v", limits, exp)
+	}
+
+	// The burst is at least the throughput.
+	if _, err := s.SetCompactionLimits(tsdb.CompactionLimits{Throughput: 1024, MaxConcurrent: 1}); err != nil {
+		t.Fatal(err)
+	}
+	limits, err = s.CompactionLimits()
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := (tsdb.CompactionLimits{Throughput: 1024, ThroughputBurst: 1024, MaxConcurrent: 1}); limits != exp {
+		t.Fatalf("unexpected limits: got %+v, exp %+v", limits, exp)
+	}
+	if got, exp := s.EngineOptions.CompactionThroughputLimiter.Burst(), 1024; got != exp {
+		t.Fatalf("unexpected limiter burst: got %d, exp %d", got, exp)
+	}
+
+	for _, limits := range []tsdb.CompactionLimits{
+		{MaxConcurrent: 0},
+		{MaxConcurrent: runtime.GOMAXPROCS(0) + 1},
+		{Throughput: -1, MaxConcurrent: 1},
+	} {
+		if _, err := s.SetCompactionLimits(limits); err == nil {
+			t.Fatalf("expected error setting limits %+v", limits)
+		}
+	}
+}
+
+func TestStore_ApplyDurability(t *testing.T) {
+	durability := make(map[string]tsdb.Durability)
+	s := NewStore(t, tsdb.DefaultIndex)
+	s.EngineOptions.DurabilityPolicy = func(database, rp string) tsdb.Durability {
+		return durability[database]
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 1,
+		`cpu value=1 0`,
+		`cpu value=2 10`,
+	)
+	s.MustCreateShardWithData("db1", "rp0", 2,
+		`cpu value=3 20`,
+	)
+
+	walSegments := func(db string, id int) []string {
+		t.Helper()
+		segments, err := filepath.Glob(filepath.Join(s.EngineOptions.Config.WALDir, db, "rp0", strconv.Itoa(id), "*.wal"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return segments
+	}
+	if len(walSegments("db0", 1)) == 0 {
+		t.Fatal("expected WAL segments for shard 1")
+	}
+
+	// Disabling the WAL of db0 writes the WAL of shard 1 to a TSM file.
+	durability["db0"] = tsdb.Durability{Mode: tsdb.DurabilityNoWAL}
+	if err := s.ApplyDurability("db0", "rp0"); err != nil {
+		t.Fatal(err)
+	}
+	if segments := walSegments("db0", 1); len(segments) != 0 {
+		t.Fatalf("unexpected WAL segments for shard 1: %v", segments)
+	} else if len(walSegments("db1", 2)) == 0 {
+		t.Fatal("expected WAL segments for shard 2")
+	}
+
+	// Writes without a WAL are written to a TSM file when the store closes.
+	s.MustWriteToShardString(1, `cpu value=4 30`)
+	if err := s.Reopen(t); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := readShardValues(t, s.Shard(1)), []float64{1, 2, 4}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values: got %v, exp %v", got, exp)
+	} else if segments := walSegments("db0", 1); len(segments) != 0 {
+		t.Fatalf("unexpected WAL segments for shard 1: %v", segments)
+	}
+
+	durability["db0"] = tsdb.Durability{Mode: tsdb.DurabilityGroupCommit}
+	if err := s.ApplyDurability("db0", "rp0"); err == nil {
+		t.Fatal("expected error applying group commit without a fsync delay")
+	}
+}
+
+// readShardValues returns the values of the cpu measurement of a shard.
+func readShardValues(t *testing.T, sh *tsdb.Shard) []float64 {
+	t.Helper()
+
+	itr, err := sh.CreateIterator(context.Background(), &influxql.Measurement{Name: "cpu"}, query.IteratorOptions{
+		Expr:      influxql.MustParseExpr(`value`),
+		Ascending: true,
+		StartTime: influxql.MinTime,
+		EndTime:   influxql.MaxTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+
+	var values []float64
+	fitr := itr.(query.FloatIterator)
+	for {
+		p, err := fitr.Next()
+		if err != nil {
+			t.Fatal(err)
+		} else if p == nil {
+			return values
+		}
+		values = append(values, p.Value)
+	}
+}
+
 func TestStore_Shard_SeriesN(t *testing.T) {
 
 	test := func(t *testing.T, index string) error {
@@ -2358,9 +2592,13 @@ func (s *Store) Reopen(tb testing.TB) error {
 		return err
 	}
 
+	coldDir := s.EngineOptions.Config.ColdDir
+	durabilityPolicy := s.EngineOptions.DurabilityPolicy
 	s.Store = tsdb.NewStore(s.Path())
 	s.EngineOptions.IndexVersion = s.index
 	s.EngineOptions.Config.WALDir = filepath.Join(s.Path(), "wal")
+	s.EngineOptions.Config.ColdDir = coldDir
+	s.EngineOptions.DurabilityPolicy = durabilityPolicy
 	s.EngineOptions.Config.TraceLoggingEnabled = true
 	s.WithLogger(zaptest.NewLogger(tb))
 
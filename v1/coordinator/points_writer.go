@@ -305,7 +305,7 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 		go func(shard *meta.ShardInfo, database, retentionPolicy string, points []models.Point) {
 			err := w.writeToShard(shard, database, retentionPolicy, points)
 			if err == tsdb.ErrShardDeletion {
-				err = tsdb.PartialWriteError{Reason: fmt.Sprintf("shard %d is pending deletion", shard.ID), Dropped: len(points)}
+				err = droppedPointsError(tsdb.DropShardDeleted, fmt.Sprintf("shard %d is pending deletion", shard.ID), points)
 			}
 			ch <- err
 		}(shardMappings.Shards[shardID], database, retentionPolicy, points)
@@ -334,8 +334,11 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 		atomic.AddInt64(&w.stats.SubWriteDrop, dropped)
 	}
 
-	if err == nil && len(shardMappings.Dropped) > 0 {
-		err = tsdb.PartialWriteError{Reason: "points beyond retention policy", Dropped: len(shardMappings.Dropped)}
+	// Partial writes of the shards are combined, so every dropped point is
+	// reported to the caller.
+	var partial *tsdb.PartialWriteError
+	if len(shardMappings.Dropped) > 0 {
+		partial = tsdb.MergePartialWriteErrors(partial, droppedPointsError(tsdb.DropBeyondRetention, "points beyond retention policy", shardMappings.Dropped))
 	}
 	timeout := time.NewTimer(w.WriteTimeout)
 	defer timeout.Stop()
@@ -348,12 +351,27 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 			// return timeout error to caller
 			return ErrTimeout
 		case err := <-ch:
-			if err != nil {
+			if perr, ok := err.(tsdb.PartialWriteError); ok {
+				partial = tsdb.MergePartialWriteErrors(partial, perr)
+			} else if err != nil {
 				return err
 			}
 		}
 	}
-	return err
+	if partial != nil {
+		return *partial
+	}
+	return nil
+}
+
+// droppedPointsError returns the partial write error of points dropped for
+// the cause, such as being outside of the retention period of the bucket.
+func droppedPointsError(cause tsdb.DropCause, reason string, points []models.Point) tsdb.PartialWriteError {
+	dropped := make([]tsdb.DroppedPoint, 0, len(points))
+	for _, p := range points {
+		dropped = append(dropped, tsdb.DroppedPoint{Point: p, Cause: cause, Reason: reason})
+	}
+	return tsdb.PartialWriteError{Reason: reason, Dropped: len(points), DroppedPoints: dropped}
 }
 
 // writeToShards writes points to a shard.
@@ -423,8 +423,51 @@ func TestPointsWriter_WritePoints_Dropped(t *testing.T) {
 	defer c.Close()
 
 	err := c.WritePointsPrivileged(pr.Database, pr.RetentionPolicy, models.ConsistencyLevelOne, pr.Points)
-	if _, ok := err.(tsdb.PartialWriteError); !ok {
-		t.Errorf("PointsWriter.WritePoints(): got %v, exp %v", err, tsdb.PartialWriteError{})
+	perr, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		t.Fatalf("PointsWriter.WritePoints(): got %v, exp %v", err, tsdb.PartialWriteError{})
+	}
+	if len(perr.DroppedPoints) != 1 || perr.DroppedPoints[0].Point != pr.Points[0] || perr.DroppedPoints[0].Cause != tsdb.DropBeyondRetention {
+		t.Errorf("PointsWriter.WritePoints(): unexpected dropped points %v", perr.DroppedPoints)
+	}
+}
+
+func TestPointsWriter_WritePoints_ShardDeleted(t *testing.T) {
+	pr := &coordinator.WritePointsRequest{
+		Database:        "mydb",
+		RetentionPolicy: "myrp",
+	}
+
+	ms := NewPointsWriterMetaClient()
+	rp, _ := ms.RetentionPolicy(pr.Database, pr.RetentionPolicy)
+	pr.AddPoint("cpu", 1.0, rp.ShardGroups[0].StartTime, nil)
+	ms.DatabaseFn = func(database string) *meta.DatabaseInfo {
+		return nil
+	}
+	ms.NodeIDFn = func() uint64 { return 1 }
+
+	// The shard the point maps to is being deleted.
+	store := &fakeStore{
+		WriteFn: func(shardID uint64, points []models.Point) error {
+			return tsdb.ErrShardDeletion
+		},
+	}
+
+	c := coordinator.NewPointsWriter()
+	c.MetaClient = ms
+	c.TSDBStore = store
+	c.Node = &influxdb.Node{ID: 1}
+
+	c.Open()
+	defer c.Close()
+
+	err := c.WritePointsPrivileged(pr.Database, pr.RetentionPolicy, models.ConsistencyLevelOne, pr.Points)
+	perr, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		t.Fatalf("PointsWriter.WritePoints(): got %v, exp %v", err, tsdb.PartialWriteError{})
+	}
+	if len(perr.DroppedPoints) != 1 || perr.DroppedPoints[0].Point != pr.Points[0] || perr.DroppedPoints[0].Cause != tsdb.DropShardDeleted {
+		t.Errorf("PointsWriter.WritePoints(): unexpected dropped points %v", perr.DroppedPoints)
 	}
 }
 
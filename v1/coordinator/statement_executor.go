@@ -16,6 +16,7 @@ import (
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/tracing"
 	"github.com/influxdata/influxdb/v2/pkg/tracing/fields"
+	"github.com/influxdata/influxdb/v2/query/registry"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	"github.com/influxdata/influxql"
@@ -37,6 +38,9 @@ type StatementExecutor struct {
 
 	DBRP influxdb.DBRPMappingServiceV2
 
+	// Registry holds the running queries for SHOW QUERIES and KILL QUERY.
+	Registry *registry.Registry
+
 	// Select statement limits
 	MaxSelectPointN   int
 	MaxSelectSeriesN  int
@@ -130,8 +134,10 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 		rows, err = nil, iql.ErrNotImplemented("SHOW USERS")
 	case *influxql.SetPasswordUserStatement:
 		err = iql.ErrNotImplemented("SET PASSWORD")
-	case *influxql.ShowQueriesStatement, *influxql.KillQueryStatement:
-		err = iql.ErrNotImplemented("SHOW QUERIES")
+	case *influxql.ShowQueriesStatement:
+		rows, err = e.executeShowQueriesStatement(ctx, stmt, ectx)
+	case *influxql.KillQueryStatement:
+		err = e.executeKillQueryStatement(ctx, stmt, ectx)
 	default:
 		return query.ErrInvalidQuery
 	}
@@ -146,6 +152,53 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 	})
 }
 
+func (e *StatementExecutor) executeShowQueriesStatement(ctx context.Context, q *influxql.ShowQueriesStatement, ectx *query.ExecutionContext) (models.Rows, error) {
+	if e.Registry == nil {
+		return nil, iql.ErrNotImplemented("SHOW QUERIES")
+	}
+
+	row := &models.Row{Columns: []string{"qid", "query", "database", "duration", "status"}}
+	now := time.Now()
+	for _, rq := range e.Registry.Queries(queriesFilter(ctx, ectx)) {
+		row.Values = append(row.Values, []interface{}{
+			rq.ID,
+			rq.Query,
+			rq.Database,
+			now.Sub(rq.StartTime).Round(time.Millisecond).String(),
+			rq.Status,
+		})
+	}
+	return models.Rows{row}, nil
+}
+
+func (e *StatementExecutor) executeKillQueryStatement(ctx context.Context, q *influxql.KillQueryStatement, ectx *query.ExecutionContext) error {
+	if e.Registry == nil {
+		return iql.ErrNotImplemented("KILL QUERY")
+	}
+	if q.Host != "" {
+		return errors.New("killing queries on a specific host is not supported")
+	}
+
+	// Killing the queries of an organization requires permission to write it.
+	filter := queriesFilter(ctx, ectx)
+	if filter.OrgID != nil {
+		if _, _, err := authorizer.AuthorizeWriteOrg(ctx, *filter.OrgID); err != nil {
+			return err
+		}
+	}
+	return e.Registry.Kill(q.QueryID, filter)
+}
+
+// queriesFilter restricts SHOW QUERIES and KILL QUERY to the queries of the
+// organization of the caller, unless the caller is an operator.
+func queriesFilter(ctx context.Context, ectx *query.ExecutionContext) registry.Filter {
+	if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err == nil {
+		return registry.Filter{}
+	}
+	orgID := ectx.OrgID
+	return registry.Filter{OrgID: &orgID}
+}
+
 func (e *StatementExecutor) executeExplainStatement(ctx context.Context, q *influxql.ExplainStatement, ectx *query.ExecutionContext) (models.Rows, error) {
 	opt := query.SelectOptions{
 		OrgID:       ectx.OrgID,
@@ -22,6 +22,7 @@ import (
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/internal"
 	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query/registry"
 	itesting "github.com/influxdata/influxdb/v2/testing"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/influxdata/influxdb/v2/v1/coordinator"
@@ -387,6 +388,127 @@ func TestQueryExecutor_ExecuteQuery_ShowDatabases(t *testing.T) {
 	}
 }
 
+func TestQueryExecutor_ExecuteQuery_ShowQueries(t *testing.T) {
+	orgID := platform.ID(0xff00)
+	otherOrgID := platform.ID(0xff01)
+
+	reg := registry.New()
+	_, done := reg.Register(registry.Query{
+		OrgID:    orgID,
+		Language: registry.LanguageFlux,
+		Query:    `from(bucket: "b")`,
+	}, func() {}, nil)
+	defer done()
+	_, otherDone := reg.Register(registry.Query{
+		OrgID:    otherOrgID,
+		Language: registry.LanguageFlux,
+		Query:    `from(bucket: "other")`,
+	}, func() {}, nil)
+	defer otherDone()
+
+	qe := query.NewExecutor(zaptest.NewLogger(t), control.NewControllerMetrics([]string{}))
+	qe.Registry = reg
+	qe.StatementExecutor = &coordinator.StatementExecutor{
+		Registry: reg,
+	}
+
+	q, err := influxql.ParseQuery("SHOW QUERIES")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{
+		ID:     orgID,
+		OrgID:  orgID,
+		Status: influxdb.Active,
+	})
+
+	results := ReadAllResults(qe.ExecuteQuery(ctx, q, query.ExecutionOptions{OrgID: orgID, Database: "db0"}))
+	if len(results) != 1 || results[0].Err != nil || len(results[0].Series) != 1 {
+		t.Fatalf("unexpected results: %s", spew.Sdump(results))
+	}
+
+	// The SHOW QUERIES statement is running as well, the query of the other org is not visible.
+	var got [][]interface{}
+	for _, v := range results[0].Series[0].Values {
+		got = append(got, []interface{}{v[1], v[2], v[4]})
+	}
+	exp := [][]interface{}{
+		{`from(bucket: "b")`, "", "running"},
+		{"SHOW QUERIES", "db0", "running"},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected queries: exp %s, got %s", spew.Sdump(exp), spew.Sdump(got))
+	}
+}
+
+func TestQueryExecutor_ExecuteQuery_KillQuery(t *testing.T) {
+	orgID := platform.ID(0xff00)
+	otherOrgID := platform.ID(0xff01)
+
+	reg := registry.New()
+	var killed bool
+	id, done := reg.Register(registry.Query{OrgID: orgID}, func() { killed = true }, nil)
+	defer done()
+	otherID, otherDone := reg.Register(registry.Query{OrgID: otherOrgID}, func() {
+		t.Fatal("the query of another org must not be killed")
+	}, nil)
+	defer otherDone()
+
+	qe := query.NewExecutor(zaptest.NewLogger(t), control.NewControllerMetrics([]string{}))
+	qe.StatementExecutor = &coordinator.StatementExecutor{
+		Registry: reg,
+	}
+
+	newCtx := func(action influxdb.Action) context.Context {
+		return icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{
+			ID:     orgID,
+			OrgID:  orgID,
+			Status: influxdb.Active,
+			Permissions: []influxdb.Permission{{
+				Action:   action,
+				Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &orgID},
+			}},
+		})
+	}
+	opt := query.ExecutionOptions{OrgID: orgID}
+
+	// A read-only token may not kill the queries of its org.
+	results := ReadAllResults(qe.ExecuteQuery(newCtx(influxdb.ReadAction), MustParseQuery(fmt.Sprintf("KILL QUERY %d", id)), opt))
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected an error killing a query with a read-only token: %s", spew.Sdump(results))
+	}
+	if killed {
+		t.Fatal("the query must not be killed with a read-only token")
+	}
+
+	// Neither may a token that can read all orgs kill the queries of another org.
+	readAll := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{
+		ID:          orgID,
+		OrgID:       orgID,
+		Status:      influxdb.Active,
+		Permissions: influxdb.ReadAllPermissions(),
+	})
+	results = ReadAllResults(qe.ExecuteQuery(readAll, MustParseQuery(fmt.Sprintf("KILL QUERY %d", otherID)), opt))
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected an error killing the query of another org: %s", spew.Sdump(results))
+	}
+
+	ctx := newCtx(influxdb.WriteAction)
+	results = ReadAllResults(qe.ExecuteQuery(ctx, MustParseQuery(fmt.Sprintf("KILL QUERY %d", otherID)), opt))
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected an error killing the query of another org: %s", spew.Sdump(results))
+	}
+
+	results = ReadAllResults(qe.ExecuteQuery(ctx, MustParseQuery(fmt.Sprintf("KILL QUERY %d", id)), opt))
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %s", spew.Sdump(results))
+	}
+	if !killed {
+		t.Fatal("expected the query to be killed")
+	}
+}
+
 // QueryExecutor is a test wrapper for coordinator.QueryExecutor.
 type QueryExecutor struct {
 	*query.Executor
@@ -0,0 +1,147 @@
+package graphite
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/toml"
+)
+
+const (
+	// DefaultBindAddress is the default binding interface if none is specified.
+	DefaultBindAddress = ":2003"
+
+	// DefaultDatabase is the default database, used as the bucket if none is
+	// specified.
+	DefaultDatabase = "graphite"
+
+	// DefaultProtocol is the default IP protocol used by the Graphite input.
+	DefaultProtocol = "tcp"
+
+	// DefaultSeparator is the default join character to use when joining multiple
+	// measurement parts in a template.
+	DefaultSeparator = "."
+
+	// DefaultBatchSize is the default write batch size.
+	DefaultBatchSize = 5000
+
+	// DefaultBatchTimeout is the default batch timeout.
+	DefaultBatchTimeout = time.Second
+)
+
+// Config represents the configuration of a Graphite input. It reads the
+// [[graphite]] sections of 1.x configuration files, with the org, bucket and
+// token the points are written with in addition. Settings of 1.x without
+// equivalent, such as consistency-level, are ignored.
+type Config struct {
+	Enabled       bool          `toml:"enabled"`
+	BindAddress   string        `toml:"bind-address"`
+	Database      string        `toml:"database"`
+	Protocol      string        `toml:"protocol"`
+	BatchSize     int           `toml:"batch-size"`
+	BatchTimeout  toml.Duration `toml:"batch-timeout"`
+	UDPReadBuffer int           `toml:"udp-read-buffer"`
+	Templates     []string      `toml:"templates"`
+	Tags          []string      `toml:"tags"`
+	Separator     string        `toml:"separator"`
+
+	// Org and Bucket are the names or IDs of the organization and bucket the
+	// points are written to, with the permissions of the token read from the
+	// environment variable TokenEnv or from the file TokenFile. The bucket
+	// defaults to the database. A token given in plain text is rejected.
+	Org       string `toml:"org"`
+	Bucket    string `toml:"bucket"`
+	Token     string `toml:"token"`
+	TokenEnv  string `toml:"token-env"`
+	TokenFile string `toml:"token-file"`
+}
+
+// NewConfig returns a new instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		BindAddress:  DefaultBindAddress,
+		Database:     DefaultDatabase,
+		Protocol:     DefaultProtocol,
+		BatchSize:    DefaultBatchSize,
+		BatchTimeout: toml.Duration(DefaultBatchTimeout),
+		Separator:    DefaultSeparator,
+	}
+}
+
+// WithDefaults takes the given config and returns a new config with any
+// required default values set.
+func (c Config) WithDefaults() Config {
+	d := c
+	if d.BindAddress == "" {
+		d.BindAddress = DefaultBindAddress
+	}
+	if d.Database == "" {
+		d.Database = DefaultDatabase
+	}
+	if d.Protocol == "" {
+		d.Protocol = DefaultProtocol
+	}
+	if d.BatchSize == 0 {
+		d.BatchSize = DefaultBatchSize
+	}
+	if d.BatchTimeout == 0 {
+		d.BatchTimeout = toml.Duration(DefaultBatchTimeout)
+	}
+	if d.Separator == "" {
+		d.Separator = DefaultSeparator
+	}
+	if d.Bucket == "" {
+		d.Bucket = d.Database
+	}
+	return d
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if _, err := c.ListenerConfig(); err != nil {
+		return err
+	}
+	if _, err := NewParser(c.Templates, c.Tags, c.Separator); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListenerConfig returns the configuration of the listener receiving the
+// Graphite data.
+func (c Config) ListenerConfig() (listener.Config, error) {
+	token, err := c.token()
+	if err != nil {
+		return listener.Config{}, fmt.Errorf("graphite %s: %w", c.BindAddress, err)
+	}
+	lc := listener.Config{
+		Protocol:     c.Protocol,
+		BindAddress:  c.BindAddress,
+		Org:          c.Org,
+		Bucket:       c.Bucket,
+		Token:        token,
+		Precision:    listener.DefaultPrecision,
+		BatchSize:    c.BatchSize,
+		BatchTimeout: time.Duration(c.BatchTimeout),
+	}
+	if c.Protocol == listener.ProtocolUDP {
+		lc.ReadBuffer = c.UDPReadBuffer
+	}
+	if err := lc.Validate(); err != nil {
+		return listener.Config{}, fmt.Errorf("graphite %s: %w", c.BindAddress, err)
+	}
+	if c.Separator == "" {
+		return listener.Config{}, errors.New("graphite: missing separator")
+	}
+	return lc, nil
+}
+
+// token reads the token of the configuration from TokenEnv or TokenFile.
+func (c Config) token() (string, error) {
+	if c.Token != "" {
+		return "", errors.New("the token must be given with token-env or token-file, not in plain text")
+	}
+	return listener.ReadToken(c.TokenEnv, c.TokenFile)
+}
@@ -0,0 +1,121 @@
+package graphite_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/v1/services/graphite"
+)
+
+func TestMain(m *testing.M) {
+	// The configurations of the tests read their token from the environment.
+	os.Setenv("GRAPHITE_TOKEN", "my-token")
+	os.Exit(m.Run())
+}
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	c := graphite.NewConfig()
+	if _, err := toml.Decode(`
+enabled = true
+bind-address = ":9000"
+database = "sensors"
+retention-policy = "autogen"
+protocol = "udp"
+batch-size = 100
+batch-pending = 10
+batch-timeout = "2s"
+consistency-level = "one"
+udp-read-buffer = 1048576
+templates = ["facility.* .room.measurement region=eu", "measurement.field"]
+tags = ["site=paris"]
+separator = "_"
+org = "my-org"
+token-env = "GRAPHITE_TOKEN"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+	c = c.WithDefaults()
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled: %v", c.Enabled)
+	} else if c.Bucket != "sensors" {
+		t.Fatalf("unexpected bucket: %s", c.Bucket)
+	} else if len(c.Templates) != 2 || c.Templates[0] != "facility.* .room.measurement region=eu" {
+		t.Fatalf("unexpected templates: %v", c.Templates)
+	} else if len(c.Tags) != 1 || c.Tags[0] != "site=paris" {
+		t.Fatalf("unexpected tags: %v", c.Tags)
+	} else if c.Separator != "_" {
+		t.Fatalf("unexpected separator: %s", c.Separator)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	lc, err := c.ListenerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := listener.Config{
+		Protocol:     listener.ProtocolUDP,
+		BindAddress:  ":9000",
+		Org:          "my-org",
+		Bucket:       "sensors",
+		Token:        "my-token",
+		Precision:    listener.DefaultPrecision,
+		BatchSize:    100,
+		BatchTimeout: 2 * time.Second,
+		ReadBuffer:   1048576,
+	}
+	if lc != want {
+		t.Fatalf("unexpected listener config:\n got %+v\nwant %+v", lc, want)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := graphite.Config{Org: "my-org", TokenEnv: "GRAPHITE_TOKEN"}.WithDefaults()
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, c := range []graphite.Config{
+		func() graphite.Config { c := valid; c.TokenEnv = ""; return c }(),
+		func() graphite.Config { c := valid; c.TokenEnv = "GRAPHITE_MISSING_TOKEN"; return c }(),
+		func() graphite.Config { c := valid; c.Token = "my-token"; return c }(),
+		func() graphite.Config { c := valid; c.TokenFile = "token"; return c }(),
+		func() graphite.Config { c := valid; c.Protocol = "http"; return c }(),
+		func() graphite.Config { c := valid; c.Templates = []string{"host.field"}; return c }(),
+		func() graphite.Config { c := valid; c.Tags = []string{"region"}; return c }(),
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", c)
+		}
+	}
+}
+
+func TestConfig_TokenFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graphite-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("my-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := graphite.Config{Org: "my-org", TokenFile: path}.WithDefaults()
+	lc, err := c.ListenerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc.Token != "my-token" {
+		t.Fatalf("unexpected token: %q", lc.Token)
+	}
+}
@@ -0,0 +1,339 @@
+// Package graphite converts the Graphite plaintext protocol into points,
+// mapping metric names to measurements, tags and fields with templates.
+package graphite // import "github.com/influxdata/influxdb/v2/v1/services/graphite"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+var (
+	// MinDate is the minimum timestamp of the points parsed.
+	MinDate = time.Date(1901, 12, 13, 0, 0, 0, 0, time.UTC)
+
+	// MaxDate is the maximum timestamp of the points parsed.
+	MaxDate = time.Date(2038, 1, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// Parser encapsulates a Graphite Parser.
+type Parser struct {
+	matcher *matcher
+	tags    models.Tags
+}
+
+// NewParser returns a Graphite parser for the given templates, default tags
+// and separator joining the parts of measurements, tags and fields.
+//
+// Templates have the form "[filter] template [tags]", as in 1.x. The filter
+// selects the metric names the template applies to, and the template maps the
+// parts of the name to the measurement, tags and field of the points:
+//
+//	servers.* .host.measurement.field* region=us-west
+//
+// Names without a matching template are stored as measurements with a field
+// named value.
+func NewParser(templates []string, defaultTags []string, separator string) (*Parser, error) {
+	tags := make(map[string]string)
+	for _, t := range defaultTags {
+		k, v, err := parseTag(t)
+		if err != nil {
+			return nil, err
+		}
+		tags[k] = v
+	}
+
+	m := newMatcher()
+	for _, pattern := range templates {
+		filter, tmpl, err := parseTemplate(pattern, separator)
+		if err != nil {
+			return nil, err
+		}
+		m.add(filter, tmpl)
+	}
+	if m.defaultTemplate == nil {
+		tmpl, err := newTemplate("measurement*", nil, separator)
+		if err != nil {
+			return nil, err
+		}
+		m.defaultTemplate = tmpl
+	}
+
+	return &Parser{matcher: m, tags: models.NewTags(tags)}, nil
+}
+
+// Parse parses the lines of buf, and returns the points of the lines that
+// parse along with the errors of the lines that do not.
+func (p *Parser) Parse(buf []byte, now time.Time) ([]models.Point, error) {
+	var (
+		points []models.Point
+		errs   []string
+	)
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		pt, err := p.ParseLine(line, now)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		points = append(points, pt)
+	}
+	if len(errs) > 0 {
+		return points, errors.New(strings.Join(errs, "\n"))
+	}
+	return points, nil
+}
+
+// ParseLine parses a single line of the form "name value [timestamp]". The
+// timestamp is in seconds, it defaults to now when it is missing or -1.
+func (p *Parser) ParseLine(line string, now time.Time) (models.Point, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("received %q which doesn't have required fields", line)
+	}
+
+	measurement, tags, field, err := p.matcher.match(fields[0]).apply(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	if measurement == "" {
+		measurement = fields[0]
+	}
+	if field == "" {
+		field = "value"
+	}
+
+	v, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf(`field "%s" value: %s`, fields[0], err)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf(`field "%s" value: %v is unsupported`, fields[0], v)
+	}
+
+	timestamp := now
+	if len(fields) == 3 {
+		unixTime, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf(`field "%s" time: %s`, fields[0], err)
+		}
+
+		// -1 is a special value that gets converted to current UTC time
+		// See https://github.com/graphite-project/carbon/issues/54
+		if unixTime != -1 {
+			timestamp = time.Unix(int64(unixTime), int64((unixTime-math.Floor(unixTime))*float64(time.Second)))
+			if timestamp.Before(MinDate) || timestamp.After(MaxDate) {
+				return nil, fmt.Errorf(`field "%s" time: timestamp out of range`, fields[0])
+			}
+		}
+	}
+
+	// Tags of the template override the default tags of the parser.
+	pt := make(map[string]string, len(p.tags)+len(tags))
+	for _, t := range p.tags {
+		pt[string(t.Key)] = string(t.Value)
+	}
+	for k, v := range tags {
+		pt[k] = v
+	}
+
+	return models.NewPoint(measurement, models.NewTags(pt), models.Fields{field: v}, timestamp)
+}
+
+// template maps the parts of metric names to measurements, tags and fields.
+type template struct {
+	tags        []string
+	defaultTags map[string]string
+	separator   string
+}
+
+func parseTemplate(pattern, separator string) (filter string, tmpl *template, err error) {
+	var tags string
+	parts := strings.Fields(pattern)
+	switch len(parts) {
+	case 1:
+		pattern = parts[0]
+	case 2:
+		// The second part is either the template or its tags.
+		if strings.Contains(parts[1], "=") {
+			pattern, tags = parts[0], parts[1]
+		} else {
+			filter, pattern = parts[0], parts[1]
+		}
+	case 3:
+		filter, pattern, tags = parts[0], parts[1], parts[2]
+	default:
+		return "", nil, fmt.Errorf("invalid template format: %q", pattern)
+	}
+
+	if filter != "" {
+		for _, p := range strings.Split(filter, ".") {
+			if p == "" {
+				return "", nil, fmt.Errorf("invalid filter %q: empty part", filter)
+			}
+		}
+	}
+
+	defaultTags := make(map[string]string)
+	if tags != "" {
+		for _, t := range strings.Split(tags, ",") {
+			k, v, err := parseTag(t)
+			if err != nil {
+				return "", nil, err
+			}
+			defaultTags[k] = v
+		}
+	}
+
+	tmpl, err = newTemplate(pattern, defaultTags, separator)
+	return filter, tmpl, err
+}
+
+func newTemplate(pattern string, defaultTags map[string]string, separator string) (*template, error) {
+	tags := strings.Split(pattern, ".")
+	hasMeasurement := false
+	greedy := ""
+	for i, tag := range tags {
+		switch tag {
+		case "measurement":
+			hasMeasurement = true
+		case "measurement*", "field*":
+			if greedy != "" {
+				return nil, fmt.Errorf("either 'field*' or 'measurement*' can be used in each template (but not both together): %q", pattern)
+			}
+			if i != len(tags)-1 {
+				return nil, fmt.Errorf("%s must be the last part of template %q", tag, pattern)
+			}
+			greedy = tag
+			hasMeasurement = hasMeasurement || tag == "measurement*"
+		}
+	}
+	if !hasMeasurement {
+		return nil, fmt.Errorf("no measurement specified for template %q", pattern)
+	}
+	return &template{tags: tags, defaultTags: defaultTags, separator: separator}, nil
+}
+
+// apply extracts the measurement, tags and field of a metric name.
+func (t *template) apply(name string) (string, map[string]string, string, error) {
+	parts := strings.Split(name, ".")
+	var (
+		measurement []string
+		field       []string
+		tags        = make(map[string][]string)
+	)
+
+	for i, tag := range t.tags {
+		if i >= len(parts) {
+			break
+		}
+		switch tag {
+		case "":
+		case "measurement":
+			measurement = append(measurement, parts[i])
+		case "field":
+			field = append(field, parts[i])
+		case "measurement*":
+			measurement = append(measurement, parts[i:]...)
+		case "field*":
+			field = append(field, parts[i:]...)
+		default:
+			tags[tag] = append(tags[tag], parts[i])
+		}
+	}
+
+	out := make(map[string]string, len(t.defaultTags)+len(tags))
+	for k, v := range t.defaultTags {
+		out[k] = v
+	}
+	for k, values := range tags {
+		out[k] = strings.Join(values, t.separator)
+	}
+	return strings.Join(measurement, t.separator), out, strings.Join(field, t.separator), nil
+}
+
+// matcher determines which template applies to a metric name.
+type matcher struct {
+	root            *node
+	defaultTemplate *template
+}
+
+func newMatcher() *matcher {
+	return &matcher{root: &node{}}
+}
+
+// add adds a template for the names matching filter. The template without
+// filter applies to the names no other template matches.
+func (m *matcher) add(filter string, tmpl *template) {
+	if filter == "" {
+		m.defaultTemplate = tmpl
+		return
+	}
+	m.root.insert(strings.Split(filter, "."), tmpl)
+}
+
+// match returns the template of the most specific filter matching name.
+func (m *matcher) match(name string) *template {
+	if tmpl := m.root.search(strings.Split(name, ".")); tmpl != nil {
+		return tmpl
+	}
+	return m.defaultTemplate
+}
+
+// node is a part of the filters, in a tree of all the filters.
+type node struct {
+	value    string
+	children []*node
+	template *template
+}
+
+func (n *node) insert(parts []string, tmpl *template) {
+	if len(parts) == 0 {
+		n.template = tmpl
+		return
+	}
+	for _, c := range n.children {
+		if c.value == parts[0] {
+			c.insert(parts[1:], tmpl)
+			return
+		}
+	}
+
+	c := &node{value: parts[0]}
+	c.insert(parts[1:], tmpl)
+	// Wildcards are kept last, so that exact parts take precedence.
+	if c.value != "*" && len(n.children) > 0 && n.children[len(n.children)-1].value == "*" {
+		last := len(n.children) - 1
+		n.children = append(n.children[:last], c, n.children[last])
+		return
+	}
+	n.children = append(n.children, c)
+}
+
+func (n *node) search(parts []string) *template {
+	if len(parts) == 0 || len(n.children) == 0 {
+		return n.template
+	}
+	for _, c := range n.children {
+		if c.value == parts[0] || c.value == "*" {
+			return c.search(parts[1:])
+		}
+	}
+	return n.template
+}
+
+func parseTag(s string) (string, string, error) {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+		return "", "", fmt.Errorf("invalid tag %q, expected key=value", s)
+	}
+	return kv[0], kv[1], nil
+}
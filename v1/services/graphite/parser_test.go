@@ -0,0 +1,156 @@
+package graphite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/v1/services/graphite"
+)
+
+func TestParser_ParseLine(t *testing.T) {
+	now := time.Unix(1600000000, 0).UTC()
+	tests := []struct {
+		name      string
+		templates []string
+		tags      []string
+		separator string
+		line      string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "default template",
+			line: "facility.room1.temperature 21.5 1500000000",
+			want: "facility.room1.temperature value=21.5 1500000000000000000",
+		},
+		{
+			name:      "default template with separator",
+			separator: "_",
+			line:      "facility.room1.temperature 21.5 1500000000",
+			want:      "facility_room1_temperature value=21.5 1500000000000000000",
+		},
+		{
+			name:      "tags and field",
+			templates: []string{"facility.* .room.measurement.field"},
+			line:      "facility.room1.sensor.temperature 21.5 1500000000",
+			want:      "sensor,room=room1 temperature=21.5 1500000000000000000",
+		},
+		{
+			name:      "greedy field",
+			templates: []string{"measurement.host.field*"},
+			line:      "cpu.server01.load.shortterm 0.5 1500000000",
+			want:      "cpu,host=server01 load.shortterm=0.5 1500000000000000000",
+		},
+		{
+			name:      "greedy measurement",
+			templates: []string{"region.measurement*"},
+			separator: "_",
+			line:      "us.cpu.load 2 1500000000",
+			want:      "cpu_load,region=us value=2 1500000000000000000",
+		},
+		{
+			name:      "repeated tag is joined",
+			templates: []string{"host.host.measurement"},
+			line:      "server.01.cpu 2 1500000000",
+			want:      "cpu,host=server.01 value=2 1500000000000000000",
+		},
+		{
+			name:      "template and default tags",
+			templates: []string{"facility.* .room.measurement region=eu,site=paris"},
+			tags:      []string{"site=lyon", "dc=1"},
+			line:      "facility.room1.temperature 21.5 1500000000",
+			want:      "temperature,dc=1,region=eu,room=room1,site=paris value=21.5 1500000000000000000",
+		},
+		{
+			name: "exact filter takes precedence over wildcard",
+			templates: []string{
+				"facility.* .room.measurement",
+				"facility.pdu .measurement.outlet.field",
+			},
+			line: "facility.pdu.a1.current 3 1500000000",
+			want: "pdu,outlet=a1 current=3 1500000000000000000",
+		},
+		{
+			name: "unfiltered template applies to other names",
+			templates: []string{
+				"facility.* .room.measurement",
+				"measurement.host",
+			},
+			line: "cpu.server01 1 1500000000",
+			want: "cpu,host=server01 value=1 1500000000000000000",
+		},
+		{
+			name: "missing timestamp is now",
+			line: "cpu 1",
+			want: "cpu value=1 1600000000000000000",
+		},
+		{
+			name: "timestamp -1 is now",
+			line: "cpu 1 -1",
+			want: "cpu value=1 1600000000000000000",
+		},
+		{
+			name: "fractional timestamp",
+			line: "cpu 1 1500000000.5",
+			want: "cpu value=1 1500000000500000000",
+		},
+		{name: "missing value", line: "cpu", wantErr: true},
+		{name: "invalid value", line: "cpu abc 1500000000", wantErr: true},
+		{name: "NaN value", line: "cpu NaN 1500000000", wantErr: true},
+		{name: "invalid timestamp", line: "cpu 1 abc", wantErr: true},
+		{name: "timestamp out of range", line: "cpu 1 99999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			separator := tt.separator
+			if separator == "" {
+				separator = graphite.DefaultSeparator
+			}
+			p, err := graphite.NewParser(tt.templates, tt.tags, separator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := p.ParseLine(tt.line, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && pt.String() != tt.want {
+				t.Errorf("unexpected point:\n got %s\nwant %s", pt.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_Parse(t *testing.T) {
+	p, err := graphite.NewParser(nil, nil, graphite.DefaultSeparator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points, err := p.Parse([]byte("cpu 1 1500000000\ninvalid\n\nmem 2 1500000000\n"), time.Now())
+	if err == nil {
+		t.Error("expected an error for the invalid line")
+	}
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(points))
+	}
+}
+
+func TestNewParser_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		templates []string
+		tags      []string
+	}{
+		{templates: []string{"host.field"}},
+		{templates: []string{"measurement*.field*"}},
+		{templates: []string{"measurement*.host"}},
+		{templates: []string{"a..b measurement"}},
+		{templates: []string{"a.* measurement region"}},
+		{templates: []string{"a.* measurement region=eu extra"}},
+		{tags: []string{"region"}},
+	} {
+		if _, err := graphite.NewParser(tt.templates, tt.tags, graphite.DefaultSeparator); err == nil {
+			t.Errorf("expected an error for templates %q and tags %q", tt.templates, tt.tags)
+		}
+	}
+}
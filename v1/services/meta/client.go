@@ -673,6 +673,18 @@ func (c *Client) DropShard(id uint64) error {
 	return c.commit(data)
 }
 
+// SetShardTier sets the storage tier of a shard.
+func (c *Client) SetShardTier(id uint64, tier string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+	if err := data.SetShardTier(id, tier); err != nil {
+		return err
+	}
+	return c.commit(data)
+}
+
 // TruncateShardGroups truncates any shard group that could contain timestamps beyond t.
 func (c *Client) TruncateShardGroups(t time.Time) error {
 	c.mu.Lock()
@@ -783,6 +795,27 @@ func (c *Client) DeleteShardGroup(database, policy string, id uint64) error {
 	return nil
 }
 
+// ReplaceShardGroups deletes the shard groups ids of a database and retention
+// policy, and creates shard groups for the time ranges of groups in their
+// place, in a single update. It returns the shard groups created.
+func (c *Client) ReplaceShardGroups(database, policy string, ids []uint64, groups []ShardGroupInfo) ([]ShardGroupInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	created, err := data.ReplaceShardGroups(database, policy, ids, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.commit(data); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
 // PrecreateShardGroups creates shard groups whose endtime is before the 'to' time passed in, but
 // is yet to expire before 'from'. This is to avoid the need for these shards to be created when data
 // for the corresponding time range arrives. Shard creation involves Raft consensus, and precreation
@@ -969,6 +969,130 @@ func TestMetaClient_Shards(t *testing.T) {
 	}
 }
 
+func TestMetaClient_ShardTier(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig()
+	store := newStore()
+
+	c := meta.NewClient(cfg, store)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	coldDuration := 24 * time.Hour
+	if err := c.UpdateRetentionPolicy("db0", "autogen", &meta.RetentionPolicyUpdate{
+		ColdDuration: &coldDuration,
+	}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	sg, err := c.CreateShardGroup("db0", "autogen", time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shardID := sg.Shards[0].ID
+
+	// Set the tier of the shard and ensure it is kept after a restart.
+	if err := c.SetShardTier(shardID, meta.ShardTierCold); err != nil {
+		t.Fatal(err)
+	} else if err := c.SetShardTier(shardID+1000, meta.ShardTierCold); err != meta.ErrShardNotFound {
+		t.Fatalf("expected error '%s', got '%v'", meta.ErrShardNotFound, err)
+	}
+	c.Close()
+
+	c = meta.NewClient(cfg, store)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	rpi, err := c.RetentionPolicy("db0", "autogen")
+	if err != nil {
+		t.Fatal(err)
+	} else if rpi.ColdDuration != coldDuration {
+		t.Fatalf("cold duration wrong: \n\texp: %s\n\tgot: %s", coldDuration, rpi.ColdDuration)
+	} else if !rpi.ShardGroups[0].Shards[0].Cold() {
+		t.Fatalf("expected shard %d to be cold", shardID)
+	}
+
+	if groups := rpi.ColdShardGroups(sg.EndTime.Add(coldDuration - time.Second)); len(groups) != 0 {
+		t.Fatalf("wrong number of cold shard groups: %d", len(groups))
+	} else if groups := rpi.ColdShardGroups(sg.EndTime.Add(coldDuration + time.Second)); len(groups) != 1 {
+		t.Fatalf("wrong number of cold shard groups: %d", len(groups))
+	}
+
+	// A negative cold duration is rejected.
+	coldDuration = -time.Hour
+	if err := c.UpdateRetentionPolicy("db0", "autogen", &meta.RetentionPolicyUpdate{
+		ColdDuration: &coldDuration,
+	}, true); err != meta.ErrColdDurationInvalid {
+		t.Fatalf("expected error '%s', got '%v'", meta.ErrColdDurationInvalid, err)
+	}
+}
+
+func TestMetaClient_ReplaceShardGroups(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer d()
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create two 1 day shard groups to merge into a 2 day group.
+	sgDuration := 24 * time.Hour
+	if err := c.UpdateRetentionPolicy("db0", "autogen", &meta.RetentionPolicyUpdate{
+		ShardGroupDuration: &sgDuration,
+	}, true); err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint64
+	for i := 0; i < 2; i++ {
+		sg, err := c.CreateShardGroup("db0", "autogen", time.Unix(0, 0).Add(time.Duration(i)*sgDuration))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, sg.ID)
+	}
+
+	start, end := time.Unix(0, 0).UTC(), time.Unix(0, 0).Add(2*sgDuration).UTC()
+	groups := []meta.ShardGroupInfo{{StartTime: start, EndTime: end}}
+
+	// A group overlapping a group left is rejected.
+	if _, err := c.ReplaceShardGroups("db0", "autogen", ids[:1], groups); err != meta.ErrShardGroupExists {
+		t.Fatalf("expected error '%s', got '%v'", meta.ErrShardGroupExists, err)
+	} else if _, err := c.ReplaceShardGroups("db0", "autogen", []uint64{1000}, groups); err != meta.ErrShardGroupNotFound {
+		t.Fatalf("expected error '%s', got '%v'", meta.ErrShardGroupNotFound, err)
+	}
+
+	created, err := c.ReplaceShardGroups("db0", "autogen", ids, groups)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(created) != 1 || len(created[0].Shards) != 1 {
+		t.Fatalf("unexpected shard groups created: %v", created)
+	}
+
+	got, err := c.ShardGroupsByTimeRange("db0", "autogen", start, end)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(got) != 1 {
+		t.Fatalf("wrong number of shard groups: %d", len(got))
+	} else if got[0].ID != created[0].ID || !got[0].StartTime.Equal(start) || !got[0].EndTime.Equal(end) {
+		t.Fatalf("unexpected shard group: %v", got[0])
+	}
+
+	// The groups replaced are deleted, so they can't be replaced again.
+	if _, err := c.ReplaceShardGroups("db0", "autogen", ids, nil); err != meta.ErrShardGroupNotFound {
+		t.Fatalf("expected error '%s', got '%v'", meta.ErrShardGroupNotFound, err)
+	}
+}
+
 // Tests that calling CreateShardGroup for the same time range doesn't increment the data.Index
 func TestMetaClient_CreateShardGroupIdempotent(t *testing.T) {
 	t.Parallel()
@@ -1116,8 +1240,8 @@ func TestMetaClient_CreateShardGroupWithShards(t *testing.T) {
 	}
 
 	shards := []meta.ShardInfo{
-		{1, []meta.ShardOwner{{1}}},
-		{3, nil},
+		{ID: 1, Owners: []meta.ShardOwner{{1}}},
+		{ID: 3},
 	}
 	// create a shard group.
 	tmin := time.Now()
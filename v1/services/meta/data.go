@@ -37,6 +37,10 @@ const (
 	// MaxNameLen is the maximum length of a database or retention policy name.
 	// InfluxDB uses the name for the directory name on disk.
 	MaxNameLen = 255
+
+	// ShardTierCold is the value of ShardInfo.Tier for shards moved to the
+	// cold tier.
+	ShardTierCold = "cold"
 )
 
 // Data represents the top level collection of all metadata.
@@ -202,6 +206,10 @@ type RetentionPolicyUpdate struct {
 	Duration           *time.Duration
 	ReplicaN           *int
 	ShardGroupDuration *time.Duration
+	ColdDuration       *time.Duration
+	DurabilityMode     *string
+	FsyncDelay         *time.Duration
+	SnapshotInterval   *time.Duration
 }
 
 // SetName sets the RetentionPolicyUpdate.Name.
@@ -216,6 +224,18 @@ func (rpu *RetentionPolicyUpdate) SetReplicaN(v int) { rpu.ReplicaN = &v }
 // SetShardGroupDuration sets the RetentionPolicyUpdate.ShardGroupDuration.
 func (rpu *RetentionPolicyUpdate) SetShardGroupDuration(v time.Duration) { rpu.ShardGroupDuration = &v }
 
+// SetColdDuration sets the RetentionPolicyUpdate.ColdDuration.
+func (rpu *RetentionPolicyUpdate) SetColdDuration(v time.Duration) { rpu.ColdDuration = &v }
+
+// SetDurabilityMode sets the RetentionPolicyUpdate.DurabilityMode.
+func (rpu *RetentionPolicyUpdate) SetDurabilityMode(v string) { rpu.DurabilityMode = &v }
+
+// SetFsyncDelay sets the RetentionPolicyUpdate.FsyncDelay.
+func (rpu *RetentionPolicyUpdate) SetFsyncDelay(v time.Duration) { rpu.FsyncDelay = &v }
+
+// SetSnapshotInterval sets the RetentionPolicyUpdate.SnapshotInterval.
+func (rpu *RetentionPolicyUpdate) SetSnapshotInterval(v time.Duration) { rpu.SnapshotInterval = &v }
+
 // UpdateRetentionPolicy updates an existing retention policy.
 func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) error {
 	// Find database.
@@ -249,6 +269,14 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 		return ErrIncompatibleDurations
 	}
 
+	if rpu.ColdDuration != nil && *rpu.ColdDuration < 0 {
+		return ErrColdDurationInvalid
+	}
+
+	if (rpu.FsyncDelay != nil && *rpu.FsyncDelay < 0) || (rpu.SnapshotInterval != nil && *rpu.SnapshotInterval < 0) {
+		return ErrDurabilityDurationInvalid
+	}
+
 	// Update fields.
 	if rpu.Name != nil {
 		rpi.Name = *rpu.Name
@@ -262,6 +290,18 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 	if rpu.ShardGroupDuration != nil {
 		rpi.ShardGroupDuration = NormalisedShardDuration(*rpu.ShardGroupDuration, rpi.Duration)
 	}
+	if rpu.ColdDuration != nil {
+		rpi.ColdDuration = *rpu.ColdDuration
+	}
+	if rpu.DurabilityMode != nil {
+		rpi.DurabilityMode = *rpu.DurabilityMode
+	}
+	if rpu.FsyncDelay != nil {
+		rpi.FsyncDelay = *rpu.FsyncDelay
+	}
+	if rpu.SnapshotInterval != nil {
+		rpi.SnapshotInterval = *rpu.SnapshotInterval
+	}
 
 	if di.DefaultRetentionPolicy != rpi.Name && makeDefault {
 		di.DefaultRetentionPolicy = rpi.Name
@@ -302,6 +342,26 @@ func (data *Data) DropShard(id uint64) {
 	}
 }
 
+// SetShardTier sets the storage tier of a shard.
+func (data *Data) SetShardTier(id uint64, tier string) error {
+	for dbidx := range data.Databases {
+		dbi := &data.Databases[dbidx]
+		for rpidx := range dbi.RetentionPolicies {
+			rpi := &dbi.RetentionPolicies[rpidx]
+			for sgidx := range rpi.ShardGroups {
+				sgi := &rpi.ShardGroups[sgidx]
+				for sidx := range sgi.Shards {
+					if sgi.Shards[sidx].ID == id {
+						sgi.Shards[sidx].Tier = tier
+						return nil
+					}
+				}
+			}
+		}
+	}
+	return ErrShardNotFound
+}
+
 // ShardGroups returns a list of all shard groups on a database and retention policy.
 func (data *Data) ShardGroups(database, policy string) ([]ShardGroupInfo, error) {
 	// Find retention policy.
@@ -425,6 +485,59 @@ func (data *Data) DeleteShardGroup(database, policy string, id uint64) error {
 	return ErrShardGroupNotFound
 }
 
+// ReplaceShardGroups deletes the shard groups ids of a database and retention
+// policy, and creates a shard group with a new shard for the time range of
+// each of groups in their place. The groups created must not overlap the
+// groups left.
+func (data *Data) ReplaceShardGroups(database, policy string, ids []uint64, groups []ShardGroupInfo) ([]ShardGroupInfo, error) {
+	// Find retention policy.
+	rpi, err := data.RetentionPolicy(database, policy)
+	if err != nil {
+		return nil, err
+	} else if rpi == nil {
+		return nil, influxdb.ErrRetentionPolicyNotFound(policy)
+	}
+
+	deletedAt := time.Now().UTC()
+	for _, id := range ids {
+		var found bool
+		for i := range rpi.ShardGroups {
+			if sgi := &rpi.ShardGroups[i]; sgi.ID == id && !sgi.Deleted() {
+				sgi.DeletedAt = deletedAt
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrShardGroupNotFound
+		}
+	}
+
+	created := make([]ShardGroupInfo, 0, len(groups))
+	for _, g := range groups {
+		for i := range rpi.ShardGroups {
+			if sgi := &rpi.ShardGroups[i]; !sgi.Deleted() && sgi.Overlaps(g.StartTime, g.EndTime.Add(-1)) {
+				return nil, ErrShardGroupExists
+			}
+		}
+
+		data.MaxShardGroupID++
+		data.MaxShardID++
+		sgi := ShardGroupInfo{
+			ID:        data.MaxShardGroupID,
+			StartTime: g.StartTime.UTC(),
+			EndTime:   g.EndTime.UTC(),
+			Shards:    []ShardInfo{{ID: data.MaxShardID}},
+		}
+		rpi.ShardGroups = append(rpi.ShardGroups, sgi)
+		created = append(created, sgi)
+	}
+
+	// Shard groups must be stored in sorted order.
+	sort.Sort(ShardGroupInfos(rpi.ShardGroups))
+	return created, nil
+}
+
 // CreateContinuousQuery adds a named continuous query to a database.
 func (data *Data) CreateContinuousQuery(database, name, query string) error {
 	di := data.Database(database)
@@ -1139,6 +1252,17 @@ type RetentionPolicyInfo struct {
 	ShardGroupDuration time.Duration
 	ShardGroups        []ShardGroupInfo
 	Subscriptions      []SubscriptionInfo
+
+	// ColdDuration is how long after the end of a shard group its shards
+	// are moved to the cold tier. Zero keeps shards on the primary tier.
+	ColdDuration time.Duration
+
+	// DurabilityMode controls when writes to the shards are durable, with
+	// the fsync delay of the WAL or the snapshot interval of the cache the
+	// mode uses. An empty mode uses the configuration of the engine.
+	DurabilityMode   string
+	FsyncDelay       time.Duration
+	SnapshotInterval time.Duration
 }
 
 // NewRetentionPolicyInfo returns a new instance of RetentionPolicyInfo
@@ -1174,6 +1298,10 @@ func (rpi *RetentionPolicyInfo) Apply(spec *RetentionPolicySpec) *RetentionPolic
 		ReplicaN:           rpi.ReplicaN,
 		Duration:           rpi.Duration,
 		ShardGroupDuration: rpi.ShardGroupDuration,
+		ColdDuration:       rpi.ColdDuration,
+		DurabilityMode:     rpi.DurabilityMode,
+		FsyncDelay:         rpi.FsyncDelay,
+		SnapshotInterval:   rpi.SnapshotInterval,
 	}
 	if spec.Name != "" {
 		rp.Name = spec.Name
@@ -1215,6 +1343,20 @@ func (rpi *RetentionPolicyInfo) ExpiredShardGroups(t time.Time) []*ShardGroupInf
 	return groups
 }
 
+// ColdShardGroups returns the Shard Groups which should be on the cold tier, for the given time.
+func (rpi *RetentionPolicyInfo) ColdShardGroups(t time.Time) []*ShardGroupInfo {
+	var groups = make([]*ShardGroupInfo, 0)
+	for i := range rpi.ShardGroups {
+		if rpi.ShardGroups[i].Deleted() {
+			continue
+		}
+		if rpi.ColdDuration != 0 && rpi.ShardGroups[i].EndTime.Add(rpi.ColdDuration).Before(t) {
+			groups = append(groups, &rpi.ShardGroups[i])
+		}
+	}
+	return groups
+}
+
 // DeletedShardGroups returns the Shard Groups which are marked as deleted.
 func (rpi *RetentionPolicyInfo) DeletedShardGroups() []*ShardGroupInfo {
 	var groups = make([]*ShardGroupInfo, 0)
@@ -1234,6 +1376,18 @@ func (rpi *RetentionPolicyInfo) marshal() *internal.RetentionPolicyInfo {
 		Duration:           proto.Int64(int64(rpi.Duration)),
 		ShardGroupDuration: proto.Int64(int64(rpi.ShardGroupDuration)),
 	}
+	if rpi.ColdDuration != 0 {
+		pb.ColdDuration = proto.Int64(int64(rpi.ColdDuration))
+	}
+	if rpi.DurabilityMode != "" {
+		pb.DurabilityMode = proto.String(rpi.DurabilityMode)
+	}
+	if rpi.FsyncDelay != 0 {
+		pb.FsyncDelay = proto.Int64(int64(rpi.FsyncDelay))
+	}
+	if rpi.SnapshotInterval != 0 {
+		pb.SnapshotInterval = proto.Int64(int64(rpi.SnapshotInterval))
+	}
 
 	pb.ShardGroups = make([]*internal.ShardGroupInfo, len(rpi.ShardGroups))
 	for i, sgi := range rpi.ShardGroups {
@@ -1254,6 +1408,10 @@ func (rpi *RetentionPolicyInfo) unmarshal(pb *internal.RetentionPolicyInfo) {
 	rpi.ReplicaN = int(pb.GetReplicaN())
 	rpi.Duration = time.Duration(pb.GetDuration())
 	rpi.ShardGroupDuration = time.Duration(pb.GetShardGroupDuration())
+	rpi.ColdDuration = time.Duration(pb.GetColdDuration())
+	rpi.DurabilityMode = pb.GetDurabilityMode()
+	rpi.FsyncDelay = time.Duration(pb.GetFsyncDelay())
+	rpi.SnapshotInterval = time.Duration(pb.GetSnapshotInterval())
 
 	if len(pb.GetShardGroups()) > 0 {
 		rpi.ShardGroups = make([]ShardGroupInfo, len(pb.GetShardGroups()))
@@ -1459,6 +1617,15 @@ func (sgi *ShardGroupInfo) unmarshal(pb *internal.ShardGroupInfo) {
 type ShardInfo struct {
 	ID     uint64
 	Owners []ShardOwner
+
+	// Tier is the storage tier holding the files of the shard. An empty
+	// tier is the primary tier.
+	Tier string
+}
+
+// Cold returns true if the shard was moved to the cold tier.
+func (si ShardInfo) Cold() bool {
+	return si.Tier == ShardTierCold
 }
 
 // OwnedBy determines whether the shard's owner IDs includes nodeID.
@@ -1490,6 +1657,9 @@ func (si ShardInfo) marshal() *internal.ShardInfo {
 	pb := &internal.ShardInfo{
 		ID: proto.Uint64(si.ID),
 	}
+	if si.Tier != "" {
+		pb.Tier = proto.String(si.Tier)
+	}
 
 	pb.Owners = make([]*internal.ShardOwner, len(si.Owners))
 	for i := range si.Owners {
@@ -1512,6 +1682,7 @@ func (si *ShardInfo) UnmarshalBinary(buf []byte) error {
 // unmarshal deserializes from a protobuf representation.
 func (si *ShardInfo) unmarshal(pb *internal.ShardInfo) {
 	si.ID = pb.GetID()
+	si.Tier = pb.GetTier()
 
 	// If deprecated "OwnerIDs" exists then convert it to "Owners" format.
 	//lint:ignore SA1019 we need to check for the presence of the deprecated field so we can convert it
@@ -66,6 +66,14 @@ var (
 	// duration.
 	ErrIncompatibleDurations = errors.New("retention policy duration must be greater than the shard duration")
 
+	// ErrColdDurationInvalid is returned when updating a retention policy
+	// with a negative cold duration.
+	ErrColdDurationInvalid = errors.New("retention policy cold duration must not be negative")
+
+	// ErrDurabilityDurationInvalid is returned when updating a retention
+	// policy with a negative fsync delay or snapshot interval.
+	ErrDurabilityDurationInvalid = errors.New("retention policy fsync delay and snapshot interval must not be negative")
+
 	// ErrReplicationFactorTooLow is returned when the replication factor is not in an
 	// acceptable range.
 	ErrReplicationFactorTooLow = errors.New("replication factor must be greater than 0")
@@ -78,6 +86,9 @@ var (
 	// ErrShardGroupNotFound is returned when mutating a shard group that doesn't exist.
 	ErrShardGroupNotFound = errors.New("shard group not found")
 
+	// ErrShardNotFound is returned when mutating a shard that doesn't exist.
+	ErrShardNotFound = errors.New("shard not found")
+
 	// ErrShardNotReplicated is returned if the node requested to be dropped has
 	// the last copy of a shard present and the force keyword was not used
 	ErrShardNotReplicated = errors.New("shard not replicated")
@@ -445,6 +445,10 @@ type RetentionPolicyInfo struct {
 	ReplicaN             *uint32             `protobuf:"varint,4,req,name=ReplicaN" json:"ReplicaN,omitempty"`
 	ShardGroups          []*ShardGroupInfo   `protobuf:"bytes,5,rep,name=ShardGroups" json:"ShardGroups,omitempty"`
 	Subscriptions        []*SubscriptionInfo `protobuf:"bytes,6,rep,name=Subscriptions" json:"Subscriptions,omitempty"`
+	ColdDuration         *int64              `protobuf:"varint,7,opt,name=ColdDuration" json:"ColdDuration,omitempty"`
+	DurabilityMode       *string             `protobuf:"bytes,8,opt,name=DurabilityMode" json:"DurabilityMode,omitempty"`
+	FsyncDelay           *int64              `protobuf:"varint,9,opt,name=FsyncDelay" json:"FsyncDelay,omitempty"`
+	SnapshotInterval     *int64              `protobuf:"varint,10,opt,name=SnapshotInterval" json:"SnapshotInterval,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
 	XXX_unrecognized     []byte              `json:"-"`
 	XXX_sizecache        int32               `json:"-"`
@@ -516,6 +520,34 @@ func (m *RetentionPolicyInfo) GetSubscriptions() []*SubscriptionInfo {
 	return nil
 }
 
+func (m *RetentionPolicyInfo) GetColdDuration() int64 {
+	if m != nil && m.ColdDuration != nil {
+		return *m.ColdDuration
+	}
+	return 0
+}
+
+func (m *RetentionPolicyInfo) GetDurabilityMode() string {
+	if m != nil && m.DurabilityMode != nil {
+		return *m.DurabilityMode
+	}
+	return ""
+}
+
+func (m *RetentionPolicyInfo) GetFsyncDelay() int64 {
+	if m != nil && m.FsyncDelay != nil {
+		return *m.FsyncDelay
+	}
+	return 0
+}
+
+func (m *RetentionPolicyInfo) GetSnapshotInterval() int64 {
+	if m != nil && m.SnapshotInterval != nil {
+		return *m.SnapshotInterval
+	}
+	return 0
+}
+
 type ShardGroupInfo struct {
 	ID                   *uint64      `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	StartTime            *int64       `protobuf:"varint,2,req,name=StartTime" json:"StartTime,omitempty"`
@@ -598,6 +630,7 @@ type ShardInfo struct {
 	ID                   *uint64       `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	OwnerIDs             []uint64      `protobuf:"varint,2,rep,name=OwnerIDs" json:"OwnerIDs,omitempty"` // Deprecated: Do not use.
 	Owners               []*ShardOwner `protobuf:"bytes,3,rep,name=Owners" json:"Owners,omitempty"`
+	Tier                 *string       `protobuf:"bytes,4,opt,name=Tier" json:"Tier,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
 	XXX_unrecognized     []byte        `json:"-"`
 	XXX_sizecache        int32         `json:"-"`
@@ -649,6 +682,13 @@ func (m *ShardInfo) GetOwners() []*ShardOwner {
 	return nil
 }
 
+func (m *ShardInfo) GetTier() string {
+	if m != nil && m.Tier != nil {
+		return *m.Tier
+	}
+	return ""
+}
+
 type SubscriptionInfo struct {
 	Name                 *string  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	Mode                 *string  `protobuf:"bytes,2,req,name=Mode" json:"Mode,omitempty"`
@@ -2747,118 +2787,123 @@ func init() {
 func init() { proto.RegisterFile("internal/meta.proto", fileDescriptor_59b0956366e72083) }
 
 var fileDescriptor_59b0956366e72083 = []byte{
-	// 1808 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x59, 0x4b, 0x6f, 0x1c, 0xc7,
-	0x11, 0x46, 0xcf, 0x3e, 0xb8, 0x5b, 0x7c, 0xaa, 0xf9, 0x1a, 0x4a, 0x14, 0xb3, 0x18, 0x08, 0xca,
-	0x22, 0x08, 0x98, 0x60, 0x03, 0xe8, 0x94, 0x97, 0xc4, 0x95, 0xc4, 0x85, 0xc0, 0x47, 0x66, 0xa9,
-	0x6b, 0x80, 0x11, 0xb7, 0x25, 0x6e, 0xb2, 0x3b, 0xb3, 0x99, 0x99, 0x95, 0xc4, 0x28, 0x4c, 0x18,
-	0x5f, 0x7c, 0xb5, 0x61, 0x18, 0x3e, 0xe8, 0x66, 0x1f, 0x7c, 0x34, 0x0c, 0x03, 0x06, 0x0c, 0x9f,
-	0x7c, 0xf7, 0x1f, 0xf0, 0x7f, 0xb0, 0xcf, 0xbe, 0x1a, 0xdd, 0x3d, 0x3d, 0xdd, 0x33, 0xd3, 0x3d,
-	0x24, 0x65, 0xf9, 0x36, 0x5d, 0x55, 0xdd, 0xf5, 0x55, 0x75, 0x75, 0x75, 0x55, 0x0f, 0x2c, 0x0f,
-	0xfd, 0x98, 0x84, 0xbe, 0x37, 0xfa, 0xdd, 0x98, 0xc4, 0xde, 0xf6, 0x24, 0x0c, 0xe2, 0x00, 0x57,
-	0xe9, 0xb7, 0xf3, 0x5e, 0x05, 0xaa, 0x5d, 0x2f, 0xf6, 0x30, 0x86, 0xea, 0x11, 0x09, 0xc7, 0x36,
-	0x6a, 0x59, 0xed, 0xaa, 0xcb, 0xbe, 0xf1, 0x0a, 0xd4, 0x7a, 0xfe, 0x80, 0xbc, 0xb4, 0x2d, 0x46,
-	0xe4, 0x03, 0xbc, 0x09, 0xcd, 0x9d, 0xd1, 0x34, 0x8a, 0x49, 0xd8, 0xeb, 0xda, 0x15, 0xc6, 0x91,
-	0x04, 0x7c, 0x0b, 0x6a, 0xfb, 0xc1, 0x80, 0x44, 0x76, 0xb5, 0x55, 0x69, 0xcf, 0x76, 0x16, 0xb6,
-	0x99, 0x4a, 0x4a, 0xea, 0xf9, 0x4f, 0x03, 0x97, 0x33, 0xf1, 0xef, 0xa1, 0x49, 0xb5, 0x3e, 0xf1,
-	0x22, 0x12, 0xd9, 0x35, 0x26, 0x89, 0xb9, 0xa4, 0x20, 0x33, 0x69, 0x29, 0x44, 0xd7, 0x7d, 0x1c,
-	0x91, 0x30, 0xb2, 0xeb, 0xea, 0xba, 0x94, 0xc4, 0xd7, 0x65, 0x4c, 0x8a, 0x6d, 0xcf, 0x7b, 0xc9,
-	0xb4, 0x75, 0xed, 0x19, 0x8e, 0x2d, 0x25, 0xe0, 0x36, 0x2c, 0xee, 0x79, 0x2f, 0xfb, 0x27, 0x5e,
-	0x38, 0x78, 0x18, 0x06, 0xd3, 0x49, 0xaf, 0x6b, 0x37, 0x98, 0x4c, 0x9e, 0x8c, 0xb7, 0x00, 0x04,
-	0xa9, 0xd7, 0xb5, 0x9b, 0x4c, 0x48, 0xa1, 0xe0, 0xdf, 0x72, 0xfc, 0xdc, 0x52, 0xd0, 0x5a, 0x2a,
-	0x05, 0xa8, 0xf4, 0x1e, 0x11, 0xd2, 0xb3, 0x7a, 0xe9, 0x54, 0xc0, 0xd9, 0x85, 0x86, 0x20, 0xe3,
-	0x05, 0xb0, 0x7a, 0xdd, 0x64, 0x4f, 0xac, 0x5e, 0x97, 0xee, 0xd2, 0x6e, 0x10, 0xc5, 0x6c, 0x43,
-	0x9a, 0x2e, 0xfb, 0xc6, 0x36, 0xcc, 0x1c, 0xed, 0x1c, 0x32, 0x72, 0xa5, 0x85, 0xda, 0x4d, 0x57,
-	0x0c, 0x9d, 0xef, 0x11, 0xcc, 0xa9, 0xfe, 0xa4, 0xd3, 0xf7, 0xbd, 0x31, 0x61, 0x0b, 0x36, 0x5d,
-	0xf6, 0x8d, 0xef, 0xc0, 0x5a, 0x97, 0x3c, 0xf5, 0xa6, 0xa3, 0xd8, 0x25, 0x31, 0xf1, 0xe3, 0x61,
-	0xe0, 0x1f, 0x06, 0xa3, 0xe1, 0xf1, 0x69, 0xa2, 0xc4, 0xc0, 0xc5, 0x0f, 0xe1, 0x5a, 0x96, 0x34,
-	0x24, 0x91, 0x5d, 0x61, 0xc6, 0x6d, 0x70, 0xe3, 0x72, 0x33, 0x98, 0x9d, 0xc5, 0x39, 0x74, 0xa1,
-	0x9d, 0xc0, 0x8f, 0x87, 0xfe, 0x34, 0x98, 0x46, 0x7f, 0x9b, 0x92, 0x70, 0x98, 0x46, 0x4f, 0xb2,
-	0x50, 0x96, 0x9d, 0x2c, 0x54, 0x98, 0xe3, 0xbc, 0x8f, 0x60, 0x39, 0xa7, 0xb3, 0x3f, 0x21, 0xc7,
-	0x8a, 0xd5, 0x28, 0xb5, 0xfa, 0x3a, 0x34, 0xba, 0xd3, 0xd0, 0xa3, 0x92, 0xb6, 0xd5, 0x42, 0xed,
-	0x8a, 0x9b, 0x8e, 0xf1, 0x36, 0x60, 0x19, 0x0c, 0xa9, 0x54, 0x85, 0x49, 0x69, 0x38, 0x74, 0x2d,
-	0x97, 0x4c, 0x46, 0xc3, 0x63, 0x6f, 0xdf, 0xae, 0xb6, 0x50, 0x7b, 0xde, 0x4d, 0xc7, 0xce, 0xbb,
-	0x56, 0x01, 0x93, 0x71, 0x27, 0xb2, 0x98, 0xac, 0x4b, 0x61, 0xb2, 0x2e, 0x85, 0xc9, 0x52, 0x31,
-	0xe1, 0x3b, 0x30, 0x2b, 0x67, 0x88, 0xe3, 0xb7, 0xc2, 0x5d, 0xad, 0x9c, 0x02, 0xea, 0x65, 0x55,
-	0x10, 0xff, 0x11, 0xe6, 0xfb, 0xd3, 0x27, 0xd1, 0x71, 0x38, 0x9c, 0x50, 0x1d, 0xe2, 0x28, 0xae,
-	0x25, 0x33, 0x15, 0x16, 0x9b, 0x9b, 0x15, 0x76, 0xbe, 0x41, 0xb0, 0x90, 0x5d, 0xbd, 0x10, 0xdd,
-	0x9b, 0xd0, 0xec, 0xc7, 0x5e, 0x18, 0x1f, 0x0d, 0xc7, 0x24, 0xf1, 0x80, 0x24, 0xd0, 0x38, 0xbf,
-	0xef, 0x0f, 0x18, 0x8f, 0xdb, 0x2d, 0x86, 0x74, 0x5e, 0x97, 0x8c, 0x48, 0x4c, 0x06, 0x77, 0x63,
-	0x66, 0x6d, 0xc5, 0x95, 0x04, 0xfc, 0x6b, 0xa8, 0x33, 0xbd, 0xc2, 0xd2, 0x45, 0xc5, 0x52, 0x06,
-	0x34, 0x61, 0xe3, 0x16, 0xcc, 0x1e, 0x85, 0x53, 0xff, 0xd8, 0xe3, 0x0b, 0xd5, 0xd9, 0x86, 0xab,
-	0x24, 0x87, 0x40, 0x33, 0x9d, 0x56, 0x40, 0xbf, 0x05, 0x8d, 0x83, 0x17, 0x3e, 0x4d, 0x82, 0x91,
-	0x6d, 0xb5, 0x2a, 0xed, 0xea, 0x3d, 0xcb, 0x46, 0x6e, 0x4a, 0xc3, 0x6d, 0xa8, 0xb3, 0x6f, 0x71,
-	0x4a, 0x96, 0x14, 0x1c, 0x8c, 0xe1, 0x26, 0x7c, 0xe7, 0xef, 0xb0, 0x94, 0xf7, 0xa6, 0x36, 0x60,
-	0x30, 0x54, 0xf7, 0x82, 0x01, 0x11, 0xd9, 0x80, 0x7e, 0x63, 0x07, 0xe6, 0xba, 0x24, 0x8a, 0x87,
-	0xbe, 0xc7, 0xf7, 0x88, 0xea, 0x6a, 0xba, 0x19, 0x9a, 0x73, 0x0b, 0x40, 0x6a, 0xc5, 0x6b, 0x50,
-	0x4f, 0x12, 0x26, 0xb7, 0x25, 0x19, 0x39, 0x7f, 0x81, 0x65, 0xcd, 0xc1, 0xd3, 0x02, 0x59, 0x81,
-	0x1a, 0x13, 0x48, 0x90, 0xf0, 0x81, 0x73, 0x06, 0x0d, 0x91, 0x9f, 0x4d, 0xf0, 0x77, 0xbd, 0xe8,
-	0x24, 0x4d, 0x66, 0x5e, 0x74, 0x42, 0x57, 0xba, 0x3b, 0x18, 0x0f, 0x79, 0x68, 0x37, 0x5c, 0x3e,
-	0xc0, 0x7f, 0x00, 0x38, 0x0c, 0x87, 0xcf, 0x87, 0x23, 0xf2, 0x2c, 0xcd, 0x0d, 0xcb, 0xf2, 0x06,
-	0x48, 0x79, 0xae, 0x22, 0xe6, 0xf4, 0x60, 0x3e, 0xc3, 0x64, 0xe7, 0x2b, 0xc9, 0x86, 0x09, 0x8e,
-	0x74, 0x4c, 0x43, 0x28, 0x15, 0x64, 0x80, 0x6a, 0xae, 0x24, 0x38, 0xdf, 0xd5, 0x61, 0x66, 0x27,
-	0x18, 0x8f, 0x3d, 0x7f, 0x80, 0x6f, 0x43, 0x35, 0x3e, 0x9d, 0xf0, 0x15, 0x16, 0xc4, 0xad, 0x95,
-	0x30, 0xb7, 0x8f, 0x4e, 0x27, 0xc4, 0x65, 0x7c, 0xe7, 0x75, 0x1d, 0xaa, 0x74, 0x88, 0x57, 0xe1,
-	0xda, 0x4e, 0x48, 0xbc, 0x98, 0x50, 0xbf, 0x26, 0x82, 0x4b, 0x88, 0x92, 0x79, 0x8c, 0xaa, 0x64,
-	0x0b, 0x6f, 0xc0, 0x2a, 0x97, 0x16, 0xd0, 0x04, 0xab, 0x82, 0xd7, 0x61, 0xb9, 0x1b, 0x06, 0x93,
-	0x3c, 0xa3, 0x8a, 0x5b, 0xb0, 0xc9, 0xe7, 0xe4, 0x32, 0x8d, 0x90, 0xa8, 0xe1, 0x2d, 0xb8, 0x4e,
-	0xa7, 0x1a, 0xf8, 0x75, 0x7c, 0x0b, 0x5a, 0x7d, 0x12, 0xeb, 0x33, 0xbd, 0x90, 0x9a, 0xa1, 0x7a,
-	0x1e, 0x4f, 0x06, 0x66, 0x3d, 0x0d, 0x7c, 0x03, 0xd6, 0x39, 0x12, 0x79, 0xd2, 0x05, 0xb3, 0x49,
-	0x99, 0xdc, 0xe2, 0x22, 0x13, 0xa4, 0x0d, 0xb9, 0x98, 0x13, 0x12, 0xb3, 0xc2, 0x06, 0x03, 0x7f,
-	0x4e, 0xfa, 0x99, 0xee, 0xba, 0x20, 0xcf, 0xe3, 0x65, 0x58, 0xa4, 0xd3, 0x54, 0xe2, 0x02, 0x95,
-	0xe5, 0x96, 0xa8, 0xe4, 0x45, 0xea, 0xe1, 0x3e, 0x89, 0xd3, 0x7d, 0x17, 0x8c, 0x25, 0x8c, 0x61,
-	0x81, 0xfa, 0xc7, 0x8b, 0x3d, 0x41, 0xbb, 0x86, 0x37, 0xc1, 0xee, 0x93, 0x98, 0x05, 0x68, 0x61,
-	0x06, 0x96, 0x1a, 0xd4, 0xed, 0x5d, 0xc6, 0x37, 0x61, 0x23, 0x71, 0x90, 0x72, 0xc0, 0x05, 0x7b,
-	0x95, 0xb9, 0x28, 0x0c, 0x26, 0x3a, 0xe6, 0x1a, 0x5d, 0xd2, 0x25, 0xe3, 0xe0, 0x39, 0x39, 0x24,
-	0x12, 0xf4, 0xba, 0x8c, 0x18, 0x51, 0x42, 0x08, 0x96, 0x9d, 0x0d, 0x26, 0x95, 0xb5, 0x41, 0x59,
-	0x1c, 0x5f, 0x9e, 0x75, 0x9d, 0xb2, 0xf8, 0x3e, 0xe5, 0x17, 0xbc, 0x21, 0x59, 0xf9, 0x59, 0x9b,
-	0x78, 0x0d, 0x70, 0x9f, 0xc4, 0xf9, 0x29, 0x37, 0xf1, 0x0a, 0x2c, 0x31, 0x93, 0xe8, 0x9e, 0x0b,
-	0xea, 0xd6, 0x6f, 0x1a, 0x8d, 0xc1, 0xd2, 0xf9, 0xf9, 0xf9, 0xb9, 0xe5, 0x9c, 0x69, 0x8e, 0x47,
-	0x5a, 0xe7, 0x20, 0xa5, 0xce, 0xc1, 0x50, 0x75, 0x3d, 0x7f, 0x90, 0x14, 0xa3, 0xec, 0xbb, 0xf3,
-	0x57, 0x98, 0x39, 0x4e, 0xa6, 0xcc, 0x67, 0x4e, 0xa2, 0x4d, 0x5a, 0xa8, 0x3d, 0xdb, 0x59, 0x4f,
-	0x88, 0x79, 0x05, 0xae, 0x98, 0xe6, 0xbc, 0xd2, 0x1c, 0xc3, 0x42, 0x6a, 0x5f, 0x81, 0xda, 0x83,
-	0x20, 0x3c, 0xe6, 0x99, 0xa1, 0xe1, 0xf2, 0x41, 0x89, 0xf2, 0xa7, 0xaa, 0xf2, 0xc2, 0xf2, 0x52,
-	0xf9, 0x97, 0xc8, 0x70, 0xda, 0xb5, 0xf9, 0x72, 0x07, 0x16, 0x8b, 0x25, 0x1a, 0x2a, 0xaf, 0xb7,
-	0xf2, 0x33, 0x3a, 0x5d, 0x23, 0xe8, 0x67, 0x6c, 0xad, 0x1b, 0xaa, 0xc7, 0x72, 0xa8, 0x24, 0xf0,
-	0xb1, 0x36, 0x15, 0xe9, 0x50, 0x77, 0xee, 0x19, 0x15, 0x9e, 0xa8, 0xe0, 0x35, 0xcb, 0x49, 0x75,
-	0xdf, 0xa2, 0xf2, 0x0c, 0x57, 0x9a, 0xda, 0xb5, 0x6e, 0xb3, 0xae, 0xe8, 0xb6, 0x47, 0x46, 0x2b,
-	0x86, 0xcc, 0x0a, 0x47, 0x75, 0x9b, 0x1e, 0xa4, 0x34, 0xe7, 0x23, 0x54, 0x96, 0x8e, 0x4b, 0x8d,
-	0x11, 0x1e, 0xb6, 0x14, 0x0f, 0xf7, 0x8c, 0xd8, 0xfe, 0xc1, 0xb0, 0xb5, 0xa4, 0x87, 0x2f, 0x42,
-	0xf6, 0x09, 0xba, 0xf8, 0x22, 0xb8, 0x32, 0xbe, 0x03, 0x23, 0xbe, 0x7f, 0x32, 0x7c, 0xb7, 0x93,
-	0x42, 0xe8, 0x02, 0xbd, 0x12, 0xe5, 0x0f, 0xa8, 0xfc, 0x22, 0xba, 0x2a, 0x42, 0x5a, 0x5a, 0xee,
-	0x93, 0x17, 0x8c, 0x9c, 0xb4, 0x50, 0xc9, 0x30, 0x53, 0x93, 0x57, 0x73, 0x7d, 0x82, 0x5a, 0x63,
-	0xd7, 0xb2, 0x75, 0x7f, 0x49, 0xbc, 0x8c, 0xd4, 0x78, 0x29, 0xb3, 0x42, 0xda, 0xfb, 0x05, 0x32,
-	0x5e, 0xab, 0xa5, 0xa6, 0xae, 0x41, 0x3d, 0xd3, 0xca, 0x25, 0x23, 0x5a, 0xec, 0xd0, 0xba, 0x39,
-	0x8a, 0xbd, 0xf1, 0x24, 0xa9, 0xa5, 0x25, 0xa1, 0xf3, 0xc0, 0x08, 0x7d, 0xcc, 0xa0, 0xdf, 0x54,
-	0x43, 0xbd, 0x00, 0x48, 0xa2, 0xfe, 0x0a, 0x19, 0xef, 0xfb, 0x37, 0x42, 0xed, 0xc0, 0x5c, 0xa6,
-	0x75, 0xe7, 0x4f, 0x0f, 0x19, 0x5a, 0x09, 0x76, 0x5f, 0xc5, 0x6e, 0x80, 0x25, 0xb1, 0x7f, 0x8e,
-	0xca, 0xcb, 0x91, 0x2b, 0x47, 0x58, 0x5a, 0x21, 0x57, 0x94, 0x0a, 0xb9, 0x24, 0x4a, 0x82, 0x62,
-	0x56, 0xd1, 0x23, 0x29, 0x66, 0x95, 0xb7, 0x83, 0xb8, 0x24, 0xab, 0x4c, 0xf2, 0x59, 0xe5, 0x22,
-	0x64, 0x1f, 0x20, 0x4d, 0x69, 0xf6, 0xf3, 0x5a, 0x82, 0x92, 0xcb, 0xf7, 0x5f, 0xc5, 0x9b, 0x5f,
-	0x51, 0x2b, 0x51, 0x91, 0x42, 0x61, 0xa8, 0xbd, 0xbf, 0xfe, 0x6c, 0x54, 0x14, 0x32, 0x45, 0xab,
-	0xd2, 0x0f, 0x5a, 0x35, 0x67, 0x9a, 0x52, 0xf3, 0xb2, 0xb6, 0x97, 0x58, 0x19, 0xa9, 0x56, 0x16,
-	0x14, 0x48, 0xf5, 0x9f, 0x21, 0x6d, 0x4d, 0x4b, 0xc3, 0x81, 0xca, 0xfb, 0x12, 0x45, 0x3a, 0xce,
-	0x84, 0x8a, 0x55, 0xd6, 0x28, 0x55, 0x72, 0x8d, 0x52, 0xc9, 0x65, 0x1f, 0xab, 0x97, 0xbd, 0x06,
-	0x90, 0x44, 0x1c, 0xe4, 0x6b, 0x6d, 0xbc, 0xc5, 0xdf, 0x28, 0x19, 0xce, 0xd9, 0x0e, 0xc8, 0x87,
-	0x42, 0x97, 0xd1, 0x3b, 0x7f, 0x32, 0x6a, 0x9d, 0x32, 0xad, 0x2b, 0xf2, 0x82, 0x91, 0xab, 0x4a,
-	0x85, 0x1f, 0x22, 0x73, 0x25, 0x5f, 0xea, 0xa7, 0x34, 0x32, 0x2d, 0x35, 0x32, 0x1f, 0x1a, 0xd1,
-	0x3c, 0x67, 0x68, 0xb6, 0x52, 0x34, 0x5a, 0x8d, 0x12, 0xd7, 0xa9, 0xa6, 0x85, 0xb8, 0xcc, 0x8b,
-	0x60, 0x49, 0xd4, 0xbc, 0x28, 0x46, 0x8d, 0xb6, 0x30, 0xfd, 0x11, 0x95, 0xf4, 0x29, 0xc6, 0xc7,
-	0x2b, 0x53, 0xcc, 0xb4, 0x8b, 0x15, 0x18, 0x4f, 0x83, 0x79, 0x72, 0xfa, 0xa2, 0x51, 0x2d, 0x79,
-	0xd1, 0xa8, 0x15, 0x5f, 0x34, 0x3a, 0xbb, 0x46, 0x8b, 0x4f, 0x99, 0xc5, 0xbf, 0xca, 0xdc, 0x59,
-	0x45, 0x93, 0xa4, 0xe5, 0x5f, 0x23, 0x63, 0x0b, 0xf6, 0xcb, 0xd9, 0x5d, 0x72, 0x6f, 0xfd, 0x3b,
-	0x73, 0x6f, 0xe9, 0x81, 0x65, 0x42, 0xa6, 0xd0, 0x22, 0xa6, 0x21, 0x83, 0x64, 0xc8, 0xdc, 0x1d,
-	0x0c, 0x42, 0x11, 0x32, 0xf4, 0xbb, 0x24, 0x64, 0x5e, 0xa9, 0x21, 0x53, 0x58, 0x5c, 0xaa, 0xfe,
-	0x14, 0x19, 0xfa, 0x50, 0xea, 0xa2, 0xdd, 0xa3, 0xa3, 0x43, 0xa6, 0x33, 0x39, 0x42, 0x62, 0x9c,
-	0x3c, 0x5e, 0x2b, 0x70, 0xc4, 0x30, 0x6d, 0xf7, 0x2a, 0x4a, 0xbb, 0x67, 0x6e, 0x5e, 0xfe, 0x53,
-	0x6c, 0x5e, 0x72, 0x30, 0x32, 0xd7, 0x91, 0xbe, 0x2d, 0x7e, 0x33, 0xa4, 0x25, 0xa8, 0xce, 0xf4,
-	0x2d, 0x95, 0x16, 0xd5, 0x6b, 0x64, 0xe8, 0xc8, 0xaf, 0xfe, 0x13, 0xc0, 0x52, 0x7e, 0x02, 0x94,
-	0xa0, 0xfb, 0xaf, 0x8a, 0x4e, 0xab, 0x5a, 0x6d, 0xf8, 0xf4, 0x6f, 0x02, 0x79, 0x70, 0x25, 0xea,
-	0xfe, 0xa7, 0xaa, 0xd3, 0x2e, 0x26, 0xd5, 0xf9, 0x86, 0x77, 0x86, 0x82, 0xba, 0xfb, 0x46, 0x75,
-	0xe7, 0xa8, 0xa8, 0xcf, 0x68, 0xde, 0x03, 0x5a, 0xca, 0x47, 0x93, 0xc0, 0x8f, 0x08, 0x55, 0x71,
-	0xf0, 0x88, 0xa9, 0x68, 0xb8, 0xd6, 0xc1, 0x23, 0x9a, 0xe5, 0xef, 0x87, 0x61, 0x10, 0xb2, 0x66,
-	0xbb, 0xe9, 0xf2, 0x81, 0xfc, 0x37, 0x56, 0x61, 0xe7, 0x8a, 0x0f, 0x9c, 0x8f, 0x91, 0xee, 0x15,
-	0xe4, 0x2d, 0x9e, 0x00, 0xf3, 0x05, 0xfb, 0x7f, 0x6e, 0xaf, 0x9d, 0xde, 0x2e, 0x46, 0xe7, 0x0e,
-	0x8a, 0x2f, 0x32, 0x05, 0xbf, 0x9a, 0xf3, 0xc1, 0x3b, 0x5c, 0xcf, 0x9a, 0x92, 0x91, 0x94, 0x85,
-	0x52, 0x2d, 0x3f, 0x05, 0x00, 0x00, 0xff, 0xff, 0xc7, 0x04, 0x86, 0xd9, 0x75, 0x1c, 0x00, 0x00,
+	// 1883 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x59, 0x4b, 0x6f, 0xdc, 0xc8,
+	0x11, 0x46, 0x73, 0x1e, 0x9a, 0x29, 0x3d, 0xdd, 0x7a, 0x51, 0xb6, 0xac, 0x0c, 0x08, 0xc3, 0x19,
+	0x18, 0x81, 0x12, 0x4c, 0x00, 0x9f, 0xf2, 0xb2, 0x35, 0x96, 0x35, 0x30, 0xf4, 0x08, 0x47, 0xbe,
+	0x06, 0xa0, 0x67, 0xda, 0x16, 0x93, 0x19, 0x72, 0x42, 0x72, 0x64, 0x4f, 0x1c, 0x25, 0x4a, 0x7e,
+	0x41, 0x82, 0x20, 0xc8, 0xc1, 0x87, 0x00, 0xc9, 0x21, 0xc7, 0x60, 0xb1, 0xc0, 0x02, 0x8b, 0x3d,
+	0xed, 0x7d, 0xff, 0xc0, 0xfe, 0x87, 0xdd, 0xf3, 0x5e, 0x17, 0xdd, 0x4d, 0xb2, 0x9b, 0x64, 0x37,
+	0x25, 0x79, 0xbd, 0x37, 0x76, 0x55, 0x75, 0xd7, 0x57, 0xd5, 0xd5, 0xd5, 0x55, 0x4d, 0x58, 0x75,
+	0xbd, 0x88, 0x04, 0x9e, 0x33, 0xfa, 0xf1, 0x98, 0x44, 0xce, 0xee, 0x24, 0xf0, 0x23, 0x1f, 0x57,
+	0xe9, 0xb7, 0xf5, 0xb7, 0x0a, 0x54, 0xbb, 0x4e, 0xe4, 0x60, 0x0c, 0xd5, 0x53, 0x12, 0x8c, 0x4d,
+	0xd4, 0x32, 0xda, 0x55, 0x9b, 0x7d, 0xe3, 0x35, 0xa8, 0xf5, 0xbc, 0x21, 0x79, 0x63, 0x1a, 0x8c,
+	0xc8, 0x07, 0x78, 0x1b, 0x9a, 0x7b, 0xa3, 0x69, 0x18, 0x91, 0xa0, 0xd7, 0x35, 0x2b, 0x8c, 0x23,
+	0x08, 0xf8, 0x1e, 0xd4, 0x8e, 0xfc, 0x21, 0x09, 0xcd, 0x6a, 0xab, 0xd2, 0x9e, 0xef, 0x2c, 0xed,
+	0x32, 0x95, 0x94, 0xd4, 0xf3, 0x5e, 0xfa, 0x36, 0x67, 0xe2, 0x9f, 0x40, 0x93, 0x6a, 0x7d, 0xe1,
+	0x84, 0x24, 0x34, 0x6b, 0x4c, 0x12, 0x73, 0xc9, 0x84, 0xcc, 0xa4, 0x85, 0x10, 0x5d, 0xf7, 0x79,
+	0x48, 0x82, 0xd0, 0xac, 0xcb, 0xeb, 0x52, 0x12, 0x5f, 0x97, 0x31, 0x29, 0xb6, 0x43, 0xe7, 0x0d,
+	0xd3, 0xd6, 0x35, 0xe7, 0x38, 0xb6, 0x94, 0x80, 0xdb, 0xb0, 0x7c, 0xe8, 0xbc, 0xe9, 0x9f, 0x39,
+	0xc1, 0xf0, 0x69, 0xe0, 0x4f, 0x27, 0xbd, 0xae, 0xd9, 0x60, 0x32, 0x79, 0x32, 0xde, 0x01, 0x48,
+	0x48, 0xbd, 0xae, 0xd9, 0x64, 0x42, 0x12, 0x05, 0xff, 0x88, 0xe3, 0xe7, 0x96, 0x82, 0xd2, 0x52,
+	0x21, 0x40, 0xa5, 0x0f, 0x49, 0x22, 0x3d, 0xaf, 0x96, 0x4e, 0x05, 0xac, 0x03, 0x68, 0x24, 0x64,
+	0xbc, 0x04, 0x46, 0xaf, 0x1b, 0xef, 0x89, 0xd1, 0xeb, 0xd2, 0x5d, 0x3a, 0xf0, 0xc3, 0x88, 0x6d,
+	0x48, 0xd3, 0x66, 0xdf, 0xd8, 0x84, 0xb9, 0xd3, 0xbd, 0x13, 0x46, 0xae, 0xb4, 0x50, 0xbb, 0x69,
+	0x27, 0x43, 0xeb, 0x2b, 0x04, 0x0b, 0xb2, 0x3f, 0xe9, 0xf4, 0x23, 0x67, 0x4c, 0xd8, 0x82, 0x4d,
+	0x9b, 0x7d, 0xe3, 0x87, 0xb0, 0xd1, 0x25, 0x2f, 0x9d, 0xe9, 0x28, 0xb2, 0x49, 0x44, 0xbc, 0xc8,
+	0xf5, 0xbd, 0x13, 0x7f, 0xe4, 0x0e, 0x66, 0xb1, 0x12, 0x0d, 0x17, 0x3f, 0x85, 0x5b, 0x59, 0x92,
+	0x4b, 0x42, 0xb3, 0xc2, 0x8c, 0xdb, 0xe2, 0xc6, 0xe5, 0x66, 0x30, 0x3b, 0x8b, 0x73, 0xe8, 0x42,
+	0x7b, 0xbe, 0x17, 0xb9, 0xde, 0xd4, 0x9f, 0x86, 0xbf, 0x9e, 0x92, 0xc0, 0x4d, 0xa3, 0x27, 0x5e,
+	0x28, 0xcb, 0x8e, 0x17, 0x2a, 0xcc, 0xb1, 0xfe, 0x8e, 0x60, 0x35, 0xa7, 0xb3, 0x3f, 0x21, 0x03,
+	0xc9, 0x6a, 0x94, 0x5a, 0x7d, 0x1b, 0x1a, 0xdd, 0x69, 0xe0, 0x50, 0x49, 0xd3, 0x68, 0xa1, 0x76,
+	0xc5, 0x4e, 0xc7, 0x78, 0x17, 0xb0, 0x08, 0x86, 0x54, 0xaa, 0xc2, 0xa4, 0x14, 0x1c, 0xba, 0x96,
+	0x4d, 0x26, 0x23, 0x77, 0xe0, 0x1c, 0x99, 0xd5, 0x16, 0x6a, 0x2f, 0xda, 0xe9, 0xd8, 0xfa, 0x77,
+	0xa5, 0x80, 0x49, 0xbb, 0x13, 0x59, 0x4c, 0xc6, 0xb5, 0x30, 0x19, 0xd7, 0xc2, 0x64, 0xc8, 0x98,
+	0xf0, 0x43, 0x98, 0x17, 0x33, 0x92, 0xe3, 0xb7, 0xc6, 0x5d, 0x2d, 0x9d, 0x02, 0xea, 0x65, 0x59,
+	0x10, 0xff, 0x0c, 0x16, 0xfb, 0xd3, 0x17, 0xe1, 0x20, 0x70, 0x27, 0x54, 0x47, 0x72, 0x14, 0x37,
+	0xe2, 0x99, 0x12, 0x8b, 0xcd, 0xcd, 0x0a, 0x63, 0x0b, 0x16, 0xf6, 0xfc, 0xd1, 0x30, 0xc5, 0x3e,
+	0xc7, 0xfc, 0x99, 0xa1, 0xe1, 0xfb, 0xb0, 0x44, 0xbf, 0x5f, 0xb8, 0x23, 0x37, 0x9a, 0x1d, 0xfa,
+	0x43, 0x62, 0x36, 0xd8, 0x9e, 0xe5, 0xa8, 0xf4, 0x78, 0xee, 0x87, 0x33, 0x6f, 0xd0, 0x25, 0x23,
+	0x67, 0x66, 0x36, 0xd9, 0x4a, 0x12, 0x05, 0x3f, 0x80, 0x95, 0xbe, 0xe7, 0x4c, 0xc2, 0x33, 0x3f,
+	0xea, 0xd1, 0xd4, 0x77, 0xee, 0x8c, 0x4c, 0x60, 0x52, 0x05, 0xba, 0xf5, 0x39, 0x82, 0xa5, 0xac,
+	0xd5, 0x85, 0x53, 0xb7, 0x0d, 0xcd, 0x7e, 0xe4, 0x04, 0xd1, 0xa9, 0x3b, 0x26, 0xf1, 0xce, 0x08,
+	0x02, 0x3d, 0x7f, 0x4f, 0xbc, 0x21, 0xe3, 0xf1, 0xfd, 0x48, 0x86, 0x74, 0x5e, 0x97, 0x8c, 0x48,
+	0x44, 0x86, 0x8f, 0x22, 0xb6, 0x0b, 0x15, 0x5b, 0x10, 0xf0, 0x0f, 0xa1, 0xce, 0xf4, 0x26, 0x3b,
+	0xb0, 0x2c, 0xed, 0x00, 0x73, 0x60, 0xcc, 0xc6, 0x2d, 0x98, 0x3f, 0x0d, 0xa6, 0xde, 0xc0, 0xe1,
+	0x0b, 0xd5, 0x99, 0x21, 0x32, 0xc9, 0x9a, 0x41, 0x33, 0x9d, 0x56, 0x40, 0xbf, 0x03, 0x8d, 0xe3,
+	0xd7, 0x1e, 0x4d, 0xce, 0xa1, 0x69, 0xb4, 0x2a, 0xed, 0xea, 0x63, 0xc3, 0x44, 0x76, 0x4a, 0xc3,
+	0x6d, 0xa8, 0xb3, 0xef, 0xe4, 0xf4, 0xae, 0x48, 0x38, 0x18, 0xc3, 0x8e, 0xf9, 0xec, 0x8e, 0x70,
+	0x49, 0xc0, 0x82, 0xbc, 0x69, 0xb3, 0x6f, 0xeb, 0x37, 0xb0, 0x92, 0xdf, 0x79, 0x65, 0x70, 0x63,
+	0xa8, 0xb2, 0x0d, 0x8d, 0x33, 0x17, 0xfd, 0xa6, 0x21, 0xd1, 0x25, 0x61, 0xe4, 0x7a, 0x0e, 0x8f,
+	0x27, 0xaa, 0xbf, 0x69, 0x67, 0x68, 0xd6, 0x3d, 0x00, 0x81, 0x04, 0x6f, 0x40, 0x3d, 0x4e, 0xee,
+	0xdc, 0xbe, 0x78, 0x64, 0xfd, 0x12, 0x56, 0x15, 0x49, 0x42, 0x09, 0x64, 0x0d, 0x6a, 0x4c, 0x20,
+	0x46, 0xc2, 0x07, 0xd6, 0x05, 0x34, 0x92, 0xbb, 0x44, 0x07, 0xff, 0xc0, 0x09, 0xcf, 0xd2, 0xc4,
+	0xeb, 0x84, 0x67, 0x74, 0xa5, 0x47, 0xc3, 0xb1, 0xcb, 0x8f, 0x61, 0xc3, 0xe6, 0x03, 0xfc, 0x53,
+	0x80, 0x93, 0xc0, 0x3d, 0x77, 0x47, 0xe4, 0x55, 0x9a, 0xc7, 0x56, 0xc5, 0x6d, 0x95, 0xf2, 0x6c,
+	0x49, 0xcc, 0xea, 0xc1, 0x62, 0x86, 0xc9, 0x72, 0x41, 0x9c, 0xb9, 0x63, 0x1c, 0xe9, 0x98, 0x86,
+	0x55, 0x2a, 0xc8, 0x00, 0xd5, 0x6c, 0x41, 0xb0, 0xbe, 0xac, 0xc3, 0xdc, 0x9e, 0x3f, 0x1e, 0x3b,
+	0xde, 0x10, 0xdf, 0x87, 0x6a, 0x34, 0x9b, 0xf0, 0x15, 0x96, 0x92, 0x1b, 0x36, 0x66, 0xee, 0x9e,
+	0xce, 0x26, 0xc4, 0x66, 0x7c, 0xeb, 0x5d, 0x1d, 0xaa, 0x74, 0x88, 0xd7, 0xe1, 0xd6, 0x5e, 0x40,
+	0x9c, 0x88, 0x50, 0xbf, 0xc6, 0x82, 0x2b, 0x88, 0x92, 0x79, 0xdc, 0xca, 0x64, 0x03, 0x6f, 0xc1,
+	0x3a, 0x97, 0x4e, 0xa0, 0x25, 0xac, 0x0a, 0xde, 0x84, 0xd5, 0x6e, 0xe0, 0x4f, 0xf2, 0x8c, 0x2a,
+	0x6e, 0xc1, 0x36, 0x9f, 0x93, 0xcb, 0x8a, 0x89, 0x44, 0x0d, 0xef, 0xc0, 0x6d, 0x3a, 0x55, 0xc3,
+	0xaf, 0xe3, 0x7b, 0xd0, 0xea, 0x93, 0x48, 0x7d, 0x2b, 0x25, 0x52, 0x73, 0x54, 0xcf, 0xf3, 0xc9,
+	0x50, 0xaf, 0xa7, 0x81, 0xef, 0xc0, 0x26, 0x47, 0x22, 0x4e, 0x7f, 0xc2, 0x6c, 0x52, 0x26, 0xb7,
+	0xb8, 0xc8, 0x04, 0x61, 0x43, 0x2e, 0xe6, 0x12, 0x89, 0xf9, 0xc4, 0x06, 0x0d, 0x7f, 0x41, 0xf8,
+	0x99, 0xee, 0x7a, 0x42, 0x5e, 0xc4, 0xab, 0xb0, 0x4c, 0xa7, 0xc9, 0xc4, 0x25, 0x2a, 0xcb, 0x2d,
+	0x91, 0xc9, 0xcb, 0xd4, 0xc3, 0x7d, 0x12, 0xa5, 0xfb, 0x9e, 0x30, 0x56, 0x30, 0x86, 0x25, 0xea,
+	0x1f, 0x27, 0x72, 0x12, 0xda, 0x2d, 0xbc, 0x0d, 0x66, 0x9f, 0x44, 0x2c, 0x40, 0x0b, 0x33, 0xb0,
+	0xd0, 0x20, 0x6f, 0xef, 0x2a, 0xbe, 0x0b, 0x5b, 0xb1, 0x83, 0xa4, 0x03, 0x9e, 0xb0, 0xd7, 0x99,
+	0x8b, 0x02, 0x7f, 0xa2, 0x62, 0x6e, 0xd0, 0x25, 0x6d, 0x32, 0xf6, 0xcf, 0xc9, 0x09, 0x11, 0xa0,
+	0x37, 0x45, 0xc4, 0x24, 0xe5, 0x4e, 0xc2, 0x32, 0xb3, 0xc1, 0x24, 0xb3, 0xb6, 0x28, 0x8b, 0xe3,
+	0xcb, 0xb3, 0x6e, 0x53, 0x16, 0xdf, 0xa7, 0xfc, 0x82, 0x77, 0x04, 0x2b, 0x3f, 0x6b, 0x1b, 0x6f,
+	0x00, 0xee, 0x93, 0x28, 0x3f, 0xe5, 0x2e, 0x5e, 0x83, 0x15, 0x66, 0x12, 0xdd, 0xf3, 0x84, 0xba,
+	0xf3, 0xa0, 0xd1, 0x18, 0xae, 0x5c, 0x5e, 0x5e, 0x5e, 0x1a, 0xd6, 0x85, 0xe2, 0x78, 0xa4, 0x35,
+	0x19, 0x92, 0x6a, 0x32, 0x0c, 0x55, 0xdb, 0xf1, 0x86, 0x71, 0xe1, 0xcc, 0xbe, 0x3b, 0xbf, 0x82,
+	0xb9, 0x41, 0x3c, 0x65, 0x31, 0x73, 0x12, 0x4d, 0xd2, 0x42, 0xed, 0xf9, 0xce, 0x66, 0x4c, 0xcc,
+	0x2b, 0xb0, 0x93, 0x69, 0xd6, 0x5b, 0xc5, 0x31, 0x2c, 0xa4, 0xfb, 0x35, 0xa8, 0xed, 0xfb, 0xc1,
+	0x80, 0x67, 0x86, 0x86, 0xcd, 0x07, 0x25, 0xca, 0x5f, 0xca, 0xca, 0x0b, 0xcb, 0x0b, 0xe5, 0x9f,
+	0x20, 0xcd, 0x69, 0x57, 0xe6, 0xcb, 0x3d, 0x58, 0x2e, 0x96, 0x93, 0xa8, 0xbc, 0x36, 0xcc, 0xcf,
+	0xe8, 0x74, 0xb5, 0xa0, 0x5f, 0xb1, 0xb5, 0xee, 0xc8, 0x1e, 0xcb, 0xa1, 0x12, 0xc0, 0xc7, 0xca,
+	0x54, 0xa4, 0x42, 0xdd, 0x79, 0xac, 0x55, 0x78, 0x26, 0x83, 0x57, 0x2c, 0x27, 0xd4, 0x7d, 0x81,
+	0xca, 0x33, 0x5c, 0x69, 0x6a, 0x57, 0xba, 0xcd, 0xb8, 0xa1, 0xdb, 0x9e, 0x69, 0xad, 0x70, 0x99,
+	0x15, 0x96, 0xec, 0x36, 0x35, 0x48, 0x61, 0xce, 0xbf, 0x50, 0x59, 0x3a, 0x2e, 0x35, 0x26, 0xf1,
+	0xb0, 0x21, 0x79, 0xb8, 0xa7, 0xc5, 0xf6, 0x5b, 0x86, 0xad, 0x25, 0x3c, 0x7c, 0x15, 0xb2, 0xff,
+	0xa2, 0xab, 0x2f, 0x82, 0x1b, 0xe3, 0x3b, 0xd6, 0xe2, 0xfb, 0x1d, 0xc3, 0x77, 0x9f, 0x13, 0xaf,
+	0xd2, 0x2b, 0x50, 0x7e, 0x8d, 0xca, 0x2f, 0xa2, 0x9b, 0x22, 0xa4, 0xe5, 0xe6, 0x11, 0x79, 0xcd,
+	0xc8, 0x71, 0xbb, 0x17, 0x0f, 0x33, 0xfd, 0x43, 0x35, 0xd7, 0xd3, 0xc8, 0xfd, 0x40, 0x2d, 0xdb,
+	0xa3, 0x94, 0xc4, 0xcb, 0x48, 0x8e, 0x97, 0x32, 0x2b, 0x84, 0xbd, 0x1f, 0x23, 0xed, 0xb5, 0x5a,
+	0x6a, 0xea, 0x06, 0xd4, 0x33, 0x6d, 0x67, 0x3c, 0xa2, 0xc5, 0x0e, 0xad, 0xa5, 0xc3, 0xc8, 0x19,
+	0x4f, 0xe2, 0xfa, 0x5a, 0x10, 0x3a, 0xfb, 0x5a, 0xe8, 0x63, 0x06, 0xfd, 0xae, 0x1c, 0xea, 0x05,
+	0x40, 0x02, 0xf5, 0xa7, 0x48, 0x7b, 0xdf, 0xbf, 0x17, 0x6a, 0x0b, 0x16, 0x32, 0xcf, 0x0c, 0xfc,
+	0x99, 0x24, 0x43, 0x2b, 0xc1, 0xee, 0xc9, 0xd8, 0x35, 0xb0, 0x04, 0xf6, 0x8f, 0x50, 0x79, 0x39,
+	0x72, 0xe3, 0x08, 0x4b, 0x2b, 0xe4, 0x8a, 0x54, 0x21, 0x97, 0x44, 0x89, 0x5f, 0xcc, 0x2a, 0x6a,
+	0x24, 0xc5, 0xac, 0xf2, 0x61, 0x10, 0x97, 0x64, 0x95, 0x49, 0x3e, 0xab, 0x5c, 0x85, 0xec, 0x1f,
+	0x48, 0x51, 0x9a, 0x7d, 0xb7, 0x96, 0xa0, 0xe4, 0xf2, 0xfd, 0x7d, 0xf1, 0xe6, 0x97, 0xd4, 0x0a,
+	0x54, 0xa4, 0x50, 0x18, 0x2a, 0xef, 0xaf, 0x5f, 0x68, 0x15, 0x05, 0x4c, 0xd1, 0xba, 0xf0, 0x83,
+	0x52, 0xcd, 0x85, 0xa2, 0xd4, 0xbc, 0xae, 0xed, 0x25, 0x56, 0x86, 0xb2, 0x95, 0x05, 0x05, 0x42,
+	0xfd, 0xff, 0x91, 0xb2, 0xa6, 0xa5, 0xe1, 0x40, 0xe5, 0x3d, 0x81, 0x22, 0x1d, 0x67, 0x42, 0xc5,
+	0x28, 0x6b, 0x94, 0x2a, 0xb9, 0x46, 0xa9, 0xe4, 0xb2, 0x8f, 0xe4, 0xcb, 0x5e, 0x01, 0x48, 0x20,
+	0xf6, 0xf3, 0xb5, 0x36, 0xde, 0xe1, 0xef, 0xa9, 0x0c, 0xe7, 0x7c, 0x07, 0xc4, 0xa3, 0xa6, 0xcd,
+	0xe8, 0x9d, 0x9f, 0x6b, 0xb5, 0x4e, 0x5b, 0x48, 0x7a, 0x87, 0xc9, 0xac, 0x2a, 0x14, 0xfe, 0x13,
+	0xe9, 0x2b, 0xf9, 0x52, 0x3f, 0xa5, 0x91, 0x69, 0xc8, 0x91, 0xf9, 0x54, 0x8b, 0xe6, 0x9c, 0xa1,
+	0xd9, 0x49, 0xd1, 0x28, 0x35, 0x0a, 0x5c, 0x33, 0x45, 0x0b, 0x71, 0x9d, 0xd7, 0xcb, 0x92, 0xa8,
+	0x79, 0x5d, 0x8c, 0x1a, 0x65, 0x61, 0xfa, 0x0d, 0x2a, 0xe9, 0x53, 0xb4, 0x0f, 0x6d, 0xba, 0x98,
+	0x69, 0x17, 0x2b, 0x30, 0x9e, 0x06, 0xf3, 0xe4, 0xf4, 0x45, 0xa3, 0x5a, 0xf2, 0xa2, 0x51, 0x2b,
+	0xbe, 0x68, 0x74, 0x0e, 0xb4, 0x16, 0xcf, 0x98, 0xc5, 0x3f, 0xc8, 0xdc, 0x59, 0x45, 0x93, 0x84,
+	0xe5, 0x9f, 0x21, 0x6d, 0x0b, 0xf6, 0xfd, 0xd9, 0x5d, 0x72, 0x6f, 0xfd, 0x21, 0x73, 0x6f, 0xa9,
+	0x81, 0x65, 0x42, 0xa6, 0xd0, 0x22, 0xa6, 0x21, 0x83, 0x44, 0xc8, 0x3c, 0x1a, 0x0e, 0x83, 0x24,
+	0x64, 0xe8, 0x77, 0x49, 0xc8, 0xbc, 0x95, 0x43, 0xa6, 0xb0, 0xb8, 0x50, 0xfd, 0x3f, 0xa4, 0xe9,
+	0x43, 0xa9, 0x8b, 0x0e, 0x4e, 0x4f, 0x4f, 0x98, 0xce, 0xf8, 0x08, 0x25, 0xe3, 0xf8, 0xa1, 0x5d,
+	0x82, 0x93, 0x0c, 0xd3, 0x76, 0xaf, 0x22, 0xb5, 0x7b, 0xfa, 0xe6, 0xe5, 0x8f, 0xc5, 0xe6, 0x25,
+	0x07, 0x23, 0x73, 0x1d, 0xa9, 0xdb, 0xe2, 0xf7, 0x43, 0x5a, 0x82, 0xea, 0x42, 0xdd, 0x52, 0x29,
+	0x51, 0xbd, 0x43, 0x9a, 0x8e, 0xfc, 0xe6, 0x3f, 0x2c, 0x0c, 0xe9, 0x87, 0x45, 0x09, 0xba, 0x3f,
+	0xc9, 0xe8, 0x94, 0xaa, 0xe5, 0x86, 0x4f, 0xfd, 0x26, 0x90, 0x07, 0x57, 0xa2, 0xee, 0xcf, 0xb2,
+	0x3a, 0xe5, 0x62, 0x42, 0x9d, 0xa7, 0x79, 0x67, 0x28, 0xa8, 0x7b, 0xa2, 0x55, 0x77, 0x89, 0x8a,
+	0xfa, 0xb4, 0xe6, 0xed, 0xd3, 0x52, 0x3e, 0x9c, 0xf8, 0x5e, 0x48, 0xa8, 0x8a, 0xe3, 0x67, 0x4c,
+	0x45, 0xc3, 0x36, 0x8e, 0x9f, 0xd1, 0x2c, 0xff, 0x24, 0x08, 0xfc, 0x80, 0x35, 0xdb, 0x4d, 0x9b,
+	0x0f, 0xc4, 0x7f, 0xbc, 0x0a, 0x3b, 0x57, 0x7c, 0x60, 0xfd, 0x07, 0xa9, 0x5e, 0x41, 0x3e, 0xe0,
+	0x09, 0xd0, 0x5f, 0xb0, 0x7f, 0xe1, 0xf6, 0x9a, 0xe9, 0xed, 0xa2, 0x75, 0xee, 0xb0, 0xf8, 0x22,
+	0x53, 0xf0, 0xab, 0x3e, 0x1f, 0xfc, 0x95, 0xeb, 0xd9, 0x90, 0x32, 0x92, 0xb4, 0x50, 0xaa, 0xe5,
+	0xdb, 0x01, 0x00, 0xbb, 0x58, 0xc0, 0xc3, 0x21, 0x1d, 0x00, 0x00,
 }
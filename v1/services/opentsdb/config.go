@@ -0,0 +1,122 @@
+package opentsdb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/toml"
+)
+
+const (
+	// DefaultBindAddress is the default address that the service binds to.
+	DefaultBindAddress = ":4242"
+
+	// DefaultDatabase is the default database, used as the bucket if none is
+	// specified.
+	DefaultDatabase = "opentsdb"
+
+	// DefaultBatchSize is the default OpenTSDB batch size.
+	DefaultBatchSize = 1000
+
+	// DefaultBatchTimeout is the default OpenTSDB batch timeout.
+	DefaultBatchTimeout = time.Second
+)
+
+// Config represents the configuration of an OpenTSDB input. It reads the
+// [[opentsdb]] sections of 1.x configuration files, with the org, bucket and
+// token the points are written with in addition. Settings of 1.x without
+// equivalent, such as consistency-level, are ignored.
+type Config struct {
+	Enabled        bool          `toml:"enabled"`
+	BindAddress    string        `toml:"bind-address"`
+	Database       string        `toml:"database"`
+	TLSEnabled     bool          `toml:"tls-enabled"`
+	BatchSize      int           `toml:"batch-size"`
+	BatchTimeout   toml.Duration `toml:"batch-timeout"`
+	LogPointErrors bool          `toml:"log-point-errors"`
+
+	// Org and Bucket are the names or IDs of the organization and bucket the
+	// points are written to, with the permissions of the token read from the
+	// environment variable TokenEnv or from the file TokenFile. The bucket
+	// defaults to the database. A token given in plain text is rejected.
+	Org       string `toml:"org"`
+	Bucket    string `toml:"bucket"`
+	Token     string `toml:"token"`
+	TokenEnv  string `toml:"token-env"`
+	TokenFile string `toml:"token-file"`
+}
+
+// NewConfig returns a new config for the service.
+func NewConfig() Config {
+	return Config{
+		BindAddress:    DefaultBindAddress,
+		Database:       DefaultDatabase,
+		BatchSize:      DefaultBatchSize,
+		BatchTimeout:   toml.Duration(DefaultBatchTimeout),
+		LogPointErrors: true,
+	}
+}
+
+// WithDefaults takes the given config and returns a new config with any
+// required default values set.
+func (c Config) WithDefaults() Config {
+	d := c
+	if d.BindAddress == "" {
+		d.BindAddress = DefaultBindAddress
+	}
+	if d.Database == "" {
+		d.Database = DefaultDatabase
+	}
+	if d.BatchSize == 0 {
+		d.BatchSize = DefaultBatchSize
+	}
+	if d.BatchTimeout == 0 {
+		d.BatchTimeout = toml.Duration(DefaultBatchTimeout)
+	}
+	if d.Bucket == "" {
+		d.Bucket = d.Database
+	}
+	return d
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	_, err := c.ListenerConfig()
+	return err
+}
+
+// ListenerConfig returns the configuration of the TCP listener receiving the
+// OpenTSDB data.
+func (c Config) ListenerConfig() (listener.Config, error) {
+	if c.TLSEnabled {
+		return listener.Config{}, errors.New("opentsdb: tls-enabled is not supported, terminate TLS in front of the listener")
+	}
+	token, err := c.token()
+	if err != nil {
+		return listener.Config{}, fmt.Errorf("opentsdb %s: %w", c.BindAddress, err)
+	}
+	lc := listener.Config{
+		Protocol:     listener.ProtocolTCP,
+		BindAddress:  c.BindAddress,
+		Org:          c.Org,
+		Bucket:       c.Bucket,
+		Token:        token,
+		Precision:    listener.DefaultPrecision,
+		BatchSize:    c.BatchSize,
+		BatchTimeout: time.Duration(c.BatchTimeout),
+	}
+	if err := lc.Validate(); err != nil {
+		return listener.Config{}, fmt.Errorf("opentsdb %s: %w", c.BindAddress, err)
+	}
+	return lc, nil
+}
+
+// token reads the token of the configuration from TokenEnv or TokenFile.
+func (c Config) token() (string, error) {
+	if c.Token != "" {
+		return "", errors.New("the token must be given with token-env or token-file, not in plain text")
+	}
+	return listener.ReadToken(c.TokenEnv, c.TokenFile)
+}
@@ -0,0 +1,93 @@
+package opentsdb_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/v1/services/opentsdb"
+)
+
+func TestMain(m *testing.M) {
+	// The configurations of the tests read their token from the environment.
+	os.Setenv("OPENTSDB_TOKEN", "my-token")
+	os.Exit(m.Run())
+}
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	c := opentsdb.NewConfig()
+	if _, err := toml.Decode(`
+enabled = true
+bind-address = ":9000"
+database = "xxx"
+retention-policy = ""
+consistency-level = "one"
+tls-enabled = false
+certificate = "/etc/ssl/influxdb.pem"
+batch-size = 100
+batch-pending = 5
+batch-timeout = "2s"
+log-point-errors = false
+org = "my-org"
+bucket = "pdu"
+token-env = "OPENTSDB_TOKEN"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+	c = c.WithDefaults()
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled: %v", c.Enabled)
+	} else if c.Database != "xxx" {
+		t.Fatalf("unexpected database: %s", c.Database)
+	} else if c.LogPointErrors {
+		t.Fatalf("unexpected log-point-errors: %v", c.LogPointErrors)
+	}
+
+	lc, err := c.ListenerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := listener.Config{
+		Protocol:     listener.ProtocolTCP,
+		BindAddress:  ":9000",
+		Org:          "my-org",
+		Bucket:       "pdu",
+		Token:        "my-token",
+		Precision:    listener.DefaultPrecision,
+		BatchSize:    100,
+		BatchTimeout: 2 * time.Second,
+	}
+	if lc != want {
+		t.Fatalf("unexpected listener config:\n got %+v\nwant %+v", lc, want)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := opentsdb.Config{Org: "my-org", TokenEnv: "OPENTSDB_TOKEN"}.WithDefaults()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Bucket != opentsdb.DefaultDatabase {
+		t.Fatalf("unexpected bucket: %s", c.Bucket)
+	}
+
+	c.TLSEnabled = true
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected an error with tls-enabled")
+	}
+	c.TLSEnabled = false
+	c.Token = "my-token"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected an error with a plain text token")
+	}
+	c.Token = ""
+	c.Org = ""
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected an error without org")
+	}
+}
@@ -0,0 +1,112 @@
+package opentsdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap"
+)
+
+// maxBodyBytes bounds the size of the /api/put request bodies.
+const maxBodyBytes = 32 << 20
+
+// handler is the http.Handler of the OpenTSDB /api/put endpoint.
+type handler struct {
+	adder          pointsAdder
+	logPointErrors bool
+}
+
+// point is a point of the /api/put endpoint.
+type point struct {
+	Metric string            `json:"metric"`
+	Time   int64             `json:"timestamp"`
+	Value  float64           `json:"value"`
+	Tags   map[string]string `json:"tags,omitempty"`
+}
+
+// ServeHTTP handles the /api/put requests, and the /api/metadata/put
+// requests some clients send, which are ignored.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/metadata/put":
+		w.WriteHeader(http.StatusNoContent)
+	case "/api/put":
+		h.servePut(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *handler) servePut(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Wrap reader if it's gzip encoded.
+	var br io.Reader = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			http.Error(w, "could not read gzip, "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer zr.Close()
+		br = zr
+	}
+
+	body, err := ioutil.ReadAll(br)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The body is either a single point or an array of points.
+	var dps []point
+	if isJSONArray(body) {
+		err = json.Unmarshal(body, &dps)
+	} else {
+		dps = make([]point, 1)
+		err = json.Unmarshal(body, &dps[0])
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	points := make([]models.Point, 0, len(dps))
+	for _, dp := range dps {
+		// Timestamps beyond ten digits are milliseconds.
+		var ts time.Time
+		if dp.Time < 10000000000 {
+			ts = time.Unix(dp.Time, 0)
+		} else {
+			ts = time.Unix(dp.Time/1000, (dp.Time%1000)*int64(time.Millisecond))
+		}
+
+		pt, err := models.NewPoint(dp.Metric, models.NewTags(dp.Tags), models.Fields{"value": dp.Value}, ts)
+		if err != nil {
+			h.adder.ParseError(err)
+			if h.logPointErrors {
+				h.adder.Logger().Info("Dropping invalid OpenTSDB point", zap.String("metric", dp.Metric), zap.Error(err))
+			}
+			continue
+		}
+		points = append(points, pt)
+	}
+	h.adder.Add(points)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isJSONArray reports whether the first non-space byte of buf opens an array.
+func isJSONArray(buf []byte) bool {
+	buf = bytes.TrimLeft(buf, " \t\r\n")
+	return len(buf) > 0 && buf[0] == '['
+}
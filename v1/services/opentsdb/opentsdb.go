@@ -0,0 +1,214 @@
+// Package opentsdb converts the OpenTSDB telnet and HTTP protocols into
+// points, served on the same port as in 1.x.
+package opentsdb // import "github.com/influxdata/influxdb/v2/v1/services/opentsdb"
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap"
+)
+
+// maxLineBytes bounds the length of telnet lines.
+const maxLineBytes = 1 << 20
+
+// httpMethods are the prefixes of HTTP requests, telling them apart from
+// telnet commands.
+var httpMethods = []string{"GET ", "POST", "PUT ", "HEAD", "DELE", "OPTI", "PATC"}
+
+// Protocol returns the protocol of the listeners receiving OpenTSDB data. The
+// connections are served as HTTP if they start with an HTTP request, and as
+// telnet otherwise.
+func Protocol(c Config) listener.Protocol {
+	return listener.Protocol{
+		Parse: func(buf []byte, now time.Time) ([]models.Point, error) {
+			pt, err := ParseTelnet(string(buf), now)
+			if err != nil || pt == nil {
+				return nil, err
+			}
+			return []models.Point{pt}, nil
+		},
+		ServeConn: func(l *listener.Listener, conn net.Conn) {
+			serveConn(l, conn, c.LogPointErrors)
+		},
+	}
+}
+
+// pointsAdder adds the points received to the batches of a listener.
+type pointsAdder interface {
+	Add(points []models.Point)
+	ParseError(err error)
+	Logger() *zap.Logger
+}
+
+func serveConn(l pointsAdder, conn net.Conn, logPointErrors bool) {
+	r := bufio.NewReader(conn)
+	prefix, err := r.Peek(4)
+	if err != nil && len(prefix) == 0 {
+		return
+	}
+	for _, m := range httpMethods {
+		if string(prefix) == m {
+			serveHTTP(l, &readerConn{Conn: conn, r: r}, logPointErrors)
+			return
+		}
+	}
+	serveTelnet(l, conn, r, logPointErrors)
+}
+
+func serveTelnet(l pointsAdder, w io.Writer, r io.Reader, logPointErrors bool) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case line == "version":
+			if _, err := io.WriteString(w, "InfluxDB TSDB proxy\n"); err != nil {
+				return
+			}
+			continue
+		}
+
+		pt, err := ParseTelnet(line, time.Now().UTC())
+		if err != nil {
+			l.ParseError(err)
+			if logPointErrors {
+				l.Logger().Info("Dropping invalid OpenTSDB point", zap.String("line", line), zap.Error(err))
+			}
+			continue
+		}
+		if pt == nil {
+			l.Logger().Debug("Ignoring unknown OpenTSDB command", zap.String("line", line))
+			continue
+		}
+		l.Add([]models.Point{pt})
+	}
+}
+
+// ParseTelnet parses a telnet put command of the form
+//
+//	put <metric> <timestamp> <value> <tagk1=tagv1[ tagk2=tagv2 ...]>
+//
+// The timestamp is in seconds or milliseconds. It returns no point and no
+// error for other commands.
+func ParseTelnet(line string, now time.Time) (models.Point, error) {
+	inputStrs := strings.Fields(line)
+	if len(inputStrs) == 0 || inputStrs[0] != "put" {
+		return nil, nil
+	}
+	if len(inputStrs) < 4 {
+		return nil, fmt.Errorf("malformed line %q", line)
+	}
+	measurement, tsStr, valueStr := inputStrs[1], inputStrs[2], inputStrs[3]
+
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed time %q: %s", tsStr, err)
+	}
+	var t time.Time
+	switch len(tsStr) {
+	case 10:
+		t = time.Unix(ts, 0)
+	case 13:
+		t = time.Unix(ts/1000, (ts%1000)*int64(time.Millisecond))
+	default:
+		return nil, fmt.Errorf("malformed time %q: must be 10 or 13 digits", tsStr)
+	}
+
+	tags := make(map[string]string)
+	for _, tag := range inputStrs[4:] {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("malformed tag %q", tag)
+		}
+		tags[parts[0]] = parts[1]
+	}
+
+	v, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed float %q: %s", valueStr, err)
+	}
+	return models.NewPoint(measurement, models.NewTags(tags), models.Fields{"value": v}, t)
+}
+
+// serveHTTP serves the HTTP requests of a single connection.
+func serveHTTP(l pointsAdder, conn net.Conn, logPointErrors bool) {
+	ln := newConnListener(conn)
+	srv := &http.Server{
+		Handler:  &handler{adder: l, logPointErrors: logPointErrors},
+		ErrorLog: zap.NewStdLog(l.Logger()),
+	}
+	_ = srv.Serve(ln)
+}
+
+// readerConn is a connection whose first bytes have been buffered in r.
+type readerConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *readerConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// connListener is a net.Listener accepting a single connection. Accept blocks
+// once the connection has been accepted, until it is closed.
+type connListener struct {
+	conn   net.Conn
+	once   sync.Once
+	closed chan struct{}
+	ch     chan net.Conn
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	ln := &connListener{closed: make(chan struct{}), ch: make(chan net.Conn, 1)}
+	ln.conn = &closeNotifyConn{Conn: conn, close: ln.close}
+	ln.ch <- ln.conn
+	return ln
+}
+
+func (ln *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-ln.ch:
+		return conn, nil
+	case <-ln.closed:
+		return nil, errors.New("connection closed")
+	}
+}
+
+func (ln *connListener) close() {
+	ln.once.Do(func() { close(ln.closed) })
+}
+
+func (ln *connListener) Close() error {
+	ln.close()
+	return nil
+}
+
+func (ln *connListener) Addr() net.Addr {
+	return ln.conn.LocalAddr()
+}
+
+// closeNotifyConn calls close once it is closed.
+type closeNotifyConn struct {
+	net.Conn
+	close func()
+}
+
+func (c *closeNotifyConn) Close() error {
+	err := c.Conn.Close()
+	c.close()
+	return err
+}
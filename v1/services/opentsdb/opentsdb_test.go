@@ -0,0 +1,204 @@
+package opentsdb_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/listener"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/v1/services/opentsdb"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestParseTelnet(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	tests := []struct {
+		line    string
+		want    string
+		wantErr bool
+	}{
+		{
+			line: "put sys.cpu.user 1356998400 42.5 host=webserver01 cpu=0",
+			want: "sys.cpu.user,cpu=0,host=webserver01 value=42.5 1356998400000000000",
+		},
+		{
+			line: "put pdu.current 1356998400123 3",
+			want: "pdu.current value=3 1356998400123000000",
+		},
+		{line: "version"},
+		{line: "put sys.cpu.user 1356998400", wantErr: true},
+		{line: "put sys.cpu.user 135699840 42.5", wantErr: true},
+		{line: "put sys.cpu.user abcdefghij 42.5", wantErr: true},
+		{line: "put sys.cpu.user 1356998400 abc", wantErr: true},
+		{line: "put sys.cpu.user 1356998400 42.5 host", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			pt, err := opentsdb.ParseTelnet(tt.line, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := ""
+			if pt != nil {
+				got = pt.String()
+			}
+			if got != tt.want {
+				t.Errorf("unexpected point:\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// pointsWriter collects the points written.
+type pointsWriter struct {
+	mu      sync.Mutex
+	points  []string
+	written chan struct{}
+}
+
+func (w *pointsWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	w.mu.Lock()
+	for _, p := range points {
+		w.points = append(w.points, p.String())
+	}
+	w.mu.Unlock()
+	w.written <- struct{}{}
+	return nil
+}
+
+func (w *pointsWriter) wait(t *testing.T, n int) []string {
+	t.Helper()
+	for {
+		w.mu.Lock()
+		points := append([]string(nil), w.points...)
+		w.mu.Unlock()
+		if len(points) >= n {
+			sort.Strings(points)
+			return points
+		}
+		select {
+		case <-w.written:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %d points, got %d", n, len(points))
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	const (
+		orgID    = platform.ID(0x1111)
+		bucketID = platform.ID(0x2222)
+	)
+	p, err := influxdb.NewPermissionAtID(bucketID, influxdb.WriteAction, influxdb.BucketsResourceType, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auths := mock.NewAuthorizationService()
+	auths.FindAuthorizationByTokenFn = func(ctx context.Context, token string) (*influxdb.Authorization, error) {
+		return &influxdb.Authorization{Status: influxdb.Active, OrgID: orgID, Permissions: []influxdb.Permission{*p}}, nil
+	}
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return &influxdb.Organization{ID: orgID, Name: *filter.Name}, nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return &influxdb.Bucket{ID: bucketID, OrgID: orgID, Name: *filter.Name}, nil
+	}
+
+	c := opentsdb.Config{BindAddress: "127.0.0.1:0", Org: "my-org", TokenEnv: "OPENTSDB_TOKEN"}.WithDefaults()
+	lc, err := c.ListenerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc.BatchTimeout = 20 * time.Millisecond
+
+	w := &pointsWriter{written: make(chan struct{}, 100)}
+	s := listener.NewService(zaptest.NewLogger(t), nil, w, auths, orgs, buckets)
+	s.Add(lc, opentsdb.Protocol(c))
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	addr := s.Listeners()[0].Addr().String()
+
+	// Telnet
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := fmt.Fprint(conn, "version\nput pdu.current 1356998400 3 outlet=a1\nput invalid\n"); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := bufio.NewReader(conn).ReadString('\n'); err != nil || line != "InfluxDB TSDB proxy\n" {
+		t.Fatalf("unexpected version response %q: %v", line, err)
+	}
+	got := w.wait(t, 1)
+	if want := "pdu.current,outlet=a1 value=3 1356998400000000000"; got[0] != want {
+		t.Errorf("unexpected point:\n got %s\nwant %s", got[0], want)
+	}
+
+	// HTTP
+	resp, err := http.Post("http://"+addr+"/api/put", "application/json", bytes.NewBufferString(`[
+		{"metric": "sys.cpu.nice", "timestamp": 1346846400, "value": 18, "tags": {"host": "web01"}},
+		{"metric": "sys.cpu.nice", "timestamp": 1346846400000, "value": 9, "tags": {"host": "web02"}}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	fmt.Fprint(zw, `{"metric": "sys.cpu.nice", "timestamp": 1346846401, "value": 7, "tags": {"host": "web03"}}`)
+	zw.Close()
+	req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/api/put", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post("http://"+addr+"/api/put", "application/json", bytes.NewBufferString(`{"metric":`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d for invalid JSON", resp.StatusCode)
+	}
+
+	got = w.wait(t, 4)
+	want := []string{
+		"pdu.current,outlet=a1 value=3 1356998400000000000",
+		"sys.cpu.nice,host=web01 value=18 1346846400000000000",
+		"sys.cpu.nice,host=web02 value=9 1346846400000000000",
+		"sys.cpu.nice,host=web03 value=7 1346846401000000000",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("unexpected points:\n got %v\nwant %v", got, want)
+	}
+}
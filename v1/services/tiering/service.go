@@ -0,0 +1,153 @@
+// Package tiering provides the service moving shards to the cold tier.
+package tiering
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"go.uber.org/zap"
+)
+
+// Service represents the service moving the shards of retention policies
+// with a cold duration to the cold tier.
+type Service struct {
+	MetaClient interface {
+		Databases() []meta.DatabaseInfo
+		SetShardTier(id uint64, tier string) error
+	}
+	TSDBStore interface {
+		ShardIDs() []uint64
+		MoveShardToColdTier(id uint64) error
+	}
+
+	config Config
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+
+	logger *zap.Logger
+}
+
+// NewService returns a configured tiering service.
+func NewService(c Config) *Service {
+	return &Service{
+		config: c,
+		logger: zap.NewNop(),
+	}
+}
+
+// Open starts moving shards to the cold tier.
+func (s *Service) Open(ctx context.Context) error {
+	if !s.config.Enabled || s.cancel != nil {
+		return nil
+	}
+
+	s.logger.Info("Starting tiering service",
+		logger.DurationLiteral("check_interval", time.Duration(s.config.CheckInterval)))
+
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(ctx)
+	}()
+	return nil
+}
+
+// Close stops moving shards to the cold tier.
+func (s *Service) Close() error {
+	if !s.config.Enabled || s.cancel == nil {
+		return nil
+	}
+
+	s.logger.Info("Closing tiering service")
+	s.cancel()
+
+	s.wg.Wait()
+
+	s.cancel = nil
+
+	return nil
+}
+
+// WithLogger sets the logger on the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.logger = log.With(zap.String("service", "tiering"))
+}
+
+func (s *Service) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.config.CheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			s.MoveColdShards(ctx)
+		}
+	}
+}
+
+// MoveColdShards moves the local shards of the shard groups that reached the
+// cold duration of their retention policy to the cold tier, and records
+// their new tier in the meta store.
+func (s *Service) MoveColdShards(ctx context.Context) {
+	log, logEnd := logger.NewOperation(ctx, s.logger, "Cold tier check", "tiering_cold_check")
+	defer logEnd()
+
+	local := make(map[uint64]struct{})
+	for _, id := range s.TSDBStore.ShardIDs() {
+		local[id] = struct{}{}
+	}
+
+	// Mark down if an error occurred so we can inform the user that we will
+	// try again on the next interval.
+	var retryNeeded bool
+	now := time.Now().UTC()
+	for _, d := range s.MetaClient.Databases() {
+		for _, r := range d.RetentionPolicies {
+			for _, g := range r.ColdShardGroups(now) {
+				for _, sh := range g.Shards {
+					if _, ok := local[sh.ID]; !ok || sh.Cold() {
+						continue
+					}
+					if ctx.Err() != nil {
+						return
+					}
+
+					if err := s.TSDBStore.MoveShardToColdTier(sh.ID); err != nil {
+						log.Info("Failed to move shard to the cold tier",
+							logger.Database(d.Name),
+							logger.Shard(sh.ID),
+							logger.RetentionPolicy(r.Name),
+							zap.Error(err))
+						retryNeeded = true
+						continue
+					}
+					if err := s.MetaClient.SetShardTier(sh.ID, meta.ShardTierCold); err != nil {
+						log.Info("Failed to update shard tier",
+							logger.Database(d.Name),
+							logger.Shard(sh.ID),
+							logger.RetentionPolicy(r.Name),
+							zap.Error(err))
+						retryNeeded = true
+						continue
+					}
+
+					log.Info("Moved shard to the cold tier",
+						logger.Database(d.Name),
+						logger.Shard(sh.ID),
+						logger.RetentionPolicy(r.Name))
+				}
+			}
+		}
+	}
+
+	if retryNeeded {
+		log.Info("One or more errors occurred while moving shards and will be retried on the next check", logger.DurationLiteral("check_interval", time.Duration(s.config.CheckInterval)))
+	}
+}
@@ -0,0 +1,181 @@
+package tiering_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/internal"
+	"github.com/influxdata/influxdb/v2/toml"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/influxdata/influxdb/v2/v1/services/tiering"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestService_OpenDisabled(t *testing.T) {
+	// Opening a disabled service should be a no-op.
+	c := tiering.NewConfig()
+	c.Enabled = false
+	s := NewService(t, c)
+
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.LogBuf.Len() > 0 {
+		t.Fatalf("service logged %q, didn't expect any logging", s.LogBuf.All())
+	}
+}
+
+func TestService_OpenClose(t *testing.T) {
+	s := NewService(t, tiering.NewConfig())
+
+	ctx := context.Background()
+	if err := s.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.LogBuf.Len() == 0 {
+		t.Fatal("service didn't log anything on open")
+	}
+
+	// Reopening is a no-op
+	if err := s.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-closing is a no-op
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_MoveColdShards(t *testing.T) {
+	end := time.Now().UTC().Truncate(time.Hour).Add(-3 * time.Hour)
+	data := []meta.DatabaseInfo{
+		{
+			Name:                   "db0",
+			DefaultRetentionPolicy: "rp0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{
+					Name:               "rp0",
+					ShardGroupDuration: time.Hour,
+					ColdDuration:       time.Hour,
+					ShardGroups: []meta.ShardGroupInfo{
+						{
+							// Already on the cold tier.
+							ID:        1,
+							StartTime: end.Add(-2 * time.Hour),
+							EndTime:   end.Add(-time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 2, Tier: meta.ShardTierCold}},
+						},
+						{
+							// Reached the cold duration, shard 5 isn't local.
+							ID:        3,
+							StartTime: end.Add(-time.Hour),
+							EndTime:   end,
+							Shards:    []meta.ShardInfo{{ID: 4}, {ID: 5}},
+						},
+						{
+							// Still on the primary tier.
+							ID:        6,
+							StartTime: end.Add(2 * time.Hour),
+							EndTime:   end.Add(3 * time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 7}},
+						},
+					},
+				},
+				{
+					// No cold duration.
+					Name:               "rp1",
+					ShardGroupDuration: time.Hour,
+					ShardGroups: []meta.ShardGroupInfo{
+						{
+							ID:        8,
+							StartTime: end.Add(-time.Hour),
+							EndTime:   end,
+							Shards:    []meta.ShardInfo{{ID: 9}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	config := tiering.NewConfig()
+	config.CheckInterval = toml.Duration(10 * time.Millisecond)
+	s := NewService(t, config)
+	s.MetaClient.DatabasesFn = func() []meta.DatabaseInfo {
+		return data
+	}
+
+	tiers := make(map[uint64]string)
+	s.MetaClient.SetShardTierFn = func(id uint64, tier string) error {
+		tiers[id] = tier
+		return nil
+	}
+
+	moved := make(map[uint64]struct{})
+	s.TSDBStore.ShardIDsFn = func() []uint64 {
+		return []uint64{2, 4, 7, 9}
+	}
+	moveErr := errors.New("disk full")
+	s.TSDBStore.MoveShardToColdTierFn = func(id uint64) error {
+		if moveErr != nil {
+			return moveErr
+		}
+		moved[id] = struct{}{}
+		return nil
+	}
+
+	// A shard that failed to move is not recorded as cold.
+	s.MoveColdShards(context.Background())
+	if len(tiers) != 0 {
+		t.Fatalf("unexpected shard tiers: %v", tiers)
+	}
+
+	moveErr = nil
+	s.MoveColdShards(context.Background())
+	if got, want := moved, map[uint64]struct{}{4: {}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected moved shards: got=%#v want=%#v", got, want)
+	}
+	if got, want := tiers, map[uint64]string{4: meta.ShardTierCold}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected shard tiers: got=%#v want=%#v", got, want)
+	}
+}
+
+type Service struct {
+	MetaClient *internal.MetaClientMock
+	TSDBStore  *internal.TSDBStoreMock
+
+	LogBuf *observer.ObservedLogs
+	*tiering.Service
+}
+
+func NewService(tb testing.TB, c tiering.Config) *Service {
+	tb.Helper()
+
+	s := &Service{
+		MetaClient: &internal.MetaClientMock{},
+		TSDBStore:  &internal.TSDBStoreMock{},
+		Service:    tiering.NewService(c),
+	}
+
+	logcore, logbuf := observer.New(zapcore.InfoLevel)
+	log := zap.New(logcore)
+
+	s.LogBuf = logbuf
+	s.WithLogger(log)
+
+	s.Service.MetaClient = s.MetaClient
+	s.Service.TSDBStore = s.TSDBStore
+	return s
+}